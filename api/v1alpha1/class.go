@@ -0,0 +1,25 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// MatchesClass returns true if an object of the class objClassName is
+// reconciled by a controller of the class className. Objects without a class
+// are only reconciled by controllers without a class, unless watchClassless
+// is set.
+func MatchesClass(objClassName, className string, watchClassless bool) bool {
+	return objClassName == className || (watchClassless && objClassName == "")
+}
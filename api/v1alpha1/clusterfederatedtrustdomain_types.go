@@ -34,9 +34,23 @@ type ClusterFederatedTrustDomainSpec struct {
 	BundleEndpointProfile BundleEndpointProfile `json:"bundleEndpointProfile"`
 
 	// TrustDomainBundle is the contents of the bundle for the referenced trust
-	// domain. This field is optional when the resource is created.
+	// domain, in SPIFFE bundle (JWKS) format. Both the X.509 and the JWT
+	// authorities of the bundle are passed to SPIRE Server. This field is
+	// optional when the resource is created.
 	// +kubebuilder:validation:Optional
 	TrustDomainBundle string `json:"trustDomainBundle,omitempty"`
+
+	// ClassName is the class of the controller manager instance that
+	// reconciles this ClusterFederatedTrustDomain. If unset, it is reconciled by the
+	// instances without a class, or that watch classless objects.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// SPIREServer is the name of the SPIRE Server target, as configured in
+	// the controller manager configuration, that the federation relationship
+	// is set on. If unset, it is set on the default SPIRE Server.
+	// +optional
+	SPIREServer string `json:"spireServer,omitempty"`
 }
 
 // BundleEndpointProfile is the profile for the federated trust domain
@@ -63,14 +77,59 @@ const (
 
 // ClusterFederatedTrustDomainStatus defines the observed state of ClusterFederatedTrustDomain
 type ClusterFederatedTrustDomainStatus struct {
+	// LastSyncedBundleSerial is the sequence number of the bundle last
+	// fetched from the bundle endpoint, if the bundle has one.
+	// +optional
+	LastSyncedBundleSerial *int64 `json:"lastSyncedBundleSerial,omitempty"`
+
+	// LastError is the error the last probe of the bundle endpoint failed
+	// with, if it failed.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// Conditions describe the outcome of the last probe of the bundle
+	// endpoint. The Reachable condition is true when the bundle of the trust
+	// domain was fetched from the bundle endpoint.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// Condition types for ClusterFederatedTrustDomains.
+const (
+	ClusterFederatedTrustDomainConditionReachable = "Reachable"
+)
+
+// Condition reasons for ClusterFederatedTrustDomains.
+const (
+	// ClusterFederatedTrustDomainReasonBundleFetched means the bundle was
+	// fetched from the bundle endpoint.
+	ClusterFederatedTrustDomainReasonBundleFetched = "BundleFetched"
+
+	// ClusterFederatedTrustDomainReasonInvalidSpec means the spec could not
+	// be parsed.
+	ClusterFederatedTrustDomainReasonInvalidSpec = "InvalidSpec"
+
+	// ClusterFederatedTrustDomainReasonAuthBundleUnavailable means the
+	// bundle used to authenticate an https_spiffe bundle endpoint could not
+	// be obtained from SPIRE Server.
+	ClusterFederatedTrustDomainReasonAuthBundleUnavailable = "AuthBundleUnavailable"
+
+	// ClusterFederatedTrustDomainReasonFetchFailed means the bundle could not
+	// be fetched from the bundle endpoint, e.g. because it is unreachable,
+	// could not be authenticated, or served an invalid bundle.
+	ClusterFederatedTrustDomainReasonFetchFailed = "FetchFailed"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:scope=Cluster
+//+kubebuilder:storageversion
 
 // +kubebuilder:printcolumn:name="Trust Domain",type=string,JSONPath=`.spec.trustDomain`
 // +kubebuilder:printcolumn:name="Endpoint URL",type=string,JSONPath=`.spec.bundleEndpointURL`
+// +kubebuilder:printcolumn:name="Reachable",type=string,JSONPath=`.status.conditions[?(@.type=="Reachable")].status`
 // ClusterFederatedTrustDomain is the Schema for the clusterfederatedtrustdomains API
 type ClusterFederatedTrustDomain struct {
 	metav1.TypeMeta   `json:",inline"`
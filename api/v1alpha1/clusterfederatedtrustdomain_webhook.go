@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -99,7 +100,13 @@ func ParseClusterFederatedTrustDomainSpec(spec *ClusterFederatedTrustDomainSpec)
 	}
 
 	var trustDomainBundle *spiffebundle.Bundle
-	if spec.TrustDomainBundle != "" {
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(spec.TrustDomainBundle), "-----BEGIN"):
+		// PEM bundles cannot carry the JWT authorities needed to validate
+		// JWT-SVIDs from the trust domain. Point users at the right format
+		// instead of failing to parse the PEM as JSON.
+		return nil, errors.New("invalid trustDomainBundle value: must be in SPIFFE bundle (JWKS) format, not PEM")
+	case spec.TrustDomainBundle != "":
 		trustDomainBundle, err = spiffebundle.Read(trustDomain, strings.NewReader(spec.TrustDomainBundle))
 		if err != nil {
 			return nil, fmt.Errorf("invalid trustDomainBundle value: %w", err)
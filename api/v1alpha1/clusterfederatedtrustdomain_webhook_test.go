@@ -0,0 +1,93 @@
+package v1alpha1
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClusterFederatedTrustDomainSpecBundle(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString("partner.test")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+
+	bundle := spiffebundle.New(td)
+	bundle.AddX509Authority(cert)
+	require.NoError(t, bundle.AddJWTAuthority("KEYID", key.Public()))
+	bundleJSON, err := bundle.Marshal()
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		desc            string
+		bundle          string
+		expectErr       string
+		expectX509      []*x509.Certificate
+		expectJWTKeyIDs []string
+	}{
+		{
+			desc: "no bundle",
+		},
+		{
+			desc:            "SPIFFE bundle with X.509 and JWT authorities",
+			bundle:          string(bundleJSON),
+			expectX509:      []*x509.Certificate{cert},
+			expectJWTKeyIDs: []string{"KEYID"},
+		},
+		{
+			desc:      "PEM bundle",
+			bundle:    "\n" + string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})),
+			expectErr: "invalid trustDomainBundle value: must be in SPIFFE bundle (JWKS) format, not PEM",
+		},
+		{
+			desc:      "malformed bundle",
+			bundle:    "{",
+			expectErr: "invalid trustDomainBundle value: spiffebundle: unable to parse JWKS: unexpected end of JSON input",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			federationRelationship, err := ParseClusterFederatedTrustDomainSpec(&ClusterFederatedTrustDomainSpec{
+				TrustDomain:           td.Name(),
+				BundleEndpointURL:     "https://partner.test/bundle",
+				BundleEndpointProfile: BundleEndpointProfile{Type: HTTPSWebProfileType},
+				TrustDomainBundle:     tt.bundle,
+			})
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.bundle == "" {
+				require.Nil(t, federationRelationship.TrustDomainBundle)
+				return
+			}
+			require.Equal(t, tt.expectX509, federationRelationship.TrustDomainBundle.X509Authorities())
+			var jwtKeyIDs []string
+			for keyID, publicKey := range federationRelationship.TrustDomainBundle.JWTAuthorities() {
+				require.True(t, publicKey.(interface{ Equal(crypto.PublicKey) bool }).Equal(key.Public()))
+				jwtKeyIDs = append(jwtKeyIDs, keyID)
+			}
+			require.Equal(t, tt.expectJWTKeyIDs, jwtKeyIDs)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterNodeAliasSpec defines the desired state of ClusterNodeAlias
+type ClusterNodeAliasSpec struct {
+	// SPIFFEID is the SPIFFE ID of the node alias. Entries whose parent ID is
+	// the SPIFFE ID are available to the agents of all of the aliased nodes.
+	SPIFFEID string `json:"spiffeID"`
+
+	// NodeLabels selects the nodes whose agents are aliased by the labels
+	// of the node. Each label is matched with a
+	// k8s_psat:agent_node_label selector. If empty, the agents of all of
+	// the nodes of the cluster are aliased.
+	// +optional
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+
+	// Selectors are additional node selectors the agents must have to be
+	// aliased, e.g. k8s_psat:agent_sa:spire-agent.
+	// +optional
+	Selectors []string `json:"selectors,omitempty"`
+
+	// ClassName is the class of the controller manager instance that
+	// reconciles this ClusterNodeAlias. If unset, it is reconciled by the
+	// instances without a class, or that watch classless objects.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// SPIREServer is the name of the SPIRE Server target, as configured in
+	// the controller manager configuration, that the entry is registered
+	// with. If unset, the entry is registered with the default SPIRE Server.
+	// +optional
+	SPIREServer string `json:"spireServer,omitempty"`
+}
+
+// ClusterNodeAliasStatus defines the observed state of ClusterNodeAlias
+type ClusterNodeAliasStatus struct {
+	// How many nodes have the node labels.
+	NodesSelected int `json:"nodesSelected"`
+
+	// If the node alias entry rendered properly.
+	Rendered bool `json:"rendered"`
+
+	// If the node alias entry was masked by another entry.
+	Masked bool `json:"masked"`
+
+	// MaskedBy names the objects, as "Kind/name", that declared the entries
+	// masking the entries of this ClusterNodeAlias. At most 10 objects are named.
+	// +optional
+	MaskedBy []string `json:"maskedBy,omitempty"`
+
+	// Masking names the objects, as "Kind/name", whose entries are masked by
+	// the entries of this ClusterNodeAlias. At most 10 objects are named.
+	// +optional
+	Masking []string `json:"masking,omitempty"`
+
+	// If the node alias entry was successfully created/updated.
+	Set bool `json:"set"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="SPIFFE ID",type=string,JSONPath=`.spec.spiffeID`
+//+kubebuilder:printcolumn:name="Nodes",type=integer,JSONPath=`.status.nodesSelected`
+//+kubebuilder:printcolumn:name="Set",type=boolean,JSONPath=`.status.set`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterNodeAlias is the Schema for the clusternodealiases API. It
+// registers a node alias entry for the agents of a group of nodes, so that
+// other entries can use the alias as their parent to target all of them.
+type ClusterNodeAlias struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterNodeAliasSpec   `json:"spec,omitempty"`
+	Status ClusterNodeAliasStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterNodeAliasList contains a list of ClusterNodeAlias
+type ClusterNodeAliasList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterNodeAlias `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterNodeAlias{}, &ClusterNodeAliasList{})
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var clusternodealiaslog = logf.Log.WithName("clusternodealias-resource")
+
+func (r *ClusterNodeAlias) SetupWebhookWithManager(mgr ctrl.Manager, options WebhookOptions) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithValidator(&clusterNodeAliasValidator{
+			allowedPathPrefixes: options.AllowedPathPrefixes,
+			className:           options.ClassName,
+			watchClassless:      options.WatchClassless,
+			spireServers:        options.SPIREServers,
+		}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-spire-spiffe-io-v1alpha1-clusternodealias,mutating=false,failurePolicy=fail,sideEffects=None,groups=spire.spiffe.io,resources=clusternodealiases,verbs=create;update,versions=v1alpha1,name=vclusternodealias.kb.io,admissionReviewVersions=v1
+
+type clusterNodeAliasValidator struct {
+	allowedPathPrefixes []string
+	className           string
+	watchClassless      bool
+	spireServers        []string
+}
+
+var _ webhook.CustomValidator = &clusterNodeAliasValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *clusterNodeAliasValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	r, ok := obj.(*ClusterNodeAlias)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterNodeAlias but got %T", obj)
+	}
+	clusternodealiaslog.Info("validate create", "name", r.Name)
+	return nil, v.validate(r)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *clusterNodeAliasValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	r, ok := newObj.(*ClusterNodeAlias)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterNodeAlias but got %T", newObj)
+	}
+	clusternodealiaslog.Info("validate update", "name", r.Name)
+	return nil, v.validate(r)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *clusterNodeAliasValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	// Deletes are not validated.
+	return nil, nil
+}
+
+func (v *clusterNodeAliasValidator) validate(r *ClusterNodeAlias) error {
+	spec, err := ParseClusterNodeAliasSpec(&r.Spec)
+	if err != nil {
+		return err
+	}
+	if !MatchesClass(r.Spec.ClassName, v.className, v.watchClassless) {
+		return nil
+	}
+	if err := CheckPathAllowed(spec.SPIFFEID.Path(), v.allowedPathPrefixes); err != nil {
+		return fmt.Errorf("invalid SPIFFEID: %w", err)
+	}
+	if err := CheckSPIREServer(r.Spec.SPIREServer, v.spireServers); err != nil {
+		return fmt.Errorf("invalid spireServer: %w", err)
+	}
+	return nil
+}
+
+// +kubebuilder:object:generate=false
+// ParsedClusterNodeAliasSpec is a parsed and validated ClusterNodeAliasSpec
+type ParsedClusterNodeAliasSpec struct {
+	SPIFFEID spiffeid.ID
+
+	// NodeSelector selects the nodes that have the node labels.
+	NodeSelector labels.Selector
+
+	// Selectors are the node selectors of the node alias, other than the
+	// k8s_psat:cluster selector, which depends on the cluster.
+	Selectors []spireapi.Selector
+}
+
+// ParseClusterNodeAliasSpec parses and validates the fields in the
+// ClusterNodeAliasSpec.
+func ParseClusterNodeAliasSpec(spec *ClusterNodeAliasSpec) (*ParsedClusterNodeAliasSpec, error) {
+	spiffeID, err := spiffeid.FromString(spec.SPIFFEID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SPIFFEID: %w", err)
+	}
+	nodeSelector, err := labels.ValidatedSelectorFromSet(spec.NodeLabels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nodeLabels value: %w", err)
+	}
+	extraSelectors, err := spireapi.ParseSelectors(spec.Selectors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Selectors: %w", err)
+	}
+
+	// Node labels are added in key order so the rendered selectors are
+	// stable.
+	keys := make([]string, 0, len(spec.NodeLabels))
+	for key := range spec.NodeLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	selectors := make([]spireapi.Selector, 0, len(keys)+len(extraSelectors))
+	for _, key := range keys {
+		selectors = append(selectors, spireapi.Selector{
+			Type:  "k8s_psat",
+			Value: fmt.Sprintf("agent_node_label:%s:%s", key, spec.NodeLabels[key]),
+		})
+	}
+	selectors = append(selectors, extraSelectors...)
+
+	return &ParsedClusterNodeAliasSpec{
+		SPIFFEID:     spiffeID,
+		NodeSelector: nodeSelector,
+		Selectors:    selectors,
+	}, nil
+}
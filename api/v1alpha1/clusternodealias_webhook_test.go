@@ -0,0 +1,72 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClusterNodeAliasSpec(t *testing.T) {
+	for _, tt := range []struct {
+		desc            string
+		spec            ClusterNodeAliasSpec
+		expectErr       string
+		expectSelectors []spireapi.Selector
+	}{
+		{
+			desc: "node labels and selectors",
+			spec: ClusterNodeAliasSpec{
+				SPIFFEID:   "spiffe://domain.test/nodes/gpu",
+				NodeLabels: map[string]string{"pool": "gpu", "accelerator": "a100"},
+				Selectors:  []string{"k8s_psat:agent_sa:spire-agent"},
+			},
+			expectSelectors: []spireapi.Selector{
+				{Type: "k8s_psat", Value: "agent_node_label:accelerator:a100"},
+				{Type: "k8s_psat", Value: "agent_node_label:pool:gpu"},
+				{Type: "k8s_psat", Value: "agent_sa:spire-agent"},
+			},
+		},
+		{
+			desc: "all nodes",
+			spec: ClusterNodeAliasSpec{
+				SPIFFEID: "spiffe://domain.test/nodes/all",
+			},
+			expectSelectors: []spireapi.Selector{},
+		},
+		{
+			desc: "invalid SPIFFE ID",
+			spec: ClusterNodeAliasSpec{
+				SPIFFEID: "nodes/gpu",
+			},
+			expectErr: "failed to parse SPIFFEID: scheme is missing or invalid",
+		},
+		{
+			desc: "invalid node label",
+			spec: ClusterNodeAliasSpec{
+				SPIFFEID:   "spiffe://domain.test/nodes/gpu",
+				NodeLabels: map[string]string{"pool": "not valid"},
+			},
+			expectErr: "invalid nodeLabels value:",
+		},
+		{
+			desc: "invalid selector",
+			spec: ClusterNodeAliasSpec{
+				SPIFFEID:  "spiffe://domain.test/nodes/gpu",
+				Selectors: []string{"k8s_psat"},
+			},
+			expectErr: "failed to parse Selectors: expected at least one colon separate the type from the value",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			spec, err := ParseClusterNodeAliasSpec(&tt.spec)
+			if tt.expectErr != "" {
+				require.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.spec.SPIFFEID, spec.SPIFFEID.String())
+			require.Equal(t, tt.expectSelectors, spec.Selectors)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Hub marks v1alpha1 as the version ClusterSPIFFEIDs are converted through,
+// and stored as. Every other version converts to and from it.
+func (*ClusterSPIFFEID) Hub() {}
+
+// Validate returns an error if the defaults are not valid ClusterSPIFFEID
+// field values.
+func (c *ClusterSPIFFEIDDefaultsConfig) Validate() error {
+	switch {
+	case c.X509SVIDTTL.Duration < 0:
+		return errors.New("invalid x509SVIDTTL value: must not be negative")
+	case c.JWTSVIDTTL.Duration < 0:
+		return errors.New("invalid jwtSVIDTTL value: must not be negative")
+	}
+	for _, value := range c.DNSNameTemplates {
+		if _, err := newEntryTemplate(dnsNameTemplateName).Parse(value); err != nil {
+			return fmt.Errorf("invalid dnsNameTemplates value: %w", err)
+		}
+	}
+	return nil
+}
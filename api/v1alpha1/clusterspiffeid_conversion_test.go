@@ -0,0 +1,26 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterSPIFFEIDDefaultsConfigValidate(t *testing.T) {
+	require.NoError(t, (&ClusterSPIFFEIDDefaultsConfig{
+		X509SVIDTTL:      metav1.Duration{Duration: time.Hour},
+		JWTSVIDTTL:       metav1.Duration{Duration: time.Minute},
+		DNSNameTemplates: []string{"{{ .PodMeta.Name }}.test"},
+	}).Validate())
+	require.EqualError(t, (&ClusterSPIFFEIDDefaultsConfig{
+		X509SVIDTTL: metav1.Duration{Duration: -time.Hour},
+	}).Validate(), "invalid x509SVIDTTL value: must not be negative")
+	require.EqualError(t, (&ClusterSPIFFEIDDefaultsConfig{
+		JWTSVIDTTL: metav1.Duration{Duration: -time.Minute},
+	}).Validate(), "invalid jwtSVIDTTL value: must not be negative")
+	require.ErrorContains(t, (&ClusterSPIFFEIDDefaultsConfig{
+		DNSNameTemplates: []string{"{{"},
+	}).Validate(), "invalid dnsNameTemplates value")
+}
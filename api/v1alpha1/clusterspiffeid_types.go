@@ -36,12 +36,30 @@ type ClusterSPIFFEIDSpec struct {
 	// ClusterSPIFFEID. If unset, a default will be chosen.
 	TTL metav1.Duration `json:"ttl,omitempty"`
 
+	// X509SVIDTTL indicates an upper-bound time-to-live for X509-SVIDs
+	// minted for this ClusterSPIFFEID. It is an alias of TTL; only one of
+	// the two may be set. If unset, a default will be chosen.
+	X509SVIDTTL metav1.Duration `json:"x509SVIDTTL,omitempty"`
+
+	// JWTSVIDTTL indicates an upper-bound time-to-live for JWT-SVIDs minted
+	// for this ClusterSPIFFEID. If unset, a default will be chosen. Requires
+	// SPIRE Server 1.5.0 or later.
+	JWTSVIDTTL metav1.Duration `json:"jwtSVIDTTL,omitempty"`
+
 	// DNSNameTemplate represents templates for extra DNS names that are
 	// applicable to SVIDs minted for this ClusterSPIFFEID.
 	// The node and pod spec are made available to the template under
 	// .NodeSpec, .PodSpec respectively.
 	DNSNameTemplates []string `json:"dnsNameTemplates,omitempty"`
 
+	// AutoPopulateDNSNames, if true, adds the DNS names of the Services that
+	// select the pod to the DNS names of the SVIDs minted for this
+	// ClusterSPIFFEID, i.e. <service>.<namespace>.svc.<cluster domain> and,
+	// for headless Services that are the subdomain of the pod,
+	// <hostname>.<service>.<namespace>.svc.<cluster domain>.
+	// +optional
+	AutoPopulateDNSNames bool `json:"autoPopulateDNSNames,omitempty"`
+
 	// WorkloadSelectorTemplates are templates to produce arbitrary workload
 	// selectors that apply to a given workload before it will receive this
 	// SPIFFE ID. The rendered value is interpreted by SPIRE and are of the
@@ -51,10 +69,21 @@ type ClusterSPIFFEIDSpec struct {
 	// .NodeSpec, .PodSpec respectively.
 	WorkloadSelectorTemplates []string `json:"workloadSelectorTemplates,omitempty"`
 
+	// HintTemplate is a template for the hint provided to workloads to
+	// help them choose between multiple SVIDs. The node and pod spec are
+	// made available to the template under .NodeSpec, .PodSpec
+	// respectively. Requires SPIRE Server 1.6.3 or later.
+	HintTemplate string `json:"hintTemplate,omitempty"`
+
 	// FederatesWith is a list of trust domain names that workloads that
 	// obtain this SPIFFE ID will federate with.
 	FederatesWith []string `json:"federatesWith,omitempty"`
 
+	// FederatesWithSets is a list of ClusterTrustDomainSet names. Workloads
+	// that obtain this SPIFFE ID will also federate with the trust domains
+	// in each set.
+	FederatesWithSets []string `json:"federatesWithSets,omitempty"`
+
 	// NamespaceSelector selects the namespaces that are targeted by this
 	// CRD.
 	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
@@ -63,6 +92,55 @@ type ClusterSPIFFEIDSpec struct {
 	// CRD.
 	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
 
+	// PodExcludeSelector, if set, excludes the pods whose labels match
+	// from those targeted by this CRD.
+	PodExcludeSelector *metav1.LabelSelector `json:"podExcludeSelector,omitempty"`
+
+	// ServiceAccountNames selects the pods that are targeted by this CRD by
+	// the name of the service account the pod runs as. Each value is either
+	// a service account name or a shell file name pattern, e.g. "frontend-*".
+	// If empty, pods running as any service account are targeted.
+	ServiceAccountNames []string `json:"serviceAccountNames,omitempty"`
+
+	// StaticPods determines whether static pods, i.e., pods managed directly
+	// by the kubelet and represented in the API server by a mirror pod, are
+	// targeted by this CRD. Entries for static pods select on the UID
+	// of the static pod known to the kubelet, not the UID of the mirror
+	// pod. Defaults to Include.
+	StaticPods PodInclusionPolicy `json:"staticPods,omitempty"`
+
+	// HostNetworkPods determines whether pods that use the host network
+	// namespace are targeted by this CRD. Defaults to Include.
+	HostNetworkPods PodInclusionPolicy `json:"hostNetworkPods,omitempty"`
+
+	// JobPods determines whether pods created by Jobs, including those of
+	// CronJobs, are targeted by this CRD. Excluding them avoids the entry
+	// churn of registering every short-lived batch pod. Defaults to Include.
+	JobPods PodInclusionPolicy `json:"jobPods,omitempty"`
+
+	// RegistrationMode determines whether an entry is registered for each
+	// pod targeted by this CRD, or for each of their service accounts. In
+	// the ServiceAccount mode, the pods running as the same service account
+	// in a namespace share a single entry, which selects the namespace and
+	// service account of the pods instead of the pod, and is parented by the
+	// ClusterNodeAlias named by NodeAlias. Defaults to Pod.
+	// +optional
+	RegistrationMode RegistrationMode `json:"registrationMode,omitempty"`
+
+	// NodeAlias is the name of the ClusterNodeAlias that parents the entries
+	// in the ServiceAccount registration mode. The entries are available to
+	// the agents of all of the nodes aliased. Required in the ServiceAccount
+	// registration mode.
+	// +optional
+	NodeAlias string `json:"nodeAlias,omitempty"`
+
+	// Fallback, if true, makes this ClusterSPIFFEID the default identity of
+	// the pods it selects that are not selected by any other ClusterSPIFFEID
+	// or NamespacedSPIFFEID. Its entries never mask the entries declared by
+	// other objects.
+	// +optional
+	Fallback bool `json:"fallback,omitempty"`
+
 	// Admin indicates whether or not the SVID can be used to access the SPIRE
 	// administrative APIs. Extra care should be taken to only apply this
 	// SPIFFE ID to admin workloads.
@@ -70,8 +148,44 @@ type ClusterSPIFFEIDSpec struct {
 
 	// Downstream indicates that the entry describes a downstream SPIRE server.
 	Downstream bool `json:"downstream,omitempty"`
+
+	// ClassName is the class of the controller manager instance that
+	// reconciles this ClusterSPIFFEID. If unset, it is reconciled by the
+	// instances without a class, or that watch classless objects.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// SPIREServer is the name of the SPIRE Server target, as configured in
+	// the controller manager configuration, that the entries are registered
+	// with. If unset, the entries are registered with the default SPIRE
+	// Server.
+	// +optional
+	SPIREServer string `json:"spireServer,omitempty"`
 }
 
+// +kubebuilder:validation:Enum=Include;Exclude
+type PodInclusionPolicy string
+
+const (
+	// PodInclusionPolicyInclude targets the pods.
+	PodInclusionPolicyInclude PodInclusionPolicy = "Include"
+
+	// PodInclusionPolicyExclude does not target the pods.
+	PodInclusionPolicyExclude PodInclusionPolicy = "Exclude"
+)
+
+// +kubebuilder:validation:Enum=Pod;ServiceAccount
+type RegistrationMode string
+
+const (
+	// RegistrationModePod registers an entry for each pod.
+	RegistrationModePod RegistrationMode = "Pod"
+
+	// RegistrationModeServiceAccount registers an entry for each service
+	// account of the pods.
+	RegistrationModeServiceAccount RegistrationMode = "ServiceAccount"
+)
+
 // ClusterSPIFFEIDStatus defines the observed state of ClusterSPIFFEID
 type ClusterSPIFFEIDStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
@@ -80,8 +194,74 @@ type ClusterSPIFFEIDStatus struct {
 	// Stats produced by the last entry reconciliation run
 	// +kubebuilder:validation:Optional
 	Stats ClusterSPIFFEIDStats `json:"stats"`
+
+	// MaskedBy names the objects, as "Kind/name", that declared the entries
+	// masking the entries of this ClusterSPIFFEID. At most 10 objects are named.
+	// +optional
+	MaskedBy []string `json:"maskedBy,omitempty"`
+
+	// Masking names the objects, as "Kind/name", whose entries are masked by
+	// the entries of this ClusterSPIFFEID. At most 10 objects are named.
+	// +optional
+	Masking []string `json:"masking,omitempty"`
+
+	// Conditions describe the outcome of the last entry reconciliation run.
+	// The Ready condition is true when entries were rendered and set for all
+	// selected pods. The Stalled condition is true when entries cannot be
+	// produced without the ClusterSPIFFEID being changed. The Federated
+	// condition is false when SPIRE Server has no bundle for one or more of
+	// the trust domains federated with.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// Condition types for ClusterSPIFFEIDs.
+const (
+	ClusterSPIFFEIDConditionReady     = "Ready"
+	ClusterSPIFFEIDConditionStalled   = "Stalled"
+	ClusterSPIFFEIDConditionFederated = "Federated"
+)
+
+// Condition reasons for ClusterSPIFFEIDs.
+const (
+	// ClusterSPIFFEIDReasonReconciled means the entries were rendered and set
+	// for all selected pods.
+	ClusterSPIFFEIDReasonReconciled = "Reconciled"
+
+	// ClusterSPIFFEIDReasonInvalidSpec means the spec could not be parsed.
+	ClusterSPIFFEIDReasonInvalidSpec = "InvalidSpec"
+
+	// ClusterSPIFFEIDReasonRenderFailed means entries could not be rendered
+	// for one or more selected pods.
+	ClusterSPIFFEIDReasonRenderFailed = "RenderFailed"
+
+	// ClusterSPIFFEIDReasonListFailed means the namespaces or pods selected
+	// could not be listed.
+	ClusterSPIFFEIDReasonListFailed = "ListFailed"
+
+	// ClusterSPIFFEIDReasonEntryFailed means one or more entries could not be
+	// created or updated on SPIRE Server.
+	ClusterSPIFFEIDReasonEntryFailed = "EntryFailed"
+
+	// ClusterSPIFFEIDReasonEntryLimitExceeded means one or more entries could
+	// not be created because SPIRE Server limits the number of entries for
+	// their parent (i.e. the agent of the node) and the limit was reached.
+	ClusterSPIFFEIDReasonEntryLimitExceeded = "EntryLimitExceeded"
+
+	// ClusterSPIFFEIDReasonBundlesAvailable means SPIRE Server has a bundle
+	// for every trust domain federated with.
+	ClusterSPIFFEIDReasonBundlesAvailable = "BundlesAvailable"
+
+	// ClusterSPIFFEIDReasonBundlesMissing means SPIRE Server has no bundle
+	// for one or more trust domains federated with, e.g. because there is no
+	// ClusterFederatedTrustDomain for them or their bundle has not been
+	// fetched yet. SPIRE Server refuses to create entries that federate with
+	// them.
+	ClusterSPIFFEIDReasonBundlesMissing = "BundlesMissing"
+)
+
 // ClusterSPIFFEIDStats contain entry reconciliation statistics.
 type ClusterSPIFFEIDStats struct {
 	// How many namespaces were selected.
@@ -92,7 +272,8 @@ type ClusterSPIFFEIDStats struct {
 	// +kubebuilder:validation:Optional
 	NamespacesIgnored int `json:"namespacesIgnored"`
 
-	// How many pods were selected out of the namespaces.
+	// How many pods were selected out of the namespaces. For a fallback,
+	// only the pods it covers are counted.
 	// +kubebuilder:validation:Optional
 	PodsSelected int `json:"podsSelected"`
 
@@ -109,6 +290,11 @@ type ClusterSPIFFEIDStats struct {
 	// +kubebuilder:validation:Optional
 	EntriesMasked int `json:"entriesMasked"`
 
+	// How many entries declared by other objects were masked by the
+	// entries of this object.
+	// +kubebuilder:validation:Optional
+	EntriesMasking int `json:"entriesMasking"`
+
 	// How many entries are to be set for this ClusterSPIFFEID. In nominal
 	// conditions, this should reflect the number of pods selected, but not
 	// always if there were problems encountered rendering an entry for the pod
@@ -120,11 +306,24 @@ type ClusterSPIFFEIDStats struct {
 	// update the entries via the SPIRE Server API.
 	// +kubebuilder:validation:Optional
 	EntryFailures int `json:"entryFailures"`
+
+	// How many of the selected pods were not selected by any other
+	// ClusterSPIFFEID or NamespacedSPIFFEID, and so were covered by this
+	// ClusterSPIFFEID as a fallback. Always zero unless the ClusterSPIFFEID
+	// is a fallback.
+	// +kubebuilder:validation:Optional
+	PodsCoveredByFallback int `json:"podsCoveredByFallback"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:scope=Cluster
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Pods",type=integer,JSONPath=`.status.stats.podsSelected`
+//+kubebuilder:printcolumn:name="Entries",type=integer,JSONPath=`.status.stats.entriesToSet`
+//+kubebuilder:printcolumn:name="Masked",type=integer,JSONPath=`.status.stats.entriesMasked`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ClusterSPIFFEID is the Schema for the clusterspiffeids API
 type ClusterSPIFFEID struct {
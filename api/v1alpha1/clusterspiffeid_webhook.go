@@ -17,16 +17,22 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"path"
+	"strings"
 	"text/template"
 	"time"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -34,6 +40,7 @@ import (
 
 const (
 	dnsNameTemplateName          = "dnsNameTemplate"
+	hintTemplateName             = "hintTemplate"
 	spiffeIDTemplateName         = "spiffeIDTemplate"
 	workloadSelectorTemplateName = "workloadSelectorTemplate"
 )
@@ -41,42 +48,83 @@ const (
 // log is for logging in this package.
 var clusterspiffeidlog = logf.Log.WithName("clusterspiffeid-resource")
 
-func (r *ClusterSPIFFEID) SetupWebhookWithManager(mgr ctrl.Manager) error {
+func (r *ClusterSPIFFEID) SetupWebhookWithManager(mgr ctrl.Manager, options WebhookOptions) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
+		WithValidator(&clusterSPIFFEIDValidator{
+			client:              mgr.GetClient(),
+			trustDomain:         options.TrustDomain,
+			allowedPathPrefixes: options.AllowedPathPrefixes,
+			className:           options.ClassName,
+			watchClassless:      options.WatchClassless,
+			spireServers:        options.SPIREServers,
+			privilegedEntries:   options.PrivilegedEntries,
+		}).
 		Complete()
 }
 
-// TODO(user): EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
-
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
 //+kubebuilder:webhook:path=/validate-spire-spiffe-io-v1alpha1-clusterspiffeid,mutating=false,failurePolicy=fail,sideEffects=None,groups=spire.spiffe.io,resources=clusterspiffeids,verbs=create;update,versions=v1alpha1,name=vclusterspiffeid.kb.io,admissionReviewVersions=v1
 
-var _ webhook.Validator = &ClusterSPIFFEID{}
+// clusterSPIFFEIDValidator validates ClusterSPIFFEIDs against the controller
+// configuration. If it has a client, it also warns about trust domains
+// federated with that have no ClusterFederatedTrustDomain.
+type clusterSPIFFEIDValidator struct {
+	client              client.Reader
+	trustDomain         spiffeid.TrustDomain
+	allowedPathPrefixes []string
+	className           string
+	watchClassless      bool
+	spireServers        []string
+	privilegedEntries   *PrivilegedEntriesConfig
+}
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (r *ClusterSPIFFEID) ValidateCreate() (admission.Warnings, error) {
-	clusterspiffeidlog.Info("validate create", "name", r.Name)
+var _ webhook.CustomValidator = &clusterSPIFFEIDValidator{}
 
-	return r.validate()
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *clusterSPIFFEIDValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	r, ok := obj.(*ClusterSPIFFEID)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterSPIFFEID but got %T", obj)
+	}
+	clusterspiffeidlog.Info("validate create", "name", r.Name)
+	return v.validate(ctx, r)
 }
 
-// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
-func (r *ClusterSPIFFEID) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *clusterSPIFFEIDValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	r, ok := newObj.(*ClusterSPIFFEID)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterSPIFFEID but got %T", newObj)
+	}
 	clusterspiffeidlog.Info("validate update", "name", r.Name)
-
-	return r.validate()
+	return v.validate(ctx, r)
 }
 
-// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
-func (r *ClusterSPIFFEID) ValidateDelete() (admission.Warnings, error) {
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *clusterSPIFFEIDValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	// Deletes are not validated.
 	return nil, nil
 }
 
-func (r *ClusterSPIFFEID) validate() (admission.Warnings, error) {
-	_, err := ParseClusterSPIFFEIDSpec(&r.Spec)
-	return nil, err
+func (v *clusterSPIFFEIDValidator) validate(ctx context.Context, r *ClusterSPIFFEID) (admission.Warnings, error) {
+	spec, err := ParseClusterSPIFFEIDSpec(&r.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if !MatchesClass(r.Spec.ClassName, v.className, v.watchClassless) {
+		return nil, nil
+	}
+	if err := checkSPIFFEIDTemplatePathAllowed(spec.SPIFFEIDTemplate, v.allowedPathPrefixes); err != nil {
+		return nil, fmt.Errorf("invalid SPIFFEID template: %w", err)
+	}
+	if err := CheckSPIREServer(r.Spec.SPIREServer, v.spireServers); err != nil {
+		return nil, fmt.Errorf("invalid spireServer: %w", err)
+	}
+	if err := v.privilegedEntries.CheckClusterSPIFFEID(r.Name, r.Spec.Admin, r.Spec.Downstream); err != nil {
+		return nil, err
+	}
+	return federatesWithWarnings(ctx, v.client, v.trustDomain, r.Spec.SPIREServer, spec.FederatesWith), nil
 }
 
 // +kubebuilder:object:generate=false
@@ -85,10 +133,22 @@ type ParsedClusterSPIFFEIDSpec struct {
 	SPIFFEIDTemplate          *template.Template
 	NamespaceSelector         labels.Selector
 	PodSelector               labels.Selector
+	PodExcludeSelector        labels.Selector
+	ServiceAccountNames       []string
+	StaticPods                PodInclusionPolicy
+	HostNetworkPods           PodInclusionPolicy
+	JobPods                   PodInclusionPolicy
+	RegistrationMode          RegistrationMode
+	NodeAlias                 string
+	Fallback                  bool
 	TTL                       time.Duration
+	JWTSVIDTTL                time.Duration
 	FederatesWith             []spiffeid.TrustDomain
+	FederatesWithSets         []string
 	DNSNameTemplates          []*template.Template
+	AutoPopulateDNSNames      bool
 	WorkloadSelectorTemplates []*template.Template
+	HintTemplate              *template.Template
 	Admin                     bool
 	Downstream                bool
 }
@@ -99,7 +159,7 @@ func ParseClusterSPIFFEIDSpec(spec *ClusterSPIFFEIDSpec) (*ParsedClusterSPIFFEID
 		return nil, errors.New("empty SPIFFEID template")
 	}
 
-	spiffeIDTemplate, err := template.New(spiffeIDTemplateName).Parse(spec.SPIFFEIDTemplate)
+	spiffeIDTemplate, err := newEntryTemplate(spiffeIDTemplateName).Parse(spec.SPIFFEIDTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("invalid SPIFFEID template: %w", err)
 	}
@@ -120,6 +180,63 @@ func ParseClusterSPIFFEIDSpec(spec *ClusterSPIFFEIDSpec) (*ParsedClusterSPIFFEID
 		}
 	}
 
+	var podExcludeSelector labels.Selector
+	if spec.PodExcludeSelector != nil {
+		podExcludeSelector, err = metav1.LabelSelectorAsSelector(spec.PodExcludeSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid podExcludeSelector value: %w", err)
+		}
+	}
+
+	for _, value := range spec.ServiceAccountNames {
+		if value == "" {
+			return nil, errors.New("invalid serviceAccountNames value: empty value")
+		}
+		if _, err := path.Match(value, ""); err != nil {
+			return nil, fmt.Errorf("invalid serviceAccountNames value %q: %w", value, err)
+		}
+	}
+
+	staticPods, err := parsePodInclusionPolicy(spec.StaticPods)
+	if err != nil {
+		return nil, fmt.Errorf("invalid staticPods value: %w", err)
+	}
+
+	hostNetworkPods, err := parsePodInclusionPolicy(spec.HostNetworkPods)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hostNetworkPods value: %w", err)
+	}
+
+	jobPods, err := parsePodInclusionPolicy(spec.JobPods)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jobPods value: %w", err)
+	}
+
+	registrationMode, err := parseRegistrationMode(spec.RegistrationMode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registrationMode value: %w", err)
+	}
+	switch {
+	case registrationMode == RegistrationModeServiceAccount && spec.NodeAlias == "":
+		return nil, errors.New("invalid nodeAlias value: required in the ServiceAccount registration mode")
+	case registrationMode != RegistrationModeServiceAccount && spec.NodeAlias != "":
+		return nil, errors.New("invalid nodeAlias value: only allowed in the ServiceAccount registration mode")
+	}
+
+	ttl := spec.TTL.Duration
+	switch {
+	case spec.TTL.Duration < 0:
+		return nil, errors.New("invalid ttl value: must not be negative")
+	case spec.X509SVIDTTL.Duration < 0:
+		return nil, errors.New("invalid x509SVIDTTL value: must not be negative")
+	case spec.JWTSVIDTTL.Duration < 0:
+		return nil, errors.New("invalid jwtSVIDTTL value: must not be negative")
+	case spec.TTL.Duration != 0 && spec.X509SVIDTTL.Duration != 0:
+		return nil, errors.New("ttl and x509SVIDTTL are mutually exclusive")
+	case spec.X509SVIDTTL.Duration != 0:
+		ttl = spec.X509SVIDTTL.Duration
+	}
+
 	federatesWith := make([]spiffeid.TrustDomain, 0, len(spec.FederatesWith))
 	for _, value := range spec.FederatesWith {
 		td, err := spiffeid.TrustDomainFromString(value)
@@ -129,33 +246,155 @@ func ParseClusterSPIFFEIDSpec(spec *ClusterSPIFFEIDSpec) (*ParsedClusterSPIFFEID
 		federatesWith = append(federatesWith, td)
 	}
 
+	for _, value := range spec.FederatesWithSets {
+		if errs := validation.IsDNS1123Subdomain(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid federatesWithSets value %q: %s", value, strings.Join(errs, ", "))
+		}
+	}
+
 	var dnsNameTemplates []*template.Template
 	for _, value := range spec.DNSNameTemplates {
-		dnsNameTemplate, err := template.New(dnsNameTemplateName).Parse(value)
+		dnsNameTemplate, err := newEntryTemplate(dnsNameTemplateName).Parse(value)
 		if err != nil {
 			return nil, fmt.Errorf("invalid dnsNameTemplate value: %w", err)
 		}
+		if err := checkDNSNameTemplate(dnsNameTemplate); err != nil {
+			return nil, fmt.Errorf("invalid dnsNameTemplate value %q: %w", value, err)
+		}
 		dnsNameTemplates = append(dnsNameTemplates, dnsNameTemplate)
 	}
 
 	var workloadSelectorTemplates []*template.Template
 	for _, value := range spec.WorkloadSelectorTemplates {
-		workloadSelectorTemplate, err := template.New(workloadSelectorTemplateName).Parse(value)
+		workloadSelectorTemplate, err := newEntryTemplate(workloadSelectorTemplateName).Parse(value)
 		if err != nil {
 			return nil, fmt.Errorf("invalid workloadSelectorTemplates value: %w", err)
 		}
 		workloadSelectorTemplates = append(workloadSelectorTemplates, workloadSelectorTemplate)
 	}
 
+	var hintTemplate *template.Template
+	if spec.HintTemplate != "" {
+		hintTemplate, err = newEntryTemplate(hintTemplateName).Parse(spec.HintTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hintTemplate value: %w", err)
+		}
+	}
+
 	return &ParsedClusterSPIFFEIDSpec{
 		SPIFFEIDTemplate:          spiffeIDTemplate,
 		NamespaceSelector:         namespaceSelector,
 		PodSelector:               podSelector,
-		TTL:                       spec.TTL.Duration,
+		PodExcludeSelector:        podExcludeSelector,
+		ServiceAccountNames:       spec.ServiceAccountNames,
+		StaticPods:                staticPods,
+		HostNetworkPods:           hostNetworkPods,
+		JobPods:                   jobPods,
+		RegistrationMode:          registrationMode,
+		NodeAlias:                 spec.NodeAlias,
+		Fallback:                  spec.Fallback,
+		TTL:                       ttl,
+		JWTSVIDTTL:                spec.JWTSVIDTTL.Duration,
 		FederatesWith:             federatesWith,
+		FederatesWithSets:         spec.FederatesWithSets,
 		DNSNameTemplates:          dnsNameTemplates,
+		AutoPopulateDNSNames:      spec.AutoPopulateDNSNames,
 		WorkloadSelectorTemplates: workloadSelectorTemplates,
+		HintTemplate:              hintTemplate,
 		Admin:                     spec.Admin,
 		Downstream:                spec.Downstream,
 	}, nil
 }
+
+func parsePodInclusionPolicy(policy PodInclusionPolicy) (PodInclusionPolicy, error) {
+	switch policy {
+	case "":
+		return PodInclusionPolicyInclude, nil
+	case PodInclusionPolicyInclude, PodInclusionPolicyExclude:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown policy %q", policy)
+	}
+}
+
+func parseRegistrationMode(mode RegistrationMode) (RegistrationMode, error) {
+	switch mode {
+	case "":
+		return RegistrationModePod, nil
+	case RegistrationModePod, RegistrationModeServiceAccount:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown mode %q", mode)
+	}
+}
+
+// SelectsPod returns true if the pod is targeted by the ClusterSPIFFEID. It
+// does not evaluate the namespace or pod label selectors.
+func (s *ParsedClusterSPIFFEIDSpec) SelectsPod(pod *corev1.Pod) bool {
+	return s.PodExclusionReason(pod) == ""
+}
+
+// PodExclusionReason returns why the pod is not targeted by the
+// ClusterSPIFFEID, or an empty string if it is. It does not evaluate the
+// namespace or pod label selectors, but does evaluate the pod exclude
+// selector.
+func (s *ParsedClusterSPIFFEIDSpec) PodExclusionReason(pod *corev1.Pod) string {
+	switch {
+	case IsSkippedPod(pod):
+		return fmt.Sprintf("pod is annotated with %s", SkipPodAnnotation)
+	case s.PodExcludeSelector != nil && s.PodExcludeSelector.Matches(labels.Set(pod.Labels)):
+		return "pod matches the podExcludeSelector"
+	case s.StaticPods == PodInclusionPolicyExclude && IsStaticPod(pod):
+		return "static pods are excluded"
+	case s.HostNetworkPods == PodInclusionPolicyExclude && pod.Spec.HostNetwork:
+		return "host network pods are excluded"
+	case s.JobPods == PodInclusionPolicyExclude && IsJobPod(pod):
+		return "job pods are excluded"
+	}
+	serviceAccountName := pod.Spec.ServiceAccountName
+	if serviceAccountName == "" {
+		// Pods that don't name a service account run as the "default"
+		// service account.
+		serviceAccountName = "default"
+	}
+	if !s.SelectsServiceAccount(serviceAccountName) {
+		return fmt.Sprintf("service account %q is not selected", serviceAccountName)
+	}
+	return ""
+}
+
+// SkipPodAnnotation, when set to "true" on a pod, opts the pod out of entry
+// creation, regardless of the objects that target it.
+const SkipPodAnnotation = "spire.spiffe.io/skip"
+
+// IsSkippedPod returns true if the pod opted out of entry creation.
+func IsSkippedPod(pod *corev1.Pod) bool {
+	return pod.Annotations[SkipPodAnnotation] == "true"
+}
+
+// IsStaticPod returns true if the pod is the mirror pod of a static pod.
+func IsStaticPod(pod *corev1.Pod) bool {
+	_, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]
+	return ok
+}
+
+// IsJobPod returns true if the pod is controlled by a Job.
+func IsJobPod(pod *corev1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "Job" && strings.HasPrefix(owner.APIVersion, "batch/")
+}
+
+// SelectsServiceAccount returns true if pods running as the named service
+// account are targeted by the ClusterSPIFFEID.
+func (s *ParsedClusterSPIFFEIDSpec) SelectsServiceAccount(name string) bool {
+	if len(s.ServiceAccountNames) == 0 {
+		return true
+	}
+	for _, pattern := range s.ServiceAccountNames {
+		// Patterns are validated when the spec is parsed.
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
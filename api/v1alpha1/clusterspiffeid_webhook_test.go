@@ -0,0 +1,374 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestParseClusterSPIFFEIDSpecServiceAccountNames(t *testing.T) {
+	for _, tt := range []struct {
+		desc                string
+		serviceAccountNames []string
+		expectErr           string
+		expectSelected      []string
+		expectNotSelected   []string
+	}{
+		{
+			desc:           "unset selects all service accounts",
+			expectSelected: []string{"default", "frontend"},
+		},
+		{
+			desc:                "names",
+			serviceAccountNames: []string{"frontend", "backend"},
+			expectSelected:      []string{"frontend", "backend"},
+			expectNotSelected:   []string{"default", "frontend-v2"},
+		},
+		{
+			desc:                "patterns",
+			serviceAccountNames: []string{"frontend-*", "db-?"},
+			expectSelected:      []string{"frontend-v1", "frontend-", "db-1"},
+			expectNotSelected:   []string{"frontend", "db-10"},
+		},
+		{
+			desc:                "empty value",
+			serviceAccountNames: []string{""},
+			expectErr:           "invalid serviceAccountNames value: empty value",
+		},
+		{
+			desc:                "bad pattern",
+			serviceAccountNames: []string{"frontend-["},
+			expectErr:           `invalid serviceAccountNames value "frontend-[": syntax error in pattern`,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			spec, err := ParseClusterSPIFFEIDSpec(&ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate:    "spiffe://domain.test/workload",
+				ServiceAccountNames: tt.serviceAccountNames,
+			})
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			for _, name := range tt.expectSelected {
+				require.True(t, spec.SelectsServiceAccount(name), "expected %q to be selected", name)
+			}
+			for _, name := range tt.expectNotSelected {
+				require.False(t, spec.SelectsServiceAccount(name), "expected %q to not be selected", name)
+			}
+		})
+	}
+}
+
+func TestParsedClusterSPIFFEIDSpecSelectsPod(t *testing.T) {
+	regularPod := &corev1.Pod{}
+	staticPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{corev1.MirrorPodAnnotationKey: "hash"}}}
+	hostNetworkPod := &corev1.Pod{Spec: corev1.PodSpec{HostNetwork: true}}
+	controller := true
+	jobPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{
+		{APIVersion: "batch/v1", Kind: "Job", Name: "job", Controller: &controller},
+	}}}
+	skippedPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{SkipPodAnnotation: "true"}}}
+	notSkippedPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{SkipPodAnnotation: "false"}}}
+	ciRunnerPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"role": "ci-runner"}}}
+	replicaSetPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs", Controller: &controller},
+	}}}
+
+	for _, tt := range []struct {
+		desc              string
+		spec              ClusterSPIFFEIDSpec
+		expectErr         string
+		expectSelected    []*corev1.Pod
+		expectNotSelected []*corev1.Pod
+	}{
+		{
+			desc:              "defaults",
+			expectSelected:    []*corev1.Pod{regularPod, staticPod, hostNetworkPod, jobPod, notSkippedPod},
+			expectNotSelected: []*corev1.Pod{skippedPod},
+		},
+		{
+			desc:           "include",
+			spec:           ClusterSPIFFEIDSpec{StaticPods: PodInclusionPolicyInclude, HostNetworkPods: PodInclusionPolicyInclude, JobPods: PodInclusionPolicyInclude},
+			expectSelected: []*corev1.Pod{regularPod, staticPod, hostNetworkPod, jobPod},
+		},
+		{
+			desc:              "exclude static pods",
+			spec:              ClusterSPIFFEIDSpec{StaticPods: PodInclusionPolicyExclude},
+			expectSelected:    []*corev1.Pod{regularPod, hostNetworkPod},
+			expectNotSelected: []*corev1.Pod{staticPod},
+		},
+		{
+			desc:              "exclude host network pods",
+			spec:              ClusterSPIFFEIDSpec{HostNetworkPods: PodInclusionPolicyExclude},
+			expectSelected:    []*corev1.Pod{regularPod, staticPod},
+			expectNotSelected: []*corev1.Pod{hostNetworkPod},
+		},
+		{
+			desc:              "exclude job pods",
+			spec:              ClusterSPIFFEIDSpec{JobPods: PodInclusionPolicyExclude},
+			expectSelected:    []*corev1.Pod{regularPod, replicaSetPod},
+			expectNotSelected: []*corev1.Pod{jobPod},
+		},
+		{
+			desc:              "service account names",
+			spec:              ClusterSPIFFEIDSpec{ServiceAccountNames: []string{"other"}},
+			expectNotSelected: []*corev1.Pod{regularPod},
+		},
+		{
+			desc:              "pod exclude selector",
+			spec:              ClusterSPIFFEIDSpec{PodExcludeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "ci-runner"}}},
+			expectSelected:    []*corev1.Pod{regularPod, jobPod},
+			expectNotSelected: []*corev1.Pod{ciRunnerPod},
+		},
+		{
+			desc: "invalid pod exclude selector",
+			spec: ClusterSPIFFEIDSpec{PodExcludeSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "role", Operator: "Sometimes"},
+			}}},
+			expectErr: `invalid podExcludeSelector value: "Sometimes" is not a valid label selector operator`,
+		},
+		{
+			desc:      "invalid static pods policy",
+			spec:      ClusterSPIFFEIDSpec{StaticPods: "Only"},
+			expectErr: `invalid staticPods value: unknown policy "Only"`,
+		},
+		{
+			desc:      "invalid host network pods policy",
+			spec:      ClusterSPIFFEIDSpec{HostNetworkPods: "Only"},
+			expectErr: `invalid hostNetworkPods value: unknown policy "Only"`,
+		},
+		{
+			desc:      "invalid hint template",
+			spec:      ClusterSPIFFEIDSpec{HintTemplate: "{{"},
+			expectErr: "invalid hintTemplate value: template: hintTemplate:1: unclosed action",
+		},
+		{
+			desc:      "invalid job pods policy",
+			spec:      ClusterSPIFFEIDSpec{JobPods: "Only"},
+			expectErr: `invalid jobPods value: unknown policy "Only"`,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			tt.spec.SPIFFEIDTemplate = "spiffe://domain.test/workload"
+			spec, err := ParseClusterSPIFFEIDSpec(&tt.spec)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			for _, pod := range tt.expectSelected {
+				require.True(t, spec.SelectsPod(pod))
+			}
+			for _, pod := range tt.expectNotSelected {
+				require.False(t, spec.SelectsPod(pod))
+			}
+		})
+	}
+}
+
+func TestParseClusterSPIFFEIDSpecRegistrationMode(t *testing.T) {
+	for _, tt := range []struct {
+		desc       string
+		spec       ClusterSPIFFEIDSpec
+		expectErr  string
+		expectMode RegistrationMode
+	}{
+		{
+			desc:       "defaults to pod",
+			expectMode: RegistrationModePod,
+		},
+		{
+			desc:       "service account",
+			spec:       ClusterSPIFFEIDSpec{RegistrationMode: RegistrationModeServiceAccount, NodeAlias: "all-nodes"},
+			expectMode: RegistrationModeServiceAccount,
+		},
+		{
+			desc:      "service account without node alias",
+			spec:      ClusterSPIFFEIDSpec{RegistrationMode: RegistrationModeServiceAccount},
+			expectErr: "invalid nodeAlias value: required in the ServiceAccount registration mode",
+		},
+		{
+			desc:      "node alias in pod mode",
+			spec:      ClusterSPIFFEIDSpec{NodeAlias: "all-nodes"},
+			expectErr: "invalid nodeAlias value: only allowed in the ServiceAccount registration mode",
+		},
+		{
+			desc:      "unknown mode",
+			spec:      ClusterSPIFFEIDSpec{RegistrationMode: "Node"},
+			expectErr: `invalid registrationMode value: unknown mode "Node"`,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			tt.spec.SPIFFEIDTemplate = "spiffe://domain.test/workload"
+			spec, err := ParseClusterSPIFFEIDSpec(&tt.spec)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectMode, spec.RegistrationMode)
+			require.Equal(t, tt.spec.NodeAlias, spec.NodeAlias)
+		})
+	}
+}
+
+func TestParseClusterSPIFFEIDSpecFederatesWithSets(t *testing.T) {
+	spec, err := ParseClusterSPIFFEIDSpec(&ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate:  "spiffe://domain.test/workload",
+		FederatesWithSets: []string{"partners", "internal.example"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"partners", "internal.example"}, spec.FederatesWithSets)
+
+	_, err = ParseClusterSPIFFEIDSpec(&ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate:  "spiffe://domain.test/workload",
+		FederatesWithSets: []string{"Partners"},
+	})
+	require.ErrorContains(t, err, `invalid federatesWithSets value "Partners"`)
+}
+
+func TestParseClusterSPIFFEIDSpecTTLs(t *testing.T) {
+	for _, tt := range []struct {
+		desc              string
+		spec              ClusterSPIFFEIDSpec
+		expectErr         string
+		expectX509SVIDTTL time.Duration
+		expectJWTSVIDTTL  time.Duration
+	}{
+		{
+			desc: "unset",
+		},
+		{
+			desc:              "ttl",
+			spec:              ClusterSPIFFEIDSpec{TTL: metav1.Duration{Duration: time.Hour}},
+			expectX509SVIDTTL: time.Hour,
+		},
+		{
+			desc: "x509 and jwt",
+			spec: ClusterSPIFFEIDSpec{
+				X509SVIDTTL: metav1.Duration{Duration: time.Hour},
+				JWTSVIDTTL:  metav1.Duration{Duration: time.Minute},
+			},
+			expectX509SVIDTTL: time.Hour,
+			expectJWTSVIDTTL:  time.Minute,
+		},
+		{
+			desc: "ttl and x509",
+			spec: ClusterSPIFFEIDSpec{
+				TTL:         metav1.Duration{Duration: time.Hour},
+				X509SVIDTTL: metav1.Duration{Duration: time.Hour},
+			},
+			expectErr: "ttl and x509SVIDTTL are mutually exclusive",
+		},
+		{
+			desc:      "negative x509",
+			spec:      ClusterSPIFFEIDSpec{X509SVIDTTL: metav1.Duration{Duration: -time.Hour}},
+			expectErr: "invalid x509SVIDTTL value: must not be negative",
+		},
+		{
+			desc:      "negative jwt",
+			spec:      ClusterSPIFFEIDSpec{JWTSVIDTTL: metav1.Duration{Duration: -time.Hour}},
+			expectErr: "invalid jwtSVIDTTL value: must not be negative",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			tt.spec.SPIFFEIDTemplate = "spiffe://domain.test/workload"
+			spec, err := ParseClusterSPIFFEIDSpec(&tt.spec)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectX509SVIDTTL, spec.TTL)
+			require.Equal(t, tt.expectJWTSVIDTTL, spec.JWTSVIDTTL)
+		})
+	}
+}
+
+func TestClusterSPIFFEIDValidatorFederatesWith(t *testing.T) {
+	newClusterFederatedTrustDomain := func(name, trustDomain, spireServer string) *ClusterFederatedTrustDomain {
+		return &ClusterFederatedTrustDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: ClusterFederatedTrustDomainSpec{
+				TrustDomain: trustDomain,
+				SPIREServer: spireServer,
+			},
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+	v := &clusterSPIFFEIDValidator{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(
+				newClusterFederatedTrustDomain("a", "a.test", ""),
+				newClusterFederatedTrustDomain("b", "b.test", "other"),
+			).
+			Build(),
+		trustDomain:  spiffeid.RequireTrustDomainFromString("domain.test"),
+		spireServers: []string{"other"},
+	}
+
+	for _, tt := range []struct {
+		desc           string
+		federatesWith  []string
+		spireServer    string
+		expectWarnings admission.Warnings
+	}{
+		{
+			desc: "no federation",
+		},
+		{
+			desc:          "federated trust domains",
+			federatesWith: []string{"a.test", "domain.test"},
+		},
+		{
+			desc:           "missing trust domains",
+			federatesWith:  []string{"a.test", "b.test", "c.test"},
+			expectWarnings: admission.Warnings{"no ClusterFederatedTrustDomain exists for federatesWith trust domains b.test, c.test; entries federating with them cannot be created until SPIRE Server has their bundles"},
+		},
+		{
+			desc:           "trust domains federated on another SPIRE Server",
+			federatesWith:  []string{"a.test", "b.test"},
+			spireServer:    "other",
+			expectWarnings: admission.Warnings{"no ClusterFederatedTrustDomain exists for federatesWith trust domains a.test; entries federating with them cannot be created until SPIRE Server has their bundles"},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			warnings, err := v.ValidateCreate(context.Background(), &ClusterSPIFFEID{
+				ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+				Spec: ClusterSPIFFEIDSpec{
+					SPIFFEIDTemplate: "spiffe://domain.test/workload",
+					FederatesWith:    tt.federatesWith,
+					SPIREServer:      tt.spireServer,
+				},
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.expectWarnings, warnings)
+		})
+	}
+
+	t.Run("without a client", func(t *testing.T) {
+		v := &clusterSPIFFEIDValidator{}
+		warnings, err := v.ValidateCreate(context.Background(), &ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+			Spec: ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://domain.test/workload",
+				FederatesWith:    []string{"c.test"},
+			},
+		})
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+	})
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import "errors"
+
+// Hub marks v1alpha1 as the version ClusterStaticEntries are converted
+// through, and stored as. Every other version converts to and from it.
+func (*ClusterStaticEntry) Hub() {}
+
+// Validate returns an error if the defaults are not valid ClusterStaticEntry
+// field values.
+func (c *ClusterStaticEntryDefaultsConfig) Validate() error {
+	switch {
+	case c.X509SVIDTTL.Duration < 0:
+		return errors.New("invalid x509SVIDTTL value: must not be negative")
+	case c.JWTSVIDTTL.Duration < 0:
+		return errors.New("invalid jwtSVIDTTL value: must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterStaticEntryDefaultsConfigValidate(t *testing.T) {
+	require.NoError(t, (&ClusterStaticEntryDefaultsConfig{
+		X509SVIDTTL: metav1.Duration{Duration: time.Hour},
+		JWTSVIDTTL:  metav1.Duration{Duration: time.Minute},
+	}).Validate())
+	require.EqualError(t, (&ClusterStaticEntryDefaultsConfig{
+		X509SVIDTTL: metav1.Duration{Duration: -time.Hour},
+	}).Validate(), "invalid x509SVIDTTL value: must not be negative")
+	require.EqualError(t, (&ClusterStaticEntryDefaultsConfig{
+		JWTSVIDTTL: metav1.Duration{Duration: -time.Minute},
+	}).Validate(), "invalid jwtSVIDTTL value: must not be negative")
+}
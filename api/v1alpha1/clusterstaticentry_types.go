@@ -35,6 +35,24 @@ type ClusterStaticEntrySpec struct {
 	Hint          string          `json:"hint,omitempty"`
 	Admin         bool            `json:"admin,omitempty"`
 	Downstream    bool            `json:"downstream,omitempty"`
+
+	// StoreSVID indicates that the SVID is stored by an SVIDStore plugin
+	// instead of being served to workloads over the Workload API. The
+	// selectors must all be of the same type, which names the store.
+	// +optional
+	StoreSVID bool `json:"storeSVID,omitempty"`
+
+	// ClassName is the class of the controller manager instance that
+	// reconciles this ClusterStaticEntry. If unset, it is reconciled by the
+	// instances without a class, or that watch classless objects.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// SPIREServer is the name of the SPIRE Server target, as configured in
+	// the controller manager configuration, that the entry is registered
+	// with. If unset, the entry is registered with the default SPIRE Server.
+	// +optional
+	SPIREServer string `json:"spireServer,omitempty"`
 }
 
 // ClusterStaticEntryStatus defines the observed state of ClusterStaticEntry
@@ -45,6 +63,16 @@ type ClusterStaticEntryStatus struct {
 	// If the static entry was masked by another entry.
 	Masked bool `json:"masked"`
 
+	// MaskedBy names the objects, as "Kind/name", that declared the entries
+	// masking the entries of this ClusterStaticEntry. At most 10 objects are named.
+	// +optional
+	MaskedBy []string `json:"maskedBy,omitempty"`
+
+	// Masking names the objects, as "Kind/name", whose entries are masked by
+	// the entries of this ClusterStaticEntry. At most 10 objects are named.
+	// +optional
+	Masking []string `json:"masking,omitempty"`
+
 	// If the static entry was successfully created/updated.
 	Set bool `json:"set"`
 }
@@ -52,6 +80,7 @@ type ClusterStaticEntryStatus struct {
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:scope=Cluster
+//+kubebuilder:storageversion
 
 // ClusterStaticEntry is the Schema for the clusterstaticentries API
 type ClusterStaticEntry struct {
@@ -0,0 +1,273 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// clusterStaticEntryIdentityField is the name of the field index used
+	// to look up ClusterStaticEntries by class name, SPIRE Server, SPIFFE ID
+	// and selector set.
+	clusterStaticEntryIdentityField = "spec.spiffeIDAndSelectors"
+)
+
+var clusterstaticentrylog = logf.Log.WithName("clusterstaticentry-resource")
+
+func (r *ClusterStaticEntry) SetupWebhookWithManager(mgr ctrl.Manager, options WebhookOptions) error {
+	// Index ClusterStaticEntries by their SPIFFE ID and selectors so the
+	// validator can detect duplicates from the informer cache without
+	// listing every object. The index cannot be registered if the CRD is
+	// not installed yet, since the informer cannot be created, nor added
+	// after the informer has started. In that case, the validator falls back
+	// to filtering the full list.
+	indexed := true
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &ClusterStaticEntry{}, clusterStaticEntryIdentityField, indexClusterStaticEntryIdentity); err != nil {
+		if !meta.IsNoMatchError(err) {
+			return fmt.Errorf("failed to index ClusterStaticEntries: %w", err)
+		}
+		clusterstaticentrylog.Info("ClusterStaticEntry CRD is not installed; conflict detection will not use an index")
+		indexed = false
+	}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithValidator(&clusterStaticEntryValidator{
+			client:              mgr.GetClient(),
+			indexed:             indexed,
+			allowedPathPrefixes: options.AllowedPathPrefixes,
+			className:           options.ClassName,
+			watchClassless:      options.WatchClassless,
+			spireServers:        options.SPIREServers,
+			privilegedEntries:   options.PrivilegedEntries,
+		}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-spire-spiffe-io-v1alpha1-clusterstaticentry,mutating=false,failurePolicy=fail,sideEffects=None,groups=spire.spiffe.io,resources=clusterstaticentries,verbs=create;update,versions=v1alpha1,name=vclusterstaticentry.kb.io,admissionReviewVersions=v1
+
+// clusterStaticEntryValidator validates ClusterStaticEntries. Unlike the
+// other validators, it needs a client to cross-check the entry against
+// existing ClusterStaticEntries.
+type clusterStaticEntryValidator struct {
+	client              client.Reader
+	indexed             bool
+	allowedPathPrefixes []string
+	className           string
+	watchClassless      bool
+	spireServers        []string
+	privilegedEntries   *PrivilegedEntriesConfig
+}
+
+var _ webhook.CustomValidator = &clusterStaticEntryValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *clusterStaticEntryValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	r, ok := obj.(*ClusterStaticEntry)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterStaticEntry but got %T", obj)
+	}
+	clusterstaticentrylog.Info("validate create", "name", r.Name)
+	return v.validate(ctx, r)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *clusterStaticEntryValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	r, ok := newObj.(*ClusterStaticEntry)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterStaticEntry but got %T", newObj)
+	}
+	clusterstaticentrylog.Info("validate update", "name", r.Name)
+	return v.validate(ctx, r)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *clusterStaticEntryValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	// Deletes are not validated.
+	return nil, nil
+}
+
+func (v *clusterStaticEntryValidator) validate(ctx context.Context, r *ClusterStaticEntry) (admission.Warnings, error) {
+	entry, err := ParseClusterStaticEntrySpec(&r.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if !MatchesClass(r.Spec.ClassName, v.className, v.watchClassless) {
+		return nil, nil
+	}
+	if err := CheckPathAllowed(entry.SPIFFEID.Path(), v.allowedPathPrefixes); err != nil {
+		return nil, fmt.Errorf("invalid SPIFFEID: %w", err)
+	}
+	if err := CheckSPIREServer(r.Spec.SPIREServer, v.spireServers); err != nil {
+		return nil, fmt.Errorf("invalid spireServer: %w", err)
+	}
+	if err := v.privilegedEntries.CheckClusterStaticEntry(r.Name, r.Spec.Admin, r.Spec.Downstream); err != nil {
+		return nil, err
+	}
+
+	// Entries reconciled by other controllers, or that select another SPIRE
+	// Server target, are registered with other SPIRE servers, so they do not
+	// conflict. Only look up the entries of the classes reconciled by this
+	// controller, for the same SPIRE Server.
+	classNames := []string{v.className}
+	if v.watchClassless && v.className != "" {
+		classNames = append(classNames, "")
+	}
+	var candidates []ClusterStaticEntry
+	for _, className := range classNames {
+		matching, err := v.listWithIdentity(ctx, clusterStaticEntryIdentity(className, r.Spec.SPIREServer, entry))
+		if err != nil {
+			return nil, fmt.Errorf("unable to check for conflicting ClusterStaticEntries: %w", err)
+		}
+		candidates = append(candidates, matching...)
+	}
+
+	// Entries with the same SPIFFE ID, selectors, and parent ID map to the
+	// same SPIRE server entry, which would cause the two resources to fight
+	// over the entry fields. Reject those outright. Matches that differ only
+	// by parent ID produce distinct entries but are likely a mistake, so
+	// just warn about them.
+	var warnings admission.Warnings
+	for _, other := range candidates {
+		if other.Name == r.Name {
+			continue
+		}
+		otherEntry, err := ParseClusterStaticEntrySpec(&other.Spec)
+		if err != nil {
+			continue
+		}
+		if otherEntry.ParentID == entry.ParentID {
+			return nil, fmt.Errorf("ClusterStaticEntry %q already declares an entry with the same SPIFFE ID, parent ID, and selectors", other.Name)
+		}
+		warnings = append(warnings, fmt.Sprintf("ClusterStaticEntry %q declares an entry with the same SPIFFE ID and selectors but a different parent ID", other.Name))
+	}
+	return warnings, nil
+}
+
+// listWithIdentity lists the ClusterStaticEntries with the given identity.
+func (v *clusterStaticEntryValidator) listWithIdentity(ctx context.Context, identity string) ([]ClusterStaticEntry, error) {
+	var list ClusterStaticEntryList
+	if v.indexed {
+		if err := v.client.List(ctx, &list, client.MatchingFields{clusterStaticEntryIdentityField: identity}); err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+
+	if err := v.client.List(ctx, &list); err != nil {
+		return nil, err
+	}
+	var matching []ClusterStaticEntry
+	for i := range list.Items {
+		for _, value := range indexClusterStaticEntryIdentity(&list.Items[i]) {
+			if value == identity {
+				matching = append(matching, list.Items[i])
+			}
+		}
+	}
+	return matching, nil
+}
+
+// ParseClusterStaticEntrySpec parses and validates the fields in the ClusterStaticEntrySpec
+func ParseClusterStaticEntrySpec(spec *ClusterStaticEntrySpec) (*spireapi.Entry, error) {
+	spiffeID, err := spiffeid.FromString(spec.SPIFFEID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SPIFFEID: %w", err)
+	}
+	parentID, err := spiffeid.FromString(spec.ParentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ParentID: %w", err)
+	}
+	selectors, err := spireapi.ParseSelectors(spec.Selectors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Selectors: %w", err)
+	}
+	switch {
+	case spec.X509SVIDTTL.Duration < 0:
+		return nil, errors.New("invalid x509SVIDTTL value: must not be negative")
+	case spec.JWTSVIDTTL.Duration < 0:
+		return nil, errors.New("invalid jwtSVIDTTL value: must not be negative")
+	}
+	if spec.StoreSVID {
+		for _, selector := range selectors[1:] {
+			if selector.Type != selectors[0].Type {
+				return nil, errors.New("invalid selectors value: must all be of the same type when storeSVID is set")
+			}
+		}
+	}
+	federatesWith := make([]spiffeid.TrustDomain, 0, len(spec.FederatesWith))
+	for _, value := range spec.FederatesWith {
+		td, err := spiffeid.TrustDomainFromString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid federatesWith value: %w", err)
+		}
+		federatesWith = append(federatesWith, td)
+	}
+	return &spireapi.Entry{
+		SPIFFEID:      spiffeID,
+		ParentID:      parentID,
+		Selectors:     selectors,
+		X509SVIDTTL:   spec.X509SVIDTTL.Duration,
+		JWTSVIDTTL:    spec.JWTSVIDTTL.Duration,
+		FederatesWith: federatesWith,
+		DNSNames:      spec.DNSNames,
+		Admin:         spec.Admin,
+		Downstream:    spec.Downstream,
+		Hint:          spec.Hint,
+		StoreSVID:     spec.StoreSVID,
+	}, nil
+}
+
+func indexClusterStaticEntryIdentity(obj client.Object) []string {
+	clusterStaticEntry, ok := obj.(*ClusterStaticEntry)
+	if !ok {
+		return nil
+	}
+	entry, err := ParseClusterStaticEntrySpec(&clusterStaticEntry.Spec)
+	if err != nil {
+		// Invalid entries cannot conflict with anything since they are never
+		// rendered.
+		return nil
+	}
+	return []string{clusterStaticEntryIdentity(clusterStaticEntry.Spec.ClassName, clusterStaticEntry.Spec.SPIREServer, entry)}
+}
+
+// clusterStaticEntryIdentity returns a canonical representation of the class
+// name, SPIRE Server, SPIFFE ID and (order independent) selector set of the
+// entry.
+func clusterStaticEntryIdentity(className, spireServer string, entry *spireapi.Entry) string {
+	selectors := make([]string, 0, len(entry.Selectors))
+	for _, selector := range entry.Selectors {
+		selectors = append(selectors, selector.Type+":"+selector.Value)
+	}
+	sort.Strings(selectors)
+	return strings.Join(append([]string{className, spireServer, entry.SPIFFEID.String()}, selectors...), "|")
+}
@@ -0,0 +1,293 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClusterStaticEntryValidator(t *testing.T) {
+	existing := &ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing"},
+		Spec: ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://domain.test/workload",
+			ParentID:  "spiffe://domain.test/parent",
+			Selectors: []string{"a:1", "b:2"},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+	indexedValidator := &clusterStaticEntryValidator{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(existing).
+			WithIndex(&ClusterStaticEntry{}, clusterStaticEntryIdentityField, indexClusterStaticEntryIdentity).
+			Build(),
+		indexed:      true,
+		spireServers: []string{"other"},
+	}
+	// The validator falls back to filtering the full list when the index
+	// could not be registered because the CRD was not yet installed.
+	unindexedValidator := &clusterStaticEntryValidator{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(existing).
+			Build(),
+		spireServers: []string{"other"},
+	}
+
+	for _, tt := range []struct {
+		desc                string
+		name                string
+		spec                ClusterStaticEntrySpec
+		allowedPathPrefixes []string
+		expectErr           string
+		expectWarnings      int
+	}{
+		{
+			desc: "unique",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://domain.test/other",
+				ParentID:  "spiffe://domain.test/parent",
+				Selectors: []string{"a:1", "b:2"},
+			},
+		},
+		{
+			desc: "invalid spec",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://domain.test/workload",
+				ParentID:  "spiffe://domain.test/parent",
+				Selectors: []string{"a"},
+			},
+			expectErr: "failed to parse Selectors: expected at least one colon separate the type from the value",
+		},
+		{
+			desc: "negative jwt svid ttl",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:   "spiffe://domain.test/other",
+				ParentID:   "spiffe://domain.test/parent",
+				Selectors:  []string{"a:1"},
+				JWTSVIDTTL: metav1.Duration{Duration: -1},
+			},
+			expectErr: "invalid jwtSVIDTTL value: must not be negative",
+		},
+		{
+			desc: "store SVID",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://domain.test/other",
+				ParentID:  "spiffe://domain.test/parent",
+				Selectors: []string{"aws_secretsmanager:secretname:foo", "aws_secretsmanager:region:us-east-1"},
+				StoreSVID: true,
+			},
+		},
+		{
+			desc: "store SVID with mixed selector types",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://domain.test/other",
+				ParentID:  "spiffe://domain.test/parent",
+				Selectors: []string{"aws_secretsmanager:secretname:foo", "k8s:ns:default"},
+				StoreSVID: true,
+			},
+			expectErr: "invalid selectors value: must all be of the same type when storeSVID is set",
+		},
+		{
+			desc: "duplicate entry with reordered selectors",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://domain.test/workload",
+				ParentID:  "spiffe://domain.test/parent",
+				Selectors: []string{"b:2", "a:1"},
+			},
+			expectErr: `ClusterStaticEntry "existing" already declares an entry with the same SPIFFE ID, parent ID, and selectors`,
+		},
+		{
+			desc: "same SPIFFE ID and selectors with different parent",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://domain.test/workload",
+				ParentID:  "spiffe://domain.test/otherparent",
+				Selectors: []string{"a:1", "b:2"},
+			},
+			expectWarnings: 1,
+		},
+		{
+			desc: "allowed path prefix",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://domain.test/allowed/workload",
+				ParentID:  "spiffe://domain.test/parent",
+				Selectors: []string{"a:1"},
+			},
+			allowedPathPrefixes: []string{"/allowed"},
+		},
+		{
+			desc: "disallowed path prefix",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://domain.test/other/workload",
+				ParentID:  "spiffe://domain.test/allowed/parent",
+				Selectors: []string{"a:1"},
+			},
+			allowedPathPrefixes: []string{"/allowed"},
+			expectErr:           `invalid SPIFFEID: path "/other/workload" is not under an allowed path prefix`,
+		},
+		{
+			desc: "duplicate entry of another class",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://domain.test/workload",
+				ParentID:  "spiffe://domain.test/parent",
+				Selectors: []string{"a:1", "b:2"},
+				ClassName: "other",
+			},
+		},
+		{
+			desc: "disallowed path prefix of another class",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://domain.test/other/workload",
+				ParentID:  "spiffe://domain.test/allowed/parent",
+				Selectors: []string{"a:1"},
+				ClassName: "other",
+			},
+			allowedPathPrefixes: []string{"/allowed"},
+		},
+		{
+			desc: "duplicate entry for another SPIRE server",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:    "spiffe://domain.test/workload",
+				ParentID:    "spiffe://domain.test/parent",
+				Selectors:   []string{"a:1", "b:2"},
+				SPIREServer: "other",
+			},
+		},
+		{
+			desc: "unknown SPIRE server",
+			name: "new",
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:    "spiffe://domain.test/other",
+				ParentID:    "spiffe://domain.test/parent",
+				Selectors:   []string{"a:1"},
+				SPIREServer: "unknown",
+			},
+			expectErr: `invalid spireServer: unknown SPIRE Server "unknown"`,
+		},
+		{
+			desc: "updating itself",
+			name: "existing",
+			spec: existing.Spec,
+		},
+	} {
+		for _, v := range []*clusterStaticEntryValidator{indexedValidator, unindexedValidator} {
+			v := v
+			t.Run(fmt.Sprintf("%s (indexed=%t)", tt.desc, v.indexed), func(t *testing.T) {
+				v.allowedPathPrefixes = tt.allowedPathPrefixes
+				warnings, err := v.ValidateCreate(context.Background(), &ClusterStaticEntry{
+					ObjectMeta: metav1.ObjectMeta{Name: tt.name},
+					Spec:       tt.spec,
+				})
+				if tt.expectErr != "" {
+					require.EqualError(t, err, tt.expectErr)
+					return
+				}
+				require.NoError(t, err)
+				require.Len(t, warnings, tt.expectWarnings)
+			})
+		}
+	}
+}
+
+func TestClusterStaticEntryValidatorClasses(t *testing.T) {
+	spec := func(className, spireServer string) ClusterStaticEntrySpec {
+		return ClusterStaticEntrySpec{
+			SPIFFEID:    "spiffe://domain.test/workload",
+			ParentID:    "spiffe://domain.test/parent",
+			Selectors:   []string{"a:1", "b:2"},
+			ClassName:   className,
+			SPIREServer: spireServer,
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+	newValidator := func(watchClassless bool, existing ...ClusterStaticEntrySpec) *clusterStaticEntryValidator {
+		builder := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&ClusterStaticEntry{}, clusterStaticEntryIdentityField, indexClusterStaticEntryIdentity)
+		for i, spec := range existing {
+			builder = builder.WithObjects(&ClusterStaticEntry{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("existing-%d", i)},
+				Spec:       spec,
+			})
+		}
+		return &clusterStaticEntryValidator{
+			client:         builder.Build(),
+			indexed:        true,
+			className:      "mine",
+			watchClassless: watchClassless,
+			spireServers:   []string{"other"},
+		}
+	}
+
+	for _, tt := range []struct {
+		desc           string
+		watchClassless bool
+		existing       ClusterStaticEntrySpec
+		spec           ClusterStaticEntrySpec
+		expectErr      bool
+	}{
+		{
+			desc:      "same class",
+			existing:  spec("mine", ""),
+			spec:      spec("mine", ""),
+			expectErr: true,
+		},
+		{
+			desc:     "reconciled by another controller",
+			existing: spec("theirs", ""),
+			spec:     spec("mine", ""),
+		},
+		{
+			desc:     "registered with another SPIRE Server",
+			existing: spec("mine", "other"),
+			spec:     spec("mine", ""),
+		},
+		{
+			desc:           "classless entry reconciled by the same controller",
+			watchClassless: true,
+			existing:       spec("", ""),
+			spec:           spec("mine", ""),
+			expectErr:      true,
+		},
+		{
+			desc:     "classless entry not reconciled by the same controller",
+			existing: spec("", ""),
+			spec:     spec("mine", ""),
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			v := newValidator(tt.watchClassless, tt.existing)
+			_, err := v.ValidateCreate(context.Background(), &ClusterStaticEntry{
+				ObjectMeta: metav1.ObjectMeta{Name: "new"},
+				Spec:       tt.spec,
+			})
+			if tt.expectErr {
+				require.EqualError(t, err, `ClusterStaticEntry "existing-0" already declares an entry with the same SPIFFE ID, parent ID, and selectors`)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
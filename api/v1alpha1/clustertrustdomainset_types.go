@@ -0,0 +1,54 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterTrustDomainSetSpec defines the desired state of ClusterTrustDomainSet
+type ClusterTrustDomainSetSpec struct {
+	// TrustDomains is the list of trust domain names in the set.
+	TrustDomains []string `json:"trustDomains"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Trust Domains",type=string,JSONPath=`.spec.trustDomains`
+
+// ClusterTrustDomainSet is the Schema for the clustertrustdomainsets API. It
+// names a group of trust domains that ClusterSPIFFEIDs can federate with by
+// referencing the set in federatesWithSets.
+type ClusterTrustDomainSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterTrustDomainSetSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterTrustDomainSetList contains a list of ClusterTrustDomainSet
+type ClusterTrustDomainSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterTrustDomainSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterTrustDomainSet{}, &ClusterTrustDomainSetList{})
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var clustertrustdomainsetlog = logf.Log.WithName("clustertrustdomainset-resource")
+
+func (r *ClusterTrustDomainSet) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-spire-spiffe-io-v1alpha1-clustertrustdomainset,mutating=false,failurePolicy=fail,sideEffects=None,groups=spire.spiffe.io,resources=clustertrustdomainsets,verbs=create;update,versions=v1alpha1,name=vclustertrustdomainset.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ClusterTrustDomainSet{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterTrustDomainSet) ValidateCreate() (admission.Warnings, error) {
+	clustertrustdomainsetlog.Info("validate create", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterTrustDomainSet) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	clustertrustdomainsetlog.Info("validate update", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterTrustDomainSet) ValidateDelete() (admission.Warnings, error) {
+	// Deletes are not validated.
+	return nil, nil
+}
+
+func (r *ClusterTrustDomainSet) validate() (admission.Warnings, error) {
+	_, err := ParseClusterTrustDomainSetSpec(&r.Spec)
+	return nil, err
+}
+
+// ParseClusterTrustDomainSetSpec parses and validates the trust domains in
+// the ClusterTrustDomainSetSpec.
+func ParseClusterTrustDomainSetSpec(spec *ClusterTrustDomainSetSpec) ([]spiffeid.TrustDomain, error) {
+	if len(spec.TrustDomains) == 0 {
+		return nil, errors.New("invalid trustDomains value: at least one trust domain is required")
+	}
+	trustDomains := make([]spiffeid.TrustDomain, 0, len(spec.TrustDomains))
+	for _, value := range spec.TrustDomains {
+		td, err := spiffeid.TrustDomainFromString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trustDomains value: %w", err)
+		}
+		trustDomains = append(trustDomains, td)
+	}
+	return trustDomains, nil
+}
@@ -0,0 +1,47 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClusterTrustDomainSetSpec(t *testing.T) {
+	for _, tt := range []struct {
+		desc         string
+		trustDomains []string
+		expectErr    string
+		expectTDs    []spiffeid.TrustDomain
+	}{
+		{
+			desc:         "valid",
+			trustDomains: []string{"partner1.test", "partner2.test"},
+			expectTDs: []spiffeid.TrustDomain{
+				spiffeid.RequireTrustDomainFromString("partner1.test"),
+				spiffeid.RequireTrustDomainFromString("partner2.test"),
+			},
+		},
+		{
+			desc:      "empty",
+			expectErr: "invalid trustDomains value: at least one trust domain is required",
+		},
+		{
+			desc:         "invalid trust domain",
+			trustDomains: []string{"partner1.test", "Partner2"},
+			expectErr:    "invalid trustDomains value: trust domain characters are limited to lowercase letters, numbers, dots, dashes, and underscores",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			trustDomains, err := ParseClusterTrustDomainSetSpec(&ClusterTrustDomainSetSpec{
+				TrustDomains: tt.trustDomains,
+			})
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectTDs, trustDomains)
+		})
+	}
+}
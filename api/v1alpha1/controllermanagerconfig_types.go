@@ -19,6 +19,8 @@ package v1alpha1
 import (
 	"time"
 
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	configv1alpha1 "k8s.io/component-base/config/v1alpha1"
 )
@@ -44,6 +46,95 @@ type ControllerManagerConfig struct {
 	// IgnoreNamespaces are the namespaces to ignore
 	IgnoreNamespaces []string `json:"ignoreNamespaces"`
 
+	// NamespaceSelector, if set, also ignores the namespaces whose labels do
+	// not match the selector, so that namespaces can opt in (e.g. with
+	// spire.spiffe.io/enabled=true) or out without changing the controller
+	// configuration.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// PodExcludeSelector, if set, excludes the pods whose labels match from
+	// identity issuance, regardless of the ClusterSPIFFEIDs and
+	// NamespacedSPIFFEIDs that select them, e.g. CI runner pods. Changes to
+	// excluded pods do not trigger reconciliation.
+	// +optional
+	PodExcludeSelector *metav1.LabelSelector `json:"podExcludeSelector,omitempty"`
+
+	// IgnoredNamespaceEntryPolicy determines what happens to existing
+	// entries for pods in namespaces that match IgnoreNamespaces, e.g. after
+	// a namespace is added to IgnoreNamespaces. Defaults to Delete.
+	IgnoredNamespaceEntryPolicy IgnoredNamespaceEntryPolicy `json:"ignoredNamespaceEntryPolicy,omitempty"`
+
+	// TerminatingPodEntryGracePeriod, if set, keeps the entries for
+	// terminating pods for the given period after the pod has been removed,
+	// so that draining workloads keep their identity until they are actually
+	// gone. If unset, entries are deleted as soon as the pod is removed.
+	// +optional
+	TerminatingPodEntryGracePeriod metav1.Duration `json:"terminatingPodEntryGracePeriod,omitempty"`
+
+	// EntryDeletionGracePeriod, if set, keeps entries that are no longer
+	// declared by any pod or custom resource for the given period before
+	// deleting them, so that workloads are not briefly left without an
+	// identity while their pods are rescheduled. If unset, entries are
+	// deleted as soon as they are no longer declared.
+	// +optional
+	EntryDeletionGracePeriod metav1.Duration `json:"entryDeletionGracePeriod,omitempty"`
+
+	// PodEntryDeletionPolicy determines when the entries of removed pods are
+	// deleted. Immediate, the default, deletes them as soon as the removal
+	// is observed, with reconciliation as a backstop. Reconcile leaves them
+	// to the reconciliation triggered by the removal, which batches them
+	// with other changes. Entries are never deleted immediately when
+	// TerminatingPodEntryGracePeriod or EntryDeletionGracePeriod is set.
+	// +optional
+	PodEntryDeletionPolicy PodEntryDeletionPolicy `json:"podEntryDeletionPolicy,omitempty"`
+
+	// PodEntryCreationPhase is the phase a pod must reach before entries are
+	// created for it. One of Pending or Running. Defaults to Pending, i.e.
+	// entries are created as soon as the pod is scheduled. With Running,
+	// pods that are never scheduled or fail to start do not cause entry
+	// churn. Pods annotated with spire.spiffe.io/init-identity: "true" get
+	// entries while Pending regardless, so that their init containers can
+	// obtain an identity.
+	// +optional
+	PodEntryCreationPhase corev1.PodPhase `json:"podEntryCreationPhase,omitempty"`
+
+	// AllowedPathPrefixes, if set, restricts the SPIFFE IDs declared by
+	// ClusterSPIFFEIDs and ClusterStaticEntries to paths under one of the
+	// prefixes. Prefixes match whole path segments. Violations are rejected
+	// at admission and the offending entries are not rendered.
+	// +optional
+	AllowedPathPrefixes []string `json:"allowedPathPrefixes,omitempty"`
+
+	// PrivilegedEntries, if set, restricts which ClusterSPIFFEIDs and
+	// ClusterStaticEntries may declare admin or downstream entries.
+	// Violations are rejected at admission and the offending entries are
+	// not rendered.
+	// +optional
+	PrivilegedEntries *PrivilegedEntriesConfig `json:"privilegedEntries,omitempty"`
+
+	// ClusterSPIFFEIDDefaults, if set, are the defaults applied at admission
+	// to the spire.spiffe.io/v1alpha2 ClusterSPIFFEIDs that leave the fields
+	// unset. ClusterSPIFFEIDs created or updated through v1alpha1 are not
+	// defaulted.
+	// +optional
+	ClusterSPIFFEIDDefaults *ClusterSPIFFEIDDefaultsConfig `json:"clusterSPIFFEIDDefaults,omitempty"`
+
+	// ClusterStaticEntryDefaults, if set, are the defaults applied at
+	// admission to the spire.spiffe.io/v1alpha2 ClusterStaticEntries that
+	// leave the fields unset. ClusterStaticEntries created or updated
+	// through v1alpha1 are not defaulted.
+	// +optional
+	ClusterStaticEntryDefaults *ClusterStaticEntryDefaultsConfig `json:"clusterStaticEntryDefaults,omitempty"`
+
+	// ClusterFederatedTrustDomainDefaults, if set, are the defaults applied
+	// at admission to the spire.spiffe.io/v1alpha2
+	// ClusterFederatedTrustDomains that leave the fields unset.
+	// ClusterFederatedTrustDomains created or updated through v1alpha1 are
+	// not defaulted.
+	// +optional
+	ClusterFederatedTrustDomainDefaults *ClusterFederatedTrustDomainDefaultsConfig `json:"clusterFederatedTrustDomainDefaults,omitempty"`
+
 	// ValidatingWebhookConfigurationName selects the webhook configuration to manage.
 	// Defaults to spire-controller-manager-webhook.
 	ValidatingWebhookConfigurationName string `json:"validatingWebhookConfigurationName"`
@@ -54,8 +145,865 @@ type ControllerManagerConfig struct {
 	// the controller.
 	GCInterval time.Duration `json:"gcInterval"`
 
+	// EntryGCInterval, if set, overrides GCInterval for the entry
+	// reconcilers. Pods come and go far more often than federation
+	// relationships change, so the entry reconcilers can be run more
+	// frequently without also hammering the SPIRE Server trust domain API.
+	// +optional
+	EntryGCInterval metav1.Duration `json:"entryGCInterval,omitempty"`
+
+	// FederationRelationshipGCInterval, if set, overrides GCInterval for the
+	// federation relationship reconcilers.
+	// +optional
+	FederationRelationshipGCInterval metav1.Duration `json:"federationRelationshipGCInterval,omitempty"`
+
+	// EntryReconcileBatchWindow, if set, is how long the entry reconciler
+	// waits after a ClusterSPIFFEID, ClusterStaticEntry, or pod change
+	// before reconciling. Changes arriving within the window are reconciled
+	// together, which reduces SPIRE Server load when many resources change
+	// at once (e.g. during a GitOps sync), at the cost of delaying
+	// reconciliation by up to the window. Status updates are not batched.
+	// +optional
+	EntryReconcileBatchWindow metav1.Duration `json:"entryReconcileBatchWindow,omitempty"`
+
+	// EntryRenderWorkers, if greater than one, is how many pods the entry
+	// reconciler renders entries for concurrently. On clusters with many
+	// pods, rendering dominates the reconcile latency. Defaults to one.
+	// +optional
+	EntryRenderWorkers int `json:"entryRenderWorkers,omitempty"`
+
+	// ObjectSelector, if set, restricts the ClusterSPIFFEIDs,
+	// NamespacedSPIFFEIDs, ClusterStaticEntries and
+	// ClusterFederatedTrustDomains reconciled by the controller to those
+	// whose labels match the selector. Objects that do not match are
+	// ignored, as if they did not exist, so that the objects can be
+	// partitioned between controller instances that manage different SPIRE
+	// Servers. ClusterTrustDomainSets are not restricted since they are
+	// referenced by name.
+	// +optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+
+	// ClassName, if set, restricts the ClusterSPIFFEIDs,
+	// NamespacedSPIFFEIDs, ClusterStaticEntries and
+	// ClusterFederatedTrustDomains reconciled by the controller to those
+	// whose spec.className matches, so that multiple controller instances
+	// can run in one cluster against different SPIRE Servers. If unset, only
+	// the objects without a class are reconciled.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// WatchClassless, if true, also reconciles the objects without a class
+	// when ClassName is set.
+	// +optional
+	WatchClassless bool `json:"watchClassless,omitempty"`
+
 	// SPIREServerSocketPath is the path to the SPIRE Server API socket
 	SPIREServerSocketPath string `json:"spireServerSocketPath"`
+
+	// SPIREServerNamedPipeName, if set, is the name of the SPIRE Server API
+	// named pipe, relative to \\.\pipe, e.g. \spire-server\private\api.
+	// Only supported on Windows, where it defaults to
+	// \spire-server\private\api. Mutually exclusive with
+	// SPIREServerSocketPath.
+	// +optional
+	SPIREServerNamedPipeName string `json:"spireServerNamedPipeName,omitempty"`
+
+	// SPIREServerAddress, if set, is the TCP address (host:port) of a remote
+	// SPIRE Server API, dialed using mTLS as configured by SPIREServerTLS.
+	// Mutually exclusive with SPIREServerSocketPath and
+	// SPIREServerNamedPipeName.
+	// +optional
+	SPIREServerAddress string `json:"spireServerAddress,omitempty"`
+
+	// SPIREServerTLS configures the credentials used to dial
+	// SPIREServerAddress.
+	// +optional
+	SPIREServerTLS *SPIREServerTLSConfig `json:"spireServerTLS,omitempty"`
+
+	// RemoteClusters, if set, enables multi-cluster mode. The pods of each
+	// remote cluster are registered in SPIRE Server alongside those of the
+	// local cluster, with the parent IDs of the agents in the remote
+	// cluster, as declared by the ClusterSPIFFEIDs in the remote cluster.
+	// +optional
+	RemoteClusters []RemoteClusterConfig `json:"remoteClusters,omitempty"`
+
+	// SPIREServers are additional SPIRE Server targets, e.g. for other
+	// trust domains, that ClusterSPIFFEIDs, ClusterStaticEntries and
+	// ClusterFederatedTrustDomains select by name with spec.spireServer.
+	// Objects that do not select a target are reconciled against the
+	// default SPIRE Server, configured by SPIREServerSocketPath or
+	// SPIREServerAddress.
+	// +optional
+	SPIREServers []SPIREServerTarget `json:"spireServers,omitempty"`
+
+	// TrustBundleNotification, if set, signals trust bundle rotations by
+	// annotating selected namespaces and ConfigMaps with a revision counter.
+	// +optional
+	TrustBundleNotification *TrustBundleNotificationConfig `json:"trustBundleNotification,omitempty"`
+
+	// BundlePublisher, if set, publishes the trust bundle, and optionally
+	// the webhook CA, to a ConfigMap or Secret in selected namespaces.
+	// +optional
+	BundlePublisher *BundlePublisherConfig `json:"bundlePublisher,omitempty"`
+
+	// IdentityReport, if set, reports the number of identities by
+	// namespace, service account, and ClusterSPIFFEID.
+	// +optional
+	IdentityReport *IdentityReportConfig `json:"identityReport,omitempty"`
+
+	// EntryLifecycleHook, if set, notifies an external system after entries
+	// are created or deleted.
+	// +optional
+	EntryLifecycleHook *EntryLifecycleHookConfig `json:"entryLifecycleHook,omitempty"`
+
+	// EntryTransformer, if set, passes the rendered entries through an
+	// external transformer that may adjust their DNS names, selectors, and
+	// TTLs before they are applied.
+	// +optional
+	EntryTransformer *EntryTransformerConfig `json:"entryTransformer,omitempty"`
+
+	// FederatedBundleGC, if set, deletes the federated bundle of a trust
+	// domain from SPIRE Server when its federation relationship is deleted
+	// (i.e. its ClusterFederatedTrustDomain is removed), so that the keys of
+	// a terminated federation are no longer trusted.
+	// +optional
+	FederatedBundleGC *FederatedBundleGCConfig `json:"federatedBundleGC,omitempty"`
+
+	// BundleEndpointProbe, if set, periodically fetches the bundle from the
+	// bundle endpoint of each ClusterFederatedTrustDomain and reports
+	// whether it is reachable in the ClusterFederatedTrustDomain status.
+	// +optional
+	BundleEndpointProbe *BundleEndpointProbeConfig `json:"bundleEndpointProbe,omitempty"`
+
+	// AgentGC, if set, deletes or bans the SPIRE agents of the nodes that
+	// have been removed from the cluster.
+	// +optional
+	AgentGC *AgentGCConfig `json:"agentGC,omitempty"`
+
+	// WebhookSelfSignedCA, if set, signs the webhook serving certificate
+	// with a long-lived self-signed CA maintained by the controller instead
+	// of minting it from SPIRE Server. The CABundle of the webhook
+	// configuration is then the CA certificate instead of the SPIRE trust
+	// bundle, so admission keeps working while SPIRE Server is unreachable
+	// (e.g. during outages or upgrades).
+	// +optional
+	WebhookSelfSignedCA *WebhookSelfSignedCAConfig `json:"webhookSelfSignedCA,omitempty"`
+
+	// WebhookCertProvider determines where the webhook serving certificate
+	// comes from. Spire, the default, mints it from SPIRE Server, or from
+	// the self-signed CA if configured, and keeps the CABundle of the
+	// webhook configuration up to date. External loads it from
+	// webhook.certDir (e.g. a mounted cert-manager Secret), reloads it when
+	// it is rotated, and leaves the CABundle to the external provider.
+	// +optional
+	WebhookCertProvider WebhookCertProvider `json:"webhookCertProvider,omitempty"`
+
+	// WebhookCABundleTargets are additional validating and mutating webhook
+	// configurations whose CABundle is kept in sync with that of the
+	// controller manager webhook, so that other webhooks serving
+	// SPIRE-minted certificates can reuse the same rotation.
+	// +optional
+	WebhookCABundleTargets []WebhookCABundleTarget `json:"webhookCABundleTargets,omitempty"`
+
+	// WebhookSettings, if set, declaratively manages the failure policy,
+	// selectors and rules of the webhooks in the validating webhook
+	// configuration. Drift, such as the failure policy being flipped to
+	// Ignore, is reverted. Settings that are not set are left as is.
+	// +optional
+	WebhookSettings *WebhookSettingsConfig `json:"webhookSettings,omitempty"`
+
+	// WebhookSVIDTTL is the lifetime requested for the webhook serving
+	// certificate. SPIRE Server may cap it. Defaults to 24 hours.
+	// +optional
+	WebhookSVIDTTL metav1.Duration `json:"webhookSVIDTTL,omitempty"`
+
+	// WebhookRotationThreshold, if set, rotates the webhook serving
+	// certificate once it expires within the threshold. If unset, or not
+	// shorter than the certificate lifetime, the certificate is rotated
+	// once a fraction of its lifetime remains.
+	// +optional
+	WebhookRotationThreshold metav1.Duration `json:"webhookRotationThreshold,omitempty"`
+
+	// SecureMetrics, if set, serves the metrics on metrics.bindAddress over
+	// TLS, and by default only to clients authorized with a
+	// SubjectAccessReview, instead of over plaintext to anyone.
+	// +optional
+	SecureMetrics *SecureMetricsConfig `json:"secureMetrics,omitempty"`
+
+	// CRDAvailabilityPolicy determines what happens at startup when custom
+	// resource definitions are not installed. Wait sets up the controllers
+	// whose CRDs are installed and the others as their CRDs are installed,
+	// but is not ready until all of them are. Fail exits. Partial, the
+	// default, is like Wait without affecting readiness.
+	// +optional
+	CRDAvailabilityPolicy CRDAvailabilityPolicy `json:"crdAvailabilityPolicy,omitempty"`
+
+	// NamespacedSPIFFEIDs, if set, enables the NamespacedSPIFFEID CRD, which
+	// lets workloads be registered by users with access to a single
+	// namespace. The NamespacedSPIFFEID CRD must be installed when enabled.
+	// +optional
+	NamespacedSPIFFEIDs *NamespacedSPIFFEIDsConfig `json:"namespacedSPIFFEIDs,omitempty"`
+
+	// PodSPIFFEIDAnnotation, if set, lets pods override the path of their
+	// SPIFFE ID with the spire.spiffe.io/spiffe-id annotation.
+	// +optional
+	PodSPIFFEIDAnnotation *PodSPIFFEIDAnnotationConfig `json:"podSPIFFEIDAnnotation,omitempty"`
+
+	// DesiredStateSnapshot, if set, persists the entries rendered for pods
+	// so that a restarted controller does not have to render them again.
+	// The controller still waits for its caches to sync before reconciling.
+	// +optional
+	DesiredStateSnapshot *DesiredStateSnapshotConfig `json:"desiredStateSnapshot,omitempty"`
+
+	// EntryCache, if set, caches the entries on SPIRE Server between
+	// reconciles instead of listing them in full on every reconcile.
+	// +optional
+	EntryCache *EntryCacheConfig `json:"entryCache,omitempty"`
+
+	// EntryAPI, if set, tunes the batch sizes, concurrency, and rate of the
+	// SPIRE Server entry API calls.
+	// +optional
+	EntryAPI *EntryAPIConfig `json:"entryAPI,omitempty"`
+
+	// SPIREAPIRetry, if set, retries SPIRE API calls that fail because SPIRE
+	// Server is unavailable, e.g. while it restarts.
+	// +optional
+	SPIREAPIRetry *SPIREAPIRetryConfig `json:"spireAPIRetry,omitempty"`
+
+	// SPIREHealthCheck, if set, adds checks of the SPIRE Server connection
+	// to the health and ready checks, so that a manager that has lost its
+	// connection is restarted or taken out of service.
+	// +optional
+	SPIREHealthCheck *SPIREHealthCheckConfig `json:"spireHealthCheck,omitempty"`
+
+	// DebugServer, if set, serves pprof profiles and expvar variables, e.g.
+	// to capture heap and goroutine profiles of a stuck or bloated
+	// controller manager. It is off by default.
+	// +optional
+	DebugServer *DebugServerConfig `json:"debugServer,omitempty"`
+
+	// DryRun, if true, computes and logs the changes the entry and
+	// federation relationship reconcilers would make to SPIRE Server
+	// without making them.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// LogLevel is the log level, i.e. one of debug, info, or error, or an
+	// integer greater than zero for increasingly verbose debug logging.
+	// The --zap-log-level flag, if given, takes precedence. Defaults to
+	// debug.
+	// +optional
+	LogLevel string `json:"logLevel,omitempty"`
+}
+
+// PodSPIFFEIDAnnotationConfig configures the pod SPIFFE ID annotation.
+type PodSPIFFEIDAnnotationConfig struct {
+	// PathPrefixTemplate is the template for the path prefix that the SPIFFE
+	// ID paths pods are annotated with must be under. The namespace of the
+	// pod is available to the template under .Namespace. Defaults to
+	// "/ns/{{ .Namespace }}".
+	// +optional
+	PathPrefixTemplate string `json:"pathPrefixTemplate,omitempty"`
+}
+
+// DesiredStateSnapshotConfig configures the desired state snapshot.
+type DesiredStateSnapshotConfig struct {
+	// Path is the file the snapshot is written to, typically on a
+	// PersistentVolume. The directory must exist and be writable.
+	Path string `json:"path"`
+}
+
+// EntryCacheConfig configures the entry cache.
+type EntryCacheConfig struct {
+	// ResyncInterval is how often the entries are listed in full to pick up
+	// changes made to the entries by others. Defaults to 10m.
+	// +optional
+	ResyncInterval metav1.Duration `json:"resyncInterval,omitempty"`
+}
+
+// EntryAPIConfig tunes the SPIRE Server entry API calls so that bursts of
+// entry changes do not overload SPIRE Server.
+type EntryAPIConfig struct {
+	// CreateBatchSize is how many entries are created per batch call.
+	// Defaults to 50.
+	// +optional
+	CreateBatchSize int `json:"createBatchSize,omitempty"`
+
+	// UpdateBatchSize is how many entries are updated per batch call.
+	// Defaults to 50.
+	// +optional
+	UpdateBatchSize int `json:"updateBatchSize,omitempty"`
+
+	// DeleteBatchSize is how many entries are deleted per batch call.
+	// Defaults to 200.
+	// +optional
+	DeleteBatchSize int `json:"deleteBatchSize,omitempty"`
+
+	// MaxConcurrentBatches is how many batch calls are in flight at once.
+	// Defaults to 1.
+	// +optional
+	MaxConcurrentBatches int `json:"maxConcurrentBatches,omitempty"`
+
+	// CallsPerSecond, if set, limits the rate of entry API calls. Calls
+	// over the limit are delayed.
+	// +optional
+	CallsPerSecond int `json:"callsPerSecond,omitempty"`
+
+	// Burst is how many calls may exceed CallsPerSecond at once. Defaults
+	// to 1.
+	// +optional
+	Burst int `json:"burst,omitempty"`
+}
+
+// SPIREAPIRetryConfig configures retries of SPIRE API calls.
+type SPIREAPIRetryConfig struct {
+	// SPIREAPIRetryPolicy is the policy for calls without a policy of their
+	// own.
+	SPIREAPIRetryPolicy `json:",inline"`
+
+	// Operations holds the policies for specific calls, by gRPC method name
+	// (e.g. BatchCreateEntry). Unset fields default to those of the
+	// default policy.
+	// +optional
+	Operations map[string]SPIREAPIRetryPolicy `json:"operations,omitempty"`
+}
+
+// SPIREAPIRetryPolicy is how failed SPIRE API calls are retried.
+type SPIREAPIRetryPolicy struct {
+	// MaxAttempts is how many times a call is attempted, including the
+	// first attempt. Defaults to 5. Set it to 1 to disable retries.
+	// +optional
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+
+	// InitialBackoff is how long to wait before the first retry. The wait
+	// doubles, with jitter, on each retry. Defaults to 100ms.
+	// +optional
+	InitialBackoff metav1.Duration `json:"initialBackoff,omitempty"`
+
+	// MaxBackoff caps the wait between retries, and between attempts to
+	// reconnect to SPIRE Server. Defaults to 5s.
+	// +optional
+	MaxBackoff metav1.Duration `json:"maxBackoff,omitempty"`
+}
+
+// SPIREHealthCheckConfig configures the checks of the SPIRE Server
+// connection.
+type SPIREHealthCheckConfig struct {
+	// Timeout is how long each check waits for SPIRE Server. Defaults to
+	// 5s.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// LivenessFailureThreshold is how many consecutive checks must fail
+	// before the health check fails, and the manager is restarted.
+	// Defaults to 3.
+	// +optional
+	LivenessFailureThreshold int `json:"livenessFailureThreshold,omitempty"`
+
+	// ReadinessFailureThreshold is how many consecutive checks must fail
+	// before the ready check fails. Defaults to 1.
+	// +optional
+	ReadinessFailureThreshold int `json:"readinessFailureThreshold,omitempty"`
+}
+
+// DebugServerConfig configures the debug server.
+type DebugServerConfig struct {
+	// BindAddress is the address the debug server listens on, e.g.
+	// 127.0.0.1:6060.
+	BindAddress string `json:"bindAddress"`
+
+	// TLS, if set, serves the debug endpoints over mutual TLS.
+	// +optional
+	TLS *DebugServerTLSConfig `json:"tls,omitempty"`
+
+	// Explain, if true, also serves the explain endpoint. It requires TLS
+	// or a loopback bind address.
+	// +optional
+	Explain bool `json:"explain,omitempty"`
+}
+
+// DebugServerTLSConfig configures mutual TLS for the debug server. The files
+// are loaded for each connection, so they can be rotated without a restart.
+type DebugServerTLSConfig struct {
+	// CertFile is the path to the PEM encoded serving certificate.
+	CertFile string `json:"certFile"`
+
+	// KeyFile is the path to the PEM encoded private key of the serving
+	// certificate.
+	KeyFile string `json:"keyFile"`
+
+	// ClientCAFile is the path to the PEM encoded CAs that client
+	// certificates must be signed by.
+	ClientCAFile string `json:"clientCAFile"`
+}
+
+// NamespacedSPIFFEIDsConfig configures NamespacedSPIFFEIDs.
+type NamespacedSPIFFEIDsConfig struct {
+	// PathPrefixTemplate is the template for the path prefix that the SPIFFE
+	// IDs declared by a NamespacedSPIFFEID must be under. The namespace of
+	// the NamespacedSPIFFEID is available to the template under .Namespace.
+	// Defaults to "/ns/{{ .Namespace }}".
+	// +optional
+	PathPrefixTemplate string `json:"pathPrefixTemplate,omitempty"`
+}
+
+// WebhookSelfSignedCAConfig configures the self-signed webhook CA.
+type WebhookSelfSignedCAConfig struct {
+	// Secret is the Secret the CA certificate and private key are stored
+	// in. It is created if it does not exist.
+	Secret SecretReference `json:"secret"`
+}
+
+// SecureMetricsConfig configures serving the metrics securely.
+type SecureMetricsConfig struct {
+	// CertFile and KeyFile, if set, are the certificate and private key the
+	// metrics are served with. They are reloaded when rotated. If unset,
+	// the webhook serving certificate is used.
+	// +optional
+	CertFile string `json:"certFile,omitempty"`
+
+	// +optional
+	KeyFile string `json:"keyFile,omitempty"`
+
+	// SkipAuthorization, if true, serves the metrics to any client. By
+	// default, clients must present a bearer token that is authenticated
+	// with a TokenReview and allowed to get the metrics path (e.g. /metrics)
+	// by a SubjectAccessReview.
+	// +optional
+	SkipAuthorization bool `json:"skipAuthorization,omitempty"`
+
+	// Explain, if true, serves the explain endpoint alongside the metrics.
+	// It requires authorization.
+	// +optional
+	Explain bool `json:"explain,omitempty"`
+}
+
+// BundleEndpointProbeConfig configures the probing of bundle endpoints.
+type BundleEndpointProbeConfig struct {
+	// Interval is how often the bundle endpoints are probed. Defaults to
+	// 5m.
+	// +optional
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// Timeout is how long each probe waits for the bundle endpoint.
+	// Defaults to 10s.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// FederatedBundleGCConfig configures the deletion of federated bundles.
+type FederatedBundleGCConfig struct {
+	// KeepTrustDomains are the trust domains whose federated bundles are
+	// kept when their federation relationship is deleted.
+	// +optional
+	KeepTrustDomains []string `json:"keepTrustDomains,omitempty"`
+}
+
+// AgentGCConfig configures the garbage collection of the SPIRE agents of
+// removed nodes.
+type AgentGCConfig struct {
+	// Delay is how long a node must have been removed before its agent is
+	// collected. Defaults to 0 (i.e. the agent is collected on the next
+	// reconciliation).
+	// +optional
+	Delay metav1.Duration `json:"delay,omitempty"`
+
+	// Ban, if true, bans the agents instead of deleting them, so that they
+	// cannot attest again.
+	// +optional
+	Ban bool `json:"ban,omitempty"`
+
+	// DryRun, if true, logs the agents that would be collected instead of
+	// collecting them.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// PrivilegedEntriesConfig names the objects that may declare admin or
+// downstream entries, i.e. entries for workloads that can access the SPIRE
+// Server administrative APIs or that are downstream SPIRE Servers.
+type PrivilegedEntriesConfig struct {
+	// ClusterSPIFFEIDs are the names of the ClusterSPIFFEIDs that may set
+	// admin or downstream.
+	// +optional
+	ClusterSPIFFEIDs []string `json:"clusterSPIFFEIDs,omitempty"`
+
+	// ClusterStaticEntries are the names of the ClusterStaticEntries that
+	// may set admin or downstream.
+	// +optional
+	ClusterStaticEntries []string `json:"clusterStaticEntries,omitempty"`
+}
+
+// ClusterSPIFFEIDDefaultsConfig holds the defaults of the v1alpha2
+// ClusterSPIFFEID fields.
+type ClusterSPIFFEIDDefaultsConfig struct {
+	// SetClassName, if true, defaults className to the className of the
+	// controller manager. Only one controller manager instance sharing the
+	// cluster should set it.
+	// +optional
+	SetClassName bool `json:"setClassName,omitempty"`
+
+	// X509SVIDTTL is the default x509SVIDTTL.
+	// +optional
+	X509SVIDTTL metav1.Duration `json:"x509SVIDTTL,omitempty"`
+
+	// JWTSVIDTTL is the default jwtSVIDTTL.
+	// +optional
+	JWTSVIDTTL metav1.Duration `json:"jwtSVIDTTL,omitempty"`
+
+	// DNSNameTemplates are the default dnsNameTemplates.
+	// +optional
+	DNSNameTemplates []string `json:"dnsNameTemplates,omitempty"`
+}
+
+// ClusterStaticEntryDefaultsConfig holds the defaults of the v1alpha2
+// ClusterStaticEntry fields.
+type ClusterStaticEntryDefaultsConfig struct {
+	// SetClassName, if true, defaults className to the className of the
+	// controller manager. Only one controller manager instance sharing the
+	// cluster should set it.
+	// +optional
+	SetClassName bool `json:"setClassName,omitempty"`
+
+	// X509SVIDTTL is the default x509SVIDTTL.
+	// +optional
+	X509SVIDTTL metav1.Duration `json:"x509SVIDTTL,omitempty"`
+
+	// JWTSVIDTTL is the default jwtSVIDTTL.
+	// +optional
+	JWTSVIDTTL metav1.Duration `json:"jwtSVIDTTL,omitempty"`
+}
+
+// ClusterFederatedTrustDomainDefaultsConfig holds the defaults of the
+// v1alpha2 ClusterFederatedTrustDomain fields.
+type ClusterFederatedTrustDomainDefaultsConfig struct {
+	// SetClassName, if true, defaults className to the className of the
+	// controller manager. Only one controller manager instance sharing the
+	// cluster should set it.
+	// +optional
+	SetClassName bool `json:"setClassName,omitempty"`
+}
+
+// IdentityReportConfig configures the identity report. The report is always
+// exposed as metrics.
+type IdentityReportConfig struct {
+	// ConfigMap, if set, is the ConfigMap the report is written to as JSON.
+	// +optional
+	ConfigMap *ConfigMapReference `json:"configMap,omitempty"`
+}
+
+// EntryLifecycleHookConfig configures where entry lifecycle events are sent.
+// Exactly one of URL or Command must be set.
+type EntryLifecycleHookConfig struct {
+	// URL is the HTTP(S) endpoint the events are POSTed to as a JSON array.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Command is executed with the events written to its standard input as
+	// a JSON array. The first element is the program to run.
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Timeout bounds each delivery. Defaults to 10s.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// EntryTransformerConfig configures the external entry transformer. Exactly
+// one of URL or Command must be set.
+type EntryTransformerConfig struct {
+	// URL is the HTTP(S) endpoint the entries are POSTed to as a JSON
+	// array. The transformed entries are read from the response.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Command is executed with the entries written to its standard input as
+	// a JSON array. The transformed entries are read from its standard
+	// output. The first element is the program to run.
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Timeout bounds each transformation. Defaults to 10s.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// SPIREServerTLSConfig configures the mTLS credentials used to dial a remote
+// SPIRE Server. The client X509-SVID must be registered as an admin in the
+// SPIRE Server. The credentials come either from the Workload API or from
+// PEM files.
+type SPIREServerTLSConfig struct {
+	// ServerID is the expected SPIFFE ID of the SPIRE Server. Defaults to
+	// spiffe://<trustDomain>/spire/server.
+	// +optional
+	ServerID string `json:"serverID,omitempty"`
+
+	// WorkloadAPISocketPath is the path to the Workload API socket used to
+	// obtain the client X509-SVID and the trust bundle.
+	// +optional
+	WorkloadAPISocketPath string `json:"workloadAPISocketPath,omitempty"`
+
+	// CertFile is the path to the PEM encoded client X509-SVID and
+	// intermediates.
+	// +optional
+	CertFile string `json:"certFile,omitempty"`
+
+	// KeyFile is the path to the PEM encoded client X509-SVID private key.
+	// +optional
+	KeyFile string `json:"keyFile,omitempty"`
+
+	// BundleFile is the path to the PEM encoded X.509 authorities used to
+	// authenticate the SPIRE Server.
+	// +optional
+	BundleFile string `json:"bundleFile,omitempty"`
+}
+
+// SPIREServerTarget configures an additional SPIRE Server.
+type SPIREServerTarget struct {
+	// Name is the name objects select the target by.
+	Name string `json:"name"`
+
+	// TrustDomain is the trust domain of the SPIRE Server.
+	TrustDomain string `json:"trustDomain"`
+
+	// ClusterName is the cluster name the SPIRE Agents attest with to the
+	// SPIRE Server. Defaults to the cluster name of the controller manager.
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// SocketPath is the path to the SPIRE Server API socket.
+	// +optional
+	SocketPath string `json:"socketPath,omitempty"`
+
+	// NamedPipeName is the name of the SPIRE Server API named pipe, relative
+	// to \\.\pipe. Only supported on Windows.
+	// +optional
+	NamedPipeName string `json:"namedPipeName,omitempty"`
+
+	// Address is the TCP address (host:port) of a remote SPIRE Server API,
+	// dialed using mTLS as configured by TLS. Mutually exclusive with
+	// SocketPath and NamedPipeName.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// TLS configures the credentials used to dial Address.
+	// +optional
+	TLS *SPIREServerTLSConfig `json:"tls,omitempty"`
+}
+
+// RemoteClusterConfig configures access to a remote cluster.
+type RemoteClusterConfig struct {
+	// Name is the name of the remote cluster, i.e. the cluster name the
+	// SPIRE Agents in the remote cluster attest with.
+	Name string `json:"name"`
+
+	// ClusterDomain is the cluster domain of the remote cluster. Defaults to
+	// cluster.local.
+	// +optional
+	ClusterDomain string `json:"clusterDomain,omitempty"`
+
+	// KubeConfigPath is the path to the kubeconfig file used to access the
+	// remote cluster.
+	KubeConfigPath string `json:"kubeConfigPath"`
+}
+
+// TrustBundleNotificationConfig configures which objects are annotated when
+// the trust bundle rotates.
+type TrustBundleNotificationConfig struct {
+	// NamespaceSelector selects the namespaces to annotate. An empty
+	// selector matches all namespaces. If unset, no namespaces are annotated.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// ConfigMaps are the ConfigMaps to annotate.
+	// +optional
+	ConfigMaps []ConfigMapReference `json:"configMaps,omitempty"`
+}
+
+// BundlePublisherConfig configures where the trust bundle is published.
+type BundlePublisherConfig struct {
+	// Kind is the kind of object to publish to, either ConfigMap or Secret.
+	// Defaults to ConfigMap.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name is the name of the object published in each namespace.
+	Name string `json:"name"`
+
+	// NamespaceSelector selects the namespaces to publish to. An empty
+	// selector matches all namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Namespaces are additional namespaces to publish to.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// BundleKey is the key holding the PEM encoded X.509 authorities of the
+	// trust bundle. Defaults to "bundle.pem".
+	// +optional
+	BundleKey string `json:"bundleKey,omitempty"`
+
+	// IncludeWebhookCA, if true, also publishes the PEM encoded CA used to
+	// verify the webhook serving certificate under WebhookCAKey.
+	// +optional
+	IncludeWebhookCA bool `json:"includeWebhookCA,omitempty"`
+
+	// WebhookCAKey is the key holding the webhook CA. Defaults to
+	// "webhook-ca.pem".
+	// +optional
+	WebhookCAKey string `json:"webhookCAKey,omitempty"`
+
+	// IncludeJWKS, if true, also publishes the trust bundle in SPIFFE
+	// bundle (JWKS) format under JWKSKey, for consumers that validate
+	// JWT-SVIDs.
+	// +optional
+	IncludeJWKS bool `json:"includeJWKS,omitempty"`
+
+	// JWKSKey is the key holding the JWKS. Defaults to "bundle.jwks".
+	// +optional
+	JWKSKey string `json:"jwksKey,omitempty"`
+}
+
+// SecretReference references a Secret by namespace and name.
+type SecretReference struct {
+	// Namespace is the namespace of the Secret
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the Secret
+	Name string `json:"name"`
+}
+
+// ConfigMapReference references a ConfigMap by namespace and name.
+type ConfigMapReference struct {
+	// Namespace is the namespace of the ConfigMap
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the ConfigMap
+	Name string `json:"name"`
+}
+
+// IgnoredNamespaceEntryPolicy is the policy applied to existing entries for
+// pods in ignored namespaces.
+type IgnoredNamespaceEntryPolicy string
+
+const (
+	// IgnoredNamespaceEntryPolicyDelete deletes existing entries for pods in
+	// ignored namespaces.
+	IgnoredNamespaceEntryPolicyDelete IgnoredNamespaceEntryPolicy = "Delete"
+
+	// IgnoredNamespaceEntryPolicyRetain leaves existing entries for pods in
+	// ignored namespaces in place, without updating them, and logs a warning.
+	// New entries are not created for pods in ignored namespaces.
+	IgnoredNamespaceEntryPolicyRetain IgnoredNamespaceEntryPolicy = "Retain"
+)
+
+// PodEntryDeletionPolicy is when the entries of removed pods are deleted.
+type PodEntryDeletionPolicy string
+
+const (
+	// PodEntryDeletionPolicyImmediate deletes the entries of removed pods
+	// as soon as the removal is observed.
+	PodEntryDeletionPolicyImmediate PodEntryDeletionPolicy = "Immediate"
+
+	// PodEntryDeletionPolicyReconcile leaves the entries of removed pods to
+	// be deleted by the next reconciliation.
+	PodEntryDeletionPolicyReconcile PodEntryDeletionPolicy = "Reconcile"
+)
+
+// CRDAvailabilityPolicy is what the controller does at startup when custom
+// resource definitions are not installed.
+type CRDAvailabilityPolicy string
+
+const (
+	// CRDAvailabilityPolicyWait runs the controllers whose CRDs are
+	// installed and sets up the others once their CRDs are installed, but
+	// is not ready until all of them are.
+	CRDAvailabilityPolicyWait CRDAvailabilityPolicy = "wait"
+
+	// CRDAvailabilityPolicyFail exits if any CRD is not installed.
+	CRDAvailabilityPolicyFail CRDAvailabilityPolicy = "fail"
+
+	// CRDAvailabilityPolicyPartial runs the controllers whose CRDs are
+	// installed and sets up the others once their CRDs are installed,
+	// without affecting readiness.
+	CRDAvailabilityPolicyPartial CRDAvailabilityPolicy = "partial"
+)
+
+// WebhookCertProvider is where the webhook serving certificate comes from.
+type WebhookCertProvider string
+
+const (
+	// WebhookCertProviderSPIRE mints the webhook serving certificate from
+	// SPIRE Server, or from the self-signed CA if configured.
+	WebhookCertProviderSPIRE WebhookCertProvider = "spire"
+
+	// WebhookCertProviderExternal loads the webhook serving certificate
+	// from webhook.certDir, where it is provided by an external issuer such
+	// as cert-manager.
+	WebhookCertProviderExternal WebhookCertProvider = "external"
+)
+
+// WebhookCABundleTarget selects webhook configurations by name or label.
+type WebhookCABundleTarget struct {
+	// Kind is the kind of the webhook configurations, either
+	// ValidatingWebhookConfiguration or MutatingWebhookConfiguration.
+	Kind string `json:"kind"`
+
+	// Name, if set, selects the webhook configuration with this name.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Selector, if set, selects the webhook configurations whose labels
+	// match.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// WebhookSettingsConfig configures the settings enforced on the webhooks
+// of the validating webhook configuration.
+type WebhookSettingsConfig struct {
+	// FailurePolicy, if set, is enforced on every webhook, either Fail or
+	// Ignore.
+	// +optional
+	FailurePolicy *admissionregistrationv1.FailurePolicyType `json:"failurePolicy,omitempty"`
+
+	// NamespaceSelector, if set, is enforced on every webhook.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// ObjectSelector, if set, is enforced on every webhook.
+	// +optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+
+	// Webhooks override the settings above for the webhooks with the given
+	// names. Rules can only be set per webhook.
+	// +optional
+	Webhooks []WebhookSettingsOverride `json:"webhooks,omitempty"`
+}
+
+// WebhookSettingsOverride configures the settings enforced on a single
+// webhook of the validating webhook configuration.
+type WebhookSettingsOverride struct {
+	// Name is the name of the webhook, e.g. vclusterspiffeid.kb.io.
+	Name string `json:"name"`
+
+	// FailurePolicy, if set, is enforced on the webhook, either Fail or
+	// Ignore.
+	// +optional
+	FailurePolicy *admissionregistrationv1.FailurePolicyType `json:"failurePolicy,omitempty"`
+
+	// NamespaceSelector, if set, is enforced on the webhook.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// ObjectSelector, if set, is enforced on the webhook.
+	// +optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+
+	// Rules, if set, are enforced on the webhook.
+	// +optional
+	Rules []admissionregistrationv1.RuleWithOperations `json:"rules,omitempty"`
 }
 
 // ControllerManagerConfigurationSpec defines the desired state of GenericControllerManagerConfiguration.
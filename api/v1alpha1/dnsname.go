@@ -0,0 +1,131 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// ValidateDNSName returns an error if the name is not a valid DNS name, i.e.
+// a name of at most 253 characters made of dot separated labels of at most
+// 63 letters, digits and hyphens that neither begin nor end with a hyphen.
+// The first label may be a "*" wildcard.
+func ValidateDNSName(dnsName string) error {
+	switch {
+	case dnsName == "":
+		return errors.New("empty DNS name")
+	case len(dnsName) > 253:
+		return errors.New("DNS name must be no more than 253 characters")
+	}
+	for i, label := range strings.Split(dnsName, ".") {
+		if i == 0 && label == "*" {
+			continue
+		}
+		if err := validateDNSLabel(label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateDNSLabel(label string) error {
+	switch {
+	case label == "":
+		return errors.New("DNS name must not contain empty labels")
+	case len(label) > 63:
+		return fmt.Errorf("DNS label %q must be no more than 63 characters", label)
+	case label[0] == '-' || label[len(label)-1] == '-':
+		return fmt.Errorf("DNS label %q must not begin or end with a hyphen", label)
+	}
+	for _, r := range label {
+		if !isDNSLabelRune(r) {
+			return fmt.Errorf("DNS label %q must only contain letters, digits, and hyphens", label)
+		}
+	}
+	return nil
+}
+
+func isDNSLabelRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-'
+}
+
+// checkDNSNameTemplate returns an error if the template cannot render a valid
+// DNS name. The values of the template actions, e.g. the pod name, are only
+// known once the template is rendered for a pod, so only the literal text is
+// checked, unless the template has no actions, in which case the whole name
+// is. The rendered DNS names are validated again when the entries are
+// rendered.
+func checkDNSNameTemplate(tmpl *template.Template) error {
+	if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+		return nil
+	}
+	var literal strings.Builder
+	complete := true
+	if err := walkDNSNameTemplate(tmpl.Tree.Root, &literal, &complete); err != nil {
+		return err
+	}
+	if complete {
+		return ValidateDNSName(literal.String())
+	}
+	return nil
+}
+
+func walkDNSNameTemplate(list *parse.ListNode, literal *strings.Builder, complete *bool) error {
+	if list == nil {
+		return nil
+	}
+	for _, node := range list.Nodes {
+		switch node := node.(type) {
+		case *parse.TextNode:
+			for _, r := range string(node.Text) {
+				if !isDNSLabelRune(r) && r != '.' && r != '*' {
+					return fmt.Errorf("DNS name template text %q must only contain letters, digits, hyphens, dots, and wildcards", node.Text)
+				}
+			}
+			literal.Write(node.Text)
+		case *parse.IfNode:
+			*complete = false
+			if err := walkDNSNameTemplateBranch(&node.BranchNode, literal, complete); err != nil {
+				return err
+			}
+		case *parse.RangeNode:
+			*complete = false
+			if err := walkDNSNameTemplateBranch(&node.BranchNode, literal, complete); err != nil {
+				return err
+			}
+		case *parse.WithNode:
+			*complete = false
+			if err := walkDNSNameTemplateBranch(&node.BranchNode, literal, complete); err != nil {
+				return err
+			}
+		default:
+			*complete = false
+		}
+	}
+	return nil
+}
+
+func walkDNSNameTemplateBranch(branch *parse.BranchNode, literal *strings.Builder, complete *bool) error {
+	if err := walkDNSNameTemplate(branch.List, literal, complete); err != nil {
+		return err
+	}
+	return walkDNSNameTemplate(branch.ElseList, literal, complete)
+}
@@ -0,0 +1,77 @@
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDNSName(t *testing.T) {
+	for _, dnsName := range []string{
+		"example",
+		"web-0.web.default.svc.cluster.local",
+		"*.example.org",
+		"Web.Example",
+		strings.Repeat("a", 63) + ".example",
+	} {
+		require.NoError(t, ValidateDNSName(dnsName), dnsName)
+	}
+
+	for dnsName, expectErr := range map[string]string{
+		"":                                "empty DNS name",
+		strings.Repeat("a.", 127):         "DNS name must be no more than 253 characters",
+		"web..example":                    "DNS name must not contain empty labels",
+		"web.example.":                    "DNS name must not contain empty labels",
+		strings.Repeat("a", 64) + ".test": `DNS label "` + strings.Repeat("a", 64) + `" must be no more than 63 characters`,
+		"-web.example":                    `DNS label "-web" must not begin or end with a hyphen`,
+		"web_0.example":                   `DNS label "web_0" must only contain letters, digits, and hyphens`,
+		"web.*.example":                   `DNS label "*" must only contain letters, digits, and hyphens`,
+	} {
+		require.EqualError(t, ValidateDNSName(dnsName), expectErr, dnsName)
+	}
+}
+
+func TestParseClusterSPIFFEIDSpecDNSNameTemplates(t *testing.T) {
+	for _, tt := range []struct {
+		desc             string
+		dnsNameTemplates []string
+		expectErr        string
+	}{
+		{
+			desc: "valid",
+			dnsNameTemplates: []string{
+				"{{ .PodMeta.Name }}.{{ .PodMeta.Namespace }}.svc.{{ .ClusterDomain }}",
+				`{{ if .PodSpec.Subdomain }}{{ .PodSpec.Hostname }}.{{ .PodSpec.Subdomain }}{{ else }}{{ .PodMeta.Name }}{{ end }}.example`,
+				"*.example.org",
+			},
+		},
+		{
+			desc:             "invalid literal name",
+			dnsNameTemplates: []string{"web..example"},
+			expectErr:        `invalid dnsNameTemplate value "web..example": DNS name must not contain empty labels`,
+		},
+		{
+			desc:             "invalid literal text",
+			dnsNameTemplates: []string{"{{ .PodMeta.Name }}_web.example"},
+			expectErr:        `invalid dnsNameTemplate value "{{ .PodMeta.Name }}_web.example": DNS name template text "_web.example" must only contain letters, digits, hyphens, dots, and wildcards`,
+		},
+		{
+			desc:             "invalid literal text in a branch",
+			dnsNameTemplates: []string{"{{ if .PodSpec.Hostname }}host name{{ end }}.example"},
+			expectErr:        `invalid dnsNameTemplate value "{{ if .PodSpec.Hostname }}host name{{ end }}.example": DNS name template text "host name" must only contain letters, digits, hyphens, dots, and wildcards`,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := ParseClusterSPIFFEIDSpec(&ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://domain.test/workload",
+				DNSNameTemplates: tt.dnsNameTemplates,
+			})
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
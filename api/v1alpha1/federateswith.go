@@ -0,0 +1,67 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// federatesWithWarnings returns a warning naming the trust domains federated
+// with that have no ClusterFederatedTrustDomain setting a federation
+// relationship on the same SPIRE Server. SPIRE Server refuses to create
+// entries that federate with a trust domain it has no bundle for. A warning
+// is returned instead of an error so that the objects can be applied in any
+// order. No warning is returned if the ClusterFederatedTrustDomains cannot be
+// listed, e.g. because the CRD is not installed.
+func federatesWithWarnings(ctx context.Context, c client.Reader, trustDomain spiffeid.TrustDomain, spireServer string, federatesWith []spiffeid.TrustDomain) admission.Warnings {
+	if c == nil || len(federatesWith) == 0 {
+		return nil
+	}
+
+	var list ClusterFederatedTrustDomainList
+	if err := c.List(ctx, &list); err != nil {
+		logf.FromContext(ctx).Error(err, "Unable to list ClusterFederatedTrustDomains; not checking federatesWith")
+		return nil
+	}
+	federated := make(map[string]struct{}, len(list.Items))
+	for _, item := range list.Items {
+		if item.Spec.SPIREServer == spireServer {
+			federated[item.Spec.TrustDomain] = struct{}{}
+		}
+	}
+
+	var missing []string
+	for _, td := range federatesWith {
+		if td == trustDomain {
+			continue
+		}
+		if _, ok := federated[td.Name()]; !ok {
+			missing = append(missing, td.Name())
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return admission.Warnings{fmt.Sprintf("no ClusterFederatedTrustDomain exists for federatesWith trust domains %s; entries federating with them cannot be created until SPIRE Server has their bundles", strings.Join(missing, ", "))}
+}
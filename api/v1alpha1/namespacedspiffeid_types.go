@@ -0,0 +1,203 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespacedSPIFFEIDSpec defines the desired state of NamespacedSPIFFEID. It
+// is a subset of the ClusterSPIFFEIDSpec that only targets pods in the
+// namespace of the NamespacedSPIFFEID. Fields that grant privileges beyond
+// the namespace (e.g. admin) are not available.
+type NamespacedSPIFFEIDSpec struct {
+	// SPIFFEID is the SPIFFE ID template. The node and pod spec are made
+	// available to the template under .NodeSpec, .PodSpec respectively.
+	// The rendered SPIFFE ID must be under the path prefix allowed for the
+	// namespace.
+	SPIFFEIDTemplate string `json:"spiffeIDTemplate"`
+
+	// TTL indicates an upper-bound time-to-live for SVIDs minted for this
+	// NamespacedSPIFFEID. If unset, a default will be chosen.
+	TTL metav1.Duration `json:"ttl,omitempty"`
+
+	// X509SVIDTTL indicates an upper-bound time-to-live for X509-SVIDs
+	// minted for this NamespacedSPIFFEID. See ClusterSPIFFEIDSpec.
+	X509SVIDTTL metav1.Duration `json:"x509SVIDTTL,omitempty"`
+
+	// JWTSVIDTTL indicates an upper-bound time-to-live for JWT-SVIDs minted
+	// for this NamespacedSPIFFEID. See ClusterSPIFFEIDSpec.
+	JWTSVIDTTL metav1.Duration `json:"jwtSVIDTTL,omitempty"`
+
+	// DNSNameTemplate represents templates for extra DNS names that are
+	// applicable to SVIDs minted for this NamespacedSPIFFEID.
+	// The node and pod spec are made available to the template under
+	// .NodeSpec, .PodSpec respectively.
+	DNSNameTemplates []string `json:"dnsNameTemplates,omitempty"`
+
+	// AutoPopulateDNSNames, if true, adds the DNS names of the Services that
+	// select the pod to the DNS names of the SVIDs minted for this
+	// NamespacedSPIFFEID. See ClusterSPIFFEIDSpec.
+	// +optional
+	AutoPopulateDNSNames bool `json:"autoPopulateDNSNames,omitempty"`
+
+	// WorkloadSelectorTemplates are templates to produce arbitrary workload
+	// selectors that apply to a given workload before it will receive this
+	// SPIFFE ID. See ClusterSPIFFEIDSpec.
+	WorkloadSelectorTemplates []string `json:"workloadSelectorTemplates,omitempty"`
+
+	// HintTemplate is a template for the hint provided to workloads to
+	// help them choose between multiple SVIDs. See ClusterSPIFFEIDSpec.
+	HintTemplate string `json:"hintTemplate,omitempty"`
+
+	// FederatesWith is a list of trust domain names that workloads that
+	// obtain this SPIFFE ID will federate with.
+	FederatesWith []string `json:"federatesWith,omitempty"`
+
+	// FederatesWithSets is a list of ClusterTrustDomainSet names. Workloads
+	// that obtain this SPIFFE ID will also federate with the trust domains
+	// in each set.
+	FederatesWithSets []string `json:"federatesWithSets,omitempty"`
+
+	// PodSelector selects the pods in the namespace that are targeted by
+	// this CRD.
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// ServiceAccountNames selects the pods that are targeted by this CRD by
+	// the name of the service account the pod runs as. See
+	// ClusterSPIFFEIDSpec.
+	ServiceAccountNames []string `json:"serviceAccountNames,omitempty"`
+
+	// JobPods determines whether pods created by Jobs, including those of
+	// CronJobs, are targeted by this CRD. Defaults to Include.
+	JobPods PodInclusionPolicy `json:"jobPods,omitempty"`
+
+	// ClassName is the class of the controller manager instance that
+	// reconciles this NamespacedSPIFFEID. If unset, it is reconciled by the
+	// instances without a class, or that watch classless objects.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+}
+
+// ClusterSPIFFEIDSpec returns the equivalent ClusterSPIFFEIDSpec. It does
+// not select namespaces; the NamespacedSPIFFEID only targets its own.
+func (s *NamespacedSPIFFEIDSpec) ClusterSPIFFEIDSpec() *ClusterSPIFFEIDSpec {
+	return &ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate:          s.SPIFFEIDTemplate,
+		TTL:                       s.TTL,
+		X509SVIDTTL:               s.X509SVIDTTL,
+		JWTSVIDTTL:                s.JWTSVIDTTL,
+		DNSNameTemplates:          s.DNSNameTemplates,
+		AutoPopulateDNSNames:      s.AutoPopulateDNSNames,
+		WorkloadSelectorTemplates: s.WorkloadSelectorTemplates,
+		HintTemplate:              s.HintTemplate,
+		FederatesWith:             s.FederatesWith,
+		FederatesWithSets:         s.FederatesWithSets,
+		PodSelector:               s.PodSelector,
+		ServiceAccountNames:       s.ServiceAccountNames,
+		JobPods:                   s.JobPods,
+		ClassName:                 s.ClassName,
+	}
+}
+
+// NamespacedSPIFFEIDStatus defines the observed state of NamespacedSPIFFEID
+type NamespacedSPIFFEIDStatus struct {
+	// Stats produced by the last entry reconciliation run
+	// +kubebuilder:validation:Optional
+	Stats NamespacedSPIFFEIDStats `json:"stats"`
+
+	// MaskedBy names the objects, as "Kind/name", that declared the entries
+	// masking the entries of this NamespacedSPIFFEID. At most 10 objects are named.
+	// +optional
+	MaskedBy []string `json:"maskedBy,omitempty"`
+
+	// Masking names the objects, as "Kind/name", whose entries are masked by
+	// the entries of this NamespacedSPIFFEID. At most 10 objects are named.
+	// +optional
+	Masking []string `json:"masking,omitempty"`
+
+	// Conditions describe the outcome of the last entry reconciliation run.
+	// They have the same types and reasons as the ClusterSPIFFEID
+	// conditions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// NamespacedSPIFFEIDStats contain entry reconciliation statistics.
+type NamespacedSPIFFEIDStats struct {
+	// How many pods were selected out of the namespace.
+	// +kubebuilder:validation:Optional
+	PodsSelected int `json:"podsSelected"`
+
+	// How many failures were encountered rendering an entry selected pods.
+	// This includes rendered SPIFFE IDs outside of the path prefix allowed
+	// for the namespace.
+	// +kubebuilder:validation:Optional
+	PodEntryRenderFailures int `json:"podEntryRenderFailures"`
+
+	// How many entries were masked by entries declared by other objects.
+	// +kubebuilder:validation:Optional
+	EntriesMasked int `json:"entriesMasked"`
+
+	// How many entries declared by other objects were masked by the
+	// entries of this object.
+	// +kubebuilder:validation:Optional
+	EntriesMasking int `json:"entriesMasking"`
+
+	// How many entries are to be set for this NamespacedSPIFFEID.
+	// +kubebuilder:validation:Optional
+	EntriesToSet int `json:"entriesToSet"`
+
+	// How many entries were unable to be set due to failures to create or
+	// update the entries via the SPIRE Server API.
+	// +kubebuilder:validation:Optional
+	EntryFailures int `json:"entryFailures"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Pods",type=integer,JSONPath=`.status.stats.podsSelected`
+//+kubebuilder:printcolumn:name="Entries",type=integer,JSONPath=`.status.stats.entriesToSet`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NamespacedSPIFFEID is the Schema for the namespacedspiffeids API. It
+// declares the identity of workloads in its own namespace, so that
+// application teams can register their workloads without cluster-wide
+// permissions.
+type NamespacedSPIFFEID struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NamespacedSPIFFEIDSpec   `json:"spec,omitempty"`
+	Status NamespacedSPIFFEIDStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// NamespacedSPIFFEIDList contains a list of NamespacedSPIFFEID
+type NamespacedSPIFFEIDList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespacedSPIFFEID `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespacedSPIFFEID{}, &NamespacedSPIFFEIDList{})
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var namespacedspiffeidlog = logf.Log.WithName("namespacedspiffeid-resource")
+
+func (r *NamespacedSPIFFEID) SetupWebhookWithManager(mgr ctrl.Manager, options WebhookOptions) error {
+	if options.NamespacePathPrefixTemplate == nil {
+		return errors.New("namespace path prefix template is required")
+	}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithValidator(&namespacedSPIFFEIDValidator{
+			client:                      mgr.GetClient(),
+			trustDomain:                 options.TrustDomain,
+			allowedPathPrefixes:         options.AllowedPathPrefixes,
+			namespacePathPrefixTemplate: options.NamespacePathPrefixTemplate,
+			className:                   options.ClassName,
+			watchClassless:              options.WatchClassless,
+		}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-spire-spiffe-io-v1alpha1-namespacedspiffeid,mutating=false,failurePolicy=fail,sideEffects=None,groups=spire.spiffe.io,resources=namespacedspiffeids,verbs=create;update,versions=v1alpha1,name=vnamespacedspiffeid.kb.io,admissionReviewVersions=v1
+
+// namespacedSPIFFEIDValidator validates NamespacedSPIFFEIDs against the
+// controller configuration. See clusterSPIFFEIDValidator.
+type namespacedSPIFFEIDValidator struct {
+	client                      client.Reader
+	trustDomain                 spiffeid.TrustDomain
+	allowedPathPrefixes         []string
+	namespacePathPrefixTemplate *template.Template
+	className                   string
+	watchClassless              bool
+}
+
+var _ webhook.CustomValidator = &namespacedSPIFFEIDValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *namespacedSPIFFEIDValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	r, ok := obj.(*NamespacedSPIFFEID)
+	if !ok {
+		return nil, fmt.Errorf("expected a NamespacedSPIFFEID but got %T", obj)
+	}
+	namespacedspiffeidlog.Info("validate create", "namespace", r.Namespace, "name", r.Name)
+	return v.validate(ctx, r)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *namespacedSPIFFEIDValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	r, ok := newObj.(*NamespacedSPIFFEID)
+	if !ok {
+		return nil, fmt.Errorf("expected a NamespacedSPIFFEID but got %T", newObj)
+	}
+	namespacedspiffeidlog.Info("validate update", "namespace", r.Namespace, "name", r.Name)
+	return v.validate(ctx, r)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *namespacedSPIFFEIDValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	// Deletes are not validated.
+	return nil, nil
+}
+
+func (v *namespacedSPIFFEIDValidator) validate(ctx context.Context, r *NamespacedSPIFFEID) (admission.Warnings, error) {
+	spec, err := ParseClusterSPIFFEIDSpec(r.Spec.ClusterSPIFFEIDSpec())
+	if err != nil {
+		return nil, err
+	}
+	if !MatchesClass(r.Spec.ClassName, v.className, v.watchClassless) {
+		return nil, nil
+	}
+	if err := checkSPIFFEIDTemplatePathAllowed(spec.SPIFFEIDTemplate, v.allowedPathPrefixes); err != nil {
+		return nil, fmt.Errorf("invalid SPIFFEID template: %w", err)
+	}
+	namespacePathPrefix, err := RenderNamespacePathPrefix(v.namespacePathPrefixTemplate, r.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSPIFFEIDTemplatePathAllowed(spec.SPIFFEIDTemplate, []string{namespacePathPrefix}); err != nil {
+		return nil, fmt.Errorf("invalid SPIFFEID template: %w", err)
+	}
+	return federatesWithWarnings(ctx, v.client, v.trustDomain, "", spec.FederatesWith), nil
+}
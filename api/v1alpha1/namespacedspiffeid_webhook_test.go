@@ -0,0 +1,60 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespacedSPIFFEIDValidator(t *testing.T) {
+	namespacePathPrefixTemplate, err := ParseNamespacePathPrefixTemplate(DefaultNamespacePathPrefixTemplate)
+	require.NoError(t, err)
+	v := &namespacedSPIFFEIDValidator{
+		allowedPathPrefixes:         []string{"/ns"},
+		namespacePathPrefixTemplate: namespacePathPrefixTemplate,
+	}
+
+	for _, tt := range []struct {
+		desc      string
+		template  string
+		expectErr string
+	}{
+		{
+			desc:     "literal path under namespace prefix",
+			template: "spiffe://{{ .TrustDomain }}/ns/team-a/sa/{{ .PodSpec.ServiceAccountName }}",
+		},
+		{
+			desc:     "literal path may be extended to namespace prefix",
+			template: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+		},
+		{
+			desc:      "invalid spec",
+			template:  "{{",
+			expectErr: "invalid SPIFFEID template: template: spiffeIDTemplate:1: unclosed action",
+		},
+		{
+			desc:      "literal path under another namespace prefix",
+			template:  "spiffe://{{ .TrustDomain }}/ns/team-b/sa/{{ .PodSpec.ServiceAccountName }}",
+			expectErr: `invalid SPIFFEID template: SPIFFE ID path beginning with "/ns/team-b/sa/" is not under an allowed path prefix`,
+		},
+		{
+			desc:      "literal path outside allowed prefixes",
+			template:  "spiffe://{{ .TrustDomain }}/admin/{{ .PodSpec.ServiceAccountName }}",
+			expectErr: `invalid SPIFFEID template: SPIFFE ID path beginning with "/admin/" is not under an allowed path prefix`,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := v.ValidateCreate(context.Background(), &NamespacedSPIFFEID{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "nsid"},
+				Spec:       NamespacedSPIFFEIDSpec{SPIFFEIDTemplate: tt.template},
+			})
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+)
+
+// +kubebuilder:object:generate=false
+// WebhookOptions configures the validating and defaulting webhooks.
+type WebhookOptions struct {
+	// AllowedPathPrefixes, if non-empty, restricts the SPIFFE IDs declared
+	// by ClusterSPIFFEIDs and ClusterStaticEntries to paths under one of the
+	// prefixes.
+	AllowedPathPrefixes []string
+
+	// NamespacePathPrefixTemplate, if set, renders the path prefix that the
+	// SPIFFE IDs declared by the NamespacedSPIFFEIDs in a namespace must be
+	// under. See ParseNamespacePathPrefixTemplate.
+	NamespacePathPrefixTemplate *template.Template
+
+	// ClassName and WatchClassless select the objects the controller
+	// reconciles (see MatchesClass). The checks against the controller
+	// configuration are skipped for other objects, which are validated by
+	// the controller of their class.
+	ClassName      string
+	WatchClassless bool
+
+	// SPIREServers are the names of the additional SPIRE Server targets.
+	// Objects that select another target are rejected.
+	SPIREServers []string
+
+	// PrivilegedEntries, if set, restricts the ClusterSPIFFEIDs and
+	// ClusterStaticEntries allowed to declare admin or downstream entries.
+	PrivilegedEntries *PrivilegedEntriesConfig
+
+	// ClusterSPIFFEIDDefaults, if set, are applied to the v1alpha2
+	// ClusterSPIFFEIDs that leave the fields unset.
+	ClusterSPIFFEIDDefaults *ClusterSPIFFEIDDefaultsConfig
+
+	// ClusterStaticEntryDefaults, if set, are applied to the v1alpha2
+	// ClusterStaticEntries that leave the fields unset.
+	ClusterStaticEntryDefaults *ClusterStaticEntryDefaultsConfig
+
+	// ClusterFederatedTrustDomainDefaults, if set, are applied to the
+	// v1alpha2 ClusterFederatedTrustDomains that leave the fields unset.
+	ClusterFederatedTrustDomainDefaults *ClusterFederatedTrustDomainDefaultsConfig
+
+	// TrustDomain is the trust domain of the default SPIRE Server. It is
+	// never reported as missing a ClusterFederatedTrustDomain when federated
+	// with.
+	TrustDomain spiffeid.TrustDomain
+}
+
+// DefaultNamespacePathPrefixTemplate is the default template for the path
+// prefix allowed for the NamespacedSPIFFEIDs in a namespace.
+const DefaultNamespacePathPrefixTemplate = "/ns/{{ .Namespace }}"
+
+// ParseNamespacePathPrefixTemplate parses the template for the path prefix
+// allowed for the NamespacedSPIFFEIDs in a namespace. The name of the
+// namespace is available to the template as .Namespace.
+func ParseNamespacePathPrefixTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("namespacePathPrefixTemplate").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace path prefix template: %w", err)
+	}
+	if _, err := RenderNamespacePathPrefix(tmpl, "namespace"); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// RenderNamespacePathPrefix renders the path prefix allowed for the
+// NamespacedSPIFFEIDs in the namespace.
+func RenderNamespacePathPrefix(tmpl *template.Template, namespace string) (string, error) {
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, struct{ Namespace string }{Namespace: namespace}); err != nil {
+		return "", fmt.Errorf("failed to render namespace path prefix: %w", err)
+	}
+	prefix := buf.String()
+	if err := ValidatePathPrefix(prefix); err != nil {
+		return "", fmt.Errorf("invalid namespace path prefix: %w", err)
+	}
+	return prefix, nil
+}
+
+// ValidatePathPrefix validates a path prefix used to restrict SPIFFE IDs.
+func ValidatePathPrefix(prefix string) error {
+	switch prefix {
+	case "":
+		return errors.New("invalid path prefix: cannot be empty")
+	case "/":
+		return nil
+	}
+	if err := spiffeid.ValidatePath(strings.TrimSuffix(prefix, "/")); err != nil {
+		return fmt.Errorf("invalid path prefix %q: %w", prefix, err)
+	}
+	return nil
+}
+
+// CheckPathAllowed returns an error if the SPIFFE ID path is not under one
+// of the allowed path prefixes. Prefixes match on path segment boundaries,
+// e.g. "/ns/foo" allows "/ns/foo" and "/ns/foo/bar" but not "/ns/foobar".
+// All paths are allowed if no prefixes are given.
+func CheckPathAllowed(path string, allowedPathPrefixes []string) error {
+	if len(allowedPathPrefixes) == 0 {
+		return nil
+	}
+	for _, prefix := range allowedPathPrefixes {
+		if pathHasPrefix(path, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("path %q is not under an allowed path prefix", path)
+}
+
+func pathHasPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// checkSPIFFEIDTemplatePathAllowed returns an error if the SPIFFE ID template
+// can never render a SPIFFE ID with a path under one of the allowed path
+// prefixes. Only the literal beginning of the template can be checked, so
+// templates that pass this check may still render disallowed SPIFFE IDs.
+// Those are caught when the entries are rendered.
+func checkSPIFFEIDTemplatePathAllowed(tmpl *template.Template, allowedPathPrefixes []string) error {
+	if len(allowedPathPrefixes) == 0 {
+		return nil
+	}
+	pathPrefix, complete, ok := spiffeIDTemplatePathPrefix(tmpl)
+	switch {
+	case !ok:
+		return nil
+	case complete:
+		// The template renders the same path every time.
+		return CheckPathAllowed(pathPrefix, allowedPathPrefixes)
+	}
+	for _, prefix := range allowedPathPrefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		// The template is compatible with the prefix if its literal path
+		// prefix is under the allowed prefix, or could be extended to be.
+		if pathHasPrefix(pathPrefix, prefix) || strings.HasPrefix(prefix+"/", pathPrefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("SPIFFE ID path beginning with %q is not under an allowed path prefix", pathPrefix)
+}
+
+// spiffeIDTemplatePathPrefix returns the literal beginning of the path of
+// the SPIFFE IDs rendered by the template, and whether that is the entire
+// path. It returns false if the beginning of the path is not known.
+func spiffeIDTemplatePathPrefix(tmpl *template.Template) (string, bool, bool) {
+	if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+		return "", false, false
+	}
+
+	// Gather the literal text up until the first action, treating the
+	// {{ .TrustDomain }} action as an opaque, but slash-free, value since
+	// it is ubiquitous in SPIFFE ID templates.
+	var literal strings.Builder
+	complete := true
+loop:
+	for _, node := range tmpl.Tree.Root.Nodes {
+		switch node := node.(type) {
+		case *parse.TextNode:
+			literal.Write(node.Text)
+		case *parse.ActionNode:
+			if !isTrustDomainAction(node) {
+				complete = false
+				break loop
+			}
+			literal.WriteString("trustdomain")
+		default:
+			complete = false
+			break loop
+		}
+	}
+
+	rest, ok := strings.CutPrefix(literal.String(), "spiffe://")
+	if !ok {
+		return "", false, false
+	}
+	_, path, ok := strings.Cut(rest, "/")
+	if !ok {
+		return "", false, false
+	}
+	return "/" + path, complete, true
+}
+
+func isTrustDomainAction(node *parse.ActionNode) bool {
+	if node.Pipe == nil || len(node.Pipe.Decl) > 0 || len(node.Pipe.Cmds) != 1 {
+		return false
+	}
+	args := node.Pipe.Cmds[0].Args
+	if len(args) != 1 {
+		return false
+	}
+	field, ok := args[0].(*parse.FieldNode)
+	return ok && len(field.Ident) == 1 && field.Ident[0] == "TrustDomain"
+}
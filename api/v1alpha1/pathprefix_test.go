@@ -0,0 +1,152 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidatePathPrefix(t *testing.T) {
+	for _, tt := range []struct {
+		prefix    string
+		expectErr string
+	}{
+		{prefix: "/"},
+		{prefix: "/ns"},
+		{prefix: "/ns/"},
+		{prefix: "/ns/foo"},
+		{prefix: "", expectErr: "invalid path prefix: cannot be empty"},
+		{prefix: "ns", expectErr: `invalid path prefix "ns": path must have a leading slash`},
+		{prefix: "/ns//foo", expectErr: `invalid path prefix "/ns//foo": path cannot contain empty segments`},
+	} {
+		t.Run(tt.prefix, func(t *testing.T) {
+			err := ValidatePathPrefix(tt.prefix)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCheckPathAllowed(t *testing.T) {
+	for _, tt := range []struct {
+		desc                string
+		path                string
+		allowedPathPrefixes []string
+		expectAllowed       bool
+	}{
+		{desc: "no prefixes", path: "/anything", expectAllowed: true},
+		{desc: "root prefix", path: "/anything", allowedPathPrefixes: []string{"/"}, expectAllowed: true},
+		{desc: "exact match", path: "/ns/foo", allowedPathPrefixes: []string{"/ns/foo"}, expectAllowed: true},
+		{desc: "under prefix", path: "/ns/foo/sa/bar", allowedPathPrefixes: []string{"/ns/foo"}, expectAllowed: true},
+		{desc: "under prefix with trailing slash", path: "/ns/foo/sa/bar", allowedPathPrefixes: []string{"/ns/foo/"}, expectAllowed: true},
+		{desc: "second prefix", path: "/other", allowedPathPrefixes: []string{"/ns", "/other"}, expectAllowed: true},
+		{desc: "partial segment", path: "/ns/foobar", allowedPathPrefixes: []string{"/ns/foo"}},
+		{desc: "parent of prefix", path: "/ns", allowedPathPrefixes: []string{"/ns/foo"}},
+		{desc: "unrelated", path: "/other", allowedPathPrefixes: []string{"/ns"}},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := CheckPathAllowed(tt.path, tt.allowedPathPrefixes)
+			if tt.expectAllowed {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestRenderNamespacePathPrefix(t *testing.T) {
+	for _, tt := range []struct {
+		desc         string
+		template     string
+		expectPrefix string
+		expectErr    string
+	}{
+		{
+			desc:         "default",
+			template:     DefaultNamespacePathPrefixTemplate,
+			expectPrefix: "/ns/team-a",
+		},
+		{
+			desc:         "custom",
+			template:     "/tenants/{{ .Namespace }}/workloads",
+			expectPrefix: "/tenants/team-a/workloads",
+		},
+		{
+			desc:      "unparseable",
+			template:  "/ns/{{ .Namespace",
+			expectErr: "invalid namespace path prefix template: template: namespacePathPrefixTemplate:1: unclosed action",
+		},
+		{
+			desc:      "unknown field",
+			template:  "/ns/{{ .Name }}",
+			expectErr: `failed to render namespace path prefix: template: namespacePathPrefixTemplate:1:7: executing "namespacePathPrefixTemplate" at <.Name>: can't evaluate field Name in type struct { Namespace string }`,
+		},
+		{
+			desc:      "invalid prefix",
+			template:  "ns/{{ .Namespace }}",
+			expectErr: `invalid namespace path prefix: invalid path prefix "ns/namespace": path must have a leading slash`,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			tmpl, err := ParseNamespacePathPrefixTemplate(tt.template)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			prefix, err := RenderNamespacePathPrefix(tmpl, "team-a")
+			require.NoError(t, err)
+			require.Equal(t, tt.expectPrefix, prefix)
+		})
+	}
+}
+
+func TestClusterSPIFFEIDValidatorAllowedPathPrefixes(t *testing.T) {
+	v := &clusterSPIFFEIDValidator{allowedPathPrefixes: []string{"/ns/prod"}}
+	for _, tt := range []struct {
+		desc      string
+		template  string
+		expectErr string
+	}{
+		{
+			desc:     "literal path under prefix",
+			template: "spiffe://{{ .TrustDomain }}/ns/prod/sa/{{ .PodSpec.ServiceAccountName }}",
+		},
+		{
+			desc:     "literal path may be extended to prefix",
+			template: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+		},
+		{
+			desc:     "path is unknown",
+			template: "spiffe://{{ .TrustDomain }}{{ .PodMeta.Annotations.path }}",
+		},
+		{
+			desc:      "literal path outside prefix",
+			template:  "spiffe://{{ .TrustDomain }}/ns/dev/sa/{{ .PodSpec.ServiceAccountName }}",
+			expectErr: `invalid SPIFFEID template: SPIFFE ID path beginning with "/ns/dev/sa/" is not under an allowed path prefix`,
+		},
+		{
+			desc:      "complete path outside prefix",
+			template:  "spiffe://{{ .TrustDomain }}/ns",
+			expectErr: `invalid SPIFFEID template: path "/ns" is not under an allowed path prefix`,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := v.ValidateCreate(context.Background(), &ClusterSPIFFEID{
+				ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+				Spec:       ClusterSPIFFEIDSpec{SPIFFEIDTemplate: tt.template},
+			})
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
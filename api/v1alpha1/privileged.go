@@ -0,0 +1,54 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import "errors"
+
+// CheckClusterSPIFFEID returns an error if the ClusterSPIFFEID declares admin
+// or downstream entries but is not privileged. All objects are privileged if
+// the config is nil.
+func (c *PrivilegedEntriesConfig) CheckClusterSPIFFEID(name string, admin, downstream bool) error {
+	if c == nil {
+		return nil
+	}
+	return checkPrivileged(name, c.ClusterSPIFFEIDs, admin, downstream)
+}
+
+// CheckClusterStaticEntry returns an error if the ClusterStaticEntry declares
+// an admin or downstream entry but is not privileged. All objects are
+// privileged if the config is nil.
+func (c *PrivilegedEntriesConfig) CheckClusterStaticEntry(name string, admin, downstream bool) error {
+	if c == nil {
+		return nil
+	}
+	return checkPrivileged(name, c.ClusterStaticEntries, admin, downstream)
+}
+
+func checkPrivileged(name string, privileged []string, admin, downstream bool) error {
+	if !admin && !downstream {
+		return nil
+	}
+	for _, value := range privileged {
+		if value == name {
+			return nil
+		}
+	}
+	if admin {
+		return errors.New("admin entries are not allowed; the object is not listed in privilegedEntries")
+	}
+	return errors.New("downstream entries are not allowed; the object is not listed in privilegedEntries")
+}
@@ -0,0 +1,29 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivilegedEntriesConfig(t *testing.T) {
+	config := &PrivilegedEntriesConfig{
+		ClusterSPIFFEIDs:     []string{"nested-spire"},
+		ClusterStaticEntries: []string{"admin-tool"},
+	}
+
+	// Unrestricted without a config.
+	var unrestricted *PrivilegedEntriesConfig
+	require.NoError(t, unrestricted.CheckClusterSPIFFEID("any", true, true))
+	require.NoError(t, unrestricted.CheckClusterStaticEntry("any", true, true))
+
+	// Unprivileged entries are always allowed.
+	require.NoError(t, config.CheckClusterSPIFFEID("workload", false, false))
+	require.NoError(t, config.CheckClusterStaticEntry("workload", false, false))
+
+	// Privileged entries are only allowed for the listed objects.
+	require.NoError(t, config.CheckClusterSPIFFEID("nested-spire", false, true))
+	require.NoError(t, config.CheckClusterStaticEntry("admin-tool", true, false))
+	require.EqualError(t, config.CheckClusterSPIFFEID("admin-tool", true, false), "admin entries are not allowed; the object is not listed in privilegedEntries")
+	require.EqualError(t, config.CheckClusterStaticEntry("nested-spire", false, true), "downstream entries are not allowed; the object is not listed in privilegedEntries")
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import "fmt"
+
+// CheckSPIREServer returns an error if the SPIRE Server target selected by an
+// object is not one of the configured targets. Objects that do not select a
+// target use the default SPIRE Server.
+func CheckSPIREServer(name string, targets []string) error {
+	if name == "" {
+		return nil
+	}
+	for _, target := range targets {
+		if name == target {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown SPIRE Server %q", name)
+}
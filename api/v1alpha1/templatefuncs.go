@@ -0,0 +1,71 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+	"text/template"
+)
+
+// newEntryTemplate returns a new template for rendering entry fields, with
+// the functions available to entry templates.
+func newEntryTemplate(name string) *template.Template {
+	return template.New(name).Funcs(template.FuncMap{
+		"sanitizePathSegment": SanitizePathSegment,
+		"sanitizeDNSLabel":    SanitizeDNSLabel,
+	})
+}
+
+// SanitizePathSegment replaces the characters that are not allowed in a
+// SPIFFE ID path segment (i.e. other than letters, digits, ".", "-" and "_")
+// with "-", so that arbitrary values such as annotations can be used in a
+// SPIFFE ID path. Since SPIFFE IDs cannot have empty, "." or ".." segments,
+// those values are replaced with "-" too.
+func SanitizePathSegment(s string) string {
+	switch s {
+	case "", ".", "..":
+		return "-"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '-'
+		}
+	}, s)
+}
+
+// SanitizeDNSLabel converts the value into a valid DNS label by lowercasing
+// it, replacing the characters other than letters, digits and "-" with "-",
+// trimming leading and trailing "-" and truncating it to 63 characters.
+func SanitizeDNSLabel(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
+		}
+	}, s)
+	if len(s) > 63 {
+		s = s[:63]
+	}
+	return strings.Trim(s, "-")
+}
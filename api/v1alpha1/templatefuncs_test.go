@@ -0,0 +1,24 @@
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizePathSegment(t *testing.T) {
+	require.Equal(t, "team-a_1.x", SanitizePathSegment("team-a_1.x"))
+	require.Equal(t, "team-a-b-", SanitizePathSegment("team/a b!"))
+	require.Equal(t, "caf-", SanitizePathSegment("café"))
+	require.Equal(t, "-", SanitizePathSegment(""))
+	require.Equal(t, "-", SanitizePathSegment("."))
+	require.Equal(t, "-", SanitizePathSegment(".."))
+	require.Equal(t, "...", SanitizePathSegment("..."))
+}
+
+func TestSanitizeDNSLabel(t *testing.T) {
+	require.Equal(t, "my-service", SanitizeDNSLabel("My_Service"))
+	require.Equal(t, "a-b", SanitizeDNSLabel("-a.b-"))
+	require.Equal(t, strings.Repeat("a", 63), SanitizeDNSLabel(strings.Repeat("a", 70)))
+}
@@ -101,7 +101,21 @@ var _ = BeforeSuite(func() {
 	err = (&ClusterFederatedTrustDomain{}).SetupWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
-	err = (&ClusterSPIFFEID{}).SetupWebhookWithManager(mgr)
+	err = (&ClusterSPIFFEID{}).SetupWebhookWithManager(mgr, WebhookOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&ClusterStaticEntry{}).SetupWebhookWithManager(mgr, WebhookOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&ClusterNodeAlias{}).SetupWebhookWithManager(mgr, WebhookOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&ClusterTrustDomainSet{}).SetupWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	namespacePathPrefixTemplate, err := ParseNamespacePathPrefixTemplate(DefaultNamespacePathPrefixTemplate)
+	Expect(err).NotTo(HaveOccurred())
+	err = (&NamespacedSPIFFEID{}).SetupWebhookWithManager(mgr, WebhookOptions{NamespacePathPrefixTemplate: namespacePathPrefixTemplate})
 	Expect(err).NotTo(HaveOccurred())
 
 	//+kubebuilder:scaffold:webhook
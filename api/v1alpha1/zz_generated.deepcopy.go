@@ -22,12 +22,46 @@ limitations under the License.
 package v1alpha1
 
 import (
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	configv1alpha1 "k8s.io/component-base/config/v1alpha1"
 	timex "time"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentGCConfig) DeepCopyInto(out *AgentGCConfig) {
+	*out = *in
+	out.Delay = in.Delay
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentGCConfig.
+func (in *AgentGCConfig) DeepCopy() *AgentGCConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentGCConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleEndpointProbeConfig) DeepCopyInto(out *BundleEndpointProbeConfig) {
+	*out = *in
+	out.Interval = in.Interval
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleEndpointProbeConfig.
+func (in *BundleEndpointProbeConfig) DeepCopy() *BundleEndpointProbeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleEndpointProbeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BundleEndpointProfile) DeepCopyInto(out *BundleEndpointProfile) {
 	*out = *in
@@ -43,13 +77,38 @@ func (in *BundleEndpointProfile) DeepCopy() *BundleEndpointProfile {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundlePublisherConfig) DeepCopyInto(out *BundlePublisherConfig) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundlePublisherConfig.
+func (in *BundlePublisherConfig) DeepCopy() *BundlePublisherConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BundlePublisherConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterFederatedTrustDomain) DeepCopyInto(out *ClusterFederatedTrustDomain) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFederatedTrustDomain.
@@ -70,6 +129,21 @@ func (in *ClusterFederatedTrustDomain) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFederatedTrustDomainDefaultsConfig) DeepCopyInto(out *ClusterFederatedTrustDomainDefaultsConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFederatedTrustDomainDefaultsConfig.
+func (in *ClusterFederatedTrustDomainDefaultsConfig) DeepCopy() *ClusterFederatedTrustDomainDefaultsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFederatedTrustDomainDefaultsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterFederatedTrustDomainList) DeepCopyInto(out *ClusterFederatedTrustDomainList) {
 	*out = *in
@@ -121,6 +195,18 @@ func (in *ClusterFederatedTrustDomainSpec) DeepCopy() *ClusterFederatedTrustDoma
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterFederatedTrustDomainStatus) DeepCopyInto(out *ClusterFederatedTrustDomainStatus) {
 	*out = *in
+	if in.LastSyncedBundleSerial != nil {
+		in, out := &in.LastSyncedBundleSerial, &out.LastSyncedBundleSerial
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFederatedTrustDomainStatus.
@@ -133,13 +219,124 @@ func (in *ClusterFederatedTrustDomainStatus) DeepCopy() *ClusterFederatedTrustDo
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterNodeAlias) DeepCopyInto(out *ClusterNodeAlias) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterNodeAlias.
+func (in *ClusterNodeAlias) DeepCopy() *ClusterNodeAlias {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterNodeAlias)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterNodeAlias) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterNodeAliasList) DeepCopyInto(out *ClusterNodeAliasList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterNodeAlias, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterNodeAliasList.
+func (in *ClusterNodeAliasList) DeepCopy() *ClusterNodeAliasList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterNodeAliasList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterNodeAliasList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterNodeAliasSpec) DeepCopyInto(out *ClusterNodeAliasSpec) {
+	*out = *in
+	if in.NodeLabels != nil {
+		in, out := &in.NodeLabels, &out.NodeLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Selectors != nil {
+		in, out := &in.Selectors, &out.Selectors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterNodeAliasSpec.
+func (in *ClusterNodeAliasSpec) DeepCopy() *ClusterNodeAliasSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterNodeAliasSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterNodeAliasStatus) DeepCopyInto(out *ClusterNodeAliasStatus) {
+	*out = *in
+	if in.MaskedBy != nil {
+		in, out := &in.MaskedBy, &out.MaskedBy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Masking != nil {
+		in, out := &in.Masking, &out.Masking
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterNodeAliasStatus.
+func (in *ClusterNodeAliasStatus) DeepCopy() *ClusterNodeAliasStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterNodeAliasStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterSPIFFEID) DeepCopyInto(out *ClusterSPIFFEID) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSPIFFEID.
@@ -160,6 +357,28 @@ func (in *ClusterSPIFFEID) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSPIFFEIDDefaultsConfig) DeepCopyInto(out *ClusterSPIFFEIDDefaultsConfig) {
+	*out = *in
+	out.X509SVIDTTL = in.X509SVIDTTL
+	out.JWTSVIDTTL = in.JWTSVIDTTL
+	if in.DNSNameTemplates != nil {
+		in, out := &in.DNSNameTemplates, &out.DNSNameTemplates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSPIFFEIDDefaultsConfig.
+func (in *ClusterSPIFFEIDDefaultsConfig) DeepCopy() *ClusterSPIFFEIDDefaultsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSPIFFEIDDefaultsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterSPIFFEIDList) DeepCopyInto(out *ClusterSPIFFEIDList) {
 	*out = *in
@@ -196,6 +415,8 @@ func (in *ClusterSPIFFEIDList) DeepCopyObject() runtime.Object {
 func (in *ClusterSPIFFEIDSpec) DeepCopyInto(out *ClusterSPIFFEIDSpec) {
 	*out = *in
 	out.TTL = in.TTL
+	out.X509SVIDTTL = in.X509SVIDTTL
+	out.JWTSVIDTTL = in.JWTSVIDTTL
 	if in.DNSNameTemplates != nil {
 		in, out := &in.DNSNameTemplates, &out.DNSNameTemplates
 		*out = make([]string, len(*in))
@@ -211,6 +432,11 @@ func (in *ClusterSPIFFEIDSpec) DeepCopyInto(out *ClusterSPIFFEIDSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.FederatesWithSets != nil {
+		in, out := &in.FederatesWithSets, &out.FederatesWithSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.NamespaceSelector != nil {
 		in, out := &in.NamespaceSelector, &out.NamespaceSelector
 		*out = new(v1.LabelSelector)
@@ -221,6 +447,16 @@ func (in *ClusterSPIFFEIDSpec) DeepCopyInto(out *ClusterSPIFFEIDSpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PodExcludeSelector != nil {
+		in, out := &in.PodExcludeSelector, &out.PodExcludeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccountNames != nil {
+		in, out := &in.ServiceAccountNames, &out.ServiceAccountNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSPIFFEIDSpec.
@@ -252,6 +488,23 @@ func (in *ClusterSPIFFEIDStats) DeepCopy() *ClusterSPIFFEIDStats {
 func (in *ClusterSPIFFEIDStatus) DeepCopyInto(out *ClusterSPIFFEIDStatus) {
 	*out = *in
 	out.Stats = in.Stats
+	if in.MaskedBy != nil {
+		in, out := &in.MaskedBy, &out.MaskedBy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Masking != nil {
+		in, out := &in.Masking, &out.Masking
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSPIFFEIDStatus.
@@ -270,7 +523,7 @@ func (in *ClusterStaticEntry) DeepCopyInto(out *ClusterStaticEntry) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStaticEntry.
@@ -291,6 +544,23 @@ func (in *ClusterStaticEntry) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterStaticEntryDefaultsConfig) DeepCopyInto(out *ClusterStaticEntryDefaultsConfig) {
+	*out = *in
+	out.X509SVIDTTL = in.X509SVIDTTL
+	out.JWTSVIDTTL = in.JWTSVIDTTL
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStaticEntryDefaultsConfig.
+func (in *ClusterStaticEntryDefaultsConfig) DeepCopy() *ClusterStaticEntryDefaultsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterStaticEntryDefaultsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterStaticEntryList) DeepCopyInto(out *ClusterStaticEntryList) {
 	*out = *in
@@ -358,6 +628,16 @@ func (in *ClusterStaticEntrySpec) DeepCopy() *ClusterStaticEntrySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterStaticEntryStatus) DeepCopyInto(out *ClusterStaticEntryStatus) {
 	*out = *in
+	if in.MaskedBy != nil {
+		in, out := &in.MaskedBy, &out.MaskedBy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Masking != nil {
+		in, out := &in.Masking, &out.Masking
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStaticEntryStatus.
@@ -371,76 +651,335 @@ func (in *ClusterStaticEntryStatus) DeepCopy() *ClusterStaticEntryStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ControllerConfigurationSpec) DeepCopyInto(out *ControllerConfigurationSpec) {
+func (in *ClusterTrustDomainSet) DeepCopyInto(out *ClusterTrustDomainSet) {
 	*out = *in
-	if in.GroupKindConcurrency != nil {
-		in, out := &in.GroupKindConcurrency, &out.GroupKindConcurrency
-		*out = make(map[string]int, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.CacheSyncTimeout != nil {
-		in, out := &in.CacheSyncTimeout, &out.CacheSyncTimeout
-		*out = new(timex.Duration)
-		**out = **in
-	}
-	if in.RecoverPanic != nil {
-		in, out := &in.RecoverPanic, &out.RecoverPanic
-		*out = new(bool)
-		**out = **in
-	}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerConfigurationSpec.
-func (in *ControllerConfigurationSpec) DeepCopy() *ControllerConfigurationSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTrustDomainSet.
+func (in *ClusterTrustDomainSet) DeepCopy() *ClusterTrustDomainSet {
 	if in == nil {
 		return nil
 	}
-	out := new(ControllerConfigurationSpec)
+	out := new(ClusterTrustDomainSet)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ControllerHealth) DeepCopyInto(out *ControllerHealth) {
-	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerHealth.
-func (in *ControllerHealth) DeepCopy() *ControllerHealth {
-	if in == nil {
-		return nil
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterTrustDomainSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
-	out := new(ControllerHealth)
-	in.DeepCopyInto(out)
-	return out
+	return nil
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ControllerManagerConfig) DeepCopyInto(out *ControllerManagerConfig) {
+func (in *ClusterTrustDomainSetList) DeepCopyInto(out *ClusterTrustDomainSetList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.ControllerManagerConfigurationSpec.DeepCopyInto(&out.ControllerManagerConfigurationSpec)
-	if in.IgnoreNamespaces != nil {
-		in, out := &in.IgnoreNamespaces, &out.IgnoreNamespaces
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterTrustDomainSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerManagerConfig.
-func (in *ControllerManagerConfig) DeepCopy() *ControllerManagerConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTrustDomainSetList.
+func (in *ClusterTrustDomainSetList) DeepCopy() *ClusterTrustDomainSetList {
 	if in == nil {
 		return nil
 	}
-	out := new(ControllerManagerConfig)
+	out := new(ClusterTrustDomainSetList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ControllerManagerConfig) DeepCopyObject() runtime.Object {
+func (in *ClusterTrustDomainSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTrustDomainSetSpec) DeepCopyInto(out *ClusterTrustDomainSetSpec) {
+	*out = *in
+	if in.TrustDomains != nil {
+		in, out := &in.TrustDomains, &out.TrustDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTrustDomainSetSpec.
+func (in *ClusterTrustDomainSetSpec) DeepCopy() *ClusterTrustDomainSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTrustDomainSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapReference) DeepCopyInto(out *ConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapReference.
+func (in *ConfigMapReference) DeepCopy() *ConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControllerConfigurationSpec) DeepCopyInto(out *ControllerConfigurationSpec) {
+	*out = *in
+	if in.GroupKindConcurrency != nil {
+		in, out := &in.GroupKindConcurrency, &out.GroupKindConcurrency
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CacheSyncTimeout != nil {
+		in, out := &in.CacheSyncTimeout, &out.CacheSyncTimeout
+		*out = new(timex.Duration)
+		**out = **in
+	}
+	if in.RecoverPanic != nil {
+		in, out := &in.RecoverPanic, &out.RecoverPanic
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerConfigurationSpec.
+func (in *ControllerConfigurationSpec) DeepCopy() *ControllerConfigurationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ControllerConfigurationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControllerHealth) DeepCopyInto(out *ControllerHealth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerHealth.
+func (in *ControllerHealth) DeepCopy() *ControllerHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(ControllerHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControllerManagerConfig) DeepCopyInto(out *ControllerManagerConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ControllerManagerConfigurationSpec.DeepCopyInto(&out.ControllerManagerConfigurationSpec)
+	if in.IgnoreNamespaces != nil {
+		in, out := &in.IgnoreNamespaces, &out.IgnoreNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodExcludeSelector != nil {
+		in, out := &in.PodExcludeSelector, &out.PodExcludeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	out.TerminatingPodEntryGracePeriod = in.TerminatingPodEntryGracePeriod
+	out.EntryDeletionGracePeriod = in.EntryDeletionGracePeriod
+	if in.AllowedPathPrefixes != nil {
+		in, out := &in.AllowedPathPrefixes, &out.AllowedPathPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PrivilegedEntries != nil {
+		in, out := &in.PrivilegedEntries, &out.PrivilegedEntries
+		*out = new(PrivilegedEntriesConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterSPIFFEIDDefaults != nil {
+		in, out := &in.ClusterSPIFFEIDDefaults, &out.ClusterSPIFFEIDDefaults
+		*out = new(ClusterSPIFFEIDDefaultsConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterStaticEntryDefaults != nil {
+		in, out := &in.ClusterStaticEntryDefaults, &out.ClusterStaticEntryDefaults
+		*out = new(ClusterStaticEntryDefaultsConfig)
+		**out = **in
+	}
+	if in.ClusterFederatedTrustDomainDefaults != nil {
+		in, out := &in.ClusterFederatedTrustDomainDefaults, &out.ClusterFederatedTrustDomainDefaults
+		*out = new(ClusterFederatedTrustDomainDefaultsConfig)
+		**out = **in
+	}
+	out.EntryGCInterval = in.EntryGCInterval
+	out.FederationRelationshipGCInterval = in.FederationRelationshipGCInterval
+	out.EntryReconcileBatchWindow = in.EntryReconcileBatchWindow
+	if in.ObjectSelector != nil {
+		in, out := &in.ObjectSelector, &out.ObjectSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SPIREServerTLS != nil {
+		in, out := &in.SPIREServerTLS, &out.SPIREServerTLS
+		*out = new(SPIREServerTLSConfig)
+		**out = **in
+	}
+	if in.RemoteClusters != nil {
+		in, out := &in.RemoteClusters, &out.RemoteClusters
+		*out = make([]RemoteClusterConfig, len(*in))
+		copy(*out, *in)
+	}
+	if in.SPIREServers != nil {
+		in, out := &in.SPIREServers, &out.SPIREServers
+		*out = make([]SPIREServerTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TrustBundleNotification != nil {
+		in, out := &in.TrustBundleNotification, &out.TrustBundleNotification
+		*out = new(TrustBundleNotificationConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BundlePublisher != nil {
+		in, out := &in.BundlePublisher, &out.BundlePublisher
+		*out = new(BundlePublisherConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IdentityReport != nil {
+		in, out := &in.IdentityReport, &out.IdentityReport
+		*out = new(IdentityReportConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EntryLifecycleHook != nil {
+		in, out := &in.EntryLifecycleHook, &out.EntryLifecycleHook
+		*out = new(EntryLifecycleHookConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EntryTransformer != nil {
+		in, out := &in.EntryTransformer, &out.EntryTransformer
+		*out = new(EntryTransformerConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FederatedBundleGC != nil {
+		in, out := &in.FederatedBundleGC, &out.FederatedBundleGC
+		*out = new(FederatedBundleGCConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BundleEndpointProbe != nil {
+		in, out := &in.BundleEndpointProbe, &out.BundleEndpointProbe
+		*out = new(BundleEndpointProbeConfig)
+		**out = **in
+	}
+	if in.AgentGC != nil {
+		in, out := &in.AgentGC, &out.AgentGC
+		*out = new(AgentGCConfig)
+		**out = **in
+	}
+	if in.WebhookSelfSignedCA != nil {
+		in, out := &in.WebhookSelfSignedCA, &out.WebhookSelfSignedCA
+		*out = new(WebhookSelfSignedCAConfig)
+		**out = **in
+	}
+	if in.WebhookCABundleTargets != nil {
+		in, out := &in.WebhookCABundleTargets, &out.WebhookCABundleTargets
+		*out = make([]WebhookCABundleTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.WebhookSettings != nil {
+		in, out := &in.WebhookSettings, &out.WebhookSettings
+		*out = new(WebhookSettingsConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	out.WebhookSVIDTTL = in.WebhookSVIDTTL
+	out.WebhookRotationThreshold = in.WebhookRotationThreshold
+	if in.SecureMetrics != nil {
+		in, out := &in.SecureMetrics, &out.SecureMetrics
+		*out = new(SecureMetricsConfig)
+		**out = **in
+	}
+	if in.NamespacedSPIFFEIDs != nil {
+		in, out := &in.NamespacedSPIFFEIDs, &out.NamespacedSPIFFEIDs
+		*out = new(NamespacedSPIFFEIDsConfig)
+		**out = **in
+	}
+	if in.PodSPIFFEIDAnnotation != nil {
+		in, out := &in.PodSPIFFEIDAnnotation, &out.PodSPIFFEIDAnnotation
+		*out = new(PodSPIFFEIDAnnotationConfig)
+		**out = **in
+	}
+	if in.DesiredStateSnapshot != nil {
+		in, out := &in.DesiredStateSnapshot, &out.DesiredStateSnapshot
+		*out = new(DesiredStateSnapshotConfig)
+		**out = **in
+	}
+	if in.EntryCache != nil {
+		in, out := &in.EntryCache, &out.EntryCache
+		*out = new(EntryCacheConfig)
+		**out = **in
+	}
+	if in.EntryAPI != nil {
+		in, out := &in.EntryAPI, &out.EntryAPI
+		*out = new(EntryAPIConfig)
+		**out = **in
+	}
+	if in.SPIREAPIRetry != nil {
+		in, out := &in.SPIREAPIRetry, &out.SPIREAPIRetry
+		*out = new(SPIREAPIRetryConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SPIREHealthCheck != nil {
+		in, out := &in.SPIREHealthCheck, &out.SPIREHealthCheck
+		*out = new(SPIREHealthCheckConfig)
+		**out = **in
+	}
+	if in.DebugServer != nil {
+		in, out := &in.DebugServer, &out.DebugServer
+		*out = new(DebugServerConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerManagerConfig.
+func (in *ControllerManagerConfig) DeepCopy() *ControllerManagerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ControllerManagerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ControllerManagerConfig) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -519,3 +1058,647 @@ func (in *ControllerWebhook) DeepCopy() *ControllerWebhook {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DebugServerConfig) DeepCopyInto(out *DebugServerConfig) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(DebugServerTLSConfig)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DebugServerConfig.
+func (in *DebugServerConfig) DeepCopy() *DebugServerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DebugServerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DebugServerTLSConfig) DeepCopyInto(out *DebugServerTLSConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DebugServerTLSConfig.
+func (in *DebugServerTLSConfig) DeepCopy() *DebugServerTLSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DebugServerTLSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DesiredStateSnapshotConfig) DeepCopyInto(out *DesiredStateSnapshotConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DesiredStateSnapshotConfig.
+func (in *DesiredStateSnapshotConfig) DeepCopy() *DesiredStateSnapshotConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DesiredStateSnapshotConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EntryAPIConfig) DeepCopyInto(out *EntryAPIConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EntryAPIConfig.
+func (in *EntryAPIConfig) DeepCopy() *EntryAPIConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EntryAPIConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EntryCacheConfig) DeepCopyInto(out *EntryCacheConfig) {
+	*out = *in
+	out.ResyncInterval = in.ResyncInterval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EntryCacheConfig.
+func (in *EntryCacheConfig) DeepCopy() *EntryCacheConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EntryCacheConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EntryLifecycleHookConfig) DeepCopyInto(out *EntryLifecycleHookConfig) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EntryLifecycleHookConfig.
+func (in *EntryLifecycleHookConfig) DeepCopy() *EntryLifecycleHookConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EntryLifecycleHookConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EntryTransformerConfig) DeepCopyInto(out *EntryTransformerConfig) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EntryTransformerConfig.
+func (in *EntryTransformerConfig) DeepCopy() *EntryTransformerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EntryTransformerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederatedBundleGCConfig) DeepCopyInto(out *FederatedBundleGCConfig) {
+	*out = *in
+	if in.KeepTrustDomains != nil {
+		in, out := &in.KeepTrustDomains, &out.KeepTrustDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederatedBundleGCConfig.
+func (in *FederatedBundleGCConfig) DeepCopy() *FederatedBundleGCConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FederatedBundleGCConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityReportConfig) DeepCopyInto(out *IdentityReportConfig) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityReportConfig.
+func (in *IdentityReportConfig) DeepCopy() *IdentityReportConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityReportConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedSPIFFEID) DeepCopyInto(out *NamespacedSPIFFEID) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedSPIFFEID.
+func (in *NamespacedSPIFFEID) DeepCopy() *NamespacedSPIFFEID {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedSPIFFEID)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NamespacedSPIFFEID) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedSPIFFEIDList) DeepCopyInto(out *NamespacedSPIFFEIDList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NamespacedSPIFFEID, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedSPIFFEIDList.
+func (in *NamespacedSPIFFEIDList) DeepCopy() *NamespacedSPIFFEIDList {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedSPIFFEIDList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NamespacedSPIFFEIDList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedSPIFFEIDSpec) DeepCopyInto(out *NamespacedSPIFFEIDSpec) {
+	*out = *in
+	out.TTL = in.TTL
+	out.X509SVIDTTL = in.X509SVIDTTL
+	out.JWTSVIDTTL = in.JWTSVIDTTL
+	if in.DNSNameTemplates != nil {
+		in, out := &in.DNSNameTemplates, &out.DNSNameTemplates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WorkloadSelectorTemplates != nil {
+		in, out := &in.WorkloadSelectorTemplates, &out.WorkloadSelectorTemplates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FederatesWith != nil {
+		in, out := &in.FederatesWith, &out.FederatesWith
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FederatesWithSets != nil {
+		in, out := &in.FederatesWithSets, &out.FederatesWithSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccountNames != nil {
+		in, out := &in.ServiceAccountNames, &out.ServiceAccountNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedSPIFFEIDSpec.
+func (in *NamespacedSPIFFEIDSpec) DeepCopy() *NamespacedSPIFFEIDSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedSPIFFEIDSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedSPIFFEIDStats) DeepCopyInto(out *NamespacedSPIFFEIDStats) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedSPIFFEIDStats.
+func (in *NamespacedSPIFFEIDStats) DeepCopy() *NamespacedSPIFFEIDStats {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedSPIFFEIDStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedSPIFFEIDStatus) DeepCopyInto(out *NamespacedSPIFFEIDStatus) {
+	*out = *in
+	out.Stats = in.Stats
+	if in.MaskedBy != nil {
+		in, out := &in.MaskedBy, &out.MaskedBy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Masking != nil {
+		in, out := &in.Masking, &out.Masking
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedSPIFFEIDStatus.
+func (in *NamespacedSPIFFEIDStatus) DeepCopy() *NamespacedSPIFFEIDStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedSPIFFEIDStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedSPIFFEIDsConfig) DeepCopyInto(out *NamespacedSPIFFEIDsConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedSPIFFEIDsConfig.
+func (in *NamespacedSPIFFEIDsConfig) DeepCopy() *NamespacedSPIFFEIDsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedSPIFFEIDsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodSPIFFEIDAnnotationConfig) DeepCopyInto(out *PodSPIFFEIDAnnotationConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSPIFFEIDAnnotationConfig.
+func (in *PodSPIFFEIDAnnotationConfig) DeepCopy() *PodSPIFFEIDAnnotationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PodSPIFFEIDAnnotationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrivilegedEntriesConfig) DeepCopyInto(out *PrivilegedEntriesConfig) {
+	*out = *in
+	if in.ClusterSPIFFEIDs != nil {
+		in, out := &in.ClusterSPIFFEIDs, &out.ClusterSPIFFEIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterStaticEntries != nil {
+		in, out := &in.ClusterStaticEntries, &out.ClusterStaticEntries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrivilegedEntriesConfig.
+func (in *PrivilegedEntriesConfig) DeepCopy() *PrivilegedEntriesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PrivilegedEntriesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteClusterConfig) DeepCopyInto(out *RemoteClusterConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteClusterConfig.
+func (in *RemoteClusterConfig) DeepCopy() *RemoteClusterConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteClusterConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SPIREAPIRetryConfig) DeepCopyInto(out *SPIREAPIRetryConfig) {
+	*out = *in
+	out.SPIREAPIRetryPolicy = in.SPIREAPIRetryPolicy
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make(map[string]SPIREAPIRetryPolicy, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SPIREAPIRetryConfig.
+func (in *SPIREAPIRetryConfig) DeepCopy() *SPIREAPIRetryConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SPIREAPIRetryConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SPIREAPIRetryPolicy) DeepCopyInto(out *SPIREAPIRetryPolicy) {
+	*out = *in
+	out.InitialBackoff = in.InitialBackoff
+	out.MaxBackoff = in.MaxBackoff
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SPIREAPIRetryPolicy.
+func (in *SPIREAPIRetryPolicy) DeepCopy() *SPIREAPIRetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SPIREAPIRetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SPIREHealthCheckConfig) DeepCopyInto(out *SPIREHealthCheckConfig) {
+	*out = *in
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SPIREHealthCheckConfig.
+func (in *SPIREHealthCheckConfig) DeepCopy() *SPIREHealthCheckConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SPIREHealthCheckConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SPIREServerTLSConfig) DeepCopyInto(out *SPIREServerTLSConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SPIREServerTLSConfig.
+func (in *SPIREServerTLSConfig) DeepCopy() *SPIREServerTLSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SPIREServerTLSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SPIREServerTarget) DeepCopyInto(out *SPIREServerTarget) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(SPIREServerTLSConfig)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SPIREServerTarget.
+func (in *SPIREServerTarget) DeepCopy() *SPIREServerTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(SPIREServerTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReference) DeepCopyInto(out *SecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReference.
+func (in *SecretReference) DeepCopy() *SecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecureMetricsConfig) DeepCopyInto(out *SecureMetricsConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecureMetricsConfig.
+func (in *SecureMetricsConfig) DeepCopy() *SecureMetricsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SecureMetricsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrustBundleNotificationConfig) DeepCopyInto(out *TrustBundleNotificationConfig) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]ConfigMapReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrustBundleNotificationConfig.
+func (in *TrustBundleNotificationConfig) DeepCopy() *TrustBundleNotificationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TrustBundleNotificationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookCABundleTarget) DeepCopyInto(out *WebhookCABundleTarget) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookCABundleTarget.
+func (in *WebhookCABundleTarget) DeepCopy() *WebhookCABundleTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookCABundleTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookSelfSignedCAConfig) DeepCopyInto(out *WebhookSelfSignedCAConfig) {
+	*out = *in
+	out.Secret = in.Secret
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookSelfSignedCAConfig.
+func (in *WebhookSelfSignedCAConfig) DeepCopy() *WebhookSelfSignedCAConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookSelfSignedCAConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookSettingsConfig) DeepCopyInto(out *WebhookSettingsConfig) {
+	*out = *in
+	if in.FailurePolicy != nil {
+		in, out := &in.FailurePolicy, &out.FailurePolicy
+		*out = new(admissionregistrationv1.FailurePolicyType)
+		**out = **in
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ObjectSelector != nil {
+		in, out := &in.ObjectSelector, &out.ObjectSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Webhooks != nil {
+		in, out := &in.Webhooks, &out.Webhooks
+		*out = make([]WebhookSettingsOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookSettingsConfig.
+func (in *WebhookSettingsConfig) DeepCopy() *WebhookSettingsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookSettingsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookSettingsOverride) DeepCopyInto(out *WebhookSettingsOverride) {
+	*out = *in
+	if in.FailurePolicy != nil {
+		in, out := &in.FailurePolicy, &out.FailurePolicy
+		*out = new(admissionregistrationv1.FailurePolicyType)
+		**out = **in
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ObjectSelector != nil {
+		in, out := &in.ObjectSelector, &out.ObjectSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]admissionregistrationv1.RuleWithOperations, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookSettingsOverride.
+func (in *WebhookSettingsOverride) DeepCopy() *WebhookSettingsOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookSettingsOverride)
+	in.DeepCopyInto(out)
+	return out
+}
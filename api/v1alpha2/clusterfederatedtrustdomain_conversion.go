@@ -0,0 +1,66 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"fmt"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+var _ conversion.Convertible = &ClusterFederatedTrustDomain{}
+
+// ConvertTo converts the ClusterFederatedTrustDomain to the v1alpha1 hub
+// version.
+func (src *ClusterFederatedTrustDomain) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1alpha1.ClusterFederatedTrustDomain)
+	if !ok {
+		return fmt.Errorf("expected a v1alpha1 ClusterFederatedTrustDomain but got %T", dstRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1alpha1.ClusterFederatedTrustDomainSpec{
+		TrustDomain:           src.Spec.TrustDomain,
+		BundleEndpointURL:     src.Spec.BundleEndpointURL,
+		BundleEndpointProfile: src.Spec.BundleEndpointProfile,
+		TrustDomainBundle:     src.Spec.TrustDomainBundle,
+		ClassName:             src.Spec.ClassName,
+		SPIREServer:           src.Spec.SPIREServer,
+	}
+	dst.Status = src.Status
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub version to the
+// ClusterFederatedTrustDomain.
+func (dst *ClusterFederatedTrustDomain) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1alpha1.ClusterFederatedTrustDomain)
+	if !ok {
+		return fmt.Errorf("expected a v1alpha1 ClusterFederatedTrustDomain but got %T", srcRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = ClusterFederatedTrustDomainSpec{
+		TrustDomain:           src.Spec.TrustDomain,
+		BundleEndpointURL:     src.Spec.BundleEndpointURL,
+		BundleEndpointProfile: src.Spec.BundleEndpointProfile,
+		TrustDomainBundle:     src.Spec.TrustDomainBundle,
+		ClassName:             src.Spec.ClassName,
+		SPIREServer:           src.Spec.SPIREServer,
+	}
+	dst.Status = src.Status
+	return nil
+}
@@ -0,0 +1,39 @@
+package v1alpha2
+
+import (
+	"testing"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterFederatedTrustDomainConversion(t *testing.T) {
+	serial := int64(3)
+	hub := &v1alpha1.ClusterFederatedTrustDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: v1alpha1.ClusterFederatedTrustDomainSpec{
+			TrustDomain:       "federated.test",
+			BundleEndpointURL: "https://federated.test/bundle",
+			BundleEndpointProfile: v1alpha1.BundleEndpointProfile{
+				Type:             v1alpha1.HTTPSSPIFFEProfileType,
+				EndpointSPIFFEID: "spiffe://federated.test/server",
+			},
+			TrustDomainBundle: "{}",
+			ClassName:         "class",
+			SPIREServer:       "other",
+		},
+		Status: v1alpha1.ClusterFederatedTrustDomainStatus{LastSyncedBundleSerial: &serial},
+	}
+
+	spoke := &ClusterFederatedTrustDomain{}
+	require.NoError(t, spoke.ConvertFrom(hub))
+	require.Equal(t, hub.Status, spoke.Status)
+
+	converted := &v1alpha1.ClusterFederatedTrustDomain{}
+	require.NoError(t, spoke.ConvertTo(converted))
+	require.Equal(t, hub, converted)
+
+	require.Error(t, spoke.ConvertFrom(&v1alpha1.ClusterSPIFFEID{}))
+	require.Error(t, spoke.ConvertTo(&v1alpha1.ClusterSPIFFEID{}))
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterFederatedTrustDomainSpec defines the desired state of
+// ClusterFederatedTrustDomain. It has the same fields as v1alpha1, which may
+// be defaulted at admission.
+type ClusterFederatedTrustDomainSpec struct {
+	// TrustDomain is the name of the trust domain to federate with (e.g. example.org)
+	// +kubebuilder:validation:Pattern="[a-z0-9._-]{1,255}"
+	TrustDomain string `json:"trustDomain"`
+
+	// BundleEndpointURL is the URL of the bundle endpoint. It must be an
+	// HTTPS URL and cannot contain userinfo (i.e. username/password).
+	BundleEndpointURL string `json:"bundleEndpointURL"`
+
+	// BundleEndpointProfile is the profile for the bundle endpoint.
+	BundleEndpointProfile v1alpha1.BundleEndpointProfile `json:"bundleEndpointProfile"`
+
+	// TrustDomainBundle is the contents of the bundle for the referenced trust
+	// domain, in SPIFFE bundle (JWKS) format. Both the X.509 and the JWT
+	// authorities of the bundle are passed to SPIRE Server. This field is
+	// optional when the resource is created.
+	// +kubebuilder:validation:Optional
+	TrustDomainBundle string `json:"trustDomainBundle,omitempty"`
+
+	// ClassName is the class of the controller manager instance that
+	// reconciles this ClusterFederatedTrustDomain. If unset, it may be
+	// defaulted at admission to the class of the controller manager.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// SPIREServer is the name of the SPIRE Server target, as configured in
+	// the controller manager configuration, that the federation relationship
+	// is set on. If unset, it is set on the default SPIRE Server.
+	// +optional
+	SPIREServer string `json:"spireServer,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// +kubebuilder:printcolumn:name="Trust Domain",type=string,JSONPath=`.spec.trustDomain`
+// +kubebuilder:printcolumn:name="Endpoint URL",type=string,JSONPath=`.spec.bundleEndpointURL`
+// +kubebuilder:printcolumn:name="Reachable",type=string,JSONPath=`.status.conditions[?(@.type=="Reachable")].status`
+// ClusterFederatedTrustDomain is the Schema for the clusterfederatedtrustdomains API.
+// The status is unchanged from v1alpha1.
+type ClusterFederatedTrustDomain struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterFederatedTrustDomainSpec            `json:"spec,omitempty"`
+	Status v1alpha1.ClusterFederatedTrustDomainStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterFederatedTrustDomainList contains a list of ClusterFederatedTrustDomain
+type ClusterFederatedTrustDomainList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterFederatedTrustDomain `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterFederatedTrustDomain{}, &ClusterFederatedTrustDomainList{})
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var clusterfederatedtrustdomainlog = logf.Log.WithName("clusterfederatedtrustdomain-resource")
+
+// SetupWebhookWithManager registers the defaulting webhook, and the
+// conversion webhook from and to v1alpha1. ClusterFederatedTrustDomains are
+// validated by the v1alpha1 validating webhook after conversion.
+func (r *ClusterFederatedTrustDomain) SetupWebhookWithManager(mgr ctrl.Manager, options v1alpha1.WebhookOptions) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(&clusterFederatedTrustDomainDefaulter{
+			className: options.ClassName,
+			defaults:  options.ClusterFederatedTrustDomainDefaults,
+		}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-spire-spiffe-io-v1alpha2-clusterfederatedtrustdomain,mutating=true,failurePolicy=fail,sideEffects=None,groups=spire.spiffe.io,resources=clusterfederatedtrustdomains,verbs=create;update,versions=v1alpha2,name=mclusterfederatedtrustdomain.kb.io,admissionReviewVersions=v1,matchPolicy=Exact
+
+// clusterFederatedTrustDomainDefaulter defaults the
+// ClusterFederatedTrustDomains created or updated through v1alpha2. The
+// webhook only matches v1alpha2 requests so that existing v1alpha1 manifests
+// are stored as is.
+type clusterFederatedTrustDomainDefaulter struct {
+	className string
+	defaults  *v1alpha1.ClusterFederatedTrustDomainDefaultsConfig
+}
+
+var _ webhook.CustomDefaulter = &clusterFederatedTrustDomainDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (d *clusterFederatedTrustDomainDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	r, ok := obj.(*ClusterFederatedTrustDomain)
+	if !ok {
+		return fmt.Errorf("expected a ClusterFederatedTrustDomain but got %T", obj)
+	}
+	clusterfederatedtrustdomainlog.Info("default", "name", r.Name)
+	if d.defaults != nil && d.defaults.SetClassName && r.Spec.ClassName == "" {
+		r.Spec.ClassName = d.className
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package v1alpha2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterFederatedTrustDomainDefaulter(t *testing.T) {
+	defaults := &v1alpha1.ClusterFederatedTrustDomainDefaultsConfig{SetClassName: true}
+
+	for _, tt := range []struct {
+		name              string
+		defaults          *v1alpha1.ClusterFederatedTrustDomainDefaultsConfig
+		className         string
+		expectedClassName string
+	}{
+		{
+			name: "no defaults",
+		},
+		{
+			name:              "unset className is defaulted",
+			defaults:          defaults,
+			expectedClassName: "class",
+		},
+		{
+			name:              "set className is kept",
+			defaults:          defaults,
+			className:         "other",
+			expectedClassName: "other",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			defaulter := &clusterFederatedTrustDomainDefaulter{className: "class", defaults: tt.defaults}
+			obj := &ClusterFederatedTrustDomain{Spec: ClusterFederatedTrustDomainSpec{ClassName: tt.className}}
+			require.NoError(t, defaulter.Default(context.Background(), obj))
+			require.Equal(t, tt.expectedClassName, obj.Spec.ClassName)
+		})
+	}
+
+	t.Run("wrong type", func(t *testing.T) {
+		defaulter := &clusterFederatedTrustDomainDefaulter{defaults: defaults}
+		require.Error(t, defaulter.Default(context.Background(), &v1alpha1.ClusterFederatedTrustDomain{}))
+	})
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"fmt"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+var _ conversion.Convertible = &ClusterSPIFFEID{}
+
+// ConvertTo converts the ClusterSPIFFEID to the v1alpha1 hub version.
+func (src *ClusterSPIFFEID) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1alpha1.ClusterSPIFFEID)
+	if !ok {
+		return fmt.Errorf("expected a v1alpha1 ClusterSPIFFEID but got %T", dstRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1alpha1.ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate:          src.Spec.SPIFFEIDTemplate,
+		X509SVIDTTL:               src.Spec.X509SVIDTTL,
+		JWTSVIDTTL:                src.Spec.JWTSVIDTTL,
+		DNSNameTemplates:          src.Spec.DNSNameTemplates,
+		AutoPopulateDNSNames:      src.Spec.AutoPopulateDNSNames,
+		WorkloadSelectorTemplates: src.Spec.WorkloadSelectorTemplates,
+		HintTemplate:              src.Spec.HintTemplate,
+		FederatesWith:             src.Spec.FederatesWith,
+		FederatesWithSets:         src.Spec.FederatesWithSets,
+		NamespaceSelector:         src.Spec.NamespaceSelector,
+		PodSelector:               src.Spec.PodSelector,
+		PodExcludeSelector:        src.Spec.PodExcludeSelector,
+		ServiceAccountNames:       src.Spec.ServiceAccountNames,
+		StaticPods:                src.Spec.StaticPods,
+		HostNetworkPods:           src.Spec.HostNetworkPods,
+		JobPods:                   src.Spec.JobPods,
+		RegistrationMode:          src.Spec.RegistrationMode,
+		NodeAlias:                 src.Spec.NodeAlias,
+		Fallback:                  src.Spec.Fallback,
+		Admin:                     src.Spec.Admin,
+		Downstream:                src.Spec.Downstream,
+		ClassName:                 src.Spec.ClassName,
+		SPIREServer:               src.Spec.SPIREServer,
+	}
+	dst.Status = src.Status
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub version to the ClusterSPIFFEID. The
+// ttl field is converted to x509SVIDTTL, which it is an alias of.
+func (dst *ClusterSPIFFEID) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1alpha1.ClusterSPIFFEID)
+	if !ok {
+		return fmt.Errorf("expected a v1alpha1 ClusterSPIFFEID but got %T", srcRaw)
+	}
+	x509SVIDTTL := src.Spec.X509SVIDTTL
+	if x509SVIDTTL.Duration == 0 {
+		x509SVIDTTL = src.Spec.TTL
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate:          src.Spec.SPIFFEIDTemplate,
+		X509SVIDTTL:               x509SVIDTTL,
+		JWTSVIDTTL:                src.Spec.JWTSVIDTTL,
+		DNSNameTemplates:          src.Spec.DNSNameTemplates,
+		AutoPopulateDNSNames:      src.Spec.AutoPopulateDNSNames,
+		WorkloadSelectorTemplates: src.Spec.WorkloadSelectorTemplates,
+		HintTemplate:              src.Spec.HintTemplate,
+		FederatesWith:             src.Spec.FederatesWith,
+		FederatesWithSets:         src.Spec.FederatesWithSets,
+		NamespaceSelector:         src.Spec.NamespaceSelector,
+		PodSelector:               src.Spec.PodSelector,
+		PodExcludeSelector:        src.Spec.PodExcludeSelector,
+		ServiceAccountNames:       src.Spec.ServiceAccountNames,
+		StaticPods:                src.Spec.StaticPods,
+		HostNetworkPods:           src.Spec.HostNetworkPods,
+		JobPods:                   src.Spec.JobPods,
+		RegistrationMode:          src.Spec.RegistrationMode,
+		NodeAlias:                 src.Spec.NodeAlias,
+		Fallback:                  src.Spec.Fallback,
+		Admin:                     src.Spec.Admin,
+		Downstream:                src.Spec.Downstream,
+		ClassName:                 src.Spec.ClassName,
+		SPIREServer:               src.Spec.SPIREServer,
+	}
+	dst.Status = src.Status
+	return nil
+}
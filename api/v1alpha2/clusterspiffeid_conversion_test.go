@@ -0,0 +1,70 @@
+package v1alpha2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterSPIFFEIDConversion(t *testing.T) {
+	hub := &v1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: v1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:          "spiffe://domain.test/workload",
+			X509SVIDTTL:               metav1.Duration{Duration: time.Hour},
+			JWTSVIDTTL:                metav1.Duration{Duration: time.Minute},
+			DNSNameTemplates:          []string{"{{ .PodMeta.Name }}.test"},
+			AutoPopulateDNSNames:      true,
+			WorkloadSelectorTemplates: []string{"k8s:container-name:app"},
+			HintTemplate:              "hint",
+			FederatesWith:             []string{"federated.test"},
+			FederatesWithSets:         []string{"set"},
+			NamespaceSelector:         &metav1.LabelSelector{MatchLabels: map[string]string{"namespace": "selected"}},
+			PodSelector:               &metav1.LabelSelector{MatchLabels: map[string]string{"pod": "selected"}},
+			PodExcludeSelector:        &metav1.LabelSelector{MatchLabels: map[string]string{"pod": "excluded"}},
+			ServiceAccountNames:       []string{"frontend-*"},
+			StaticPods:                v1alpha1.PodInclusionPolicyExclude,
+			HostNetworkPods:           v1alpha1.PodInclusionPolicyExclude,
+			JobPods:                   v1alpha1.PodInclusionPolicyExclude,
+			RegistrationMode:          v1alpha1.RegistrationModeServiceAccount,
+			NodeAlias:                 "alias",
+			Fallback:                  true,
+			Admin:                     true,
+			Downstream:                true,
+			ClassName:                 "class",
+			SPIREServer:               "other",
+		},
+		Status: v1alpha1.ClusterSPIFFEIDStatus{
+			Stats: v1alpha1.ClusterSPIFFEIDStats{PodsSelected: 1, EntriesToSet: 1},
+		},
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		spoke := &ClusterSPIFFEID{}
+		require.NoError(t, spoke.ConvertFrom(hub))
+		require.Equal(t, hub.Spec.X509SVIDTTL, spoke.Spec.X509SVIDTTL)
+		require.Equal(t, hub.Status, spoke.Status)
+
+		converted := &v1alpha1.ClusterSPIFFEID{}
+		require.NoError(t, spoke.ConvertTo(converted))
+		require.Equal(t, hub, converted)
+	})
+
+	t.Run("ttl is converted to x509SVIDTTL", func(t *testing.T) {
+		legacy := hub.DeepCopy()
+		legacy.Spec.X509SVIDTTL = metav1.Duration{}
+		legacy.Spec.TTL = metav1.Duration{Duration: 2 * time.Hour}
+
+		spoke := &ClusterSPIFFEID{}
+		require.NoError(t, spoke.ConvertFrom(legacy))
+		require.Equal(t, 2*time.Hour, spoke.Spec.X509SVIDTTL.Duration)
+
+		converted := &v1alpha1.ClusterSPIFFEID{}
+		require.NoError(t, spoke.ConvertTo(converted))
+		require.Zero(t, converted.Spec.TTL)
+		require.Equal(t, 2*time.Hour, converted.Spec.X509SVIDTTL.Duration)
+	})
+}
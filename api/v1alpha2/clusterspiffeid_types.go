@@ -0,0 +1,194 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterSPIFFEIDSpec defines the desired state of ClusterSPIFFEID. It is the
+// v1alpha1 spec without the ttl alias of x509SVIDTTL, and with the defaults
+// of the optional fields made explicit.
+type ClusterSPIFFEIDSpec struct {
+	// SPIFFEID is the SPIFFE ID template. The node and pod spec are made
+	// available to the template under .NodeSpec, .PodSpec respectively.
+	SPIFFEIDTemplate string `json:"spiffeIDTemplate"`
+
+	// X509SVIDTTL indicates an upper-bound time-to-live for X509-SVIDs
+	// minted for this ClusterSPIFFEID. If unset, it is defaulted at
+	// admission from the controller manager configuration, or else a
+	// default will be chosen by SPIRE Server.
+	// +optional
+	X509SVIDTTL metav1.Duration `json:"x509SVIDTTL,omitempty"`
+
+	// JWTSVIDTTL indicates an upper-bound time-to-live for JWT-SVIDs minted
+	// for this ClusterSPIFFEID. If unset, it is defaulted at admission from
+	// the controller manager configuration, or else a default will be
+	// chosen by SPIRE Server. Requires SPIRE Server 1.5.0 or later.
+	// +optional
+	JWTSVIDTTL metav1.Duration `json:"jwtSVIDTTL,omitempty"`
+
+	// DNSNameTemplates represents templates for extra DNS names that are
+	// applicable to SVIDs minted for this ClusterSPIFFEID. The node and pod
+	// spec are made available to the template under .NodeSpec, .PodSpec
+	// respectively. If unset, they are defaulted at admission from the
+	// controller manager configuration.
+	// +optional
+	DNSNameTemplates []string `json:"dnsNameTemplates,omitempty"`
+
+	// AutoPopulateDNSNames, if true, adds the DNS names of the Services that
+	// select the pod to the DNS names of the SVIDs minted for this
+	// ClusterSPIFFEID.
+	// +optional
+	AutoPopulateDNSNames bool `json:"autoPopulateDNSNames,omitempty"`
+
+	// WorkloadSelectorTemplates are templates to produce arbitrary workload
+	// selectors that apply to a given workload before it will receive this
+	// SPIFFE ID. The rendered value is interpreted by SPIRE and are of the
+	// form type:value. The node and pod spec are made available to the
+	// template under .NodeSpec, .PodSpec respectively.
+	// +optional
+	WorkloadSelectorTemplates []string `json:"workloadSelectorTemplates,omitempty"`
+
+	// HintTemplate is a template for the hint provided to workloads to
+	// help them choose between multiple SVIDs. Requires SPIRE Server 1.6.3
+	// or later.
+	// +optional
+	HintTemplate string `json:"hintTemplate,omitempty"`
+
+	// FederatesWith is a list of trust domain names that workloads that
+	// obtain this SPIFFE ID will federate with.
+	// +optional
+	FederatesWith []string `json:"federatesWith,omitempty"`
+
+	// FederatesWithSets is a list of ClusterTrustDomainSet names. Workloads
+	// that obtain this SPIFFE ID will also federate with the trust domains
+	// in each set.
+	// +optional
+	FederatesWithSets []string `json:"federatesWithSets,omitempty"`
+
+	// NamespaceSelector selects the namespaces that are targeted by this
+	// CRD.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// PodSelector selects the pods that are targeted by this CRD.
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// PodExcludeSelector, if set, excludes the pods whose labels match
+	// from those targeted by this CRD.
+	// +optional
+	PodExcludeSelector *metav1.LabelSelector `json:"podExcludeSelector,omitempty"`
+
+	// ServiceAccountNames selects the pods that are targeted by this CRD by
+	// the name of the service account the pod runs as. Each value is either
+	// a service account name or a shell file name pattern. If empty, pods
+	// running as any service account are targeted.
+	// +optional
+	ServiceAccountNames []string `json:"serviceAccountNames,omitempty"`
+
+	// StaticPods determines whether static pods are targeted by this CRD.
+	// +kubebuilder:default=Include
+	// +optional
+	StaticPods v1alpha1.PodInclusionPolicy `json:"staticPods,omitempty"`
+
+	// HostNetworkPods determines whether pods that use the host network
+	// namespace are targeted by this CRD.
+	// +kubebuilder:default=Include
+	// +optional
+	HostNetworkPods v1alpha1.PodInclusionPolicy `json:"hostNetworkPods,omitempty"`
+
+	// JobPods determines whether pods created by Jobs, including those of
+	// CronJobs, are targeted by this CRD.
+	// +kubebuilder:default=Include
+	// +optional
+	JobPods v1alpha1.PodInclusionPolicy `json:"jobPods,omitempty"`
+
+	// RegistrationMode determines whether an entry is registered for each
+	// pod targeted by this CRD, or for each of their service accounts.
+	// +kubebuilder:default=Pod
+	// +optional
+	RegistrationMode v1alpha1.RegistrationMode `json:"registrationMode,omitempty"`
+
+	// NodeAlias is the name of the ClusterNodeAlias that parents the entries
+	// in the ServiceAccount registration mode. Required in the
+	// ServiceAccount registration mode.
+	// +optional
+	NodeAlias string `json:"nodeAlias,omitempty"`
+
+	// Fallback, if true, makes this ClusterSPIFFEID the default identity of
+	// the pods it selects that are not selected by any other ClusterSPIFFEID
+	// or NamespacedSPIFFEID.
+	// +optional
+	Fallback bool `json:"fallback,omitempty"`
+
+	// Admin indicates whether or not the SVID can be used to access the SPIRE
+	// administrative APIs.
+	// +optional
+	Admin bool `json:"admin,omitempty"`
+
+	// Downstream indicates that the entry describes a downstream SPIRE server.
+	// +optional
+	Downstream bool `json:"downstream,omitempty"`
+
+	// ClassName is the class of the controller manager instance that
+	// reconciles this ClusterSPIFFEID. If unset, it may be defaulted at
+	// admission to the class of the controller manager.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// SPIREServer is the name of the SPIRE Server target, as configured in
+	// the controller manager configuration, that the entries are registered
+	// with. If unset, the entries are registered with the default SPIRE
+	// Server.
+	// +optional
+	SPIREServer string `json:"spireServer,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Pods",type=integer,JSONPath=`.status.stats.podsSelected`
+//+kubebuilder:printcolumn:name="Entries",type=integer,JSONPath=`.status.stats.entriesToSet`
+//+kubebuilder:printcolumn:name="Masked",type=integer,JSONPath=`.status.stats.entriesMasked`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterSPIFFEID is the Schema for the clusterspiffeids API. The status is
+// unchanged from v1alpha1.
+type ClusterSPIFFEID struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterSPIFFEIDSpec            `json:"spec,omitempty"`
+	Status v1alpha1.ClusterSPIFFEIDStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterSPIFFEIDList contains a list of ClusterSPIFFEID
+type ClusterSPIFFEIDList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterSPIFFEID `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterSPIFFEID{}, &ClusterSPIFFEIDList{})
+}
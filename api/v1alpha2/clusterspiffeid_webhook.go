@@ -0,0 +1,84 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var clusterspiffeidlog = logf.Log.WithName("clusterspiffeid-resource")
+
+// SetupWebhookWithManager registers the defaulting webhook, and the
+// conversion webhook from and to v1alpha1. ClusterSPIFFEIDs are validated
+// by the v1alpha1 validating webhook after conversion.
+func (r *ClusterSPIFFEID) SetupWebhookWithManager(mgr ctrl.Manager, options v1alpha1.WebhookOptions) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(&clusterSPIFFEIDDefaulter{
+			className: options.ClassName,
+			defaults:  options.ClusterSPIFFEIDDefaults,
+		}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-spire-spiffe-io-v1alpha2-clusterspiffeid,mutating=true,failurePolicy=fail,sideEffects=None,groups=spire.spiffe.io,resources=clusterspiffeids,verbs=create;update,versions=v1alpha2,name=mclusterspiffeid.kb.io,admissionReviewVersions=v1,matchPolicy=Exact
+
+// clusterSPIFFEIDDefaulter defaults the ClusterSPIFFEIDs created or updated
+// through v1alpha2. The webhook only matches v1alpha2 requests so that
+// existing v1alpha1 manifests are stored as is.
+type clusterSPIFFEIDDefaulter struct {
+	className string
+	defaults  *v1alpha1.ClusterSPIFFEIDDefaultsConfig
+}
+
+var _ webhook.CustomDefaulter = &clusterSPIFFEIDDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (d *clusterSPIFFEIDDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	r, ok := obj.(*ClusterSPIFFEID)
+	if !ok {
+		return fmt.Errorf("expected a ClusterSPIFFEID but got %T", obj)
+	}
+	clusterspiffeidlog.Info("default", "name", r.Name)
+	d.setDefaults(&r.Spec)
+	return nil
+}
+
+func (d *clusterSPIFFEIDDefaulter) setDefaults(spec *ClusterSPIFFEIDSpec) {
+	if d.defaults == nil {
+		return
+	}
+	if spec.ClassName == "" && d.defaults.SetClassName {
+		spec.ClassName = d.className
+	}
+	if spec.X509SVIDTTL.Duration == 0 {
+		spec.X509SVIDTTL = d.defaults.X509SVIDTTL
+	}
+	if spec.JWTSVIDTTL.Duration == 0 {
+		spec.JWTSVIDTTL = d.defaults.JWTSVIDTTL
+	}
+	if len(spec.DNSNameTemplates) == 0 && len(d.defaults.DNSNameTemplates) > 0 {
+		spec.DNSNameTemplates = append([]string(nil), d.defaults.DNSNameTemplates...)
+	}
+}
@@ -0,0 +1,91 @@
+package v1alpha2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterSPIFFEIDDefaulter(t *testing.T) {
+	defaults := &v1alpha1.ClusterSPIFFEIDDefaultsConfig{
+		SetClassName:     true,
+		X509SVIDTTL:      metav1.Duration{Duration: time.Hour},
+		JWTSVIDTTL:       metav1.Duration{Duration: time.Minute},
+		DNSNameTemplates: []string{"{{ .PodMeta.Name }}.test"},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		className string
+		defaults  *v1alpha1.ClusterSPIFFEIDDefaultsConfig
+		spec      ClusterSPIFFEIDSpec
+		expected  ClusterSPIFFEIDSpec
+	}{
+		{
+			name:      "no defaults",
+			className: "class",
+			spec:      ClusterSPIFFEIDSpec{SPIFFEIDTemplate: "spiffe://domain.test/workload"},
+			expected:  ClusterSPIFFEIDSpec{SPIFFEIDTemplate: "spiffe://domain.test/workload"},
+		},
+		{
+			name:      "unset fields are defaulted",
+			className: "class",
+			defaults:  defaults,
+			spec:      ClusterSPIFFEIDSpec{SPIFFEIDTemplate: "spiffe://domain.test/workload"},
+			expected: ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://domain.test/workload",
+				ClassName:        "class",
+				X509SVIDTTL:      metav1.Duration{Duration: time.Hour},
+				JWTSVIDTTL:       metav1.Duration{Duration: time.Minute},
+				DNSNameTemplates: []string{"{{ .PodMeta.Name }}.test"},
+			},
+		},
+		{
+			name:      "set fields are kept",
+			className: "class",
+			defaults:  defaults,
+			spec: ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://domain.test/workload",
+				ClassName:        "other",
+				X509SVIDTTL:      metav1.Duration{Duration: 2 * time.Hour},
+				JWTSVIDTTL:       metav1.Duration{Duration: 2 * time.Minute},
+				DNSNameTemplates: []string{"set.test"},
+			},
+			expected: ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://domain.test/workload",
+				ClassName:        "other",
+				X509SVIDTTL:      metav1.Duration{Duration: 2 * time.Hour},
+				JWTSVIDTTL:       metav1.Duration{Duration: 2 * time.Minute},
+				DNSNameTemplates: []string{"set.test"},
+			},
+		},
+		{
+			name:     "className is not defaulted without a class",
+			defaults: defaults,
+			spec:     ClusterSPIFFEIDSpec{SPIFFEIDTemplate: "spiffe://domain.test/workload"},
+			expected: ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://domain.test/workload",
+				X509SVIDTTL:      metav1.Duration{Duration: time.Hour},
+				JWTSVIDTTL:       metav1.Duration{Duration: time.Minute},
+				DNSNameTemplates: []string{"{{ .PodMeta.Name }}.test"},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			defaulter := &clusterSPIFFEIDDefaulter{className: tt.className, defaults: tt.defaults}
+			obj := &ClusterSPIFFEID{Spec: tt.spec}
+			require.NoError(t, defaulter.Default(context.Background(), obj))
+			require.Equal(t, tt.expected, obj.Spec)
+		})
+	}
+
+	t.Run("wrong type", func(t *testing.T) {
+		defaulter := &clusterSPIFFEIDDefaulter{defaults: defaults}
+		require.Error(t, defaulter.Default(context.Background(), &v1alpha1.ClusterSPIFFEID{}))
+	})
+}
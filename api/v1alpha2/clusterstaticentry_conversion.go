@@ -0,0 +1,78 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"fmt"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+var _ conversion.Convertible = &ClusterStaticEntry{}
+
+// ConvertTo converts the ClusterStaticEntry to the v1alpha1 hub version.
+func (src *ClusterStaticEntry) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1alpha1.ClusterStaticEntry)
+	if !ok {
+		return fmt.Errorf("expected a v1alpha1 ClusterStaticEntry but got %T", dstRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1alpha1.ClusterStaticEntrySpec{
+		SPIFFEID:      src.Spec.SPIFFEID,
+		ParentID:      src.Spec.ParentID,
+		Selectors:     src.Spec.Selectors,
+		FederatesWith: src.Spec.FederatesWith,
+		X509SVIDTTL:   src.Spec.X509SVIDTTL,
+		JWTSVIDTTL:    src.Spec.JWTSVIDTTL,
+		DNSNames:      src.Spec.DNSNames,
+		Hint:          src.Spec.Hint,
+		Admin:         src.Spec.Admin,
+		Downstream:    src.Spec.Downstream,
+		StoreSVID:     src.Spec.StoreSVID,
+		ClassName:     src.Spec.ClassName,
+		SPIREServer:   src.Spec.SPIREServer,
+	}
+	dst.Status = src.Status
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub version to the ClusterStaticEntry.
+func (dst *ClusterStaticEntry) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1alpha1.ClusterStaticEntry)
+	if !ok {
+		return fmt.Errorf("expected a v1alpha1 ClusterStaticEntry but got %T", srcRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = ClusterStaticEntrySpec{
+		SPIFFEID:      src.Spec.SPIFFEID,
+		ParentID:      src.Spec.ParentID,
+		Selectors:     src.Spec.Selectors,
+		FederatesWith: src.Spec.FederatesWith,
+		X509SVIDTTL:   src.Spec.X509SVIDTTL,
+		JWTSVIDTTL:    src.Spec.JWTSVIDTTL,
+		DNSNames:      src.Spec.DNSNames,
+		Hint:          src.Spec.Hint,
+		Admin:         src.Spec.Admin,
+		Downstream:    src.Spec.Downstream,
+		StoreSVID:     src.Spec.StoreSVID,
+		ClassName:     src.Spec.ClassName,
+		SPIREServer:   src.Spec.SPIREServer,
+	}
+	dst.Status = src.Status
+	return nil
+}
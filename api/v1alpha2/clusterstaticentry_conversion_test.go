@@ -0,0 +1,43 @@
+package v1alpha2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterStaticEntryConversion(t *testing.T) {
+	hub := &v1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: v1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:      "spiffe://domain.test/workload",
+			ParentID:      "spiffe://domain.test/parent",
+			Selectors:     []string{"a:1"},
+			FederatesWith: []string{"federated.test"},
+			X509SVIDTTL:   metav1.Duration{Duration: time.Hour},
+			JWTSVIDTTL:    metav1.Duration{Duration: time.Minute},
+			DNSNames:      []string{"workload.test"},
+			Hint:          "hint",
+			Admin:         true,
+			Downstream:    true,
+			StoreSVID:     true,
+			ClassName:     "class",
+			SPIREServer:   "other",
+		},
+		Status: v1alpha1.ClusterStaticEntryStatus{Rendered: true, Set: true},
+	}
+
+	spoke := &ClusterStaticEntry{}
+	require.NoError(t, spoke.ConvertFrom(hub))
+	require.Equal(t, hub.Status, spoke.Status)
+
+	converted := &v1alpha1.ClusterStaticEntry{}
+	require.NoError(t, spoke.ConvertTo(converted))
+	require.Equal(t, hub, converted)
+
+	require.Error(t, spoke.ConvertFrom(&v1alpha1.ClusterSPIFFEID{}))
+	require.Error(t, spoke.ConvertTo(&v1alpha1.ClusterSPIFFEID{}))
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterStaticEntrySpec defines the desired state of ClusterStaticEntry. It
+// has the same fields as v1alpha1, which may be defaulted at admission.
+type ClusterStaticEntrySpec struct {
+	// SPIFFEID is the SPIFFE ID of the entry.
+	SPIFFEID string `json:"spiffeID"`
+
+	// ParentID is the SPIFFE ID of the parent of the entry.
+	ParentID string `json:"parentID"`
+
+	// Selectors are the selectors of the entry, as "type:value".
+	Selectors []string `json:"selectors"`
+
+	// FederatesWith are the trust domains whose bundles are served to the
+	// workload along with the bundle of its own trust domain.
+	// +optional
+	FederatesWith []string `json:"federatesWith,omitempty"`
+
+	// X509SVIDTTL indicates an upper-bound time-to-live for X509-SVIDs
+	// minted for this entry. If unset, it is defaulted at admission from the
+	// controller manager configuration, or else a default will be chosen by
+	// SPIRE Server.
+	// +optional
+	X509SVIDTTL metav1.Duration `json:"x509SVIDTTL,omitempty"`
+
+	// JWTSVIDTTL indicates an upper-bound time-to-live for JWT-SVIDs minted
+	// for this entry. If unset, it is defaulted at admission from the
+	// controller manager configuration, or else a default will be chosen by
+	// SPIRE Server.
+	// +optional
+	JWTSVIDTTL metav1.Duration `json:"jwtSVIDTTL,omitempty"`
+
+	// DNSNames are the DNS names of the X509-SVIDs minted for this entry.
+	// +optional
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// Hint is the hint of the entry, passed to the workload to tell its
+	// identities apart.
+	// +optional
+	Hint string `json:"hint,omitempty"`
+
+	// Admin indicates that the entry describes an admin workload.
+	// +optional
+	Admin bool `json:"admin,omitempty"`
+
+	// Downstream indicates that the entry describes a downstream SPIRE
+	// server.
+	// +optional
+	Downstream bool `json:"downstream,omitempty"`
+
+	// StoreSVID indicates that the SVID is stored by an SVIDStore plugin
+	// instead of being served to workloads over the Workload API. The
+	// selectors must all be of the same type, which names the store.
+	// +optional
+	StoreSVID bool `json:"storeSVID,omitempty"`
+
+	// ClassName is the class of the controller manager instance that
+	// reconciles this ClusterStaticEntry. If unset, it may be defaulted at
+	// admission to the class of the controller manager.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// SPIREServer is the name of the SPIRE Server target, as configured in
+	// the controller manager configuration, that the entry is registered
+	// with. If unset, the entry is registered with the default SPIRE Server.
+	// +optional
+	SPIREServer string `json:"spireServer,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// ClusterStaticEntry is the Schema for the clusterstaticentries API. The
+// status is unchanged from v1alpha1.
+type ClusterStaticEntry struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterStaticEntrySpec            `json:"spec,omitempty"`
+	Status v1alpha1.ClusterStaticEntryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterStaticEntryList contains a list of ClusterStaticEntry
+type ClusterStaticEntryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterStaticEntry `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterStaticEntry{}, &ClusterStaticEntryList{})
+}
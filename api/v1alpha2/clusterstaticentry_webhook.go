@@ -0,0 +1,81 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var clusterstaticentrylog = logf.Log.WithName("clusterstaticentry-resource")
+
+// SetupWebhookWithManager registers the defaulting webhook, and the
+// conversion webhook from and to v1alpha1. ClusterStaticEntries are
+// validated by the v1alpha1 validating webhook after conversion.
+func (r *ClusterStaticEntry) SetupWebhookWithManager(mgr ctrl.Manager, options v1alpha1.WebhookOptions) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(&clusterStaticEntryDefaulter{
+			className: options.ClassName,
+			defaults:  options.ClusterStaticEntryDefaults,
+		}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-spire-spiffe-io-v1alpha2-clusterstaticentry,mutating=true,failurePolicy=fail,sideEffects=None,groups=spire.spiffe.io,resources=clusterstaticentries,verbs=create;update,versions=v1alpha2,name=mclusterstaticentry.kb.io,admissionReviewVersions=v1,matchPolicy=Exact
+
+// clusterStaticEntryDefaulter defaults the ClusterStaticEntries created or
+// updated through v1alpha2. The webhook only matches v1alpha2 requests so
+// that existing v1alpha1 manifests are stored as is.
+type clusterStaticEntryDefaulter struct {
+	className string
+	defaults  *v1alpha1.ClusterStaticEntryDefaultsConfig
+}
+
+var _ webhook.CustomDefaulter = &clusterStaticEntryDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (d *clusterStaticEntryDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	r, ok := obj.(*ClusterStaticEntry)
+	if !ok {
+		return fmt.Errorf("expected a ClusterStaticEntry but got %T", obj)
+	}
+	clusterstaticentrylog.Info("default", "name", r.Name)
+	d.setDefaults(&r.Spec)
+	return nil
+}
+
+func (d *clusterStaticEntryDefaulter) setDefaults(spec *ClusterStaticEntrySpec) {
+	if d.defaults == nil {
+		return
+	}
+	if spec.ClassName == "" && d.defaults.SetClassName {
+		spec.ClassName = d.className
+	}
+	if spec.X509SVIDTTL.Duration == 0 {
+		spec.X509SVIDTTL = d.defaults.X509SVIDTTL
+	}
+	if spec.JWTSVIDTTL.Duration == 0 {
+		spec.JWTSVIDTTL = d.defaults.JWTSVIDTTL
+	}
+}
@@ -0,0 +1,76 @@
+package v1alpha2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterStaticEntryDefaulter(t *testing.T) {
+	defaults := &v1alpha1.ClusterStaticEntryDefaultsConfig{
+		SetClassName: true,
+		X509SVIDTTL:  metav1.Duration{Duration: time.Hour},
+		JWTSVIDTTL:   metav1.Duration{Duration: time.Minute},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		className string
+		defaults  *v1alpha1.ClusterStaticEntryDefaultsConfig
+		spec      ClusterStaticEntrySpec
+		expected  ClusterStaticEntrySpec
+	}{
+		{
+			name:      "no defaults",
+			className: "class",
+			spec:      ClusterStaticEntrySpec{SPIFFEID: "spiffe://domain.test/workload"},
+			expected:  ClusterStaticEntrySpec{SPIFFEID: "spiffe://domain.test/workload"},
+		},
+		{
+			name:      "unset fields are defaulted",
+			className: "class",
+			defaults:  defaults,
+			spec:      ClusterStaticEntrySpec{SPIFFEID: "spiffe://domain.test/workload"},
+			expected: ClusterStaticEntrySpec{
+				SPIFFEID:    "spiffe://domain.test/workload",
+				ClassName:   "class",
+				X509SVIDTTL: metav1.Duration{Duration: time.Hour},
+				JWTSVIDTTL:  metav1.Duration{Duration: time.Minute},
+			},
+		},
+		{
+			name:      "set fields are kept",
+			className: "class",
+			defaults:  defaults,
+			spec: ClusterStaticEntrySpec{
+				SPIFFEID:    "spiffe://domain.test/workload",
+				ClassName:   "other",
+				X509SVIDTTL: metav1.Duration{Duration: 2 * time.Hour},
+				JWTSVIDTTL:  metav1.Duration{Duration: 2 * time.Minute},
+			},
+			expected: ClusterStaticEntrySpec{
+				SPIFFEID:    "spiffe://domain.test/workload",
+				ClassName:   "other",
+				X509SVIDTTL: metav1.Duration{Duration: 2 * time.Hour},
+				JWTSVIDTTL:  metav1.Duration{Duration: 2 * time.Minute},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			defaulter := &clusterStaticEntryDefaulter{className: tt.className, defaults: tt.defaults}
+			obj := &ClusterStaticEntry{Spec: tt.spec}
+			require.NoError(t, defaulter.Default(context.Background(), obj))
+			require.Equal(t, tt.expected, obj.Spec)
+		})
+	}
+
+	t.Run("wrong type", func(t *testing.T) {
+		defaulter := &clusterStaticEntryDefaulter{defaults: defaults}
+		require.Error(t, defaulter.Default(context.Background(), &v1alpha1.ClusterStaticEntry{}))
+	})
+}
@@ -1,12 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
 )
 
+const baseConfig = `apiVersion: spire.spiffe.io/v1alpha1
+kind: ControllerManagerConfig
+clusterName: cluster
+clusterDomain: cluster.local
+trustDomain: example.org
+`
+
 func TestParseClusterDomainCNAME(t *testing.T) {
 	for _, test := range []struct {
 		name           string
@@ -52,3 +63,149 @@ func TestParseClusterDomainCNAME(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfigValidateOnly(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name:   "valid",
+			config: baseConfig,
+		},
+		{
+			name:        "invalid trust domain",
+			config:      "apiVersion: spire.spiffe.io/v1alpha1\nkind: ControllerManagerConfig\nclusterName: cluster\ntrustDomain: Example.ORG/path\n",
+			expectedErr: "invalid trust domain: ",
+		},
+		{
+			name:        "invalid SPIRE Server TLS",
+			config:      baseConfig + "spireServerAddress: spire-server:8081\nspireServerTLS:\n  certFile: tls.crt\n",
+			expectedErr: "invalid spire server TLS configuration: either a workload API socket path or certificate, key, and bundle files are required",
+		},
+		{
+			name:        "invalid SPIRE Server ID",
+			config:      baseConfig + "spireServerAddress: spire-server:8081\nspireServerTLS:\n  workloadAPISocketPath: /run/agent.sock\n  serverID: not-an-id\n",
+			expectedErr: "invalid spire server TLS configuration: invalid server ID: ",
+		},
+		{
+			name:        "SPIRE Server named pipe and address",
+			config:      baseConfig + "spireServerNamedPipeName: \\spire-server\\private\\api\nspireServerAddress: spire-server:8081\n",
+			expectedErr: "spire server named pipe name is mutually exclusive with the socket path and address",
+		},
+		{
+			name:        "additional SPIRE Server socket path and named pipe",
+			config:      baseConfig + "spireServers:\n- name: other\n  trustDomain: other.org\n  socketPath: /run/other.sock\n  namedPipeName: \\other\\private\\api\n",
+			expectedErr: `spire server "other" requires exactly one of socketPath, namedPipeName, or address`,
+		},
+		{
+			name:        "invalid webhook CABundle target",
+			config:      baseConfig + "webhookCABundleTargets:\n- kind: Secret\n  name: other\n",
+			expectedErr: `invalid webhook CABundle targets: invalid webhook configuration kind "Secret"`,
+		},
+		{
+			name:   "valid webhook settings",
+			config: baseConfig + "webhookSettings:\n  failurePolicy: Fail\n  namespaceSelector:\n    matchLabels:\n      spiffe: enabled\n  webhooks:\n  - name: vclusterspiffeid.kb.io\n    rules:\n    - apiGroups: [spire.spiffe.io]\n      apiVersions: [v1alpha1]\n      operations: [CREATE, UPDATE]\n      resources: [clusterspiffeids]\n",
+		},
+		{
+			name:        "invalid webhook settings failure policy",
+			config:      baseConfig + "webhookSettings:\n  failurePolicy: Sometimes\n",
+			expectedErr: `invalid webhook settings: invalid failure policy "Sometimes"`,
+		},
+		{
+			name:        "duplicate webhook settings override",
+			config:      baseConfig + "webhookSettings:\n  webhooks:\n  - name: vclusterspiffeid.kb.io\n  - name: vclusterspiffeid.kb.io\n",
+			expectedErr: `invalid webhook settings: duplicate webhook settings override for "vclusterspiffeid.kb.io"`,
+		},
+		{
+			name:        "invalid webhook settings rule",
+			config:      baseConfig + "webhookSettings:\n  webhooks:\n  - name: vclusterspiffeid.kb.io\n    rules:\n    - operations: [CREATE]\n",
+			expectedErr: `invalid webhook settings: invalid webhook settings override for "vclusterspiffeid.kb.io": rules require operations, apiGroups, apiVersions, and resources`,
+		},
+		{
+			name:        "invalid pod exclude selector",
+			config:      baseConfig + "podExcludeSelector:\n  matchExpressions:\n  - key: role\n    operator: Sometimes\n",
+			expectedErr: `invalid pod exclude selector: "Sometimes" is not a valid label selector operator`,
+		},
+		{
+			name:        "invalid pod entry deletion policy",
+			config:      baseConfig + "podEntryDeletionPolicy: Later\n",
+			expectedErr: `invalid pod entry deletion policy "Later"`,
+		},
+		{
+			name:        "invalid CRD availability policy",
+			config:      baseConfig + "crdAvailabilityPolicy: sometimes\n",
+			expectedErr: `invalid CRD availability policy "sometimes"`,
+		},
+		{
+			name:        "secure metrics without bind address",
+			config:      baseConfig + "secureMetrics: {}\n",
+			expectedErr: "secure metrics requires metrics.bindAddress",
+		},
+		{
+			name:        "secure metrics with only a certificate file",
+			config:      baseConfig + "metrics:\n  bindAddress: :8443\nsecureMetrics:\n  certFile: tls.crt\n",
+			expectedErr: "secure metrics requires both a certificate and key file, or neither",
+		},
+		{
+			name:        "secure metrics explain endpoint without authorization",
+			config:      baseConfig + "metrics:\n  bindAddress: :8443\nsecureMetrics:\n  skipAuthorization: true\n  explain: true\n",
+			expectedErr: "secure metrics explain endpoint requires authorization",
+		},
+		{
+			name:        "debug server explain endpoint on a public address without TLS",
+			config:      baseConfig + "debugServer:\n  bindAddress: :6060\n  explain: true\n",
+			expectedErr: "invalid debug server configuration: the explain endpoint requires TLS or a loopback bind address",
+		},
+		{
+			name:   "debug server explain endpoint on the loopback address",
+			config: baseConfig + "debugServer:\n  bindAddress: 127.0.0.1:6060\n  explain: true\n",
+		},
+		{
+			name:        "invalid trust bundle notification",
+			config:      baseConfig + "trustBundleNotification:\n  configMaps:\n  - name: bundle\n",
+			expectedErr: "invalid trust bundle notification configuration: config map namespace and name are required",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.config), 0600))
+
+			_, _, err := loadConfig(configSource{path: path, validateOnly: true})
+			if test.expectedErr != "" {
+				require.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestReportValidation(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.True(t, reportValidation(buf, configSource{path: "config.yaml"}, nil))
+	require.JSONEq(t, `{"config": "config.yaml", "valid": true}`, buf.String())
+
+	buf.Reset()
+	require.False(t, reportValidation(buf, configSource{path: "config.yaml"}, errors.New("oh no")))
+	require.JSONEq(t, `{"config": "config.yaml", "valid": false, "error": "oh no"}`, buf.String())
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for level, expected := range map[string]zapcore.Level{
+		"":      zapcore.DebugLevel,
+		"debug": zapcore.DebugLevel,
+		"info":  zapcore.InfoLevel,
+		"error": zapcore.ErrorLevel,
+		"3":     zapcore.Level(-3),
+	} {
+		actual, err := parseLogLevel(level)
+		require.NoError(t, err, level)
+		require.Equal(t, expected, actual, level)
+	}
+	for _, level := range []string{"loud", "0", "-1"} {
+		_, err := parseLogLevel(level)
+		require.EqualError(t, err, `invalid log level "`+level+`"`)
+	}
+}
@@ -21,8 +21,10 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
 	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
@@ -47,9 +49,11 @@ func (r *ClusterFederatedTrustDomainReconciler) Reconcile(ctx context.Context, r
 	return ctrl.Result{}, nil
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// SetupWithManager sets up the controller with the Manager. Status updates,
+// e.g. those written by the bundle endpoint prober, do not change the spec
+// and do not trigger reconciliation.
 func (r *ClusterFederatedTrustDomainReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&spirev1alpha1.ClusterFederatedTrustDomain{}).
+		For(&spirev1alpha1.ClusterFederatedTrustDomain{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Complete(r)
 }
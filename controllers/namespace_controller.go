@@ -0,0 +1,60 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/spiffe/spire-controller-manager/pkg/namespacefilter"
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// NamespaceReconciler reconciles a Namespace object, so that the entries of
+// the pods in a namespace are created or deleted as soon as the labels of the
+// namespace start or stop matching the namespace selector.
+type NamespaceReconciler struct {
+	client.Client
+	Scheme          *runtime.Scheme
+	Triggerer       reconciler.Triggerer
+	NamespaceFilter *namespacefilter.Dynamic
+}
+
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Namespace labels only matter when there is a namespace selector,
+	// which can be set when the configuration is reloaded.
+	if r.NamespaceFilter.Load().Selector != nil {
+		log.FromContext(ctx).V(1).Info("Triggering reconciliation")
+		r.Triggerer.Trigger()
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Namespace{}).
+		Complete(r)
+}
@@ -18,22 +18,58 @@ package controllers
 
 import (
 	"context"
+	"sync"
 
+	"github.com/spiffe/spire-controller-manager/pkg/namespacefilter"
 	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
-	"github.com/spiffe/spire-controller-manager/pkg/stringset"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
+// PodEntryDeleter deletes the entries of a pod that has been removed.
+type PodEntryDeleter interface {
+	DeletePodEntries(ctx context.Context, pod *corev1.Pod)
+}
+
+// PodEntryDeleters deletes the entries of a pod with each of several
+// PodEntryDeleters, e.g. the entry reconcilers of several SPIRE Servers.
+type PodEntryDeleters []PodEntryDeleter
+
+func (ds PodEntryDeleters) DeletePodEntries(ctx context.Context, pod *corev1.Pod) {
+	for _, d := range ds {
+		d.DeletePodEntries(ctx, pod)
+	}
+}
+
 // PodReconciler reconciles a Pod object
 type PodReconciler struct {
 	client.Client
-	Scheme           *runtime.Scheme
-	Triggerer        reconciler.Triggerer
-	IgnoreNamespaces stringset.StringSet
+	Scheme          *runtime.Scheme
+	Triggerer       reconciler.Triggerer
+	NamespaceFilter *namespacefilter.Dynamic
+
+	// PodExcludeSelector, if set, excludes the pods whose labels match from
+	// identity issuance. Changes to excluded pods do not trigger
+	// reconciliation.
+	PodExcludeSelector labels.Selector
+
+	// PodEntryDeleter, if set, deletes the entries of removed pods as soon
+	// as the removal is observed, instead of leaving them to the triggered
+	// reconciliation.
+	PodEntryDeleter PodEntryDeleter
+
+	// removed holds the removed pods by name until they are reconciled,
+	// since the request does not carry the UID their entries select.
+	removedMtx sync.Mutex
+	removed    map[types.NamespacedName][]*corev1.Pod
 }
 
 //+kubebuilder:rbac:groups=spire.spiffe.io,resources=clusterspiffeids,verbs=get;list;watch;create;update;patch;delete
@@ -46,16 +82,70 @@ type PodReconciler struct {
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
-	if !r.IgnoreNamespaces.In(req.Namespace) {
+	ignored, err := r.NamespaceFilter.Load().IgnoredByName(ctx, r.Client, req.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	removed := r.takeRemoved(req.NamespacedName)
+	if !ignored {
+		for _, pod := range removed {
+			r.PodEntryDeleter.DeletePodEntries(ctx, pod)
+		}
 		log.FromContext(ctx).V(1).Info("Triggering reconciliation")
 		r.Triggerer.Trigger()
 	}
 	return ctrl.Result{}, nil
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// SetupWithManager sets up the controller with the Manager. Pods that are
+// excluded are filtered out, unless an update moves them in or out of the
+// exclusion, which adds or removes their entries. Removed pods are recorded
+// for their entries to be deleted when they are reconciled.
 func (r *PodReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Pod{}).
+		For(&corev1.Pod{}, builder.WithPredicates(predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return !r.excluded(e.Object)
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return !r.excluded(e.ObjectOld) || !r.excluded(e.ObjectNew)
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				if r.excluded(e.Object) {
+					return false
+				}
+				r.recordRemoved(e.Object)
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return !r.excluded(e.Object)
+			},
+		})).
 		Complete(r)
 }
+
+func (r *PodReconciler) recordRemoved(obj client.Object) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || r.PodEntryDeleter == nil {
+		return
+	}
+	r.removedMtx.Lock()
+	defer r.removedMtx.Unlock()
+	if r.removed == nil {
+		r.removed = make(map[types.NamespacedName][]*corev1.Pod)
+	}
+	name := client.ObjectKeyFromObject(pod)
+	r.removed[name] = append(r.removed[name], pod)
+}
+
+func (r *PodReconciler) takeRemoved(name types.NamespacedName) []*corev1.Pod {
+	r.removedMtx.Lock()
+	defer r.removedMtx.Unlock()
+	removed := r.removed[name]
+	delete(r.removed, name)
+	return removed
+}
+
+func (r *PodReconciler) excluded(obj client.Object) bool {
+	return r.PodExcludeSelector != nil && r.PodExcludeSelector.Matches(labels.Set(obj.GetLabels()))
+}
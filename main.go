@@ -17,14 +17,20 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"os"
-	"path/filepath"
+	goruntime "runtime"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -33,30 +39,78 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
 
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	spirev1alpha2 "github.com/spiffe/spire-controller-manager/api/v1alpha2"
 	"github.com/spiffe/spire-controller-manager/controllers"
+	"github.com/spiffe/spire-controller-manager/pkg/bundlenotifier"
+	"github.com/spiffe/spire-controller-manager/pkg/bundlepublisher"
+	"github.com/spiffe/spire-controller-manager/pkg/configreloader"
+	"github.com/spiffe/spire-controller-manager/pkg/crdwatcher"
+	"github.com/spiffe/spire-controller-manager/pkg/debugserver"
+	"github.com/spiffe/spire-controller-manager/pkg/entryhook"
+	"github.com/spiffe/spire-controller-manager/pkg/entrytransformer"
+	"github.com/spiffe/spire-controller-manager/pkg/identityreport"
+	"github.com/spiffe/spire-controller-manager/pkg/metricsserver"
+	"github.com/spiffe/spire-controller-manager/pkg/namespacefilter"
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	"github.com/spiffe/spire-controller-manager/pkg/resyncer"
+	"github.com/spiffe/spire-controller-manager/pkg/spireagent"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
 	"github.com/spiffe/spire-controller-manager/pkg/spireentry"
 	"github.com/spiffe/spire-controller-manager/pkg/spirefederationrelationship"
+	"github.com/spiffe/spire-controller-manager/pkg/spirehealth"
 	"github.com/spiffe/spire-controller-manager/pkg/webhookmanager"
 	//+kubebuilder:scaffold:imports
 )
 
 const (
-	defaultSPIREServerSocketPath = "/spire-server/api.sock"
-	defaultGCInterval            = 10 * time.Second
-	k8sDefaultService            = "kubernetes.default.svc"
+	defaultSPIREServerSocketPath    = "/spire-server/api.sock"
+	defaultSPIREServerNamedPipeName = `\spire-server\private\api`
+	defaultGCInterval               = 10 * time.Second
+	defaultEntryCacheResync         = 10 * time.Minute
+	defaultRetryMaxAttempts         = 5
+	defaultRetryInitialBackoff      = 100 * time.Millisecond
+	defaultRetryMaxBackoff          = 5 * time.Second
+	defaultLivenessThreshold        = 3
+	defaultProbeInterval            = 5 * time.Minute
+	explainPath                     = "/debug/explain"
+	defaultLeaderElectionID         = "spire-controller-manager-leader-election"
+	defaultConfigReloadInterval     = 10 * time.Second
+	defaultLogLevel                 = zapcore.DebugLevel
+	k8sDefaultService               = "kubernetes.default.svc"
+	defaultRemoteClusterDomain      = "cluster.local"
 )
 
 var (
@@ -68,29 +122,54 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(spirev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(spirev1alpha2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
 func main() {
-	ctrlConfig, options, err := parseConfig()
+	ctrlConfig, options, source, err := parseConfig()
+	if source.validateOnly {
+		if !reportValidation(os.Stdout, source, err) {
+			os.Exit(1)
+		}
+		return
+	}
 	if err != nil {
 		setupLog.Error(err, "error parsing configuration")
 		os.Exit(1)
 	}
 
-	if err := run(ctrlConfig, options); err != nil {
+	if err := run(ctrlConfig, options, source); err != nil {
 		os.Exit(1)
 	}
 }
 
-func parseConfig() (spirev1alpha1.ControllerManagerConfig, ctrl.Options, error) {
-	var configFileFlag string
-	var spireAPISocketFlag string
-	flag.StringVar(&configFileFlag, "config", "",
+// configSource is where the configuration is loaded from, so that it can be
+// loaded again when the configuration file changes.
+type configSource struct {
+	path               string
+	spireAPISocketFlag string
+
+	// logLevel is the level of the logger, or nil if the log level was set
+	// on the command line, which takes precedence over the configuration.
+	logLevel *uberzap.AtomicLevel
+
+	// validateOnly is true if the configuration is only validated, e.g. in
+	// CI, so that nothing that requires the cluster, like autodetecting the
+	// cluster domain, is attempted.
+	validateOnly bool
+}
+
+func parseConfig() (spirev1alpha1.ControllerManagerConfig, ctrl.Options, configSource, error) {
+	var source configSource
+	flag.StringVar(&source.path, "config", "",
 		"The controller will load its initial configuration from this file. "+
 			"Omit this flag to use the default configuration values. "+
 			"Command-line flags override configuration from this file.")
-	flag.StringVar(&spireAPISocketFlag, "spire-api-socket", "", "The path to the SPIRE API socket (deprecated; use the config file)")
+	flag.StringVar(&source.spireAPISocketFlag, "spire-api-socket", "", "The path to the SPIRE API socket (deprecated; use the config file)")
+	flag.BoolVar(&source.validateOnly, "validate", false,
+		"Validate the configuration, print the result as JSON, and exit. "+
+			"Exits non-zero if the configuration is invalid. Does not require a cluster or SPIRE Server.")
 
 	// Parse log flags
 	opts := zap.Options{
@@ -99,13 +178,40 @@ func parseConfig() (spirev1alpha1.ControllerManagerConfig, ctrl.Options, error)
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	// Unless the log level is set on the command line, use a level that can
+	// be changed by the configuration file, including when it is reloaded.
+	if !isFlagSet("zap-log-level") {
+		logLevel := uberzap.NewAtomicLevelAt(defaultLogLevel)
+		opts.Level = logLevel
+		source.logLevel = &logLevel
+	}
+
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	ctrlConfig, options, err := loadConfig(source)
+	if err != nil {
+		return ctrlConfig, options, source, err
+	}
+	applyLogLevel(source, ctrlConfig.LogLevel)
+	return ctrlConfig, options, source, nil
+}
+
+// loadConfig loads and validates the configuration.
+func loadConfig(source configSource) (spirev1alpha1.ControllerManagerConfig, ctrl.Options, error) {
+	configFileFlag := source.path
+	spireAPISocketFlag := source.spireAPISocketFlag
+
 	// Set default values
 	ctrlConfig := spirev1alpha1.ControllerManagerConfig{
 		IgnoreNamespaces:                   []string{"kube-system", "kube-public", "spire-system"},
+		IgnoredNamespaceEntryPolicy:        spirev1alpha1.IgnoredNamespaceEntryPolicyDelete,
+		PodEntryCreationPhase:              corev1.PodPending,
+		PodEntryDeletionPolicy:             spirev1alpha1.PodEntryDeletionPolicyImmediate,
 		GCInterval:                         defaultGCInterval,
 		ValidatingWebhookConfigurationName: "spire-controller-manager-webhook",
+		WebhookCertProvider:                spirev1alpha1.WebhookCertProviderSPIRE,
+		WebhookSVIDTTL:                     metav1.Duration{Duration: webhookmanager.DefaultSVIDTTL},
+		CRDAvailabilityPolicy:              spirev1alpha1.CRDAvailabilityPolicyPartial,
 	}
 
 	options := ctrl.Options{Scheme: scheme}
@@ -116,6 +222,13 @@ func parseConfig() (spirev1alpha1.ControllerManagerConfig, ctrl.Options, error)
 	}
 	// Determine the SPIRE Server socket path
 	switch {
+	case ctrlConfig.SPIREServerAddress != "" || ctrlConfig.SPIREServerNamedPipeName != "":
+		// A remote SPIRE Server or a named pipe is configured. The socket
+		// path is validated below.
+	case ctrlConfig.SPIREServerSocketPath == "" && spireAPISocketFlag == "" && goruntime.GOOS == "windows":
+		// Neither is set on Windows. Use the default named pipe, as SPIRE
+		// Server does.
+		ctrlConfig.SPIREServerNamedPipeName = defaultSPIREServerNamedPipeName
 	case ctrlConfig.SPIREServerSocketPath == "" && spireAPISocketFlag == "":
 		// Neither is set. Use the default.
 		ctrlConfig.SPIREServerSocketPath = defaultSPIREServerSocketPath
@@ -130,8 +243,18 @@ func parseConfig() (spirev1alpha1.ControllerManagerConfig, ctrl.Options, error)
 		setupLog.Error(nil, "Ignoring deprecated spire-api-socket flag which will be removed in a future release")
 	}
 
+	// Leader election requires an ID. Default it so that enabling leader
+	// election only requires setting leaderElection.leaderElect.
+	if options.LeaderElection && options.LeaderElectionID == "" {
+		options.LeaderElectionID = defaultLeaderElectionID
+	}
+	// Release the lease on shutdown so that another replica can take over
+	// without waiting for the lease to expire. The manager exits once the
+	// leader runnables have stopped, so there is no risk of two leaders.
+	options.LeaderElectionReleaseOnCancel = true
+
 	// Attempt to auto detect cluster domain if it wasn't specified
-	if ctrlConfig.ClusterDomain == "" {
+	if ctrlConfig.ClusterDomain == "" && !source.validateOnly {
 		clusterDomain, err := autoDetectClusterDomain()
 		if err != nil {
 			setupLog.Error(err, "unable to autodetect cluster domain")
@@ -145,8 +268,48 @@ func parseConfig() (spirev1alpha1.ControllerManagerConfig, ctrl.Options, error)
 		"cluster domain", ctrlConfig.ClusterDomain,
 		"trust domain", ctrlConfig.TrustDomain,
 		"ignore namespaces", ctrlConfig.IgnoreNamespaces,
+		"namespace selector", metav1.FormatLabelSelector(ctrlConfig.NamespaceSelector),
+		"pod exclude selector", metav1.FormatLabelSelector(ctrlConfig.PodExcludeSelector),
+		"ignored namespace entry policy", ctrlConfig.IgnoredNamespaceEntryPolicy,
+		"terminating pod entry grace period", ctrlConfig.TerminatingPodEntryGracePeriod.Duration,
+		"entry deletion grace period", ctrlConfig.EntryDeletionGracePeriod.Duration,
+		"pod entry deletion policy", ctrlConfig.PodEntryDeletionPolicy,
+		"pod entry creation phase", ctrlConfig.PodEntryCreationPhase,
+		"allowed path prefixes", ctrlConfig.AllowedPathPrefixes,
+		"privileged entries restricted", ctrlConfig.PrivilegedEntries != nil,
+		"cluster spiffeid defaults", ctrlConfig.ClusterSPIFFEIDDefaults != nil,
+		"cluster static entry defaults", ctrlConfig.ClusterStaticEntryDefaults != nil,
+		"cluster federated trust domain defaults", ctrlConfig.ClusterFederatedTrustDomainDefaults != nil,
 		"gc interval", ctrlConfig.GCInterval,
-		"spire server socket path", ctrlConfig.SPIREServerSocketPath)
+		"entry gc interval", configreloader.EntryGCInterval(ctrlConfig),
+		"federation relationship gc interval", configreloader.FederationRelationshipGCInterval(ctrlConfig),
+		"entry reconcile batch window", ctrlConfig.EntryReconcileBatchWindow.Duration,
+		"entry render workers", ctrlConfig.EntryRenderWorkers,
+		"object selector", metav1.FormatLabelSelector(ctrlConfig.ObjectSelector),
+		"class name", ctrlConfig.ClassName,
+		"watch classless", ctrlConfig.WatchClassless,
+		"spire server socket path", ctrlConfig.SPIREServerSocketPath,
+		"spire server named pipe name", ctrlConfig.SPIREServerNamedPipeName,
+		"spire server address", ctrlConfig.SPIREServerAddress,
+		"spire servers", len(ctrlConfig.SPIREServers),
+		"federated bundle gc", ctrlConfig.FederatedBundleGC != nil,
+		"bundle endpoint probe", ctrlConfig.BundleEndpointProbe != nil,
+		"agent gc", ctrlConfig.AgentGC != nil,
+		"webhook self-signed ca", ctrlConfig.WebhookSelfSignedCA != nil,
+		"webhook cert provider", ctrlConfig.WebhookCertProvider,
+		"webhook svid ttl", ctrlConfig.WebhookSVIDTTL.Duration,
+		"webhook rotation threshold", ctrlConfig.WebhookRotationThreshold.Duration,
+		"namespaced spiffe ids", ctrlConfig.NamespacedSPIFFEIDs != nil,
+		"pod spiffe id annotation", ctrlConfig.PodSPIFFEIDAnnotation != nil,
+		"desired state snapshot", ctrlConfig.DesiredStateSnapshot != nil,
+		"entry cache", ctrlConfig.EntryCache != nil,
+		"spire health check", ctrlConfig.SPIREHealthCheck != nil,
+		"debug server", ctrlConfig.DebugServer != nil,
+		"dry run", ctrlConfig.DryRun,
+		"log level", ctrlConfig.LogLevel,
+		"leader election", options.LeaderElection,
+		"leader election id", options.LeaderElectionID,
+		"leader election namespace", options.LeaderElectionNamespace)
 
 	switch {
 	case ctrlConfig.TrustDomain == "":
@@ -156,40 +319,306 @@ func parseConfig() (spirev1alpha1.ControllerManagerConfig, ctrl.Options, error)
 		return ctrlConfig, options, errors.New("cluster name is required configuration")
 	case ctrlConfig.ValidatingWebhookConfigurationName == "":
 		return ctrlConfig, options, errors.New("validating webhook configuration name is required configuration")
-	case ctrlConfig.ControllerManagerConfigurationSpec.Webhook.CertDir != "":
+	case ctrlConfig.IgnoredNamespaceEntryPolicy != spirev1alpha1.IgnoredNamespaceEntryPolicyDelete &&
+		ctrlConfig.IgnoredNamespaceEntryPolicy != spirev1alpha1.IgnoredNamespaceEntryPolicyRetain:
+		return ctrlConfig, options, fmt.Errorf("invalid ignored namespace entry policy %q", ctrlConfig.IgnoredNamespaceEntryPolicy)
+	case ctrlConfig.PodEntryCreationPhase != corev1.PodPending && ctrlConfig.PodEntryCreationPhase != corev1.PodRunning:
+		return ctrlConfig, options, fmt.Errorf("invalid pod entry creation phase %q", ctrlConfig.PodEntryCreationPhase)
+	case ctrlConfig.PodEntryDeletionPolicy != spirev1alpha1.PodEntryDeletionPolicyImmediate &&
+		ctrlConfig.PodEntryDeletionPolicy != spirev1alpha1.PodEntryDeletionPolicyReconcile:
+		return ctrlConfig, options, fmt.Errorf("invalid pod entry deletion policy %q", ctrlConfig.PodEntryDeletionPolicy)
+	case ctrlConfig.TerminatingPodEntryGracePeriod.Duration < 0:
+		return ctrlConfig, options, errors.New("terminating pod entry grace period must not be negative")
+	case ctrlConfig.EntryDeletionGracePeriod.Duration < 0:
+		return ctrlConfig, options, errors.New("entry deletion grace period must not be negative")
+	case ctrlConfig.SPIREServerAddress != "" && (ctrlConfig.SPIREServerSocketPath != "" || spireAPISocketFlag != ""):
+		return ctrlConfig, options, errors.New("spire server address and socket path are mutually exclusive")
+	case ctrlConfig.SPIREServerNamedPipeName != "" && (ctrlConfig.SPIREServerSocketPath != "" || spireAPISocketFlag != "" || ctrlConfig.SPIREServerAddress != ""):
+		return ctrlConfig, options, errors.New("spire server named pipe name is mutually exclusive with the socket path and address")
+	case ctrlConfig.SPIREServerAddress != "" && ctrlConfig.SPIREServerTLS == nil:
+		return ctrlConfig, options, errors.New("spire server TLS configuration is required to dial the spire server address")
+	case ctrlConfig.IdentityReport != nil && ctrlConfig.IdentityReport.ConfigMap != nil &&
+		(ctrlConfig.IdentityReport.ConfigMap.Namespace == "" || ctrlConfig.IdentityReport.ConfigMap.Name == ""):
+		return ctrlConfig, options, errors.New("identity report ConfigMap requires a namespace and name")
+	case ctrlConfig.EntryLifecycleHook != nil && (ctrlConfig.EntryLifecycleHook.URL == "") == (len(ctrlConfig.EntryLifecycleHook.Command) == 0):
+		return ctrlConfig, options, errors.New("entry lifecycle hook requires exactly one of url or command")
+	case ctrlConfig.EntryTransformer != nil && (ctrlConfig.EntryTransformer.URL == "") == (len(ctrlConfig.EntryTransformer.Command) == 0):
+		return ctrlConfig, options, errors.New("entry transformer requires exactly one of url or command")
+	case ctrlConfig.EntryGCInterval.Duration < 0 || ctrlConfig.FederationRelationshipGCInterval.Duration < 0:
+		return ctrlConfig, options, errors.New("entry and federation relationship GC intervals must not be negative")
+	case ctrlConfig.EntryReconcileBatchWindow.Duration < 0:
+		return ctrlConfig, options, errors.New("entry reconcile batch window must not be negative")
+	case ctrlConfig.EntryRenderWorkers < 0:
+		return ctrlConfig, options, errors.New("entry render workers must not be negative")
+	case ctrlConfig.WebhookSelfSignedCA != nil &&
+		(ctrlConfig.WebhookSelfSignedCA.Secret.Namespace == "" || ctrlConfig.WebhookSelfSignedCA.Secret.Name == ""):
+		return ctrlConfig, options, errors.New("webhook self-signed CA secret requires a namespace and name")
+	case ctrlConfig.DesiredStateSnapshot != nil && ctrlConfig.DesiredStateSnapshot.Path == "":
+		return ctrlConfig, options, errors.New("desired state snapshot requires a path")
+	case ctrlConfig.EntryCache != nil && ctrlConfig.EntryCache.ResyncInterval.Duration < 0:
+		return ctrlConfig, options, errors.New("entry cache resync interval must not be negative")
+	case ctrlConfig.EntryAPI != nil && (ctrlConfig.EntryAPI.CreateBatchSize < 0 || ctrlConfig.EntryAPI.UpdateBatchSize < 0 || ctrlConfig.EntryAPI.DeleteBatchSize < 0 ||
+		ctrlConfig.EntryAPI.MaxConcurrentBatches < 0 || ctrlConfig.EntryAPI.CallsPerSecond < 0 || ctrlConfig.EntryAPI.Burst < 0):
+		return ctrlConfig, options, errors.New("entry API batch sizes, concurrency, and rate limit must not be negative")
+	case ctrlConfig.SPIREAPIRetry != nil && !validRetryPolicies(ctrlConfig.SPIREAPIRetry):
+		return ctrlConfig, options, errors.New("spire API retry attempts and backoffs must not be negative")
+	case ctrlConfig.SPIREHealthCheck != nil && (ctrlConfig.SPIREHealthCheck.Timeout.Duration < 0 ||
+		ctrlConfig.SPIREHealthCheck.LivenessFailureThreshold < 0 || ctrlConfig.SPIREHealthCheck.ReadinessFailureThreshold < 0):
+		return ctrlConfig, options, errors.New("spire health check timeout and failure thresholds must not be negative")
+	case ctrlConfig.BundleEndpointProbe != nil && (ctrlConfig.BundleEndpointProbe.Interval.Duration < 0 || ctrlConfig.BundleEndpointProbe.Timeout.Duration < 0):
+		return ctrlConfig, options, errors.New("bundle endpoint probe interval and timeout must not be negative")
+	case ctrlConfig.AgentGC != nil && ctrlConfig.AgentGC.Delay.Duration < 0:
+		return ctrlConfig, options, errors.New("agent GC delay must not be negative")
+	case ctrlConfig.SecureMetrics != nil && (ctrlConfig.SecureMetrics.CertFile == "") != (ctrlConfig.SecureMetrics.KeyFile == ""):
+		return ctrlConfig, options, errors.New("secure metrics requires both a certificate and key file, or neither")
+	case ctrlConfig.SecureMetrics != nil && (options.MetricsBindAddress == "" || options.MetricsBindAddress == "0"):
+		return ctrlConfig, options, errors.New("secure metrics requires metrics.bindAddress")
+	case ctrlConfig.SecureMetrics != nil && ctrlConfig.SecureMetrics.Explain && ctrlConfig.SecureMetrics.SkipAuthorization:
+		return ctrlConfig, options, errors.New("secure metrics explain endpoint requires authorization")
+	case ctrlConfig.CRDAvailabilityPolicy != spirev1alpha1.CRDAvailabilityPolicyWait &&
+		ctrlConfig.CRDAvailabilityPolicy != spirev1alpha1.CRDAvailabilityPolicyFail &&
+		ctrlConfig.CRDAvailabilityPolicy != spirev1alpha1.CRDAvailabilityPolicyPartial:
+		return ctrlConfig, options, fmt.Errorf("invalid CRD availability policy %q", ctrlConfig.CRDAvailabilityPolicy)
+	case ctrlConfig.WebhookCertProvider != spirev1alpha1.WebhookCertProviderSPIRE &&
+		ctrlConfig.WebhookCertProvider != spirev1alpha1.WebhookCertProviderExternal:
+		return ctrlConfig, options, fmt.Errorf("invalid webhook cert provider %q", ctrlConfig.WebhookCertProvider)
+	case ctrlConfig.WebhookCertProvider == spirev1alpha1.WebhookCertProviderExternal && ctrlConfig.WebhookSelfSignedCA != nil:
+		return ctrlConfig, options, errors.New("webhook self-signed CA cannot be used with the external webhook cert provider")
+	case ctrlConfig.WebhookCertProvider == spirev1alpha1.WebhookCertProviderExternal && ctrlConfig.ControllerManagerConfigurationSpec.Webhook.CertDir == "":
+		return ctrlConfig, options, errors.New("webhook certDir is required by the external webhook cert provider")
+	case ctrlConfig.WebhookSVIDTTL.Duration < 0 || ctrlConfig.WebhookRotationThreshold.Duration < 0:
+		return ctrlConfig, options, errors.New("webhook SVID TTL and rotation threshold must not be negative")
+	case ctrlConfig.WebhookSVIDTTL.Duration > 0 && ctrlConfig.WebhookRotationThreshold.Duration >= ctrlConfig.WebhookSVIDTTL.Duration:
+		return ctrlConfig, options, errors.New("webhook rotation threshold must be shorter than the webhook SVID TTL")
+	case len(ctrlConfig.WebhookCABundleTargets) > 0 && ctrlConfig.WebhookCertProvider == spirev1alpha1.WebhookCertProviderExternal:
+		return ctrlConfig, options, errors.New("webhook CABundle targets cannot be used with the external webhook cert provider")
+	case ctrlConfig.WebhookSettings != nil && ctrlConfig.WebhookCertProvider == spirev1alpha1.WebhookCertProviderExternal:
+		return ctrlConfig, options, errors.New("webhook settings cannot be used with the external webhook cert provider")
+	case ctrlConfig.BundlePublisher != nil && ctrlConfig.BundlePublisher.Name == "":
+		return ctrlConfig, options, errors.New("bundle publisher requires a name")
+	case ctrlConfig.BundlePublisher != nil && ctrlConfig.BundlePublisher.Kind != "" &&
+		ctrlConfig.BundlePublisher.Kind != string(bundlepublisher.KindConfigMap) && ctrlConfig.BundlePublisher.Kind != string(bundlepublisher.KindSecret):
+		return ctrlConfig, options, fmt.Errorf("invalid bundle publisher kind %q", ctrlConfig.BundlePublisher.Kind)
+	case ctrlConfig.BundlePublisher != nil && ctrlConfig.BundlePublisher.IncludeWebhookCA &&
+		ctrlConfig.WebhookCertProvider == spirev1alpha1.WebhookCertProviderExternal:
+		return ctrlConfig, options, errors.New("bundle publisher cannot include the webhook CA with the external webhook cert provider")
+	case ctrlConfig.WebhookCertProvider == spirev1alpha1.WebhookCertProviderSPIRE && ctrlConfig.ControllerManagerConfigurationSpec.Webhook.CertDir != "":
 		setupLog.Info("certDir configuration is ignored", "certDir", ctrlConfig.ControllerManagerConfigurationSpec.Webhook.CertDir)
 	}
 
+	if _, err := spiffeid.TrustDomainFromString(ctrlConfig.TrustDomain); err != nil {
+		return ctrlConfig, options, fmt.Errorf("invalid trust domain: %w", err)
+	}
+	if ctrlConfig.SPIREServerTLS != nil {
+		if err := validateSPIREServerTLS(ctrlConfig.SPIREServerTLS); err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid spire server TLS configuration: %w", err)
+		}
+	}
+	if _, err := parseWebhookCABundleTargets(ctrlConfig.WebhookCABundleTargets); err != nil {
+		return ctrlConfig, options, fmt.Errorf("invalid webhook CABundle targets: %w", err)
+	}
+	if ctrlConfig.WebhookSettings != nil {
+		if _, _, err := parseWebhookSettings(ctrlConfig.WebhookSettings); err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid webhook settings: %w", err)
+		}
+	}
+	if ctrlConfig.TrustBundleNotification != nil {
+		if _, _, err := parseTrustBundleNotificationConfig(ctrlConfig.TrustBundleNotification); err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid trust bundle notification configuration: %w", err)
+		}
+	}
+	if ctrlConfig.BundlePublisher != nil {
+		if _, err := parseBundlePublisherConfig(ctrlConfig.BundlePublisher); err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid bundle publisher configuration: %w", err)
+		}
+	}
+	if ctrlConfig.DebugServer != nil {
+		if _, err := debugserver.New(makeDebugServerConfig(ctrlConfig.DebugServer)); err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid debug server configuration: %w", err)
+		}
+		if ctrlConfig.DebugServer.Explain && ctrlConfig.DebugServer.TLS == nil && !debugserver.IsLoopback(ctrlConfig.DebugServer.BindAddress) {
+			return ctrlConfig, options, errors.New("invalid debug server configuration: the explain endpoint requires TLS or a loopback bind address")
+		}
+	}
+
+	for _, prefix := range ctrlConfig.AllowedPathPrefixes {
+		if err := spirev1alpha1.ValidatePathPrefix(prefix); err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid allowed path prefixes: %w", err)
+		}
+	}
+
+	if ctrlConfig.ClusterSPIFFEIDDefaults != nil {
+		if err := ctrlConfig.ClusterSPIFFEIDDefaults.Validate(); err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid ClusterSPIFFEID defaults: %w", err)
+		}
+	}
+	if ctrlConfig.ClusterStaticEntryDefaults != nil {
+		if err := ctrlConfig.ClusterStaticEntryDefaults.Validate(); err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid ClusterStaticEntry defaults: %w", err)
+		}
+	}
+
+	remoteClusterNames := map[string]bool{ctrlConfig.ClusterName: true}
+	for i, remoteCluster := range ctrlConfig.RemoteClusters {
+		switch {
+		case remoteCluster.Name == "" || remoteCluster.KubeConfigPath == "":
+			return ctrlConfig, options, errors.New("remote clusters require a name and kubeConfigPath")
+		case remoteClusterNames[remoteCluster.Name]:
+			return ctrlConfig, options, fmt.Errorf("remote cluster name %q is not unique", remoteCluster.Name)
+		}
+		remoteClusterNames[remoteCluster.Name] = true
+		if remoteCluster.ClusterDomain == "" {
+			ctrlConfig.RemoteClusters[i].ClusterDomain = defaultRemoteClusterDomain
+		}
+	}
+
+	spireServerNames := make(map[string]bool)
+	for i, spireServer := range ctrlConfig.SPIREServers {
+		switch {
+		case spireServer.Name == "" || spireServer.TrustDomain == "":
+			return ctrlConfig, options, errors.New("spire servers require a name and trust domain")
+		case spireServerNames[spireServer.Name]:
+			return ctrlConfig, options, fmt.Errorf("spire server name %q is not unique", spireServer.Name)
+		case countNonEmpty(spireServer.SocketPath, spireServer.NamedPipeName, spireServer.Address) != 1:
+			return ctrlConfig, options, fmt.Errorf("spire server %q requires exactly one of socketPath, namedPipeName, or address", spireServer.Name)
+		case spireServer.Address != "" && spireServer.TLS == nil:
+			return ctrlConfig, options, fmt.Errorf("spire server %q requires TLS configuration to dial its address", spireServer.Name)
+		}
+		if _, err := spiffeid.TrustDomainFromString(spireServer.TrustDomain); err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid spire server %q trust domain: %w", spireServer.Name, err)
+		}
+		if spireServer.TLS != nil {
+			if err := validateSPIREServerTLS(spireServer.TLS); err != nil {
+				return ctrlConfig, options, fmt.Errorf("invalid spire server %q TLS configuration: %w", spireServer.Name, err)
+			}
+		}
+		spireServerNames[spireServer.Name] = true
+		if spireServer.ClusterName == "" {
+			ctrlConfig.SPIREServers[i].ClusterName = ctrlConfig.ClusterName
+		}
+	}
+
+	if ctrlConfig.NamespacedSPIFFEIDs != nil {
+		if ctrlConfig.NamespacedSPIFFEIDs.PathPrefixTemplate == "" {
+			ctrlConfig.NamespacedSPIFFEIDs.PathPrefixTemplate = spirev1alpha1.DefaultNamespacePathPrefixTemplate
+		}
+		if _, err := spirev1alpha1.ParseNamespacePathPrefixTemplate(ctrlConfig.NamespacedSPIFFEIDs.PathPrefixTemplate); err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid namespaced SPIFFE IDs path prefix template: %w", err)
+		}
+	}
+
+	if ctrlConfig.PodSPIFFEIDAnnotation != nil {
+		if ctrlConfig.PodSPIFFEIDAnnotation.PathPrefixTemplate == "" {
+			ctrlConfig.PodSPIFFEIDAnnotation.PathPrefixTemplate = spirev1alpha1.DefaultNamespacePathPrefixTemplate
+		}
+		if _, err := spirev1alpha1.ParseNamespacePathPrefixTemplate(ctrlConfig.PodSPIFFEIDAnnotation.PathPrefixTemplate); err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid pod SPIFFE ID annotation path prefix template: %w", err)
+		}
+	}
+
+	if _, err := namespacefilter.New(ctrlConfig.IgnoreNamespaces, ctrlConfig.NamespaceSelector); err != nil {
+		return ctrlConfig, options, err
+	}
+
+	if _, err := parseLogLevel(ctrlConfig.LogLevel); err != nil {
+		return ctrlConfig, options, err
+	}
+
+	if _, err := parsePodExcludeSelector(ctrlConfig.PodExcludeSelector); err != nil {
+		return ctrlConfig, options, err
+	}
+
+	// The metrics are served securely by the metrics server instead of the
+	// plaintext controller-runtime listener.
+	if ctrlConfig.SecureMetrics != nil {
+		options.MetricsBindAddress = "0"
+	}
+
+	if ctrlConfig.ObjectSelector != nil {
+		objectSelector, err := metav1.LabelSelectorAsSelector(ctrlConfig.ObjectSelector)
+		if err != nil {
+			return ctrlConfig, options, fmt.Errorf("invalid object selector: %w", err)
+		}
+		restrictCacheToSelectedObjects(&options, objectSelector)
+	}
+
+	if ctrlConfig.FederatedBundleGC != nil {
+		for _, td := range ctrlConfig.FederatedBundleGC.KeepTrustDomains {
+			if _, err := spiffeid.TrustDomainFromString(td); err != nil {
+				return ctrlConfig, options, fmt.Errorf("invalid federated bundle GC keep trust domains: %w", err)
+			}
+		}
+	}
+
 	return ctrlConfig, options, nil
 }
 
-func run(ctrlConfig spirev1alpha1.ControllerManagerConfig, options ctrl.Options) error {
-	// It's unfortunate that we have to keep credentials on disk so that the
-	// manager can load them:
-	// TODO: upstream a change to the WebhookServer so it can use callbacks to
-	// obtain the certificates so we don't have to touch disk.
-	certDir, err := os.MkdirTemp("", "spire-controller-manager-")
+// validateSPIREServerTLS returns an error if SPIRE Server cannot be dialed
+// with the TLS configuration. The credentials themselves are only loaded
+// when dialing.
+func validateSPIREServerTLS(config *spirev1alpha1.SPIREServerTLSConfig) error {
+	if config.ServerID != "" {
+		if _, err := spiffeid.FromString(config.ServerID); err != nil {
+			return fmt.Errorf("invalid server ID: %w", err)
+		}
+	}
+	if config.WorkloadAPISocketPath == "" && (config.CertFile == "" || config.KeyFile == "" || config.BundleFile == "") {
+		return errors.New("either a workload API socket path or certificate, key, and bundle files are required")
+	}
+	return nil
+}
+
+// validationResult is printed by the --validate flag.
+type validationResult struct {
+	Config string `json:"config"`
+	Valid  bool   `json:"valid"`
+	Error  string `json:"error,omitempty"`
+}
+
+// reportValidation prints the result of validating the configuration as
+// JSON and returns true if the configuration is valid.
+func reportValidation(w io.Writer, source configSource, err error) bool {
+	result := validationResult{Config: source.path, Valid: err == nil}
 	if err != nil {
-		setupLog.Error(err, "failed to create temporary cert directory")
-		return err
+		result.Error = err.Error()
 	}
-	defer func() {
-		if err := os.RemoveAll(certDir); err != nil {
-			setupLog.Error(err, "failed to remove temporary cert directory", "certDir", certDir)
-			os.Exit(1)
-		}
-	}()
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(result)
+	return result.Valid
+}
 
-	// webhook server credentials are stored in a single file to keep rotation
-	// simple.
-	const keyPairName = "keypair.pem"
+// restrictCacheToSelectedObjects restricts the manager cache, and therefore
+// the objects seen by the controllers, to the custom resources matching the
+// selector.
+func restrictCacheToSelectedObjects(options *ctrl.Options, selector labels.Selector) {
+	if options.Cache.ByObject == nil {
+		options.Cache.ByObject = make(map[client.Object]cache.ByObject)
+	}
+	for _, obj := range []client.Object{
+		&spirev1alpha1.ClusterSPIFFEID{},
+		&spirev1alpha1.NamespacedSPIFFEID{},
+		&spirev1alpha1.ClusterStaticEntry{},
+		&spirev1alpha1.ClusterNodeAlias{},
+		&spirev1alpha1.ClusterFederatedTrustDomain{},
+	} {
+		options.Cache.ByObject[obj] = cache.ByObject{Label: selector}
+	}
+}
+
+func run(ctrlConfig spirev1alpha1.ControllerManagerConfig, options ctrl.Options, source configSource) error {
+	// The webhook server obtains its certificate from the webhook manager,
+	// which keeps it in memory so that a minted private key never touches
+	// disk. The webhook manager is created below, before the webhook server
+	// is started.
+	var webhookManager *webhookmanager.Manager
 	options.WebhookServer = webhook.NewServer(webhook.Options{
-		CertDir:  certDir,
-		CertName: keyPairName,
-		KeyName:  keyPairName,
 		TLSOpts: []func(*tls.Config){
 			func(s *tls.Config) {
 				s.MinVersion = tls.VersionTLS12
+				s.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+					return webhookManager.GetCertificate(hello)
+				}
 			},
 		},
 	})
@@ -201,15 +630,24 @@ func run(ctrlConfig spirev1alpha1.ControllerManagerConfig, options ctrl.Options)
 		setupLog.Error(err, "invalid trust domain name")
 		return err
 	}
-	setupLog.Info("Dialing SPIRE Server socket")
-	spireClient, err := spireapi.DialSocket(ctx, ctrlConfig.SPIREServerSocketPath)
+	spireClient, err := dialSPIREServer(ctx, ctrlConfig, defaultSPIREServerTarget(ctrlConfig))
 	if err != nil {
-		setupLog.Error(err, "unable to dial SPIRE Server socket")
 		return err
 	}
 	defer spireClient.Close()
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
+	spireServerClients := make([]spireapi.Client, 0, len(ctrlConfig.SPIREServers))
+	for _, spireServer := range ctrlConfig.SPIREServers {
+		spireServerClient, err := dialSPIREServer(ctx, ctrlConfig, spireServer)
+		if err != nil {
+			return err
+		}
+		defer spireServerClient.Close()
+		spireServerClients = append(spireServerClients, spireServerClient)
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	mgr, err := ctrl.NewManager(restConfig, options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		return err
@@ -230,94 +668,670 @@ func run(ctrlConfig spirev1alpha1.ControllerManagerConfig, options ctrl.Options)
 		setupLog.Error(err, "failed to create an API client")
 		return err
 	}
+	apiextensionsClientset, err := apiextensionsclientset.NewForConfig(config)
+	if err != nil {
+		setupLog.Error(err, "failed to create an API extensions client")
+		return err
+	}
+
+	var webhookClient webhookmanager.WebhookClient
+	webhookAPIVersion, err := webhookmanager.WebhookAPIVersion(clientset.Discovery())
+	if err != nil {
+		setupLog.Error(err, "failed to determine webhook API version")
+		return err
+	}
+	caBundleTargets, err := parseWebhookCABundleTargets(ctrlConfig.WebhookCABundleTargets)
+	if err != nil {
+		setupLog.Error(err, "invalid webhook CABundle targets")
+		return err
+	}
+	switch webhookAPIVersion {
+	case admissionregistrationv1beta1.SchemeGroupVersion.String():
+		setupLog.Info("Using deprecated webhook API version", "version", webhookAPIVersion)
+		for _, target := range caBundleTargets {
+			if target.Kind == webhookmanager.MutatingWebhookKind {
+				err := errors.New("mutating webhook CABundle targets require admissionregistration.k8s.io/v1")
+				setupLog.Error(err, "invalid webhook CABundle targets")
+				return err
+			}
+		}
+		webhookClient = webhookmanager.NewV1Beta1WebhookClient(clientset.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations())
+	default:
+		webhookClient = clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+	}
+
+	var webhookCA *webhookmanager.SelfSignedCA
+	if ctrlConfig.WebhookSelfSignedCA != nil {
+		secret := ctrlConfig.WebhookSelfSignedCA.Secret
+		webhookCA, err = webhookmanager.LoadSelfSignedCA(ctx, webhookmanager.SelfSignedCAConfig{
+			SecretClient: clientset.CoreV1().Secrets(secret.Namespace),
+			SecretName:   secret.Name,
+		})
+		if err != nil {
+			setupLog.Error(err, "failed to load self-signed webhook CA")
+			return err
+		}
+	}
+
+	var webhookSettings *webhookmanager.WebhookSettings
+	var perWebhookSettings map[string]webhookmanager.WebhookSettings
+	if ctrlConfig.WebhookSettings != nil {
+		webhookSettings, perWebhookSettings, err = parseWebhookSettings(ctrlConfig.WebhookSettings)
+		if err != nil {
+			setupLog.Error(err, "invalid webhook settings")
+			return err
+		}
+	}
 
 	webhookID, _ := spiffeid.FromPath(trustDomain, "/spire-controller-manager-webhook")
-	webhookManager := webhookmanager.New(webhookmanager.Config{
-		ID:            webhookID,
-		KeyPairPath:   filepath.Join(certDir, keyPairName),
-		WebhookName:   ctrlConfig.ValidatingWebhookConfigurationName,
-		WebhookClient: clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations(),
-		SVIDClient:    spireClient,
-		BundleClient:  spireClient,
-	})
+	webhookManagerConfig := webhookmanager.Config{
+		ID:                    webhookID,
+		WebhookName:           ctrlConfig.ValidatingWebhookConfigurationName,
+		WebhookClient:         webhookClient,
+		SVIDClient:            spireClient,
+		BundleClient:          spireClient,
+		SelfSignedCA:          webhookCA,
+		CABundleTargets:       caBundleTargets,
+		MutatingWebhookClient: clientset.AdmissionregistrationV1().MutatingWebhookConfigurations(),
+		CRDClient:             apiextensionsClientset.ApiextensionsV1().CustomResourceDefinitions(),
+		SVIDTTL:               ctrlConfig.WebhookSVIDTTL.Duration,
+		RotationThreshold:     ctrlConfig.WebhookRotationThreshold.Duration,
+		WebhookSettings:       webhookSettings,
+		PerWebhookSettings:    perWebhookSettings,
+	}
+	if ctrlConfig.WebhookCertProvider == spirev1alpha1.WebhookCertProviderExternal {
+		webhookManagerConfig.ExternalCertDir = ctrlConfig.ControllerManagerConfigurationSpec.Webhook.CertDir
+	}
+	webhookManager = webhookmanager.New(webhookManagerConfig)
 
 	if err := webhookManager.Init(ctx); err != nil {
-		setupLog.Error(err, "failed to mint initial webhook certificate")
+		setupLog.Error(err, "failed to obtain initial webhook certificate")
 		return err
 	}
 
-	entryReconciler := spireentry.Reconciler(spireentry.ReconcilerConfig{
-		TrustDomain:      trustDomain,
-		ClusterName:      ctrlConfig.ClusterName,
-		ClusterDomain:    ctrlConfig.ClusterDomain,
-		K8sClient:        mgr.GetClient(),
-		EntryClient:      spireClient,
-		IgnoreNamespaces: ctrlConfig.IgnoreNamespaces,
-		GCInterval:       ctrlConfig.GCInterval,
-	})
+	var entryHook entryhook.Notifier
+	if ctrlConfig.EntryLifecycleHook != nil {
+		hook, err := entryhook.New(entryhook.Config{
+			URL:     ctrlConfig.EntryLifecycleHook.URL,
+			Command: ctrlConfig.EntryLifecycleHook.Command,
+			Timeout: ctrlConfig.EntryLifecycleHook.Timeout.Duration,
+		})
+		if err != nil {
+			setupLog.Error(err, "invalid entry lifecycle hook configuration")
+			return err
+		}
+		if err = mgr.Add(manager.RunnableFunc(hook.Run)); err != nil {
+			setupLog.Error(err, "unable to manage entry lifecycle hook")
+			return err
+		}
+		entryHook = hook
+	}
+
+	var entryTransformer entrytransformer.Transformer
+	if ctrlConfig.EntryTransformer != nil {
+		entryTransformer, err = entrytransformer.New(entrytransformer.Config{
+			URL:     ctrlConfig.EntryTransformer.URL,
+			Command: ctrlConfig.EntryTransformer.Command,
+			Timeout: ctrlConfig.EntryTransformer.Timeout.Duration,
+		})
+		if err != nil {
+			setupLog.Error(err, "invalid entry transformer configuration")
+			return err
+		}
+	}
+
+	var identityReporter identityreport.Reporter
+	if ctrlConfig.IdentityReport != nil {
+		var configMap *types.NamespacedName
+		if ref := ctrlConfig.IdentityReport.ConfigMap; ref != nil {
+			configMap = &types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+		}
+		reporter, err := identityreport.New(identityreport.Config{
+			K8sClient: mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			ConfigMap: configMap,
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to create identity reporter")
+			return err
+		}
+		identityReporter = reporter
+	}
 
-	federationRelationshipReconciler := spirefederationrelationship.Reconciler(spirefederationrelationship.ReconcilerConfig{
+	eventRecorder := mgr.GetEventRecorderFor("spire-controller-manager")
+
+	initialNamespaceFilter, err := namespacefilter.New(ctrlConfig.IgnoreNamespaces, ctrlConfig.NamespaceSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid namespace filter")
+		return err
+	}
+	namespaceFilter := namespacefilter.NewDynamic(initialNamespaceFilter)
+
+	podExcludeSelector, err := parsePodExcludeSelector(ctrlConfig.PodExcludeSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid pod exclude selector")
+		return err
+	}
+
+	var namespacePathPrefixTemplate *template.Template
+	if ctrlConfig.NamespacedSPIFFEIDs != nil {
+		namespacePathPrefixTemplate, err = spirev1alpha1.ParseNamespacePathPrefixTemplate(ctrlConfig.NamespacedSPIFFEIDs.PathPrefixTemplate)
+		if err != nil {
+			setupLog.Error(err, "invalid namespaced SPIFFE IDs path prefix template")
+			return err
+		}
+	}
+
+	var spiffeIDAnnotationPathPrefixTemplate *template.Template
+	if ctrlConfig.PodSPIFFEIDAnnotation != nil {
+		spiffeIDAnnotationPathPrefixTemplate, err = spirev1alpha1.ParseNamespacePathPrefixTemplate(ctrlConfig.PodSPIFFEIDAnnotation.PathPrefixTemplate)
+		if err != nil {
+			setupLog.Error(err, "invalid pod SPIFFE ID annotation path prefix template")
+			return err
+		}
+	}
+
+	// The CRD watcher is created after the reconcilers, since its
+	// registrations trigger them, but it polls before they first run.
+	var crdWatcher *crdwatcher.Watcher
+	kindNotServed := func(gvk schema.GroupVersionKind) bool {
+		return crdWatcher.NotServed(gvk)
+	}
+
+	entryReconcilerConfig := spireentry.ReconcilerConfig{
+		TrustDomain:     trustDomain,
+		ClusterName:     ctrlConfig.ClusterName,
+		ClusterDomain:   ctrlConfig.ClusterDomain,
+		K8sClient:       mgr.GetClient(),
+		EntryClient:     spireClient,
+		BundleClient:    spireClient,
+		NamespaceFilter: namespaceFilter,
+		ClassName:       ctrlConfig.ClassName,
+		WatchClassless:  ctrlConfig.WatchClassless,
+		GCInterval:      configreloader.EntryGCInterval(ctrlConfig),
+		BatchWindow:     ctrlConfig.EntryReconcileBatchWindow.Duration,
+		RenderWorkers:   ctrlConfig.EntryRenderWorkers,
+
+		PodExcludeSelector:             podExcludeSelector,
+		RetainIgnoredNamespaceEntries:  ctrlConfig.IgnoredNamespaceEntryPolicy == spirev1alpha1.IgnoredNamespaceEntryPolicyRetain,
+		TerminatingPodEntryGracePeriod: ctrlConfig.TerminatingPodEntryGracePeriod.Duration,
+		EntryDeletionGracePeriod:       ctrlConfig.EntryDeletionGracePeriod.Duration,
+		WaitForRunningPods:             ctrlConfig.PodEntryCreationPhase == corev1.PodRunning,
+		AllowedPathPrefixes:            ctrlConfig.AllowedPathPrefixes,
+		PrivilegedEntries:              ctrlConfig.PrivilegedEntries,
+		DryRun:                         ctrlConfig.DryRun,
+		EntryTransformer:               entryTransformer,
+		EntryHook:                      entryHook,
+		IdentityReporter:               identityReporter,
+		EventRecorder:                  eventRecorder,
+		NamespacePathPrefixTemplate:    namespacePathPrefixTemplate,
+		KindNotServed:                  kindNotServed,
+
+		SPIFFEIDAnnotationPathPrefixTemplate: spiffeIDAnnotationPathPrefixTemplate,
+	}
+	if ctrlConfig.DesiredStateSnapshot != nil {
+		entryReconcilerConfig.SnapshotPath = ctrlConfig.DesiredStateSnapshot.Path
+	}
+	if ctrlConfig.EntryCache != nil {
+		entryReconcilerConfig.EntryCacheResyncInterval = ctrlConfig.EntryCache.ResyncInterval.Duration
+		if entryReconcilerConfig.EntryCacheResyncInterval == 0 {
+			entryReconcilerConfig.EntryCacheResyncInterval = defaultEntryCacheResync
+		}
+	}
+	if len(ctrlConfig.RemoteClusters) > 0 {
+		entryReconcilerConfig.ManagesEntry = localClusterEntries(ctrlConfig.RemoteClusters)
+	}
+	entryReconciler := spireentry.Reconciler(entryReconcilerConfig)
+
+	// The explain endpoint reveals how pods are registered, so it is only
+	// served when enabled, and only on the secure metrics or debug server.
+	explainer := spireentry.NewExplainer(entryReconcilerConfig)
+
+	if ctrlConfig.SecureMetrics != nil {
+		metricsServer, err := newSecureMetricsServer(ctrlConfig, clientset, webhookManager)
+		if err != nil {
+			setupLog.Error(err, "unable to create secure metrics server")
+			return err
+		}
+		if err := mgr.Add(metricsServer); err != nil {
+			setupLog.Error(err, "unable to manage secure metrics server")
+			return err
+		}
+		if ctrlConfig.SecureMetrics.Explain {
+			if err := metricsServer.AddExtraHandler(explainPath, explainer); err != nil {
+				setupLog.Error(err, "failed to add explain handler")
+				return err
+			}
+		}
+	}
+
+	var keepFederatedBundles []spiffeid.TrustDomain
+	if ctrlConfig.FederatedBundleGC != nil {
+		for _, td := range ctrlConfig.FederatedBundleGC.KeepTrustDomains {
+			keepFederatedBundles = append(keepFederatedBundles, spiffeid.RequireTrustDomainFromString(td))
+		}
+	}
+
+	federationRelationshipReconcilerConfig := spirefederationrelationship.ReconcilerConfig{
 		K8sClient:         mgr.GetClient(),
 		TrustDomainClient: spireClient,
-		GCInterval:        ctrlConfig.GCInterval,
-	})
+		ClassName:         ctrlConfig.ClassName,
+		WatchClassless:    ctrlConfig.WatchClassless,
+		GCInterval:        configreloader.FederationRelationshipGCInterval(ctrlConfig),
+		EventRecorder:     eventRecorder,
+		DryRun:            ctrlConfig.DryRun,
+		KindNotServed:     kindNotServed,
 
-	if err = (&controllers.ClusterSPIFFEIDReconciler{
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
-		Triggerer: entryReconciler,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ClusterSPIFFEID")
+		BundleClient:           spireClient,
+		DeleteFederatedBundles: ctrlConfig.FederatedBundleGC != nil,
+		KeepFederatedBundles:   keepFederatedBundles,
+	}
+	federationRelationshipReconciler := spirefederationrelationship.Reconciler(federationRelationshipReconcilerConfig)
+
+	// Each additional SPIRE Server gets its own entry and federation
+	// relationship reconcilers, which are triggered along with those of the
+	// default SPIRE Server but garbage collect independently.
+	entryTriggerer := reconciler.Triggerers{entryReconciler}
+	federationRelationshipTriggerer := reconciler.Triggerers{federationRelationshipReconciler}
+	podEntryDeleters := controllers.PodEntryDeleters{entryReconciler}
+	entryReconcilers := []reconciler.Reconciler{entryReconciler}
+	federationRelationshipReconcilers := []reconciler.Reconciler{federationRelationshipReconciler}
+	for i, spireServer := range ctrlConfig.SPIREServers {
+		spireServerEntryReconciler, spireServerFederationRelationshipReconciler := spireServerTargetReconcilers(spireServer, spireServerClients[i], entryReconcilerConfig, federationRelationshipReconcilerConfig)
+		entryTriggerer = append(entryTriggerer, spireServerEntryReconciler)
+		podEntryDeleters = append(podEntryDeleters, spireServerEntryReconciler)
+		federationRelationshipTriggerer = append(federationRelationshipTriggerer, spireServerFederationRelationshipReconciler)
+		entryReconcilers = append(entryReconcilers, spireServerEntryReconciler)
+		federationRelationshipReconcilers = append(federationRelationshipReconcilers, spireServerFederationRelationshipReconciler)
+	}
+
+	// The bundle endpoint probers run on their own interval rather than the
+	// GC interval, and are also triggered when a ClusterFederatedTrustDomain
+	// changes so that a typo in a bundle endpoint URL is reported promptly.
+	var probers []reconciler.Reconciler
+	if ctrlConfig.BundleEndpointProbe != nil {
+		proberConfig := spirefederationrelationship.ProberConfig{
+			K8sClient:      mgr.GetClient(),
+			BundleClient:   spireClient,
+			TrustDomain:    trustDomain,
+			ClassName:      ctrlConfig.ClassName,
+			WatchClassless: ctrlConfig.WatchClassless,
+			Interval:       ctrlConfig.BundleEndpointProbe.Interval.Duration,
+			Timeout:        ctrlConfig.BundleEndpointProbe.Timeout.Duration,
+			EventRecorder:  eventRecorder,
+		}
+		if proberConfig.Interval == 0 {
+			proberConfig.Interval = defaultProbeInterval
+		}
+		probers = append(probers, spirefederationrelationship.Prober(proberConfig))
+		for i, spireServer := range ctrlConfig.SPIREServers {
+			proberConfig.SPIREServer = spireServer.Name
+			proberConfig.TrustDomain = spiffeid.RequireTrustDomainFromString(spireServer.TrustDomain)
+			proberConfig.BundleClient = spireServerClients[i]
+			probers = append(probers, spirefederationrelationship.Prober(proberConfig))
+		}
+		for _, prober := range probers {
+			federationRelationshipTriggerer = append(federationRelationshipTriggerer, prober)
+		}
+	}
+
+	// The controllers for the custom resources are set up once their CRDs
+	// are available so that the manager can start, and serve the webhooks,
+	// while the CRDs are still being installed, unless the CRD availability
+	// policy is to fail.
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to create discovery client")
 		return err
 	}
-	if err = (&controllers.ClusterFederatedTrustDomainReconciler{
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
-		Triggerer: federationRelationshipReconciler,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ClusterFederatedTrustDomain")
+	crdRegistrations := []crdwatcher.Registration{
+		{
+			GroupVersionKind: spirev1alpha1.GroupVersion.WithKind("ClusterSPIFFEID"),
+			Setup: func() error {
+				return (&controllers.ClusterSPIFFEIDReconciler{
+					Client:    mgr.GetClient(),
+					Scheme:    mgr.GetScheme(),
+					Triggerer: entryTriggerer,
+				}).SetupWithManager(mgr)
+			},
+		},
+		{
+			GroupVersionKind: spirev1alpha1.GroupVersion.WithKind("ClusterFederatedTrustDomain"),
+			Setup: func() error {
+				// The entries are also reconciled so that the ClusterSPIFFEIDs
+				// federating with the trust domain pick up its bundle.
+				return (&controllers.ClusterFederatedTrustDomainReconciler{
+					Client:    mgr.GetClient(),
+					Scheme:    mgr.GetScheme(),
+					Triggerer: reconciler.Triggerers{federationRelationshipTriggerer, entryTriggerer},
+				}).SetupWithManager(mgr)
+			},
+		},
+		{
+			GroupVersionKind: spirev1alpha1.GroupVersion.WithKind("ClusterStaticEntry"),
+			Setup: func() error {
+				return (&controllers.ClusterStaticEntryReconciler{
+					Client:    mgr.GetClient(),
+					Scheme:    mgr.GetScheme(),
+					Triggerer: entryTriggerer,
+				}).SetupWithManager(mgr)
+			},
+		},
+		{
+			GroupVersionKind: spirev1alpha1.GroupVersion.WithKind("ClusterNodeAlias"),
+			Setup: func() error {
+				return (&controllers.ClusterNodeAliasReconciler{
+					Client:    mgr.GetClient(),
+					Scheme:    mgr.GetScheme(),
+					Triggerer: entryTriggerer,
+				}).SetupWithManager(mgr)
+			},
+		},
+		{
+			GroupVersionKind: spirev1alpha1.GroupVersion.WithKind("ClusterTrustDomainSet"),
+			Setup: func() error {
+				return (&controllers.ClusterTrustDomainSetReconciler{
+					Client:    mgr.GetClient(),
+					Scheme:    mgr.GetScheme(),
+					Triggerer: entryTriggerer,
+				}).SetupWithManager(mgr)
+			},
+		},
+	}
+	if namespacePathPrefixTemplate != nil {
+		crdRegistrations = append(crdRegistrations, crdwatcher.Registration{
+			GroupVersionKind: spirev1alpha1.GroupVersion.WithKind("NamespacedSPIFFEID"),
+			Setup: func() error {
+				return (&controllers.NamespacedSPIFFEIDReconciler{
+					Client:    mgr.GetClient(),
+					Scheme:    mgr.GetScheme(),
+					Triggerer: entryTriggerer,
+				}).SetupWithManager(mgr)
+			},
+		})
+	}
+	crdWatcher, err = crdwatcher.New(crdwatcher.Config{
+		Discovery:     discoveryClient,
+		Registrations: crdRegistrations,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to create CRD watcher")
 		return err
 	}
-	if err = (&controllers.ClusterStaticEntryReconciler{
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
-		Triggerer: entryReconciler,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ClusterStaticEntry")
+	pendingCRDs, err := crdWatcher.SetupAvailable(ctx)
+	if err != nil {
+		setupLog.Error(err, "unable to create controllers")
 		return err
 	}
+	if pendingCRDs > 0 {
+		if ctrlConfig.CRDAvailabilityPolicy == spirev1alpha1.CRDAvailabilityPolicyFail {
+			err := fmt.Errorf("custom resource definitions are not installed: %s", formatKinds(crdWatcher.Pending()))
+			setupLog.Error(err, "unable to create controllers")
+			return err
+		}
+		setupLog.Info("Waiting for custom resource definitions to be installed", "pending", pendingCRDs, "policy", ctrlConfig.CRDAvailabilityPolicy)
+		if err = mgr.Add(crdWatcher); err != nil {
+			setupLog.Error(err, "unable to manage CRD watcher")
+			return err
+		}
+	}
+	if ctrlConfig.CRDAvailabilityPolicy == spirev1alpha1.CRDAvailabilityPolicyWait {
+		if err := mgr.AddReadyzCheck("crds", crdAvailabilityCheck(crdWatcher)); err != nil {
+			setupLog.Error(err, "unable to set up CRD availability check")
+			return err
+		}
+	}
 	if err = (&spirev1alpha1.ClusterFederatedTrustDomain{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterFederatedTrustDomain")
 		return err
 	}
-	if err = (&spirev1alpha1.ClusterSPIFFEID{}).SetupWebhookWithManager(mgr); err != nil {
+	webhookOptions := spirev1alpha1.WebhookOptions{
+		AllowedPathPrefixes:         ctrlConfig.AllowedPathPrefixes,
+		NamespacePathPrefixTemplate: namespacePathPrefixTemplate,
+		ClassName:                   ctrlConfig.ClassName,
+		WatchClassless:              ctrlConfig.WatchClassless,
+		PrivilegedEntries:           ctrlConfig.PrivilegedEntries,
+		ClusterSPIFFEIDDefaults:     ctrlConfig.ClusterSPIFFEIDDefaults,
+		TrustDomain:                 trustDomain,
+
+		ClusterStaticEntryDefaults:          ctrlConfig.ClusterStaticEntryDefaults,
+		ClusterFederatedTrustDomainDefaults: ctrlConfig.ClusterFederatedTrustDomainDefaults,
+	}
+	for _, spireServer := range ctrlConfig.SPIREServers {
+		webhookOptions.SPIREServers = append(webhookOptions.SPIREServers, spireServer.Name)
+	}
+	if err = (&spirev1alpha1.ClusterSPIFFEID{}).SetupWebhookWithManager(mgr, webhookOptions); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterSPIFFEID")
 		return err
 	}
+	if err = (&spirev1alpha2.ClusterSPIFFEID{}).SetupWebhookWithManager(mgr, webhookOptions); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterSPIFFEID", "version", "v1alpha2")
+		return err
+	}
+	if err = (&spirev1alpha1.ClusterStaticEntry{}).SetupWebhookWithManager(mgr, webhookOptions); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterStaticEntry")
+		return err
+	}
+	if err = (&spirev1alpha2.ClusterStaticEntry{}).SetupWebhookWithManager(mgr, webhookOptions); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterStaticEntry", "version", "v1alpha2")
+		return err
+	}
+	if err = (&spirev1alpha2.ClusterFederatedTrustDomain{}).SetupWebhookWithManager(mgr, webhookOptions); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterFederatedTrustDomain", "version", "v1alpha2")
+		return err
+	}
+	if err = (&spirev1alpha1.ClusterNodeAlias{}).SetupWebhookWithManager(mgr, webhookOptions); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterNodeAlias")
+		return err
+	}
+	if err = (&spirev1alpha1.ClusterTrustDomainSet{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterTrustDomainSet")
+		return err
+	}
+	if namespacePathPrefixTemplate != nil {
+		if err = (&spirev1alpha1.NamespacedSPIFFEID{}).SetupWebhookWithManager(mgr, webhookOptions); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NamespacedSPIFFEID")
+			return err
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
+	// The entries of removed pods are deleted as soon as the removal is
+	// observed, unless they are left to reconciliation.
+	var podEntryDeleter controllers.PodEntryDeleter
+	if ctrlConfig.PodEntryDeletionPolicy == spirev1alpha1.PodEntryDeletionPolicyImmediate {
+		podEntryDeleter = podEntryDeleters
+	}
 	if err = (&controllers.PodReconciler{
-		Client:           mgr.GetClient(),
-		Scheme:           mgr.GetScheme(),
-		Triggerer:        entryReconciler,
-		IgnoreNamespaces: ctrlConfig.IgnoreNamespaces,
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Triggerer:       entryTriggerer,
+		NamespaceFilter: namespaceFilter,
+
+		PodExcludeSelector: podExcludeSelector,
+		PodEntryDeleter:    podEntryDeleter,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Pod")
 		return err
 	}
 
-	if err = mgr.Add(manager.RunnableFunc(entryReconciler.Run)); err != nil {
-		setupLog.Error(err, "unable to manage entry reconciler")
+	if err = (&controllers.NamespaceReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Triggerer:       entryTriggerer,
+		NamespaceFilter: namespaceFilter,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Namespace")
 		return err
 	}
 
-	if err = mgr.Add(manager.RunnableFunc(federationRelationshipReconciler.Run)); err != nil {
-		setupLog.Error(err, "unable to manage federation relationship reconciler")
+	if err = (&controllers.ServiceReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Triggerer:       entryTriggerer,
+		NamespaceFilter: namespaceFilter,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Service")
 		return err
 	}
 
-	if err = mgr.Add(webhookManager); err != nil {
-		setupLog.Error(err, "unable to manage federation relationship reconciler")
+	for _, entryReconciler := range entryReconcilers {
+		if err = mgr.Add(manager.RunnableFunc(entryReconciler.Run)); err != nil {
+			setupLog.Error(err, "unable to manage entry reconciler")
+			return err
+		}
+	}
+
+	for _, federationRelationshipReconciler := range federationRelationshipReconcilers {
+		if err = mgr.Add(manager.RunnableFunc(federationRelationshipReconciler.Run)); err != nil {
+			setupLog.Error(err, "unable to manage federation relationship reconciler")
+			return err
+		}
+	}
+
+	var gcReconcilers []reconciler.Reconciler
+
+	for _, prober := range probers {
+		if err = mgr.Add(manager.RunnableFunc(prober.Run)); err != nil {
+			setupLog.Error(err, "unable to manage bundle endpoint prober")
+			return err
+		}
+	}
+
+	for _, remoteCluster := range ctrlConfig.RemoteClusters {
+		remoteEntryReconciler, err := addRemoteCluster(ctx, mgr, remoteCluster, entryReconcilerConfig, namespacePathPrefixTemplate != nil)
+		if err != nil {
+			setupLog.Error(err, "unable to manage remote cluster", "cluster", remoteCluster.Name)
+			return err
+		}
+		entryReconcilers = append(entryReconcilers, remoteEntryReconciler)
+	}
+
+	if ctrlConfig.TrustBundleNotification != nil {
+		namespaceSelector, configMaps, err := parseTrustBundleNotificationConfig(ctrlConfig.TrustBundleNotification)
+		if err != nil {
+			setupLog.Error(err, "invalid trust bundle notification configuration")
+			return err
+		}
+		bundleNotifier := bundlenotifier.Reconciler(bundlenotifier.ReconcilerConfig{
+			BundleClient:      spireClient,
+			K8sClient:         mgr.GetClient(),
+			APIReader:         mgr.GetAPIReader(),
+			NamespaceSelector: namespaceSelector,
+			ConfigMaps:        configMaps,
+			GCInterval:        ctrlConfig.GCInterval,
+		})
+		if err = mgr.Add(manager.RunnableFunc(bundleNotifier.Run)); err != nil {
+			setupLog.Error(err, "unable to manage trust bundle notifier")
+			return err
+		}
+		gcReconcilers = append(gcReconcilers, bundleNotifier)
+	}
+
+	if ctrlConfig.BundlePublisher != nil {
+		publisherConfig, err := parseBundlePublisherConfig(ctrlConfig.BundlePublisher)
+		if err != nil {
+			setupLog.Error(err, "invalid bundle publisher configuration")
+			return err
+		}
+		publisherConfig.BundleClient = spireClient
+		publisherConfig.K8sClient = mgr.GetClient()
+		publisherConfig.APIReader = mgr.GetAPIReader()
+		publisherConfig.GCInterval = ctrlConfig.GCInterval
+		if ctrlConfig.BundlePublisher.IncludeWebhookCA {
+			publisherConfig.WebhookCABundle = webhookManager.CABundle
+		}
+		bundlePublisher := bundlepublisher.Reconciler(publisherConfig)
+		if err = mgr.Add(manager.RunnableFunc(bundlePublisher.Run)); err != nil {
+			setupLog.Error(err, "unable to manage bundle publisher")
+			return err
+		}
+		gcReconcilers = append(gcReconcilers, bundlePublisher)
+	}
+
+	// The agents of removed nodes are collected when a node is deleted, and
+	// every GC interval once their delay has passed.
+	if ctrlConfig.AgentGC != nil {
+		agentGC := spireagent.Reconciler(spireagent.ReconcilerConfig{
+			TrustDomain: trustDomain,
+			ClusterName: ctrlConfig.ClusterName,
+			AgentClient: spireClient,
+			K8sClient:   mgr.GetClient(),
+			Delay:       ctrlConfig.AgentGC.Delay.Duration,
+			Ban:         ctrlConfig.AgentGC.Ban,
+			DryRun:      ctrlConfig.DryRun || ctrlConfig.AgentGC.DryRun,
+			GCInterval:  ctrlConfig.GCInterval,
+		})
+		if err = mgr.Add(manager.RunnableFunc(agentGC.Run)); err != nil {
+			setupLog.Error(err, "unable to manage agent GC")
+			return err
+		}
+		if err = (&controllers.NodeReconciler{
+			Client:    mgr.GetClient(),
+			Scheme:    mgr.GetScheme(),
+			Triggerer: agentGC,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Node")
+			return err
+		}
+		gcReconcilers = append(gcReconcilers, agentGC)
+	}
+
+	// Every reconciler, and the bundle endpoint probers, run right away when
+	// the process receives a resync signal.
+	resyncTriggerer := reconciler.Triggerers{}
+	for _, reconcilers := range [][]reconciler.Reconciler{entryReconcilers, federationRelationshipReconcilers, probers, gcReconcilers} {
+		for _, r := range reconcilers {
+			resyncTriggerer = append(resyncTriggerer, r)
+		}
+	}
+	if err = mgr.Add(&resyncer.Resyncer{Triggerer: resyncTriggerer}); err != nil {
+		setupLog.Error(err, "unable to manage resync signal handler")
+		return err
+	}
+
+	if source.path != "" {
+		reloaderConfig := configreloader.Config{
+			Path: source.path,
+			Load: func() (spirev1alpha1.ControllerManagerConfig, error) {
+				ctrlConfig, _, err := loadConfig(source)
+				return ctrlConfig, err
+			},
+			Config:          ctrlConfig,
+			Interval:        defaultConfigReloadInterval,
+			GCReconcilers:   gcReconcilers,
+			EntryReconciler: entryTriggerer,
+			NamespaceFilter: namespaceFilter,
+
+			EntryGCReconcilers:                  entryReconcilers,
+			FederationRelationshipGCReconcilers: federationRelationshipReconcilers,
+		}
+		if source.logLevel != nil {
+			reloaderConfig.SetLogLevel = func(level string) {
+				applyLogLevel(source, level)
+			}
+		}
+		reloader, err := configreloader.New(reloaderConfig)
+		if err != nil {
+			setupLog.Error(err, "unable to create configuration reloader")
+			return err
+		}
+		if err = mgr.Add(reloader); err != nil {
+			setupLog.Error(err, "unable to manage configuration reloader")
+			return err
+		}
+	}
+
+	// The CABundle of an externally provided certificate is managed
+	// externally too (e.g. by the cert-manager CA injector).
+	if ctrlConfig.WebhookCertProvider != spirev1alpha1.WebhookCertProviderExternal {
+		if err = mgr.Add(webhookManager); err != nil {
+			setupLog.Error(err, "unable to manage federation relationship reconciler")
+			return err
+		}
+	}
+	if err = mgr.Add(webhookManager.CertificateRotator()); err != nil {
+		setupLog.Error(err, "unable to manage webhook certificate rotator")
 		return err
 	}
 
@@ -329,6 +1343,28 @@ func run(ctrlConfig spirev1alpha1.ControllerManagerConfig, options ctrl.Options)
 		setupLog.Error(err, "unable to set up ready check")
 		return err
 	}
+	if err := mgr.AddReadyzCheck("initial-sync", initialSyncCheck(mgr.Elected(), entryReconcilers, federationRelationshipReconcilers)); err != nil {
+		setupLog.Error(err, "unable to set up initial sync ready check")
+		return err
+	}
+	if ctrlConfig.SPIREHealthCheck != nil {
+		if err := addSPIREHealthChecks(mgr, ctrlConfig.SPIREHealthCheck, spireClient); err != nil {
+			setupLog.Error(err, "unable to set up SPIRE Server health checks")
+			return err
+		}
+	}
+	if ctrlConfig.DebugServer != nil {
+		debugServerConfig := makeDebugServerConfig(ctrlConfig.DebugServer)
+		if ctrlConfig.DebugServer.Explain {
+			debugServerConfig.Handlers = map[string]http.Handler{explainPath: explainer}
+		}
+		// The configuration was validated when it was loaded.
+		debugServer, _ := debugserver.New(debugServerConfig)
+		if err := mgr.Add(debugServer); err != nil {
+			setupLog.Error(err, "unable to manage debug server")
+			return err
+		}
+	}
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctx); err != nil {
@@ -339,6 +1375,558 @@ func run(ctrlConfig spirev1alpha1.ControllerManagerConfig, options ctrl.Options)
 	return nil
 }
 
+// countNonEmpty returns how many of the values are set.
+func countNonEmpty(values ...string) int {
+	n := 0
+	for _, value := range values {
+		if value != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// defaultSPIREServerTarget returns the target of the default SPIRE Server.
+func defaultSPIREServerTarget(ctrlConfig spirev1alpha1.ControllerManagerConfig) spirev1alpha1.SPIREServerTarget {
+	return spirev1alpha1.SPIREServerTarget{
+		TrustDomain:   ctrlConfig.TrustDomain,
+		ClusterName:   ctrlConfig.ClusterName,
+		SocketPath:    ctrlConfig.SPIREServerSocketPath,
+		NamedPipeName: ctrlConfig.SPIREServerNamedPipeName,
+		Address:       ctrlConfig.SPIREServerAddress,
+		TLS:           ctrlConfig.SPIREServerTLS,
+	}
+}
+
+func dialSPIREServer(ctx context.Context, ctrlConfig spirev1alpha1.ControllerManagerConfig, target spirev1alpha1.SPIREServerTarget) (spireapi.Client, error) {
+	setupLog := setupLog
+	if target.Name != "" {
+		setupLog = setupLog.WithValues("spire server", target.Name)
+	}
+
+	var dialOptions []spireapi.DialOption
+	if entryAPI := ctrlConfig.EntryAPI; entryAPI != nil {
+		dialOptions = append(dialOptions, spireapi.WithEntryClientOptions(spireapi.EntryClientOptions{
+			CreateBatchSize:      entryAPI.CreateBatchSize,
+			UpdateBatchSize:      entryAPI.UpdateBatchSize,
+			DeleteBatchSize:      entryAPI.DeleteBatchSize,
+			MaxConcurrentBatches: entryAPI.MaxConcurrentBatches,
+			CallsPerSecond:       entryAPI.CallsPerSecond,
+			Burst:                entryAPI.Burst,
+		}))
+	}
+
+	if ctrlConfig.SPIREAPIRetry != nil {
+		dialOptions = append(dialOptions, spireapi.WithRetry(makeRetryConfig(ctrlConfig.SPIREAPIRetry)))
+	}
+
+	if target.NamedPipeName != "" {
+		setupLog.Info("Dialing SPIRE Server named pipe")
+		spireClient, err := spireapi.DialNamedPipe(ctx, target.NamedPipeName, dialOptions...)
+		if err != nil {
+			setupLog.Error(err, "unable to dial SPIRE Server named pipe")
+			return nil, err
+		}
+		return spireClient, nil
+	}
+
+	if target.Address == "" {
+		setupLog.Info("Dialing SPIRE Server socket")
+		spireClient, err := spireapi.DialSocket(ctx, target.SocketPath, dialOptions...)
+		if err != nil {
+			setupLog.Error(err, "unable to dial SPIRE Server socket")
+			return nil, err
+		}
+		return spireClient, nil
+	}
+
+	trustDomain, err := spiffeid.TrustDomainFromString(target.TrustDomain)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := target.TLS
+	serverID, err := spiffeid.FromPath(trustDomain, "/spire/server")
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig.ServerID != "" {
+		serverID, err = spiffeid.FromString(tlsConfig.ServerID)
+		if err != nil {
+			setupLog.Error(err, "invalid SPIRE Server ID")
+			return nil, err
+		}
+	}
+
+	var svidSource x509svid.Source
+	var bundleSource x509bundle.Source
+	var closer io.Closer
+	switch {
+	case tlsConfig.WorkloadAPISocketPath != "":
+		setupLog.Info("Fetching SPIRE Server client credentials from the Workload API")
+		source, err := workloadapi.NewX509Source(ctx, workloadapi.WithClientOptions(workloadapi.WithAddr("unix://"+tlsConfig.WorkloadAPISocketPath)))
+		if err != nil {
+			setupLog.Error(err, "unable to fetch SPIRE Server client credentials from the Workload API")
+			return nil, err
+		}
+		svidSource, bundleSource, closer = source, source, source
+	case tlsConfig.CertFile != "" && tlsConfig.KeyFile != "" && tlsConfig.BundleFile != "":
+		source := spireapi.NewFileSource(serverID.TrustDomain(), tlsConfig.CertFile, tlsConfig.KeyFile, tlsConfig.BundleFile)
+		svidSource, bundleSource = source, source
+	default:
+		err := errors.New("either a workload API socket path or certificate, key, and bundle files are required")
+		setupLog.Error(err, "invalid SPIRE Server TLS configuration")
+		return nil, err
+	}
+
+	setupLog.Info("Dialing SPIRE Server address", "address", target.Address, "server ID", serverID.String())
+	spireClient, err := spireapi.DialTCP(ctx, target.Address, tlsconfig.MTLSClientConfig(svidSource, bundleSource, tlsconfig.AuthorizeID(serverID)), dialOptions...)
+	if err != nil {
+		if closer != nil {
+			_ = closer.Close()
+		}
+		setupLog.Error(err, "unable to dial SPIRE Server address")
+		return nil, err
+	}
+	if closer == nil {
+		return spireClient, nil
+	}
+	return closingClient{Client: spireClient, source: closer}, nil
+}
+
+// makeRetryConfig converts the retry configuration, applying defaults. The
+// operation policies default to the default policy.
+func makeRetryConfig(config *spirev1alpha1.SPIREAPIRetryConfig) spireapi.RetryConfig {
+	defaultPolicy := mergeRetryPolicy(config.SPIREAPIRetryPolicy, spireapi.RetryPolicy{
+		MaxAttempts:    defaultRetryMaxAttempts,
+		InitialBackoff: defaultRetryInitialBackoff,
+		MaxBackoff:     defaultRetryMaxBackoff,
+	})
+	retryConfig := spireapi.RetryConfig{Default: defaultPolicy}
+	if len(config.Operations) > 0 {
+		retryConfig.Operations = make(map[string]spireapi.RetryPolicy, len(config.Operations))
+		for operation, policy := range config.Operations {
+			retryConfig.Operations[operation] = mergeRetryPolicy(policy, defaultPolicy)
+		}
+	}
+	return retryConfig
+}
+
+func mergeRetryPolicy(policy spirev1alpha1.SPIREAPIRetryPolicy, defaults spireapi.RetryPolicy) spireapi.RetryPolicy {
+	merged := defaults
+	if policy.MaxAttempts != 0 {
+		merged.MaxAttempts = policy.MaxAttempts
+	}
+	if policy.InitialBackoff.Duration != 0 {
+		merged.InitialBackoff = policy.InitialBackoff.Duration
+	}
+	if policy.MaxBackoff.Duration != 0 {
+		merged.MaxBackoff = policy.MaxBackoff.Duration
+	}
+	return merged
+}
+
+func validRetryPolicies(config *spirev1alpha1.SPIREAPIRetryConfig) bool {
+	valid := func(policy spirev1alpha1.SPIREAPIRetryPolicy) bool {
+		return policy.MaxAttempts >= 0 && policy.InitialBackoff.Duration >= 0 && policy.MaxBackoff.Duration >= 0
+	}
+	if !valid(config.SPIREAPIRetryPolicy) {
+		return false
+	}
+	for _, policy := range config.Operations {
+		if !valid(policy) {
+			return false
+		}
+	}
+	return true
+}
+
+// initialSyncCheck fails until the entry and federation relationship
+// reconcilers, including those of additional SPIRE Servers and remote
+// clusters, have each completed a reconciliation, so that a new instance is
+// not ready before it has converged the SPIRE Server state. Only the leader
+// reconciles, so instances that are not the leader are ready.
+func initialSyncCheck(elected <-chan struct{}, entryReconcilers, federationRelationshipReconcilers []reconciler.Reconciler) healthz.Checker {
+	return func(*http.Request) error {
+		select {
+		case <-elected:
+		default:
+			return nil
+		}
+		if !allSynced(entryReconcilers) {
+			return errors.New("initial entry reconciliation has not completed")
+		}
+		if !allSynced(federationRelationshipReconcilers) {
+			return errors.New("initial federation relationship reconciliation has not completed")
+		}
+		return nil
+	}
+}
+
+func allSynced(reconcilers []reconciler.Reconciler) bool {
+	for _, r := range reconcilers {
+		if !r.Synced() {
+			return false
+		}
+	}
+	return true
+}
+
+// newSecureMetricsServer returns the metrics server for the secure metrics
+// configuration. The metrics are served with the webhook serving certificate
+// unless a certificate is configured.
+func newSecureMetricsServer(ctrlConfig spirev1alpha1.ControllerManagerConfig, clientset kubernetes.Interface, webhookManager *webhookmanager.Manager) (*metricsserver.Server, error) {
+	config := metricsserver.Config{
+		BindAddress:    ctrlConfig.ControllerManagerConfigurationSpec.Metrics.BindAddress,
+		CertFile:       ctrlConfig.SecureMetrics.CertFile,
+		KeyFile:        ctrlConfig.SecureMetrics.KeyFile,
+		GetCertificate: webhookManager.GetCertificate,
+	}
+	if !ctrlConfig.SecureMetrics.SkipAuthorization {
+		config.TokenReviews = clientset.AuthenticationV1().TokenReviews()
+		config.SubjectAccessReviews = clientset.AuthorizationV1().SubjectAccessReviews()
+	}
+	return metricsserver.New(config)
+}
+
+// crdAvailabilityCheck fails until the custom resource definitions for all
+// of the controllers are installed.
+func crdAvailabilityCheck(crdWatcher *crdwatcher.Watcher) healthz.Checker {
+	return func(*http.Request) error {
+		if pending := crdWatcher.Pending(); len(pending) > 0 {
+			return fmt.Errorf("custom resource definitions are not installed: %s", formatKinds(pending))
+		}
+		return nil
+	}
+}
+
+func formatKinds(kinds []schema.GroupVersionKind) string {
+	names := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		names = append(names, kind.Kind)
+	}
+	return strings.Join(names, ", ")
+}
+
+// addSPIREHealthChecks adds checks of the SPIRE Server connection to the
+// health and ready checks. Each check counts its own consecutive failures.
+func addSPIREHealthChecks(mgr ctrl.Manager, config *spirev1alpha1.SPIREHealthCheckConfig, bundleClient spireapi.BundleClient) error {
+	livenessThreshold := config.LivenessFailureThreshold
+	if livenessThreshold == 0 {
+		livenessThreshold = defaultLivenessThreshold
+	}
+	livenessChecker, err := spirehealth.NewChecker(spirehealth.Config{
+		BundleClient:     bundleClient,
+		Timeout:          config.Timeout.Duration,
+		FailureThreshold: livenessThreshold,
+	})
+	if err != nil {
+		return err
+	}
+	readinessChecker, err := spirehealth.NewChecker(spirehealth.Config{
+		BundleClient:     bundleClient,
+		Timeout:          config.Timeout.Duration,
+		FailureThreshold: config.ReadinessFailureThreshold,
+	})
+	if err != nil {
+		return err
+	}
+	if err := mgr.AddHealthzCheck("spire-server", livenessChecker.Check); err != nil {
+		return err
+	}
+	return mgr.AddReadyzCheck("spire-server", readinessChecker.Check)
+}
+
+// closingClient closes the credential source along with the client.
+type closingClient struct {
+	spireapi.Client
+	source io.Closer
+}
+
+func (c closingClient) Close() error {
+	return errors.Join(c.Client.Close(), c.source.Close())
+}
+
+func parseTrustBundleNotificationConfig(config *spirev1alpha1.TrustBundleNotificationConfig) (labels.Selector, []types.NamespacedName, error) {
+	var namespaceSelector labels.Selector
+	if config.NamespaceSelector != nil {
+		var err error
+		namespaceSelector, err = metav1.LabelSelectorAsSelector(config.NamespaceSelector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid namespace selector: %w", err)
+		}
+	}
+	configMaps := make([]types.NamespacedName, 0, len(config.ConfigMaps))
+	for _, configMap := range config.ConfigMaps {
+		if configMap.Namespace == "" || configMap.Name == "" {
+			return nil, nil, errors.New("config map namespace and name are required")
+		}
+		configMaps = append(configMaps, types.NamespacedName{Namespace: configMap.Namespace, Name: configMap.Name})
+	}
+	return namespaceSelector, configMaps, nil
+}
+
+// spireServerTargetReconcilers returns the entry and federation relationship
+// reconcilers for an additional SPIRE Server. They are based on those of the
+// default SPIRE Server. NamespacedSPIFFEIDs, pod SPIFFE ID annotations, the
+// snapshot and the identity report only apply to the default SPIRE Server.
+func spireServerTargetReconcilers(target spirev1alpha1.SPIREServerTarget, spireClient spireapi.Client, entryReconcilerConfig spireentry.ReconcilerConfig, federationRelationshipReconcilerConfig spirefederationrelationship.ReconcilerConfig) (spireentry.EntryReconciler, reconciler.Reconciler) {
+	entryReconcilerConfig.SPIREServer = target.Name
+	entryReconcilerConfig.TrustDomain = spiffeid.RequireTrustDomainFromString(target.TrustDomain)
+	entryReconcilerConfig.ClusterName = target.ClusterName
+	entryReconcilerConfig.EntryClient = spireClient
+	entryReconcilerConfig.BundleClient = spireClient
+	entryReconcilerConfig.ManagesEntry = nil
+	entryReconcilerConfig.NamespacePathPrefixTemplate = nil
+	entryReconcilerConfig.SPIFFEIDAnnotationPathPrefixTemplate = nil
+	entryReconcilerConfig.SnapshotPath = ""
+	entryReconcilerConfig.IdentityReporter = nil
+
+	federationRelationshipReconcilerConfig.SPIREServer = target.Name
+	federationRelationshipReconcilerConfig.TrustDomainClient = spireClient
+	federationRelationshipReconcilerConfig.BundleClient = spireClient
+
+	return spireentry.Reconciler(entryReconcilerConfig), spirefederationrelationship.Reconciler(federationRelationshipReconcilerConfig)
+}
+
+// localClusterEntries returns whether the entry belongs to the local
+// cluster, i.e. is not the entry of a pod in one of the remote clusters.
+func localClusterEntries(remoteClusters []spirev1alpha1.RemoteClusterConfig) func(spireapi.Entry) bool {
+	prefixes := make([]string, 0, len(remoteClusters))
+	for _, remoteCluster := range remoteClusters {
+		prefixes = append(prefixes, spireentry.ClusterAgentPathPrefix(remoteCluster.Name))
+	}
+	return func(entry spireapi.Entry) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(entry.ParentID.Path(), prefix) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// addRemoteCluster caches the objects of the remote cluster and adds an
+// entry reconciler for its pods, which is triggered when the cached objects
+// change. The entry reconciler is based on that of the local cluster.
+func addRemoteCluster(ctx context.Context, mgr manager.Manager, remoteCluster spirev1alpha1.RemoteClusterConfig, entryReconcilerConfig spireentry.ReconcilerConfig, namespacedSPIFFEIDs bool) (reconciler.Reconciler, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", remoteCluster.KubeConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	remote, err := cluster.New(restConfig, func(o *cluster.Options) {
+		o.Scheme = mgr.GetScheme()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cluster: %w", err)
+	}
+	if err := mgr.Add(remote); err != nil {
+		return nil, fmt.Errorf("failed to manage cluster: %w", err)
+	}
+
+	agentPathPrefix := spireentry.ClusterAgentPathPrefix(remoteCluster.Name)
+	entryReconcilerConfig.ClusterName = remoteCluster.Name
+	entryReconcilerConfig.ClusterDomain = remoteCluster.ClusterDomain
+	entryReconcilerConfig.K8sClient = remote.GetClient()
+	entryReconcilerConfig.EventRecorder = remote.GetEventRecorderFor("spire-controller-manager")
+	entryReconcilerConfig.ManagesEntry = func(entry spireapi.Entry) bool {
+		return strings.HasPrefix(entry.ParentID.Path(), agentPathPrefix)
+	}
+	entryReconcilerConfig.RemoteCluster = true
+	// The snapshot and identity report describe the local cluster.
+	entryReconcilerConfig.SnapshotPath = ""
+	entryReconcilerConfig.IdentityReporter = nil
+	entryReconciler := spireentry.Reconciler(entryReconcilerConfig)
+
+	objs := []client.Object{
+		&corev1.Pod{},
+		&corev1.Namespace{},
+		&corev1.Service{},
+		&spirev1alpha1.ClusterSPIFFEID{},
+		&spirev1alpha1.ClusterTrustDomainSet{},
+	}
+	if namespacedSPIFFEIDs {
+		objs = append(objs, &spirev1alpha1.NamespacedSPIFFEID{})
+	}
+	trigger := func(interface{}) { entryReconciler.Trigger() }
+	for _, obj := range objs {
+		informer, err := remote.GetCache().GetInformer(ctx, obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to watch %T: %w", obj, err)
+		}
+		if _, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+			AddFunc:    trigger,
+			UpdateFunc: func(_, obj interface{}) { trigger(obj) },
+			DeleteFunc: trigger,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to watch %T: %w", obj, err)
+		}
+	}
+
+	if err := mgr.Add(manager.RunnableFunc(entryReconciler.Run)); err != nil {
+		return nil, fmt.Errorf("failed to manage entry reconciler: %w", err)
+	}
+	return entryReconciler, nil
+}
+
+func parseWebhookCABundleTargets(targets []spirev1alpha1.WebhookCABundleTarget) ([]webhookmanager.CABundleTarget, error) {
+	out := make([]webhookmanager.CABundleTarget, 0, len(targets))
+	for _, target := range targets {
+		kind := webhookmanager.WebhookKind(target.Kind)
+		if kind != webhookmanager.ValidatingWebhookKind && kind != webhookmanager.MutatingWebhookKind && kind != webhookmanager.CustomResourceDefinitionKind {
+			return nil, fmt.Errorf("invalid webhook configuration kind %q", target.Kind)
+		}
+		if target.Name == "" && target.Selector == nil {
+			return nil, errors.New("webhook CABundle target requires a name or selector")
+		}
+		caBundleTarget := webhookmanager.CABundleTarget{Kind: kind, Name: target.Name}
+		if target.Selector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(target.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid webhook CABundle target selector: %w", err)
+			}
+			caBundleTarget.Selector = selector
+		}
+		out = append(out, caBundleTarget)
+	}
+	return out, nil
+}
+
+func parseWebhookSettings(config *spirev1alpha1.WebhookSettingsConfig) (*webhookmanager.WebhookSettings, map[string]webhookmanager.WebhookSettings, error) {
+	settings, err := parseWebhookSettingsOverride(spirev1alpha1.WebhookSettingsOverride{
+		FailurePolicy:     config.FailurePolicy,
+		NamespaceSelector: config.NamespaceSelector,
+		ObjectSelector:    config.ObjectSelector,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	perWebhookSettings := make(map[string]webhookmanager.WebhookSettings, len(config.Webhooks))
+	for _, override := range config.Webhooks {
+		if override.Name == "" {
+			return nil, nil, errors.New("webhook settings override requires a name")
+		}
+		if _, ok := perWebhookSettings[override.Name]; ok {
+			return nil, nil, fmt.Errorf("duplicate webhook settings override for %q", override.Name)
+		}
+		overrideSettings, err := parseWebhookSettingsOverride(override)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid webhook settings override for %q: %w", override.Name, err)
+		}
+		perWebhookSettings[override.Name] = *overrideSettings
+	}
+	return settings, perWebhookSettings, nil
+}
+
+func parseWebhookSettingsOverride(override spirev1alpha1.WebhookSettingsOverride) (*webhookmanager.WebhookSettings, error) {
+	if failurePolicy := override.FailurePolicy; failurePolicy != nil &&
+		*failurePolicy != admissionregistrationv1.Fail && *failurePolicy != admissionregistrationv1.Ignore {
+		return nil, fmt.Errorf("invalid failure policy %q", *failurePolicy)
+	}
+	for _, selector := range []*metav1.LabelSelector{override.NamespaceSelector, override.ObjectSelector} {
+		if selector == nil {
+			continue
+		}
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			return nil, fmt.Errorf("invalid selector: %w", err)
+		}
+	}
+	for _, rule := range override.Rules {
+		if len(rule.Operations) == 0 || len(rule.APIGroups) == 0 || len(rule.APIVersions) == 0 || len(rule.Resources) == 0 {
+			return nil, errors.New("rules require operations, apiGroups, apiVersions, and resources")
+		}
+	}
+	return &webhookmanager.WebhookSettings{
+		FailurePolicy:     override.FailurePolicy,
+		NamespaceSelector: override.NamespaceSelector,
+		ObjectSelector:    override.ObjectSelector,
+		Rules:             override.Rules,
+	}, nil
+}
+
+func parseBundlePublisherConfig(config *spirev1alpha1.BundlePublisherConfig) (bundlepublisher.ReconcilerConfig, error) {
+	publisherConfig := bundlepublisher.ReconcilerConfig{
+		Kind:         bundlepublisher.Kind(config.Kind),
+		Name:         config.Name,
+		Namespaces:   config.Namespaces,
+		BundleKey:    config.BundleKey,
+		WebhookCAKey: config.WebhookCAKey,
+		IncludeJWKS:  config.IncludeJWKS,
+		JWKSKey:      config.JWKSKey,
+	}
+	if config.NamespaceSelector != nil {
+		namespaceSelector, err := metav1.LabelSelectorAsSelector(config.NamespaceSelector)
+		if err != nil {
+			return publisherConfig, fmt.Errorf("invalid namespace selector: %w", err)
+		}
+		publisherConfig.NamespaceSelector = namespaceSelector
+	}
+	return publisherConfig, nil
+}
+
+func makeDebugServerConfig(config *spirev1alpha1.DebugServerConfig) debugserver.Config {
+	debugServerConfig := debugserver.Config{BindAddress: config.BindAddress}
+	if config.TLS != nil {
+		debugServerConfig.CertFile = config.TLS.CertFile
+		debugServerConfig.KeyFile = config.TLS.KeyFile
+		debugServerConfig.ClientCAFile = config.TLS.ClientCAFile
+	}
+	return debugServerConfig
+}
+
+// parsePodExcludeSelector parses the global pod exclude selector, which may
+// be nil.
+func parsePodExcludeSelector(podExcludeSelector *metav1.LabelSelector) (labels.Selector, error) {
+	if podExcludeSelector == nil {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(podExcludeSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pod exclude selector: %w", err)
+	}
+	return selector, nil
+}
+
+// parseLogLevel parses the log level the same way as the --zap-log-level
+// flag.
+func parseLogLevel(level string) (zapcore.Level, error) {
+	switch strings.ToLower(level) {
+	case "":
+		return defaultLogLevel, nil
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "info":
+		return zapcore.InfoLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	}
+	verbosity, err := strconv.Atoi(level)
+	if err != nil || verbosity <= 0 || verbosity > 127 {
+		return 0, fmt.Errorf("invalid log level %q", level)
+	}
+	return zapcore.Level(int8(-verbosity)), nil
+}
+
+// applyLogLevel sets the level of the logger from the configuration, unless
+// the log level was set on the command line.
+func applyLogLevel(source configSource, level string) {
+	if source.logLevel == nil {
+		return
+	}
+	// The log level was validated when the configuration was loaded.
+	zapLevel, _ := parseLogLevel(level)
+	source.logLevel.SetLevel(zapLevel)
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 func autoDetectClusterDomain() (string, error) {
 	cname, err := net.LookupCNAME(k8sDefaultService)
 	if err != nil {
@@ -0,0 +1,232 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundlenotifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// RevisionAnnotation is incremented each time the trust bundle changes.
+	// Workloads can watch for changes to this annotation as a cheap signal
+	// to reload trust bundles they consume from files.
+	RevisionAnnotation = "spire.spiffe.io/trust-bundle-revision"
+
+	// DigestAnnotation holds the digest of the trust bundle contents as of
+	// the current revision. It is used to detect rotation without having to
+	// keep any state in the controller.
+	DigestAnnotation = "spire.spiffe.io/trust-bundle-digest"
+
+	namespaceLogKey = "namespace"
+	configMapLogKey = "configMap"
+	revisionLogKey  = "revision"
+)
+
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;patch
+
+type ReconcilerConfig struct {
+	BundleClient spireapi.BundleClient
+	K8sClient    client.Client
+
+	// APIReader is used to read the ConfigMaps directly from the API server
+	// so that the controller does not need to cache every ConfigMap in the
+	// cluster.
+	APIReader client.Reader
+
+	// NamespaceSelector selects the namespaces to annotate. If nil, no
+	// namespaces are annotated.
+	NamespaceSelector labels.Selector
+
+	// ConfigMaps are the ConfigMaps to annotate.
+	ConfigMaps []types.NamespacedName
+
+	// GCInterval how long to sit idle (i.e. untriggered) before doing
+	// another reconcile.
+	GCInterval time.Duration
+}
+
+func Reconciler(config ReconcilerConfig) reconciler.Reconciler {
+	return reconciler.New(reconciler.Config{
+		Kind: "trust bundle notification",
+		Reconcile: func(ctx context.Context) error {
+			return Reconcile(ctx, config)
+		},
+		GCInterval: config.GCInterval,
+	})
+}
+
+func Reconcile(ctx context.Context, config ReconcilerConfig) error {
+	r := &bundleNotifier{
+		config: config,
+	}
+	return r.reconcile(ctx)
+}
+
+type bundleNotifier struct {
+	config ReconcilerConfig
+}
+
+func (r *bundleNotifier) reconcile(ctx context.Context) error {
+	log := log.FromContext(ctx)
+
+	bundle, err := r.config.BundleClient.GetBundle(ctx)
+	if err != nil {
+		log.Error(err, "Failed to get trust bundle")
+		return err
+	}
+
+	digest, err := bundleDigest(bundle)
+	if err != nil {
+		log.Error(err, "Failed to calculate trust bundle digest")
+		return err
+	}
+
+	if r.config.NamespaceSelector != nil {
+		r.annotateNamespaces(ctx, digest)
+	}
+	for _, name := range r.config.ConfigMaps {
+		r.annotateConfigMap(ctx, name, digest)
+	}
+	return nil
+}
+
+func (r *bundleNotifier) annotateNamespaces(ctx context.Context, digest string) {
+	log := log.FromContext(ctx)
+
+	var namespaces corev1.NamespaceList
+	if err := r.config.K8sClient.List(ctx, &namespaces, client.MatchingLabelsSelector{Selector: r.config.NamespaceSelector}); err != nil {
+		log.Error(err, "Failed to list namespaces")
+		return
+	}
+
+	for i := range namespaces.Items {
+		namespace := &namespaces.Items[i]
+		revision, err := r.annotate(ctx, namespace, digest)
+		switch {
+		case err != nil:
+			log.Error(err, "Failed to annotate namespace with trust bundle revision", namespaceLogKey, namespace.Name)
+		case revision != "":
+			log.Info("Annotated namespace with trust bundle revision", namespaceLogKey, namespace.Name, revisionLogKey, revision)
+		}
+	}
+}
+
+func (r *bundleNotifier) annotateConfigMap(ctx context.Context, name types.NamespacedName, digest string) {
+	log := log.FromContext(ctx).WithValues(configMapLogKey, name.String())
+
+	configMap := new(corev1.ConfigMap)
+	if err := r.config.APIReader.Get(ctx, name, configMap); err != nil {
+		log.Error(err, "Failed to get ConfigMap")
+		return
+	}
+
+	revision, err := r.annotate(ctx, configMap, digest)
+	switch {
+	case err != nil:
+		log.Error(err, "Failed to annotate ConfigMap with trust bundle revision")
+	case revision != "":
+		log.Info("Annotated ConfigMap with trust bundle revision", revisionLogKey, revision)
+	}
+}
+
+// annotate bumps the revision annotation on the object if the digest
+// annotation does not match the given digest. It returns the new revision, or
+// an empty string if the object was already up-to-date.
+func (r *bundleNotifier) annotate(ctx context.Context, obj client.Object, digest string) (string, error) {
+	annotations := obj.GetAnnotations()
+	if annotations[DigestAnnotation] == digest {
+		return "", nil
+	}
+
+	// Treat a missing or malformed revision as zero so the object converges
+	// on a valid revision.
+	revision, _ := strconv.ParseUint(annotations[RevisionAnnotation], 10, 64)
+	newRevision := strconv.FormatUint(revision+1, 10)
+
+	// The optimistic lock prevents concurrent updates from losing a revision
+	// bump. Conflicts are resolved on the next reconcile.
+	patch := client.MergeFromWithOptions(obj.DeepCopyObject().(client.Object), client.MergeFromWithOptimisticLock{})
+
+	newAnnotations := make(map[string]string, len(annotations)+2)
+	for k, v := range annotations {
+		newAnnotations[k] = v
+	}
+	newAnnotations[RevisionAnnotation] = newRevision
+	newAnnotations[DigestAnnotation] = digest
+	obj.SetAnnotations(newAnnotations)
+
+	if err := r.config.K8sClient.Patch(ctx, obj, patch); err != nil {
+		return "", err
+	}
+	return newRevision, nil
+}
+
+// bundleDigest returns a digest over the X.509 and JWT authorities in the
+// bundle. Other bundle fields (e.g. the sequence number and refresh hint)
+// are excluded so that the digest only changes when the authorities do.
+func bundleDigest(bundle *spiffebundle.Bundle) (string, error) {
+	x509Authorities := bundle.X509Authorities()
+	sort.Slice(x509Authorities, func(i, j int) bool {
+		return string(x509Authorities[i].Raw) < string(x509Authorities[j].Raw)
+	})
+
+	jwtAuthorities := bundle.JWTAuthorities()
+	keyIDs := make([]string, 0, len(jwtAuthorities))
+	for keyID := range jwtAuthorities {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+
+	h := sha256.New()
+	for _, x509Authority := range x509Authorities {
+		writeField(h, x509Authority.Raw)
+	}
+	for _, keyID := range keyIDs {
+		publicKey, err := x509.MarshalPKIXPublicKey(jwtAuthorities[keyID])
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal JWT authority %q: %w", keyID, err)
+		}
+		writeField(h, []byte(keyID))
+		writeField(h, publicKey)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeField writes a length-prefixed field to the hash so that field
+// boundaries are unambiguous.
+func writeField(w io.Writer, b []byte) {
+	_, _ = w.Write([]byte(strconv.Itoa(len(b)) + ":"))
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,148 @@
+package bundlenotifier
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/spiffe/spire-controller-manager/pkg/test/k8stest"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	ctx = context.Background()
+	td  = spiffeid.RequireTrustDomainFromString("domain.test")
+)
+
+func TestReconcile(t *testing.T) {
+	k8sClient := k8stest.WithScheme(t, fake.NewClientBuilder()).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "selected", Labels: map[string]string{"bundle": "yes"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unselected"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "malformed", Labels: map[string]string{"bundle": "yes"}, Annotations: map[string]string{
+			RevisionAnnotation: "bad",
+			"other":            "value",
+		}}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "spire-system", Name: "trust-bundle"}},
+	).Build()
+
+	bundleClient := &bundleClient{bundle: newBundle(t)}
+	config := ReconcilerConfig{
+		BundleClient:      bundleClient,
+		K8sClient:         k8sClient,
+		APIReader:         k8sClient,
+		NamespaceSelector: labels.SelectorFromSet(labels.Set{"bundle": "yes"}),
+		ConfigMaps:        []types.NamespacedName{{Namespace: "spire-system", Name: "trust-bundle"}},
+	}
+
+	// Objects start at revision 1, including ones with a malformed revision.
+	Reconcile(ctx, config)
+	requireRevision(t, k8sClient, &corev1.Namespace{}, "selected", "1")
+	requireRevision(t, k8sClient, &corev1.Namespace{}, "unselected", "")
+	requireRevision(t, k8sClient, &corev1.Namespace{}, "malformed", "1")
+	requireRevision(t, k8sClient, &corev1.ConfigMap{}, "spire-system/trust-bundle", "1")
+
+	// Other annotations are preserved.
+	ns := new(corev1.Namespace)
+	require.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Name: "malformed"}, ns))
+	require.Equal(t, "value", ns.Annotations["other"])
+
+	// Revision is not bumped when the authorities have not changed, even if
+	// other bundle fields have.
+	bundleClient.bundle.SetSequenceNumber(2)
+	Reconcile(ctx, config)
+	requireRevision(t, k8sClient, &corev1.Namespace{}, "selected", "1")
+	requireRevision(t, k8sClient, &corev1.ConfigMap{}, "spire-system/trust-bundle", "1")
+
+	// Revision is bumped when the authorities change.
+	bundleClient.bundle = newBundle(t)
+	Reconcile(ctx, config)
+	requireRevision(t, k8sClient, &corev1.Namespace{}, "selected", "2")
+	requireRevision(t, k8sClient, &corev1.Namespace{}, "unselected", "")
+	requireRevision(t, k8sClient, &corev1.Namespace{}, "malformed", "2")
+	requireRevision(t, k8sClient, &corev1.ConfigMap{}, "spire-system/trust-bundle", "2")
+}
+
+func TestBundleDigest(t *testing.T) {
+	bundle1 := newBundle(t)
+	bundle2 := newBundle(t)
+
+	digest1, err := bundleDigest(bundle1)
+	require.NoError(t, err)
+	digest2, err := bundleDigest(bundle2)
+	require.NoError(t, err)
+	require.NotEqual(t, digest1, digest2)
+
+	// Authority order does not matter
+	combined1 := spiffebundle.FromX509Authorities(td, append(bundle1.X509Authorities(), bundle2.X509Authorities()...))
+	combined2 := spiffebundle.FromX509Authorities(td, append(bundle2.X509Authorities(), bundle1.X509Authorities()...))
+	combinedDigest1, err := bundleDigest(combined1)
+	require.NoError(t, err)
+	combinedDigest2, err := bundleDigest(combined2)
+	require.NoError(t, err)
+	require.Equal(t, combinedDigest1, combinedDigest2)
+
+	// JWT authorities are included
+	require.NoError(t, bundle1.AddJWTAuthority("kid", bundle2.X509Authorities()[0].PublicKey))
+	withJWTDigest, err := bundleDigest(bundle1)
+	require.NoError(t, err)
+	require.NotEqual(t, digest1, withJWTDigest)
+}
+
+func requireRevision(t *testing.T, k8sClient client.Client, obj client.Object, name string, expected string) {
+	key := types.NamespacedName{Name: name}
+	if ns, n, ok := strings.Cut(name, "/"); ok {
+		key = types.NamespacedName{Namespace: ns, Name: n}
+	}
+	require.NoError(t, k8sClient.Get(ctx, key, obj))
+	require.Equal(t, expected, obj.GetAnnotations()[RevisionAnnotation], "unexpected revision for %s", name)
+}
+
+func newBundle(t *testing.T) *spiffebundle.Bundle {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+	return spiffebundle.FromX509Authorities(td, []*x509.Certificate{cert})
+}
+
+type bundleClient struct {
+	bundle *spiffebundle.Bundle
+}
+
+func (c *bundleClient) GetBundle(context.Context) (*spiffebundle.Bundle, error) {
+	return c.bundle, nil
+}
+
+func (c *bundleClient) GetFederatedBundle(context.Context, spiffeid.TrustDomain) (*spiffebundle.Bundle, error) {
+	return nil, errors.New("unexpected call to GetFederatedBundle")
+}
+
+func (c *bundleClient) ListFederatedBundles(context.Context) ([]*spiffebundle.Bundle, error) {
+	return nil, errors.New("unexpected call to ListFederatedBundles")
+}
+
+func (c *bundleClient) DeleteFederatedBundles(context.Context, []spiffeid.TrustDomain) ([]spireapi.Status, error) {
+	return nil, errors.New("unexpected call to DeleteFederatedBundles")
+}
@@ -0,0 +1,316 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundlepublisher
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// ManagedByLabel marks the objects written by the publisher. Existing
+	// objects without the label are never overwritten.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+
+	// ManagedByValue is the value of ManagedByLabel on published objects.
+	ManagedByValue = "spire-controller-manager"
+
+	// DefaultBundleKey is the default key holding the PEM encoded X.509
+	// authorities of the trust bundle.
+	DefaultBundleKey = "bundle.pem"
+
+	// DefaultWebhookCAKey is the default key holding the PEM encoded
+	// webhook CA.
+	DefaultWebhookCAKey = "webhook-ca.pem"
+
+	// DefaultJWKSKey is the default key holding the trust bundle in SPIFFE
+	// bundle (JWKS) format.
+	DefaultJWKSKey = "bundle.jwks"
+
+	namespaceLogKey = "namespace"
+	nameLogKey      = "name"
+	kindLogKey      = "kind"
+)
+
+// Kind is the kind of object the bundle is published to.
+type Kind string
+
+const (
+	KindConfigMap Kind = "ConfigMap"
+	KindSecret    Kind = "Secret"
+)
+
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;create;update
+
+type ReconcilerConfig struct {
+	BundleClient spireapi.BundleClient
+	K8sClient    client.Client
+
+	// APIReader is used to read the published objects directly from the
+	// API server so that the controller does not need to cache every
+	// ConfigMap or Secret in the cluster.
+	APIReader client.Reader
+
+	// Kind is the kind of object to publish to. Defaults to ConfigMap.
+	Kind Kind
+
+	// Name is the name of the published object in each namespace.
+	Name string
+
+	// NamespaceSelector selects the namespaces to publish to. If nil, only
+	// the namespaces listed in Namespaces are published to.
+	NamespaceSelector labels.Selector
+
+	// Namespaces are additional namespaces to publish to.
+	Namespaces []string
+
+	// BundleKey is the key holding the trust bundle. Defaults to
+	// DefaultBundleKey.
+	BundleKey string
+
+	// WebhookCABundle, if set, returns the PEM encoded webhook CA, which is
+	// published under WebhookCAKey.
+	WebhookCABundle func() []byte
+
+	// WebhookCAKey is the key holding the webhook CA. Defaults to
+	// DefaultWebhookCAKey.
+	WebhookCAKey string
+
+	// IncludeJWKS, if true, also publishes the trust bundle in SPIFFE
+	// bundle (JWKS) format, which holds the JWT authorities as well as the
+	// X.509 authorities, under JWKSKey.
+	IncludeJWKS bool
+
+	// JWKSKey is the key holding the JWKS. Defaults to DefaultJWKSKey.
+	JWKSKey string
+
+	// GCInterval how long to sit idle (i.e. untriggered) before doing
+	// another reconcile.
+	GCInterval time.Duration
+}
+
+func Reconciler(config ReconcilerConfig) reconciler.Reconciler {
+	return reconciler.New(reconciler.Config{
+		Kind: "bundle publisher",
+		Reconcile: func(ctx context.Context) error {
+			return Reconcile(ctx, config)
+		},
+		GCInterval: config.GCInterval,
+	})
+}
+
+func Reconcile(ctx context.Context, config ReconcilerConfig) error {
+	if config.Kind == "" {
+		config.Kind = KindConfigMap
+	}
+	if config.BundleKey == "" {
+		config.BundleKey = DefaultBundleKey
+	}
+	if config.WebhookCAKey == "" {
+		config.WebhookCAKey = DefaultWebhookCAKey
+	}
+	if config.JWKSKey == "" {
+		config.JWKSKey = DefaultJWKSKey
+	}
+	r := &bundlePublisher{
+		config: config,
+	}
+	return r.reconcile(ctx)
+}
+
+type bundlePublisher struct {
+	config ReconcilerConfig
+}
+
+func (r *bundlePublisher) reconcile(ctx context.Context) error {
+	log := log.FromContext(ctx)
+
+	bundle, err := r.config.BundleClient.GetBundle(ctx)
+	if err != nil {
+		log.Error(err, "Failed to get trust bundle")
+		return err
+	}
+
+	data := map[string][]byte{
+		r.config.BundleKey: marshalX509Authorities(bundle.X509Authorities()),
+	}
+	if r.config.IncludeJWKS {
+		jwks, err := bundle.Marshal()
+		if err != nil {
+			log.Error(err, "Failed to marshal trust bundle")
+			return err
+		}
+		data[r.config.JWKSKey] = jwks
+	}
+	if r.config.WebhookCABundle != nil {
+		webhookCA := r.config.WebhookCABundle()
+		if len(webhookCA) == 0 {
+			// The webhook manager has not fetched the CA yet. Publishing
+			// without it would remove the key from existing objects.
+			err := errors.New("webhook CA is not yet available")
+			log.Error(err, "Failed to publish trust bundle")
+			return err
+		}
+		data[r.config.WebhookCAKey] = webhookCA
+	}
+
+	namespaces, err := r.listNamespaces(ctx)
+	if err != nil {
+		log.Error(err, "Failed to list namespaces")
+		return err
+	}
+
+	var errs []error
+	for _, namespace := range namespaces {
+		if err := r.publish(ctx, namespace, data); err != nil {
+			log.Error(err, "Failed to publish trust bundle", kindLogKey, r.config.Kind, namespaceLogKey, namespace, nameLogKey, r.config.Name)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// listNamespaces returns the sorted, deduplicated names of the namespaces
+// to publish to.
+func (r *bundlePublisher) listNamespaces(ctx context.Context) ([]string, error) {
+	names := make(map[string]struct{})
+	for _, name := range r.config.Namespaces {
+		names[name] = struct{}{}
+	}
+	if r.config.NamespaceSelector != nil {
+		var namespaces corev1.NamespaceList
+		if err := r.config.K8sClient.List(ctx, &namespaces, client.MatchingLabelsSelector{Selector: r.config.NamespaceSelector}); err != nil {
+			return nil, err
+		}
+		for _, namespace := range namespaces.Items {
+			// Terminating namespaces reject new objects.
+			if namespace.Status.Phase == corev1.NamespaceTerminating {
+				continue
+			}
+			names[namespace.Name] = struct{}{}
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+func (r *bundlePublisher) publish(ctx context.Context, namespace string, data map[string][]byte) error {
+	log := log.FromContext(ctx).WithValues(kindLogKey, r.config.Kind, namespaceLogKey, namespace, nameLogKey, r.config.Name)
+
+	obj := r.newObject()
+	err := r.config.APIReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: r.config.Name}, obj)
+	switch {
+	case apierrors.IsNotFound(err):
+		obj = r.newObject()
+		obj.SetNamespace(namespace)
+		obj.SetName(r.config.Name)
+		obj.SetLabels(map[string]string{ManagedByLabel: ManagedByValue})
+		setData(obj, data)
+		if err := r.config.K8sClient.Create(ctx, obj); err != nil {
+			return err
+		}
+		log.Info("Published trust bundle")
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if obj.GetLabels()[ManagedByLabel] != ManagedByValue {
+		return fmt.Errorf("existing object is not managed by %s", ManagedByValue)
+	}
+	if !setData(obj, data) {
+		return nil
+	}
+	// Updating with the resource version that was read prevents clobbering
+	// concurrent changes. Conflicts are resolved on the next reconcile.
+	if err := r.config.K8sClient.Update(ctx, obj); err != nil {
+		return err
+	}
+	log.Info("Updated published trust bundle")
+	return nil
+}
+
+func (r *bundlePublisher) newObject() client.Object {
+	if r.config.Kind == KindSecret {
+		return &corev1.Secret{}
+	}
+	return &corev1.ConfigMap{}
+}
+
+// setData sets the published keys on the object, leaving other keys in
+// place. It returns true if the object was modified.
+func setData(obj client.Object, data map[string][]byte) bool {
+	modified := false
+	switch obj := obj.(type) {
+	case *corev1.ConfigMap:
+		if obj.Data == nil {
+			obj.Data = make(map[string]string, len(data))
+		}
+		for key, value := range data {
+			if current, ok := obj.Data[key]; !ok || current != string(value) {
+				obj.Data[key] = string(value)
+				modified = true
+			}
+		}
+	case *corev1.Secret:
+		if obj.Data == nil {
+			obj.Data = make(map[string][]byte, len(data))
+		}
+		for key, value := range data {
+			if current, ok := obj.Data[key]; !ok || !bytes.Equal(current, value) {
+				obj.Data[key] = value
+				modified = true
+			}
+		}
+	default:
+		panic(fmt.Sprintf("unexpected object type %T", obj))
+	}
+	return modified
+}
+
+func marshalX509Authorities(x509Authorities []*x509.Certificate) []byte {
+	buf := new(bytes.Buffer)
+	for _, x509Authority := range x509Authorities {
+		_ = pem.Encode(buf, &pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: x509Authority.Raw,
+		})
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,185 @@
+package bundlepublisher
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/spiffe/spire-controller-manager/pkg/test/k8stest"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	ctx = context.Background()
+	td  = spiffeid.RequireTrustDomainFromString("domain.test")
+)
+
+func TestReconcileConfigMap(t *testing.T) {
+	k8sClient := k8stest.WithScheme(t, fake.NewClientBuilder()).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "selected", Labels: map[string]string{"bundle": "yes"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unselected"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "listed"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Labels: map[string]string{"bundle": "yes"}}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "listed", Name: "spire-bundle", Labels: map[string]string{ManagedByLabel: ManagedByValue}}, Data: map[string]string{
+			"other": "value",
+		}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "unmanaged", Name: "spire-bundle"}, Data: map[string]string{
+			DefaultBundleKey: "unmanaged",
+		}},
+	).Build()
+
+	bundleClient := &bundleClient{bundle: newBundle(t)}
+	config := ReconcilerConfig{
+		BundleClient:      bundleClient,
+		K8sClient:         k8sClient,
+		APIReader:         k8sClient,
+		Name:              "spire-bundle",
+		NamespaceSelector: labels.SelectorFromSet(labels.Set{"bundle": "yes"}),
+		Namespaces:        []string{"listed"},
+	}
+
+	// The unmanaged ConfigMap fails the reconcile but does not prevent
+	// publishing to the other namespaces.
+	require.Error(t, Reconcile(ctx, config))
+	bundlePEM := string(marshalX509Authorities(bundleClient.bundle.X509Authorities()))
+	requireConfigMapData(t, k8sClient, "selected", map[string]string{DefaultBundleKey: bundlePEM})
+	requireConfigMapData(t, k8sClient, "listed", map[string]string{DefaultBundleKey: bundlePEM, "other": "value"})
+	requireConfigMapData(t, k8sClient, "unmanaged", map[string]string{DefaultBundleKey: "unmanaged"})
+	requireNotFound(t, k8sClient, &corev1.ConfigMap{}, "unselected")
+
+	configMap := new(corev1.ConfigMap)
+	require.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Namespace: "selected", Name: "spire-bundle"}, configMap))
+	require.Equal(t, ManagedByValue, configMap.Labels[ManagedByLabel])
+
+	// Objects are updated when the bundle rotates.
+	config.NamespaceSelector = nil
+	bundleClient.bundle = newBundle(t)
+	require.NoError(t, Reconcile(ctx, config))
+	rotatedPEM := string(marshalX509Authorities(bundleClient.bundle.X509Authorities()))
+	requireConfigMapData(t, k8sClient, "listed", map[string]string{DefaultBundleKey: rotatedPEM, "other": "value"})
+	requireConfigMapData(t, k8sClient, "selected", map[string]string{DefaultBundleKey: bundlePEM})
+}
+
+func TestReconcileSecretWithWebhookCA(t *testing.T) {
+	k8sClient := k8stest.WithScheme(t, fake.NewClientBuilder()).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "listed"}},
+	).Build()
+
+	var webhookCA []byte
+	bundleClient := &bundleClient{bundle: newBundle(t)}
+	config := ReconcilerConfig{
+		BundleClient:    bundleClient,
+		K8sClient:       k8sClient,
+		APIReader:       k8sClient,
+		Kind:            KindSecret,
+		Name:            "spire-bundle",
+		Namespaces:      []string{"listed"},
+		BundleKey:       "ca.crt",
+		WebhookCABundle: func() []byte { return webhookCA },
+	}
+
+	// Nothing is published until the webhook CA is available.
+	require.EqualError(t, Reconcile(ctx, config), "webhook CA is not yet available")
+	requireNotFound(t, k8sClient, &corev1.Secret{}, "listed")
+
+	webhookCA = []byte("webhook-ca")
+	require.NoError(t, Reconcile(ctx, config))
+	secret := new(corev1.Secret)
+	require.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Namespace: "listed", Name: "spire-bundle"}, secret))
+	require.Equal(t, map[string][]byte{
+		"ca.crt":            marshalX509Authorities(bundleClient.bundle.X509Authorities()),
+		DefaultWebhookCAKey: []byte("webhook-ca"),
+	}, secret.Data)
+}
+
+func TestReconcileJWKS(t *testing.T) {
+	k8sClient := k8stest.WithScheme(t, fake.NewClientBuilder()).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "listed"}},
+	).Build()
+
+	bundleClient := &bundleClient{bundle: newBundle(t)}
+	config := ReconcilerConfig{
+		BundleClient: bundleClient,
+		K8sClient:    k8sClient,
+		APIReader:    k8sClient,
+		Name:         "spire-bundle",
+		Namespaces:   []string{"listed"},
+		IncludeJWKS:  true,
+	}
+
+	require.NoError(t, Reconcile(ctx, config))
+	bundleJWKS, err := bundleClient.bundle.Marshal()
+	require.NoError(t, err)
+	requireConfigMapData(t, k8sClient, "listed", map[string]string{
+		DefaultBundleKey: string(marshalX509Authorities(bundleClient.bundle.X509Authorities())),
+		DefaultJWKSKey:   string(bundleJWKS),
+	})
+
+	// The published JWKS is a SPIFFE bundle for the trust domain.
+	configMap := new(corev1.ConfigMap)
+	require.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Namespace: "listed", Name: "spire-bundle"}, configMap))
+	published, err := spiffebundle.Parse(td, []byte(configMap.Data[DefaultJWKSKey]))
+	require.NoError(t, err)
+	require.True(t, published.Equal(bundleClient.bundle))
+}
+
+func requireConfigMapData(t *testing.T, k8sClient client.Client, namespace string, expected map[string]string) {
+	configMap := new(corev1.ConfigMap)
+	require.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "spire-bundle"}, configMap))
+	require.Equal(t, expected, configMap.Data, "unexpected data in %s", namespace)
+}
+
+func requireNotFound(t *testing.T, k8sClient client.Client, obj client.Object, namespace string) {
+	err := k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "spire-bundle"}, obj)
+	require.True(t, apierrors.IsNotFound(err), "expected not found in %s; got %v", namespace, err)
+}
+
+func newBundle(t *testing.T) *spiffebundle.Bundle {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+	return spiffebundle.FromX509Authorities(td, []*x509.Certificate{cert})
+}
+
+type bundleClient struct {
+	bundle *spiffebundle.Bundle
+}
+
+func (c *bundleClient) GetBundle(context.Context) (*spiffebundle.Bundle, error) {
+	return c.bundle, nil
+}
+
+func (c *bundleClient) GetFederatedBundle(context.Context, spiffeid.TrustDomain) (*spiffebundle.Bundle, error) {
+	return nil, errors.New("unexpected call to GetFederatedBundle")
+}
+
+func (c *bundleClient) ListFederatedBundles(context.Context) ([]*spiffebundle.Bundle, error) {
+	return nil, errors.New("unexpected call to ListFederatedBundles")
+}
+
+func (c *bundleClient) DeleteFederatedBundles(context.Context, []spiffeid.TrustDomain) ([]spireapi.Status, error) {
+	return nil, errors.New("unexpected call to DeleteFederatedBundles")
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package configreloader applies changes to the configuration file while the
+// controller is running.
+package configreloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/namespacefilter"
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type Config struct {
+	// Path is the path of the configuration file.
+	Path string
+
+	// Load loads and validates the configuration file.
+	Load func() (spirev1alpha1.ControllerManagerConfig, error)
+
+	// Config is the configuration the controller is running with.
+	Config spirev1alpha1.ControllerManagerConfig
+
+	// Interval is how often the configuration file is checked for changes.
+	Interval time.Duration
+
+	// GCReconcilers are the reconcilers whose GC interval is updated when
+	// gcInterval changes.
+	GCReconcilers []reconciler.Reconciler
+
+	// EntryGCReconcilers are the reconcilers whose GC interval is updated
+	// when the effective entry GC interval changes.
+	EntryGCReconcilers []reconciler.Reconciler
+
+	// FederationRelationshipGCReconcilers are the reconcilers whose GC
+	// interval is updated when the effective federation relationship GC
+	// interval changes.
+	FederationRelationshipGCReconcilers []reconciler.Reconciler
+
+	// EntryReconciler is triggered when the namespace filter changes.
+	EntryReconciler reconciler.Triggerer
+
+	// NamespaceFilter is replaced when the namespace filter changes.
+	NamespaceFilter *namespacefilter.Dynamic
+
+	// SetLogLevel, if set, applies the log level of the configuration. It is
+	// unset when the log level is set on the command line.
+	SetLogLevel func(level string)
+}
+
+// Reloader watches the configuration file and applies the changes that are
+// safe to apply while running, i.e. the GC intervals, the namespace filter,
+// and the log level. Other changes are logged and require a restart.
+//
+// The file is polled, rather than watched for filesystem events, since a
+// ConfigMap volume is updated by swapping symlinks.
+type Reloader struct {
+	config   Config
+	contents []byte
+}
+
+func New(config Config) (*Reloader, error) {
+	contents, err := os.ReadFile(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the config file: %w", err)
+	}
+	return &Reloader{
+		config:   config,
+		contents: contents,
+	}, nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// replica reloads its configuration.
+func (r *Reloader) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *Reloader) Start(ctx context.Context) error {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithName("config-reloader"))
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			r.reloadIfChanged(ctx)
+		}
+	}
+}
+
+func (r *Reloader) reloadIfChanged(ctx context.Context) {
+	log := log.FromContext(ctx)
+
+	contents, err := os.ReadFile(r.config.Path)
+	if err != nil {
+		log.Error(err, "Failed to read the config file")
+		return
+	}
+	if bytes.Equal(contents, r.contents) {
+		return
+	}
+	r.contents = contents
+
+	log.Info("Config file changed; reloading")
+	newConfig, err := r.config.Load()
+	if err != nil {
+		log.Error(err, "Rejected invalid configuration; the current configuration remains in effect")
+		metrics.ConfigReloads.WithLabelValues(metrics.ResultFailure).Inc()
+		return
+	}
+	metrics.ConfigReloads.WithLabelValues(metrics.ResultSuccess).Inc()
+	r.apply(ctx, newConfig)
+}
+
+func (r *Reloader) apply(ctx context.Context, newConfig spirev1alpha1.ControllerManagerConfig) {
+	log := log.FromContext(ctx)
+	current := &r.config.Config
+
+	if fields := restartRequiredFields(*current, newConfig); len(fields) > 0 {
+		log.Error(nil, "Configuration changes require a restart to take effect", "fields", fields)
+		metrics.ConfigRestartRequired.Set(1)
+	} else {
+		metrics.ConfigRestartRequired.Set(0)
+	}
+
+	if gcInterval := EntryGCInterval(newConfig); gcInterval != EntryGCInterval(*current) {
+		log.Info("Applying entry GC interval", "gc interval", gcInterval)
+		setGCInterval(r.config.EntryGCReconcilers, gcInterval)
+	}
+	if gcInterval := FederationRelationshipGCInterval(newConfig); gcInterval != FederationRelationshipGCInterval(*current) {
+		log.Info("Applying federation relationship GC interval", "gc interval", gcInterval)
+		setGCInterval(r.config.FederationRelationshipGCReconcilers, gcInterval)
+	}
+	if newConfig.GCInterval != current.GCInterval {
+		log.Info("Applying GC interval", "gc interval", newConfig.GCInterval)
+		setGCInterval(r.config.GCReconcilers, newConfig.GCInterval)
+	}
+	current.GCInterval = newConfig.GCInterval
+	current.EntryGCInterval = newConfig.EntryGCInterval
+	current.FederationRelationshipGCInterval = newConfig.FederationRelationshipGCInterval
+
+	if !reflect.DeepEqual(newConfig.IgnoreNamespaces, current.IgnoreNamespaces) ||
+		!reflect.DeepEqual(newConfig.NamespaceSelector, current.NamespaceSelector) {
+		// The namespace filter was validated when the configuration was
+		// loaded.
+		namespaceFilter, _ := namespacefilter.New(newConfig.IgnoreNamespaces, newConfig.NamespaceSelector)
+		log.Info("Applying namespace filter",
+			"ignore namespaces", newConfig.IgnoreNamespaces,
+			"namespace selector", namespaceFilter.Selector)
+		r.config.NamespaceFilter.Store(namespaceFilter)
+		r.config.EntryReconciler.Trigger()
+		current.IgnoreNamespaces = newConfig.IgnoreNamespaces
+		current.NamespaceSelector = newConfig.NamespaceSelector
+	}
+
+	if newConfig.LogLevel != current.LogLevel {
+		if r.config.SetLogLevel == nil {
+			log.Info("Ignoring log level change; the log level is set on the command line")
+		} else {
+			log.Info("Applying log level", "log level", newConfig.LogLevel)
+			r.config.SetLogLevel(newConfig.LogLevel)
+		}
+		current.LogLevel = newConfig.LogLevel
+	}
+}
+
+func setGCInterval(reconcilers []reconciler.Reconciler, gcInterval time.Duration) {
+	for _, gcReconciler := range reconcilers {
+		gcReconciler.SetGCInterval(gcInterval)
+		gcReconciler.Trigger()
+	}
+}
+
+// EntryGCInterval returns how often the entry reconcilers run when otherwise
+// idle.
+func EntryGCInterval(config spirev1alpha1.ControllerManagerConfig) time.Duration {
+	if config.EntryGCInterval.Duration != 0 {
+		return config.EntryGCInterval.Duration
+	}
+	return config.GCInterval
+}
+
+// FederationRelationshipGCInterval returns how often the federation
+// relationship reconcilers run when otherwise idle.
+func FederationRelationshipGCInterval(config spirev1alpha1.ControllerManagerConfig) time.Duration {
+	if config.FederationRelationshipGCInterval.Duration != 0 {
+		return config.FederationRelationshipGCInterval.Duration
+	}
+	return config.GCInterval
+}
+
+// restartRequiredFields returns the names of the configuration fields that
+// changed and cannot be applied while running.
+func restartRequiredFields(current, next spirev1alpha1.ControllerManagerConfig) []string {
+	for _, config := range []*spirev1alpha1.ControllerManagerConfig{&current, &next} {
+		config.GCInterval = 0
+		config.EntryGCInterval = metav1.Duration{}
+		config.FederationRelationshipGCInterval = metav1.Duration{}
+		config.IgnoreNamespaces = nil
+		config.NamespaceSelector = nil
+		config.LogLevel = ""
+	}
+
+	var fields []string
+	currentValue := reflect.ValueOf(current)
+	nextValue := reflect.ValueOf(next)
+	for i := 0; i < currentValue.NumField(); i++ {
+		if reflect.DeepEqual(currentValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			continue
+		}
+		field := currentValue.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
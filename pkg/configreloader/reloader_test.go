@@ -0,0 +1,206 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configreloader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/namespacefilter"
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestReloader(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	// The file contents only signal a change; Load returns the configuration
+	// set by the test.
+	var (
+		next    spirev1alpha1.ControllerManagerConfig
+		loadErr error
+		writes  int
+	)
+	writeConfig := func(config spirev1alpha1.ControllerManagerConfig, err error) {
+		next, loadErr = config, err
+		writes++
+		require.NoError(t, os.WriteFile(path, []byte{byte(writes)}, 0600))
+	}
+
+	config := spirev1alpha1.ControllerManagerConfig{
+		ClusterName:   "cluster",
+		ClusterDomain: "cluster.local",
+		TrustDomain:   "example.org",
+		GCInterval:    10 * time.Second,
+	}
+	writeConfig(config, nil)
+
+	var logLevel string
+	gcReconciler := new(fakeReconciler)
+	entryGCReconciler := new(fakeReconciler)
+	federationRelationshipGCReconciler := new(fakeReconciler)
+	entryReconciler := new(fakeReconciler)
+	namespaceFilter := namespacefilter.NewDynamic(namespacefilter.Filter{})
+	reloader, err := New(Config{
+		Path: path,
+		Load: func() (spirev1alpha1.ControllerManagerConfig, error) {
+			return next, loadErr
+		},
+		Config:          config,
+		Interval:        time.Second,
+		GCReconcilers:   []reconciler.Reconciler{gcReconciler},
+		EntryReconciler: entryReconciler,
+		NamespaceFilter: namespaceFilter,
+		SetLogLevel: func(level string) {
+			logLevel = level
+		},
+
+		EntryGCReconcilers:                  []reconciler.Reconciler{entryGCReconciler},
+		FederationRelationshipGCReconcilers: []reconciler.Reconciler{federationRelationshipGCReconciler},
+	})
+	require.NoError(t, err)
+
+	t.Run("unchanged", func(t *testing.T) {
+		reloader.reloadIfChanged(ctx)
+		require.Zero(t, gcReconciler.triggers)
+		require.Zero(t, entryReconciler.triggers)
+	})
+
+	reloadable := config
+	reloadable.GCInterval = time.Minute
+	reloadable.IgnoreNamespaces = []string{"ignored"}
+	reloadable.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"spire.spiffe.io/enabled": "true"}}
+	reloadable.LogLevel = "info"
+
+	t.Run("reloadable changes are applied", func(t *testing.T) {
+		writeConfig(reloadable, nil)
+		reloader.reloadIfChanged(ctx)
+		require.Equal(t, time.Minute, gcReconciler.gcInterval)
+		require.Equal(t, 1, gcReconciler.triggers)
+		require.Equal(t, time.Minute, entryGCReconciler.gcInterval)
+		require.Equal(t, time.Minute, federationRelationshipGCReconciler.gcInterval)
+		require.Equal(t, 1, entryReconciler.triggers)
+		filter := namespaceFilter.Load()
+		require.Equal(t, []string{"ignored"}, []string(filter.IgnoreNamespaces))
+		require.True(t, filter.Selector.Matches(labels.Set{"spire.spiffe.io/enabled": "true"}))
+		require.Equal(t, "info", logLevel)
+		require.Equal(t, float64(0), testutil.ToFloat64(metrics.ConfigRestartRequired))
+	})
+
+	t.Run("per-reconciler GC intervals override the GC interval", func(t *testing.T) {
+		perReconciler := reloadable
+		perReconciler.EntryGCInterval = metav1.Duration{Duration: 10 * time.Second}
+		writeConfig(perReconciler, nil)
+		reloader.reloadIfChanged(ctx)
+		require.Equal(t, 10*time.Second, entryGCReconciler.gcInterval)
+		require.Equal(t, 2, entryGCReconciler.triggers)
+		require.Equal(t, time.Minute, federationRelationshipGCReconciler.gcInterval)
+		require.Equal(t, 1, federationRelationshipGCReconciler.triggers)
+		require.Equal(t, 1, gcReconciler.triggers)
+	})
+
+	t.Run("invalid configuration is rejected", func(t *testing.T) {
+		invalid := reloadable
+		invalid.LogLevel = "loud"
+		writeConfig(invalid, errors.New("invalid log level"))
+		reloader.reloadIfChanged(ctx)
+		require.Equal(t, float64(1), testutil.ToFloat64(metrics.ConfigReloads.WithLabelValues(metrics.ResultFailure)))
+		require.Equal(t, 1, entryReconciler.triggers)
+		require.Equal(t, "info", logLevel)
+	})
+
+	t.Run("changes requiring a restart are not applied", func(t *testing.T) {
+		restart := reloadable
+		restart.DryRun = true
+		writeConfig(restart, nil)
+		reloader.reloadIfChanged(ctx)
+		require.Equal(t, float64(1), testutil.ToFloat64(metrics.ConfigRestartRequired))
+		require.False(t, reloader.config.Config.DryRun)
+	})
+
+	t.Run("log level is ignored when set on the command line", func(t *testing.T) {
+		reloader.config.SetLogLevel = nil
+		debug := reloadable
+		debug.LogLevel = "debug"
+		writeConfig(debug, nil)
+		reloader.reloadIfChanged(ctx)
+		require.Equal(t, "info", logLevel)
+	})
+}
+
+func TestRestartRequiredFields(t *testing.T) {
+	current := spirev1alpha1.ControllerManagerConfig{
+		ClusterName:   "cluster",
+		ClusterDomain: "cluster.local",
+		TrustDomain:   "example.org",
+		GCInterval:    10 * time.Second,
+	}
+
+	next := current
+	next.GCInterval = time.Minute
+	next.EntryGCInterval = metav1.Duration{Duration: time.Second}
+	next.FederationRelationshipGCInterval = metav1.Duration{Duration: time.Hour}
+	next.IgnoreNamespaces = []string{"other"}
+	next.LogLevel = "error"
+	require.Empty(t, restartRequiredFields(current, next))
+
+	next.ClusterName = "other"
+	next.DryRun = true
+	require.Equal(t, []string{"clusterName", "dryRun"}, restartRequiredFields(current, next))
+}
+
+func TestGCIntervals(t *testing.T) {
+	config := spirev1alpha1.ControllerManagerConfig{GCInterval: time.Minute}
+	require.Equal(t, time.Minute, EntryGCInterval(config))
+	require.Equal(t, time.Minute, FederationRelationshipGCInterval(config))
+
+	config.EntryGCInterval = metav1.Duration{Duration: time.Second}
+	config.FederationRelationshipGCInterval = metav1.Duration{Duration: time.Hour}
+	require.Equal(t, time.Second, EntryGCInterval(config))
+	require.Equal(t, time.Hour, FederationRelationshipGCInterval(config))
+}
+
+type fakeReconciler struct {
+	gcInterval time.Duration
+	triggers   int
+}
+
+func (r *fakeReconciler) Trigger() {
+	r.triggers++
+}
+
+func (r *fakeReconciler) Run(context.Context) error {
+	return nil
+}
+
+func (r *fakeReconciler) Synced() bool {
+	return false
+}
+
+func (r *fakeReconciler) SetGCInterval(gcInterval time.Duration) {
+	r.gcInterval = gcInterval
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crdwatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const defaultPollInterval = 10 * time.Second
+
+// Registration is set up once the custom resource definition for its kind
+// is served by the API server.
+type Registration struct {
+	// GroupVersionKind is the kind that must be available.
+	GroupVersionKind schema.GroupVersionKind
+
+	// Setup is called once the kind is available, e.g. to register a
+	// controller with the manager. If it fails, it is retried on the next
+	// poll.
+	Setup func() error
+}
+
+type Config struct {
+	// Discovery is used to determine which kinds are served.
+	Discovery discovery.DiscoveryInterface
+
+	// PollInterval is how often the API server is polled for kinds that are
+	// not yet available. Defaults to 10s.
+	PollInterval time.Duration
+
+	// Registrations are the registrations to set up.
+	Registrations []Registration
+
+	// Clock is used to wait between polls. Defaults to the real clock.
+	Clock clock.WithTicker
+}
+
+// Watcher sets up registrations as the custom resource definitions for their
+// kinds become available, so that the controller can run, and keep serving
+// webhooks, while CRDs are still being installed. It runs on every replica so
+// that each one can report which kinds are pending; the controllers set up by
+// the registrations still only run on the leader.
+type Watcher struct {
+	config Config
+
+	mtx     sync.Mutex
+	pending []Registration
+
+	// notServed holds the pending kinds that the last poll found are not
+	// served, as opposed to those that could not be discovered.
+	notServed map[schema.GroupVersionKind]bool
+}
+
+func New(config Config) (*Watcher, error) {
+	if config.Discovery == nil {
+		return nil, errors.New("discovery client is required")
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	if config.Clock == nil {
+		config.Clock = clock.RealClock{}
+	}
+	return &Watcher{
+		config:    config,
+		pending:   append([]Registration(nil), config.Registrations...),
+		notServed: make(map[schema.GroupVersionKind]bool),
+	}, nil
+}
+
+// SetupAvailable sets up the registrations for the kinds that are currently
+// available. It returns the number of registrations still pending.
+func (w *Watcher) SetupAvailable(ctx context.Context) (int, error) {
+	log := log.FromContext(ctx)
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	var pending []Registration
+	var errs []error
+	for _, registration := range w.pending {
+		gvk := registration.GroupVersionKind
+		available, err := w.isAvailable(gvk)
+		w.notServed[gvk] = err == nil && !available
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed to discover %s: %w", gvk, err))
+			pending = append(pending, registration)
+			continue
+		case !available:
+			pending = append(pending, registration)
+			continue
+		}
+		if err := registration.Setup(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to set up %s: %w", gvk, err))
+			pending = append(pending, registration)
+			continue
+		}
+		log.Info("Custom resource definition is available", "kind", gvk.String())
+	}
+	w.pending = pending
+	return len(w.pending), errors.Join(errs...)
+}
+
+// Pending returns the kinds whose registrations are not yet set up.
+func (w *Watcher) Pending() []schema.GroupVersionKind {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	kinds := make([]schema.GroupVersionKind, 0, len(w.pending))
+	for _, registration := range w.pending {
+		kinds = append(kinds, registration.GroupVersionKind)
+	}
+	return kinds
+}
+
+// NotServed returns whether the kind is registered and the last poll found
+// that the API server does not serve it. Kinds that are set up, or that could
+// not be discovered, are not reported as not served.
+func (w *Watcher) NotServed(gvk schema.GroupVersionKind) bool {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	return w.notServed[gvk]
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+// Start polls for the kinds that are not yet available and sets up their
+// registrations. It returns once all registrations are set up or the context
+// is canceled.
+func (w *Watcher) Start(ctx context.Context) error {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithName("crd-watcher"))
+	log := log.FromContext(ctx)
+
+	ticker := w.config.Clock.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		pending, err := w.SetupAvailable(ctx)
+		if err != nil {
+			log.Error(err, "Failed to set up registrations")
+		}
+		if pending == 0 {
+			return nil
+		}
+		log.V(1).Info("Waiting for custom resource definitions", "pending", pending)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C():
+		}
+	}
+}
+
+func (w *Watcher) isAvailable(gvk schema.GroupVersionKind) (bool, error) {
+	resources, err := w.config.Discovery.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+	switch {
+	case apierrors.IsNotFound(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Kind == gvk.Kind {
+			return true, nil
+		}
+	}
+	return false, nil
+}
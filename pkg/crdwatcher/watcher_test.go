@@ -0,0 +1,211 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crdwatcher_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/crdwatcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	testclock "k8s.io/utils/clock/testing"
+)
+
+var (
+	fooGVK = schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Foo"}
+	barGVK = schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Bar"}
+)
+
+func TestWatcher(t *testing.T) {
+	clock := testclock.NewFakeClock(time.Now())
+	discoveryClient := &fakeDiscovery{}
+	discoveryClient.setKinds(fooGVK)
+
+	var fooSetup, barSetup setupCounter
+	barSetup.err = errors.New("oh no")
+
+	w, err := crdwatcher.New(crdwatcher.Config{
+		Discovery:    discoveryClient,
+		PollInterval: time.Second,
+		Clock:        clock,
+		Registrations: []crdwatcher.Registration{
+			{GroupVersionKind: fooGVK, Setup: fooSetup.setup},
+			{GroupVersionKind: barGVK, Setup: barSetup.setup},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Log("Set up the kinds available at startup")
+	pending, err := w.SetupAvailable(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+	assert.Equal(t, []schema.GroupVersionKind{barGVK}, w.Pending())
+	assert.Equal(t, 1, fooSetup.count())
+	assert.Equal(t, 0, barSetup.count())
+	assert.False(t, w.NotServed(fooGVK))
+	assert.True(t, w.NotServed(barGVK))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- w.Start(ctx)
+	}()
+
+	t.Log("Wait until the watcher is polling")
+	require.Eventually(t, clock.HasWaiters, time.Minute, time.Millisecond*10)
+
+	t.Log("Install the CRD for the pending kind, which fails to set up")
+	discoveryClient.setKinds(fooGVK, barGVK)
+	clock.Step(time.Second)
+	require.Eventually(t, func() bool { return barSetup.count() == 1 }, time.Minute, time.Millisecond*10)
+
+	t.Log("Retry the failed set up on the next poll")
+	barSetup.setErr(nil)
+	require.Eventually(t, clock.HasWaiters, time.Minute, time.Millisecond*10)
+	clock.Step(time.Second)
+
+	t.Log("Wait until the watcher returns")
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(time.Minute):
+		require.FailNow(t, "timed out waiting for the watcher to return")
+	}
+	assert.Equal(t, 1, fooSetup.count())
+	assert.Equal(t, 2, barSetup.count())
+	assert.Empty(t, w.Pending())
+	assert.False(t, w.NotServed(barGVK))
+}
+
+func TestWatcherNotServedUnknownOnDiscoveryFailure(t *testing.T) {
+	var setup setupCounter
+	discoveryClient := &fakeDiscovery{}
+	w, err := crdwatcher.New(crdwatcher.Config{
+		Discovery: discoveryClient,
+		Clock:     testclock.NewFakeClock(time.Now()),
+		Registrations: []crdwatcher.Registration{
+			{GroupVersionKind: fooGVK, Setup: setup.setup},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = w.SetupAvailable(context.Background())
+	require.NoError(t, err)
+	assert.True(t, w.NotServed(fooGVK))
+
+	// A kind that could not be discovered may be served, so it is no longer
+	// reported as not served.
+	discoveryClient.setErr(errors.New("oh no"))
+	_, err = w.SetupAvailable(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, []schema.GroupVersionKind{fooGVK}, w.Pending())
+	assert.False(t, w.NotServed(fooGVK))
+
+	// Kinds that are not registered are not reported as not served.
+	assert.False(t, w.NotServed(barGVK))
+}
+
+func TestWatcherReturnsOnCancel(t *testing.T) {
+	var setup setupCounter
+	w, err := crdwatcher.New(crdwatcher.Config{
+		Discovery: &fakeDiscovery{},
+		Clock:     testclock.NewFakeClock(time.Now()),
+		Registrations: []crdwatcher.Registration{
+			{GroupVersionKind: fooGVK, Setup: setup.setup},
+		},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, w.Start(ctx))
+	assert.Equal(t, 0, setup.count())
+}
+
+type setupCounter struct {
+	mtx   sync.Mutex
+	calls int
+	err   error
+}
+
+func (s *setupCounter) setup() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.calls++
+	return s.err
+}
+
+func (s *setupCounter) setErr(err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.err = err
+}
+
+func (s *setupCounter) count() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.calls
+}
+
+type fakeDiscovery struct {
+	discovery.DiscoveryInterface
+
+	mtx   sync.Mutex
+	kinds []schema.GroupVersionKind
+	err   error
+}
+
+func (d *fakeDiscovery) setKinds(kinds ...schema.GroupVersionKind) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.kinds = kinds
+}
+
+func (d *fakeDiscovery) setErr(err error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.err = err
+}
+
+func (d *fakeDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	list := &metav1.APIResourceList{GroupVersion: groupVersion}
+	for _, kind := range d.kinds {
+		if kind.GroupVersion().String() == groupVersion {
+			list.APIResources = append(list.APIResources, metav1.APIResource{Kind: kind.Kind})
+		}
+	}
+	if len(list.APIResources) == 0 {
+		return nil, apierrors.NewNotFound(schema.GroupResource{}, groupVersion)
+	}
+	return list, nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debugserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Config configures the debug server.
+type Config struct {
+	// BindAddress is the address the debug server listens on.
+	BindAddress string
+
+	// CertFile, KeyFile and ClientCAFile, if set, serve the endpoints over
+	// TLS and require clients to present a certificate signed by one of the
+	// CAs in ClientCAFile. Either all or none must be set.
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+
+	// Handlers, if set, are served alongside the debug endpoints, keyed by
+	// path. They require TLS or a loopback bind address.
+	Handlers map[string]http.Handler
+}
+
+// Server serves the pprof profiles under /debug/pprof/, the expvar variables
+// under /debug/vars, and any additional handlers. Every replica serves its
+// own profiles, so it runs regardless of leader election.
+type Server struct {
+	config Config
+}
+
+// New returns a debug server for the configuration.
+func New(config Config) (*Server, error) {
+	if config.BindAddress == "" {
+		return nil, errors.New("bind address is required")
+	}
+	tlsFiles := 0
+	for _, file := range []string{config.CertFile, config.KeyFile, config.ClientCAFile} {
+		if file != "" {
+			tlsFiles++
+		}
+	}
+	if tlsFiles != 0 && tlsFiles != 3 {
+		return nil, errors.New("TLS requires a certificate, key, and client CA file")
+	}
+	if len(config.Handlers) > 0 && tlsFiles == 0 && !IsLoopback(config.BindAddress) {
+		return nil, errors.New("additional handlers require TLS or a loopback bind address")
+	}
+	return &Server{config: config}, nil
+}
+
+// IsLoopback returns true if the bind address only accepts connections from
+// the local host.
+func IsLoopback(bindAddress string) bool {
+	host, _, err := net.SplitHostPort(bindAddress)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves the debug endpoints until the context is canceled.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.config.BindAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen for debug server: %w", err)
+	}
+	return s.serve(ctx, listener)
+}
+
+func (s *Server) serve(ctx context.Context, listener net.Listener) error {
+	log := log.FromContext(ctx).WithName("debug-server")
+
+	if s.config.CertFile != "" {
+		listener = tls.NewListener(listener, &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			GetConfigForClient: s.getConfigForClient,
+		})
+	}
+
+	server := &http.Server{
+		Handler:           newHandler(s.config.Handlers),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+	log.Info("Serving debug endpoints", "address", listener.Addr().String(), "tls", s.config.CertFile != "")
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("debug server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down debug server: %w", err)
+	}
+	return nil
+}
+
+// getConfigForClient loads the certificate, key and client CAs for each
+// connection, so that rotated files are picked up without a restart. The
+// debug endpoints are rarely used, so the cost is negligible.
+func (s *Server) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(s.config.CertFile, s.config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load debug server certificate: %w", err)
+	}
+	clientCAs, err := os.ReadFile(s.config.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load debug server client CAs: %w", err)
+	}
+	clientCAPool := x509.NewCertPool()
+	if !clientCAPool.AppendCertsFromPEM(clientCAs) {
+		return nil, errors.New("failed to load debug server client CAs: no certificates found")
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAPool,
+	}, nil
+}
+
+func newHandler(handlers map[string]http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	for path, handler := range handlers {
+		mux.Handle(path, handler)
+	}
+	return mux
+}
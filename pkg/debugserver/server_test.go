@@ -0,0 +1,189 @@
+package debugserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{})
+	require.EqualError(t, err, "bind address is required")
+
+	_, err = New(Config{BindAddress: ":6060", CertFile: "tls.crt", KeyFile: "tls.key"})
+	require.EqualError(t, err, "TLS requires a certificate, key, and client CA file")
+
+	_, err = New(Config{BindAddress: ":6060"})
+	require.NoError(t, err)
+
+	_, err = New(Config{BindAddress: ":6060", CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt"})
+	require.NoError(t, err)
+
+	handlers := map[string]http.Handler{"/debug/explain": http.NotFoundHandler()}
+	_, err = New(Config{BindAddress: ":6060", Handlers: handlers})
+	require.EqualError(t, err, "additional handlers require TLS or a loopback bind address")
+
+	_, err = New(Config{BindAddress: "127.0.0.1:6060", Handlers: handlers})
+	require.NoError(t, err)
+
+	_, err = New(Config{BindAddress: ":6060", CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt", Handlers: handlers})
+	require.NoError(t, err)
+}
+
+func TestIsLoopback(t *testing.T) {
+	require.True(t, IsLoopback("127.0.0.1:6060"))
+	require.True(t, IsLoopback("[::1]:6060"))
+	require.True(t, IsLoopback("localhost:6060"))
+	require.False(t, IsLoopback(":6060"))
+	require.False(t, IsLoopback("0.0.0.0:6060"))
+	require.False(t, IsLoopback("10.0.0.1:6060"))
+	require.False(t, IsLoopback("127.0.0.1"))
+}
+
+func TestServe(t *testing.T) {
+	s, err := New(Config{
+		BindAddress: "127.0.0.1:0",
+		Handlers: map[string]http.Handler{
+			"/debug/explain": http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}),
+		},
+	})
+	require.NoError(t, err)
+
+	addr := startServer(t, s)
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	requireStatusOK(t, client, "http://"+addr+"/debug/explain")
+	requireStatusOK(t, client, "http://"+addr+"/debug/pprof/")
+	requireStatusOK(t, client, "http://"+addr+"/debug/pprof/goroutine?debug=1")
+	requireStatusOK(t, client, "http://"+addr+"/debug/pprof/heap")
+	requireStatusOK(t, client, "http://"+addr+"/debug/vars")
+
+	resp, err := client.Get("http://" + addr + "/metrics")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServeMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := createCertificate(t, nil, nil, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+	serverCert, serverKey := createCertificate(t, ca, caKey, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	clientCert, clientKey := createCertificate(t, ca, caKey, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	config := Config{
+		BindAddress:  "127.0.0.1:0",
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+	}
+	writeCertificate(t, config.CertFile, serverCert)
+	writeKey(t, config.KeyFile, serverKey)
+	writeCertificate(t, config.ClientCAFile, ca)
+
+	s, err := New(config)
+	require.NoError(t, err)
+	addr := startServer(t, s)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	newClient := func(certificates ...tls.Certificate) *http.Client {
+		return &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				RootCAs:      roots,
+				Certificates: certificates,
+			}},
+		}
+	}
+
+	requireStatusOK(t, newClient(tls.Certificate{
+		Certificate: [][]byte{clientCert.Raw},
+		PrivateKey:  clientKey,
+	}), "https://"+addr+"/debug/vars")
+
+	// Clients without a certificate are refused.
+	_, err = newClient().Get("https://" + addr + "/debug/vars")
+	require.Error(t, err)
+}
+
+func startServer(t *testing.T, s *Server) string {
+	listener, err := net.Listen("tcp", s.config.BindAddress)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.serve(ctx, listener)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-errCh)
+	})
+	return listener.Addr().String()
+}
+
+func requireStatusOK(t *testing.T, client *http.Client, url string) {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status for %s", url)
+}
+
+func createCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, tmpl *x509.Certificate) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl.SerialNumber = big.NewInt(1)
+	tmpl.NotBefore = time.Now().Add(-time.Minute)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writeCertificate(t *testing.T, path string, cert *x509.Certificate) {
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0600))
+}
+
+func writeKey(t *testing.T, path string, key *ecdsa.PrivateKey) {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entryhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	defaultTimeout   = 10 * time.Second
+	defaultQueueSize = 100
+)
+
+// EventType is the type of an entry lifecycle event.
+type EventType string
+
+const (
+	// EntryCreated is sent after an entry has been created on the SPIRE
+	// server.
+	EntryCreated EventType = "EntryCreated"
+
+	// EntryDeleted is sent after an entry has been deleted from the SPIRE
+	// server.
+	EntryDeleted EventType = "EntryDeleted"
+)
+
+// Event describes a change to a SPIRE server entry made by the controller.
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Entry is the entry that was created or deleted.
+	Entry Entry `json:"entry"`
+
+	// Owner is the custom resource that declared the entry. It is not set
+	// for deleted entries since they are no longer declared.
+	Owner *ObjectReference `json:"owner,omitempty"`
+
+	// Workload is the pod the entry was rendered for. It is only set for
+	// entries created for pods.
+	Workload *Workload `json:"workload,omitempty"`
+}
+
+// Entry describes a SPIRE server entry. The ID is not known for created
+// entries.
+type Entry struct {
+	ID            string   `json:"id,omitempty"`
+	SPIFFEID      string   `json:"spiffeID"`
+	ParentID      string   `json:"parentID"`
+	Selectors     []string `json:"selectors"`
+	X509SVIDTTL   string   `json:"x509SVIDTTL,omitempty"`
+	JWTSVIDTTL    string   `json:"jwtSVIDTTL,omitempty"`
+	FederatesWith []string `json:"federatesWith,omitempty"`
+	DNSNames      []string `json:"dnsNames,omitempty"`
+	Admin         bool     `json:"admin,omitempty"`
+	Downstream    bool     `json:"downstream,omitempty"`
+	Hint          string   `json:"hint,omitempty"`
+	StoreSVID     bool     `json:"storeSVID,omitempty"`
+}
+
+// ObjectReference references a Kubernetes object.
+type ObjectReference struct {
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid"`
+}
+
+// Workload describes the pod an entry was rendered for.
+type Workload struct {
+	Namespace          string            `json:"namespace"`
+	Name               string            `json:"name"`
+	UID                types.UID         `json:"uid"`
+	NodeName           string            `json:"nodeName,omitempty"`
+	ServiceAccountName string            `json:"serviceAccountName,omitempty"`
+	Labels             map[string]string `json:"labels,omitempty"`
+}
+
+// Notifier is notified of entry lifecycle events.
+type Notifier interface {
+	// Notify queues the events for delivery. It must not block.
+	Notify(ctx context.Context, events []Event)
+}
+
+type Config struct {
+	// URL, if set, is the endpoint the events are POSTed to as a JSON
+	// array.
+	URL string
+
+	// Command, if set, is executed with the events written to its standard
+	// input as a JSON array.
+	Command []string
+
+	// Timeout bounds each delivery. Defaults to 10s.
+	Timeout time.Duration
+
+	// QueueSize is the number of batches of events that can be queued for
+	// delivery before new events are dropped. Defaults to 100.
+	QueueSize int
+
+	// HTTPClient is used to deliver events to the URL. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Hook delivers entry lifecycle events to an HTTP endpoint or a command.
+// Events are delivered asynchronously, in order, so that slow or failing
+// receivers do not hold up reconciliation. Events that cannot be delivered
+// are logged and dropped.
+type Hook struct {
+	config  Config
+	queueCh chan []Event
+}
+
+var _ Notifier = (*Hook)(nil)
+
+func New(config Config) (*Hook, error) {
+	switch {
+	case config.URL == "" && len(config.Command) == 0:
+		return nil, errors.New("either a URL or a command is required")
+	case config.URL != "" && len(config.Command) > 0:
+		return nil, errors.New("a URL and a command are mutually exclusive")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &Hook{
+		config:  config,
+		queueCh: make(chan []Event, config.QueueSize),
+	}, nil
+}
+
+// Notify queues the events for delivery. If the queue is full, the events
+// are dropped.
+func (h *Hook) Notify(ctx context.Context, events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	select {
+	case h.queueCh <- events:
+	default:
+		log.FromContext(ctx).Error(nil, "Entry lifecycle hook queue is full; dropping events", "count", len(events))
+	}
+}
+
+// Run delivers queued events until the context is canceled.
+func (h *Hook) Run(ctx context.Context) error {
+	log := log.FromContext(ctx).WithName("entry-lifecycle-hook")
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case events := <-h.queueCh:
+			if err := h.deliver(ctx, events); err != nil {
+				log.Error(err, "Failed to deliver entry lifecycle events", "count", len(events))
+			}
+		}
+	}
+}
+
+func (h *Hook) deliver(ctx context.Context, events []Event) error {
+	payload, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal events: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
+	defer cancel()
+
+	if h.config.URL != "" {
+		return h.post(ctx, payload)
+	}
+	return h.exec(ctx, payload)
+}
+
+func (h *Hook) post(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (h *Hook) exec(ctx context.Context, payload []byte) error {
+	cmd := exec.CommandContext(ctx, h.config.Command[0], h.config.Command[1:]...) // nolint: gosec // the command is operator configuration
+	cmd.Stdin = bytes.NewReader(payload)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("command failed: %w: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
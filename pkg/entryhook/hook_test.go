@@ -0,0 +1,86 @@
+package entryhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testEvents = []Event{
+	{
+		Type: EntryCreated,
+		Time: time.Unix(1, 0).UTC(),
+		Entry: Entry{
+			SPIFFEID:  "spiffe://domain.test/workload",
+			ParentID:  "spiffe://domain.test/node",
+			Selectors: []string{"k8s:pod-uid:uid"},
+		},
+		Owner:    &ObjectReference{Kind: "ClusterSPIFFEID", Name: "csid", UID: "csiduid"},
+		Workload: &Workload{Namespace: "ns", Name: "pod", UID: "uid"},
+	},
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{})
+	require.EqualError(t, err, "either a URL or a command is required")
+
+	_, err = New(Config{URL: "http://localhost", Command: []string{"true"}})
+	require.EqualError(t, err, "a URL and a command are mutually exclusive")
+}
+
+func TestHookURL(t *testing.T) {
+	receivedCh := make(chan []Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []Event
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		receivedCh <- events
+	}))
+	defer server.Close()
+
+	hook, err := New(Config{URL: server.URL})
+	require.NoError(t, err)
+	require.NoError(t, hook.deliver(context.Background(), testEvents))
+	require.Equal(t, testEvents, <-receivedCh)
+}
+
+func TestHookURLFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	hook, err := New(Config{URL: server.URL})
+	require.NoError(t, err)
+	require.EqualError(t, hook.deliver(context.Background(), testEvents), "unexpected status code 500")
+}
+
+func TestHookCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "events.json")
+	hook, err := New(Config{Command: []string{"sh", "-c", `cat > "$0"`, out}})
+	require.NoError(t, err)
+	require.NoError(t, hook.deliver(context.Background(), testEvents))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var events []Event
+	require.NoError(t, json.Unmarshal(data, &events))
+	require.Equal(t, testEvents, events)
+}
+
+func TestHookNotifyDropsWhenQueueIsFull(t *testing.T) {
+	hook, err := New(Config{URL: "http://localhost", QueueSize: 1})
+	require.NoError(t, err)
+	hook.Notify(context.Background(), testEvents)
+	hook.Notify(context.Background(), testEvents)
+	require.Len(t, hook.queueCh, 1)
+}
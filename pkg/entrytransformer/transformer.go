@@ -0,0 +1,164 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package entrytransformer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/entryhook"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Request is an entry to be transformed, along with the objects it was
+// rendered from. Entries are described in the same format as entry lifecycle
+// events.
+type Request struct {
+	// Entry is the rendered entry.
+	Entry entryhook.Entry `json:"entry"`
+
+	// Owner is the custom resource that declared the entry.
+	Owner *entryhook.ObjectReference `json:"owner,omitempty"`
+
+	// Workload is the pod the entry was rendered for. It is only set for
+	// entries rendered for pods.
+	Workload *entryhook.Workload `json:"workload,omitempty"`
+}
+
+// Transformer adjusts rendered entries before they are applied to the SPIRE
+// server.
+type Transformer interface {
+	// Transform returns the transformed entries, one for each request, in
+	// the same order as the requests.
+	Transform(ctx context.Context, requests []Request) ([]entryhook.Entry, error)
+}
+
+type Config struct {
+	// URL, if set, is the endpoint the requests are POSTed to as a JSON
+	// array. The transformed entries are read from the response body as a
+	// JSON array.
+	URL string
+
+	// Command, if set, is executed with the requests written to its standard
+	// input as a JSON array. The transformed entries are read from its
+	// standard output as a JSON array.
+	Command []string
+
+	// Timeout bounds each transformation. Defaults to 10s.
+	Timeout time.Duration
+
+	// HTTPClient is used to send requests to the URL. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// External transforms entries with an HTTP endpoint or a command.
+type External struct {
+	config Config
+}
+
+var _ Transformer = (*External)(nil)
+
+func New(config Config) (*External, error) {
+	switch {
+	case config.URL == "" && len(config.Command) == 0:
+		return nil, errors.New("either a URL or a command is required")
+	case config.URL != "" && len(config.Command) > 0:
+		return nil, errors.New("a URL and a command are mutually exclusive")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &External{config: config}, nil
+}
+
+// Transform sends the requests to the endpoint or command and returns the
+// transformed entries.
+func (t *External) Transform(ctx context.Context, requests []Request) ([]entryhook.Entry, error) {
+	payload, err := json.Marshal(requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal requests: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.config.Timeout)
+	defer cancel()
+
+	var output []byte
+	if t.config.URL != "" {
+		output, err = t.post(ctx, payload)
+	} else {
+		output, err = t.exec(ctx, payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entryhook.Entry
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transformed entries: %w", err)
+	}
+	if len(entries) != len(requests) {
+		return nil, fmt.Errorf("expected %d transformed entries but got %d", len(requests), len(entries))
+	}
+	return entries, nil
+}
+
+func (t *External) post(ctx context.Context, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return body, nil
+}
+
+func (t *External) exec(ctx context.Context, payload []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, t.config.Command[0], t.config.Command[1:]...) // nolint: gosec // the command is operator configuration
+	cmd.Stdin = bytes.NewReader(payload)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("command failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return output, nil
+}
@@ -0,0 +1,93 @@
+package entrytransformer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spiffe/spire-controller-manager/pkg/entryhook"
+	"github.com/stretchr/testify/require"
+)
+
+var testRequests = []Request{
+	{
+		Entry: entryhook.Entry{
+			SPIFFEID:  "spiffe://domain.test/workload",
+			ParentID:  "spiffe://domain.test/node",
+			Selectors: []string{"k8s:pod-uid:uid"},
+		},
+		Owner:    &entryhook.ObjectReference{Kind: "ClusterSPIFFEID", Name: "csid", UID: "csiduid"},
+		Workload: &entryhook.Workload{Namespace: "ns", Name: "pod", UID: "uid"},
+	},
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{})
+	require.EqualError(t, err, "either a URL or a command is required")
+
+	_, err = New(Config{URL: "http://localhost", Command: []string{"true"}})
+	require.EqualError(t, err, "a URL and a command are mutually exclusive")
+}
+
+func TestTransformURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requests []Request
+		if err := json.NewDecoder(r.Body).Decode(&requests); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		entries := make([]entryhook.Entry, 0, len(requests))
+		for _, request := range requests {
+			entry := request.Entry
+			entry.DNSNames = append(entry.DNSNames, request.Workload.Name+"."+request.Workload.Namespace)
+			entries = append(entries, entry)
+		}
+		_ = json.NewEncoder(w).Encode(entries)
+	}))
+	defer server.Close()
+
+	transformer, err := New(Config{URL: server.URL})
+	require.NoError(t, err)
+	entries, err := transformer.Transform(context.Background(), testRequests)
+	require.NoError(t, err)
+	expected := testRequests[0].Entry
+	expected.DNSNames = []string{"pod.ns"}
+	require.Equal(t, []entryhook.Entry{expected}, entries)
+}
+
+func TestTransformURLFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oh no", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	transformer, err := New(Config{URL: server.URL})
+	require.NoError(t, err)
+	_, err = transformer.Transform(context.Background(), testRequests)
+	require.EqualError(t, err, "unexpected status code 500: oh no")
+}
+
+func TestTransformCommand(t *testing.T) {
+	// The command echoes the entries back unchanged.
+	transformer, err := New(Config{Command: []string{"sh", "-c", `sed -e 's/^\[{"entry":\(.*\),"owner".*$/[\1]/'`}})
+	require.NoError(t, err)
+	entries, err := transformer.Transform(context.Background(), testRequests)
+	require.NoError(t, err)
+	require.Equal(t, []entryhook.Entry{testRequests[0].Entry}, entries)
+}
+
+func TestTransformCommandFailure(t *testing.T) {
+	transformer, err := New(Config{Command: []string{"sh", "-c", "echo oh no >&2; exit 1"}})
+	require.NoError(t, err)
+	_, err = transformer.Transform(context.Background(), testRequests)
+	require.EqualError(t, err, "command failed: exit status 1: oh no")
+}
+
+func TestTransformRequiresAnEntryPerRequest(t *testing.T) {
+	transformer, err := New(Config{Command: []string{"echo", "[]"}})
+	require.NoError(t, err)
+	_, err = transformer.Transform(context.Background(), testRequests)
+	require.EqualError(t, err, "expected 1 transformed entries but got 0")
+}
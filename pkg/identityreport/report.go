@@ -0,0 +1,260 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identityreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// ReportKey is the ConfigMap data key holding the JSON report.
+	ReportKey = "report.json"
+
+	metricsNamespace = "spire_controller_manager"
+	metricsSubsystem = "identities"
+)
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
+
+// Report counts the identities (i.e. SPIRE Server entries) declared by the
+// controller.
+type Report struct {
+	// Total is the total number of identities.
+	Total int `json:"total"`
+
+	// Namespaces counts the identities for pods by namespace.
+	Namespaces map[string]int `json:"namespaces"`
+
+	// ServiceAccounts counts the identities for pods by service account,
+	// keyed by "namespace/name".
+	ServiceAccounts map[string]int `json:"serviceAccounts"`
+
+	// ClusterSPIFFEIDs counts the identities by the ClusterSPIFFEID that
+	// declared them.
+	ClusterSPIFFEIDs map[string]int `json:"clusterSPIFFEIDs"`
+
+	// NamespacedSPIFFEIDs is the number of identities declared by
+	// NamespacedSPIFFEIDs.
+	NamespacedSPIFFEIDs int `json:"namespacedSPIFFEIDs,omitempty"`
+
+	// ClusterStaticEntries is the number of identities declared by
+	// ClusterStaticEntries.
+	ClusterStaticEntries int `json:"clusterStaticEntries"`
+}
+
+// NewReport returns an empty report.
+func NewReport() *Report {
+	return &Report{
+		Namespaces:       make(map[string]int),
+		ServiceAccounts:  make(map[string]int),
+		ClusterSPIFFEIDs: make(map[string]int),
+	}
+}
+
+// AddPodIdentity counts an identity declared by a ClusterSPIFFEID for a pod.
+func (r *Report) AddPodIdentity(clusterSPIFFEID, namespace, serviceAccountName string) {
+	r.addPodIdentity(namespace, serviceAccountName)
+	r.ClusterSPIFFEIDs[clusterSPIFFEID]++
+}
+
+// AddNamespacedPodIdentity counts an identity declared by a
+// NamespacedSPIFFEID for a pod.
+func (r *Report) AddNamespacedPodIdentity(namespace, serviceAccountName string) {
+	r.addPodIdentity(namespace, serviceAccountName)
+	r.NamespacedSPIFFEIDs++
+}
+
+func (r *Report) addPodIdentity(namespace, serviceAccountName string) {
+	r.Total++
+	r.Namespaces[namespace]++
+	r.ServiceAccounts[types.NamespacedName{Namespace: namespace, Name: serviceAccountName}.String()]++
+}
+
+// AddStaticIdentity counts an identity declared by a ClusterStaticEntry.
+func (r *Report) AddStaticIdentity() {
+	r.Total++
+	r.ClusterStaticEntries++
+}
+
+// Reporter publishes identity reports.
+type Reporter interface {
+	Publish(ctx context.Context, report *Report)
+}
+
+type Config struct {
+	// K8sClient is used to write the ConfigMap.
+	K8sClient client.Client
+
+	// APIReader is used to read the ConfigMap directly from the API server
+	// so that the controller does not need to cache every ConfigMap in the
+	// cluster.
+	APIReader client.Reader
+
+	// ConfigMap, if set, is the ConfigMap the JSON report is written to.
+	ConfigMap *types.NamespacedName
+
+	// Registerer registers the metrics. Defaults to the controller-runtime
+	// metrics registry.
+	Registerer prometheus.Registerer
+}
+
+// MetricsReporter publishes identity reports as metrics, and optionally, to
+// a ConfigMap.
+type MetricsReporter struct {
+	config Config
+
+	total                prometheus.Gauge
+	byNamespace          *prometheus.GaugeVec
+	byServiceAccount     *prometheus.GaugeVec
+	byClusterSPIFFEID    *prometheus.GaugeVec
+	byClusterStaticEntry prometheus.Gauge
+
+	// lastReport is the last report written to the ConfigMap, used to
+	// avoid writing the ConfigMap when nothing has changed.
+	lastReport []byte
+}
+
+var _ Reporter = (*MetricsReporter)(nil)
+
+func New(config Config) (*MetricsReporter, error) {
+	if config.Registerer == nil {
+		config.Registerer = metrics.Registry
+	}
+
+	r := &MetricsReporter{
+		config: config,
+		total: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "total",
+			Help:      "Number of identities declared by the controller.",
+		}),
+		byNamespace: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "by_namespace",
+			Help:      "Number of identities declared for pods, by namespace.",
+		}, []string{"namespace"}),
+		byServiceAccount: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "by_service_account",
+			Help:      "Number of identities declared for pods, by service account.",
+		}, []string{"namespace", "service_account"}),
+		byClusterSPIFFEID: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "by_cluster_spiffeid",
+			Help:      "Number of identities declared by each ClusterSPIFFEID.",
+		}, []string{"cluster_spiffeid"}),
+		byClusterStaticEntry: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "cluster_static_entries",
+			Help:      "Number of identities declared by ClusterStaticEntries.",
+		}),
+	}
+
+	for _, collector := range []prometheus.Collector{r.total, r.byNamespace, r.byServiceAccount, r.byClusterSPIFFEID, r.byClusterStaticEntry} {
+		if err := config.Registerer.Register(collector); err != nil {
+			return nil, fmt.Errorf("failed to register identity report metrics: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// Publish updates the metrics and, if configured, the ConfigMap with the
+// report.
+func (r *MetricsReporter) Publish(ctx context.Context, report *Report) {
+	r.total.Set(float64(report.Total))
+
+	// Reset the vectors so that series for namespaces, service accounts, and
+	// ClusterSPIFFEIDs that no longer have identities go away.
+	r.byNamespace.Reset()
+	for namespace, count := range report.Namespaces {
+		r.byNamespace.WithLabelValues(namespace).Set(float64(count))
+	}
+	r.byServiceAccount.Reset()
+	for serviceAccount, count := range report.ServiceAccounts {
+		namespace, name := splitNamespacedName(serviceAccount)
+		r.byServiceAccount.WithLabelValues(namespace, name).Set(float64(count))
+	}
+	r.byClusterSPIFFEID.Reset()
+	for clusterSPIFFEID, count := range report.ClusterSPIFFEIDs {
+		r.byClusterSPIFFEID.WithLabelValues(clusterSPIFFEID).Set(float64(count))
+	}
+	r.byClusterStaticEntry.Set(float64(report.ClusterStaticEntries))
+
+	if r.config.ConfigMap != nil {
+		if err := r.writeConfigMap(ctx, report); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to write identity report ConfigMap", "configMap", r.config.ConfigMap.String())
+		}
+	}
+}
+
+func (r *MetricsReporter) writeConfigMap(ctx context.Context, report *Report) error {
+	// Maps are marshaled with sorted keys so the output is stable.
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if string(data) == string(r.lastReport) {
+		return nil
+	}
+
+	configMap := new(corev1.ConfigMap)
+	err = r.config.APIReader.Get(ctx, *r.config.ConfigMap, configMap)
+	switch {
+	case apierrors.IsNotFound(err):
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: r.config.ConfigMap.Namespace,
+				Name:      r.config.ConfigMap.Name,
+			},
+			Data: map[string]string{ReportKey: string(data)},
+		}
+		err = r.config.K8sClient.Create(ctx, configMap)
+	case err == nil:
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		configMap.Data[ReportKey] = string(data)
+		err = r.config.K8sClient.Update(ctx, configMap)
+	}
+	if err != nil {
+		return err
+	}
+	r.lastReport = data
+	return nil
+}
+
+func splitNamespacedName(s string) (string, string) {
+	namespace, name, _ := strings.Cut(s, string(types.Separator))
+	return namespace, name
+}
@@ -0,0 +1,81 @@
+package identityreport
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spiffe/spire-controller-manager/pkg/test/k8stest"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	configMapName := types.NamespacedName{Namespace: "spire-system", Name: "identity-report"}
+	k8sClient := k8stest.NewClientBuilder(t).Build()
+	registry := prometheus.NewRegistry()
+
+	reporter, err := New(Config{
+		K8sClient:  k8sClient,
+		APIReader:  k8sClient,
+		ConfigMap:  &configMapName,
+		Registerer: registry,
+	})
+	require.NoError(t, err)
+
+	report := NewReport()
+	report.AddPodIdentity("csid1", "ns1", "sa1")
+	report.AddPodIdentity("csid1", "ns1", "sa1")
+	report.AddPodIdentity("csid2", "ns2", "default")
+	report.AddStaticIdentity()
+	reporter.Publish(ctx, report)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP spire_controller_manager_identities_by_cluster_spiffeid Number of identities declared by each ClusterSPIFFEID.
+# TYPE spire_controller_manager_identities_by_cluster_spiffeid gauge
+spire_controller_manager_identities_by_cluster_spiffeid{cluster_spiffeid="csid1"} 2
+spire_controller_manager_identities_by_cluster_spiffeid{cluster_spiffeid="csid2"} 1
+# HELP spire_controller_manager_identities_by_namespace Number of identities declared for pods, by namespace.
+# TYPE spire_controller_manager_identities_by_namespace gauge
+spire_controller_manager_identities_by_namespace{namespace="ns1"} 2
+spire_controller_manager_identities_by_namespace{namespace="ns2"} 1
+# HELP spire_controller_manager_identities_by_service_account Number of identities declared for pods, by service account.
+# TYPE spire_controller_manager_identities_by_service_account gauge
+spire_controller_manager_identities_by_service_account{namespace="ns1",service_account="sa1"} 2
+spire_controller_manager_identities_by_service_account{namespace="ns2",service_account="default"} 1
+# HELP spire_controller_manager_identities_cluster_static_entries Number of identities declared by ClusterStaticEntries.
+# TYPE spire_controller_manager_identities_cluster_static_entries gauge
+spire_controller_manager_identities_cluster_static_entries 1
+# HELP spire_controller_manager_identities_total Number of identities declared by the controller.
+# TYPE spire_controller_manager_identities_total gauge
+spire_controller_manager_identities_total 4
+`)))
+
+	requireConfigMapReport(t, k8sClient, configMapName, report)
+
+	t.Run("series for removed identities are dropped", func(t *testing.T) {
+		report := NewReport()
+		report.AddPodIdentity("csid1", "ns1", "sa1")
+		reporter.Publish(ctx, report)
+
+		require.Equal(t, 1, testutil.CollectAndCount(reporter.byNamespace))
+		require.Equal(t, 1, testutil.CollectAndCount(reporter.byServiceAccount))
+		require.Equal(t, 1, testutil.CollectAndCount(reporter.byClusterSPIFFEID))
+		requireConfigMapReport(t, k8sClient, configMapName, report)
+	})
+}
+
+func requireConfigMapReport(t *testing.T, k8sClient client.Client, name types.NamespacedName, expected *Report) {
+	t.Helper()
+	configMap := new(corev1.ConfigMap)
+	require.NoError(t, k8sClient.Get(context.Background(), name, configMap))
+	actual := new(Report)
+	require.NoError(t, json.Unmarshal([]byte(configMap.Data[ReportKey]), actual))
+	require.Equal(t, expected, actual)
+}
@@ -21,10 +21,28 @@ import (
 
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// IgnoreNotServed returns nil if listing the kind failed because its CRD is
+// not installed, and the error otherwise. With the partial and wait CRD
+// availability policies, a kind whose CRD is not installed has no objects
+// and the other kinds are still reconciled. A NoMatch error alone does not
+// show that the CRD is not installed, since it is also returned while the
+// REST mapping is stale or the CRD is replaced during an upgrade, and
+// treating the kind as having no objects then would delete everything
+// declared by its objects. So the error is only ignored once notServed, if
+// set, has confirmed that the API server does not serve the kind.
+func IgnoreNotServed(err error, gvk schema.GroupVersionKind, notServed func(schema.GroupVersionKind) bool) error {
+	if meta.IsNoMatchError(err) && notServed != nil && notServed(gvk) {
+		return nil
+	}
+	return err
+}
+
 func ListClusterStaticEntries(ctx context.Context, c client.Client) ([]spirev1alpha1.ClusterStaticEntry, error) {
 	var list spirev1alpha1.ClusterStaticEntryList
 	if err := c.List(ctx, &list); err != nil {
@@ -49,6 +67,30 @@ func ListClusterFederatedTrustDomains(ctx context.Context, c client.Client) ([]s
 	return list.Items, nil
 }
 
+func ListClusterTrustDomainSets(ctx context.Context, c client.Client) ([]spirev1alpha1.ClusterTrustDomainSet, error) {
+	var list spirev1alpha1.ClusterTrustDomainSetList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func ListClusterNodeAliases(ctx context.Context, c client.Client) ([]spirev1alpha1.ClusterNodeAlias, error) {
+	var list spirev1alpha1.ClusterNodeAliasList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func ListNamespacedSPIFFEIDs(ctx context.Context, c client.Client) ([]spirev1alpha1.NamespacedSPIFFEID, error) {
+	var list spirev1alpha1.NamespacedSPIFFEIDList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
 func ListNamespaces(ctx context.Context, c client.Client, namespaceSelector labels.Selector) ([]corev1.Namespace, error) {
 	var opts []client.ListOption
 	if namespaceSelector != nil {
@@ -61,6 +103,26 @@ func ListNamespaces(ctx context.Context, c client.Client, namespaceSelector labe
 	return list.Items, nil
 }
 
+func ListNodes(ctx context.Context, c client.Client, nodeSelector labels.Selector) ([]corev1.Node, error) {
+	var opts []client.ListOption
+	if nodeSelector != nil {
+		opts = append(opts, client.MatchingLabelsSelector{Selector: nodeSelector})
+	}
+	list := new(corev1.NodeList)
+	if err := c.List(ctx, list, opts...); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func ListNamespaceServices(ctx context.Context, c client.Client, namespace string) ([]corev1.Service, error) {
+	list := new(corev1.ServiceList)
+	if err := c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
 func ListNamespacePods(ctx context.Context, c client.Client, namespace string, podSelector labels.Selector) ([]corev1.Pod, error) {
 	opts := []client.ListOption{
 		client.InNamespace(namespace),
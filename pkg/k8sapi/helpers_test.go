@@ -11,8 +11,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -75,6 +77,87 @@ func TestListClusterFederatedTrustDomains(t *testing.T) {
 	})
 }
 
+func TestListClusterTrustDomainSets(t *testing.T) {
+	foo := spirev1alpha1.ClusterTrustDomainSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+	}
+
+	t.Run("list fails", func(t *testing.T) {
+		client := FailList(k8stest.NewClientBuilder(t).Build())
+		actual, err := k8sapi.ListClusterTrustDomainSets(context.Background(), client)
+		assert.EqualError(t, err, errList.Error())
+		assert.Empty(t, actual)
+	})
+
+	t.Run("list empty", func(t *testing.T) {
+		client := k8stest.NewClientBuilder(t).Build()
+		actual, err := k8sapi.ListClusterTrustDomainSets(context.Background(), client)
+		assert.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("list not empty", func(t *testing.T) {
+		client := k8stest.NewClientBuilder(t).WithRuntimeObjects(&foo).Build()
+		actual, err := k8sapi.ListClusterTrustDomainSets(context.Background(), client)
+		assert.NoError(t, err)
+		assert.Equal(t, []spirev1alpha1.ClusterTrustDomainSet{foo}, actual)
+	})
+}
+
+func TestListClusterNodeAliases(t *testing.T) {
+	foo := spirev1alpha1.ClusterNodeAlias{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+	}
+
+	t.Run("list fails", func(t *testing.T) {
+		client := FailList(k8stest.NewClientBuilder(t).Build())
+		actual, err := k8sapi.ListClusterNodeAliases(context.Background(), client)
+		assert.EqualError(t, err, errList.Error())
+		assert.Empty(t, actual)
+	})
+
+	t.Run("list empty", func(t *testing.T) {
+		client := k8stest.NewClientBuilder(t).Build()
+		actual, err := k8sapi.ListClusterNodeAliases(context.Background(), client)
+		assert.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("list not empty", func(t *testing.T) {
+		client := k8stest.NewClientBuilder(t).WithRuntimeObjects(&foo).Build()
+		actual, err := k8sapi.ListClusterNodeAliases(context.Background(), client)
+		assert.NoError(t, err)
+		assert.Equal(t, []spirev1alpha1.ClusterNodeAlias{foo}, actual)
+	})
+}
+
+func TestListNamespacedSPIFFEIDs(t *testing.T) {
+	foo := spirev1alpha1.NamespacedSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "foo"},
+	}
+
+	t.Run("list fails", func(t *testing.T) {
+		client := FailList(k8stest.NewClientBuilder(t).Build())
+		actual, err := k8sapi.ListNamespacedSPIFFEIDs(context.Background(), client)
+		assert.EqualError(t, err, errList.Error())
+		assert.Empty(t, actual)
+	})
+
+	t.Run("list empty", func(t *testing.T) {
+		client := k8stest.NewClientBuilder(t).Build()
+		actual, err := k8sapi.ListNamespacedSPIFFEIDs(context.Background(), client)
+		assert.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("list not empty", func(t *testing.T) {
+		client := k8stest.NewClientBuilder(t).WithRuntimeObjects(&foo).Build()
+		actual, err := k8sapi.ListNamespacedSPIFFEIDs(context.Background(), client)
+		assert.NoError(t, err)
+		assert.Equal(t, []spirev1alpha1.NamespacedSPIFFEID{foo}, actual)
+	})
+}
+
 func TestListNamespaces(t *testing.T) {
 	ns1 := corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{Name: "ns1", Labels: map[string]string{"widget": "foo"}},
@@ -115,6 +198,39 @@ func TestListNamespaces(t *testing.T) {
 	})
 }
 
+func TestListNodes(t *testing.T) {
+	node1 := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"pool": "cpu"}},
+	}
+	node2 := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{"pool": "gpu"}},
+	}
+
+	t.Run("list fails", func(t *testing.T) {
+		client := FailList(k8stest.NewClientBuilder(t).Build())
+		actual, err := k8sapi.ListNodes(context.Background(), client, nil)
+		assert.EqualError(t, err, errList.Error())
+		assert.Empty(t, actual)
+	})
+
+	t.Run("list not empty", func(t *testing.T) {
+		client := fake.NewClientBuilder().WithRuntimeObjects(&node1, &node2).Build()
+		actual, err := k8sapi.ListNodes(context.Background(), client, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []corev1.Node{node1, node2}, actual)
+	})
+
+	t.Run("list filtered by labels", func(t *testing.T) {
+		selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchLabels: node2.Labels})
+		require.NoError(t, err)
+
+		client := fake.NewClientBuilder().WithRuntimeObjects(&node1, &node2).Build()
+		actual, err := k8sapi.ListNodes(context.Background(), client, selector)
+		assert.NoError(t, err)
+		assert.Equal(t, []corev1.Node{node2}, actual)
+	})
+}
+
 func TestListNamespacePods(t *testing.T) {
 	pod1 := corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "pod1", Labels: map[string]string{"widget": "foo"}},
@@ -171,3 +287,16 @@ type failList struct {
 func (c failList) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
 	return errList
 }
+
+func TestIgnoreNotServed(t *testing.T) {
+	gvk := spirev1alpha1.GroupVersion.WithKind("ClusterSPIFFEID")
+	noMatch := &meta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+	notServed := func(kind schema.GroupVersionKind) bool { return kind == gvk }
+	served := func(schema.GroupVersionKind) bool { return false }
+
+	assert.NoError(t, k8sapi.IgnoreNotServed(nil, gvk, nil))
+	assert.NoError(t, k8sapi.IgnoreNotServed(noMatch, gvk, notServed))
+	assert.Equal(t, noMatch, k8sapi.IgnoreNotServed(noMatch, gvk, served), "kind not confirmed to be absent")
+	assert.Equal(t, noMatch, k8sapi.IgnoreNotServed(noMatch, gvk, nil), "no confirmation")
+	assert.Equal(t, errList, k8sapi.IgnoreNotServed(errList, gvk, notServed), "other errors")
+}
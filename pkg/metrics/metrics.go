@@ -0,0 +1,218 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics holds the reconciliation metrics. They are registered with
+// the controller-runtime metrics registry and served on the manager metrics
+// endpoint alongside the controller-runtime metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "spire_controller_manager"
+
+// Entry change operations.
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+	OperationBan    = "ban"
+)
+
+// Entry update results.
+const (
+	ResultApplied = "applied"
+	ResultSkipped = "skipped"
+)
+
+// Configuration reload results.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Federation relationship states.
+const (
+	StateInSync    = "in_sync"
+	StateOutOfSync = "out_of_sync"
+)
+
+var (
+	// ReconcileDuration observes how long each reconciliation pass takes,
+	// by reconciler kind.
+	ReconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of reconciliation passes, by reconciler kind.",
+	}, []string{"kind"})
+
+	// ReconcileLastTimestamp is the time the last reconciliation pass
+	// finished, by reconciler kind. It stops advancing when reconciliation
+	// stalls.
+	ReconcileLastTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "reconcile_last_timestamp_seconds",
+		Help:      "Unix time the last reconciliation pass finished, by reconciler kind.",
+	}, []string{"kind"})
+
+	// EntriesManaged is the number of SPIRE entries managed by the
+	// controller as of the last entry reconciliation.
+	EntriesManaged = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "entries_managed",
+		Help:      "Number of SPIRE entries managed by the controller.",
+	})
+
+	// EntryChanges counts the SPIRE entries successfully created, updated,
+	// and deleted.
+	EntryChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "entry_changes_total",
+		Help:      "Number of SPIRE entries changed, by operation.",
+	}, []string{"operation"})
+
+	// EntryUpdates counts the declared SPIRE entries that already exist, by
+	// whether an update was applied because they were outdated, or skipped
+	// because they were up to date.
+	EntryUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "entry_updates_total",
+		Help:      "Number of existing SPIRE entries updated or skipped because they were up to date, by result.",
+	}, []string{"result"})
+
+	// EntriesPendingDeletion is the number of SPIRE entries that are no
+	// longer declared but are retained until the entry deletion grace period
+	// expires.
+	EntriesPendingDeletion = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "entries_pending_deletion",
+		Help:      "Number of SPIRE entries that are no longer declared and are awaiting deletion.",
+	})
+
+	// DryRunChanges is the number of changes the last reconciliation pass
+	// would have made to SPIRE Server had dry run not been enabled, by
+	// reconciler kind and operation.
+	DryRunChanges = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dry_run_changes",
+		Help:      "Number of changes a dry run would make, by reconciler kind and operation.",
+	}, []string{"kind", "operation"})
+
+	// SPIREAPIErrors counts failed SPIRE API calls, as well as the failed
+	// items of batch calls, by call.
+	SPIREAPIErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "spire_api_errors_total",
+		Help:      "Number of SPIRE API errors, by call.",
+	}, []string{"call"})
+
+	// SPIREAPIRetries counts the SPIRE API calls retried because SPIRE
+	// Server was unavailable, by call.
+	SPIREAPIRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "spire_api_retries_total",
+		Help:      "Number of SPIRE API calls retried because SPIRE Server was unavailable, by call.",
+	}, []string{"call"})
+
+	// SPIREAPIThrottledCalls counts the SPIRE API calls delayed by the
+	// client-side rate limit, by call.
+	SPIREAPIThrottledCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "spire_api_throttled_calls_total",
+		Help:      "Number of SPIRE API calls delayed by the client-side rate limit, by call.",
+	}, []string{"call"})
+
+	// EntryLimitExceeded counts the entries SPIRE Server refused to create
+	// because the entry limit of their parent was reached.
+	EntryLimitExceeded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "entry_limit_exceeded_total",
+		Help:      "Number of SPIRE entries not created because the entry limit of their parent was reached.",
+	})
+
+	// ParentsBackingOff is the number of parents (i.e. agents) for which
+	// entries are not being created because their entry limit was reached.
+	ParentsBackingOff = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "parents_backing_off",
+		Help:      "Number of parents for which entries are not created because their entry limit was reached.",
+	})
+
+	// FederationRelationships is the number of federation relationships
+	// that are in sync, or out of sync, with the ClusterFederatedTrustDomains
+	// as of the last federation relationship reconciliation.
+	FederationRelationships = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "federation_relationships",
+		Help:      "Number of federation relationships, by whether they are in sync.",
+	}, []string{"state"})
+
+	// AgentsCollected counts the SPIRE agents of removed nodes that were
+	// deleted or banned, by operation.
+	AgentsCollected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "agents_collected_total",
+		Help:      "Number of SPIRE agents of removed nodes collected, by operation.",
+	}, []string{"operation"})
+
+	// ConfigReloads counts the reloads of the configuration file, by
+	// whether the new configuration was valid.
+	ConfigReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Number of configuration file reloads, by result.",
+	}, []string{"result"})
+
+	// ConfigRestartRequired is 1 if the configuration file has changes that
+	// only take effect when the controller is restarted, and 0 otherwise.
+	ConfigRestartRequired = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_restart_required",
+		Help:      "Whether the configuration file has changes that require a restart.",
+	})
+
+	// WebhookCertificateExpiresIn is the time until the webhook serving
+	// certificate expires. It is negative once the certificate has expired.
+	WebhookCertificateExpiresIn = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "webhook_certificate_expires_in_seconds",
+		Help:      "Seconds until the webhook serving certificate expires.",
+	})
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		ReconcileDuration,
+		ReconcileLastTimestamp,
+		EntriesManaged,
+		EntryChanges,
+		EntryUpdates,
+		EntriesPendingDeletion,
+		DryRunChanges,
+		SPIREAPIErrors,
+		SPIREAPIThrottledCalls,
+		SPIREAPIRetries,
+		EntryLimitExceeded,
+		ParentsBackingOff,
+		FederationRelationships,
+		AgentsCollected,
+		ConfigReloads,
+		ConfigRestartRequired,
+		WebhookCertificateExpiresIn,
+	)
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metricsserver serves the controller metrics over TLS and, if
+// configured, only to clients whose bearer token is authenticated with a
+// TokenReview and authorized with a SubjectAccessReview, like a
+// kube-rbac-proxy in front of the plaintext metrics listener would.
+package metricsserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// MetricsPath is the path the metrics are served on, the same as the
+	// controller-runtime metrics listener.
+	MetricsPath = "/metrics"
+
+	shutdownTimeout = 5 * time.Second
+	reviewTimeout   = 10 * time.Second
+)
+
+//+kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// Config configures the metrics server.
+type Config struct {
+	// BindAddress is the address the metrics server listens on.
+	BindAddress string
+
+	// CertFile and KeyFile, if set, are the serving certificate and private
+	// key. They are loaded for each connection, so that rotated files are
+	// picked up without a restart.
+	CertFile string
+	KeyFile  string
+
+	// GetCertificate returns the serving certificate if CertFile and KeyFile
+	// are not set, e.g. the webhook serving certificate.
+	GetCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+
+	// TokenReviews and SubjectAccessReviews, if set, are used to
+	// authenticate and authorize each request. Either both or neither must
+	// be set.
+	TokenReviews         authenticationv1client.TokenReviewInterface
+	SubjectAccessReviews authorizationv1client.SubjectAccessReviewInterface
+
+	// Gatherer is the source of the metrics. Defaults to the
+	// controller-runtime metrics registry.
+	Gatherer prometheus.Gatherer
+}
+
+// Server serves the metrics over TLS. Every replica serves its own metrics,
+// so it runs regardless of leader election.
+type Server struct {
+	config Config
+
+	mtx           sync.Mutex
+	extraHandlers map[string]http.Handler
+}
+
+// New returns a metrics server for the configuration.
+func New(config Config) (*Server, error) {
+	switch {
+	case config.BindAddress == "":
+		return nil, errors.New("bind address is required")
+	case (config.CertFile == "") != (config.KeyFile == ""):
+		return nil, errors.New("TLS requires both a certificate and key file")
+	case config.CertFile == "" && config.GetCertificate == nil:
+		return nil, errors.New("TLS requires a certificate")
+	case (config.TokenReviews == nil) != (config.SubjectAccessReviews == nil):
+		return nil, errors.New("authorization requires both a TokenReview and SubjectAccessReview client")
+	}
+	if config.Gatherer == nil {
+		config.Gatherer = metrics.Registry
+	}
+	return &Server{
+		config:        config,
+		extraHandlers: make(map[string]http.Handler),
+	}, nil
+}
+
+// AddExtraHandler adds a handler served alongside the metrics, subject to
+// the same authorization. It must be called before the server is started.
+func (s *Server) AddExtraHandler(path string, handler http.Handler) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if path == MetricsPath {
+		return fmt.Errorf("overriding the metrics path %q is not allowed", path)
+	}
+	if _, ok := s.extraHandlers[path]; ok {
+		return fmt.Errorf("handler for path %q is already registered", path)
+	}
+	s.extraHandlers[path] = handler
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves the metrics until the context is canceled.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.config.BindAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen for metrics server: %w", err)
+	}
+	return s.serve(ctx, listener)
+}
+
+func (s *Server) serve(ctx context.Context, listener net.Listener) error {
+	log := log.FromContext(ctx).WithName("metrics-server")
+
+	listener = tls.NewListener(listener, &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: s.getCertificate,
+	})
+
+	server := &http.Server{
+		Handler:           s.newHandler(ctx),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+	log.Info("Serving metrics", "address", listener.Addr().String(), "authorization", s.config.TokenReviews != nil)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("metrics server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down metrics server: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if s.config.CertFile == "" {
+		return s.config.GetCertificate(hello)
+	}
+	certificate, err := tls.LoadX509KeyPair(s.config.CertFile, s.config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metrics server certificate: %w", err)
+	}
+	return &certificate, nil
+}
+
+func (s *Server) newHandler(ctx context.Context) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, promhttp.HandlerFor(s.config.Gatherer, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.HTTPErrorOnError,
+	}))
+	s.mtx.Lock()
+	for path, handler := range s.extraHandlers {
+		mux.Handle(path, handler)
+	}
+	s.mtx.Unlock()
+
+	if s.config.TokenReviews == nil {
+		return mux
+	}
+	return s.authorize(ctx, mux)
+}
+
+// authorize only passes requests whose bearer token is authenticated, and
+// whose user is allowed to use the HTTP method, lowercased, as verb on the
+// request path, e.g. get on /metrics.
+func (s *Server) authorize(ctx context.Context, next http.Handler) http.Handler {
+	log := log.FromContext(ctx).WithName("metrics-server")
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token, ok := bearerToken(req)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), reviewTimeout)
+		defer cancel()
+
+		tokenReview, err := s.config.TokenReviews.Create(ctx, &authenticationv1.TokenReview{
+			Spec: authenticationv1.TokenReviewSpec{Token: token},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			log.Error(err, "Failed to review token")
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !tokenReview.Status.Authenticated {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		user := tokenReview.Status.User
+		extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+		for key, value := range user.Extra {
+			extra[key] = authorizationv1.ExtraValue(value)
+		}
+		subjectAccessReview, err := s.config.SubjectAccessReviews.Create(ctx, &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   user.Username,
+				UID:    user.UID,
+				Groups: user.Groups,
+				Extra:  extra,
+				NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+					Path: req.URL.Path,
+					Verb: strings.ToLower(req.Method),
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			log.Error(err, "Failed to review subject access")
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !subjectAccessReview.Status.Allowed {
+			log.V(1).Info("Metrics request denied", "user", user.Username, "path", req.URL.Path, "reason", subjectAccessReview.Status.Reason)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricsserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestNew(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	getCertificate := func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return nil, nil }
+
+	_, err := New(Config{GetCertificate: getCertificate})
+	require.EqualError(t, err, "bind address is required")
+
+	_, err = New(Config{BindAddress: ":8443", CertFile: "tls.crt"})
+	require.EqualError(t, err, "TLS requires both a certificate and key file")
+
+	_, err = New(Config{BindAddress: ":8443"})
+	require.EqualError(t, err, "TLS requires a certificate")
+
+	_, err = New(Config{BindAddress: ":8443", GetCertificate: getCertificate, TokenReviews: clientset.AuthenticationV1().TokenReviews()})
+	require.EqualError(t, err, "authorization requires both a TokenReview and SubjectAccessReview client")
+
+	_, err = New(Config{BindAddress: ":8443", CertFile: "tls.crt", KeyFile: "tls.key"})
+	require.NoError(t, err)
+}
+
+func TestServeAuthorization(t *testing.T) {
+	certificate, roots := createServingCertificate(t)
+
+	var lastAttributes *authorizationv1.NonResourceAttributes
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "tokenreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		tokenReview := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch tokenReview.Spec.Token {
+		case "prometheus", "other":
+			tokenReview.Status.Authenticated = true
+			tokenReview.Status.User = authenticationv1.UserInfo{Username: tokenReview.Spec.Token}
+		}
+		return true, tokenReview, nil
+	})
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		subjectAccessReview := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		lastAttributes = subjectAccessReview.Spec.NonResourceAttributes
+		subjectAccessReview.Status.Allowed = subjectAccessReview.Spec.User == "prometheus"
+		return true, subjectAccessReview, nil
+	})
+
+	s, err := New(Config{
+		BindAddress:          "127.0.0.1:0",
+		GetCertificate:       func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return certificate, nil },
+		TokenReviews:         clientset.AuthenticationV1().TokenReviews(),
+		SubjectAccessReviews: clientset.AuthorizationV1().SubjectAccessReviews(),
+		Gatherer:             newGatherer(t),
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.AddExtraHandler("/explain", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "explained")
+	})))
+	require.EqualError(t, s.AddExtraHandler("/explain", http.NotFoundHandler()), `handler for path "/explain" is already registered`)
+	require.EqualError(t, s.AddExtraHandler(MetricsPath, http.NotFoundHandler()), `overriding the metrics path "/metrics" is not allowed`)
+
+	addr := startServer(t, s)
+	client := newClient(roots)
+
+	status, _ := get(t, client, "https://"+addr+"/metrics", "")
+	require.Equal(t, http.StatusUnauthorized, status, "requests without a token are refused")
+
+	status, _ = get(t, client, "https://"+addr+"/metrics", "unknown")
+	require.Equal(t, http.StatusUnauthorized, status, "requests with an unauthenticated token are refused")
+
+	status, _ = get(t, client, "https://"+addr+"/metrics", "other")
+	require.Equal(t, http.StatusForbidden, status, "requests by unauthorized users are refused")
+
+	status, body := get(t, client, "https://"+addr+"/metrics", "prometheus")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "test_requests_total 1")
+	require.Equal(t, &authorizationv1.NonResourceAttributes{Path: "/metrics", Verb: "get"}, lastAttributes)
+
+	status, body = get(t, client, "https://"+addr+"/explain", "prometheus")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "explained", body)
+	require.Equal(t, &authorizationv1.NonResourceAttributes{Path: "/explain", Verb: "get"}, lastAttributes)
+}
+
+func TestServeCertificateFiles(t *testing.T) {
+	dir := t.TempDir()
+	certificate, roots := createServingCertificate(t)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	keyDER, err := x509.MarshalPKCS8PrivateKey(certificate.PrivateKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	s, err := New(Config{
+		BindAddress: "127.0.0.1:0",
+		CertFile:    certFile,
+		KeyFile:     keyFile,
+		Gatherer:    newGatherer(t),
+	})
+	require.NoError(t, err)
+	addr := startServer(t, s)
+
+	// Without authorization, the metrics are served to any client, but only
+	// over TLS.
+	status, body := get(t, newClient(roots), "https://"+addr+"/metrics", "")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "test_requests_total 1")
+
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Get("http://" + addr + "/metrics")
+	if err == nil {
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func newGatherer(t *testing.T) prometheus.Gatherer {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_requests_total", Help: "Test counter."})
+	require.NoError(t, registry.Register(counter))
+	counter.Inc()
+	return registry
+}
+
+func startServer(t *testing.T, s *Server) string {
+	listener, err := net.Listen("tcp", s.config.BindAddress)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.serve(ctx, listener)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-errCh)
+	})
+	return listener.Addr().String()
+}
+
+func newClient(roots *x509.CertPool) *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    roots,
+		}},
+	}
+}
+
+func get(t *testing.T, client *http.Client, url, token string) (int, string) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func createServingCertificate(t *testing.T) (*tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "metrics"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return &tls.Certificate{Certificate: [][]byte{certDER}, PrivateKey: key, Leaf: cert}, roots
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespacefilter
+
+import "sync"
+
+// Dynamic holds a filter that can be replaced while it is in use, e.g. when
+// the configuration is reloaded. A nil Dynamic holds the zero Filter.
+type Dynamic struct {
+	mtx    sync.RWMutex
+	filter Filter
+}
+
+// NewDynamic returns a Dynamic holding the given filter.
+func NewDynamic(filter Filter) *Dynamic {
+	return &Dynamic{filter: filter}
+}
+
+// Load returns the current filter.
+func (d *Dynamic) Load() Filter {
+	if d == nil {
+		return Filter{}
+	}
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	return d.filter
+}
+
+// Store replaces the current filter.
+func (d *Dynamic) Store(filter Filter) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.filter = filter
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package namespacefilter decides which namespaces the controller processes
+// pods in.
+package namespacefilter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spiffe/spire-controller-manager/pkg/stringset"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Filter ignores namespaces by name or by label. The zero value ignores no
+// namespaces.
+type Filter struct {
+	// IgnoreNamespaces are the names of the namespaces to ignore.
+	IgnoreNamespaces stringset.StringSet
+
+	// Selector, if set, ignores the namespaces whose labels do not match.
+	Selector labels.Selector
+}
+
+// New returns the filter for the ignored namespaces and the namespace
+// selector, which may be nil.
+func New(ignoreNamespaces []string, namespaceSelector *metav1.LabelSelector) (Filter, error) {
+	filter := Filter{IgnoreNamespaces: ignoreNamespaces}
+	if namespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(namespaceSelector)
+		if err != nil {
+			return filter, fmt.Errorf("invalid namespace selector: %w", err)
+		}
+		filter.Selector = selector
+	}
+	return filter, nil
+}
+
+// Ignored returns true if the namespace is ignored.
+func (f Filter) Ignored(namespace *corev1.Namespace) bool {
+	if f.IgnoreNamespaces.In(namespace.Name) {
+		return true
+	}
+	return f.Selector != nil && !f.Selector.Matches(labels.Set(namespace.Labels))
+}
+
+// IgnoredByName returns true if the namespace with the given name is
+// ignored. The namespace is only read, from the given reader, if its labels
+// are needed. Namespaces that do not exist are ignored when a selector is
+// set.
+func (f Filter) IgnoredByName(ctx context.Context, r client.Reader, name string) (bool, error) {
+	if f.IgnoreNamespaces.In(name) {
+		return true, nil
+	}
+	if f.Selector == nil {
+		return false, nil
+	}
+	namespace := new(corev1.Namespace)
+	if err := r.Get(ctx, client.ObjectKey{Name: name}, namespace); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return f.Ignored(namespace), nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespacefilter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spiffe/spire-controller-manager/pkg/namespacefilter"
+	"github.com/spiffe/spire-controller-manager/pkg/test/k8stest"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestFilter(t *testing.T) {
+	enabled := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "enabled", Labels: map[string]string{"spire.spiffe.io/enabled": "true"}}}
+	disabled := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "disabled"}}
+	ignored := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ignored", Labels: map[string]string{"spire.spiffe.io/enabled": "true"}}}
+	k8sClient := k8stest.NewClientBuilder(t).WithObjects(enabled, disabled, ignored).Build()
+
+	for _, tt := range []struct {
+		desc          string
+		filter        namespacefilter.Filter
+		expectIgnored []string
+	}{
+		{
+			desc: "zero value",
+		},
+		{
+			desc:          "by name",
+			filter:        namespacefilter.Filter{IgnoreNamespaces: []string{"ignored"}},
+			expectIgnored: []string{"ignored"},
+		},
+		{
+			desc: "by name and label",
+			filter: namespacefilter.Filter{
+				IgnoreNamespaces: []string{"ignored"},
+				Selector:         labels.SelectorFromSet(labels.Set{"spire.spiffe.io/enabled": "true"}),
+			},
+			expectIgnored: []string{"disabled", "ignored", "missing"},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			var ignoredNamespaces []string
+			for _, namespace := range []*corev1.Namespace{enabled, disabled, ignored} {
+				if tt.filter.Ignored(namespace) {
+					ignoredNamespaces = append(ignoredNamespaces, namespace.Name)
+				}
+			}
+			var ignoredNames []string
+			for _, name := range []string{"enabled", "disabled", "ignored", "missing"} {
+				ignored, err := tt.filter.IgnoredByName(context.Background(), k8sClient, name)
+				require.NoError(t, err)
+				if ignored {
+					ignoredNames = append(ignoredNames, name)
+				}
+			}
+			require.ElementsMatch(t, tt.expectIgnored, ignoredNames)
+			require.Subset(t, tt.expectIgnored, ignoredNamespaces)
+		})
+	}
+}
+
+func TestDynamic(t *testing.T) {
+	var nilDynamic *namespacefilter.Dynamic
+	require.Equal(t, namespacefilter.Filter{}, nilDynamic.Load())
+
+	filter := namespacefilter.Filter{IgnoreNamespaces: []string{"ignored"}}
+	dynamic := namespacefilter.NewDynamic(filter)
+	require.Equal(t, filter, dynamic.Load())
+
+	filter = namespacefilter.Filter{IgnoreNamespaces: []string{"other"}}
+	dynamic.Store(filter)
+	require.Equal(t, filter, dynamic.Load())
+}
+
+func TestNew(t *testing.T) {
+	filter, err := namespacefilter.New([]string{"ignored"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, namespacefilter.Filter{IgnoreNamespaces: []string{"ignored"}}, filter)
+
+	filter, err = namespacefilter.New(nil, &metav1.LabelSelector{MatchLabels: map[string]string{"spire.spiffe.io/enabled": "true"}})
+	require.NoError(t, err)
+	require.True(t, filter.Selector.Matches(labels.Set{"spire.spiffe.io/enabled": "true"}))
+	require.False(t, filter.Selector.Matches(labels.Set{}))
+
+	_, err = namespacefilter.New(nil, &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "key", Operator: "bad"}}})
+	require.ErrorContains(t, err, "invalid namespace selector")
+}
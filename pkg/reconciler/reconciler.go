@@ -19,8 +19,11 @@ package reconciler
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -29,16 +32,45 @@ type Triggerer interface {
 	Trigger()
 }
 
+// Triggerers triggers each of several Triggerers, e.g. the reconcilers of
+// several SPIRE Servers that the same Kubernetes objects are reconciled
+// against.
+type Triggerers []Triggerer
+
+func (ts Triggerers) Trigger() {
+	for _, t := range ts {
+		t.Trigger()
+	}
+}
+
 type Reconciler interface {
 	Trigger()
 	Run(ctx context.Context) error
+
+	// Synced returns true once a reconciliation has completed without
+	// error.
+	Synced() bool
+
+	// SetGCInterval changes how long to sit idle before doing a periodic
+	// reconciliation. It takes effect once the current wait is over, or
+	// sooner if the reconciler is triggered.
+	SetGCInterval(gcInterval time.Duration)
 }
 
 type Config struct {
-	Kind       string
-	Reconcile  func(ctx context.Context)
+	Kind string
+
+	// Reconcile does a reconciliation pass. It returns an error if the
+	// pass could not be completed.
+	Reconcile  func(ctx context.Context) error
 	GCInterval time.Duration
 	Clock      clock.Clock
+
+	// BatchWindow, if non-zero, is how long to wait after a trigger before
+	// reconciling. Triggers received during the window are folded into the
+	// same reconciliation so that bursts of changes (e.g. a GitOps sync
+	// touching many resources) are handled in a single pass.
+	BatchWindow time.Duration
 }
 
 func New(config Config) Reconciler {
@@ -46,20 +78,44 @@ func New(config Config) Reconciler {
 		config.Clock = clock.RealClock{}
 	}
 	return &reconciler{
-		kind:       config.Kind,
-		reconcile:  config.Reconcile,
-		gcInterval: config.GCInterval,
-		clock:      config.Clock,
-		triggerCh:  make(chan struct{}),
+		kind:        config.Kind,
+		reconcile:   config.Reconcile,
+		gcInterval:  config.GCInterval,
+		batchWindow: config.BatchWindow,
+		clock:       config.Clock,
+		// The trigger channel is buffered so that a trigger received while
+		// reconciling is not lost but instead causes exactly one follow-up
+		// reconciliation.
+		triggerCh: make(chan struct{}, 1),
 	}
 }
 
 type reconciler struct {
-	kind       string
-	reconcile  func(ctx context.Context)
+	kind        string
+	reconcile   func(ctx context.Context) error
+	batchWindow time.Duration
+	clock       clock.Clock
+	triggerCh   chan struct{}
+	synced      atomic.Bool
+
+	mtx        sync.Mutex
 	gcInterval time.Duration
-	clock      clock.Clock
-	triggerCh  chan struct{}
+}
+
+func (r *reconciler) SetGCInterval(gcInterval time.Duration) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.gcInterval = gcInterval
+}
+
+func (r *reconciler) Synced() bool {
+	return r.synced.Load()
+}
+
+func (r *reconciler) getGCInterval() time.Duration {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.gcInterval
 }
 
 func (r *reconciler) Trigger() {
@@ -81,16 +137,21 @@ func (r *reconciler) Run(ctx context.Context) error {
 	var timer clock.Timer
 	for {
 		log.V(2).Info("Starting reconciliation")
-		r.reconcile(ctx)
+		start := r.clock.Now()
+		if err := r.reconcile(ctx); err == nil {
+			r.synced.Store(true)
+		}
+		metrics.ReconcileDuration.WithLabelValues(r.kind).Observe(r.clock.Since(start).Seconds())
+		metrics.ReconcileLastTimestamp.WithLabelValues(r.kind).Set(float64(r.clock.Now().Unix()))
 		log.V(2).Info("Reconciliation finished")
 
 		log.V(2).Info("Waiting for next reconciliation")
 
 		if timer == nil {
-			timer = r.clock.NewTimer(r.gcInterval)
+			timer = r.clock.NewTimer(r.getGCInterval())
 			defer timer.Stop()
 		} else {
-			timer.Reset(r.gcInterval)
+			timer.Reset(r.getGCInterval())
 		}
 
 		select {
@@ -100,11 +161,35 @@ func (r *reconciler) Run(ctx context.Context) error {
 		case <-timer.C():
 			log.V(2).Info("Performing periodic reconciliation")
 		case <-r.triggerCh:
+			if err := r.waitForBatch(ctx); err != nil {
+				log.Info("Reconciliation canceled")
+				return err
+			}
 			log.V(2).Info("Performing triggered reconciliation")
 		}
 	}
 }
 
+// waitForBatch waits out the batch window, absorbing any triggers that
+// arrive in the meantime.
+func (r *reconciler) waitForBatch(ctx context.Context) error {
+	if r.batchWindow <= 0 {
+		return nil
+	}
+	log.FromContext(ctx).V(2).Info("Waiting for batch window", "window", r.batchWindow)
+	timer := r.clock.NewTimer(r.batchWindow)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C():
+			return nil
+		case <-r.triggerCh:
+		}
+	}
+}
+
 func (r *reconciler) drain() {
 	select {
 	case <-r.triggerCh:
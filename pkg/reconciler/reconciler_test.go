@@ -42,7 +42,7 @@ func TestReconciler(t *testing.T) {
 	}
 	r := reconciler.New(reconciler.Config{
 		Kind: "test",
-		Reconcile: func(ctx context.Context) {
+		Reconcile: func(ctx context.Context) error {
 			t.Log("Reconcile called")
 			select {
 			case <-ctx.Done():
@@ -50,6 +50,7 @@ func TestReconciler(t *testing.T) {
 			case calledCh <- struct{}{}:
 				t.Log("Indicated that reconcile was called")
 			}
+			return nil
 		},
 		GCInterval: time.Second,
 		Clock:      clock,
@@ -87,3 +88,160 @@ func TestReconciler(t *testing.T) {
 	t.Log("Wait until the trigger reconcile call")
 	require.Eventually(t, checkIfCalled, time.Minute, time.Millisecond*10)
 }
+
+func TestReconcilerBatchWindow(t *testing.T) {
+	clock := new(testclock.FakeClock)
+
+	calledCh := make(chan struct{})
+	checkIfCalled := func() bool {
+		select {
+		case <-calledCh:
+			return true
+		default:
+			return false
+		}
+	}
+	r := reconciler.New(reconciler.Config{
+		Kind: "test",
+		Reconcile: func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+			case calledCh <- struct{}{}:
+			}
+			return nil
+		},
+		GCInterval:  time.Hour,
+		BatchWindow: time.Second,
+		Clock:       clock,
+	})
+
+	errCh := make(chan error)
+	t.Cleanup(func() {
+		err := <-errCh
+		assert.True(t, errors.Is(err, context.Canceled), "expected canceled error; got %f", err)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		errCh <- r.Run(ctx)
+	}()
+
+	t.Log("Wait until the initial reconcile call")
+	require.Eventually(t, checkIfCalled, time.Minute, time.Millisecond*10)
+
+	t.Log("Wait until run is waiting")
+	require.Eventually(t, clock.HasWaiters, time.Minute, time.Millisecond*10)
+
+	t.Log("Trigger reconciliation a few times")
+	r.Trigger()
+	r.Trigger()
+	r.Trigger()
+
+	t.Log("Assert reconcile is deferred until the batch window elapses")
+	require.Never(t, checkIfCalled, time.Millisecond*100, time.Millisecond*10)
+
+	t.Log("Step the clock past the batch window")
+	clock.Step(time.Second)
+
+	t.Log("Wait until the batched reconcile call")
+	require.Eventually(t, checkIfCalled, time.Minute, time.Millisecond*10)
+}
+
+func TestReconcilerSetGCInterval(t *testing.T) {
+	clock := new(testclock.FakeClock)
+
+	calledCh := make(chan struct{})
+	checkIfCalled := func() bool {
+		select {
+		case <-calledCh:
+			return true
+		default:
+			return false
+		}
+	}
+	r := reconciler.New(reconciler.Config{
+		Kind: "test",
+		Reconcile: func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+			case calledCh <- struct{}{}:
+			}
+			return nil
+		},
+		GCInterval: time.Hour,
+		Clock:      clock,
+	})
+
+	errCh := make(chan error)
+	t.Cleanup(func() {
+		err := <-errCh
+		assert.True(t, errors.Is(err, context.Canceled), "expected canceled error; got %f", err)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		errCh <- r.Run(ctx)
+	}()
+
+	t.Log("Wait until the initial reconcile call")
+	require.Eventually(t, checkIfCalled, time.Minute, time.Millisecond*10)
+
+	t.Log("Wait until run is waiting")
+	require.Eventually(t, clock.HasWaiters, time.Minute, time.Millisecond*10)
+
+	t.Log("Shorten the GC interval and trigger reconciliation so it takes effect")
+	r.SetGCInterval(time.Second)
+	r.Trigger()
+	require.Eventually(t, checkIfCalled, time.Minute, time.Millisecond*10)
+
+	t.Log("Wait until run is waiting")
+	require.Eventually(t, clock.HasWaiters, time.Minute, time.Millisecond*10)
+
+	t.Log("Step the clock by the new GC interval")
+	clock.Step(time.Second)
+
+	t.Log("Wait until the GC reconcile call")
+	require.Eventually(t, checkIfCalled, time.Minute, time.Millisecond*10)
+}
+
+func TestReconcilerSynced(t *testing.T) {
+	clock := new(testclock.FakeClock)
+
+	resultCh := make(chan error)
+	r := reconciler.New(reconciler.Config{
+		Kind: "test",
+		Reconcile: func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case err := <-resultCh:
+				return err
+			}
+		},
+		GCInterval: time.Hour,
+		Clock:      clock,
+	})
+
+	errCh := make(chan error)
+	t.Cleanup(func() {
+		err := <-errCh
+		assert.True(t, errors.Is(err, context.Canceled), "expected canceled error; got %f", err)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		errCh <- r.Run(ctx)
+	}()
+
+	require.False(t, r.Synced())
+
+	t.Log("Fail the initial reconcile")
+	resultCh <- errors.New("oh no")
+	require.Eventually(t, clock.HasWaiters, time.Minute, time.Millisecond*10)
+	require.False(t, r.Synced())
+
+	t.Log("Trigger a reconcile that succeeds")
+	r.Trigger()
+	resultCh <- nil
+	require.Eventually(t, r.Synced, time.Minute, time.Millisecond*10)
+}
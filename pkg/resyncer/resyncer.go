@@ -0,0 +1,63 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resyncer triggers a full resync when the process is signalled.
+package resyncer
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Resyncer triggers every reconciler when the process receives a resync
+// signal, so that SPIRE state can be converged right away, e.g. after it was
+// modified out from underneath the controller, without waiting for the GC
+// interval.
+type Resyncer struct {
+	Triggerer reconciler.Triggerer
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// replica handles the signal, since otherwise it would terminate the
+// replicas that are not the leader.
+func (r *Resyncer) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *Resyncer) Start(ctx context.Context) error {
+	if len(resyncSignals) == 0 {
+		return nil
+	}
+	log := log.FromContext(ctx).WithName("resyncer")
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, resyncSignals...)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig := <-signals:
+			log.Info("Received resync signal; triggering a full resync", "signal", sig)
+			r.Triggerer.Trigger()
+		}
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spireagent garbage collects the SPIRE agents of the nodes that
+// have been removed from the cluster.
+package spireagent
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/spiffe/spire-controller-manager/pkg/spireentry"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	agentIDLogKey = "agentID"
+	actionLogKey  = "action"
+
+	kind = "agent gc"
+)
+
+type ReconcilerConfig struct {
+	TrustDomain spiffeid.TrustDomain
+	ClusterName string
+	AgentClient spireapi.AgentClient
+	K8sClient   client.Client
+
+	// Delay is how long the node of an agent must have been gone before the
+	// agent is collected, so that agents of nodes that are being replaced
+	// under the same UID, or that are briefly missing, are left in place.
+	Delay time.Duration
+
+	// Ban, if true, bans the agents of removed nodes instead of deleting
+	// them, so that they cannot attest again.
+	Ban bool
+
+	// DryRun, if true, logs the agents that would be collected instead of
+	// collecting them.
+	DryRun bool
+
+	// GCInterval how long to sit idle (i.e. untriggered) before doing
+	// another reconcile.
+	GCInterval time.Duration
+
+	// Clock is used to time the delay. Defaults to the real clock.
+	Clock clock.Clock
+}
+
+func Reconciler(config ReconcilerConfig) reconciler.Reconciler {
+	if config.Clock == nil {
+		config.Clock = clock.RealClock{}
+	}
+	r := &agentReconciler{
+		config:       config,
+		removedSince: make(map[spiffeid.ID]time.Time),
+	}
+	return reconciler.New(reconciler.Config{
+		Kind:       kind,
+		Reconcile:  r.reconcile,
+		GCInterval: config.GCInterval,
+	})
+}
+
+type agentReconciler struct {
+	config ReconcilerConfig
+
+	// removedSince holds when the node of each agent was first observed to
+	// be gone, by agent ID.
+	removedSince map[spiffeid.ID]time.Time
+}
+
+func (r *agentReconciler) reconcile(ctx context.Context) error {
+	log := log.FromContext(ctx)
+
+	var nodes corev1.NodeList
+	if err := r.config.K8sClient.List(ctx, &nodes); err != nil {
+		log.Error(err, "Failed to list nodes")
+		return err
+	}
+	// A cluster always has nodes. Collecting every agent because the nodes
+	// failed to be listed would be far worse than collecting none.
+	if len(nodes.Items) == 0 {
+		log.Info("No nodes listed; not collecting agents")
+		return nil
+	}
+	nodeUIDs := make(map[string]struct{}, len(nodes.Items))
+	for _, node := range nodes.Items {
+		nodeUIDs[string(node.UID)] = struct{}{}
+	}
+
+	agents, err := r.config.AgentClient.ListAgents(ctx)
+	if err != nil {
+		metrics.SPIREAPIErrors.WithLabelValues("ListAgents").Inc()
+		log.Error(err, "Failed to list SPIRE agents")
+		return err
+	}
+
+	now := r.config.Clock.Now()
+	removedSince := make(map[spiffeid.ID]time.Time)
+	var toCollect []spireapi.Agent
+	for _, agent := range agents {
+		nodeUID, ok := r.nodeUID(agent)
+		if !ok || agent.Banned {
+			continue
+		}
+		if _, ok := nodeUIDs[nodeUID]; ok {
+			continue
+		}
+		since, ok := r.removedSince[agent.ID]
+		if !ok {
+			since = now
+			if r.config.Delay > 0 {
+				log.Info("Deferring collection of agent of removed node", agentIDLogKey, agent.ID)
+			}
+		}
+		if now.Sub(since) >= r.config.Delay {
+			toCollect = append(toCollect, agent)
+		}
+		removedSince[agent.ID] = since
+	}
+	r.removedSince = removedSince
+
+	action := r.action()
+	if r.config.DryRun {
+		for _, agent := range toCollect {
+			log.Info("Dry run: would collect agent of removed node", agentIDLogKey, agent.ID, actionLogKey, action)
+		}
+		metrics.DryRunChanges.WithLabelValues(kind, action).Set(float64(len(toCollect)))
+		return nil
+	}
+
+	for _, agent := range toCollect {
+		log := log.WithValues(agentIDLogKey, agent.ID, actionLogKey, action)
+		if err := r.collect(ctx, agent); err != nil {
+			log.Error(err, "Failed to collect agent of removed node")
+			continue
+		}
+		delete(r.removedSince, agent.ID)
+		metrics.AgentsCollected.WithLabelValues(action).Inc()
+		log.Info("Collected agent of removed node")
+	}
+	return nil
+}
+
+// nodeUID returns the UID of the node of an agent attested with the k8s_psat
+// node attestor in the cluster, which is the last segment of the agent ID.
+func (r *agentReconciler) nodeUID(agent spireapi.Agent) (string, bool) {
+	if agent.ID.TrustDomain() != r.config.TrustDomain {
+		return "", false
+	}
+	nodeUID, ok := strings.CutPrefix(agent.ID.Path(), spireentry.ClusterAgentPathPrefix(r.config.ClusterName))
+	if !ok || nodeUID == "" || strings.Contains(nodeUID, "/") {
+		return "", false
+	}
+	return nodeUID, true
+}
+
+func (r *agentReconciler) action() string {
+	if r.config.Ban {
+		return metrics.OperationBan
+	}
+	return metrics.OperationDelete
+}
+
+func (r *agentReconciler) collect(ctx context.Context, agent spireapi.Agent) error {
+	if r.config.Ban {
+		if err := r.config.AgentClient.BanAgent(ctx, agent.ID); err != nil {
+			metrics.SPIREAPIErrors.WithLabelValues("BanAgent").Inc()
+			return err
+		}
+		return nil
+	}
+	if err := r.config.AgentClient.DeleteAgent(ctx, agent.ID); err != nil {
+		metrics.SPIREAPIErrors.WithLabelValues("DeleteAgent").Inc()
+		return err
+	}
+	return nil
+}
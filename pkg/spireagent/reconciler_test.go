@@ -0,0 +1,192 @@
+package spireagent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/spiffe/spire-controller-manager/pkg/test/k8stest"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var (
+	ctx = context.Background()
+	td  = spiffeid.RequireTrustDomainFromString("domain.test")
+)
+
+func TestReconcile(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "present"}}
+	present := newAgent(td, "/spire/agent/k8s_psat/cluster/present")
+	removed := newAgent(td, "/spire/agent/k8s_psat/cluster/removed")
+	banned := newAgent(td, "/spire/agent/k8s_psat/cluster/banned")
+	banned.Banned = true
+	otherCluster := newAgent(td, "/spire/agent/k8s_psat/other/removed")
+	otherAttestor := newAgent(td, "/spire/agent/join_token/removed")
+	otherTrustDomain := newAgent(spiffeid.RequireTrustDomainFromString("other.test"), "/spire/agent/k8s_psat/cluster/removed")
+	agents := []spireapi.Agent{present, removed, banned, otherCluster, otherAttestor, otherTrustDomain}
+
+	for _, tt := range []struct {
+		desc         string
+		ban          bool
+		dryRun       bool
+		expectAgents []spireapi.Agent
+	}{
+		{
+			desc:         "delete",
+			expectAgents: []spireapi.Agent{present, banned, otherCluster, otherAttestor, otherTrustDomain},
+		},
+		{
+			desc:         "ban",
+			ban:          true,
+			expectAgents: []spireapi.Agent{present, withBanned(removed), banned, otherCluster, otherAttestor, otherTrustDomain},
+		},
+		{
+			desc:         "dry run",
+			dryRun:       true,
+			expectAgents: agents,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			agentClient := newAgentClient(agents...)
+			clk := clocktesting.NewFakeClock(time.Now())
+			r := &agentReconciler{
+				config: ReconcilerConfig{
+					TrustDomain: td,
+					ClusterName: "cluster",
+					AgentClient: agentClient,
+					K8sClient:   k8stest.NewClientBuilder(t).WithObjects(node).Build(),
+					Delay:       time.Minute,
+					Ban:         tt.ban,
+					DryRun:      tt.dryRun,
+					Clock:       clk,
+				},
+				removedSince: make(map[spiffeid.ID]time.Time),
+			}
+
+			// Agents are not collected until their node has been gone for
+			// the delay.
+			require.NoError(t, r.reconcile(ctx))
+			require.Equal(t, agents, agentClient.getAgents())
+			clk.Step(time.Minute - time.Second)
+			require.NoError(t, r.reconcile(ctx))
+			require.Equal(t, agents, agentClient.getAgents())
+
+			clk.Step(time.Second)
+			require.NoError(t, r.reconcile(ctx))
+			require.Equal(t, tt.expectAgents, agentClient.getAgents())
+		})
+	}
+}
+
+func TestReconcileRestartsDelayWhenNodeReturns(t *testing.T) {
+	agent := newAgent(td, "/spire/agent/k8s_psat/cluster/node")
+	other := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "other", UID: "other"}}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "node"}}
+	k8sClient := k8stest.NewClientBuilder(t).WithObjects(other).Build()
+	agentClient := newAgentClient(agent)
+	clk := clocktesting.NewFakeClock(time.Now())
+	r := &agentReconciler{
+		config: ReconcilerConfig{
+			TrustDomain: td,
+			ClusterName: "cluster",
+			AgentClient: agentClient,
+			K8sClient:   k8sClient,
+			Delay:       time.Minute,
+			Clock:       clk,
+		},
+		removedSince: make(map[spiffeid.ID]time.Time),
+	}
+
+	require.NoError(t, r.reconcile(ctx))
+	clk.Step(30 * time.Second)
+	require.NoError(t, k8sClient.Create(ctx, node))
+	require.NoError(t, r.reconcile(ctx))
+	require.NoError(t, k8sClient.Delete(ctx, node))
+	clk.Step(30 * time.Second)
+	require.NoError(t, r.reconcile(ctx))
+	require.Equal(t, []spireapi.Agent{agent}, agentClient.getAgents())
+
+	clk.Step(time.Minute)
+	require.NoError(t, r.reconcile(ctx))
+	require.Empty(t, agentClient.getAgents())
+}
+
+func TestReconcileWithoutNodes(t *testing.T) {
+	agent := newAgent(td, "/spire/agent/k8s_psat/cluster/node")
+	agentClient := newAgentClient(agent)
+	r := &agentReconciler{
+		config: ReconcilerConfig{
+			TrustDomain: td,
+			ClusterName: "cluster",
+			AgentClient: agentClient,
+			K8sClient:   k8stest.NewClientBuilder(t).Build(),
+			Clock:       clocktesting.NewFakeClock(time.Now()),
+		},
+		removedSince: make(map[spiffeid.ID]time.Time),
+	}
+
+	require.NoError(t, r.reconcile(ctx))
+	require.Equal(t, []spireapi.Agent{agent}, agentClient.getAgents())
+}
+
+func newAgent(td spiffeid.TrustDomain, path string) spireapi.Agent {
+	return spireapi.Agent{ID: spiffeid.RequireFromPath(td, path), AttestationType: "k8s_psat"}
+}
+
+func withBanned(agent spireapi.Agent) spireapi.Agent {
+	agent.Banned = true
+	return agent
+}
+
+type agentClient struct {
+	agents map[spiffeid.ID]spireapi.Agent
+	order  []spiffeid.ID
+}
+
+func newAgentClient(agents ...spireapi.Agent) *agentClient {
+	c := &agentClient{agents: make(map[spiffeid.ID]spireapi.Agent)}
+	for _, agent := range agents {
+		c.agents[agent.ID] = agent
+		c.order = append(c.order, agent.ID)
+	}
+	return c
+}
+
+func (c *agentClient) ListAgents(context.Context) ([]spireapi.Agent, error) {
+	return c.getAgents(), nil
+}
+
+func (c *agentClient) DeleteAgent(_ context.Context, id spiffeid.ID) error {
+	if _, ok := c.agents[id]; !ok {
+		return errors.New("agent not found")
+	}
+	delete(c.agents, id)
+	return nil
+}
+
+func (c *agentClient) BanAgent(_ context.Context, id spiffeid.ID) error {
+	agent, ok := c.agents[id]
+	if !ok {
+		return errors.New("agent not found")
+	}
+	agent.Banned = true
+	c.agents[id] = agent
+	return nil
+}
+
+// getAgents returns the agents in the order they were added.
+func (c *agentClient) getAgents() []spireapi.Agent {
+	var out []spireapi.Agent
+	for _, id := range c.order {
+		if agent, ok := c.agents[id]; ok {
+			out = append(out, agent)
+		}
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	agentv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	apitypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/grpc"
+)
+
+type Agent struct {
+	// ID is the SPIFFE ID of the agent.
+	ID spiffeid.ID
+
+	// AttestationType is the node attestor the agent attested with.
+	AttestationType string
+
+	// Banned is true if the agent is banned.
+	Banned bool
+}
+
+type AgentClient interface {
+	// ListAgents lists the agents attested to the SPIRE server
+	ListAgents(ctx context.Context) ([]Agent, error)
+
+	// DeleteAgent evicts the agent. The agent can attest again, e.g. if the
+	// node comes back.
+	DeleteAgent(ctx context.Context, id spiffeid.ID) error
+
+	// BanAgent bans the agent. The agent cannot attest again with the same
+	// ID until the ban is lifted by deleting the agent.
+	BanAgent(ctx context.Context, id spiffeid.ID) error
+}
+
+func NewAgentClient(conn grpc.ClientConnInterface) AgentClient {
+	return agentClient{api: agentv1.NewAgentClient(conn)}
+}
+
+type agentClient struct {
+	api agentv1.AgentClient
+}
+
+func (c agentClient) ListAgents(ctx context.Context) ([]Agent, error) {
+	var agents []Agent
+	var pageToken string
+	for {
+		resp, err := c.api.ListAgents(ctx, &agentv1.ListAgentsRequest{
+			OutputMask: &apitypes.AgentMask{AttestationType: true, Banned: true},
+			PageToken:  pageToken,
+			PageSize:   int32(agentListPageSize),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list agents: %w", err)
+		}
+		for _, apiAgent := range resp.Agents {
+			agent, err := agentFromAPI(apiAgent)
+			if err != nil {
+				return nil, err
+			}
+			agents = append(agents, agent)
+		}
+		pageToken = resp.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+	return agents, nil
+}
+
+func (c agentClient) DeleteAgent(ctx context.Context, id spiffeid.ID) error {
+	if _, err := c.api.DeleteAgent(ctx, &agentv1.DeleteAgentRequest{Id: spiffeIDToAPI(id)}); err != nil {
+		return fmt.Errorf("failed to delete agent: %w", err)
+	}
+	return nil
+}
+
+func (c agentClient) BanAgent(ctx context.Context, id spiffeid.ID) error {
+	if _, err := c.api.BanAgent(ctx, &agentv1.BanAgentRequest{Id: spiffeIDToAPI(id)}); err != nil {
+		return fmt.Errorf("failed to ban agent: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package spireapi
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	agentv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	apitypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+var (
+	agent1 = Agent{ID: spiffeid.RequireFromPath(domain1, "/spire/agent/k8s_psat/cluster/node1"), AttestationType: "k8s_psat"}
+	agent2 = Agent{ID: spiffeid.RequireFromPath(domain1, "/spire/agent/k8s_psat/cluster/node2"), AttestationType: "k8s_psat", Banned: true}
+	agent3 = Agent{ID: spiffeid.RequireFromPath(domain1, "/spire/agent/k8s_psat/cluster/node3"), AttestationType: "k8s_psat"}
+)
+
+func init() {
+	agentListPageSize = 2
+}
+
+func TestAgentAPIListAgents(t *testing.T) {
+	server, client := startAgentAPIServer(t)
+
+	for _, tc := range []struct {
+		desc         string
+		withAgents   []Agent
+		expectAgents []Agent
+		expectErr    error
+	}{
+		{
+			desc:      "error",
+			expectErr: status.Error(codes.Internal, "oh no"),
+		},
+		{
+			desc: "empty",
+		},
+		{
+			desc:         "less than a page",
+			withAgents:   []Agent{agent1},
+			expectAgents: []Agent{agent1},
+		},
+		{
+			desc:         "more than a page",
+			withAgents:   []Agent{agent1, agent2, agent3},
+			expectAgents: []Agent{agent1, agent2, agent3},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			server.setAgents(tc.withAgents...)
+			server.listAgentsErr = tc.expectErr
+			agents, err := client.ListAgents(ctx)
+			if tc.expectErr != nil {
+				assertErrorIs(t, err, tc.expectErr)
+				assert.Nil(t, agents)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectAgents, agents)
+		})
+	}
+}
+
+func TestAgentAPIDeleteAgent(t *testing.T) {
+	server, client := startAgentAPIServer(t)
+	server.setAgents(agent1, agent2)
+
+	require.NoError(t, client.DeleteAgent(ctx, agent1.ID))
+	assert.Equal(t, []Agent{agent2}, server.getAgents())
+
+	err := client.DeleteAgent(ctx, agent1.ID)
+	assertErrorIs(t, err, status.Error(codes.NotFound, "agent not found"))
+}
+
+func TestAgentAPIBanAgent(t *testing.T) {
+	server, client := startAgentAPIServer(t)
+	server.setAgents(agent1)
+
+	require.NoError(t, client.BanAgent(ctx, agent1.ID))
+	banned := agent1
+	banned.Banned = true
+	assert.Equal(t, []Agent{banned}, server.getAgents())
+
+	err := client.BanAgent(ctx, agent2.ID)
+	assertErrorIs(t, err, status.Error(codes.NotFound, "agent not found"))
+}
+
+func startAgentAPIServer(t *testing.T) (*agentServer, AgentClient) {
+	api := &agentServer{}
+	conn := startServer(t, func(s *grpc.Server) {
+		agentv1.RegisterAgentServer(s, api)
+	})
+	return api, NewAgentClient(conn)
+}
+
+type agentServer struct {
+	agentv1.UnimplementedAgentServer
+
+	mtx    sync.RWMutex
+	agents []*apitypes.Agent
+
+	listAgentsErr error
+}
+
+func (s *agentServer) ListAgents(ctx context.Context, req *agentv1.ListAgentsRequest) (*agentv1.ListAgentsResponse, error) {
+	if s.listAgentsErr != nil {
+		return nil, s.listAgentsErr
+	}
+
+	resp := new(agentv1.ListAgentsResponse)
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	start, end, more := listBounds(req.PageToken, int(req.PageSize), len(s.agents), func(i int) string { return s.agents[i].Id.Path })
+	for _, agent := range s.agents[start:end] {
+		resp.Agents = append(resp.Agents, agent)
+		if more {
+			resp.NextPageToken = agent.Id.Path
+		}
+	}
+	return resp, nil
+}
+
+func (s *agentServer) DeleteAgent(ctx context.Context, req *agentv1.DeleteAgentRequest) (*emptypb.Empty, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for i, agent := range s.agents {
+		if agent.Id.Path == req.Id.Path {
+			s.agents = append(s.agents[:i], s.agents[i+1:]...)
+			return &emptypb.Empty{}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "agent not found")
+}
+
+func (s *agentServer) BanAgent(ctx context.Context, req *agentv1.BanAgentRequest) (*emptypb.Empty, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, agent := range s.agents {
+		if agent.Id.Path == req.Id.Path {
+			agent.Banned = true
+			return &emptypb.Empty{}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "agent not found")
+}
+
+func (s *agentServer) setAgents(agents ...Agent) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.agents = nil
+	for _, agent := range agents {
+		s.agents = append(s.agents, &apitypes.Agent{
+			Id:              spiffeIDToAPI(agent.ID),
+			AttestationType: agent.AttestationType,
+			Banned:          agent.Banned,
+		})
+	}
+}
+
+func (s *agentServer) getAgents() []Agent {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var out []Agent
+	for _, agent := range s.agents {
+		out = append(out, Agent{
+			ID:              spiffeid.RequireFromPath(spiffeid.RequireTrustDomainFromString(agent.Id.TrustDomain), agent.Id.Path),
+			AttestationType: agent.AttestationType,
+			Banned:          agent.Banned,
+		})
+	}
+	return out
+}
@@ -16,6 +16,19 @@ limitations under the License.
 
 package spireapi
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"golang.org/x/time/rate"
+)
+
+// errBatchFailed stops running batches once a concurrent batch has failed.
+var errBatchFailed = errors.New("batch failed")
+
 var (
 	// TODO: optimize batch/page sizes
 	// These batch sizes are vars so they can be adjusted during tests.
@@ -29,6 +42,11 @@ var (
 	federationRelationshipUpdateBatchSize = 50
 	federationRelationshipDeleteBatchSize = 200
 	federationRelationshipListPageSize    = 200
+
+	federatedBundleDeleteBatchSize = 200
+	federatedBundleListPageSize    = 200
+
+	agentListPageSize = 200
 )
 
 func runBatch(size, batch int, fn func(start, end int) error) error {
@@ -48,3 +66,58 @@ func runBatch(size, batch int, fn func(start, end int) error) error {
 	}
 	return nil
 }
+
+// runConcurrentBatches is like runBatch, but runs up to concurrency batches
+// at once. Once a batch fails, no further batches are started and the first
+// error is returned.
+func runConcurrentBatches(size, batch, concurrency int, fn func(start, end int) error) error {
+	if concurrency <= 1 {
+		return runBatch(size, batch, fn)
+	}
+
+	var wg sync.WaitGroup
+	var mtx sync.Mutex
+	var firstErr error
+	sem := make(chan struct{}, concurrency)
+	_ = runBatch(size, batch, func(start, end int) error {
+		sem <- struct{}{}
+		mtx.Lock()
+		failed := firstErr != nil
+		mtx.Unlock()
+		if failed {
+			<-sem
+			return errBatchFailed
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(start, end); err != nil {
+				mtx.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mtx.Unlock()
+			}
+		}()
+		return nil
+	})
+	wg.Wait()
+	return firstErr
+}
+
+// waitForRateLimit waits until the limiter allows another call, counting the
+// call as throttled if it had to wait. A nil limiter allows all calls.
+func waitForRateLimit(ctx context.Context, limiter *rate.Limiter, call string) error {
+	if limiter == nil || limiter.Allow() {
+		return nil
+	}
+	metrics.SPIREAPIThrottledCalls.WithLabelValues(call).Inc()
+	return limiter.Wait(ctx)
+}
+
+func unexpectedResultCount(expected, actual int) error {
+	return fmt.Errorf("expected %d results but got %d", expected, actual)
+}
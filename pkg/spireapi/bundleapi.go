@@ -21,13 +21,27 @@ import (
 	"fmt"
 
 	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
 	"google.golang.org/grpc"
 )
 
 type BundleClient interface {
-	// MintX509SVID mints an X509-SVID
+	// GetBundle gets the bundle for the trust domain of the SPIRE server
 	GetBundle(ctx context.Context) (*spiffebundle.Bundle, error)
+
+	// GetFederatedBundle gets the bundle the SPIRE server holds for the
+	// federated trust domain
+	GetFederatedBundle(ctx context.Context, td spiffeid.TrustDomain) (*spiffebundle.Bundle, error)
+
+	// ListFederatedBundles lists the bundles the SPIRE server holds for
+	// federated trust domains
+	ListFederatedBundles(ctx context.Context) ([]*spiffebundle.Bundle, error)
+
+	// DeleteFederatedBundles deletes the federated bundles for the trust
+	// domains. Entries that federate with the trust domains are updated to
+	// no longer federate with them.
+	DeleteFederatedBundles(ctx context.Context, tds []spiffeid.TrustDomain) ([]Status, error)
 }
 
 func NewBundleClient(conn grpc.ClientConnInterface) BundleClient {
@@ -46,3 +60,55 @@ func (c bundleClient) GetBundle(ctx context.Context) (*spiffebundle.Bundle, erro
 
 	return bundleFromAPI(bundle)
 }
+
+func (c bundleClient) GetFederatedBundle(ctx context.Context, td spiffeid.TrustDomain) (*spiffebundle.Bundle, error) {
+	bundle, err := c.api.GetFederatedBundle(ctx, &bundlev1.GetFederatedBundleRequest{TrustDomain: td.Name()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get federated bundle: %w", err)
+	}
+
+	return bundleFromAPI(bundle)
+}
+
+func (c bundleClient) ListFederatedBundles(ctx context.Context) ([]*spiffebundle.Bundle, error) {
+	var bundles []*spiffebundle.Bundle
+	var pageToken string
+	for {
+		resp, err := c.api.ListFederatedBundles(ctx, &bundlev1.ListFederatedBundlesRequest{
+			PageToken: pageToken,
+			PageSize:  int32(federatedBundleListPageSize),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list federated bundles: %w", err)
+		}
+		for _, apiBundle := range resp.Bundles {
+			bundle, err := bundleFromAPI(apiBundle)
+			if err != nil {
+				return nil, err
+			}
+			bundles = append(bundles, bundle)
+		}
+		pageToken = resp.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+	return bundles, nil
+}
+
+func (c bundleClient) DeleteFederatedBundles(ctx context.Context, tds []spiffeid.TrustDomain) ([]Status, error) {
+	var statuses []Status
+	err := runBatch(len(tds), federatedBundleDeleteBatchSize, func(start, end int) error {
+		resp, err := c.api.BatchDeleteFederatedBundle(ctx, &bundlev1.BatchDeleteFederatedBundleRequest{
+			TrustDomains: trustDomainsToAPI(tds[start:end]),
+			Mode:         bundlev1.BatchDeleteFederatedBundleRequest_DISSOCIATE,
+		})
+		if err == nil {
+			for _, result := range resp.Results {
+				statuses = append(statuses, statusFromAPI(result.Status))
+			}
+		}
+		return err
+	})
+	return statuses, err
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
 	apitypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +20,11 @@ var (
 	bundleNotAvailable = status.Errorf(codes.Unavailable, "bundle not available")
 )
 
+func init() {
+	federatedBundleDeleteBatchSize = 2
+	federatedBundleListPageSize = 2
+}
+
 func TestBundleAPIGetBundle(t *testing.T) {
 	server, client := startBundleAPIServer(t)
 
@@ -58,6 +64,121 @@ func TestBundleAPIGetBundle(t *testing.T) {
 	}
 }
 
+func TestBundleAPIGetFederatedBundle(t *testing.T) {
+	server, client := startBundleAPIServer(t)
+	server.setFederatedBundles(domain1)
+
+	bundle, err := client.GetFederatedBundle(ctx, domain1)
+	require.NoError(t, err)
+	assert.Equal(t, domain1, bundle.TrustDomain())
+
+	bundle, err = client.GetFederatedBundle(ctx, domain2)
+	assertErrorIs(t, err, status.Errorf(codes.NotFound, "federated bundle %q not found", domain2.Name()))
+	assert.Nil(t, bundle)
+}
+
+func TestBundleAPIListFederatedBundles(t *testing.T) {
+	server, client := startBundleAPIServer(t)
+
+	for _, tc := range []struct {
+		desc          string
+		withBundles   []spiffeid.TrustDomain
+		expectBundles []spiffeid.TrustDomain
+		expectErr     error
+	}{
+		{
+			desc:      "error",
+			expectErr: status.Error(codes.Internal, "oh no"),
+		},
+		{
+			desc: "empty",
+		},
+		{
+			desc:          "less than a page",
+			withBundles:   []spiffeid.TrustDomain{domain1},
+			expectBundles: []spiffeid.TrustDomain{domain1},
+		},
+		{
+			desc:          "more than a page",
+			withBundles:   []spiffeid.TrustDomain{domain1, domain2, domain3},
+			expectBundles: []spiffeid.TrustDomain{domain1, domain2, domain3},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			server.setFederatedBundles(tc.withBundles...)
+			server.listFederatedBundlesErr = tc.expectErr
+			bundles, err := client.ListFederatedBundles(ctx)
+			if tc.expectErr != nil {
+				assertErrorIs(t, err, tc.expectErr)
+				assert.Nil(t, bundles)
+				return
+			}
+			require.NoError(t, err)
+			var actualBundles []spiffeid.TrustDomain
+			for _, bundle := range bundles {
+				actualBundles = append(actualBundles, bundle.TrustDomain())
+			}
+			assert.Equal(t, tc.expectBundles, actualBundles)
+		})
+	}
+}
+
+func TestBundleAPIDeleteFederatedBundles(t *testing.T) {
+	server, client := startBundleAPIServer(t)
+
+	ok := Status{Code: codes.OK}
+
+	for _, tc := range []struct {
+		desc          string
+		withBundles   []spiffeid.TrustDomain
+		deleteBundles []spiffeid.TrustDomain
+		expectBundles []spiffeid.TrustDomain
+		expectStatus  []Status
+		expectErr     error
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc:          "RPC error",
+			deleteBundles: []spiffeid.TrustDomain{domain1},
+			expectErr:     status.Error(codes.Internal, "oh no"),
+		},
+		{
+			desc:          "not found",
+			deleteBundles: []spiffeid.TrustDomain{domain1},
+			expectStatus:  []Status{{Code: codes.NotFound, Message: `federated bundle "domain1" not found`}},
+		},
+		{
+			desc:          "less than a batch",
+			withBundles:   []spiffeid.TrustDomain{domain1, domain2},
+			deleteBundles: []spiffeid.TrustDomain{domain1},
+			expectBundles: []spiffeid.TrustDomain{domain2},
+			expectStatus:  []Status{ok},
+		},
+		{
+			desc:          "more than a batch",
+			withBundles:   []spiffeid.TrustDomain{domain1, domain2, domain3},
+			deleteBundles: []spiffeid.TrustDomain{domain1, domain2, domain3},
+			expectStatus:  []Status{ok, ok, ok},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			server.setFederatedBundles(tc.withBundles...)
+			server.batchDeleteFederatedBundleErr = tc.expectErr
+			actualStatus, err := client.DeleteFederatedBundles(ctx, tc.deleteBundles)
+			if tc.expectErr != nil {
+				assertErrorIs(t, err, tc.expectErr)
+				assert.Nil(t, actualStatus)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectStatus, actualStatus)
+			assert.ElementsMatch(t, tc.expectBundles, server.getFederatedBundles())
+		})
+	}
+}
+
 func startBundleAPIServer(t *testing.T) (*bundleServer, BundleClient) {
 	api := &bundleServer{}
 	conn := startServer(t, func(s *grpc.Server) {
@@ -69,8 +190,12 @@ func startBundleAPIServer(t *testing.T) (*bundleServer, BundleClient) {
 type bundleServer struct {
 	bundlev1.UnimplementedBundleServer
 
-	mtx    sync.RWMutex
-	bundle *apitypes.Bundle
+	mtx              sync.RWMutex
+	bundle           *apitypes.Bundle
+	federatedBundles []string
+
+	listFederatedBundlesErr       error
+	batchDeleteFederatedBundleErr error
 }
 
 func (s *bundleServer) GetBundle(ctx context.Context, req *bundlev1.GetBundleRequest) (*apitypes.Bundle, error) {
@@ -93,6 +218,87 @@ func (s *bundleServer) setBundle(t *testing.T, bundle *spiffebundle.Bundle) {
 	s.mtx.Unlock()
 }
 
+func (s *bundleServer) GetFederatedBundle(ctx context.Context, req *bundlev1.GetFederatedBundleRequest) (*apitypes.Bundle, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	for _, federatedBundle := range s.federatedBundles {
+		if federatedBundle == req.TrustDomain {
+			return &apitypes.Bundle{TrustDomain: federatedBundle}, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "federated bundle %q not found", req.TrustDomain)
+}
+
+func (s *bundleServer) ListFederatedBundles(ctx context.Context, req *bundlev1.ListFederatedBundlesRequest) (*bundlev1.ListFederatedBundlesResponse, error) {
+	if s.listFederatedBundlesErr != nil {
+		return nil, s.listFederatedBundlesErr
+	}
+
+	resp := new(bundlev1.ListFederatedBundlesResponse)
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	start, end, more := listBounds(req.PageToken, int(req.PageSize), len(s.federatedBundles), func(i int) string { return s.federatedBundles[i] })
+	for _, federatedBundle := range s.federatedBundles[start:end] {
+		resp.Bundles = append(resp.Bundles, &apitypes.Bundle{TrustDomain: federatedBundle})
+		if more {
+			resp.NextPageToken = federatedBundle
+		}
+	}
+	return resp, nil
+}
+
+func (s *bundleServer) BatchDeleteFederatedBundle(ctx context.Context, req *bundlev1.BatchDeleteFederatedBundleRequest) (*bundlev1.BatchDeleteFederatedBundleResponse, error) {
+	if req.Mode != bundlev1.BatchDeleteFederatedBundleRequest_DISSOCIATE {
+		return nil, status.Errorf(codes.InvalidArgument, "unexpected mode %s", req.Mode)
+	}
+
+	resp := new(bundlev1.BatchDeleteFederatedBundleResponse)
+	for _, td := range req.TrustDomains {
+		st := status.Convert(s.deleteFederatedBundle(td))
+		resp.Results = append(resp.Results, &bundlev1.BatchDeleteFederatedBundleResponse_Result{
+			Status: &apitypes.Status{
+				Code:    int32(st.Code()),
+				Message: st.Message(),
+			},
+			TrustDomain: td,
+		})
+	}
+	return resp, s.batchDeleteFederatedBundleErr
+}
+
+func (s *bundleServer) deleteFederatedBundle(td string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for i, federatedBundle := range s.federatedBundles {
+		if federatedBundle == td {
+			s.federatedBundles = append(s.federatedBundles[:i], s.federatedBundles[i+1:]...)
+			return nil
+		}
+	}
+	return status.Errorf(codes.NotFound, "federated bundle %q not found", td)
+}
+
+func (s *bundleServer) setFederatedBundles(tds ...spiffeid.TrustDomain) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.federatedBundles = nil
+	for _, td := range tds {
+		s.federatedBundles = append(s.federatedBundles, td.Name())
+	}
+}
+
+func (s *bundleServer) getFederatedBundles() []spiffeid.TrustDomain {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var out []spiffeid.TrustDomain
+	for _, td := range s.federatedBundles {
+		out = append(out, spiffeid.RequireTrustDomainFromString(td))
+	}
+	return out
+}
+
 func marshalBundle(t *testing.T, b *spiffebundle.Bundle) string {
 	d, err := b.Marshal()
 	require.NoError(t, err)
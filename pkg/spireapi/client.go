@@ -18,24 +18,66 @@ package spireapi
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"path/filepath"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+const dialTimeout = 5 * time.Second
+
 type Client interface {
 	EntryClient
 	TrustDomainClient
 	SVIDClient
 	BundleClient
+	AgentClient
 	io.Closer
 }
 
-func DialSocket(ctx context.Context, path string) (Client, error) {
+// DialOption configures the client returned by DialSocket and DialTCP.
+type DialOption func(*dialOptions)
+
+type dialOptions struct {
+	entryClientOptions EntryClientOptions
+	retry              *RetryConfig
+}
+
+func newDialOptions(opts []DialOption) dialOptions {
+	var options dialOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// grpcDialOptions returns the gRPC dial options for the options.
+func (o dialOptions) grpcDialOptions() []grpc.DialOption {
+	if o.retry == nil {
+		return nil
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(retryInterceptor(*o.retry)),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           redialBackoff(o.retry.Default),
+			MinConnectTimeout: dialTimeout,
+		}),
+	}
+}
+
+// WithEntryClientOptions tunes how the client calls the entry API.
+func WithEntryClientOptions(options EntryClientOptions) DialOption {
+	return func(o *dialOptions) {
+		o.entryClientOptions = options
+	}
+}
+
+func DialSocket(ctx context.Context, path string, opts ...DialOption) (Client, error) {
 	var target string
 	if filepath.IsAbs(path) {
 		target = "unix://" + path
@@ -43,24 +85,45 @@ func DialSocket(ctx context.Context, path string) (Client, error) {
 		target = "unix:" + path
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	options := newDialOptions(opts)
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
 	defer cancel()
-	grpcClient, err := grpc.DialContext(ctx, target, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	grpcClient, err := grpc.DialContext(ctx, target, append(options.grpcDialOptions(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial API socket: %w", err)
 	}
+	return newClient(grpcClient, options), nil
+}
+
+// DialTCP dials the SPIRE Server API at the given TCP address (host:port).
+// The TLS configuration must authenticate the SPIRE Server and present a
+// client certificate (e.g. an admin X509-SVID) that the SPIRE Server
+// authorizes for the API.
+func DialTCP(ctx context.Context, address string, tlsConfig *tls.Config, opts ...DialOption) (Client, error) {
+	options := newDialOptions(opts)
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	grpcClient, err := grpc.DialContext(ctx, address, append(options.grpcDialOptions(), grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)), grpc.WithBlock())...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial API address: %w", err)
+	}
+	return newClient(grpcClient, options), nil
+}
 
+func newClient(grpcClient *grpc.ClientConn, options dialOptions) Client {
 	return struct {
 		EntryClient
 		TrustDomainClient
 		SVIDClient
 		BundleClient
+		AgentClient
 		io.Closer
 	}{
-		EntryClient:       NewEntryClient(grpcClient),
+		EntryClient:       NewEntryClientWithOptions(grpcClient, options.entryClientOptions),
 		TrustDomainClient: NewTrustDomainClient(grpcClient),
 		SVIDClient:        NewSVIDClient(grpcClient),
 		BundleClient:      NewBundleClient(grpcClient),
+		AgentClient:       NewAgentClient(grpcClient),
 		Closer:            grpcClient,
-	}, nil
+	}
 }
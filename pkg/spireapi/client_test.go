@@ -0,0 +1,99 @@
+package spireapi
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestDialTCP(t *testing.T) {
+	dir := t.TempDir()
+	serverID := spiffeid.RequireFromPath(domain1, "/spire/server")
+	clientID := spiffeid.RequireFromPath(domain1, "/admin")
+
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		URIs:                  []*url.URL{domain1.ID().URL()},
+	}
+	ca, err := createCertificate(caTmpl, caTmpl, key.Public(), key)
+	require.NoError(t, err)
+	bundleFile := writePEM(t, dir, "bundle.pem", "CERTIFICATE", ca.Raw)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	keyFile := writePEM(t, dir, "key.pem", "PRIVATE KEY", keyDER)
+
+	writeSVID := func(name string, id spiffeid.ID, serial int64) string {
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     now.Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			URIs:         []*url.URL{id.URL()},
+		}
+		cert, err := createCertificate(tmpl, ca, key.Public(), key)
+		require.NoError(t, err)
+		return writePEM(t, dir, name, "CERTIFICATE", cert.Raw)
+	}
+	serverCertFile := writeSVID("server.pem", serverID, 2)
+	clientCertFile := writeSVID("client.pem", clientID, 3)
+
+	serverSource := NewFileSource(domain1, serverCertFile, keyFile, bundleFile)
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsconfig.MTLSServerConfig(serverSource, serverSource, tlsconfig.AuthorizeID(clientID)))))
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(listener) }()
+	t.Cleanup(s.Stop)
+
+	clientSource := NewFileSource(domain1, clientCertFile, keyFile, bundleFile)
+
+	t.Run("success", func(t *testing.T) {
+		client, err := DialTCP(context.Background(), listener.Addr().String(), tlsconfig.MTLSClientConfig(clientSource, clientSource, tlsconfig.AuthorizeID(serverID)))
+		require.NoError(t, err)
+		require.NoError(t, client.Close())
+	})
+
+	t.Run("unexpected server ID", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		otherID := spiffeid.RequireFromPath(domain1, "/other")
+		_, err := DialTCP(ctx, listener.Addr().String(), tlsconfig.MTLSClientConfig(clientSource, clientSource, tlsconfig.AuthorizeID(otherID)))
+		require.Error(t, err)
+	})
+}
+
+func TestFileSource(t *testing.T) {
+	source := NewFileSource(domain1, "missing-cert.pem", "missing-key.pem", "missing-bundle.pem")
+
+	_, err := source.GetX509SVID()
+	require.ErrorContains(t, err, "failed to load X509-SVID")
+
+	_, err = source.GetX509BundleForTrustDomain(domain1)
+	require.ErrorContains(t, err, "failed to load X.509 bundle")
+
+	_, err = source.GetX509BundleForTrustDomain(domain2)
+	require.EqualError(t, err, `no X.509 bundle for trust domain "domain2"`)
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+	return path
+}
@@ -21,29 +21,103 @@ import (
 
 	entryv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
 	apitypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 )
 
 type EntryClient interface {
 	ListEntries(ctx context.Context) ([]Entry, error)
-	CreateEntries(ctx context.Context, entries []Entry) ([]Status, error)
-	UpdateEntries(ctx context.Context, entries []Entry) ([]Status, error)
+
+	// ListEntriesBySelector lists the entries whose selectors include the
+	// given selector, e.g. the entries of a single pod.
+	ListEntriesBySelector(ctx context.Context, selector Selector) ([]Entry, error)
+
+	CreateEntries(ctx context.Context, entries []Entry) ([]EntryStatus, error)
+	UpdateEntries(ctx context.Context, entries []Entry) ([]EntryStatus, error)
 	DeleteEntries(ctx context.Context, entryIDs []string) ([]Status, error)
 }
 
+// EntryClientOptions tune how the entry client calls the SPIRE Server API
+// so that bursts of entry changes do not overload SPIRE Server.
+type EntryClientOptions struct {
+	// CreateBatchSize, UpdateBatchSize, and DeleteBatchSize are how many
+	// entries are sent in each batch call. Default to 50, 50, and 200.
+	CreateBatchSize int
+	UpdateBatchSize int
+	DeleteBatchSize int
+
+	// MaxConcurrentBatches is how many batch calls are in flight at once.
+	// Defaults to 1.
+	MaxConcurrentBatches int
+
+	// CallsPerSecond, if non-zero, limits the rate of entry API calls,
+	// including each page of a listing.
+	CallsPerSecond int
+
+	// Burst is how many calls can exceed the rate at once. Defaults to 1.
+	Burst int
+}
+
 func NewEntryClient(conn grpc.ClientConnInterface) EntryClient {
-	return entryClient{api: entryv1.NewEntryClient(conn)}
+	return NewEntryClientWithOptions(conn, EntryClientOptions{})
+}
+
+func NewEntryClientWithOptions(conn grpc.ClientConnInterface, options EntryClientOptions) EntryClient {
+	if options.CreateBatchSize <= 0 {
+		options.CreateBatchSize = entryCreateBatchSize
+	}
+	if options.UpdateBatchSize <= 0 {
+		options.UpdateBatchSize = entryUpdateBatchSize
+	}
+	if options.DeleteBatchSize <= 0 {
+		options.DeleteBatchSize = entryDeleteBatchSize
+	}
+	if options.MaxConcurrentBatches <= 0 {
+		options.MaxConcurrentBatches = 1
+	}
+	if options.Burst <= 0 {
+		options.Burst = 1
+	}
+	c := entryClient{
+		api:     entryv1.NewEntryClient(conn),
+		options: options,
+	}
+	if options.CallsPerSecond > 0 {
+		c.limiter = rate.NewLimiter(rate.Limit(options.CallsPerSecond), options.Burst)
+	}
+	return c
 }
 
 type entryClient struct {
-	api entryv1.EntryClient
+	api     entryv1.EntryClient
+	options EntryClientOptions
+
+	// limiter limits the rate of calls. It is nil if the rate is unlimited.
+	limiter *rate.Limiter
 }
 
 func (c entryClient) ListEntries(ctx context.Context) ([]Entry, error) {
+	return c.listEntries(ctx, nil)
+}
+
+func (c entryClient) ListEntriesBySelector(ctx context.Context, selector Selector) ([]Entry, error) {
+	return c.listEntries(ctx, &entryv1.ListEntriesRequest_Filter{
+		BySelectors: &apitypes.SelectorMatch{
+			Selectors: []*apitypes.Selector{selectorToAPI(selector)},
+			Match:     apitypes.SelectorMatch_MATCH_SUPERSET,
+		},
+	})
+}
+
+func (c entryClient) listEntries(ctx context.Context, filter *entryv1.ListEntriesRequest_Filter) ([]Entry, error) {
 	var entries []*apitypes.Entry
 	var pageToken string
 	for {
+		if err := waitForRateLimit(ctx, c.limiter, "ListEntries"); err != nil {
+			return nil, err
+		}
 		resp, err := c.api.ListEntries(ctx, &entryv1.ListEntriesRequest{
+			Filter:    filter,
 			PageToken: pageToken,
 			PageSize:  int32(entryListPageSize),
 		})
@@ -59,50 +133,80 @@ func (c entryClient) ListEntries(ctx context.Context) ([]Entry, error) {
 	return entriesFromAPI(entries)
 }
 
-func (c entryClient) CreateEntries(ctx context.Context, entries []Entry) ([]Status, error) {
-	statuses := make([]Status, 0, len(entries))
-	err := runBatch(len(entries), entryCreateBatchSize, func(start, end int) error {
+func (c entryClient) CreateEntries(ctx context.Context, entries []Entry) ([]EntryStatus, error) {
+	statuses := make([]EntryStatus, len(entries))
+	err := runConcurrentBatches(len(entries), c.options.CreateBatchSize, c.options.MaxConcurrentBatches, func(start, end int) error {
+		if err := waitForRateLimit(ctx, c.limiter, "CreateEntries"); err != nil {
+			return err
+		}
 		resp, err := c.api.BatchCreateEntry(ctx, &entryv1.BatchCreateEntryRequest{
 			Entries: entriesToAPI(entries[start:end]),
 		})
-		if err == nil {
-			for _, result := range resp.Results {
-				statuses = append(statuses, statusFromAPI(result.Status))
-			}
+		if err != nil {
+			return err
+		}
+		if len(resp.Results) != end-start {
+			return unexpectedResultCount(end-start, len(resp.Results))
+		}
+		for i, result := range resp.Results {
+			statuses[start+i] = entryStatusFromAPI(result.Status, result.Entry)
 		}
-		return err
+		return nil
 	})
-	return statuses, err
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
 }
 
-func (c entryClient) UpdateEntries(ctx context.Context, entries []Entry) ([]Status, error) {
-	statuses := make([]Status, 0, len(entries))
-	err := runBatch(len(entries), entryUpdateBatchSize, func(start, end int) error {
+func (c entryClient) UpdateEntries(ctx context.Context, entries []Entry) ([]EntryStatus, error) {
+	statuses := make([]EntryStatus, len(entries))
+	err := runConcurrentBatches(len(entries), c.options.UpdateBatchSize, c.options.MaxConcurrentBatches, func(start, end int) error {
+		if err := waitForRateLimit(ctx, c.limiter, "UpdateEntries"); err != nil {
+			return err
+		}
 		resp, err := c.api.BatchUpdateEntry(ctx, &entryv1.BatchUpdateEntryRequest{
 			Entries: entriesToAPI(entries[start:end]),
 		})
-		if err == nil {
-			for _, result := range resp.Results {
-				statuses = append(statuses, statusFromAPI(result.Status))
-			}
+		if err != nil {
+			return err
+		}
+		if len(resp.Results) != end-start {
+			return unexpectedResultCount(end-start, len(resp.Results))
+		}
+		for i, result := range resp.Results {
+			statuses[start+i] = entryStatusFromAPI(result.Status, result.Entry)
 		}
-		return err
+		return nil
 	})
-	return statuses, err
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
 }
 
 func (c entryClient) DeleteEntries(ctx context.Context, entryIDs []string) ([]Status, error) {
-	statuses := make([]Status, 0, len(entryIDs))
-	err := runBatch(len(entryIDs), entryDeleteBatchSize, func(start, end int) error {
+	statuses := make([]Status, len(entryIDs))
+	err := runConcurrentBatches(len(entryIDs), c.options.DeleteBatchSize, c.options.MaxConcurrentBatches, func(start, end int) error {
+		if err := waitForRateLimit(ctx, c.limiter, "DeleteEntries"); err != nil {
+			return err
+		}
 		resp, err := c.api.BatchDeleteEntry(ctx, &entryv1.BatchDeleteEntryRequest{
 			Ids: entryIDs[start:end],
 		})
-		if err == nil {
-			for _, result := range resp.Results {
-				statuses = append(statuses, statusFromAPI(result.Status))
-			}
+		if err != nil {
+			return err
+		}
+		if len(resp.Results) != end-start {
+			return unexpectedResultCount(end-start, len(resp.Results))
 		}
-		return err
+		for i, result := range resp.Results {
+			statuses[start+i] = statusFromAPI(result.Status)
+		}
+		return nil
 	})
-	return statuses, err
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
 }
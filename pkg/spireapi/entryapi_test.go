@@ -7,9 +7,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	entryv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
 	apitypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
@@ -92,23 +94,48 @@ func TestEntryAPIListEntries(t *testing.T) {
 	}
 }
 
+func TestEntryAPIListEntriesBySelector(t *testing.T) {
+	server, client := startEntryAPIServer(t)
+
+	entry1b := entry1
+	entry1b.ID = "E1b"
+	entry1b.SPIFFEID = spiffeid.RequireFromString("spiffe://domain.test/workload1b")
+	entry1b.Selectors = []Selector{{Type: "T0", Value: "V0"}, {Type: "T1", Value: "V1"}}
+	server.setEntries(t, entry1, entry1b, entry2, entry3)
+
+	actualEntries, err := client.ListEntriesBySelector(ctx, Selector{Type: "T1", Value: "V1"})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Entry{entry1, entry1b}, actualEntries)
+
+	actualEntries, err = client.ListEntriesBySelector(ctx, Selector{Type: "T4", Value: "V4"})
+	assert.NoError(t, err)
+	assert.Empty(t, actualEntries)
+
+	server.listEntriesErr = status.Error(codes.Internal, "oh no")
+	actualEntries, err = client.ListEntriesBySelector(ctx, Selector{Type: "T1", Value: "V1"})
+	assertErrorIs(t, err, server.listEntriesErr)
+	assert.Empty(t, actualEntries)
+}
+
 func TestCreateEntries(t *testing.T) {
 	server, client := startEntryAPIServer(t)
 
-	ok := Status{Code: codes.OK}
+	ok := func(entry Entry) EntryStatus {
+		return EntryStatus{Status: Status{Code: codes.OK}, Entry: &entry}
+	}
 
 	for _, tc := range []struct {
 		desc          string
 		withEntries   []Entry
 		createEntries []Entry
 		expectEntries []Entry
-		expectStatus  []Status
+		expectStatus  []EntryStatus
 		expectErr     error
 	}{
 		{
 			desc:          "empty",
 			expectEntries: nil,
-			expectStatus:  []Status{},
+			expectStatus:  []EntryStatus{},
 		},
 		{
 			desc:          "RPC error",
@@ -120,25 +147,25 @@ func TestCreateEntries(t *testing.T) {
 			withEntries:   []Entry{entry1},
 			createEntries: []Entry{entry1},
 			expectEntries: []Entry{entry1},
-			expectStatus:  []Status{{Code: codes.AlreadyExists, Message: `entry "E1" already exists`}},
+			expectStatus:  []EntryStatus{{Status: Status{Code: codes.AlreadyExists, Message: `entry "E1" already exists`}}},
 		},
 		{
 			desc:          "less than a batch",
 			createEntries: []Entry{entry1},
 			expectEntries: []Entry{entry1},
-			expectStatus:  []Status{ok},
+			expectStatus:  []EntryStatus{ok(entry1)},
 		},
 		{
 			desc:          "exactly a batch",
 			createEntries: []Entry{entry1, entry2},
 			expectEntries: []Entry{entry1, entry2},
-			expectStatus:  []Status{ok, ok},
+			expectStatus:  []EntryStatus{ok(entry1), ok(entry2)},
 		},
 		{
 			desc:          "more than a batch",
 			createEntries: []Entry{entry1, entry2, entry3},
 			expectEntries: []Entry{entry1, entry2, entry3},
-			expectStatus:  []Status{ok, ok, ok},
+			expectStatus:  []EntryStatus{ok(entry1), ok(entry2), ok(entry3)},
 		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
@@ -160,7 +187,9 @@ func TestCreateEntries(t *testing.T) {
 func TestUpdateEntries(t *testing.T) {
 	server, client := startEntryAPIServer(t)
 
-	ok := Status{Code: codes.OK}
+	ok := func(entry Entry) EntryStatus {
+		return EntryStatus{Status: Status{Code: codes.OK}, Entry: &entry}
+	}
 
 	dupWithTTL := func(entry Entry, ttl time.Duration) Entry {
 		entry.X509SVIDTTL = ttl
@@ -176,13 +205,13 @@ func TestUpdateEntries(t *testing.T) {
 		withEntries   []Entry
 		updateEntries []Entry
 		expectEntries []Entry
-		expectStatus  []Status
+		expectStatus  []EntryStatus
 		expectErr     error
 	}{
 		{
 			desc:          "empty",
 			expectEntries: nil,
-			expectStatus:  []Status{},
+			expectStatus:  []EntryStatus{},
 		},
 		{
 			desc:          "RPC error",
@@ -192,28 +221,28 @@ func TestUpdateEntries(t *testing.T) {
 		{
 			desc:          "not found",
 			updateEntries: []Entry{entry1},
-			expectStatus:  []Status{{Code: codes.NotFound, Message: `entry "E1" not found`}},
+			expectStatus:  []EntryStatus{{Status: Status{Code: codes.NotFound, Message: `entry "E1" not found`}}},
 		},
 		{
 			desc:          "less than a batch",
 			withEntries:   []Entry{entry1},
 			updateEntries: []Entry{entry1},
 			expectEntries: []Entry{entry1},
-			expectStatus:  []Status{ok},
+			expectStatus:  []EntryStatus{ok(entry1)},
 		},
 		{
 			desc:          "exactly a batch",
 			withEntries:   []Entry{entry1Old, entry2Old},
 			updateEntries: []Entry{entry1, entry2},
 			expectEntries: []Entry{entry1, entry2},
-			expectStatus:  []Status{ok, ok},
+			expectStatus:  []EntryStatus{ok(entry1), ok(entry2)},
 		},
 		{
 			desc:          "more than a batch",
 			withEntries:   []Entry{entry1Old, entry2Old, entry3Old},
 			updateEntries: []Entry{entry1, entry2, entry3},
 			expectEntries: []Entry{entry1, entry2, entry3},
-			expectStatus:  []Status{ok, ok, ok},
+			expectStatus:  []EntryStatus{ok(entry1), ok(entry2), ok(entry3)},
 		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
@@ -295,12 +324,53 @@ func TestDeleteEntries(t *testing.T) {
 	}
 }
 
+func TestEntryClientOptions(t *testing.T) {
+	server, conn := startEntryAPIConn(t)
+
+	client := NewEntryClientWithOptions(conn, EntryClientOptions{
+		CreateBatchSize:      1,
+		DeleteBatchSize:      1,
+		MaxConcurrentBatches: 3,
+		CallsPerSecond:       1000,
+	})
+
+	ok := Status{Code: codes.OK}
+	throttled := testutil.ToFloat64(metrics.SPIREAPIThrottledCalls.WithLabelValues("CreateEntries"))
+
+	statuses, err := client.CreateEntries(ctx, []Entry{entry1, entry2, entry3})
+	require.NoError(t, err)
+	require.Equal(t, []EntryStatus{
+		{Status: ok, Entry: &entry1},
+		{Status: ok, Entry: &entry2},
+		{Status: ok, Entry: &entry3},
+	}, statuses)
+	assert.ElementsMatch(t, []Entry{entry1, entry2, entry3}, server.getEntries(t))
+
+	// The burst allows a single call, so the later batches are throttled.
+	assert.Greater(t, testutil.ToFloat64(metrics.SPIREAPIThrottledCalls.WithLabelValues("CreateEntries")), throttled)
+
+	deleteStatuses, err := client.DeleteEntries(ctx, []string{entry3ID, "missing", entry1ID})
+	require.NoError(t, err)
+	require.Equal(t, []Status{ok, {Code: codes.NotFound, Message: `entry "missing" not found`}, ok}, deleteStatuses)
+	assert.Equal(t, []Entry{entry2}, server.getEntries(t))
+
+	server.batchCreateEntriesErr = status.Error(codes.Internal, "oh no")
+	statuses, err = client.CreateEntries(ctx, []Entry{entry1, entry3})
+	assertErrorIs(t, err, server.batchCreateEntriesErr)
+	assert.Empty(t, statuses)
+}
+
 func startEntryAPIServer(t *testing.T) (*entryServer, EntryClient) {
+	api, conn := startEntryAPIConn(t)
+	return api, NewEntryClient(conn)
+}
+
+func startEntryAPIConn(t *testing.T) (*entryServer, grpc.ClientConnInterface) {
 	api := &entryServer{}
 	conn := startServer(t, func(s *grpc.Server) {
 		entryv1.RegisterEntryServer(s, api)
 	})
-	return api, NewEntryClient(conn)
+	return api, conn
 }
 
 type entryServer struct {
@@ -321,8 +391,21 @@ func (s *entryServer) ListEntries(ctx context.Context, req *entryv1.ListEntriesR
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
 
-	start, end, more := listBounds(req.PageToken, int(req.PageSize), len(s.entries), func(i int) string { return s.entries[i].Id })
-	for _, entry := range s.entries[start:end] {
+	entries := s.entries
+	if bySelectors := req.GetFilter().GetBySelectors(); bySelectors != nil {
+		if bySelectors.Match != apitypes.SelectorMatch_MATCH_SUPERSET {
+			return nil, status.Error(codes.Unimplemented, "only superset selector matches are supported")
+		}
+		entries = nil
+		for _, entry := range s.entries {
+			if hasSelectors(entry, bySelectors.Selectors) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	start, end, more := listBounds(req.PageToken, int(req.PageSize), len(entries), func(i int) string { return entries[i].Id })
+	for _, entry := range entries[start:end] {
 		resp.Entries = append(resp.Entries, entry)
 		if more {
 			resp.NextPageToken = entry.Id
@@ -402,6 +485,22 @@ func (s *entryServer) getEntries(t *testing.T) []Entry {
 	return entries
 }
 
+func hasSelectors(entry *apitypes.Entry, selectors []*apitypes.Selector) bool {
+	for _, selector := range selectors {
+		found := false
+		for _, entrySelector := range entry.Selectors {
+			if entrySelector.Type == selector.Type && entrySelector.Value == selector.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *entryServer) setEntries(t *testing.T, entries ...Entry) {
 	s.clearEntries()
 	for _, entry := range entries {
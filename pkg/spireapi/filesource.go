@@ -0,0 +1,73 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireapi
+
+import (
+	"fmt"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+)
+
+// FileSource is an X509-SVID and X.509 bundle source backed by PEM files.
+// The files are read on every call so that credentials rotated on disk
+// (e.g. by a sidecar) are picked up on the next TLS handshake.
+type FileSource struct {
+	trustDomain spiffeid.TrustDomain
+	certFile    string
+	keyFile     string
+	bundleFile  string
+}
+
+var (
+	_ x509svid.Source   = (*FileSource)(nil)
+	_ x509bundle.Source = (*FileSource)(nil)
+)
+
+// NewFileSource returns a source that loads the X509-SVID from the
+// certificate and key files, and the X.509 authorities for the trust domain
+// from the bundle file.
+func NewFileSource(trustDomain spiffeid.TrustDomain, certFile, keyFile, bundleFile string) *FileSource {
+	return &FileSource{
+		trustDomain: trustDomain,
+		certFile:    certFile,
+		keyFile:     keyFile,
+		bundleFile:  bundleFile,
+	}
+}
+
+// GetX509SVID implements x509svid.Source.
+func (s *FileSource) GetX509SVID() (*x509svid.SVID, error) {
+	svid, err := x509svid.Load(s.certFile, s.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load X509-SVID: %w", err)
+	}
+	return svid, nil
+}
+
+// GetX509BundleForTrustDomain implements x509bundle.Source.
+func (s *FileSource) GetX509BundleForTrustDomain(trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	if trustDomain != s.trustDomain {
+		return nil, fmt.Errorf("no X.509 bundle for trust domain %q", trustDomain)
+	}
+	bundle, err := x509bundle.Load(s.trustDomain, s.bundleFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load X.509 bundle: %w", err)
+	}
+	return bundle, nil
+}
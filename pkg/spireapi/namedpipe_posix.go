@@ -0,0 +1,30 @@
+//go:build !windows
+
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireapi
+
+import (
+	"context"
+	"errors"
+)
+
+// DialNamedPipe dials the SPIRE Server API at the given named pipe name.
+// Named pipes are only supported on Windows.
+func DialNamedPipe(context.Context, string, ...DialOption) (Client, error) {
+	return nil, errors.New("named pipes are only supported on Windows")
+}
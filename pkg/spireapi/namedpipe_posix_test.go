@@ -0,0 +1,15 @@
+//go:build !windows
+
+package spireapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialNamedPipe(t *testing.T) {
+	_, err := DialNamedPipe(context.Background(), `\spire-server\private\api`)
+	require.EqualError(t, err, "named pipes are only supported on Windows")
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireapi
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Microsoft/go-winio"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// DialNamedPipe dials the SPIRE Server API at the given named pipe name,
+// e.g. \spire-server\private\api, which is relative to \\.\pipe as in the
+// SPIRE Server named_pipe_name configuration.
+func DialNamedPipe(ctx context.Context, pipeName string, opts ...DialOption) (Client, error) {
+	options := newDialOptions(opts)
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	grpcClient, err := grpc.DialContext(ctx, namedPipeTarget(pipeName), append(options.grpcDialOptions(), grpc.WithContextDialer(winio.DialPipeContext), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial API named pipe: %w", err)
+	}
+	return newClient(grpcClient, options), nil
+}
+
+// namedPipeTarget returns the path of the named pipe with the given name.
+func namedPipeTarget(pipeName string) string {
+	return `\\.\` + filepath.Join("pipe", pipeName)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireapi
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"google.golang.org/grpc"
+	grpcbackoff "google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy is how calls that fail because SPIRE Server is unavailable
+// (e.g. it is restarting) are retried.
+type RetryPolicy struct {
+	// MaxAttempts is how many times a call is attempted, including the
+	// first attempt. Calls are not retried if it is less than 2.
+	MaxAttempts int
+
+	// InitialBackoff is how long to wait before the first retry. The wait
+	// doubles, with jitter, on each retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between retries.
+	MaxBackoff time.Duration
+}
+
+// RetryConfig configures retries of SPIRE API calls.
+type RetryConfig struct {
+	// Default is the policy for calls without a policy of their own.
+	Default RetryPolicy
+
+	// Operations holds the policies for specific calls, by gRPC method name
+	// (e.g. "BatchCreateEntry").
+	Operations map[string]RetryPolicy
+}
+
+// WithRetry retries calls that fail because SPIRE Server is unavailable. The
+// connection is also redialed with the backoff of the default policy instead
+// of the gRPC default, which backs off for up to two minutes.
+func WithRetry(config RetryConfig) DialOption {
+	return func(o *dialOptions) {
+		o.retry = &config
+	}
+}
+
+func (c RetryConfig) policyFor(method string) RetryPolicy {
+	if policy, ok := c.Operations[method]; ok {
+		return policy
+	}
+	return c.Default
+}
+
+// retryInterceptor retries calls that fail with Unavailable, which SPIRE
+// Server returns (or the client returns on its behalf) when the call could
+// not be served, e.g. because the connection to SPIRE Server was lost.
+func retryInterceptor(config RetryConfig) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		call := methodName(method)
+		policy := config.policyFor(call)
+		b := backoff.Backoff{
+			Min:    policy.InitialBackoff,
+			Max:    policy.MaxBackoff,
+			Factor: 2,
+			Jitter: true,
+		}
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= policy.MaxAttempts || status.Code(err) != codes.Unavailable {
+				return err
+			}
+			metrics.SPIREAPIRetries.WithLabelValues(call).Inc()
+			timer := time.NewTimer(b.Duration())
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// redialBackoff returns the backoff for reconnecting to SPIRE Server.
+func redialBackoff(policy RetryPolicy) grpcbackoff.Config {
+	config := grpcbackoff.DefaultConfig
+	if policy.InitialBackoff > 0 {
+		config.BaseDelay = policy.InitialBackoff
+	}
+	if policy.MaxBackoff > 0 {
+		config.MaxDelay = policy.MaxBackoff
+	}
+	return config
+}
+
+// methodName returns the name of the gRPC method without the service, e.g.
+// "BatchCreateEntry" for "/spire.api.server.entry.v1.Entry/BatchCreateEntry".
+func methodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
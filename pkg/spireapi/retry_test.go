@@ -0,0 +1,75 @@
+package spireapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryInterceptor(t *testing.T) {
+	interceptor := retryInterceptor(RetryConfig{
+		Default: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+		Operations: map[string]RetryPolicy{
+			"BatchCreateEntry": {MaxAttempts: 1},
+		},
+	})
+
+	failingInvoker := func(failures int, code codes.Code) (grpc.UnaryInvoker, *int) {
+		attempts := new(int)
+		return func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			*attempts++
+			if *attempts <= failures {
+				return status.Error(code, "oh no")
+			}
+			return nil
+		}, attempts
+	}
+
+	t.Run("retries until the call succeeds", func(t *testing.T) {
+		retries := testutil.ToFloat64(metrics.SPIREAPIRetries.WithLabelValues("ListEntries"))
+		invoker, attempts := failingInvoker(2, codes.Unavailable)
+		err := interceptor(ctx, "/spire.api.server.entry.v1.Entry/ListEntries", nil, nil, nil, invoker)
+		require.NoError(t, err)
+		require.Equal(t, 3, *attempts)
+		require.Equal(t, retries+2, testutil.ToFloat64(metrics.SPIREAPIRetries.WithLabelValues("ListEntries")))
+	})
+
+	t.Run("gives up after the max attempts", func(t *testing.T) {
+		invoker, attempts := failingInvoker(3, codes.Unavailable)
+		err := interceptor(ctx, "/spire.api.server.entry.v1.Entry/ListEntries", nil, nil, nil, invoker)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+		require.Equal(t, 3, *attempts)
+	})
+
+	t.Run("does not retry other failures", func(t *testing.T) {
+		invoker, attempts := failingInvoker(1, codes.PermissionDenied)
+		err := interceptor(ctx, "/spire.api.server.entry.v1.Entry/ListEntries", nil, nil, nil, invoker)
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		require.Equal(t, 1, *attempts)
+	})
+
+	t.Run("uses the operation policy", func(t *testing.T) {
+		invoker, attempts := failingInvoker(1, codes.Unavailable)
+		err := interceptor(ctx, "/spire.api.server.entry.v1.Entry/BatchCreateEntry", nil, nil, nil, invoker)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+		require.Equal(t, 1, *attempts)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		invoker, attempts := failingInvoker(3, codes.Unavailable)
+		err := retryInterceptor(RetryConfig{
+			Default: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour},
+		})(ctx, "/spire.api.server.entry.v1.Entry/ListEntries", nil, nil, nil, invoker)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+		require.Equal(t, 1, *attempts)
+	})
+}
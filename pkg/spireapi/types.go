@@ -44,6 +44,7 @@ type Entry struct {
 	Downstream    bool
 	DNSNames      []string
 	Hint          string
+	StoreSVID     bool
 }
 
 type Selector struct {
@@ -123,6 +124,14 @@ func (s Status) Err() error {
 	return status.Error(s.Code, s.Message)
 }
 
+// EntryStatus is the status of an entry created or updated by a batch call.
+// If the call succeeded for the entry, Entry is the entry as stored by SPIRE
+// Server, including its ID.
+type EntryStatus struct {
+	Status
+	Entry *Entry
+}
+
 func ValidateBundleEndpointURL(s string) error {
 	if s == "" {
 		return errors.New("bundle endpoint URL is missing")
@@ -141,6 +150,37 @@ func ValidateBundleEndpointURL(s string) error {
 	return nil
 }
 
+// ParseSelector parses a selector of the form type:value. The value may
+// itself contain colons.
+func ParseSelector(selector string) (Selector, error) {
+	parts := strings.SplitN(selector, ":", 2)
+	switch {
+	case len(parts) == 1:
+		return Selector{}, errors.New("expected at least one colon separate the type from the value")
+	case len(parts[0]) == 0:
+		return Selector{}, errors.New("type cannot be empty")
+	case len(parts[1]) == 0:
+		return Selector{}, errors.New("value cannot be empty")
+	}
+	return Selector{
+		Type:  parts[0],
+		Value: parts[1],
+	}, nil
+}
+
+// ParseSelectors parses a list of selectors of the form type:value.
+func ParseSelectors(selectors []string) ([]Selector, error) {
+	ss := make([]Selector, 0, len(selectors))
+	for _, selector := range selectors {
+		s, err := ParseSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
+
 func entryToAPI(in Entry) *apitypes.Entry {
 	return &apitypes.Entry{
 		Id:            in.ID,
@@ -154,6 +194,7 @@ func entryToAPI(in Entry) *apitypes.Entry {
 		DnsNames:      in.DNSNames,
 		Downstream:    in.Downstream,
 		Hint:          in.Hint,
+		StoreSvid:     in.StoreSVID,
 	}
 }
 
@@ -204,6 +245,7 @@ func entryFromAPI(in *apitypes.Entry) (Entry, error) {
 		DNSNames:      in.DnsNames,
 		Downstream:    in.Downstream,
 		Hint:          in.Hint,
+		StoreSVID:     in.StoreSvid,
 	}, nil
 }
 
@@ -240,6 +282,18 @@ func spiffeIDFromAPI(in *apitypes.SPIFFEID) (spiffeid.ID, error) {
 	return spiffeid.FromPath(td, in.Path)
 }
 
+func agentFromAPI(in *apitypes.Agent) (Agent, error) {
+	id, err := spiffeIDFromAPI(in.Id)
+	if err != nil {
+		return Agent{}, fmt.Errorf("invalid agent ID: %w", err)
+	}
+	return Agent{
+		ID:              id,
+		AttestationType: in.AttestationType,
+		Banned:          in.Banned,
+	}, nil
+}
+
 func selectorToAPI(in Selector) *apitypes.Selector {
 	return &apitypes.Selector{
 		Type:  in.Type,
@@ -584,6 +638,16 @@ func jwtAuthorityFromAPI(in *apitypes.JWTKey) (string, crypto.PublicKey, error)
 	return in.KeyId, publicKey, nil
 }
 
+func entryStatusFromAPI(in *apitypes.Status, entry *apitypes.Entry) EntryStatus {
+	out := EntryStatus{Status: statusFromAPI(in)}
+	if out.Code == codes.OK && entry != nil {
+		if converted, err := entryFromAPI(entry); err == nil {
+			out.Entry = &converted
+		}
+	}
+	return out
+}
+
 func statusFromAPI(in *apitypes.Status) Status {
 	if in == nil {
 		return Status{
@@ -0,0 +1,132 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// initialParentBackoff is how long entries are not created for a parent
+	// after SPIRE Server first rejects an entry for exceeding the entry
+	// limit of the parent.
+	initialParentBackoff = 30 * time.Second
+
+	// maxParentBackoff caps the backoff, which doubles each time an entry
+	// is rejected again after the backoff expires.
+	maxParentBackoff = 10 * time.Minute
+)
+
+// parentBackoff tracks the parents (i.e. agents) for which SPIRE Server
+// rejected entries with ResourceExhausted, which it returns when the number
+// of entries for a parent is capped. Creating further entries for such a
+// parent is futile until entries for it are deleted, so creation is paused
+// for the parent, with exponential backoff, instead of retrying on every
+// reconcile. Entries for other parents are unaffected. The zero value is
+// ready to use.
+type parentBackoff struct {
+	clock   clock.Clock
+	parents map[spiffeid.ID]parentBackoffState
+}
+
+type parentBackoffState struct {
+	delay time.Duration
+	until time.Time
+}
+
+func newParentBackoff(clk clock.Clock) parentBackoff {
+	return parentBackoff{
+		clock:   clk,
+		parents: make(map[spiffeid.ID]parentBackoffState),
+	}
+}
+
+// now returns the current time. The zero parentBackoff uses the real clock.
+func (b *parentBackoff) now() time.Time {
+	if b.clock == nil {
+		return time.Now()
+	}
+	return b.clock.Now()
+}
+
+// Err returns an error if entries for the parent must not be created yet.
+func (b *parentBackoff) Err(parentID spiffeid.ID) error {
+	state, ok := b.parents[parentID]
+	if !ok || !b.now().Before(state.until) {
+		return nil
+	}
+	return status.Error(codes.ResourceExhausted, fmt.Sprintf("entry limit for parent %q exceeded; not creating entries until %s", parentID, state.until.Format(time.RFC3339)))
+}
+
+// Exhausted records that SPIRE Server rejected an entry for the parent for
+// exceeding the entry limit of the parent.
+func (b *parentBackoff) Exhausted(parentID spiffeid.ID) {
+	now := b.now()
+	state, ok := b.parents[parentID]
+	switch {
+	case !ok:
+		state.delay = initialParentBackoff
+	case now.Before(state.until):
+		// Already backing off, e.g. for another entry in the same batch.
+		return
+	default:
+		state.delay *= 2
+		if state.delay > maxParentBackoff {
+			state.delay = maxParentBackoff
+		}
+	}
+	state.until = now.Add(state.delay)
+	if b.parents == nil {
+		b.parents = make(map[spiffeid.ID]parentBackoffState)
+	}
+	b.parents[parentID] = state
+}
+
+// Succeeded records that an entry was created for the parent, which resets
+// its backoff.
+func (b *parentBackoff) Succeeded(parentID spiffeid.ID) {
+	delete(b.parents, parentID)
+}
+
+// Active returns the number of parents for which entries are not being
+// created. Parents whose backoff expired long ago are forgotten, so that the
+// parents of agents that are gone do not accumulate.
+func (b *parentBackoff) Active() int {
+	now := b.now()
+	n := 0
+	for parentID, state := range b.parents {
+		switch {
+		case now.Before(state.until):
+			n++
+		case now.After(state.until.Add(maxParentBackoff)):
+			delete(b.parents, parentID)
+		}
+	}
+	return n
+}
+
+// isEntryLimitExceeded returns true if SPIRE Server rejected the entry for
+// exceeding the entry limit of its parent.
+func isEntryLimitExceeded(err error) bool {
+	return status.Code(err) == codes.ResourceExhausted
+}
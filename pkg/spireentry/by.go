@@ -1,7 +1,13 @@
 package spireentry
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -15,46 +21,306 @@ type byObject interface {
 	GetDeletionTimestamp() *metav1.Time
 
 	IncrementEntriesToSet()
-	IncrementEntriesMasked()
+	IncrementEntriesMasked(maskedBy byObject)
+	IncrementEntriesMasking(masked byObject)
 	IncrementEntrySuccess()
-	IncrementEntryFailures()
+	IncrementEntryFailures(err error)
 }
 
 type ClusterStaticEntry struct {
 	spirev1alpha1.ClusterStaticEntry
 	NextStatus spirev1alpha1.ClusterStaticEntryStatus
+
+	// lastErrReason and lastErr are the reason and the error last
+	// encountered reconciling the entry, reported as an Event.
+	lastErrReason string
+	lastErr       error
 }
 
 func (by *ClusterStaticEntry) IncrementEntriesToSet() {
 }
 
-func (by *ClusterStaticEntry) IncrementEntriesMasked() {
+func (by *ClusterStaticEntry) IncrementEntriesMasked(maskedBy byObject) {
 	by.NextStatus.Masked = true
+	by.NextStatus.MaskedBy = addConflictingObject(by.NextStatus.MaskedBy, maskedBy)
+}
+
+func (by *ClusterStaticEntry) IncrementEntriesMasking(masked byObject) {
+	by.NextStatus.Masking = addConflictingObject(by.NextStatus.Masking, masked)
 }
 
 func (by *ClusterStaticEntry) IncrementEntrySuccess() {
 	by.NextStatus.Set = true
 }
 
-func (by *ClusterStaticEntry) IncrementEntryFailures() {
+func (by *ClusterStaticEntry) IncrementEntryFailures(err error) {
+	by.lastErrReason = entryFailureReason(err)
+	by.lastErr = err
+}
+
+type ClusterNodeAlias struct {
+	spirev1alpha1.ClusterNodeAlias
+	NextStatus spirev1alpha1.ClusterNodeAliasStatus
+
+	// lastErrReason and lastErr are the reason and the error last
+	// encountered reconciling the entry, reported as an Event.
+	lastErrReason string
+	lastErr       error
+}
+
+func (by *ClusterNodeAlias) IncrementEntriesToSet() {
+}
+
+func (by *ClusterNodeAlias) IncrementEntriesMasked(maskedBy byObject) {
+	by.NextStatus.Masked = true
+	by.NextStatus.MaskedBy = addConflictingObject(by.NextStatus.MaskedBy, maskedBy)
+}
+
+func (by *ClusterNodeAlias) IncrementEntriesMasking(masked byObject) {
+	by.NextStatus.Masking = addConflictingObject(by.NextStatus.Masking, masked)
+}
+
+func (by *ClusterNodeAlias) IncrementEntrySuccess() {
+	by.NextStatus.Set = true
+}
+
+func (by *ClusterNodeAlias) IncrementEntryFailures(err error) {
+	by.lastErrReason = entryFailureReason(err)
+	by.lastErr = err
 }
 
 type ClusterSPIFFEID struct {
 	spirev1alpha1.ClusterSPIFFEID
 	NextStatus spirev1alpha1.ClusterSPIFFEIDStatus
+
+	// SetTrustDomains are the trust domains resolved from the
+	// ClusterTrustDomainSets referenced by federatesWithSets.
+	SetTrustDomains []spiffeid.TrustDomain
+
+	// lastErrReason and lastErr are the reason and the error last
+	// encountered reconciling the entries, reported in the conditions.
+	lastErrReason string
+	lastErr       error
+
+	// bundlesChecked is true if the bundles of the trust domains federated
+	// with were checked, in which case missingBundles are the trust domains
+	// SPIRE Server has no bundle for.
+	bundlesChecked bool
+	missingBundles []spiffeid.TrustDomain
 }
 
 func (by *ClusterSPIFFEID) IncrementEntriesToSet() {
 	by.NextStatus.Stats.EntriesToSet++
 }
 
-func (by *ClusterSPIFFEID) IncrementEntriesMasked() {
+func (by *ClusterSPIFFEID) IncrementEntriesMasked(maskedBy byObject) {
 	by.NextStatus.Stats.EntriesMasked++
+	by.NextStatus.MaskedBy = addConflictingObject(by.NextStatus.MaskedBy, maskedBy)
+}
+
+func (by *ClusterSPIFFEID) IncrementEntriesMasking(masked byObject) {
+	by.NextStatus.Stats.EntriesMasking++
+	by.NextStatus.Masking = addConflictingObject(by.NextStatus.Masking, masked)
 }
 
 func (by *ClusterSPIFFEID) IncrementEntrySuccess() {
 }
 
-func (by *ClusterSPIFFEID) IncrementEntryFailures() {
+func (by *ClusterSPIFFEID) IncrementEntryFailures(err error) {
+	by.NextStatus.Stats.EntryFailures++
+	by.RecordError(entryFailureReason(err), err)
+}
+
+// RecordError records an error encountered reconciling the entries. Errors
+// that prevent progress until the ClusterSPIFFEID is changed (i.e. an invalid
+// spec or render failures) take precedence over transient ones.
+func (by *ClusterSPIFFEID) RecordError(reason string, err error) {
+	if isStalledReason(by.lastErrReason) && !isStalledReason(reason) {
+		return
+	}
+	by.lastErrReason = reason
+	by.lastErr = err
+}
+
+// SetNextConditions sets the conditions in the next status based on the
+// errors recorded during the reconciliation. Conditions that do not change
+// keep their last transition time.
+func (by *ClusterSPIFFEID) SetNextConditions() {
+	by.NextStatus.Conditions = nextConditions(by.Status.Conditions, by.Generation, by.lastErrReason, by.lastErr)
+	if by.bundlesChecked {
+		meta.SetStatusCondition(&by.NextStatus.Conditions, federatedCondition(by.Generation, by.missingBundles))
+	}
+}
+
+// SetMissingBundles records the trust domains federated with that SPIRE
+// Server has no bundle for, reported in the Federated condition.
+func (by *ClusterSPIFFEID) SetMissingBundles(tds []spiffeid.TrustDomain) {
+	by.bundlesChecked = true
+	by.missingBundles = tds
+}
+
+type NamespacedSPIFFEID struct {
+	spirev1alpha1.NamespacedSPIFFEID
+	NextStatus spirev1alpha1.NamespacedSPIFFEIDStatus
+
+	// SetTrustDomains are the trust domains resolved from the
+	// ClusterTrustDomainSets referenced by federatesWithSets.
+	SetTrustDomains []spiffeid.TrustDomain
+
+	// lastErrReason and lastErr are the reason and the error last
+	// encountered reconciling the entries, reported in the conditions.
+	lastErrReason string
+	lastErr       error
+
+	// bundlesChecked is true if the bundles of the trust domains federated
+	// with were checked, in which case missingBundles are the trust domains
+	// SPIRE Server has no bundle for.
+	bundlesChecked bool
+	missingBundles []spiffeid.TrustDomain
+}
+
+func (by *NamespacedSPIFFEID) IncrementEntriesToSet() {
+	by.NextStatus.Stats.EntriesToSet++
+}
+
+func (by *NamespacedSPIFFEID) IncrementEntriesMasked(maskedBy byObject) {
+	by.NextStatus.Stats.EntriesMasked++
+	by.NextStatus.MaskedBy = addConflictingObject(by.NextStatus.MaskedBy, maskedBy)
+}
+
+func (by *NamespacedSPIFFEID) IncrementEntriesMasking(masked byObject) {
+	by.NextStatus.Stats.EntriesMasking++
+	by.NextStatus.Masking = addConflictingObject(by.NextStatus.Masking, masked)
+}
+
+func (by *NamespacedSPIFFEID) IncrementEntrySuccess() {
+}
+
+func (by *NamespacedSPIFFEID) IncrementEntryFailures(err error) {
 	by.NextStatus.Stats.EntryFailures++
+	by.RecordError(entryFailureReason(err), err)
+}
+
+// RecordError records an error encountered reconciling the entries. See
+// ClusterSPIFFEID.RecordError.
+func (by *NamespacedSPIFFEID) RecordError(reason string, err error) {
+	if isStalledReason(by.lastErrReason) && !isStalledReason(reason) {
+		return
+	}
+	by.lastErrReason = reason
+	by.lastErr = err
+}
+
+// SetNextConditions sets the conditions in the next status based on the
+// errors recorded during the reconciliation.
+func (by *NamespacedSPIFFEID) SetNextConditions() {
+	by.NextStatus.Conditions = nextConditions(by.Status.Conditions, by.Generation, by.lastErrReason, by.lastErr)
+	if by.bundlesChecked {
+		meta.SetStatusCondition(&by.NextStatus.Conditions, federatedCondition(by.Generation, by.missingBundles))
+	}
+}
+
+// SetMissingBundles records the trust domains federated with that SPIRE
+// Server has no bundle for. See ClusterSPIFFEID.SetMissingBundles.
+func (by *NamespacedSPIFFEID) SetMissingBundles(tds []spiffeid.TrustDomain) {
+	by.bundlesChecked = true
+	by.missingBundles = tds
+}
+
+// nextConditions returns the Ready and Stalled conditions, merged into the
+// current conditions, for the last error recorded, if any.
+func nextConditions(current []metav1.Condition, generation int64, lastErrReason string, lastErr error) []metav1.Condition {
+	conditions := append([]metav1.Condition(nil), current...)
+
+	ready := metav1.Condition{
+		Type:               spirev1alpha1.ClusterSPIFFEIDConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             spirev1alpha1.ClusterSPIFFEIDReasonReconciled,
+		Message:            "Entries are set for all selected pods",
+	}
+	stalled := metav1.Condition{
+		Type:               spirev1alpha1.ClusterSPIFFEIDConditionStalled,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             spirev1alpha1.ClusterSPIFFEIDReasonReconciled,
+	}
+	if lastErr != nil {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = lastErrReason
+		ready.Message = lastErr.Error()
+		if isStalledReason(lastErrReason) {
+			stalled.Status = metav1.ConditionTrue
+			stalled.Reason = lastErrReason
+			stalled.Message = lastErr.Error()
+		}
+	}
+	meta.SetStatusCondition(&conditions, ready)
+	meta.SetStatusCondition(&conditions, stalled)
+	return conditions
+}
+
+// federatedCondition returns the Federated condition for the trust domains
+// federated with that SPIRE Server has no bundle for, if any.
+func federatedCondition(generation int64, missingBundles []spiffeid.TrustDomain) metav1.Condition {
+	if len(missingBundles) == 0 {
+		return metav1.Condition{
+			Type:               spirev1alpha1.ClusterSPIFFEIDConditionFederated,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             spirev1alpha1.ClusterSPIFFEIDReasonBundlesAvailable,
+			Message:            "SPIRE Server has bundles for all trust domains federated with",
+		}
+	}
+	names := make([]string, 0, len(missingBundles))
+	for _, td := range missingBundles {
+		names = append(names, td.Name())
+	}
+	return metav1.Condition{
+		Type:               spirev1alpha1.ClusterSPIFFEIDConditionFederated,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             spirev1alpha1.ClusterSPIFFEIDReasonBundlesMissing,
+		Message:            fmt.Sprintf("SPIRE Server has no bundle for trust domains federated with: %s; check that a ClusterFederatedTrustDomain exists for each of them", strings.Join(names, ", ")),
+	}
+}
+
+// entryFailureReason returns the reason for a failure to set an entry.
+func entryFailureReason(err error) string {
+	if isEntryLimitExceeded(err) {
+		return spirev1alpha1.ClusterSPIFFEIDReasonEntryLimitExceeded
+	}
+	return spirev1alpha1.ClusterSPIFFEIDReasonEntryFailed
+}
+
+// maxConflictingObjects is the maximum number of objects named in the
+// MaskedBy and Masking status fields.
+const maxConflictingObjects = 10
+
+// addConflictingObject adds the object to the sorted names of the objects
+// declaring entries similar to the entries of another object. Only the first
+// maxConflictingObjects names are kept, so that the status stays small when
+// many objects conflict.
+func addConflictingObject(names []string, by byObject) []string {
+	name := describeObject(by)
+	i := sort.SearchStrings(names, name)
+	if (i < len(names) && names[i] == name) || i >= maxConflictingObjects {
+		return names
+	}
+	names = append(names, "")
+	copy(names[i+1:], names[i:])
+	names[i] = name
+	if len(names) > maxConflictingObjects {
+		names = names[:maxConflictingObjects]
+	}
+	return names
+}
+
+func isStalledReason(reason string) bool {
+	switch reason {
+	case spirev1alpha1.ClusterSPIFFEIDReasonInvalidSpec, spirev1alpha1.ClusterSPIFFEIDReasonRenderFailed:
+		return true
+	default:
+		return false
+	}
 }
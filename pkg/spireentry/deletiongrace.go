@@ -0,0 +1,79 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// staleEntryTracker defers deleting entries that are no longer declared by
+// any pod or custom resource until they have been unclaimed for a grace
+// period, so that entries are not deleted, and then recreated, while their
+// pod is being rescheduled or their custom resource is being replaced.
+//
+// Entries are tracked by entry ID. An entry that is declared again within
+// the grace period is reused as usual.
+type staleEntryTracker struct {
+	gracePeriod time.Duration
+	clock       clock.Clock
+
+	// unclaimedSince holds when each stale entry was first observed to be
+	// unclaimed, by entry ID.
+	unclaimedSince map[string]time.Time
+}
+
+func newStaleEntryTracker(gracePeriod time.Duration, clk clock.Clock) staleEntryTracker {
+	return staleEntryTracker{
+		gracePeriod:    gracePeriod,
+		clock:          clk,
+		unclaimedSince: make(map[string]time.Time),
+	}
+}
+
+// Enabled returns true if the deletion of stale entries should be deferred.
+func (t *staleEntryTracker) Enabled() bool {
+	return t.gracePeriod > 0
+}
+
+// Expired returns the stale entries that have been unclaimed for longer than
+// the grace period. It must be called once per reconcile with all of the
+// stale entries; entries that are no longer stale stop being tracked.
+func (t *staleEntryTracker) Expired(ctx context.Context, stale []spireapi.Entry) []spireapi.Entry {
+	log := log.FromContext(ctx)
+	now := t.clock.Now()
+
+	unclaimedSince := make(map[string]time.Time, len(stale))
+	var expired []spireapi.Entry
+	for _, entry := range stale {
+		since, ok := t.unclaimedSince[entry.ID]
+		if !ok {
+			since = now
+			log.Info("Deferring deletion of unclaimed entry", entryLogFields(entry)...)
+		}
+		if now.Sub(since) >= t.gracePeriod {
+			expired = append(expired, entry)
+		}
+		unclaimedSince[entry.ID] = since
+	}
+	t.unclaimedSince = unclaimedSince
+	return expired
+}
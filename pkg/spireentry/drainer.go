@@ -0,0 +1,118 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// podEntryDrainer keeps the entries for terminating pods around for a grace
+// period after the pod has disappeared, so that workloads that are draining
+// (e.g. finishing in-flight requests) do not lose their identity until they
+// are actually gone.
+//
+// A pod is terminating once it has a deletion timestamp. It remains
+// terminating, and its entries are declared as usual, until it has been
+// removed from the API server, i.e., after its containers have stopped and
+// all finalizers have been removed. The grace period starts when the pod is
+// first observed to be gone.
+type podEntryDrainer struct {
+	gracePeriod time.Duration
+	clock       clock.Clock
+
+	// terminating holds the entries declared for terminating pods in the
+	// current reconcile.
+	terminating map[entryKey]spireapi.Entry
+
+	// lastTerminating holds the entries declared for terminating pods in the
+	// previous reconcile.
+	lastTerminating map[entryKey]spireapi.Entry
+
+	// draining holds the entries for terminated pods that are retained until
+	// the grace period expires.
+	draining map[entryKey]drainingEntry
+}
+
+type drainingEntry struct {
+	entry     spireapi.Entry
+	expiresAt time.Time
+}
+
+func newPodEntryDrainer(gracePeriod time.Duration, clk clock.Clock) podEntryDrainer {
+	return podEntryDrainer{
+		gracePeriod:     gracePeriod,
+		clock:           clk,
+		terminating:     make(map[entryKey]spireapi.Entry),
+		lastTerminating: make(map[entryKey]spireapi.Entry),
+		draining:        make(map[entryKey]drainingEntry),
+	}
+}
+
+// Enabled returns true if terminated pod entries should be drained.
+func (d *podEntryDrainer) Enabled() bool {
+	return d.gracePeriod > 0
+}
+
+// ObserveTerminating records that the entry was declared for a terminating
+// pod.
+func (d *podEntryDrainer) ObserveTerminating(entry spireapi.Entry) {
+	d.terminating[makeEntryKey(entry)] = entry
+}
+
+// Drain retains the entries for pods that have terminated within the grace
+// period. It must be called once per reconcile, after all declared entries
+// have been added to the state.
+func (d *podEntryDrainer) Drain(ctx context.Context, state entriesState) {
+	log := log.FromContext(ctx)
+	now := d.clock.Now()
+
+	// Entries for pods that were terminating in the previous reconcile that
+	// are no longer declared belong to pods that have gone away. Start
+	// draining them.
+	for key, entry := range d.lastTerminating {
+		if isDeclared(state, key) {
+			continue
+		}
+		if _, ok := d.draining[key]; !ok {
+			d.draining[key] = drainingEntry{
+				entry:     entry,
+				expiresAt: now.Add(d.gracePeriod),
+			}
+			log.Info("Draining entry for terminated pod", entryLogFields(entry)...)
+		}
+	}
+
+	for key, drainingEntry := range d.draining {
+		if isDeclared(state, key) || !now.Before(drainingEntry.expiresAt) {
+			delete(d.draining, key)
+			continue
+		}
+		state.AddRetained(drainingEntry.entry)
+	}
+
+	d.lastTerminating, d.terminating = d.terminating, make(map[entryKey]spireapi.Entry)
+}
+
+func isDeclared(state entriesState, key entryKey) bool {
+	s, ok := state[key]
+	return ok && len(s.Declared) > 0
+}
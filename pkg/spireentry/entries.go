@@ -18,7 +18,6 @@ package spireentry
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"strings"
 	"text/template"
@@ -26,64 +25,71 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func renderStaticEntry(spec *spirev1alpha1.ClusterStaticEntrySpec) (*spireapi.Entry, error) {
-	spiffeID, err := spiffeid.FromString(spec.SPIFFEID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse SPIFFEID: %w", err)
-	}
-	parentID, err := spiffeid.FromString(spec.ParentID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse ParentID: %w", err)
+	return spirev1alpha1.ParseClusterStaticEntrySpec(spec)
+}
+
+// renderNodeAliasEntry renders the node alias entry, which is parented by
+// SPIRE Server and selects the agents of the cluster that have the node
+// labels and selectors.
+func renderNodeAliasEntry(spec *spirev1alpha1.ParsedClusterNodeAliasSpec, trustDomain spiffeid.TrustDomain, clusterName string) (*spireapi.Entry, error) {
+	if spec.SPIFFEID.TrustDomain() != trustDomain {
+		return nil, fmt.Errorf("invalid SPIFFE ID: expected trust domain %q but got %q", trustDomain, spec.SPIFFEID.TrustDomain())
 	}
-	selectors, err := parseSelectors(spec.Selectors)
+	parentID, err := spiffeid.FromPath(trustDomain, "/spire/server")
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Selectors: %w", err)
-	}
-	federatesWith := make([]spiffeid.TrustDomain, 0, len(spec.FederatesWith))
-	for _, value := range spec.FederatesWith {
-		td, err := spiffeid.TrustDomainFromString(value)
-		if err != nil {
-			return nil, fmt.Errorf("invalid federatesWith value: %w", err)
-		}
-		federatesWith = append(federatesWith, td)
+		return nil, fmt.Errorf("failed to render parent ID: %w", err)
 	}
+	selectors := append([]spireapi.Selector{
+		{Type: "k8s_psat", Value: "cluster:" + clusterName},
+	}, spec.Selectors...)
 	return &spireapi.Entry{
-		SPIFFEID:      spiffeID,
-		ParentID:      parentID,
-		Selectors:     selectors,
-		X509SVIDTTL:   spec.X509SVIDTTL.Duration,
-		JWTSVIDTTL:    spec.JWTSVIDTTL.Duration,
-		FederatesWith: federatesWith,
-		DNSNames:      spec.DNSNames,
-		Admin:         spec.Admin,
-		Downstream:    spec.Downstream,
-		Hint:          spec.Hint,
+		SPIFFEID:  spec.SPIFFEID,
+		ParentID:  parentID,
+		Selectors: selectors,
 	}, nil
 }
 
+// checkEntryPathAllowed returns an error if the path of the entry SPIFFE ID
+// is not under one of the allowed path prefixes.
+func checkEntryPathAllowed(entry *spireapi.Entry, allowedPathPrefixes []string) error {
+	if err := spirev1alpha1.CheckPathAllowed(entry.SPIFFEID.Path(), allowedPathPrefixes); err != nil {
+		return fmt.Errorf("invalid SPIFFE ID %q: %w", entry.SPIFFEID, err)
+	}
+	return nil
+}
+
 func renderPodEntry(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, node *corev1.Node, pod *corev1.Pod, trustDomain spiffeid.TrustDomain, clusterName, clusterDomain string) (*spireapi.Entry, error) {
 	// We uniquely target the Pod running on the Node. The former is done
 	// via the k8s:pod-uid selector, the latter via the parent ID.
 	selectors := []spireapi.Selector{
-		{Type: "k8s", Value: fmt.Sprintf("pod-uid:%s", pod.UID)},
+		{Type: "k8s", Value: fmt.Sprintf("pod-uid:%s", kubeletPodUID(pod))},
 	}
-	parentID, err := spiffeid.FromPathf(trustDomain, "/spire/agent/k8s_psat/%s/%s", clusterName, node.UID)
+	parentID, err := spiffeid.FromPath(trustDomain, ClusterAgentPathPrefix(clusterName)+string(node.UID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to render parent ID: %w", err)
 	}
 
+	workloadKind, workloadName := podWorkload(pod)
 	data := &templateData{
 		TrustDomain:   trustDomain.Name(),
 		ClusterName:   clusterName,
 		ClusterDomain: clusterDomain,
 		PodMeta:       &pod.ObjectMeta,
 		PodSpec:       &pod.Spec,
+		PodStatus:     &pod.Status,
 		NodeMeta:      &node.ObjectMeta,
 		NodeSpec:      &node.Spec,
+		NodeName:      node.Name,
+		Zone:          nodeTopologyLabel(node, corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone),
+		Region:        nodeTopologyLabel(node, corev1.LabelTopologyRegion, corev1.LabelFailureDomainBetaRegion),
+		WorkloadKind:  workloadKind,
+		WorkloadName:  workloadName,
 	}
 
 	spiffeID, err := renderSPIFFEID(spec.SPIFFEIDTemplate, data, trustDomain)
@@ -106,12 +112,29 @@ func renderPodEntry(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, node *corev1.
 		}
 	}
 
+	selectorsSet := map[spireapi.Selector]struct{}{selectors[0]: {}}
 	for _, workloadSelectorTemplate := range spec.WorkloadSelectorTemplates {
-		selector, err := renderSelector(workloadSelectorTemplate, data)
+		rendered, err := renderSelectors(workloadSelectorTemplate, data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to render workload selector: %w", err)
 		}
-		selectors = append(selectors, selector)
+
+		// A template may render the same selector more than once, e.g. for
+		// containers running the same image.
+		for _, selector := range rendered {
+			if _, exists := selectorsSet[selector]; !exists {
+				selectorsSet[selector] = struct{}{}
+				selectors = append(selectors, selector)
+			}
+		}
+	}
+
+	var hint string
+	if spec.HintTemplate != nil {
+		hint, err = renderTemplate(spec.HintTemplate, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render hint: %w", err)
+		}
 	}
 
 	return &spireapi.Entry{
@@ -119,13 +142,27 @@ func renderPodEntry(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, node *corev1.
 		ParentID:      parentID,
 		Selectors:     selectors,
 		X509SVIDTTL:   spec.TTL,
+		JWTSVIDTTL:    spec.JWTSVIDTTL,
 		FederatesWith: spec.FederatesWith,
 		DNSNames:      dnsNames,
+		Hint:          hint,
 		Admin:         spec.Admin,
 		Downstream:    spec.Downstream,
 	}, nil
 }
 
+// kubeletPodUID returns the UID of the pod as known to the kubelet, which is
+// what workload attestation observes. For most pods, this is the UID of the
+// pod object. Static pods are known to the kubelet by the hash of the pod
+// manifest, which differs from the UID of the mirror pod in the API server
+// and is recorded in the mirror pod annotation.
+func kubeletPodUID(pod *corev1.Pod) string {
+	if uid := pod.Annotations[corev1.MirrorPodAnnotationKey]; uid != "" {
+		return uid
+	}
+	return string(pod.UID)
+}
+
 type templateData struct {
 	TrustDomain   string
 	ClusterName   string
@@ -134,6 +171,48 @@ type templateData struct {
 	PodSpec       *corev1.PodSpec
 	NodeMeta      *metav1.ObjectMeta
 	NodeSpec      *corev1.NodeSpec
+	NodeName      string
+
+	// PodStatus is the status of the pod, e.g. for the image digests of its
+	// containers, which are only known once the images have been pulled.
+	PodStatus *corev1.PodStatus
+
+	// Zone and Region are the topology of the node the pod is scheduled
+	// on, or empty if the node is not labeled with them.
+	Zone   string
+	Region string
+
+	// WorkloadKind and WorkloadName identify the workload the pod belongs
+	// to. See podWorkload.
+	WorkloadKind string
+	WorkloadName string
+}
+
+// podWorkload returns the kind and name of the workload that owns the pod.
+// This is the controller of the pod, except that pods of ReplicaSets created
+// by a Deployment are attributed to the Deployment, as derived from the
+// pod-template-hash the Deployment adds to the ReplicaSet name. Pods without
+// a controller are their own workload.
+func podWorkload(pod *corev1.Pod) (kind, name string) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod", pod.Name
+	}
+	if owner.Kind == "ReplicaSet" && strings.HasPrefix(owner.APIVersion, "apps/") {
+		if hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+			return "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+		}
+	}
+	return owner.Kind, owner.Name
+}
+
+// nodeTopologyLabel returns the value of the topology label on the node,
+// falling back to the deprecated beta label still set by some providers.
+func nodeTopologyLabel(node *corev1.Node, label, betaLabel string) string {
+	if value := node.Labels[label]; value != "" {
+		return value
+	}
+	return node.Labels[betaLabel]
 }
 
 func renderSPIFFEID(tmpl *template.Template, data *templateData, expectTD spiffeid.TrustDomain) (spiffeid.ID, error) {
@@ -156,22 +235,33 @@ func renderDNSName(tmpl *template.Template, data *templateData) (string, error)
 	if err != nil {
 		return "", err
 	}
-	if err := validateDNSName(rendered); err != nil {
+	if err := spirev1alpha1.ValidateDNSName(rendered); err != nil {
 		return "", fmt.Errorf("invalid DNS name %q: %w", rendered, err)
 	}
 	return rendered, nil
 }
 
-func renderSelector(tmpl *template.Template, data *templateData) (spireapi.Selector, error) {
+// renderSelectors renders the selectors from the template, one per line, so
+// that a template can render a selector for each of a list of values, e.g.
+// the images of the pod containers. Blank lines are ignored.
+func renderSelectors(tmpl *template.Template, data *templateData) ([]spireapi.Selector, error) {
 	rendered, err := renderTemplate(tmpl, data)
 	if err != nil {
-		return spireapi.Selector{}, err
+		return nil, err
 	}
-	selector, err := parseSelector(rendered)
-	if err != nil {
-		return spireapi.Selector{}, fmt.Errorf("invalid workload selector %q: %w", rendered, err)
+	var selectors []spireapi.Selector
+	for _, line := range strings.Split(rendered, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		selector, err := spireapi.ParseSelector(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workload selector %q: %w", line, err)
+		}
+		selectors = append(selectors, selector)
 	}
-	return selector, nil
+	return selectors, nil
 }
 
 func renderTemplate(tmpl *template.Template, data *templateData) (string, error) {
@@ -181,36 +271,3 @@ func renderTemplate(tmpl *template.Template, data *templateData) (string, error)
 	}
 	return buf.String(), nil
 }
-
-func validateDNSName(dnsName string) error {
-	// TODO:
-	return nil
-}
-
-func parseSelectors(selectors []string) ([]spireapi.Selector, error) {
-	ss := make([]spireapi.Selector, 0, len(selectors))
-	for _, selector := range selectors {
-		s, err := parseSelector(selector)
-		if err != nil {
-			return nil, err
-		}
-		ss = append(ss, s)
-	}
-	return ss, nil
-}
-
-func parseSelector(selector string) (spireapi.Selector, error) {
-	parts := strings.SplitN(selector, ":", 2)
-	switch {
-	case len(parts) == 1:
-		return spireapi.Selector{}, errors.New("expected at least one colon separate the type from the value")
-	case len(parts[0]) == 0:
-		return spireapi.Selector{}, errors.New("type cannot be empty")
-	case len(parts[1]) == 0:
-		return spireapi.Selector{}, errors.New("value cannot be empty")
-	}
-	return spireapi.Selector{
-		Type:  parts[0],
-		Value: parts[1],
-	}, nil
-}
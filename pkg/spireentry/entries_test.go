@@ -2,10 +2,13 @@ package spireentry
 
 import (
 	"testing"
+	"time"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -24,6 +27,9 @@ func TestRenderPodEntry(t *testing.T) {
 			"{{ .PodMeta.Name }}.{{ .PodMeta.Namespace }}.svc.{{ .ClusterDomain }}",
 			"{{ .PodMeta.Name }}.{{ .TrustDomain }}.svc",
 		},
+		HintTemplate: "{{ .PodMeta.Namespace }}",
+		X509SVIDTTL:  metav1.Duration{Duration: time.Hour},
+		JWTSVIDTTL:   metav1.Duration{Duration: time.Minute},
 	}
 	node := &corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{
@@ -63,4 +69,198 @@ func TestRenderPodEntry(t *testing.T) {
 	require.Len(t, entry.DNSNames, len(spec.DNSNameTemplates)-1)
 	require.Contains(t, entry.DNSNames, pod.Name+"."+pod.Namespace+".svc."+clusterDomain)
 	require.Contains(t, entry.DNSNames, pod.Name+"."+trustDomain+".svc")
+
+	// TTLs set
+	require.Equal(t, time.Hour, entry.X509SVIDTTL)
+	require.Equal(t, time.Minute, entry.JWTSVIDTTL)
+
+	// Hint rendered correctly
+	require.Equal(t, pod.Namespace, entry.Hint)
+}
+
+func TestRenderPodEntryInvalidDNSName(t *testing.T) {
+	parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/workload",
+		DNSNameTemplates: []string{`{{ index .PodMeta.Annotations "alias" }}.example`},
+	})
+	require.NoError(t, err)
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{UID: "uid"}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "test",
+		Namespace:   "namespace",
+		Annotations: map[string]string{"alias": "my service"},
+	}}
+
+	_, err = renderPodEntry(parsedSpec, node, pod, td, clusterName, clusterDomain)
+	require.EqualError(t, err, `failed to render DNS name: invalid DNS name "my service.example": DNS label "my service" must only contain letters, digits, and hyphens`)
+}
+
+func TestRenderPodEntryWorkloadSelectors(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "uid", Labels: map[string]string{"pool": "secure"}}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "namespace", UID: "poduid"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init", Image: "init:1.0"}},
+			Containers: []corev1.Container{
+				{Name: "app", Image: "app:1.0"},
+				{Name: "sidecar", Image: "app:1.0"},
+			},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", ImageID: "docker.io/library/app@sha256:1234"},
+				{Name: "sidecar", ImageID: "docker.io/library/app@sha256:1234"},
+			},
+		},
+	}
+
+	render := func(workloadSelectorTemplates ...string) (*spireapi.Entry, error) {
+		parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:          "spiffe://{{ .TrustDomain }}/workload",
+			WorkloadSelectorTemplates: workloadSelectorTemplates,
+		})
+		require.NoError(t, err)
+		return renderPodEntry(parsedSpec, node, pod, td, clusterName, clusterDomain)
+	}
+
+	entry, err := render(
+		`{{ range .PodStatus.ContainerStatuses }}{{ printf "k8s:pod-image:%s\n" .ImageID }}{{ end }}`,
+		`{{ range .PodSpec.InitContainers }}{{ printf "k8s:pod-init-image:%s\n" .Image }}{{ end }}`,
+		`{{ range .PodStatus.InitContainerStatuses }}{{ printf "k8s:pod-init-image:%s\n" .ImageID }}{{ end }}`,
+		`k8s:node-name:{{ .NodeName }}`,
+		`custom:node-pool:{{ index .NodeMeta.Labels "pool" }}`,
+	)
+	require.NoError(t, err)
+	require.Equal(t, []spireapi.Selector{
+		{Type: "k8s", Value: "pod-uid:poduid"},
+		{Type: "k8s", Value: "pod-image:docker.io/library/app@sha256:1234"},
+		{Type: "k8s", Value: "pod-init-image:init:1.0"},
+		{Type: "k8s", Value: "node-name:node"},
+		{Type: "custom", Value: "node-pool:secure"},
+	}, entry.Selectors)
+
+	_, err = render("k8s:pod-image:app:1.0\ninvalid")
+	require.EqualError(t, err, `failed to render workload selector: invalid workload selector "invalid": expected at least one colon separate the type from the value`)
+}
+
+func TestRenderPodEntryStaticPod(t *testing.T) {
+	parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+	})
+	require.NoError(t, err)
+	td, err := spiffeid.TrustDomainFromString(trustDomain)
+	require.NoError(t, err)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{UID: "nodeuid"}}
+
+	// Static pods are selected by the UID known to the kubelet, which is
+	// recorded on the mirror pod.
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "etcd-node",
+			Namespace:   "namespace",
+			UID:         "mirrorpoduid",
+			Annotations: map[string]string{corev1.MirrorPodAnnotationKey: "staticpoduid"},
+		},
+	}
+	entry, err := renderPodEntry(parsedSpec, node, pod, td, clusterName, clusterDomain)
+	require.NoError(t, err)
+	require.Equal(t, []spireapi.Selector{{Type: "k8s", Value: "pod-uid:staticpoduid"}}, entry.Selectors)
+
+	// Other pods are selected by their UID.
+	delete(pod.Annotations, corev1.MirrorPodAnnotationKey)
+	entry, err = renderPodEntry(parsedSpec, node, pod, td, clusterName, clusterDomain)
+	require.NoError(t, err)
+	require.Equal(t, []spireapi.Selector{{Type: "k8s", Value: "pod-uid:mirrorpoduid"}}, entry.Selectors)
+}
+
+func TestRenderPodEntryTopology(t *testing.T) {
+	parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/region/{{ .Region }}/zone/{{ .Zone }}/ns/{{ .PodMeta.Namespace }}",
+	})
+	require.NoError(t, err)
+	td, err := spiffeid.TrustDomainFromString(trustDomain)
+	require.NoError(t, err)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "namespace"}}
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{UID: "nodeuid", Labels: map[string]string{
+		corev1.LabelTopologyRegion: "us-east-1",
+		corev1.LabelTopologyZone:   "us-east-1a",
+	}}}
+	entry, err := renderPodEntry(parsedSpec, node, pod, td, clusterName, clusterDomain)
+	require.NoError(t, err)
+	require.Equal(t, "spiffe://example.org/region/us-east-1/zone/us-east-1a/ns/namespace", entry.SPIFFEID.String())
+
+	// The deprecated beta labels are used if the topology labels are unset.
+	node.Labels = map[string]string{
+		corev1.LabelFailureDomainBetaRegion: "eu-west-1",
+		corev1.LabelFailureDomainBetaZone:   "eu-west-1b",
+	}
+	entry, err = renderPodEntry(parsedSpec, node, pod, td, clusterName, clusterDomain)
+	require.NoError(t, err)
+	require.Equal(t, "spiffe://example.org/region/eu-west-1/zone/eu-west-1b/ns/namespace", entry.SPIFFEID.String())
+}
+
+func TestRenderPodEntryWorkload(t *testing.T) {
+	parsedSpec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&spirev1alpha1.ClusterSPIFFEIDSpec{
+		SPIFFEIDTemplate: `spiffe://{{ .TrustDomain }}/team/{{ index .PodMeta.Labels "team" | sanitizePathSegment }}/{{ .WorkloadKind }}/{{ .WorkloadName }}`,
+		DNSNameTemplates: []string{`{{ index .PodMeta.Annotations "alias" | sanitizeDNSLabel }}.{{ .NodeName }}.example`},
+	})
+	require.NoError(t, err)
+	td, err := spiffeid.TrustDomainFromString(trustDomain)
+	require.NoError(t, err)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	controller := true
+	newPod := func(owner *metav1.OwnerReference, labels map[string]string) *corev1.Pod {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        "pod",
+			Namespace:   "namespace",
+			Labels:      map[string]string{"team": "Payments & Billing"},
+			Annotations: map[string]string{"alias": "My_Service"},
+		}}
+		for k, v := range labels {
+			pod.Labels[k] = v
+		}
+		if owner != nil {
+			owner.Controller = &controller
+			pod.OwnerReferences = []metav1.OwnerReference{*owner}
+		}
+		return pod
+	}
+
+	for _, tt := range []struct {
+		desc           string
+		pod            *corev1.Pod
+		expectSPIFFEID string
+	}{
+		{
+			desc:           "no controller",
+			pod:            newPod(nil, nil),
+			expectSPIFFEID: "spiffe://example.org/team/Payments---Billing/Pod/pod",
+		},
+		{
+			desc: "deployment",
+			pod: newPod(&metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5d9c8b7f4"},
+				map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "5d9c8b7f4"}),
+			expectSPIFFEID: "spiffe://example.org/team/Payments---Billing/Deployment/web",
+		},
+		{
+			desc:           "bare replica set",
+			pod:            newPod(&metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web"}, nil),
+			expectSPIFFEID: "spiffe://example.org/team/Payments---Billing/ReplicaSet/web",
+		},
+		{
+			desc:           "stateful set",
+			pod:            newPod(&metav1.OwnerReference{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db"}, nil),
+			expectSPIFFEID: "spiffe://example.org/team/Payments---Billing/StatefulSet/db",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			entry, err := renderPodEntry(parsedSpec, node, tt.pod, td, clusterName, clusterDomain)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectSPIFFEID, entry.SPIFFEID.String())
+			require.Equal(t, []string{"my-service.node.example"}, entry.DNSNames)
+		})
+	}
 }
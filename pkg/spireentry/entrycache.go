@@ -0,0 +1,115 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"k8s.io/utils/clock"
+)
+
+// entryCache holds the entries on SPIRE Server between reconciles so that
+// they do not have to be listed in full on every reconcile. It is seeded by a
+// full listing and kept up to date with the entries SPIRE Server returns
+// from the writes made by the reconciler. Since changes made to the entries
+// by others are not observed, the entries are listed in full again once the
+// resync interval has passed, or whenever the outcome of a write is unknown.
+// The zero value is disabled, i.e. entries are always listed in full. It is
+// safe for concurrent use, since the entries of removed pods are deleted
+// concurrently with reconciliation.
+type entryCache struct {
+	clock          clock.Clock
+	resyncInterval time.Duration
+
+	mtx sync.Mutex
+
+	// entries holds the cached entries by ID. It is nil if the cache must
+	// be resynced.
+	entries  map[string]spireapi.Entry
+	syncedAt time.Time
+}
+
+func newEntryCache(resyncInterval time.Duration, clk clock.Clock) entryCache {
+	return entryCache{
+		clock:          clk,
+		resyncInterval: resyncInterval,
+	}
+}
+
+// Entries returns the cached entries, sorted by ID. It returns false if the
+// entries must be listed in full, i.e. the cache is disabled, invalidated,
+// or due for a resync.
+func (c *entryCache) Entries() ([]spireapi.Entry, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.resyncInterval <= 0 || c.entries == nil {
+		return nil, false
+	}
+	if !c.clock.Now().Before(c.syncedAt.Add(c.resyncInterval)) {
+		return nil, false
+	}
+	entries := make([]spireapi.Entry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, true
+}
+
+// Reset replaces the cached entries with a full listing of the entries.
+func (c *entryCache) Reset(entries []spireapi.Entry) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.resyncInterval <= 0 {
+		return
+	}
+	c.entries = make(map[string]spireapi.Entry, len(entries))
+	for _, entry := range entries {
+		c.entries[entry.ID] = entry
+	}
+	c.syncedAt = c.clock.Now()
+}
+
+// Put caches an entry that was created or updated.
+func (c *entryCache) Put(entry spireapi.Entry) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.entries != nil {
+		c.entries[entry.ID] = entry
+	}
+}
+
+// Delete removes an entry that was deleted, or that no longer exists.
+func (c *entryCache) Delete(entryID string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.entries != nil {
+		delete(c.entries, entryID)
+	}
+}
+
+// Invalidate forces the entries to be listed in full on the next reconcile.
+func (c *entryCache) Invalidate() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.entries = nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Event reasons for ClusterStaticEntries and ClusterNodeAliases.
+// ClusterSPIFFEID Events use the reasons of the Ready condition.
+const (
+	eventReasonRenderFailed = "RenderFailed"
+	eventReasonEntryFailed  = "EntryFailed"
+	eventReasonMasked       = "Masked"
+	eventReasonMasking      = "Masking"
+)
+
+// recordClusterSPIFFEIDEvents records Events explaining why entries for the
+// ClusterSPIFFEID were not registered. Events are recorded on every
+// reconciliation the problem persists; the event recorder aggregates
+// repeated Events.
+func (r *entryReconciler) recordClusterSPIFFEIDEvents(clusterSPIFFEID *ClusterSPIFFEID) {
+	if r.config.EventRecorder == nil {
+		return
+	}
+	obj := &clusterSPIFFEID.ClusterSPIFFEID
+	if clusterSPIFFEID.lastErr != nil {
+		r.config.EventRecorder.Event(obj, corev1.EventTypeWarning, clusterSPIFFEID.lastErrReason, clusterSPIFFEID.lastErr.Error())
+	}
+	if masked := clusterSPIFFEID.NextStatus.Stats.EntriesMasked; masked > 0 {
+		r.config.EventRecorder.Eventf(obj, corev1.EventTypeWarning, eventReasonMasked, "%d entries are masked by similar entries declared by %s", masked, describeConflictingObjects(clusterSPIFFEID.NextStatus.MaskedBy))
+	}
+	if masking := clusterSPIFFEID.NextStatus.Stats.EntriesMasking; masking > 0 {
+		r.config.EventRecorder.Eventf(obj, corev1.EventTypeNormal, eventReasonMasking, "%d entries mask similar entries declared by %s", masking, describeConflictingObjects(clusterSPIFFEID.NextStatus.Masking))
+	}
+}
+
+// recordNamespacedSPIFFEIDEvents records Events explaining why entries for
+// the NamespacedSPIFFEID were not registered.
+func (r *entryReconciler) recordNamespacedSPIFFEIDEvents(namespacedSPIFFEID *NamespacedSPIFFEID) {
+	if r.config.EventRecorder == nil {
+		return
+	}
+	obj := &namespacedSPIFFEID.NamespacedSPIFFEID
+	if namespacedSPIFFEID.lastErr != nil {
+		r.config.EventRecorder.Event(obj, corev1.EventTypeWarning, namespacedSPIFFEID.lastErrReason, namespacedSPIFFEID.lastErr.Error())
+	}
+	if masked := namespacedSPIFFEID.NextStatus.Stats.EntriesMasked; masked > 0 {
+		r.config.EventRecorder.Eventf(obj, corev1.EventTypeWarning, eventReasonMasked, "%d entries are masked by similar entries declared by %s", masked, describeConflictingObjects(namespacedSPIFFEID.NextStatus.MaskedBy))
+	}
+	if masking := namespacedSPIFFEID.NextStatus.Stats.EntriesMasking; masking > 0 {
+		r.config.EventRecorder.Eventf(obj, corev1.EventTypeNormal, eventReasonMasking, "%d entries mask similar entries declared by %s", masking, describeConflictingObjects(namespacedSPIFFEID.NextStatus.Masking))
+	}
+}
+
+// recordClusterNodeAliasEvents records Events explaining why the entry for
+// the ClusterNodeAlias was not registered.
+func (r *entryReconciler) recordClusterNodeAliasEvents(clusterNodeAlias *ClusterNodeAlias) {
+	if r.config.EventRecorder == nil {
+		return
+	}
+	obj := &clusterNodeAlias.ClusterNodeAlias
+	if clusterNodeAlias.lastErr != nil {
+		r.config.EventRecorder.Event(obj, corev1.EventTypeWarning, clusterNodeAlias.lastErrReason, clusterNodeAlias.lastErr.Error())
+	}
+	if clusterNodeAlias.NextStatus.Masked {
+		r.config.EventRecorder.Eventf(obj, corev1.EventTypeWarning, eventReasonMasked, "Entry is masked by a similar entry declared by %s", describeConflictingObjects(clusterNodeAlias.NextStatus.MaskedBy))
+	}
+	if len(clusterNodeAlias.NextStatus.Masking) > 0 {
+		r.config.EventRecorder.Eventf(obj, corev1.EventTypeNormal, eventReasonMasking, "Entry masks similar entries declared by %s", describeConflictingObjects(clusterNodeAlias.NextStatus.Masking))
+	}
+}
+
+// recordClusterStaticEntryEvents records Events explaining why the entry for
+// the ClusterStaticEntry was not registered.
+func (r *entryReconciler) recordClusterStaticEntryEvents(clusterStaticEntry *ClusterStaticEntry) {
+	if r.config.EventRecorder == nil {
+		return
+	}
+	obj := &clusterStaticEntry.ClusterStaticEntry
+	if clusterStaticEntry.lastErr != nil {
+		r.config.EventRecorder.Event(obj, corev1.EventTypeWarning, clusterStaticEntry.lastErrReason, clusterStaticEntry.lastErr.Error())
+	}
+	if clusterStaticEntry.NextStatus.Masked {
+		r.config.EventRecorder.Eventf(obj, corev1.EventTypeWarning, eventReasonMasked, "Entry is masked by a similar entry declared by %s", describeConflictingObjects(clusterStaticEntry.NextStatus.MaskedBy))
+	}
+	if len(clusterStaticEntry.NextStatus.Masking) > 0 {
+		r.config.EventRecorder.Eventf(obj, corev1.EventTypeNormal, eventReasonMasking, "Entry masks similar entries declared by %s", describeConflictingObjects(clusterStaticEntry.NextStatus.Masking))
+	}
+}
+
+// describeConflictingObjects describes the objects named in the MaskedBy or
+// Masking status fields. The list is truncated when too many objects
+// conflict.
+func describeConflictingObjects(names []string) string {
+	description := strings.Join(names, ", ")
+	if len(names) == maxConflictingObjects {
+		description += ", and possibly others"
+	}
+	return description
+}
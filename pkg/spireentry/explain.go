@@ -0,0 +1,348 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/entryhook"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ExplainResult is the outcome of evaluating a ClusterSPIFFEID against a pod.
+type ExplainResult string
+
+const (
+	// ExplainResultRendered means the entry rendered for the pod is set on
+	// SPIRE Server.
+	ExplainResultRendered ExplainResult = "Rendered"
+
+	// ExplainResultMasked means the entry rendered for the pod is masked by
+	// a similar entry declared by an older object.
+	ExplainResultMasked ExplainResult = "Masked"
+
+	// ExplainResultNotSelected means the ClusterSPIFFEID does not select
+	// the pod.
+	ExplainResultNotSelected ExplainResult = "NotSelected"
+
+	// ExplainResultNamespaceIgnored means the pod is in an ignored
+	// namespace.
+	ExplainResultNamespaceIgnored ExplainResult = "NamespaceIgnored"
+
+	// ExplainResultPodPending means the entry is not created until the pod
+	// is Running.
+	ExplainResultPodPending ExplainResult = "PodPending"
+
+	// ExplainResultNotRendered means no entry could be rendered for the pod.
+	ExplainResultNotRendered ExplainResult = "NotRendered"
+
+	// ExplainResultInvalid means the ClusterSPIFFEID spec is invalid.
+	ExplainResultInvalid ExplainResult = "Invalid"
+)
+
+// Explanation describes how the ClusterSPIFFEIDs apply to a pod.
+type Explanation struct {
+	// Namespace is the namespace of the pod.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the pod.
+	Name string `json:"name"`
+
+	// ClusterSPIFFEIDs explains the result of each ClusterSPIFFEID.
+	ClusterSPIFFEIDs []ClusterSPIFFEIDExplanation `json:"clusterSPIFFEIDs"`
+}
+
+// ClusterSPIFFEIDExplanation explains the result of a ClusterSPIFFEID for a
+// pod.
+type ClusterSPIFFEIDExplanation struct {
+	// Name is the name of the ClusterSPIFFEID.
+	Name string `json:"name"`
+
+	// Result is the outcome for the pod.
+	Result ExplainResult `json:"result"`
+
+	// Reason details why the ClusterSPIFFEID did not produce an entry for
+	// the pod.
+	Reason string `json:"reason,omitempty"`
+
+	// Entry is the entry rendered for the pod, if any.
+	Entry *entryhook.Entry `json:"entry,omitempty"`
+
+	// MaskedBy is the object that declared the entry masking the rendered
+	// entry, as "Kind/name".
+	MaskedBy string `json:"maskedBy,omitempty"`
+}
+
+// Explainer explains why pods do or do not get entries. It evaluates the
+// ClusterSPIFFEIDs the same way as the entry reconciler, using the same
+// configuration, but does not change any state.
+type Explainer struct {
+	r *entryReconciler
+}
+
+func NewExplainer(config ReconcilerConfig) *Explainer {
+	return &Explainer{r: &entryReconciler{config: config}}
+}
+
+// Explain explains how the ClusterSPIFFEIDs apply to the pod. Entries are
+// only considered masked by similar entries declared for the same pod or by
+// ClusterStaticEntries.
+func (e *Explainer) Explain(ctx context.Context, podName types.NamespacedName) (*Explanation, error) {
+	// Explanations may run concurrently, so each uses its own reconciler to
+	// hold the services it lists.
+	r := &entryReconciler{config: e.r.config}
+
+	pod := new(corev1.Pod)
+	if err := r.config.K8sClient.Get(ctx, podName, pod); err != nil {
+		return nil, err
+	}
+	namespace := new(corev1.Namespace)
+	if err := r.config.K8sClient.Get(ctx, types.NamespacedName{Name: pod.Namespace}, namespace); err != nil {
+		return nil, err
+	}
+	node := new(corev1.Node)
+	if err := r.config.K8sClient.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		node = nil
+	}
+
+	clusterSPIFFEIDs, err := r.listClusterSPIFFEIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClusterSPIFFEIDs: %w", err)
+	}
+	sets, err := r.listClusterTrustDomainSets(ctx, clusterSPIFFEIDs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClusterTrustDomainSets: %w", err)
+	}
+	clusterStaticEntries, err := r.listClusterStaticEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClusterStaticEntries: %w", err)
+	}
+
+	clusterNodeAliases, err := r.listClusterNodeAliases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClusterNodeAliases: %w", err)
+	}
+
+	// Declare the entries rendered for the pod, along with the static
+	// entries and node aliases, to determine which entries are masked.
+	state := make(entriesState)
+	r.addClusterStaticEntryEntriesState(log.IntoContext(ctx, log.FromContext(ctx).V(1)), state, clusterStaticEntries)
+	r.addClusterNodeAliasEntriesState(log.IntoContext(ctx, log.FromContext(ctx).V(1)), state, clusterNodeAliases)
+
+	explanation := &Explanation{
+		Namespace:        pod.Namespace,
+		Name:             pod.Name,
+		ClusterSPIFFEIDs: make([]ClusterSPIFFEIDExplanation, 0, len(clusterSPIFFEIDs)),
+	}
+	// Like the reconciler, fallback ClusterSPIFFEIDs do not apply to pods
+	// selected by a NamespacedSPIFFEID.
+	selectedBy, err := r.namespacedSPIFFEIDSelectingPod(ctx, pod)
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered []renderedEntry
+	specific, fallbacks := splitFallbacks(clusterSPIFFEIDs)
+	for _, clusterSPIFFEID := range append(specific, fallbacks...) {
+		clusterSPIFFEID.SetTrustDomains, _ = sets.resolve(clusterSPIFFEID.Spec.FederatesWithSets)
+
+		result, reason, entry := r.explainClusterSPIFFEID(ctx, clusterSPIFFEID, namespace, pod, node)
+		switch {
+		case !isPodSelected(result):
+		case !clusterSPIFFEID.Spec.Fallback:
+			if selectedBy == "" {
+				selectedBy = describeObject(clusterSPIFFEID)
+			}
+		case selectedBy != "":
+			result, reason, entry = ExplainResultNotSelected, fmt.Sprintf("fallback does not apply; pod is selected by %s", selectedBy), nil
+		}
+		out := ClusterSPIFFEIDExplanation{
+			Name:   clusterSPIFFEID.Name,
+			Result: result,
+			Reason: reason,
+		}
+		if entry != nil {
+			hookEntry := hookEntryFromEntry(*entry)
+			out.Entry = &hookEntry
+			state.AddDeclared(*entry, clusterSPIFFEID, pod)
+			rendered = append(rendered, renderedEntry{
+				index: len(explanation.ClusterSPIFFEIDs),
+				by:    clusterSPIFFEID,
+				key:   makeEntryKey(*entry),
+			})
+		}
+		explanation.ClusterSPIFFEIDs = append(explanation.ClusterSPIFFEIDs, out)
+	}
+
+	for _, rendered := range rendered {
+		s := state[rendered.key]
+		sortDeclaredEntriesByPreference(s.Declared)
+		if preferred := s.Declared[0].By; preferred != byObject(rendered.by) {
+			explanation.ClusterSPIFFEIDs[rendered.index].Result = ExplainResultMasked
+			explanation.ClusterSPIFFEIDs[rendered.index].MaskedBy = describeObject(preferred)
+		}
+	}
+	return explanation, nil
+}
+
+// ServeHTTP serves the explanation for the pod named by the namespace and
+// name query parameters as JSON.
+func (e *Explainer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	podName := types.NamespacedName{
+		Namespace: req.URL.Query().Get("namespace"),
+		Name:      req.URL.Query().Get("name"),
+	}
+	if podName.Namespace == "" || podName.Name == "" {
+		http.Error(w, "namespace and name query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	explanation, err := e.Explain(req.Context(), podName)
+	switch {
+	case apierrors.IsNotFound(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(explanation)
+}
+
+// renderedEntry tracks the entry rendered for the pod by a ClusterSPIFFEID.
+type renderedEntry struct {
+	index int
+	by    *ClusterSPIFFEID
+	key   entryKey
+}
+
+// explainClusterSPIFFEID evaluates the ClusterSPIFFEID against the pod the
+// same way as the reconciler and returns the entry rendered for the pod, if
+// any. The node is nil if it does not exist.
+func (r *entryReconciler) explainClusterSPIFFEID(ctx context.Context, clusterSPIFFEID *ClusterSPIFFEID, namespace *corev1.Namespace, pod *corev1.Pod, node *corev1.Node) (ExplainResult, string, *spireapi.Entry) {
+	spec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&clusterSPIFFEID.Spec)
+	if err != nil {
+		return ExplainResultInvalid, err.Error(), nil
+	}
+
+	switch {
+	case spec.NamespaceSelector != nil && !spec.NamespaceSelector.Matches(labels.Set(namespace.Labels)):
+		return ExplainResultNotSelected, "namespace selector does not match", nil
+	case r.namespaceFilter().Ignored(namespace):
+		return ExplainResultNamespaceIgnored, fmt.Sprintf("namespace %q is ignored", namespace.Name), nil
+	case spec.PodSelector != nil && !spec.PodSelector.Matches(labels.Set(pod.Labels)):
+		return ExplainResultNotSelected, "pod selector does not match", nil
+	case r.podExcluded(pod):
+		return ExplainResultNotSelected, "pod matches the global podExcludeSelector", nil
+	}
+	if reason := spec.PodExclusionReason(pod); reason != "" {
+		return ExplainResultNotSelected, reason, nil
+	}
+	if !r.podReachedEntryCreationPhase(pod) {
+		return ExplainResultPodPending, "pod is not Running yet", nil
+	}
+	if node == nil {
+		return ExplainResultNotRendered, fmt.Sprintf("node %q does not exist", pod.Spec.NodeName), nil
+	}
+
+	parentID, err := r.serviceAccountParentID(spec)
+	if err != nil {
+		return ExplainResultNotRendered, err.Error(), nil
+	}
+	entry, err := r.renderAllowedPodEntry(spec, node, pod)
+	if err == nil && spec.AutoPopulateDNSNames {
+		entry, err = r.withServiceDNSNames(ctx, entry, pod)
+	}
+	if err != nil {
+		return ExplainResultNotRendered, err.Error(), nil
+	}
+	if !parentID.IsZero() {
+		entry = serviceAccountEntry(entry, pod, parentID)
+	}
+	return ExplainResultRendered, "", withFederatesWith(entry, clusterSPIFFEID.SetTrustDomains)
+}
+
+// namespacedSPIFFEIDSelectingPod returns the description of a
+// NamespacedSPIFFEID that selects the pod, or an empty string if none does
+// or NamespacedSPIFFEIDs are disabled.
+func (r *entryReconciler) namespacedSPIFFEIDSelectingPod(ctx context.Context, pod *corev1.Pod) (string, error) {
+	if r.config.NamespacePathPrefixTemplate == nil || r.podExcluded(pod) {
+		return "", nil
+	}
+	namespacedSPIFFEIDs, err := r.listNamespacedSPIFFEIDs(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list NamespacedSPIFFEIDs: %w", err)
+	}
+	for _, namespacedSPIFFEID := range namespacedSPIFFEIDs {
+		if namespacedSPIFFEID.Namespace != pod.Namespace {
+			continue
+		}
+		spec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(namespacedSPIFFEID.Spec.ClusterSPIFFEIDSpec())
+		if err != nil {
+			continue
+		}
+		if spec.PodSelector != nil && !spec.PodSelector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if spec.SelectsPod(pod) {
+			return describeObject(namespacedSPIFFEID), nil
+		}
+	}
+	return "", nil
+}
+
+// isPodSelected returns whether the ClusterSPIFFEID selects the pod given
+// the result of its evaluation.
+func isPodSelected(result ExplainResult) bool {
+	switch result {
+	case ExplainResultNotSelected, ExplainResultNamespaceIgnored, ExplainResultInvalid:
+		return false
+	default:
+		return true
+	}
+}
+
+func describeObject(by byObject) string {
+	switch by := by.(type) {
+	case *ClusterSPIFFEID:
+		return "ClusterSPIFFEID/" + by.Name
+	case *NamespacedSPIFFEID:
+		return "NamespacedSPIFFEID/" + by.Namespace + "/" + by.Name
+	case *ClusterStaticEntry:
+		return "ClusterStaticEntry/" + by.Name
+	case *ClusterNodeAlias:
+		return "ClusterNodeAlias/" + by.Name
+	default:
+		return string(by.GetUID())
+	}
+}
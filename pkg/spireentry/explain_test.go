@@ -0,0 +1,164 @@
+package spireentry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/namespacefilter"
+	"github.com/spiffe/spire-controller-manager/pkg/test/k8stest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestExplain(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	now := time.Now()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "namespace", UID: "poduid", Labels: map[string]string{"app": "pod"}},
+		Spec:       corev1.PodSpec{NodeName: "node", ServiceAccountName: "sa"},
+	}
+	makeClusterSPIFFEID := func(name string, created time.Time, mutate func(spec *spirev1alpha1.ClusterSPIFFEIDSpec)) *spirev1alpha1.ClusterSPIFFEID {
+		clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.Time{Time: created}},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+			},
+		}
+		if mutate != nil {
+			mutate(&clusterSPIFFEID.Spec)
+		}
+		return clusterSPIFFEID
+	}
+	objects := []client.Object{
+		node, namespace, pod,
+		makeClusterSPIFFEID("older", now, nil),
+		makeClusterSPIFFEID("newer", now.Add(time.Second), nil),
+		makeClusterSPIFFEID("other-app", now, func(spec *spirev1alpha1.ClusterSPIFFEIDSpec) {
+			spec.PodSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "other"}}
+		}),
+		makeClusterSPIFFEID("invalid", now, func(spec *spirev1alpha1.ClusterSPIFFEIDSpec) {
+			spec.SPIFFEIDTemplate = "{{"
+		}),
+	}
+
+	t.Run("explains each ClusterSPIFFEID", func(t *testing.T) {
+		explanation, err := newTestExplainer(t, td, nil, objects...).Explain(context.Background(), types.NamespacedName{Namespace: "namespace", Name: "pod"})
+		require.NoError(t, err)
+
+		results := make(map[string]ClusterSPIFFEIDExplanation)
+		for _, e := range explanation.ClusterSPIFFEIDs {
+			results[e.Name] = e
+		}
+		require.Len(t, results, 4)
+
+		assert.Equal(t, ExplainResultRendered, results["older"].Result)
+		if assert.NotNil(t, results["older"].Entry) {
+			assert.Equal(t, "spiffe://example.org/ns/namespace/sa/sa", results["older"].Entry.SPIFFEID)
+		}
+
+		assert.Equal(t, ExplainResultMasked, results["newer"].Result)
+		assert.Equal(t, "ClusterSPIFFEID/older", results["newer"].MaskedBy)
+
+		assert.Equal(t, ExplainResultNotSelected, results["other-app"].Result)
+		assert.Equal(t, "pod selector does not match", results["other-app"].Reason)
+
+		assert.Equal(t, ExplainResultInvalid, results["invalid"].Result)
+		assert.NotEmpty(t, results["invalid"].Reason)
+	})
+
+	t.Run("explains ignored namespaces", func(t *testing.T) {
+		explanation, err := newTestExplainer(t, td, []string{"namespace"}, objects...).Explain(context.Background(), types.NamespacedName{Namespace: "namespace", Name: "pod"})
+		require.NoError(t, err)
+		for _, e := range explanation.ClusterSPIFFEIDs {
+			switch e.Name {
+			case "older", "newer":
+				assert.Equal(t, ExplainResultNamespaceIgnored, e.Result, e.Name)
+			}
+		}
+	})
+
+	t.Run("explains fallbacks", func(t *testing.T) {
+		fallback := makeClusterSPIFFEID("fallback", now, func(spec *spirev1alpha1.ClusterSPIFFEIDSpec) {
+			spec.Fallback = true
+		})
+
+		explanation, err := newTestExplainer(t, td, nil, append(objects, fallback)...).Explain(context.Background(), types.NamespacedName{Namespace: "namespace", Name: "pod"})
+		require.NoError(t, err)
+		require.Len(t, explanation.ClusterSPIFFEIDs, 5)
+		last := explanation.ClusterSPIFFEIDs[4]
+		assert.Equal(t, "fallback", last.Name)
+		assert.Equal(t, ExplainResultNotSelected, last.Result)
+		assert.Contains(t, last.Reason, "fallback does not apply; pod is selected by ClusterSPIFFEID/")
+
+		explanation, err = newTestExplainer(t, td, nil, node, namespace, pod, fallback).Explain(context.Background(), types.NamespacedName{Namespace: "namespace", Name: "pod"})
+		require.NoError(t, err)
+		require.Len(t, explanation.ClusterSPIFFEIDs, 1)
+		assert.Equal(t, ExplainResultRendered, explanation.ClusterSPIFFEIDs[0].Result)
+
+		namespacePathPrefixTemplate, err := spirev1alpha1.ParseNamespacePathPrefixTemplate(spirev1alpha1.DefaultNamespacePathPrefixTemplate)
+		require.NoError(t, err)
+		namespacedSPIFFEID := &spirev1alpha1.NamespacedSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "namespace", Name: "namespaced"},
+			Spec: spirev1alpha1.NamespacedSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			},
+		}
+		explainer := NewExplainer(ReconcilerConfig{
+			TrustDomain:                 td,
+			ClusterName:                 clusterName,
+			ClusterDomain:               clusterDomain,
+			K8sClient:                   k8stest.NewClientBuilder(t).WithObjects(node, namespace, pod, fallback, namespacedSPIFFEID).Build(),
+			NamespaceFilter:             namespacefilter.NewDynamic(namespacefilter.Filter{}),
+			NamespacePathPrefixTemplate: namespacePathPrefixTemplate,
+		})
+		explanation, err = explainer.Explain(context.Background(), types.NamespacedName{Namespace: "namespace", Name: "pod"})
+		require.NoError(t, err)
+		require.Len(t, explanation.ClusterSPIFFEIDs, 1)
+		assert.Equal(t, ExplainResultNotSelected, explanation.ClusterSPIFFEIDs[0].Result)
+		assert.Equal(t, "fallback does not apply; pod is selected by NamespacedSPIFFEID/namespace/namespaced", explanation.ClusterSPIFFEIDs[0].Reason)
+	})
+
+	t.Run("serves explanations over HTTP", func(t *testing.T) {
+		server := httptest.NewServer(newTestExplainer(t, td, nil, objects...))
+		defer server.Close()
+
+		get := func(query string) *http.Response {
+			resp, err := http.Get(server.URL + "?" + query)
+			require.NoError(t, err)
+			t.Cleanup(func() { resp.Body.Close() })
+			return resp
+		}
+
+		assert.Equal(t, http.StatusBadRequest, get("namespace=namespace").StatusCode)
+		assert.Equal(t, http.StatusNotFound, get("namespace=namespace&name=missing").StatusCode)
+
+		resp := get("namespace=namespace&name=pod")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var explanation Explanation
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&explanation))
+		assert.Equal(t, "pod", explanation.Name)
+		assert.Len(t, explanation.ClusterSPIFFEIDs, 4)
+	})
+}
+
+func newTestExplainer(t *testing.T, td spiffeid.TrustDomain, ignoreNamespaces []string, objects ...client.Object) *Explainer {
+	return NewExplainer(ReconcilerConfig{
+		TrustDomain:     td,
+		ClusterName:     clusterName,
+		ClusterDomain:   clusterDomain,
+		K8sClient:       k8stest.NewClientBuilder(t).WithObjects(objects...).Build(),
+		NamespaceFilter: namespacefilter.NewDynamic(namespacefilter.Filter{IgnoreNamespaces: ignoreNamespaces}),
+	})
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// checkFederatedBundles records on the ClusterSPIFFEIDs and
+// NamespacedSPIFFEIDs the trust domains they federate with that SPIRE Server
+// has no bundle for, since SPIRE Server refuses to create entries that
+// federate with them. The federated bundles are only listed if any of the
+// objects federate with a trust domain. The check is skipped if the bundles
+// cannot be listed, leaving the Federated conditions as they were.
+func (r *entryReconciler) checkFederatedBundles(ctx context.Context, clusterSPIFFEIDs []*ClusterSPIFFEID, namespacedSPIFFEIDs []*NamespacedSPIFFEID) {
+	if r.config.BundleClient == nil {
+		return
+	}
+
+	clusterFederatesWith := make([][]spiffeid.TrustDomain, len(clusterSPIFFEIDs))
+	namespacedFederatesWith := make([][]spiffeid.TrustDomain, len(namespacedSPIFFEIDs))
+	federates := false
+	for i, clusterSPIFFEID := range clusterSPIFFEIDs {
+		clusterFederatesWith[i] = federatedTrustDomains(clusterSPIFFEID.Spec.FederatesWith, clusterSPIFFEID.SetTrustDomains)
+		federates = federates || len(clusterFederatesWith[i]) > 0
+	}
+	for i, namespacedSPIFFEID := range namespacedSPIFFEIDs {
+		namespacedFederatesWith[i] = federatedTrustDomains(namespacedSPIFFEID.Spec.FederatesWith, namespacedSPIFFEID.SetTrustDomains)
+		federates = federates || len(namespacedFederatesWith[i]) > 0
+	}
+
+	available := make(map[spiffeid.TrustDomain]struct{})
+	if federates {
+		bundles, err := r.config.BundleClient.ListFederatedBundles(ctx)
+		if err != nil {
+			metrics.SPIREAPIErrors.WithLabelValues("ListFederatedBundles").Inc()
+			log.FromContext(ctx).Error(err, "Failed to list federated bundles; not checking the trust domains federated with")
+			return
+		}
+		for _, bundle := range bundles {
+			available[bundle.TrustDomain()] = struct{}{}
+		}
+	}
+
+	for i, clusterSPIFFEID := range clusterSPIFFEIDs {
+		clusterSPIFFEID.SetMissingBundles(r.missingBundles(clusterFederatesWith[i], available))
+	}
+	for i, namespacedSPIFFEID := range namespacedSPIFFEIDs {
+		namespacedSPIFFEID.SetMissingBundles(r.missingBundles(namespacedFederatesWith[i], available))
+	}
+}
+
+// missingBundles returns the trust domains that SPIRE Server has no bundle
+// for. The trust domain of SPIRE Server itself is never missing.
+func (r *entryReconciler) missingBundles(tds []spiffeid.TrustDomain, available map[spiffeid.TrustDomain]struct{}) []spiffeid.TrustDomain {
+	var missing []spiffeid.TrustDomain
+	for _, td := range tds {
+		if td == r.config.TrustDomain {
+			continue
+		}
+		if _, ok := available[td]; !ok {
+			missing = append(missing, td)
+		}
+	}
+	return missing
+}
+
+// federatedTrustDomains returns the trust domains federated with, i.e. the
+// trust domains named by federatesWith followed by those resolved from the
+// ClusterTrustDomainSets. Invalid names are skipped since they fail the spec
+// validation.
+func federatedTrustDomains(federatesWith []string, setTrustDomains []spiffeid.TrustDomain) []spiffeid.TrustDomain {
+	var tds []spiffeid.TrustDomain
+	for _, name := range federatesWith {
+		td, err := spiffeid.TrustDomainFromString(name)
+		if err != nil {
+			continue
+		}
+		tds = append(tds, td)
+	}
+	return withFederatesWith(&spireapi.Entry{FederatesWith: tds}, setTrustDomains).FederatesWith
+}
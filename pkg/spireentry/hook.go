@@ -0,0 +1,102 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/entryhook"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func makeEntryCreatedEvent(now time.Time, declaredEntry declaredEntry) entryhook.Event {
+	event := entryhook.Event{
+		Type:  entryhook.EntryCreated,
+		Time:  now,
+		Entry: hookEntryFromEntry(declaredEntry.Entry),
+		Owner: ownerFromObject(declaredEntry.By),
+	}
+	if pod := declaredEntry.Pod; pod != nil {
+		event.Workload = workloadFromPod(pod)
+	}
+	return event
+}
+
+func workloadFromPod(pod *corev1.Pod) *entryhook.Workload {
+	return &entryhook.Workload{
+		Namespace:          pod.Namespace,
+		Name:               pod.Name,
+		UID:                pod.UID,
+		NodeName:           pod.Spec.NodeName,
+		ServiceAccountName: pod.Spec.ServiceAccountName,
+		Labels:             pod.Labels,
+	}
+}
+
+func makeEntryDeletedEvent(now time.Time, entry spireapi.Entry) entryhook.Event {
+	return entryhook.Event{
+		Type:  entryhook.EntryDeleted,
+		Time:  now,
+		Entry: hookEntryFromEntry(entry),
+	}
+}
+
+func ownerFromObject(by byObject) *entryhook.ObjectReference {
+	switch by := by.(type) {
+	case *ClusterSPIFFEID:
+		return &entryhook.ObjectReference{Kind: "ClusterSPIFFEID", Name: by.Name, UID: by.UID}
+	case *NamespacedSPIFFEID:
+		return &entryhook.ObjectReference{Kind: "NamespacedSPIFFEID", Namespace: by.Namespace, Name: by.Name, UID: by.UID}
+	case *ClusterStaticEntry:
+		return &entryhook.ObjectReference{Kind: "ClusterStaticEntry", Name: by.Name, UID: by.UID}
+	case *ClusterNodeAlias:
+		return &entryhook.ObjectReference{Kind: "ClusterNodeAlias", Name: by.Name, UID: by.UID}
+	default:
+		return nil
+	}
+}
+
+func hookEntryFromEntry(entry spireapi.Entry) entryhook.Entry {
+	selectors := make([]string, 0, len(entry.Selectors))
+	for _, selector := range entry.Selectors {
+		selectors = append(selectors, selector.Type+":"+selector.Value)
+	}
+	var federatesWith []string
+	for _, td := range entry.FederatesWith {
+		federatesWith = append(federatesWith, td.String())
+	}
+	hookEntry := entryhook.Entry{
+		ID:            entry.ID,
+		SPIFFEID:      entry.SPIFFEID.String(),
+		ParentID:      entry.ParentID.String(),
+		Selectors:     selectors,
+		FederatesWith: federatesWith,
+		DNSNames:      entry.DNSNames,
+		Admin:         entry.Admin,
+		Downstream:    entry.Downstream,
+		Hint:          entry.Hint,
+		StoreSVID:     entry.StoreSVID,
+	}
+	if entry.X509SVIDTTL != 0 {
+		hookEntry.X509SVIDTTL = entry.X509SVIDTTL.String()
+	}
+	if entry.JWTSVIDTTL != 0 {
+		hookEntry.JWTSVIDTTL = entry.JWTSVIDTTL.String()
+	}
+	return hookEntry
+}
@@ -27,21 +27,26 @@ import (
 )
 
 const (
-	clusterStaticEntryLogKey = "clusterStaticEntry"
-	clusterSPIFFEIDLogKey    = "clusterSPIFFEID"
-	namespaceLogKey          = "namespace"
-	podLogKey                = "pod"
-	idKey                    = "id"
-	parentIDKey              = "parentID"
-	spiffeIDKey              = "spiffeID"
-	selectorsKey             = "selectors"
-	x509SVIDTTLKey           = "x509SVIDTTL"
-	jwtSVIDTTLKey            = "jwtSVIDTTL"
-	federatesWithKey         = "federatesWith"
-	dnsNamesKey              = "dnsNames"
-	adminKey                 = "admin"
-	downstreamKey            = "downstream"
-	hintKey                  = "hint"
+	clusterNodeAliasLogKey      = "clusterNodeAlias"
+	clusterStaticEntryLogKey    = "clusterStaticEntry"
+	clusterSPIFFEIDLogKey       = "clusterSPIFFEID"
+	clusterTrustDomainSetLogKey = "clusterTrustDomainSet"
+	namespacedSPIFFEIDLogKey    = "namespacedSPIFFEID"
+	namespaceLogKey             = "namespace"
+	podLogKey                   = "pod"
+	idKey                       = "id"
+	parentIDKey                 = "parentID"
+	spiffeIDKey                 = "spiffeID"
+	selectorsKey                = "selectors"
+	x509SVIDTTLKey              = "x509SVIDTTL"
+	jwtSVIDTTLKey               = "jwtSVIDTTL"
+	federatesWithKey            = "federatesWith"
+	dnsNamesKey                 = "dnsNames"
+	adminKey                    = "admin"
+	downstreamKey               = "downstream"
+	hintKey                     = "hint"
+	storeSVIDKey                = "storeSVID"
+	outdatedFieldsKey           = "outdatedFields"
 )
 
 func objectName(o metav1.Object) string {
@@ -64,6 +69,7 @@ func entryLogFields(entry spireapi.Entry) []interface{} {
 		adminKey, entry.Admin,
 		downstreamKey, entry.Downstream,
 		hintKey, entry.Hint,
+		storeSVIDKey, entry.StoreSVID,
 	}
 }
 
@@ -0,0 +1,189 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// EntryReconciler is an entry reconciler that can also delete the entries
+// of a deleted pod without waiting for the next reconciliation.
+type EntryReconciler interface {
+	reconciler.Reconciler
+
+	// DeletePodEntries queues the entries of a pod that has been removed
+	// for deletion, so that its identity does not outlive it. It does not
+	// block; the entries are deleted in the background while the
+	// reconciler runs. Reconciliation remains the backstop for entries that
+	// could not be deleted.
+	DeletePodEntries(ctx context.Context, pod *corev1.Pod)
+}
+
+type podEntryDeletingReconciler struct {
+	reconciler.Reconciler
+	*entryReconciler
+}
+
+// Run runs the reconciler, and deletes the entries of removed pods in the
+// background until the reconciler stops.
+func (r podEntryDeletingReconciler) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.runPodEntryDeletion(ctx)
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	return r.Reconciler.Run(ctx)
+}
+
+// DeletePodEntries queues the entries of the pod for deletion. Nothing is
+// deleted in dry run mode, or when entries are retained for a grace period
+// after they are no longer declared. Entries of static pods are left to
+// reconciliation, since their mirror pods are recreated by the kubelet as
+// long as they run.
+func (r *entryReconciler) DeletePodEntries(ctx context.Context, pod *corev1.Pod) {
+	if !r.deletesPodEntries() || pod.Annotations[corev1.MirrorPodAnnotationKey] != "" {
+		return
+	}
+
+	r.removedPodsMtx.Lock()
+	if r.removedPods == nil {
+		r.removedPods = make(map[types.UID]*corev1.Pod)
+	}
+	r.removedPods[pod.UID] = pod
+	r.removedPodsMtx.Unlock()
+
+	select {
+	case r.removedPodsCh <- struct{}{}:
+	default:
+	}
+}
+
+func (r *entryReconciler) deletesPodEntries() bool {
+	return !r.config.DryRun && r.config.TerminatingPodEntryGracePeriod == 0 && r.config.EntryDeletionGracePeriod == 0
+}
+
+// runPodEntryDeletion deletes the entries of the queued pods until the
+// context is canceled. The pods queued while a batch is being deleted are
+// deleted together in the next batch.
+func (r *entryReconciler) runPodEntryDeletion(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.removedPodsCh:
+			r.deleteRemovedPodEntries(ctx)
+		}
+	}
+}
+
+// deleteRemovedPodEntries deletes the entries of the queued pods in a single
+// batch. It runs concurrently with reconciliation, so a reconciliation in
+// progress that listed the pods before they were removed may recreate their
+// entries; those are deleted by the reconciliation that the removal of the
+// pods triggered.
+func (r *entryReconciler) deleteRemovedPodEntries(ctx context.Context) {
+	r.removedPodsMtx.Lock()
+	pods := make([]*corev1.Pod, 0, len(r.removedPods))
+	for _, pod := range r.removedPods {
+		pods = append(pods, pod)
+	}
+	r.removedPods = nil
+	r.removedPodsMtx.Unlock()
+	if len(pods) == 0 {
+		return
+	}
+
+	log := log.FromContext(ctx)
+	entries, err := r.listPodEntries(ctx, pods)
+	if err != nil {
+		metrics.SPIREAPIErrors.WithLabelValues("ListEntries").Inc()
+		log.Error(err, "Failed to list entries of deleted pods", "pods", len(pods))
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+	log.V(1).Info("Deleting entries of deleted pods", "pods", len(pods), "count", len(entries))
+	r.deleteEntries(ctx, entries)
+}
+
+// listPodEntries returns the managed entries rendered for the pods, i.e. the
+// entries parented by an agent of the cluster that select one of the pods by
+// UID. The entry cache is used if it is up to date. Otherwise the entries of
+// a single pod are listed by selector, and the entries are listed in full
+// for several pods, which takes a single listing like a reconciliation does.
+func (r *entryReconciler) listPodEntries(ctx context.Context, pods []*corev1.Pod) ([]spireapi.Entry, error) {
+	selectors := make(map[spireapi.Selector]bool, len(pods))
+	for _, pod := range pods {
+		selectors[podUIDSelector(pod)] = true
+	}
+
+	entries, ok := r.entryCache.Entries()
+	if !ok {
+		var err error
+		if len(pods) == 1 {
+			entries, err = r.config.EntryClient.ListEntriesBySelector(ctx, podUIDSelector(pods[0]))
+		} else {
+			entries, err = r.config.EntryClient.ListEntries(ctx)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	agentPathPrefix := ClusterAgentPathPrefix(r.config.ClusterName)
+	var podEntries []spireapi.Entry
+	for _, entry := range entries {
+		switch {
+		case entry.ParentID.TrustDomain() != r.config.TrustDomain,
+			!strings.HasPrefix(entry.ParentID.Path(), agentPathPrefix),
+			!hasAnySelector(entry, selectors),
+			r.config.ManagesEntry != nil && !r.config.ManagesEntry(entry):
+			continue
+		}
+		podEntries = append(podEntries, entry)
+	}
+	return podEntries, nil
+}
+
+func podUIDSelector(pod *corev1.Pod) spireapi.Selector {
+	return spireapi.Selector{Type: "k8s", Value: "pod-uid:" + kubeletPodUID(pod)}
+}
+
+func hasAnySelector(entry spireapi.Entry, selectors map[spireapi.Selector]bool) bool {
+	for _, entrySelector := range entry.Selectors {
+		if selectors[entrySelector] {
+			return true
+		}
+	}
+	return false
+}
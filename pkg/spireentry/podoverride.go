@@ -0,0 +1,55 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"fmt"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SPIFFEIDAnnotation, when set on a pod, overrides the path of the SPIFFE ID
+// rendered for the pod. The path must be under the path prefix allowed for
+// the namespace of the pod. It is only honored if the reconciler is
+// configured with a SPIFFEIDAnnotationPathPrefixTemplate.
+const SPIFFEIDAnnotation = "spire.spiffe.io/spiffe-id"
+
+// overridePodSPIFFEID replaces the SPIFFE ID of the entry with the one the
+// pod is annotated with, if any and if overrides are enabled.
+func (r *entryReconciler) overridePodSPIFFEID(entry *spireapi.Entry, pod *corev1.Pod) error {
+	path, ok := pod.Annotations[SPIFFEIDAnnotation]
+	if !ok || r.config.SPIFFEIDAnnotationPathPrefixTemplate == nil {
+		return nil
+	}
+
+	id, err := spiffeid.FromPath(r.config.TrustDomain, path)
+	if err != nil {
+		return fmt.Errorf("invalid %s annotation: %w", SPIFFEIDAnnotation, err)
+	}
+	pathPrefix, err := spirev1alpha1.RenderNamespacePathPrefix(r.config.SPIFFEIDAnnotationPathPrefixTemplate, pod.Namespace)
+	if err != nil {
+		return err
+	}
+	if err := spirev1alpha1.CheckPathAllowed(path, []string{pathPrefix}); err != nil {
+		return fmt.Errorf("invalid %s annotation: %w", SPIFFEIDAnnotation, err)
+	}
+	entry.SPIFFEID = id
+	return nil
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// InitIdentityAnnotation, when set to "true" on a pod, declares that the
+// init containers of the pod need an identity. Entries for the pod are then
+// created while it is still Pending even when waiting for running pods.
+const InitIdentityAnnotation = "spire.spiffe.io/init-identity"
+
+// podReachedEntryCreationPhase returns true if entries can be created for
+// the pod, i.e. the pod is running (or has run), needs an identity for its
+// init containers, or the reconciler is not waiting for running pods.
+func (r *entryReconciler) podReachedEntryCreationPhase(pod *corev1.Pod) bool {
+	if !r.config.WaitForRunningPods {
+		return true
+	}
+	switch pod.Status.Phase {
+	case corev1.PodPending, "":
+		return pod.Annotations[InitIdentityAnnotation] == "true"
+	default:
+		return true
+	}
+}
@@ -20,61 +20,289 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"sort"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/entryhook"
+	"github.com/spiffe/spire-controller-manager/pkg/entrytransformer"
+	"github.com/spiffe/spire-controller-manager/pkg/identityreport"
 	"github.com/spiffe/spire-controller-manager/pkg/k8sapi"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/namespacefilter"
 	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
-	"github.com/spiffe/spire-controller-manager/pkg/stringset"
 	"google.golang.org/grpc/codes"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 type ReconcilerConfig struct {
-	TrustDomain      spiffeid.TrustDomain
-	ClusterName      string
-	ClusterDomain    string
-	EntryClient      spireapi.EntryClient
-	K8sClient        client.Client
-	IgnoreNamespaces stringset.StringSet
+	TrustDomain   spiffeid.TrustDomain
+	ClusterName   string
+	ClusterDomain string
+	EntryClient   spireapi.EntryClient
+	K8sClient     client.Client
+
+	// BundleClient, if set, is used to check that SPIRE Server has bundles
+	// for the trust domains that ClusterSPIFFEIDs and NamespacedSPIFFEIDs
+	// federate with. Missing bundles are reported in the Federated
+	// condition.
+	BundleClient spireapi.BundleClient
+
+	// NamespaceFilter ignores namespaces by name or by label. It may be
+	// replaced while the reconciler runs. If nil, no namespaces are ignored.
+	NamespaceFilter *namespacefilter.Dynamic
+
+	// PodExcludeSelector, if set, excludes the pods whose labels match from
+	// every ClusterSPIFFEID and NamespacedSPIFFEID.
+	PodExcludeSelector labels.Selector
+
+	// RetainIgnoredNamespaceEntries, if true, leaves existing entries for
+	// pods in ignored namespaces in place instead of deleting them.
+	RetainIgnoredNamespaceEntries bool
+
+	// ClassName is the class of the ClusterSPIFFEIDs, NamespacedSPIFFEIDs,
+	// and ClusterStaticEntries reconciled. Objects of other classes are
+	// ignored.
+	ClassName string
+
+	// WatchClassless, if true, also reconciles the objects without a class
+	// when ClassName is set.
+	WatchClassless bool
+
+	// AllowedPathPrefixes, if non-empty, restricts the SPIFFE IDs of rendered
+	// entries to paths under one of the prefixes. Entries with other SPIFFE
+	// IDs fail to render.
+	AllowedPathPrefixes []string
+
+	// PrivilegedEntries, if set, restricts the ClusterSPIFFEIDs and
+	// ClusterStaticEntries allowed to declare admin or downstream entries.
+	// The entries of other objects fail to render.
+	PrivilegedEntries *spirev1alpha1.PrivilegedEntriesConfig
+
+	// TerminatingPodEntryGracePeriod, if non-zero, is how long entries for
+	// terminating pods are retained after the pod has been removed.
+	TerminatingPodEntryGracePeriod time.Duration
+
+	// EntryDeletionGracePeriod, if non-zero, is how long entries that are no
+	// longer declared are retained before they are deleted.
+	EntryDeletionGracePeriod time.Duration
+
+	// WaitForRunningPods, if true, delays creating entries for pods until
+	// they are Running, unless they are annotated with
+	// InitIdentityAnnotation.
+	WaitForRunningPods bool
+
+	// EntryCacheResyncInterval, if non-zero, enables caching the entries on
+	// SPIRE Server between reconciles. The entries are listed in full when
+	// the interval has passed since they were last listed, instead of on
+	// every reconcile.
+	EntryCacheResyncInterval time.Duration
 
 	// GCInterval how long to sit idle (i.e. untriggered) before doing
 	// another reconcile.
 	GCInterval time.Duration
+
+	// EntryTransformer, if set, adjusts the declared entries before they are
+	// applied. If the transformation fails, no changes are made to the
+	// entries on SPIRE Server, since applying the untransformed entries
+	// could undo the adjustments.
+	EntryTransformer entrytransformer.Transformer
+
+	// DryRun, if true, logs the entries that would be created, updated, or
+	// deleted instead of changing them.
+	DryRun bool
+
+	// EntryHook, if set, is notified after entries are created or deleted.
+	EntryHook entryhook.Notifier
+
+	// IdentityReporter, if set, is sent a report of the declared identities
+	// after each reconcile.
+	IdentityReporter identityreport.Reporter
+
+	// BatchWindow, if non-zero, is how long to collect triggers before
+	// reconciling, so that many ClusterStaticEntries or ClusterSPIFFEIDs
+	// changing at once are reconciled in a single pass. Statuses are still
+	// written with one update per resource whose status changed.
+	BatchWindow time.Duration
+
+	// RenderWorkers, if greater than one, is how many pods are rendered
+	// concurrently for each ClusterSPIFFEID and NamespacedSPIFFEID. On
+	// clusters with many pods, rendering dominates the reconcile latency.
+	RenderWorkers int
+
+	// SnapshotPath, if set, is the file the entries rendered for pods are
+	// saved to after each reconcile and loaded from on the first reconcile,
+	// so that a restarted controller does not have to render the entries of
+	// unchanged pods again. It does not let the reconciler run before the
+	// caches have synced.
+	SnapshotPath string
+
+	// EventRecorder, if set, records Events on the ClusterSPIFFEIDs and
+	// ClusterStaticEntries that fail to produce entries or whose entries are
+	// masked.
+	EventRecorder record.EventRecorder
+
+	// NamespacePathPrefixTemplate, if set, enables NamespacedSPIFFEIDs. The
+	// SPIFFE IDs of entries rendered for a NamespacedSPIFFEID are restricted
+	// to the path prefix rendered from the template for its namespace.
+	NamespacePathPrefixTemplate *template.Template
+
+	// SPIFFEIDAnnotationPathPrefixTemplate, if set, enables overriding the
+	// SPIFFE IDs of pods with the SPIFFEIDAnnotation. The overriding SPIFFE
+	// ID must be under the path prefix rendered from the template for the
+	// namespace of the pod.
+	SPIFFEIDAnnotationPathPrefixTemplate *template.Template
+
+	// ManagesEntry, if set, limits the entries on SPIRE Server managed by
+	// the reconciler to those it returns true for. Other entries are neither
+	// updated nor deleted, which lets several reconcilers share a SPIRE
+	// Server, e.g. one per cluster in multi-cluster mode.
+	ManagesEntry func(spireapi.Entry) bool
+
+	// RemoteCluster, if true, reconciles the pod entries of a remote cluster
+	// in multi-cluster mode. ClusterStaticEntries are not reconciled, the
+	// entry gauges, which describe the local cluster, are not updated, and
+	// the reconcile metrics are reported under the "entry/<cluster name>"
+	// kind.
+	RemoteCluster bool
+
+	// KindNotServed, if set, reports whether the CRD of a kind is confirmed
+	// not to be installed. Only then is failing to list the kind treated as
+	// there being no objects of the kind; otherwise the reconcile fails so
+	// that nothing is deleted.
+	KindNotServed func(schema.GroupVersionKind) bool
+
+	// SPIREServer is the name of the SPIRE Server target the reconciler
+	// registers entries with. Only the ClusterSPIFFEIDs and
+	// ClusterStaticEntries that select the target are reconciled. If set,
+	// the entry gauges, which describe the default SPIRE Server, are not
+	// updated, and the reconcile metrics are reported under the
+	// "entry@<target name>" kind.
+	SPIREServer string
 }
 
-func Reconciler(config ReconcilerConfig) reconciler.Reconciler {
+// ClusterAgentPathPrefix returns the path prefix of the parent IDs of the pod
+// entries for the cluster, i.e. of the IDs of the agents in the cluster.
+func ClusterAgentPathPrefix(clusterName string) string {
+	return fmt.Sprintf("/spire/agent/k8s_psat/%s/", clusterName)
+}
+
+func Reconciler(config ReconcilerConfig) EntryReconciler {
 	r := &entryReconciler{
-		config: config,
+		config:       config,
+		drainer:      newPodEntryDrainer(config.TerminatingPodEntryGracePeriod, clock.RealClock{}),
+		staleEntries: newStaleEntryTracker(config.EntryDeletionGracePeriod, clock.RealClock{}),
+
+		entryCache:    newEntryCache(config.EntryCacheResyncInterval, clock.RealClock{}),
+		parentBackoff: newParentBackoff(clock.RealClock{}),
+		removedPodsCh: make(chan struct{}, 1),
+	}
+	return podEntryDeletingReconciler{
+		Reconciler: reconciler.New(reconciler.Config{
+			Kind:        r.kind(),
+			Reconcile:   r.reconcile,
+			GCInterval:  config.GCInterval,
+			BatchWindow: config.BatchWindow,
+		}),
+		entryReconciler: r,
 	}
-	return reconciler.New(reconciler.Config{
-		Kind:       "entry",
-		Reconcile:  r.reconcile,
-		GCInterval: config.GCInterval,
-	})
+}
+
+// kind returns the reconciler kind reported in the metrics.
+func (r *entryReconciler) kind() string {
+	switch {
+	case r.config.RemoteCluster:
+		return "entry/" + r.config.ClusterName
+	case r.config.SPIREServer != "":
+		return "entry@" + r.config.SPIREServer
+	}
+	return "entry"
+}
+
+// updatesGauges returns whether the reconciler updates the entry gauges,
+// which describe the local cluster and the default SPIRE Server.
+func (r *entryReconciler) updatesGauges() bool {
+	return !r.config.RemoteCluster && r.config.SPIREServer == ""
 }
 
 type entryReconciler struct {
 	config ReconcilerConfig
+
+	// removedPods holds the pods whose entries are queued for deletion, by
+	// UID. It is guarded by removedPodsMtx since pods are queued by the pod
+	// controller. removedPodsCh wakes the pod entry deletion worker.
+	removedPodsMtx sync.Mutex
+	removedPods    map[types.UID]*corev1.Pod
+	removedPodsCh  chan struct{}
+
+	// entryCache holds the entries on SPIRE Server, if enabled.
+	entryCache entryCache
+
+	// renderCache holds the entries rendered for pods during previous
+	// reconciles.
+	renderCache renderCache
+
+	// services holds the services listed by namespace during the current
+	// reconcile. It is guarded by servicesMtx since pods may be rendered
+	// concurrently.
+	servicesMtx sync.Mutex
+	services    map[string][]corev1.Service
+
+	// nodeAliases holds the SPIFFE IDs of the ClusterNodeAliases rendered
+	// during the current reconcile, by name.
+	nodeAliases map[string]spiffeid.ID
+
+	// snapshotLoaded is true once the render cache has been seeded from the
+	// snapshot, if configured.
+	snapshotLoaded bool
+
+	// drainer retains entries for terminated pods.
+	drainer podEntryDrainer
+
+	// staleEntries defers deleting entries that are no longer declared.
+	staleEntries staleEntryTracker
+
+	// parentBackoff pauses creating entries for parents whose entry limit
+	// was reached.
+	parentBackoff parentBackoff
 }
 
-func (r *entryReconciler) reconcile(ctx context.Context) {
+func (r *entryReconciler) reconcile(ctx context.Context) error {
 	log := log.FromContext(ctx)
 
+	if r.config.SnapshotPath != "" && !r.snapshotLoaded {
+		if err := r.renderCache.Load(r.config.SnapshotPath, renderConfigFingerprint(r.config)); err != nil {
+			log.Error(err, "Failed to load desired state snapshot; entries will be rendered from scratch")
+		}
+		r.snapshotLoaded = true
+	}
+
+	r.services = nil
+	r.nodeAliases = nil
+
 	// Load current entries from SPIRE server.
 	currentEntries, err := r.listEntries(ctx)
 	if err != nil {
+		metrics.SPIREAPIErrors.WithLabelValues("ListEntries").Inc()
 		log.Error(err, "Failed to list SPIRE entries")
-		return
+		return err
 	}
 
 	// Populate the existing state
@@ -83,25 +311,99 @@ func (r *entryReconciler) reconcile(ctx context.Context) {
 		state.AddCurrent(entry)
 	}
 
-	// Load and add entry state for ClusterStaticEntries
-	clusterStaticEntries, err := r.listClusterStaticEntries(ctx)
-	if err != nil {
-		log.Error(err, "Failed to list ClusterStaticEntries")
-		return
+	// Load and add entry state for ClusterStaticEntries. Those of remote
+	// clusters are not reconciled since their entries are not tied to the
+	// cluster.
+	var clusterStaticEntries []*ClusterStaticEntry
+	if !r.config.RemoteCluster {
+		clusterStaticEntries, err = r.listClusterStaticEntries(ctx)
+		if err != nil {
+			log.Error(err, "Failed to list ClusterStaticEntries")
+			return err
+		}
+		r.addClusterStaticEntryEntriesState(ctx, state, clusterStaticEntries)
+	}
+
+	// Load and add entry state for ClusterNodeAliases. Like
+	// ClusterStaticEntries, those of remote clusters are not reconciled.
+	var clusterNodeAliases []*ClusterNodeAlias
+	if !r.config.RemoteCluster {
+		clusterNodeAliases, err = r.listClusterNodeAliases(ctx)
+		if err != nil {
+			log.Error(err, "Failed to list ClusterNodeAliases")
+			return err
+		}
+		r.addClusterNodeAliasEntriesState(ctx, state, clusterNodeAliases)
 	}
-	r.addClusterStaticEntryEntriesState(ctx, state, clusterStaticEntries)
 
 	// Load and add entry state for ClusterSPIFFEIDs
 	clusterSPIFFEIDs, err := r.listClusterSPIFFEIDs(ctx)
 	if err != nil {
 		log.Error(err, "Failed to list ClusterSPIFFEIDs")
-		return
+		return err
+	}
+
+	// Load NamespacedSPIFFEIDs, if enabled
+	var namespacedSPIFFEIDs []*NamespacedSPIFFEID
+	if r.config.NamespacePathPrefixTemplate != nil {
+		namespacedSPIFFEIDs, err = r.listNamespacedSPIFFEIDs(ctx)
+		if err != nil {
+			log.Error(err, "Failed to list NamespacedSPIFFEIDs")
+			return err
+		}
+	}
+
+	sets, err := r.listClusterTrustDomainSets(ctx, clusterSPIFFEIDs, namespacedSPIFFEIDs)
+	if err != nil {
+		log.Error(err, "Failed to list ClusterTrustDomainSets")
+		return err
+	}
+	for _, clusterSPIFFEID := range clusterSPIFFEIDs {
+		var missing []string
+		clusterSPIFFEID.SetTrustDomains, missing = sets.resolve(clusterSPIFFEID.Spec.FederatesWithSets)
+		if len(missing) > 0 {
+			log.Error(nil, "ClusterTrustDomainSets referenced by federatesWithSets do not exist or are invalid; not federating with their trust domains", clusterSPIFFEIDLogKey, objectName(clusterSPIFFEID), "missing", missing)
+		}
+	}
+	for _, namespacedSPIFFEID := range namespacedSPIFFEIDs {
+		var missing []string
+		namespacedSPIFFEID.SetTrustDomains, missing = sets.resolve(namespacedSPIFFEID.Spec.FederatesWithSets)
+		if len(missing) > 0 {
+			log.Error(nil, "ClusterTrustDomainSets referenced by federatesWithSets do not exist or are invalid; not federating with their trust domains", namespacedSPIFFEIDLogKey, objectName(namespacedSPIFFEID), "missing", missing)
+		}
+	}
+	r.checkFederatedBundles(ctx, clusterSPIFFEIDs, namespacedSPIFFEIDs)
+
+	// Fallback ClusterSPIFFEIDs only cover the pods that no other
+	// ClusterSPIFFEID or NamespacedSPIFFEID selects, so they are evaluated
+	// last.
+	specificClusterSPIFFEIDs, fallbackClusterSPIFFEIDs := splitFallbacks(clusterSPIFFEIDs)
+	selectedPods := make(map[types.UID]struct{})
+	r.addClusterSPIFFEIDEntriesState(ctx, state, specificClusterSPIFFEIDs, selectedPods)
+	r.addNamespacedSPIFFEIDEntriesState(ctx, state, namespacedSPIFFEIDs, selectedPods)
+	r.addClusterSPIFFEIDEntriesState(ctx, state, fallbackClusterSPIFFEIDs, selectedPods)
+	r.renderCache.Sweep()
+	if r.config.SnapshotPath != "" && r.renderCache.dirty {
+		if err := r.renderCache.Save(r.config.SnapshotPath, renderConfigFingerprint(r.config)); err != nil {
+			log.Error(err, "Failed to save desired state snapshot")
+		}
+	}
+	if r.config.EntryTransformer != nil {
+		state, err = r.transformEntries(ctx, state)
+		if err != nil {
+			log.Error(err, "Failed to transform entries; not applying changes")
+			return err
+		}
+	}
+	if r.drainer.Enabled() {
+		r.drainer.Drain(ctx, state)
 	}
-	r.addClusterSPIFFEIDEntriesState(ctx, state, clusterSPIFFEIDs)
 
 	var toDelete []spireapi.Entry
 	var toCreate []declaredEntry
 	var toUpdate []declaredEntry
+	var upToDate int
+	report := identityreport.NewReport()
 
 	for _, s := range state {
 		// Sort declared entries.
@@ -110,10 +412,12 @@ func (r *entryReconciler) reconcile(ctx context.Context) {
 			// Grab the first to set.
 			preferredEntry := s.Declared[0]
 			preferredEntry.By.IncrementEntriesToSet()
+			addToReport(report, preferredEntry)
 
 			// Record the remaining as masked.
 			for _, otherEntry := range s.Declared[1:] {
-				otherEntry.By.IncrementEntriesMasked()
+				otherEntry.By.IncrementEntriesMasked(preferredEntry.By)
+				preferredEntry.By.IncrementEntriesMasking(otherEntry.By)
 			}
 
 			// Borrow the current entry ID if available, for the update. Then
@@ -124,33 +428,74 @@ func (r *entryReconciler) reconcile(ctx context.Context) {
 			} else {
 				preferredEntry.Entry.ID = s.Current[0].ID
 				if outdatedFields := getOutdatedEntryFields(preferredEntry.Entry, s.Current[0]); len(outdatedFields) != 0 {
-					// Current field does not match. Nothing to do.
+					log.V(1).Info("Entry is outdated", append(entryLogFields(preferredEntry.Entry), outdatedFieldsKey, outdatedFields)...)
 					toUpdate = append(toUpdate, preferredEntry)
+				} else {
+					// Current entry is up to date. Nothing to do.
+					upToDate++
 				}
 				s.Current = s.Current[1:]
 			}
 		}
 
+		// Retained entries (e.g. for pods in ignored namespaces or pods that
+		// are draining) are left as-is.
+		if len(s.Declared) == 0 && s.Retained {
+			continue
+		}
+
 		// Any remaining current entries should be removed that aren't going
 		// to be reused for the entry update.
 		toDelete = append(toDelete, s.Current...)
 	}
 
-	if len(toDelete) > 0 {
-		r.deleteEntries(ctx, toDelete)
+	// Stale entries are left as-is until they have been unclaimed for the
+	// grace period.
+	if r.staleEntries.Enabled() {
+		numStale := len(toDelete)
+		toDelete = r.staleEntries.Expired(ctx, toDelete)
+		if r.updatesGauges() {
+			metrics.EntriesPendingDeletion.Set(float64(numStale - len(toDelete)))
+		}
 	}
-	if len(toCreate) > 0 {
-		r.createEntries(ctx, toCreate)
+
+	var deleted, created, updated int
+	if r.config.DryRun {
+		logDryRun(ctx, r.kind(), toCreate, toUpdate, toDelete)
+	} else {
+		// Entries for parents whose entry limit was reached are not created
+		// until their backoff expires. Deletions are still made since they
+		// free up room under the limit.
+		toCreate = r.skipBackingOffParents(toCreate)
+
+		if len(toDelete) > 0 {
+			deleted = r.deleteEntries(ctx, toDelete)
+		}
+		if len(toCreate) > 0 {
+			created = r.createEntries(ctx, toCreate)
+		}
+		if r.updatesGauges() {
+			metrics.ParentsBackingOff.Set(float64(r.parentBackoff.Active()))
+		}
+		if len(toUpdate) > 0 {
+			updated = r.updateEntries(ctx, toUpdate)
+		}
+		metrics.EntryUpdates.WithLabelValues(metrics.ResultApplied).Add(float64(updated))
+	}
+	metrics.EntryUpdates.WithLabelValues(metrics.ResultSkipped).Add(float64(upToDate))
+	if r.updatesGauges() {
+		metrics.EntriesManaged.Set(float64(len(currentEntries) - deleted + created))
 	}
-	if len(toUpdate) > 0 {
-		r.updateEntries(ctx, toUpdate)
+	if r.config.IdentityReporter != nil {
+		r.config.IdentityReporter.Publish(ctx, report)
 	}
 
 	// Update the ClusterStaticEntry statuses
 	for _, clusterStaticEntry := range clusterStaticEntries {
 		log := log.WithValues(clusterStaticEntryLogKey, objectName(clusterStaticEntry))
 
-		if clusterStaticEntry.Status == clusterStaticEntry.NextStatus {
+		r.recordClusterStaticEntryEvents(clusterStaticEntry)
+		if equality.Semantic.DeepEqual(clusterStaticEntry.Status, clusterStaticEntry.NextStatus) {
 			continue
 		}
 		clusterStaticEntry.Status = clusterStaticEntry.NextStatus
@@ -161,11 +506,29 @@ func (r *entryReconciler) reconcile(ctx context.Context) {
 		}
 	}
 
+	// Update the ClusterNodeAlias statuses
+	for _, clusterNodeAlias := range clusterNodeAliases {
+		log := log.WithValues(clusterNodeAliasLogKey, objectName(clusterNodeAlias))
+
+		r.recordClusterNodeAliasEvents(clusterNodeAlias)
+		if equality.Semantic.DeepEqual(clusterNodeAlias.Status, clusterNodeAlias.NextStatus) {
+			continue
+		}
+		clusterNodeAlias.Status = clusterNodeAlias.NextStatus
+		if err := r.config.K8sClient.Status().Update(ctx, &clusterNodeAlias.ClusterNodeAlias); err == nil {
+			log.Info("Updated status")
+		} else {
+			log.Error(err, "Failed to update status")
+		}
+	}
+
 	// Update the ClusterSPIFFEID statuses
 	for _, clusterSPIFFEID := range clusterSPIFFEIDs {
 		log := log.WithValues(clusterSPIFFEIDLogKey, objectName(clusterSPIFFEID))
 
-		if clusterSPIFFEID.Status == clusterSPIFFEID.NextStatus {
+		r.recordClusterSPIFFEIDEvents(clusterSPIFFEID)
+		clusterSPIFFEID.SetNextConditions()
+		if equality.Semantic.DeepEqual(clusterSPIFFEID.Status, clusterSPIFFEID.NextStatus) {
 			continue
 		}
 		clusterSPIFFEID.Status = clusterSPIFFEID.NextStatus
@@ -175,20 +538,58 @@ func (r *entryReconciler) reconcile(ctx context.Context) {
 			log.Error(err, "Failed to update status")
 		}
 	}
+
+	// Update the NamespacedSPIFFEID statuses
+	for _, namespacedSPIFFEID := range namespacedSPIFFEIDs {
+		log := log.WithValues(namespacedSPIFFEIDLogKey, objectName(namespacedSPIFFEID))
+
+		r.recordNamespacedSPIFFEIDEvents(namespacedSPIFFEID)
+		namespacedSPIFFEID.SetNextConditions()
+		if equality.Semantic.DeepEqual(namespacedSPIFFEID.Status, namespacedSPIFFEID.NextStatus) {
+			continue
+		}
+		namespacedSPIFFEID.Status = namespacedSPIFFEID.NextStatus
+		if err := r.config.K8sClient.Status().Update(ctx, &namespacedSPIFFEID.NamespacedSPIFFEID); err == nil {
+			log.Info("Updated status")
+		} else {
+			log.Error(err, "Failed to update status")
+		}
+	}
+	return nil
 }
 
 func (r *entryReconciler) listEntries(ctx context.Context) ([]spireapi.Entry, error) {
-	// TODO: cache?
-	return r.config.EntryClient.ListEntries(ctx)
+	entries, ok := r.entryCache.Entries()
+	if !ok {
+		var err error
+		entries, err = r.config.EntryClient.ListEntries(ctx)
+		if err != nil {
+			return nil, err
+		}
+		r.entryCache.Reset(entries)
+	}
+	if r.config.ManagesEntry == nil {
+		return entries, nil
+	}
+	managed := make([]spireapi.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if r.config.ManagesEntry(entry) {
+			managed = append(managed, entry)
+		}
+	}
+	return managed, nil
 }
 
 func (r *entryReconciler) listClusterStaticEntries(ctx context.Context) ([]*ClusterStaticEntry, error) {
 	clusterStaticEntries, err := k8sapi.ListClusterStaticEntries(ctx, r.config.K8sClient)
-	if err != nil {
+	if err := r.ignoreNotServed(err, "ClusterStaticEntry"); err != nil {
 		return nil, err
 	}
 	out := make([]*ClusterStaticEntry, 0, len(clusterStaticEntries))
 	for _, clusterStaticEntry := range clusterStaticEntries {
+		if !r.matchesClass(clusterStaticEntry.Spec.ClassName) || clusterStaticEntry.Spec.SPIREServer != r.config.SPIREServer {
+			continue
+		}
 		out = append(out, &ClusterStaticEntry{
 			ClusterStaticEntry: clusterStaticEntry,
 		})
@@ -196,13 +597,33 @@ func (r *entryReconciler) listClusterStaticEntries(ctx context.Context) ([]*Clus
 	return out, nil
 }
 
+func (r *entryReconciler) listClusterNodeAliases(ctx context.Context) ([]*ClusterNodeAlias, error) {
+	clusterNodeAliases, err := k8sapi.ListClusterNodeAliases(ctx, r.config.K8sClient)
+	if err := r.ignoreNotServed(err, "ClusterNodeAlias"); err != nil {
+		return nil, err
+	}
+	out := make([]*ClusterNodeAlias, 0, len(clusterNodeAliases))
+	for _, clusterNodeAlias := range clusterNodeAliases {
+		if !r.matchesClass(clusterNodeAlias.Spec.ClassName) || clusterNodeAlias.Spec.SPIREServer != r.config.SPIREServer {
+			continue
+		}
+		out = append(out, &ClusterNodeAlias{
+			ClusterNodeAlias: clusterNodeAlias,
+		})
+	}
+	return out, nil
+}
+
 func (r *entryReconciler) listClusterSPIFFEIDs(ctx context.Context) ([]*ClusterSPIFFEID, error) {
 	clusterSPIFFEIDs, err := k8sapi.ListClusterSPIFFEIDs(ctx, r.config.K8sClient)
-	if err != nil {
+	if err := r.ignoreNotServed(err, "ClusterSPIFFEID"); err != nil {
 		return nil, err
 	}
 	out := make([]*ClusterSPIFFEID, 0, len(clusterSPIFFEIDs))
 	for _, clusterSPIFFEID := range clusterSPIFFEIDs {
+		if !r.matchesClass(clusterSPIFFEID.Spec.ClassName) || clusterSPIFFEID.Spec.SPIREServer != r.config.SPIREServer {
+			continue
+		}
 		out = append(out, &ClusterSPIFFEID{
 			ClusterSPIFFEID: clusterSPIFFEID,
 		})
@@ -210,6 +631,43 @@ func (r *entryReconciler) listClusterSPIFFEIDs(ctx context.Context) ([]*ClusterS
 	return out, nil
 }
 
+func (r *entryReconciler) listNamespacedSPIFFEIDs(ctx context.Context) ([]*NamespacedSPIFFEID, error) {
+	namespacedSPIFFEIDs, err := k8sapi.ListNamespacedSPIFFEIDs(ctx, r.config.K8sClient)
+	if err := r.ignoreNotServed(err, "NamespacedSPIFFEID"); err != nil {
+		return nil, err
+	}
+	out := make([]*NamespacedSPIFFEID, 0, len(namespacedSPIFFEIDs))
+	for _, namespacedSPIFFEID := range namespacedSPIFFEIDs {
+		if !r.matchesClass(namespacedSPIFFEID.Spec.ClassName) {
+			continue
+		}
+		out = append(out, &NamespacedSPIFFEID{
+			NamespacedSPIFFEID: namespacedSPIFFEID,
+		})
+	}
+	return out, nil
+}
+
+// ignoreNotServed returns nil if listing the kind failed because its CRD is
+// confirmed not to be installed. See k8sapi.IgnoreNotServed.
+func (r *entryReconciler) ignoreNotServed(err error, kind string) error {
+	return k8sapi.IgnoreNotServed(err, spirev1alpha1.GroupVersion.WithKind(kind), r.config.KindNotServed)
+}
+
+func (r *entryReconciler) namespaceFilter() namespacefilter.Filter {
+	return r.config.NamespaceFilter.Load()
+}
+
+// podExcluded returns true if the pod is excluded by the global pod exclude
+// selector.
+func (r *entryReconciler) podExcluded(pod *corev1.Pod) bool {
+	return r.config.PodExcludeSelector != nil && r.config.PodExcludeSelector.Matches(labels.Set(pod.Labels))
+}
+
+func (r *entryReconciler) matchesClass(className string) bool {
+	return spirev1alpha1.MatchesClass(className, r.config.ClassName, r.config.WatchClassless)
+}
+
 func (r *entryReconciler) listNamespaces(ctx context.Context, namespaceSelector labels.Selector) ([]corev1.Namespace, error) {
 	return k8sapi.ListNamespaces(ctx, r.config.K8sClient, namespaceSelector)
 }
@@ -218,146 +676,581 @@ func (r *entryReconciler) listNamespacePods(ctx context.Context, namespace strin
 	return k8sapi.ListNamespacePods(ctx, r.config.K8sClient, namespace, podSelector)
 }
 
+// listSelectedPods lists the pods in the namespace that are targeted by the
+// ClusterSPIFFEID.
+func (r *entryReconciler) listSelectedPods(ctx context.Context, spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, namespace string) ([]corev1.Pod, error) {
+	pods, err := r.listNamespacePods(ctx, namespace, spec.PodSelector)
+	if err != nil {
+		return nil, err
+	}
+	selected := pods[:0]
+	for i := range pods {
+		if !r.podExcluded(&pods[i]) && spec.SelectsPod(&pods[i]) {
+			selected = append(selected, pods[i])
+		}
+	}
+	return selected, nil
+}
+
 func (r *entryReconciler) addClusterStaticEntryEntriesState(ctx context.Context, state entriesState, clusterStaticEntries []*ClusterStaticEntry) {
 	log := log.FromContext(ctx)
 	for _, clusterStaticEntry := range clusterStaticEntries {
 		log := log.WithValues(clusterSPIFFEIDLogKey, objectName(clusterStaticEntry))
 		entry, err := renderStaticEntry(&clusterStaticEntry.Spec)
+		if err == nil {
+			err = checkEntryPathAllowed(entry, r.config.AllowedPathPrefixes)
+		}
+		if err == nil {
+			err = r.config.PrivilegedEntries.CheckClusterStaticEntry(clusterStaticEntry.Name, entry.Admin, entry.Downstream)
+		}
 		if err != nil {
 			log.Error(err, "Failed to render ClusterStaticEntry")
 			clusterStaticEntry.NextStatus.Rendered = false
+			clusterStaticEntry.lastErrReason = eventReasonRenderFailed
+			clusterStaticEntry.lastErr = err
 			continue
 		}
 		clusterStaticEntry.NextStatus.Rendered = true
-		state.AddDeclared(*entry, clusterStaticEntry)
+		state.AddDeclared(*entry, clusterStaticEntry, nil)
 	}
 }
 
-func (r *entryReconciler) addClusterSPIFFEIDEntriesState(ctx context.Context, state entriesState, clusterSPIFFEIDs []*ClusterSPIFFEID) {
+func (r *entryReconciler) addClusterNodeAliasEntriesState(ctx context.Context, state entriesState, clusterNodeAliases []*ClusterNodeAlias) {
+	log := log.FromContext(ctx)
+	for _, clusterNodeAlias := range clusterNodeAliases {
+		log := log.WithValues(clusterNodeAliasLogKey, objectName(clusterNodeAlias))
+		spec, err := spirev1alpha1.ParseClusterNodeAliasSpec(&clusterNodeAlias.Spec)
+		var entry *spireapi.Entry
+		if err == nil {
+			entry, err = renderNodeAliasEntry(spec, r.config.TrustDomain, r.config.ClusterName)
+		}
+		if err == nil {
+			err = checkEntryPathAllowed(entry, r.config.AllowedPathPrefixes)
+		}
+		if err != nil {
+			log.Error(err, "Failed to render ClusterNodeAlias")
+			clusterNodeAlias.NextStatus.Rendered = false
+			clusterNodeAlias.lastErrReason = eventReasonRenderFailed
+			clusterNodeAlias.lastErr = err
+			continue
+		}
+		clusterNodeAlias.NextStatus.Rendered = true
+		state.AddDeclared(*entry, clusterNodeAlias, nil)
+		if r.nodeAliases == nil {
+			r.nodeAliases = make(map[string]spiffeid.ID)
+		}
+		r.nodeAliases[clusterNodeAlias.Name] = entry.SPIFFEID
+
+		// The nodes are only counted to report them in the status, so the
+		// count is left as-is if they cannot be listed.
+		clusterNodeAlias.NextStatus.NodesSelected = clusterNodeAlias.Status.NodesSelected
+		nodes, err := k8sapi.ListNodes(ctx, r.config.K8sClient, spec.NodeSelector)
+		if err != nil {
+			log.Error(err, "Failed to list nodes")
+			continue
+		}
+		clusterNodeAlias.NextStatus.NodesSelected = len(nodes)
+	}
+}
+
+// addClusterSPIFFEIDEntriesState declares the entries of the
+// ClusterSPIFFEIDs. The pods selected by ClusterSPIFFEIDs that are not
+// fallbacks are added to selectedPods, and fallback ClusterSPIFFEIDs skip
+// the pods already in it.
+func (r *entryReconciler) addClusterSPIFFEIDEntriesState(ctx context.Context, state entriesState, clusterSPIFFEIDs []*ClusterSPIFFEID, selectedPods map[types.UID]struct{}) {
 	log := log.FromContext(ctx)
 	for _, clusterSPIFFEID := range clusterSPIFFEIDs {
 		log := log.WithValues(clusterSPIFFEIDLogKey, objectName(clusterSPIFFEID))
 
 		spec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(&clusterSPIFFEID.Spec)
 		if err != nil {
-			// TODO: should this be prevented via admission webhook? should
-			// we dump this failure into the status?
+			// TODO: should this be prevented via admission webhook?
 			log.Error(err, "Failed to parse ClusterSPIFFEID spec")
+			clusterSPIFFEID.RecordError(spirev1alpha1.ClusterSPIFFEIDReasonInvalidSpec, err)
 			continue
 		}
 
+		if err := r.config.PrivilegedEntries.CheckClusterSPIFFEID(clusterSPIFFEID.Name, spec.Admin, spec.Downstream); err != nil {
+			log.Error(err, "ClusterSPIFFEID is not allowed to declare privileged entries")
+			clusterSPIFFEID.RecordError(spirev1alpha1.ClusterSPIFFEIDReasonRenderFailed, err)
+			continue
+		}
+
+		parentID, err := r.serviceAccountParentID(spec)
+		if err != nil {
+			log.Error(err, "Failed to resolve node alias")
+			clusterSPIFFEID.RecordError(spirev1alpha1.ClusterSPIFFEIDReasonRenderFailed, err)
+			continue
+		}
+
+		// In the ServiceAccount registration mode, the pods of a service
+		// account render the same entry, which is only declared once.
+		var declared map[entryKey]struct{}
+		if !parentID.IsZero() {
+			declared = make(map[entryKey]struct{})
+		}
+
 		// List namespaces applicable to the ClusterSPIFFEID
 		namespaces, err := r.listNamespaces(ctx, spec.NamespaceSelector)
 		if err != nil {
 			log.Error(err, "Failed to list namespaces")
+			clusterSPIFFEID.RecordError(spirev1alpha1.ClusterSPIFFEIDReasonListFailed, err)
 			continue
 		}
 
 		clusterSPIFFEID.NextStatus.Stats.NamespacesSelected += len(namespaces)
 		for i := range namespaces {
-			if r.config.IgnoreNamespaces.In(namespaces[i].Name) {
+			if r.namespaceFilter().Ignored(&namespaces[i]) {
 				clusterSPIFFEID.NextStatus.Stats.NamespacesIgnored++
+				if r.config.RetainIgnoredNamespaceEntries {
+					r.retainNamespaceEntries(ctx, state, clusterSPIFFEID, spec, &namespaces[i])
+				}
 				continue
 			}
 			log := log.WithValues(namespaceLogKey, objectName(&namespaces[i]))
 
-			pods, err := r.listNamespacePods(ctx, namespaces[i].Name, spec.PodSelector)
+			pods, err := r.listSelectedPods(ctx, spec, namespaces[i].Name)
 			switch {
 			case err == nil:
 			case apierrors.IsNotFound(err):
 				continue
 			default:
 				log.Error(err, "Failed to list namespace pods")
+				clusterSPIFFEID.RecordError(spirev1alpha1.ClusterSPIFFEIDReasonListFailed, err)
 				continue
 			}
 
-			clusterSPIFFEID.NextStatus.Stats.PodsSelected += len(pods)
+			var renderPods []*corev1.Pod
 			for i := range pods {
-				log := log.WithValues(podLogKey, objectName(&pods[i]))
+				if spec.Fallback {
+					if _, ok := selectedPods[pods[i].UID]; ok {
+						continue
+					}
+					clusterSPIFFEID.NextStatus.Stats.PodsCoveredByFallback++
+				} else {
+					selectedPods[pods[i].UID] = struct{}{}
+				}
+				clusterSPIFFEID.NextStatus.Stats.PodsSelected++
+				if !r.podReachedEntryCreationPhase(&pods[i]) {
+					continue
+				}
+				renderPods = append(renderPods, &pods[i])
+			}
+
+			for i, rendered := range r.renderPodEntries(ctx, clusterSPIFFEID, clusterSPIFFEID.SetTrustDomains, spec, renderPods) {
+				pod := renderPods[i]
+				log := log.WithValues(podLogKey, objectName(pod))
 
-				entry, err := r.renderPodEntry(ctx, spec, &pods[i])
+				entry, err := rendered.entry, rendered.err
 				switch {
 				case err != nil:
 					log.Error(err, "Failed to render entry")
 					clusterSPIFFEID.NextStatus.Stats.PodEntryRenderFailures++
+					clusterSPIFFEID.RecordError(spirev1alpha1.ClusterSPIFFEIDReasonRenderFailed, fmt.Errorf("failed to render entry for pod %s: %w", objectName(pod), err))
 				case entry != nil:
 					// renderPodEntry will return a nil entry if requisite k8s
 					// objects disappeared from underneath.
-					state.AddDeclared(*entry, clusterSPIFFEID)
+					if declared != nil {
+						entry = serviceAccountEntry(entry, pod, parentID)
+						key := makeEntryKey(*entry)
+						if _, ok := declared[key]; ok {
+							continue
+						}
+						declared[key] = struct{}{}
+					}
+					state.AddDeclared(*entry, clusterSPIFFEID, pod)
+					if pod.DeletionTimestamp != nil && r.drainer.Enabled() {
+						r.drainer.ObserveTerminating(*entry)
+					}
 				}
 			}
 		}
 	}
 }
 
-func (r *entryReconciler) renderPodEntry(ctx context.Context, spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, pod *corev1.Pod) (*spireapi.Entry, error) {
+// splitFallbacks splits the ClusterSPIFFEIDs into those that are not
+// fallbacks and the fallback ClusterSPIFFEIDs.
+func splitFallbacks(clusterSPIFFEIDs []*ClusterSPIFFEID) (specific, fallbacks []*ClusterSPIFFEID) {
+	for _, clusterSPIFFEID := range clusterSPIFFEIDs {
+		if clusterSPIFFEID.Spec.Fallback {
+			fallbacks = append(fallbacks, clusterSPIFFEID)
+		} else {
+			specific = append(specific, clusterSPIFFEID)
+		}
+	}
+	return specific, fallbacks
+}
+
+// addNamespacedSPIFFEIDEntriesState declares the entries of the
+// NamespacedSPIFFEIDs. The pods they select are added to selectedPods, so
+// that fallback ClusterSPIFFEIDs skip them.
+func (r *entryReconciler) addNamespacedSPIFFEIDEntriesState(ctx context.Context, state entriesState, namespacedSPIFFEIDs []*NamespacedSPIFFEID, selectedPods map[types.UID]struct{}) {
+	log := log.FromContext(ctx)
+	for _, namespacedSPIFFEID := range namespacedSPIFFEIDs {
+		log := log.WithValues(namespacedSPIFFEIDLogKey, objectName(namespacedSPIFFEID))
+
+		// Pods in ignored namespaces are not registered, regardless of who
+		// declares them.
+		ignored, err := r.namespaceFilter().IgnoredByName(ctx, r.config.K8sClient, namespacedSPIFFEID.Namespace)
+		if err != nil {
+			log.Error(err, "Failed to get namespace")
+			namespacedSPIFFEID.RecordError(spirev1alpha1.ClusterSPIFFEIDReasonListFailed, err)
+			continue
+		}
+		if ignored {
+			continue
+		}
+
+		spec, err := spirev1alpha1.ParseClusterSPIFFEIDSpec(namespacedSPIFFEID.Spec.ClusterSPIFFEIDSpec())
+		if err != nil {
+			log.Error(err, "Failed to parse NamespacedSPIFFEID spec")
+			namespacedSPIFFEID.RecordError(spirev1alpha1.ClusterSPIFFEIDReasonInvalidSpec, err)
+			continue
+		}
+		namespacePathPrefix, err := spirev1alpha1.RenderNamespacePathPrefix(r.config.NamespacePathPrefixTemplate, namespacedSPIFFEID.Namespace)
+		if err != nil {
+			log.Error(err, "Failed to render namespace path prefix")
+			namespacedSPIFFEID.RecordError(spirev1alpha1.ClusterSPIFFEIDReasonInvalidSpec, err)
+			continue
+		}
+
+		pods, err := r.listSelectedPods(ctx, spec, namespacedSPIFFEID.Namespace)
+		if err != nil {
+			log.Error(err, "Failed to list namespace pods")
+			namespacedSPIFFEID.RecordError(spirev1alpha1.ClusterSPIFFEIDReasonListFailed, err)
+			continue
+		}
+
+		namespacedSPIFFEID.NextStatus.Stats.PodsSelected += len(pods)
+		var renderPods []*corev1.Pod
+		for i := range pods {
+			selectedPods[pods[i].UID] = struct{}{}
+			if r.podReachedEntryCreationPhase(&pods[i]) {
+				renderPods = append(renderPods, &pods[i])
+			}
+		}
+
+		for i, rendered := range r.renderPodEntries(ctx, namespacedSPIFFEID, namespacedSPIFFEID.SetTrustDomains, spec, renderPods) {
+			pod := renderPods[i]
+			log := log.WithValues(podLogKey, objectName(pod))
+
+			entry, err := rendered.entry, rendered.err
+			if err == nil && entry != nil {
+				err = checkEntryPathAllowed(entry, []string{namespacePathPrefix})
+			}
+			switch {
+			case err != nil:
+				log.Error(err, "Failed to render entry")
+				namespacedSPIFFEID.NextStatus.Stats.PodEntryRenderFailures++
+				namespacedSPIFFEID.RecordError(spirev1alpha1.ClusterSPIFFEIDReasonRenderFailed, fmt.Errorf("failed to render entry for pod %s: %w", objectName(pod), err))
+			case entry != nil:
+				state.AddDeclared(*entry, namespacedSPIFFEID, pod)
+				if pod.DeletionTimestamp != nil && r.drainer.Enabled() {
+					r.drainer.ObserveTerminating(*entry)
+				}
+			}
+		}
+	}
+}
+
+// retainNamespaceEntries marks the entries that would be rendered for the pods
+// in an ignored namespace as retained, so that existing entries are neither
+// updated nor deleted.
+func (r *entryReconciler) retainNamespaceEntries(ctx context.Context, state entriesState, clusterSPIFFEID *ClusterSPIFFEID, spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, namespace *corev1.Namespace) {
+	log := log.FromContext(ctx).WithValues(namespaceLogKey, objectName(namespace))
+
+	pods, err := r.listSelectedPods(ctx, spec, namespace.Name)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.Error(err, "Failed to list namespace pods")
+		}
+		return
+	}
+
+	parentID, err := r.serviceAccountParentID(spec)
+	if err != nil {
+		return
+	}
+
+	retained := 0
+	for i := range pods {
+		entry, err := r.renderPodEntry(ctx, clusterSPIFFEID, clusterSPIFFEID.SetTrustDomains, spec, &pods[i])
+		if err != nil || entry == nil {
+			continue
+		}
+		if !parentID.IsZero() {
+			entry = serviceAccountEntry(entry, &pods[i], parentID)
+		}
+		if state.AddRetained(*entry) {
+			retained++
+		}
+	}
+	if retained > 0 {
+		// This repeats on every reconcile, so keep it out of the default log.
+		log.V(1).Info("Retaining existing entries for pods in ignored namespace; they will not be updated", "count", retained)
+	}
+}
+
+// renderResult is the result of rendering the entry for a pod.
+type renderResult struct {
+	entry *spireapi.Entry
+	err   error
+}
+
+// renderPodEntries renders the entries for the pods as selected by the
+// ClusterSPIFFEID or NamespacedSPIFFEID, using up to RenderWorkers
+// goroutines. The results are in the order of the pods, so that the
+// declared entries do not depend on which worker finishes first.
+func (r *entryReconciler) renderPodEntries(ctx context.Context, by metav1.Object, setTrustDomains []spiffeid.TrustDomain, spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, pods []*corev1.Pod) []renderResult {
+	results := make([]renderResult, len(pods))
+	workers := r.config.RenderWorkers
+	if workers > len(pods) {
+		workers = len(pods)
+	}
+	if workers <= 1 {
+		for i, pod := range pods {
+			results[i].entry, results[i].err = r.renderPodEntry(ctx, by, setTrustDomains, spec, pod)
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	indices := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i].entry, results[i].err = r.renderPodEntry(ctx, by, setTrustDomains, spec, pods[i])
+			}
+		}()
+	}
+	for i := range pods {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return results
+}
+
+// renderPodEntry renders the entry for the pod as selected by the
+// ClusterSPIFFEID or NamespacedSPIFFEID. The trust domains resolved from the
+// ClusterTrustDomainSets the object references are added to the entry.
+func (r *entryReconciler) renderPodEntry(ctx context.Context, by metav1.Object, setTrustDomains []spiffeid.TrustDomain, spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, pod *corev1.Pod) (*spireapi.Entry, error) {
 	// TODO: should we be caching this? probably not since it grabs from the
 	// controller client, which is cached already.
 	node := new(corev1.Node)
 	if err := r.config.K8sClient.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
 		return nil, client.IgnoreNotFound(err)
 	}
-	return renderPodEntry(spec, node, pod, r.config.TrustDomain, r.config.ClusterName, r.config.ClusterDomain)
+
+	// Template execution is relatively expensive, so reuse the entry rendered
+	// by a previous reconcile if none of the inputs have changed.
+	if cached, ok := r.renderCache.Get(by, pod, node); ok {
+		if cached.err != nil || !spec.AutoPopulateDNSNames {
+			return withFederatesWith(cached.entry, setTrustDomains), cached.err
+		}
+		entry, err := r.withServiceDNSNames(ctx, cached.entry, pod)
+		return withFederatesWith(entry, setTrustDomains), err
+	}
+	entry, err := r.renderAllowedPodEntry(spec, node, pod)
+	r.renderCache.Put(by, pod, node, entry, err)
+
+	// The trust domains from the ClusterTrustDomainSets and the DNS names of
+	// the services are added after caching since the sets and services can
+	// change without the object generation changing.
+	if err == nil && spec.AutoPopulateDNSNames {
+		entry, err = r.withServiceDNSNames(ctx, entry, pod)
+	}
+	return withFederatesWith(entry, setTrustDomains), err
+}
+
+// renderAllowedPodEntry renders the entry for the pod, applying the SPIFFE ID
+// override the pod is annotated with, and checks that the SPIFFE ID is
+// allowed. The entry is nil if an error is returned.
+func (r *entryReconciler) renderAllowedPodEntry(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec, node *corev1.Node, pod *corev1.Pod) (*spireapi.Entry, error) {
+	entry, err := renderPodEntry(spec, node, pod, r.config.TrustDomain, r.config.ClusterName, r.config.ClusterDomain)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.overridePodSPIFFEID(entry, pod); err != nil {
+		return nil, err
+	}
+	if err := checkEntryPathAllowed(entry, r.config.AllowedPathPrefixes); err != nil {
+		return nil, err
+	}
+	return entry, nil
 }
 
-func (r *entryReconciler) createEntries(ctx context.Context, declaredEntries []declaredEntry) {
+// createEntries creates the entries and returns how many were created.
+func (r *entryReconciler) createEntries(ctx context.Context, declaredEntries []declaredEntry) int {
 	log := log.FromContext(ctx)
 	statuses, err := r.config.EntryClient.CreateEntries(ctx, entriesFromDeclaredEntries(declaredEntries))
 	if err != nil {
+		// Some of the entries may have been created.
+		r.entryCache.Invalidate()
 		for _, declaredEntry := range declaredEntries {
-			declaredEntry.By.IncrementEntryFailures()
+			declaredEntry.By.IncrementEntryFailures(err)
 		}
+		metrics.SPIREAPIErrors.WithLabelValues("CreateEntries").Inc()
 		log.Error(err, "Failed to update entries")
-		return
+		return 0
 	}
+	var created int
+	var events []entryhook.Event
 	for i, status := range statuses {
 		switch status.Code {
 		case codes.OK:
 			log.Info("Created entry", entryLogFields(declaredEntries[i].Entry)...)
 			declaredEntries[i].By.IncrementEntrySuccess()
+			events = append(events, makeEntryCreatedEvent(time.Now(), declaredEntries[i]))
+			r.parentBackoff.Succeeded(declaredEntries[i].Entry.ParentID)
+			r.cacheWrittenEntry(status)
+			created++
+		case codes.ResourceExhausted:
+			declaredEntries[i].By.IncrementEntryFailures(status.Err())
+			metrics.EntryLimitExceeded.Inc()
+			log.Error(status.Err(), "Entry limit of parent exceeded; pausing entry creation for the parent", entryLogFields(declaredEntries[i].Entry)...)
+			r.parentBackoff.Exhausted(declaredEntries[i].Entry.ParentID)
+		case codes.AlreadyExists:
+			// The entry was created by others since the entries were
+			// listed, so the cache is out of date.
+			r.entryCache.Invalidate()
+			declaredEntries[i].By.IncrementEntryFailures(status.Err())
+			metrics.SPIREAPIErrors.WithLabelValues("CreateEntries").Inc()
+			log.Error(status.Err(), "Failed to create entry", entryLogFields(declaredEntries[i].Entry)...)
 		default:
-			declaredEntries[i].By.IncrementEntryFailures()
+			declaredEntries[i].By.IncrementEntryFailures(status.Err())
+			metrics.SPIREAPIErrors.WithLabelValues("CreateEntries").Inc()
 			log.Error(status.Err(), "Failed to create entry", entryLogFields(declaredEntries[i].Entry)...)
 		}
 	}
+	metrics.EntryChanges.WithLabelValues(metrics.OperationCreate).Add(float64(created))
+	r.notifyEntryHook(ctx, events)
+	return created
+}
+
+// logDryRun logs the changes that would be made to the entries and records
+// how many there are.
+func logDryRun(ctx context.Context, kind string, toCreate, toUpdate []declaredEntry, toDelete []spireapi.Entry) {
+	log := log.FromContext(ctx)
+	for _, declaredEntry := range toCreate {
+		log.Info("Dry run: would create entry", entryLogFields(declaredEntry.Entry)...)
+	}
+	for _, declaredEntry := range toUpdate {
+		log.Info("Dry run: would update entry", entryLogFields(declaredEntry.Entry)...)
+	}
+	for _, entry := range toDelete {
+		log.Info("Dry run: would delete entry", entryLogFields(entry)...)
+	}
+	metrics.DryRunChanges.WithLabelValues(kind, metrics.OperationCreate).Set(float64(len(toCreate)))
+	metrics.DryRunChanges.WithLabelValues(kind, metrics.OperationUpdate).Set(float64(len(toUpdate)))
+	metrics.DryRunChanges.WithLabelValues(kind, metrics.OperationDelete).Set(float64(len(toDelete)))
+}
+
+// skipBackingOffParents returns the entries whose parents are not backing
+// off. The skipped entries are recorded as failures.
+func (r *entryReconciler) skipBackingOffParents(declaredEntries []declaredEntry) []declaredEntry {
+	filtered := declaredEntries[:0]
+	for _, declaredEntry := range declaredEntries {
+		if err := r.parentBackoff.Err(declaredEntry.Entry.ParentID); err != nil {
+			declaredEntry.By.IncrementEntryFailures(err)
+			continue
+		}
+		filtered = append(filtered, declaredEntry)
+	}
+	return filtered
 }
 
-func (r *entryReconciler) updateEntries(ctx context.Context, declaredEntries []declaredEntry) {
+// updateEntries updates the entries and returns how many were updated.
+func (r *entryReconciler) updateEntries(ctx context.Context, declaredEntries []declaredEntry) int {
 	log := log.FromContext(ctx)
 	statuses, err := r.config.EntryClient.UpdateEntries(ctx, entriesFromDeclaredEntries(declaredEntries))
 	if err != nil {
+		// Some of the entries may have been updated.
+		r.entryCache.Invalidate()
 		for _, declaredEntry := range declaredEntries {
-			declaredEntry.By.IncrementEntryFailures()
+			declaredEntry.By.IncrementEntryFailures(err)
 		}
+		metrics.SPIREAPIErrors.WithLabelValues("UpdateEntries").Inc()
 		log.Error(err, "Failed to update entries")
-		return
+		return 0
 	}
+	var updated int
 	for i, status := range statuses {
 		switch status.Code {
 		case codes.OK:
 			log.Info("Updated entry", entryLogFields(declaredEntries[i].Entry)...)
+			r.cacheWrittenEntry(status)
+			updated++
+		case codes.NotFound:
+			// The entry was deleted by others. It is created again on the
+			// next reconcile.
+			r.entryCache.Delete(declaredEntries[i].Entry.ID)
+			declaredEntries[i].By.IncrementEntryFailures(status.Err())
+			metrics.SPIREAPIErrors.WithLabelValues("UpdateEntries").Inc()
+			log.Error(status.Err(), "Failed to update entry", entryLogFields(declaredEntries[i].Entry)...)
 		default:
-			declaredEntries[i].By.IncrementEntryFailures()
+			declaredEntries[i].By.IncrementEntryFailures(status.Err())
+			metrics.SPIREAPIErrors.WithLabelValues("UpdateEntries").Inc()
 			log.Error(status.Err(), "Failed to update entry", entryLogFields(declaredEntries[i].Entry)...)
 		}
 	}
+	metrics.EntryChanges.WithLabelValues(metrics.OperationUpdate).Add(float64(updated))
+	return updated
 }
 
-func (r *entryReconciler) deleteEntries(ctx context.Context, entries []spireapi.Entry) {
+// deleteEntries deletes the entries and returns how many were deleted.
+func (r *entryReconciler) deleteEntries(ctx context.Context, entries []spireapi.Entry) int {
 	log := log.FromContext(ctx)
 	statuses, err := r.config.EntryClient.DeleteEntries(ctx, idsFromEntries(entries))
 	if err != nil {
+		// Some of the entries may have been deleted.
+		r.entryCache.Invalidate()
+		metrics.SPIREAPIErrors.WithLabelValues("DeleteEntries").Inc()
 		log.Error(err, "Failed to delete entries")
-		return
+		return 0
 	}
+	var deleted int
+	var events []entryhook.Event
 	for i, status := range statuses {
 		switch status.Code {
 		case codes.OK:
 			log.Info("Deleted entry", entryLogFields(entries[i])...)
+			events = append(events, makeEntryDeletedEvent(time.Now(), entries[i]))
+			r.entryCache.Delete(entries[i].ID)
+			deleted++
+		case codes.NotFound:
+			// The entry was deleted by others since the entries were
+			// listed, e.g. by the deletion of the entries of a removed pod
+			// racing the reconciliation the removal triggered.
+			r.entryCache.Delete(entries[i].ID)
+			log.V(1).Info("Entry already deleted", entryLogFields(entries[i])...)
 		default:
+			metrics.SPIREAPIErrors.WithLabelValues("DeleteEntries").Inc()
 			log.Error(status.Err(), "Failed to delete entry", entryLogFields(entries[i])...)
 		}
 	}
+	metrics.EntryChanges.WithLabelValues(metrics.OperationDelete).Add(float64(deleted))
+	r.notifyEntryHook(ctx, events)
+	return deleted
+}
+
+// cacheWrittenEntry caches the entry SPIRE Server returned for a successful
+// create or update. If it did not return the entry, the cache is resynced
+// instead.
+func (r *entryReconciler) cacheWrittenEntry(status spireapi.EntryStatus) {
+	if status.Entry == nil {
+		r.entryCache.Invalidate()
+		return
+	}
+	r.entryCache.Put(*status.Entry)
+}
+
+func (r *entryReconciler) notifyEntryHook(ctx context.Context, events []entryhook.Event) {
+	if r.config.EntryHook != nil && len(events) > 0 {
+		r.config.EntryHook.Notify(ctx, events)
+	}
 }
 
 type entriesState map[entryKey]*entryState
@@ -367,14 +1260,25 @@ func (es entriesState) AddCurrent(entry spireapi.Entry) {
 	s.Current = append(s.Current, entry)
 }
 
-func (es entriesState) AddDeclared(entry spireapi.Entry, by byObject) {
+// AddDeclared adds an entry declared by the object. The pod is the workload
+// the entry was rendered for, if any.
+func (es entriesState) AddDeclared(entry spireapi.Entry, by byObject, pod *corev1.Pod) {
 	s := es.stateFor(entry)
 	s.Declared = append(s.Declared, declaredEntry{
 		Entry: entry,
 		By:    by,
+		Pod:   pod,
 	})
 }
 
+// AddRetained marks the entry as retained. It returns true if there is a
+// current entry that will be retained as a result.
+func (es entriesState) AddRetained(entry spireapi.Entry) bool {
+	s := es.stateFor(entry)
+	s.Retained = true
+	return len(s.Current) > 0
+}
+
 func (es entriesState) stateFor(entry spireapi.Entry) *entryState {
 	key := makeEntryKey(entry)
 	s, ok := es[key]
@@ -388,11 +1292,13 @@ func (es entriesState) stateFor(entry spireapi.Entry) *entryState {
 type entryState struct {
 	Current  []spireapi.Entry
 	Declared []declaredEntry
+	Retained bool
 }
 
 type declaredEntry struct {
 	Entry spireapi.Entry
 	By    byObject
+	Pod   *corev1.Pod
 }
 
 type entryKey string
@@ -433,6 +1339,14 @@ func sortDeclaredEntriesByPreference(entries []declaredEntry) {
 }
 
 func objectCmp(a, b byObject) int {
+	// Fallback ClusterSPIFFEIDs never mask the entries of other objects
+	if aFallback, bFallback := isFallback(a), isFallback(b); aFallback != bFallback {
+		if aFallback {
+			return 1
+		}
+		return -1
+	}
+
 	// Sort ascending by creation timestamp
 	creationDiff := a.GetCreationTimestamp().UnixNano() - b.GetCreationTimestamp().UnixNano()
 	switch {
@@ -460,6 +1374,14 @@ func objectCmp(a, b byObject) int {
 		}
 	}
 
+	// Creation timestamps only have a second of precision, so objects
+	// created together often tie. Tie-break with the kind and name, which
+	// unlike the UID are known to the user, to keep the precedence
+	// predictable.
+	if aName, bName := describeObject(a), describeObject(b); aName != bName {
+		return strings.Compare(aName, bName)
+	}
+
 	// At this point, these two entries are more or less equal in
 	// precedence, but we need a stable sorting mechanism, so tie-break
 	// with the UID.
@@ -473,15 +1395,25 @@ func objectCmp(a, b byObject) int {
 	}
 }
 
+func isFallback(by byObject) bool {
+	clusterSPIFFEID, ok := by.(*ClusterSPIFFEID)
+	return ok && clusterSPIFFEID.Spec.Fallback
+}
+
+// getOutdatedEntryFields returns the fields of the current entry that do not
+// match the declared entry. Fields are compared the way SPIRE Server stores
+// them, so that entries are not rewritten on every pass for differences that
+// do not survive the round trip: TTLs are compared in whole seconds, and
+// federatesWith and dnsNames as sets.
 func getOutdatedEntryFields(newEntry, oldEntry spireapi.Entry) []string {
 	// We don't need to bother with the parent ID, the SPIFFE ID, or the
 	// selectors since they are part of the uniqueness check that resulted in
 	// the AlreadyExists error code.
 	var outdated []string
-	if oldEntry.X509SVIDTTL != newEntry.X509SVIDTTL {
+	if !ttlsMatch(oldEntry.X509SVIDTTL, newEntry.X509SVIDTTL) {
 		outdated = append(outdated, "x509SVIDTTL")
 	}
-	if oldEntry.JWTSVIDTTL != newEntry.JWTSVIDTTL {
+	if !ttlsMatch(oldEntry.JWTSVIDTTL, newEntry.JWTSVIDTTL) {
 		outdated = append(outdated, "jwtSVIDTTL")
 	}
 	if !trustDomainsMatch(oldEntry.FederatesWith, newEntry.FederatesWith) {
@@ -499,40 +1431,40 @@ func getOutdatedEntryFields(newEntry, oldEntry spireapi.Entry) []string {
 	if oldEntry.Hint != newEntry.Hint {
 		outdated = append(outdated, "hint")
 	}
+	if oldEntry.StoreSVID != newEntry.StoreSVID {
+		outdated = append(outdated, "storeSVID")
+	}
 
 	return outdated
 }
 
+// ttlsMatch returns true if the TTLs are the same in whole seconds, the
+// precision of the SPIRE API.
+func ttlsMatch(a, b time.Duration) bool {
+	return a/time.Second == b/time.Second
+}
+
+// trustDomainsMatch returns true if the trust domains are the same, ignoring
+// order and duplicates.
 func trustDomainsMatch(as, bs []spiffeid.TrustDomain) bool {
-	if len(as) != len(bs) {
-		return false
-	}
-	// copy the sort the slices
-	as = append([]spiffeid.TrustDomain(nil), as...)
-	sort.Slice(as, func(i, j int) bool {
-		return as[i].Compare(as[j]) < 0
-	})
-	bs = append([]spiffeid.TrustDomain(nil), bs...)
-	sort.Slice(bs, func(i, j int) bool {
-		return bs[i].Compare(bs[j]) < 0
-	})
-	for i := range as {
-		if as[i] != bs[i] {
-			return false
-		}
+	return stringsMatch(trustDomainNames(as), trustDomainNames(bs))
+}
+
+func trustDomainNames(tds []spiffeid.TrustDomain) []string {
+	names := make([]string, 0, len(tds))
+	for _, td := range tds {
+		names = append(names, td.Name())
 	}
-	return true
+	return names
 }
 
+// stringsMatch returns true if the strings are the same, ignoring order and
+// duplicates.
 func stringsMatch(as, bs []string) bool {
+	as, bs = sortedUnique(as), sortedUnique(bs)
 	if len(as) != len(bs) {
 		return false
 	}
-	// copy the sort the slices
-	as = append([]string(nil), as...)
-	sort.Strings(as)
-	bs = append([]string(nil), bs...)
-	sort.Strings(bs)
 	for i := range as {
 		if as[i] != bs[i] {
 			return false
@@ -541,6 +1473,19 @@ func stringsMatch(as, bs []string) bool {
 	return true
 }
 
+func sortedUnique(ss []string) []string {
+	// copy then sort the slice
+	sorted := append([]string(nil), ss...)
+	sort.Strings(sorted)
+	unique := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
+
 func entriesFromDeclaredEntries(declaredEntries []declaredEntry) []spireapi.Entry {
 	entries := make([]spireapi.Entry, 0, len(declaredEntries))
 	for _, declaredEntry := range declaredEntries {
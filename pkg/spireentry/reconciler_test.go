@@ -1,11 +1,40 @@
 package spireentry
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"testing"
+	"text/template"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/entryhook"
+	"github.com/spiffe/spire-controller-manager/pkg/entrytransformer"
+	"github.com/spiffe/spire-controller-manager/pkg/identityreport"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
+	"github.com/spiffe/spire-controller-manager/pkg/namespacefilter"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/spiffe/spire-controller-manager/pkg/test/k8stest"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 func TestMakeEntryKey(t *testing.T) {
@@ -75,3 +104,2732 @@ func TestMakeEntryKey(t *testing.T) {
 		require.Equal(t, makeEntryKey(a), makeEntryKey(b))
 	})
 }
+
+func TestGetOutdatedEntryFields(t *testing.T) {
+	a := spiffeid.RequireTrustDomainFromString("a.test")
+	b := spiffeid.RequireTrustDomainFromString("b.test")
+	current := spireapi.Entry{
+		X509SVIDTTL:   time.Hour,
+		JWTSVIDTTL:    5 * time.Minute,
+		FederatesWith: []spiffeid.TrustDomain{a, b},
+		DNSNames:      []string{"a.test", "b.test"},
+		Hint:          "hint",
+	}
+
+	for _, tt := range []struct {
+		name     string
+		modify   func(*spireapi.Entry)
+		outdated []string
+	}{
+		{
+			name:   "same",
+			modify: func(*spireapi.Entry) {},
+		},
+		{
+			name:   "TTLs within the same second",
+			modify: func(e *spireapi.Entry) { e.X509SVIDTTL += 500 * time.Millisecond; e.JWTSVIDTTL += time.Millisecond },
+		},
+		{
+			name:   "federatesWith reordered and duplicated",
+			modify: func(e *spireapi.Entry) { e.FederatesWith = []spiffeid.TrustDomain{b, a, b} },
+		},
+		{
+			name:   "dnsNames reordered and duplicated",
+			modify: func(e *spireapi.Entry) { e.DNSNames = []string{"b.test", "a.test", "a.test"} },
+		},
+		{
+			name:     "TTLs changed",
+			modify:   func(e *spireapi.Entry) { e.X509SVIDTTL = 0; e.JWTSVIDTTL = 6 * time.Minute },
+			outdated: []string{"x509SVIDTTL", "jwtSVIDTTL"},
+		},
+		{
+			name:     "federatesWith changed",
+			modify:   func(e *spireapi.Entry) { e.FederatesWith = []spiffeid.TrustDomain{a, a} },
+			outdated: []string{"federatesWith"},
+		},
+		{
+			name:     "dnsNames changed",
+			modify:   func(e *spireapi.Entry) { e.DNSNames = []string{"a.test", "c.test"} },
+			outdated: []string{"dnsNames"},
+		},
+		{
+			name:     "other fields changed",
+			modify:   func(e *spireapi.Entry) { e.Admin = true; e.Downstream = true; e.Hint = ""; e.StoreSVID = true },
+			outdated: []string{"admin", "downstream", "hint", "storeSVID"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			declared := current
+			declared.FederatesWith = append([]spiffeid.TrustDomain(nil), current.FederatesWith...)
+			declared.DNSNames = append([]string(nil), current.DNSNames...)
+			tt.modify(&declared)
+			require.Equal(t, tt.outdated, getOutdatedEntryFields(declared, current))
+		})
+	}
+}
+
+func TestReconcileIgnoredNamespaceEntries(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ignored"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ignored", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node", ServiceAccountName: "sa"},
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+		},
+	}
+	existing := spireapi.Entry{
+		ID:        "existing",
+		SPIFFEID:  spiffeid.RequireFromPath(td, "/ns/ignored/sa/sa"),
+		ParentID:  spiffeid.RequireFromPath(td, "/spire/agent/k8s_psat/test/nodeuid"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:poduid"}},
+	}
+
+	for _, tt := range []struct {
+		desc          string
+		retain        bool
+		expectEntries []spireapi.Entry
+	}{
+		{
+			desc: "deletes entries by default",
+		},
+		{
+			desc:          "retains entries when configured",
+			retain:        true,
+			expectEntries: []spireapi.Entry{existing},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			k8sClient := k8stest.NewClientBuilder(t).
+				WithObjects(node, namespace, pod, clusterSPIFFEID).
+				WithStatusSubresource(clusterSPIFFEID).
+				Build()
+			entryClient := newEntryClient(existing)
+
+			r := &entryReconciler{config: ReconcilerConfig{
+				TrustDomain:                   td,
+				ClusterName:                   clusterName,
+				ClusterDomain:                 clusterDomain,
+				K8sClient:                     k8sClient,
+				EntryClient:                   entryClient,
+				NamespaceFilter:               namespacefilter.NewDynamic(namespacefilter.Filter{IgnoreNamespaces: []string{"ignored"}}),
+				RetainIgnoredNamespaceEntries: tt.retain,
+			}}
+			r.reconcile(context.Background())
+
+			require.Equal(t, tt.expectEntries, entryClient.getEntries())
+		})
+	}
+}
+
+func TestReconcileNamespaceSelector(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	newPod := func(namespace string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: namespace, UID: types.UID(namespace + "uid")},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}",
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, clusterSPIFFEID,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "enabled", Labels: map[string]string{"spire.spiffe.io/enabled": "true"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "disabled"}},
+			newPod("enabled"),
+			newPod("disabled"),
+		).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+	namespaceFilter := namespacefilter.NewDynamic(namespacefilter.Filter{
+		Selector: labels.SelectorFromSet(labels.Set{"spire.spiffe.io/enabled": "true"}),
+	})
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:     td,
+		ClusterName:     clusterName,
+		ClusterDomain:   clusterDomain,
+		K8sClient:       k8sClient,
+		EntryClient:     entryClient,
+		NamespaceFilter: namespaceFilter,
+	}}
+	r.reconcile(ctx)
+
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "spiffe://example.org/ns/enabled", entries[0].SPIFFEID.String())
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+	require.Equal(t, 2, clusterSPIFFEID.Status.Stats.NamespacesSelected)
+	require.Equal(t, 1, clusterSPIFFEID.Status.Stats.NamespacesIgnored)
+
+	// Replacing the filter, e.g. on configuration reload, takes effect on
+	// the next reconciliation.
+	namespaceFilter.Store(namespacefilter.Filter{})
+	r.reconcile(ctx)
+	require.Len(t, entryClient.getEntries(), 2)
+}
+
+func TestReconcileTerminatingPodEntries(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+		},
+	}
+	expectEntry := spireapi.Entry{
+		ID:            "created-1",
+		SPIFFEID:      spiffeid.RequireFromPath(td, "/ns/ns/sa/sa"),
+		ParentID:      spiffeid.RequireFromPath(td, "/spire/agent/k8s_psat/test/nodeuid"),
+		Selectors:     []spireapi.Selector{{Type: "k8s", Value: "pod-uid:poduid"}},
+		FederatesWith: []spiffeid.TrustDomain{},
+	}
+
+	for _, tt := range []struct {
+		desc                     string
+		terminating              bool
+		gracePeriod              time.Duration
+		expectEntriesAfterRemove []spireapi.Entry
+	}{
+		{
+			desc:        "deleted when pod is removed when disabled",
+			terminating: true,
+		},
+		{
+			desc:        "deleted when pod is removed without terminating first",
+			gracePeriod: time.Minute,
+		},
+		{
+			desc:                     "retained for grace period after terminating pod is removed",
+			terminating:              true,
+			gracePeriod:              time.Minute,
+			expectEntriesAfterRemove: []spireapi.Entry{expectEntry},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+				Spec:       corev1.PodSpec{NodeName: "node", ServiceAccountName: "sa"},
+			}
+			if tt.terminating {
+				pod.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+				pod.Finalizers = []string{"test"}
+			}
+
+			ctx := context.Background()
+			k8sClient := k8stest.NewClientBuilder(t).
+				WithObjects(node, namespace, pod, clusterSPIFFEID).
+				WithStatusSubresource(clusterSPIFFEID).
+				Build()
+			entryClient := newEntryClient()
+			clk := clocktesting.NewFakeClock(time.Now())
+
+			r := &entryReconciler{
+				config: ReconcilerConfig{
+					TrustDomain:   td,
+					ClusterName:   clusterName,
+					ClusterDomain: clusterDomain,
+					K8sClient:     k8sClient,
+					EntryClient:   entryClient,
+				},
+				drainer: newPodEntryDrainer(tt.gracePeriod, clk),
+			}
+
+			// The entry is created for the pod, even if it is terminating.
+			r.reconcile(ctx)
+			require.Equal(t, []spireapi.Entry{expectEntry}, entryClient.getEntries())
+
+			// Remove the pod.
+			if tt.terminating {
+				require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(pod), pod))
+				pod.Finalizers = nil
+				require.NoError(t, k8sClient.Update(ctx, pod))
+			} else {
+				require.NoError(t, k8sClient.Delete(ctx, pod))
+			}
+			require.True(t, apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(pod), pod)))
+
+			r.reconcile(ctx)
+			require.Equal(t, tt.expectEntriesAfterRemove, entryClient.getEntries())
+
+			// Entries are deleted once the grace period expires.
+			clk.Step(tt.gracePeriod)
+			r.reconcile(ctx)
+			require.Empty(t, entryClient.getEntries())
+		})
+	}
+}
+
+func TestReconcileEntryDeletionGracePeriod(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node", ServiceAccountName: "sa"},
+	}
+	newClusterSPIFFEID := func() *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+			},
+		}
+	}
+	clusterSPIFFEID := newClusterSPIFFEID()
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, clusterSPIFFEID).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+	clk := clocktesting.NewFakeClock(time.Now())
+
+	r := &entryReconciler{
+		config: ReconcilerConfig{
+			TrustDomain:   td,
+			ClusterName:   clusterName,
+			ClusterDomain: clusterDomain,
+			K8sClient:     k8sClient,
+			EntryClient:   entryClient,
+		},
+		staleEntries: newStaleEntryTracker(time.Minute, clk),
+	}
+
+	r.reconcile(ctx)
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 1)
+
+	// The entry is retained while it is unclaimed for less than the grace
+	// period.
+	require.NoError(t, k8sClient.Delete(ctx, clusterSPIFFEID))
+	r.reconcile(ctx)
+	require.Equal(t, entries, entryClient.getEntries())
+
+	// The entry is reused when it is declared again within the grace period,
+	// which restarts the grace period once it is unclaimed again.
+	clk.Step(time.Second * 30)
+	require.NoError(t, k8sClient.Create(ctx, newClusterSPIFFEID()))
+	r.reconcile(ctx)
+	require.Equal(t, entries, entryClient.getEntries())
+	require.Equal(t, 1, entryClient.createCalls)
+
+	require.NoError(t, k8sClient.Delete(ctx, newClusterSPIFFEID()))
+	r.reconcile(ctx)
+	clk.Step(time.Second * 30)
+	r.reconcile(ctx)
+	require.Equal(t, entries, entryClient.getEntries())
+
+	// The entry is deleted once the grace period expires.
+	clk.Step(time.Second * 30)
+	r.reconcile(ctx)
+	require.Empty(t, entryClient.getEntries())
+}
+
+func TestReconcileServiceAccountNames(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newPod := func(name, serviceAccountName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid")},
+			Spec:       corev1.PodSpec{NodeName: "node", ServiceAccountName: serviceAccountName},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:    "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			ServiceAccountNames: []string{"default", "frontend-*"},
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, clusterSPIFFEID,
+			newPod("frontend", "frontend-v1"),
+			newPod("backend", "backend"),
+			newPod("nosa", ""),
+		).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+	}}
+	r.reconcile(ctx)
+
+	var spiffeIDs []string
+	for _, entry := range entryClient.getEntries() {
+		spiffeIDs = append(spiffeIDs, entry.SPIFFEID.String())
+	}
+	require.ElementsMatch(t, []string{
+		"spiffe://example.org/ns/ns/pod/frontend",
+		"spiffe://example.org/ns/ns/pod/nosa",
+	}, spiffeIDs)
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+	require.Equal(t, 2, clusterSPIFFEID.Status.Stats.PodsSelected)
+}
+
+func TestReconcilePodExcludeSelector(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newPod := func(name, role string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid"), Labels: map[string]string{"role": role}},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:   "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			PodExcludeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "job"}},
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, clusterSPIFFEID,
+			newPod("app", "app"),
+			newPod("ci", "ci-runner"),
+			newPod("job", "job"),
+		).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:        td,
+		ClusterName:        clusterName,
+		ClusterDomain:      clusterDomain,
+		K8sClient:          k8sClient,
+		EntryClient:        entryClient,
+		PodExcludeSelector: labels.SelectorFromSet(labels.Set{"role": "ci-runner"}),
+	}}
+	r.reconcile(ctx)
+
+	// Pods are excluded by both the global and the ClusterSPIFFEID pod
+	// exclude selectors.
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "spiffe://example.org/ns/ns/pod/app", entries[0].SPIFFEID.String())
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+	require.Equal(t, 1, clusterSPIFFEID.Status.Stats.PodsSelected)
+}
+
+func TestReconcileFallback(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newPod := func(name, app string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid"), Labels: map[string]string{"app": app}},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		}
+	}
+	specific := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "specific"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/app/{{ .PodMeta.Name }}",
+			PodSelector:      &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}},
+		},
+	}
+	// The fallback is older than the specific ClusterSPIFFEID so that it
+	// would be preferred if precedence was only based on age.
+	fallback := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "fallback", CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour))},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/default/{{ .PodMeta.Name }}",
+			Fallback:         true,
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, fallback, specific,
+			newPod("frontend", "frontend"),
+			newPod("backend", "backend"),
+			newPod("batch", "batch"),
+		).
+		WithStatusSubresource(fallback, specific).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+	}}
+	r.reconcile(ctx)
+
+	var spiffeIDs []string
+	for _, entry := range entryClient.getEntries() {
+		spiffeIDs = append(spiffeIDs, entry.SPIFFEID.String())
+	}
+	require.ElementsMatch(t, []string{
+		"spiffe://example.org/app/frontend",
+		"spiffe://example.org/default/backend",
+		"spiffe://example.org/default/batch",
+	}, spiffeIDs)
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(specific), specific))
+	require.Equal(t, 1, specific.Status.Stats.PodsSelected)
+	require.Equal(t, 1, specific.Status.Stats.EntriesToSet)
+	require.Zero(t, specific.Status.Stats.PodsCoveredByFallback)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(fallback), fallback))
+	require.Equal(t, 2, fallback.Status.Stats.PodsSelected)
+	require.Equal(t, 2, fallback.Status.Stats.PodsCoveredByFallback)
+	require.Equal(t, 2, fallback.Status.Stats.EntriesToSet)
+}
+
+func TestReconcileFallbackNamespacedSPIFFEID(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newPod := func(name string, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid"), Labels: map[string]string{"app": name}, Annotations: annotations},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		}
+	}
+	namespacedSPIFFEID := &spirev1alpha1.NamespacedSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "backend"},
+		Spec: spirev1alpha1.NamespacedSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/app/{{ .PodMeta.Name }}",
+			PodSelector:      &metav1.LabelSelector{MatchLabels: map[string]string{"app": "backend"}},
+		},
+	}
+	fallback := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "fallback"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/default/{{ .PodMeta.Name }}",
+			Fallback:         true,
+		},
+	}
+	pathPrefixTemplate, err := spirev1alpha1.ParseNamespacePathPrefixTemplate(spirev1alpha1.DefaultNamespacePathPrefixTemplate)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, namespacedSPIFFEID, fallback,
+			newPod("frontend", nil),
+			newPod("backend", nil),
+			newPod("batch", map[string]string{SPIFFEIDAnnotation: "/ns/ns/custom"}),
+		).
+		WithStatusSubresource(namespacedSPIFFEID, fallback).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:                          td,
+		ClusterName:                          clusterName,
+		ClusterDomain:                        clusterDomain,
+		K8sClient:                            k8sClient,
+		EntryClient:                          entryClient,
+		NamespacePathPrefixTemplate:          pathPrefixTemplate,
+		SPIFFEIDAnnotationPathPrefixTemplate: pathPrefixTemplate,
+	}}
+	r.reconcile(ctx)
+
+	// The pod selected by the NamespacedSPIFFEID is not covered by the
+	// fallback, and the annotated pod gets a single entry, rendered by the
+	// fallback with the SPIFFE ID of the annotation.
+	var spiffeIDs []string
+	for _, entry := range entryClient.getEntries() {
+		spiffeIDs = append(spiffeIDs, entry.SPIFFEID.String())
+	}
+	require.ElementsMatch(t, []string{
+		"spiffe://example.org/default/frontend",
+		"spiffe://example.org/ns/ns/app/backend",
+		"spiffe://example.org/ns/ns/custom",
+	}, spiffeIDs)
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(namespacedSPIFFEID), namespacedSPIFFEID))
+	require.Equal(t, spirev1alpha1.NamespacedSPIFFEIDStats{PodsSelected: 1, EntriesToSet: 1}, namespacedSPIFFEID.Status.Stats)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(fallback), fallback))
+	require.Equal(t, 2, fallback.Status.Stats.PodsSelected)
+	require.Equal(t, 2, fallback.Status.Stats.PodsCoveredByFallback)
+	require.Equal(t, 2, fallback.Status.Stats.EntriesToSet)
+}
+
+func TestObjectCmpFallback(t *testing.T) {
+	older := &ClusterSPIFFEID{ClusterSPIFFEID: spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "older", CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour))},
+		Spec:       spirev1alpha1.ClusterSPIFFEIDSpec{Fallback: true},
+	}}
+	newer := &ClusterSPIFFEID{ClusterSPIFFEID: spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "newer", CreationTimestamp: metav1.Now()},
+	}}
+	require.Equal(t, 1, objectCmp(older, newer))
+	require.Equal(t, -1, objectCmp(newer, older))
+}
+
+func TestReconcileServiceAccountRegistrationMode(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newNode := func(name string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name + "uid")}}
+	}
+	newPod := func(name, nodeName, serviceAccountName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid")},
+			Spec:       corev1.PodSpec{NodeName: nodeName, ServiceAccountName: serviceAccountName},
+		}
+	}
+	allNodes := &spirev1alpha1.ClusterNodeAlias{
+		ObjectMeta: metav1.ObjectMeta{Name: "all-nodes"},
+		Spec: spirev1alpha1.ClusterNodeAliasSpec{
+			SPIFFEID: "spiffe://example.org/nodes/all",
+		},
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+			RegistrationMode: spirev1alpha1.RegistrationModeServiceAccount,
+			NodeAlias:        "all-nodes",
+		},
+	}
+	missingAlias := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "missing"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/missing/{{ .PodSpec.ServiceAccountName }}",
+			RegistrationMode: spirev1alpha1.RegistrationModeServiceAccount,
+			NodeAlias:        "missing",
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(newNode("node1"), newNode("node2"), namespace, allNodes, clusterSPIFFEID, missingAlias,
+			newPod("frontend-1", "node1", "frontend"),
+			newPod("frontend-2", "node2", "frontend"),
+			newPod("frontend-3", "node2", "frontend"),
+			newPod("backend-1", "node1", "backend"),
+		).
+		WithStatusSubresource(allNodes, clusterSPIFFEID, missingAlias).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+	}}
+	require.NoError(t, r.reconcile(ctx))
+
+	// The pods of a service account share an entry parented by the node
+	// alias.
+	serviceAccountEntry := func(serviceAccountName string) spireapi.Entry {
+		return spireapi.Entry{
+			SPIFFEID: spiffeid.RequireFromPath(td, "/ns/ns/sa/"+serviceAccountName),
+			ParentID: spiffeid.RequireFromPath(td, "/nodes/all"),
+			Selectors: []spireapi.Selector{
+				{Type: "k8s", Value: "ns:ns"},
+				{Type: "k8s", Value: "sa:" + serviceAccountName},
+			},
+			FederatesWith: []spiffeid.TrustDomain{},
+		}
+	}
+	var entries []spireapi.Entry
+	for _, entry := range entryClient.getEntries() {
+		if entry.ParentID.Path() == "/spire/server" {
+			continue
+		}
+		entry.ID = ""
+		entries = append(entries, entry)
+	}
+	require.ElementsMatch(t, []spireapi.Entry{
+		serviceAccountEntry("frontend"),
+		serviceAccountEntry("backend"),
+	}, entries)
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+	require.Equal(t, 4, clusterSPIFFEID.Status.Stats.PodsSelected)
+	require.Equal(t, 2, clusterSPIFFEID.Status.Stats.EntriesToSet)
+	require.Zero(t, clusterSPIFFEID.Status.Stats.EntriesMasked)
+
+	// Entries are not rendered if the node alias does not exist.
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(missingAlias), missingAlias))
+	ready := meta.FindStatusCondition(missingAlias.Status.Conditions, spirev1alpha1.ClusterSPIFFEIDConditionReady)
+	require.NotNil(t, ready)
+	require.Equal(t, spirev1alpha1.ClusterSPIFFEIDReasonRenderFailed, ready.Reason)
+	require.Equal(t, `ClusterNodeAlias "missing" does not exist or is invalid`, ready.Message)
+}
+
+func TestReconcileEntryLimitExceeded(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	newNode := func(name string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name + "uid")}}
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newPod := func(name, nodeName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid")},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(newNode("full"), newNode("other"), namespace, clusterSPIFFEID,
+			newPod("a", "full"),
+			newPod("b", "full"),
+			newPod("c", "other"),
+		).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+	entryClient.entryLimit = 1
+	clk := clocktesting.NewFakeClock(time.Now())
+
+	r := &entryReconciler{
+		config: ReconcilerConfig{
+			TrustDomain:   td,
+			ClusterName:   clusterName,
+			ClusterDomain: clusterDomain,
+			K8sClient:     k8sClient,
+			EntryClient:   entryClient,
+		},
+		parentBackoff: newParentBackoff(clk),
+	}
+
+	// The first create for the full node fails. Entries for the other node
+	// are still created.
+	r.reconcile(ctx)
+	require.Len(t, entryClient.getEntries(), 2)
+	require.Equal(t, 3, entryClient.createCalls)
+	require.Equal(t, 1, r.parentBackoff.Active())
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+	ready := meta.FindStatusCondition(clusterSPIFFEID.Status.Conditions, spirev1alpha1.ClusterSPIFFEIDConditionReady)
+	require.NotNil(t, ready)
+	require.Equal(t, metav1.ConditionFalse, ready.Status)
+	require.Equal(t, spirev1alpha1.ClusterSPIFFEIDReasonEntryLimitExceeded, ready.Reason)
+	require.False(t, meta.IsStatusConditionTrue(clusterSPIFFEID.Status.Conditions, spirev1alpha1.ClusterSPIFFEIDConditionStalled))
+	require.Equal(t, 1, clusterSPIFFEID.Status.Stats.EntryFailures)
+
+	// While backing off, the entry is not created again.
+	r.reconcile(ctx)
+	require.Equal(t, 3, entryClient.createCalls)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+	require.Equal(t, 1, clusterSPIFFEID.Status.Stats.EntryFailures)
+
+	// Once the backoff expires and there is room for the entry, it is
+	// created.
+	entryClient.entryLimit = 2
+	clk.Step(initialParentBackoff)
+	r.reconcile(ctx)
+	require.Equal(t, 4, entryClient.createCalls)
+	require.Len(t, entryClient.getEntries(), 3)
+	require.Equal(t, 0, r.parentBackoff.Active())
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+	require.True(t, meta.IsStatusConditionTrue(clusterSPIFFEID.Status.Conditions, spirev1alpha1.ClusterSPIFFEIDConditionReady))
+}
+
+func TestReconcileEntryTransformer(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, clusterSPIFFEID).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+	transformer := &fakeEntryTransformer{
+		transform: func(request entrytransformer.Request) entryhook.Entry {
+			entry := request.Entry
+			entry.DNSNames = []string{request.Workload.Name + ".example.org"}
+			entry.Selectors = append(entry.Selectors, "k8s:ns:"+request.Workload.Namespace)
+			entry.X509SVIDTTL = "1h"
+			return entry
+		},
+	}
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:      td,
+		ClusterName:      clusterName,
+		ClusterDomain:    clusterDomain,
+		K8sClient:        k8sClient,
+		EntryClient:      entryClient,
+		EntryTransformer: transformer,
+	}}
+	r.reconcile(ctx)
+
+	require.Len(t, transformer.requests, 1)
+	require.Equal(t, &entryhook.ObjectReference{Kind: "ClusterSPIFFEID", Name: "csid"}, transformer.requests[0].Owner)
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, []string{"pod.example.org"}, entries[0].DNSNames)
+	require.Contains(t, entries[0].Selectors, spireapi.Selector{Type: "k8s", Value: "ns:ns"})
+	require.Equal(t, time.Hour, entries[0].X509SVIDTTL)
+
+	// The transformed entry is stable across reconciles.
+	r.reconcile(ctx)
+	require.Equal(t, entries, entryClient.getEntries())
+
+	// Entries are left untouched when the transformation is invalid.
+	transformer.transform = func(request entrytransformer.Request) entryhook.Entry {
+		entry := request.Entry
+		entry.SPIFFEID = "spiffe://example.org/admin"
+		return entry
+	}
+	r.reconcile(ctx)
+	require.Equal(t, entries, entryClient.getEntries())
+
+	// Entries are left untouched when the transformer fails.
+	transformer.err = errors.New("oh no")
+	r.reconcile(ctx)
+	require.Equal(t, entries, entryClient.getEntries())
+}
+
+func TestReconcileEntryCache(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid")},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	stale := spireapi.Entry{
+		ID:        "stale",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/stale"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/node"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:stale"}},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, clusterSPIFFEID, newPod("a")).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient(stale)
+	clk := clocktesting.NewFakeClock(time.Now())
+
+	r := &entryReconciler{
+		config: ReconcilerConfig{
+			TrustDomain:   td,
+			ClusterName:   clusterName,
+			ClusterDomain: clusterDomain,
+			K8sClient:     k8sClient,
+			EntryClient:   entryClient,
+		},
+		entryCache: newEntryCache(time.Minute, clk),
+	}
+
+	// The entries are listed in full on the first reconcile.
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.listCalls)
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 1)
+	require.NotEqual(t, "stale", entries[0].ID)
+
+	// The created and deleted entries are tracked without listing the
+	// entries again.
+	require.NoError(t, k8sClient.Create(ctx, newPod("b")))
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.listCalls)
+	require.Equal(t, 2, entryClient.createCalls)
+	require.Len(t, entryClient.getEntries(), 2)
+
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.listCalls)
+	require.Equal(t, 2, entryClient.createCalls)
+
+	// Entries changed by others are not observed until the resync.
+	entryClient.entries[stale.ID] = stale
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.listCalls)
+	require.Contains(t, entryClient.getEntries(), stale)
+
+	clk.Step(time.Minute)
+	r.reconcile(ctx)
+	require.Equal(t, 2, entryClient.listCalls)
+	require.NotContains(t, entryClient.getEntries(), stale)
+	require.Len(t, entryClient.getEntries(), 2)
+}
+
+func TestReconcileUpToDateEntries(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			TTL:              metav1.Duration{Duration: 90*time.Second + 500*time.Millisecond},
+			DNSNameTemplates: []string{"{{ .PodMeta.Name }}.a.test", "{{ .PodMeta.Name }}.b.test"},
+			FederatesWith:    []string{"a.test", "b.test"},
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, clusterSPIFFEID).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+	}}
+
+	r.reconcile(ctx)
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 1)
+
+	t.Log("Entries that only differ in how SPIRE Server stores them are not updated")
+	entry := entries[0]
+	entry.X509SVIDTTL = 90 * time.Second
+	entry.DNSNames = []string{entry.DNSNames[1], entry.DNSNames[0]}
+	entry.FederatesWith = []spiffeid.TrustDomain{entry.FederatesWith[1], entry.FederatesWith[0]}
+	entryClient.entries[entry.ID] = entry
+	skipped := testutil.ToFloat64(metrics.EntryUpdates.WithLabelValues(metrics.ResultSkipped))
+	applied := testutil.ToFloat64(metrics.EntryUpdates.WithLabelValues(metrics.ResultApplied))
+	r.reconcile(ctx)
+	require.Equal(t, 0, entryClient.updateCalls)
+	require.Equal(t, skipped+1, testutil.ToFloat64(metrics.EntryUpdates.WithLabelValues(metrics.ResultSkipped)))
+	require.Equal(t, applied, testutil.ToFloat64(metrics.EntryUpdates.WithLabelValues(metrics.ResultApplied)))
+
+	t.Log("Entries that changed are updated")
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+	clusterSPIFFEID.Spec.TTL = metav1.Duration{Duration: 2 * time.Minute}
+	clusterSPIFFEID.Generation++
+	require.NoError(t, k8sClient.Update(ctx, clusterSPIFFEID))
+	r.reconcile(ctx)
+	require.Equal(t, 1, entryClient.updateCalls)
+	require.Equal(t, 2*time.Minute, entryClient.getEntries()[0].X509SVIDTTL)
+	require.Equal(t, applied+1, testutil.ToFloat64(metrics.EntryUpdates.WithLabelValues(metrics.ResultApplied)))
+
+	t.Log("Entries that fail to update are not counted as applied")
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+	clusterSPIFFEID.Spec.TTL = metav1.Duration{Duration: 3 * time.Minute}
+	clusterSPIFFEID.Generation++
+	require.NoError(t, k8sClient.Update(ctx, clusterSPIFFEID))
+	entryClient.updateErr = errors.New("oh no")
+	r.reconcile(ctx)
+	require.Equal(t, 2*time.Minute, entryClient.getEntries()[0].X509SVIDTTL)
+	require.Equal(t, applied+1, testutil.ToFloat64(metrics.EntryUpdates.WithLabelValues(metrics.ResultApplied)))
+}
+
+func TestDeletePodEntries(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid")},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	podA, podB := newPod("a"), newPod("b")
+	static := spireapi.Entry{
+		ID:        "static",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/static"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/node"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:auid"}},
+	}
+
+	for _, tt := range []struct {
+		name          string
+		config        func(*ReconcilerConfig)
+		pod           func(*corev1.Pod)
+		expectDeleted bool
+	}{
+		{
+			name:          "deleted",
+			expectDeleted: true,
+		},
+		{
+			name:          "deleted from the entry cache",
+			config:        func(c *ReconcilerConfig) { c.EntryCacheResyncInterval = time.Minute },
+			expectDeleted: true,
+		},
+		{
+			name:   "not deleted in dry run mode",
+			config: func(c *ReconcilerConfig) { c.DryRun = true },
+		},
+		{
+			name:   "not deleted with a terminating pod entry grace period",
+			config: func(c *ReconcilerConfig) { c.TerminatingPodEntryGracePeriod = time.Minute },
+		},
+		{
+			name:   "not deleted with an entry deletion grace period",
+			config: func(c *ReconcilerConfig) { c.EntryDeletionGracePeriod = time.Minute },
+		},
+		{
+			name: "not deleted for static pods",
+			pod:  func(pod *corev1.Pod) { pod.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "auid"} },
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			k8sClient := k8stest.NewClientBuilder(t).
+				WithObjects(node, namespace, clusterSPIFFEID, podA.DeepCopy(), podB.DeepCopy()).
+				WithStatusSubresource(clusterSPIFFEID).
+				Build()
+			entryClient := newEntryClient()
+			config := ReconcilerConfig{
+				TrustDomain:   td,
+				ClusterName:   clusterName,
+				ClusterDomain: clusterDomain,
+				K8sClient:     k8sClient,
+				EntryClient:   entryClient,
+			}
+			if tt.config != nil {
+				tt.config(&config)
+			}
+			clk := clocktesting.NewFakeClock(time.Now())
+			r := &entryReconciler{
+				config:     config,
+				drainer:    newPodEntryDrainer(config.TerminatingPodEntryGracePeriod, clk),
+				entryCache: newEntryCache(config.EntryCacheResyncInterval, clk),
+			}
+
+			// Create the entries of the pods, then add an entry that
+			// selects pod a but was not rendered for it.
+			setup := &entryReconciler{config: config}
+			setup.config.DryRun = false
+			setup.reconcile(ctx)
+			r.reconcile(ctx)
+			require.Len(t, entryClient.getEntries(), 2)
+			entryClient.entries[static.ID] = static
+
+			pod := podA.DeepCopy()
+			if tt.pod != nil {
+				tt.pod(pod)
+			}
+			require.NoError(t, k8sClient.Delete(ctx, podA.DeepCopy()))
+			r.DeletePodEntries(ctx, pod)
+			r.deleteRemovedPodEntries(ctx)
+
+			var spiffeIDs []string
+			for _, entry := range entryClient.getEntries() {
+				spiffeIDs = append(spiffeIDs, entry.SPIFFEID.String())
+			}
+			if tt.expectDeleted {
+				require.ElementsMatch(t, []string{"spiffe://example.org/ns/ns/pod/b", "spiffe://example.org/static"}, spiffeIDs)
+				require.Equal(t, config.EntryCacheResyncInterval == 0, entryClient.listBySelectorCalls == 1, "entries are listed unless cached")
+			} else {
+				require.ElementsMatch(t, []string{"spiffe://example.org/ns/ns/pod/a", "spiffe://example.org/ns/ns/pod/b", "spiffe://example.org/static"}, spiffeIDs)
+			}
+		})
+	}
+}
+
+func TestDeletePodEntriesBatch(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid")},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	podA, podB, podC := newPod("a"), newPod("b"), newPod("c")
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, clusterSPIFFEID, podA.DeepCopy(), podB.DeepCopy(), podC.DeepCopy()).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+	}}
+	require.NoError(t, r.reconcile(ctx))
+	require.Len(t, entryClient.getEntries(), 3)
+
+	// The entries of the pods removed before the batch is deleted are
+	// listed and deleted together.
+	listCalls := entryClient.listCalls
+	r.DeletePodEntries(ctx, podA)
+	r.DeletePodEntries(ctx, podB)
+	r.deleteRemovedPodEntries(ctx)
+	require.Equal(t, 1, entryClient.deleteCalls)
+	require.Equal(t, listCalls+1, entryClient.listCalls)
+	require.Zero(t, entryClient.listBySelectorCalls)
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "spiffe://example.org/ns/ns/pod/c", entries[0].SPIFFEID.String())
+
+	// Queued pods are only deleted once.
+	r.deleteRemovedPodEntries(ctx)
+	require.Equal(t, 1, entryClient.deleteCalls)
+}
+
+func TestDeleteEntriesAlreadyDeleted(t *testing.T) {
+	entry := spireapi.Entry{
+		ID:        "deleted",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/workload"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/node"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:uid"}},
+	}
+	r := &entryReconciler{config: ReconcilerConfig{EntryClient: newEntryClient()}}
+
+	// Entries deleted by others since they were listed are not failures.
+	errorsBefore := testutil.ToFloat64(metrics.SPIREAPIErrors.WithLabelValues("DeleteEntries"))
+	require.Zero(t, r.deleteEntries(context.Background(), []spireapi.Entry{entry}))
+	require.Equal(t, errorsBefore, testutil.ToFloat64(metrics.SPIREAPIErrors.WithLabelValues("DeleteEntries")))
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	stale := spireapi.Entry{
+		ID:        "stale",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/stale"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/node"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:stale"}},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, clusterSPIFFEID).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient(stale)
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+		DryRun:        true,
+	}}
+	r.reconcile(ctx)
+
+	// The stale entry is not deleted and the declared entry is not created.
+	require.Equal(t, []spireapi.Entry{stale}, entryClient.getEntries())
+	require.Equal(t, 0, entryClient.createCalls)
+
+	// The status still reflects the declared entries.
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+	require.Equal(t, 1, clusterSPIFFEID.Status.Stats.EntriesToSet)
+}
+
+func TestReconcileWaitForRunningPods(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newPod := func(name string, phase corev1.PodPhase, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid"), Annotations: annotations},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, clusterSPIFFEID,
+			newPod("pending", corev1.PodPending, nil),
+			newPod("init", corev1.PodPending, map[string]string{InitIdentityAnnotation: "true"}),
+			newPod("running", corev1.PodRunning, nil),
+		).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:        td,
+		ClusterName:        clusterName,
+		ClusterDomain:      clusterDomain,
+		K8sClient:          k8sClient,
+		EntryClient:        entryClient,
+		WaitForRunningPods: true,
+	}}
+	r.reconcile(ctx)
+
+	var spiffeIDs []string
+	for _, entry := range entryClient.getEntries() {
+		spiffeIDs = append(spiffeIDs, entry.SPIFFEID.String())
+	}
+	require.ElementsMatch(t, []string{
+		"spiffe://example.org/ns/ns/pod/init",
+		"spiffe://example.org/ns/ns/pod/running",
+	}, spiffeIDs)
+}
+
+func TestReconcileAutoPopulateDNSNames(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "ns", UID: "web-0uid", Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{NodeName: "node", Hostname: "web-0", Subdomain: "web-headless"},
+	}
+	newService := func(name string, spec corev1.ServiceSpec) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"}, Spec: spec}
+	}
+	webService := newService("web", corev1.ServiceSpec{Selector: map[string]string{"app": "web"}, ClusterIP: "10.0.0.1"})
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:     "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			DNSNameTemplates:     []string{"web.example"},
+			AutoPopulateDNSNames: true,
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, clusterSPIFFEID, webService,
+			newService("web-headless", corev1.ServiceSpec{Selector: map[string]string{"app": "web"}, ClusterIP: corev1.ClusterIPNone}),
+			newService("other", corev1.ServiceSpec{Selector: map[string]string{"app": "other"}}),
+			newService("selectorless", corev1.ServiceSpec{}),
+			newService("external", corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "web.example", Selector: map[string]string{"app": "web"}}),
+		).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+	}}
+	r.reconcile(ctx)
+
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, []string{
+		"web.example",
+		"web-0.web-headless.ns.svc.cluster.local",
+		"web-headless.ns.svc.cluster.local",
+		"web.ns.svc.cluster.local",
+	}, entries[0].DNSNames)
+
+	t.Run("service deletion removes the DNS name", func(t *testing.T) {
+		require.NoError(t, k8sClient.Delete(ctx, webService))
+		r.reconcile(ctx)
+
+		entries := entryClient.getEntries()
+		require.Len(t, entries, 1)
+		require.Equal(t, []string{
+			"web.example",
+			"web-0.web-headless.ns.svc.cluster.local",
+			"web-headless.ns.svc.cluster.local",
+		}, entries[0].DNSNames)
+	})
+}
+
+func TestReconcileRenderWorkers(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "web"}, ClusterIP: "10.0.0.1"},
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:     "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			AutoPopulateDNSNames: true,
+		},
+	}
+	objects := []client.Object{node, namespace, service, clusterSPIFFEID}
+	var expectedIDs []string
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("web-%02d", i)
+		objects = append(objects, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid"), Labels: map[string]string{"app": "web"}},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		})
+		expectedIDs = append(expectedIDs, "spiffe://"+trustDomain+"/ns/ns/pod/"+name)
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(objects...).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+		RenderWorkers: 8,
+	}}
+	r.reconcile(ctx)
+
+	entries := entryClient.getEntries()
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, entry.SPIFFEID.String())
+		require.Equal(t, []string{"web.ns.svc.cluster.local"}, entry.DNSNames)
+	}
+	sort.Strings(ids)
+	require.Equal(t, expectedIDs, ids)
+
+	clusterSPIFFEID = new(spirev1alpha1.ClusterSPIFFEID)
+	require.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Name: "csid"}, clusterSPIFFEID))
+	require.Equal(t, 50, clusterSPIFFEID.Status.Stats.PodsSelected)
+	require.Equal(t, 50, clusterSPIFFEID.Status.Stats.EntriesToSet)
+}
+
+func TestReconcilePodAnnotations(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newPod := func(name string, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid"), Annotations: annotations},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	pathPrefixTemplate, err := spirev1alpha1.ParseNamespacePathPrefixTemplate(spirev1alpha1.DefaultNamespacePathPrefixTemplate)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, clusterSPIFFEID,
+			newPod("plain", nil),
+			newPod("skipped", map[string]string{spirev1alpha1.SkipPodAnnotation: "true"}),
+			newPod("overridden", map[string]string{SPIFFEIDAnnotation: "/ns/ns/custom"}),
+			newPod("escaping", map[string]string{SPIFFEIDAnnotation: "/ns/other/custom"}),
+		).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+
+	reconcile := func(t *testing.T, pathPrefixTemplate *template.Template) []string {
+		entryClient := newEntryClient()
+		r := &entryReconciler{config: ReconcilerConfig{
+			TrustDomain:                          td,
+			ClusterName:                          clusterName,
+			ClusterDomain:                        clusterDomain,
+			K8sClient:                            k8sClient,
+			EntryClient:                          entryClient,
+			SPIFFEIDAnnotationPathPrefixTemplate: pathPrefixTemplate,
+		}}
+		r.reconcile(ctx)
+
+		var spiffeIDs []string
+		for _, entry := range entryClient.getEntries() {
+			spiffeIDs = append(spiffeIDs, entry.SPIFFEID.String())
+		}
+		return spiffeIDs
+	}
+
+	t.Run("overrides disabled", func(t *testing.T) {
+		require.ElementsMatch(t, []string{
+			"spiffe://example.org/ns/ns/pod/plain",
+			"spiffe://example.org/ns/ns/pod/overridden",
+			"spiffe://example.org/ns/ns/pod/escaping",
+		}, reconcile(t, nil))
+	})
+
+	t.Run("overrides enabled", func(t *testing.T) {
+		require.ElementsMatch(t, []string{
+			"spiffe://example.org/ns/ns/pod/plain",
+			"spiffe://example.org/ns/ns/custom",
+		}, reconcile(t, pathPrefixTemplate))
+
+		updated := new(spirev1alpha1.ClusterSPIFFEID)
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), updated))
+		require.Equal(t, 3, updated.Status.Stats.PodsSelected)
+		require.Equal(t, 1, updated.Status.Stats.PodEntryRenderFailures)
+	})
+}
+
+func TestReconcileAllowedPathPrefixes(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	newPod := func(namespace string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: namespace, UID: types.UID(namespace + "uid")},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	allowedStaticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "allowed"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static/allowed",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"a:1"},
+		},
+	}
+	disallowedStaticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "disallowed"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/other",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"a:1"},
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, clusterSPIFFEID, allowedStaticEntry, disallowedStaticEntry,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+			newPod("prod"),
+			newPod("dev"),
+		).
+		WithStatusSubresource(clusterSPIFFEID, allowedStaticEntry, disallowedStaticEntry).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:         td,
+		ClusterName:         clusterName,
+		ClusterDomain:       clusterDomain,
+		K8sClient:           k8sClient,
+		EntryClient:         entryClient,
+		AllowedPathPrefixes: []string{"/ns/prod", "/static"},
+	}}
+	r.reconcile(ctx)
+
+	var spiffeIDs []string
+	for _, entry := range entryClient.getEntries() {
+		spiffeIDs = append(spiffeIDs, entry.SPIFFEID.String())
+	}
+	require.ElementsMatch(t, []string{
+		"spiffe://example.org/ns/prod/pod/pod",
+		"spiffe://example.org/static/allowed",
+	}, spiffeIDs)
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+	require.Equal(t, 1, clusterSPIFFEID.Status.Stats.PodEntryRenderFailures)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(allowedStaticEntry), allowedStaticEntry))
+	require.True(t, allowedStaticEntry.Status.Rendered)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(disallowedStaticEntry), disallowedStaticEntry))
+	require.False(t, disallowedStaticEntry.Status.Rendered)
+}
+
+func TestReconcilePrivilegedEntries(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "namespace", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+	newClusterSPIFFEID := func(name string) *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/" + name,
+				Downstream:       true,
+			},
+		}
+	}
+	newClusterStaticEntry := func(name string) *spirev1alpha1.ClusterStaticEntry {
+		return &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://example.org/" + name,
+				ParentID:  "spiffe://example.org/parent",
+				Selectors: []string{"a:1"},
+				Admin:     true,
+			},
+		}
+	}
+	privilegedClusterSPIFFEID := newClusterSPIFFEID("privileged-csid")
+	unprivilegedClusterSPIFFEID := newClusterSPIFFEID("unprivileged-csid")
+	privilegedStaticEntry := newClusterStaticEntry("privileged-cse")
+	unprivilegedStaticEntry := newClusterStaticEntry("unprivileged-cse")
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod,
+			privilegedClusterSPIFFEID, unprivilegedClusterSPIFFEID,
+			privilegedStaticEntry, unprivilegedStaticEntry,
+		).
+		WithStatusSubresource(privilegedClusterSPIFFEID, unprivilegedClusterSPIFFEID, privilegedStaticEntry, unprivilegedStaticEntry).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+		PrivilegedEntries: &spirev1alpha1.PrivilegedEntriesConfig{
+			ClusterSPIFFEIDs:     []string{"privileged-csid"},
+			ClusterStaticEntries: []string{"privileged-cse"},
+		},
+	}}
+	r.reconcile(ctx)
+
+	var spiffeIDs []string
+	for _, entry := range entryClient.getEntries() {
+		spiffeIDs = append(spiffeIDs, entry.SPIFFEID.String())
+	}
+	require.ElementsMatch(t, []string{
+		"spiffe://example.org/privileged-csid",
+		"spiffe://example.org/privileged-cse",
+	}, spiffeIDs)
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(unprivilegedClusterSPIFFEID), unprivilegedClusterSPIFFEID))
+	stalled := meta.FindStatusCondition(unprivilegedClusterSPIFFEID.Status.Conditions, spirev1alpha1.ClusterSPIFFEIDConditionStalled)
+	require.NotNil(t, stalled)
+	require.Equal(t, metav1.ConditionTrue, stalled.Status)
+	require.Equal(t, spirev1alpha1.ClusterSPIFFEIDReasonRenderFailed, stalled.Reason)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(privilegedStaticEntry), privilegedStaticEntry))
+	require.True(t, privilegedStaticEntry.Status.Rendered)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(unprivilegedStaticEntry), unprivilegedStaticEntry))
+	require.False(t, unprivilegedStaticEntry.Status.Rendered)
+}
+
+func TestReconcileClassName(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+	nameOf := func(className string) string {
+		if className == "" {
+			return "classless"
+		}
+		return className
+	}
+	newClusterSPIFFEID := func(className string) *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: "csid-" + nameOf(className)},
+			Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/csid/" + nameOf(className),
+				ClassName:        className,
+			},
+		}
+	}
+	newClusterStaticEntry := func(className string) *spirev1alpha1.ClusterStaticEntry {
+		return &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "cse-" + nameOf(className)},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:  "spiffe://example.org/cse/" + nameOf(className),
+				ParentID:  "spiffe://example.org/parent",
+				Selectors: []string{"a:1"},
+				ClassName: className,
+			},
+		}
+	}
+
+	for _, tt := range []struct {
+		desc            string
+		className       string
+		watchClassless  bool
+		expectSPIFFEIDs []string
+	}{
+		{
+			desc: "no class",
+			expectSPIFFEIDs: []string{
+				"spiffe://example.org/csid/classless",
+				"spiffe://example.org/cse/classless",
+			},
+		},
+		{
+			desc:      "class",
+			className: "a",
+			expectSPIFFEIDs: []string{
+				"spiffe://example.org/csid/a",
+				"spiffe://example.org/cse/a",
+			},
+		},
+		{
+			desc:           "class and classless",
+			className:      "a",
+			watchClassless: true,
+			expectSPIFFEIDs: []string{
+				"spiffe://example.org/csid/classless",
+				"spiffe://example.org/csid/a",
+				"spiffe://example.org/cse/classless",
+				"spiffe://example.org/cse/a",
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			var objs []client.Object
+			for _, className := range []string{"", "a", "b"} {
+				objs = append(objs, newClusterSPIFFEID(className), newClusterStaticEntry(className))
+			}
+			k8sClient := k8stest.NewClientBuilder(t).
+				WithObjects(append(objs, node, namespace, pod)...).
+				WithStatusSubresource(objs...).
+				Build()
+			entryClient := newEntryClient()
+
+			r := &entryReconciler{config: ReconcilerConfig{
+				TrustDomain:    td,
+				ClusterName:    clusterName,
+				ClusterDomain:  clusterDomain,
+				K8sClient:      k8sClient,
+				EntryClient:    entryClient,
+				ClassName:      tt.className,
+				WatchClassless: tt.watchClassless,
+			}}
+			r.reconcile(context.Background())
+
+			var spiffeIDs []string
+			for _, entry := range entryClient.getEntries() {
+				spiffeIDs = append(spiffeIDs, entry.SPIFFEID.String())
+			}
+			require.ElementsMatch(t, tt.expectSPIFFEIDs, spiffeIDs)
+		})
+	}
+}
+
+func TestReconcileClusterSPIFFEIDConditions(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+	newClusterSPIFFEID := func(name, spiffeIDTemplate string) *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 2},
+			Spec:       spirev1alpha1.ClusterSPIFFEIDSpec{SPIFFEIDTemplate: spiffeIDTemplate},
+		}
+	}
+
+	for _, tt := range []struct {
+		desc             string
+		spiffeIDTemplate string
+		createErr        error
+		expectReady      metav1.ConditionStatus
+		expectStalled    metav1.ConditionStatus
+		expectReason     string
+	}{
+		{
+			desc:             "ready",
+			spiffeIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			expectReady:      metav1.ConditionTrue,
+			expectStalled:    metav1.ConditionFalse,
+			expectReason:     spirev1alpha1.ClusterSPIFFEIDReasonReconciled,
+		},
+		{
+			desc:             "invalid spec",
+			spiffeIDTemplate: "{{",
+			expectReady:      metav1.ConditionFalse,
+			expectStalled:    metav1.ConditionTrue,
+			expectReason:     spirev1alpha1.ClusterSPIFFEIDReasonInvalidSpec,
+		},
+		{
+			desc:             "render failure",
+			spiffeIDTemplate: "spiffe://{{ .TrustDomain }}/{{ .PodMeta.Labels.missing }}",
+			expectReady:      metav1.ConditionFalse,
+			expectStalled:    metav1.ConditionTrue,
+			expectReason:     spirev1alpha1.ClusterSPIFFEIDReasonRenderFailed,
+		},
+		{
+			desc:             "entry failure",
+			spiffeIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			createErr:        errors.New("oh no"),
+			expectReady:      metav1.ConditionFalse,
+			expectStalled:    metav1.ConditionFalse,
+			expectReason:     spirev1alpha1.ClusterSPIFFEIDReasonEntryFailed,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx := context.Background()
+			clusterSPIFFEID := newClusterSPIFFEID("csid", tt.spiffeIDTemplate)
+			k8sClient := k8stest.NewClientBuilder(t).
+				WithObjects(node, namespace, pod, clusterSPIFFEID).
+				WithStatusSubresource(clusterSPIFFEID).
+				Build()
+			entryClient := newEntryClient()
+			entryClient.createErr = tt.createErr
+
+			r := &entryReconciler{config: ReconcilerConfig{
+				TrustDomain:   td,
+				ClusterName:   clusterName,
+				ClusterDomain: clusterDomain,
+				K8sClient:     k8sClient,
+				EntryClient:   entryClient,
+			}}
+			r.reconcile(ctx)
+
+			require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+			ready := meta.FindStatusCondition(clusterSPIFFEID.Status.Conditions, spirev1alpha1.ClusterSPIFFEIDConditionReady)
+			require.NotNil(t, ready)
+			require.Equal(t, tt.expectReady, ready.Status)
+			require.Equal(t, tt.expectReason, ready.Reason)
+			require.Equal(t, clusterSPIFFEID.Generation, ready.ObservedGeneration)
+			stalled := meta.FindStatusCondition(clusterSPIFFEID.Status.Conditions, spirev1alpha1.ClusterSPIFFEIDConditionStalled)
+			require.NotNil(t, stalled)
+			require.Equal(t, tt.expectStalled, stalled.Status)
+
+			// Reconciling again without changes keeps the conditions as-is.
+			conditions := clusterSPIFFEID.Status.Conditions
+			r.reconcile(ctx)
+			require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterSPIFFEID), clusterSPIFFEID))
+			require.Equal(t, conditions, clusterSPIFFEID.Status.Conditions)
+		})
+	}
+}
+
+func TestReconcileNamespacedSPIFFEIDs(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	newPod := func(namespace string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: namespace, UID: types.UID(namespace + "uid")},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		}
+	}
+	newNamespacedSPIFFEID := func(name, spiffeIDTemplate string) *spirev1alpha1.NamespacedSPIFFEID {
+		return &spirev1alpha1.NamespacedSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: name, UID: types.UID(name + "uid")},
+			Spec:       spirev1alpha1.NamespacedSPIFFEIDSpec{SPIFFEIDTemplate: spiffeIDTemplate},
+		}
+	}
+	allowed := newNamespacedSPIFFEID("allowed", "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}")
+	escaping := newNamespacedSPIFFEID("escaping", "spiffe://{{ .TrustDomain }}/ns/team-b/pod/{{ .PodMeta.Name }}")
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, allowed, escaping,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+			newPod("team-a"),
+			newPod("team-b"),
+		).
+		WithStatusSubresource(allowed, escaping).
+		Build()
+	entryClient := newEntryClient()
+
+	namespacePathPrefixTemplate, err := spirev1alpha1.ParseNamespacePathPrefixTemplate(spirev1alpha1.DefaultNamespacePathPrefixTemplate)
+	require.NoError(t, err)
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:                 td,
+		ClusterName:                 clusterName,
+		ClusterDomain:               clusterDomain,
+		K8sClient:                   k8sClient,
+		EntryClient:                 entryClient,
+		NamespacePathPrefixTemplate: namespacePathPrefixTemplate,
+	}}
+	r.reconcile(ctx)
+
+	// Only the pod in the namespace of the NamespacedSPIFFEIDs is selected,
+	// and the SPIFFE ID outside of the namespace path prefix is rejected.
+	var spiffeIDs []string
+	for _, entry := range entryClient.getEntries() {
+		spiffeIDs = append(spiffeIDs, entry.SPIFFEID.String())
+	}
+	require.Equal(t, []string{"spiffe://example.org/ns/team-a/pod/pod"}, spiffeIDs)
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(allowed), allowed))
+	require.Equal(t, spirev1alpha1.NamespacedSPIFFEIDStats{PodsSelected: 1, EntriesToSet: 1}, allowed.Status.Stats)
+	require.True(t, meta.IsStatusConditionTrue(allowed.Status.Conditions, spirev1alpha1.ClusterSPIFFEIDConditionReady))
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(escaping), escaping))
+	require.Equal(t, spirev1alpha1.NamespacedSPIFFEIDStats{PodsSelected: 1, PodEntryRenderFailures: 1}, escaping.Status.Stats)
+	ready := meta.FindStatusCondition(escaping.Status.Conditions, spirev1alpha1.ClusterSPIFFEIDConditionReady)
+	require.NotNil(t, ready)
+	require.Equal(t, metav1.ConditionFalse, ready.Status)
+	require.Equal(t, spirev1alpha1.ClusterSPIFFEIDReasonRenderFailed, ready.Reason)
+	require.Contains(t, ready.Message, `path "/ns/team-b/pod/pod" is not under an allowed path prefix`)
+}
+
+func TestReconcileEvents(t *testing.T) {
+	ctx := context.Background()
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	now := time.Now()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+	newClusterSPIFFEID := func(name string, created time.Time, spiffeIDTemplate string) *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.Time{Time: created}},
+			Spec:       spirev1alpha1.ClusterSPIFFEIDSpec{SPIFFEIDTemplate: spiffeIDTemplate},
+		}
+	}
+	podTemplate := "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}"
+	older := newClusterSPIFFEID("older", now, podTemplate)
+	newer := newClusterSPIFFEID("newer", now.Add(time.Second), podTemplate)
+	renderFailure := newClusterSPIFFEID("render-failure", now, "spiffe://{{ .TrustDomain }}/{{ .PodMeta.Labels.missing }}")
+	invalidStaticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "invalid",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"a:b"},
+		},
+	}
+
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, older, newer, renderFailure, invalidStaticEntry).
+		WithStatusSubresource(older, newer, renderFailure, invalidStaticEntry).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   newEntryClient(),
+		EventRecorder: recorder,
+	}}
+	r.reconcile(ctx)
+	close(recorder.Events)
+
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	require.Len(t, events, 4)
+	require.Contains(t, events, "Warning Masked 1 entries are masked by similar entries declared by ClusterSPIFFEID/older")
+	require.Contains(t, events, "Normal Masking 1 entries mask similar entries declared by ClusterSPIFFEID/newer")
+	require.Contains(t, events, "Warning RenderFailed failed to parse SPIFFEID: scheme is missing or invalid")
+	require.Contains(t, events, "Warning RenderFailed failed to render entry for pod ns/pod: failed to render SPIFFE ID: "+
+		"invalid SPIFFE ID: path segment characters are limited to letters, numbers, dots, dashes, and underscores")
+}
+
+func TestReconcileEntryConflicts(t *testing.T) {
+	ctx := context.Background()
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	now := time.Now()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+	newClusterSPIFFEID := func(name string, created time.Time, uid types.UID) *spirev1alpha1.ClusterSPIFFEID {
+		return &spirev1alpha1.ClusterSPIFFEID{
+			ObjectMeta: metav1.ObjectMeta{Name: name, UID: uid, CreationTimestamp: metav1.Time{Time: created}},
+			Spec:       spirev1alpha1.ClusterSPIFFEIDSpec{SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}"},
+		}
+	}
+	// The objects created at the same time are ordered by name, regardless
+	// of their UIDs.
+	alpha := newClusterSPIFFEID("alpha", now, "2")
+	beta := newClusterSPIFFEID("beta", now, "1")
+	newer := newClusterSPIFFEID("newer", now.Add(time.Second), "0")
+	staticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static", CreationTimestamp: metav1.Time{Time: now.Add(-time.Second)}},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"a:1"},
+		},
+	}
+	maskedStaticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "masked-static", CreationTimestamp: metav1.Time{Time: now}},
+		Spec:       staticEntry.Spec,
+	}
+
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, alpha, beta, newer, staticEntry, maskedStaticEntry).
+		WithStatusSubresource(alpha, beta, newer, staticEntry, maskedStaticEntry).
+		Build()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   newEntryClient(),
+	}}
+	r.reconcile(ctx)
+
+	for _, obj := range []client.Object{alpha, beta, newer, staticEntry, maskedStaticEntry} {
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj))
+	}
+	require.Equal(t, 2, alpha.Status.Stats.EntriesMasking)
+	require.Equal(t, []string{"ClusterSPIFFEID/beta", "ClusterSPIFFEID/newer"}, alpha.Status.Masking)
+	require.Empty(t, alpha.Status.MaskedBy)
+	require.Equal(t, 1, beta.Status.Stats.EntriesMasked)
+	require.Equal(t, []string{"ClusterSPIFFEID/alpha"}, beta.Status.MaskedBy)
+	require.Equal(t, 1, newer.Status.Stats.EntriesMasked)
+	require.Equal(t, []string{"ClusterSPIFFEID/alpha"}, newer.Status.MaskedBy)
+	require.Equal(t, []string{"ClusterStaticEntry/masked-static"}, staticEntry.Status.Masking)
+	require.True(t, maskedStaticEntry.Status.Masked)
+	require.Equal(t, []string{"ClusterStaticEntry/static"}, maskedStaticEntry.Status.MaskedBy)
+}
+
+func TestAddConflictingObject(t *testing.T) {
+	var names []string
+	for i := 20; i > 0; i-- {
+		names = addConflictingObject(names, &ClusterStaticEntry{ClusterStaticEntry: spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("entry-%02d", i)},
+		}})
+	}
+	names = addConflictingObject(names, &ClusterStaticEntry{ClusterStaticEntry: spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "entry-01"},
+	}})
+	require.Equal(t, []string{
+		"ClusterStaticEntry/entry-01", "ClusterStaticEntry/entry-02", "ClusterStaticEntry/entry-03",
+		"ClusterStaticEntry/entry-04", "ClusterStaticEntry/entry-05", "ClusterStaticEntry/entry-06",
+		"ClusterStaticEntry/entry-07", "ClusterStaticEntry/entry-08", "ClusterStaticEntry/entry-09",
+		"ClusterStaticEntry/entry-10",
+	}, names)
+}
+
+func TestReconcileFederatesWithSets(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:  "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+			FederatesWith:     []string{"a.test"},
+			FederatesWithSets: []string{"partners", "missing"},
+		},
+	}
+	partners := &spirev1alpha1.ClusterTrustDomainSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "partners"},
+		Spec: spirev1alpha1.ClusterTrustDomainSetSpec{
+			TrustDomains: []string{"a.test", "b.test"},
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, clusterSPIFFEID, partners).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+	}}
+
+	requireFederatesWith := func(expected ...string) {
+		entries := entryClient.getEntries()
+		require.Len(t, entries, 1)
+		var actual []string
+		for _, td := range entries[0].FederatesWith {
+			actual = append(actual, td.String())
+		}
+		require.Equal(t, expected, actual)
+	}
+
+	t.Log("Federate with the trust domains in the set, skipping the missing set")
+	r.reconcile(ctx)
+	requireFederatesWith("a.test", "b.test")
+
+	t.Log("Pick up changes to the set even though the entry render is cached")
+	partners.Spec.TrustDomains = append(partners.Spec.TrustDomains, "c.test")
+	require.NoError(t, k8sClient.Update(ctx, partners))
+	r.reconcile(ctx)
+	requireFederatesWith("a.test", "b.test", "c.test")
+
+	t.Log("Stop federating with the trust domains of a deleted set")
+	require.NoError(t, k8sClient.Delete(ctx, partners))
+	r.reconcile(ctx)
+	requireFederatesWith("a.test")
+}
+
+func TestReconcileFederatedBundles(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+	federating := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "federating", Generation: 1},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate:  "spiffe://{{ .TrustDomain }}/federating/{{ .PodMeta.Name }}",
+			FederatesWith:     []string{"a.test", trustDomain},
+			FederatesWithSets: []string{"partners"},
+		},
+	}
+	nonFederating := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "nonfederating", Generation: 1},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/nonfederating/{{ .PodMeta.Name }}",
+		},
+	}
+	partners := &spirev1alpha1.ClusterTrustDomainSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "partners"},
+		Spec: spirev1alpha1.ClusterTrustDomainSetSpec{
+			TrustDomains: []string{"b.test", "c.test"},
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, federating, nonFederating, partners).
+		WithStatusSubresource(federating, nonFederating).
+		Build()
+	bundleClient := &bundleClient{}
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   newEntryClient(),
+		BundleClient:  bundleClient,
+	}}
+
+	requireFederated := func(o *spirev1alpha1.ClusterSPIFFEID, expectStatus metav1.ConditionStatus, expectReason, expectMessage string) {
+		t.Helper()
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(o), o))
+		federated := meta.FindStatusCondition(o.Status.Conditions, spirev1alpha1.ClusterSPIFFEIDConditionFederated)
+		require.NotNil(t, federated)
+		require.Equal(t, expectStatus, federated.Status)
+		require.Equal(t, expectReason, federated.Reason)
+		require.Contains(t, federated.Message, expectMessage)
+	}
+
+	t.Log("Report the trust domains without a bundle, except the local one")
+	bundleClient.trustDomains = []spiffeid.TrustDomain{spiffeid.RequireTrustDomainFromString("b.test")}
+	r.reconcile(ctx)
+	requireFederated(federating, metav1.ConditionFalse, spirev1alpha1.ClusterSPIFFEIDReasonBundlesMissing, "a.test, c.test;")
+	requireFederated(nonFederating, metav1.ConditionTrue, spirev1alpha1.ClusterSPIFFEIDReasonBundlesAvailable, "")
+
+	t.Log("Keep the conditions as they were when the bundles cannot be listed")
+	bundleClient.listErr = errors.New("oh no")
+	bundleClient.trustDomains = append(bundleClient.trustDomains,
+		spiffeid.RequireTrustDomainFromString("a.test"),
+		spiffeid.RequireTrustDomainFromString("c.test"))
+	r.reconcile(ctx)
+	requireFederated(federating, metav1.ConditionFalse, spirev1alpha1.ClusterSPIFFEIDReasonBundlesMissing, "a.test, c.test;")
+
+	t.Log("Report the bundles available once they appear")
+	bundleClient.listErr = nil
+	r.reconcile(ctx)
+	requireFederated(federating, metav1.ConditionTrue, spirev1alpha1.ClusterSPIFFEIDReasonBundlesAvailable, "")
+
+	t.Log("Do not list the bundles when nothing federates")
+	require.NoError(t, k8sClient.Delete(ctx, federating))
+	listCalls := bundleClient.listCalls
+	r.reconcile(ctx)
+	require.Equal(t, listCalls, bundleClient.listCalls)
+	requireFederated(nonFederating, metav1.ConditionTrue, spirev1alpha1.ClusterSPIFFEIDReasonBundlesAvailable, "")
+}
+
+func TestReconcileEntryHook(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid", Labels: map[string]string{"app": "pod"}},
+		Spec:       corev1.PodSpec{NodeName: "node", ServiceAccountName: "sa"},
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid", UID: "csiduid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	staleEntry := spireapi.Entry{
+		ID:        "stale",
+		SPIFFEID:  spiffeid.RequireFromPath(td, "/stale"),
+		ParentID:  spiffeid.RequireFromPath(td, "/parent"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:staleuid"}},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, clusterSPIFFEID).
+		WithStatusSubresource(clusterSPIFFEID).
+		Build()
+	hook := new(fakeEntryHook)
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   newEntryClient(staleEntry),
+		EntryHook:     hook,
+	}}
+	r.reconcile(ctx)
+
+	require.Len(t, hook.events, 2)
+
+	deleted := hook.events[0]
+	require.Equal(t, entryhook.EntryDeleted, deleted.Type)
+	require.Equal(t, "stale", deleted.Entry.ID)
+	require.Equal(t, "spiffe://example.org/stale", deleted.Entry.SPIFFEID)
+	require.Nil(t, deleted.Owner)
+	require.Nil(t, deleted.Workload)
+
+	created := hook.events[1]
+	require.Equal(t, entryhook.EntryCreated, created.Type)
+	require.Equal(t, "spiffe://example.org/ns/ns/pod/pod", created.Entry.SPIFFEID)
+	require.Equal(t, []string{"k8s:pod-uid:poduid"}, created.Entry.Selectors)
+	require.Equal(t, &entryhook.ObjectReference{Kind: "ClusterSPIFFEID", Name: "csid", UID: "csiduid"}, created.Owner)
+	require.Equal(t, &entryhook.Workload{
+		Namespace:          "ns",
+		Name:               "pod",
+		UID:                "poduid",
+		NodeName:           "node",
+		ServiceAccountName: "sa",
+		Labels:             map[string]string{"app": "pod"},
+	}, created.Workload)
+}
+
+func TestReconcileIdentityReport(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newPod := func(name, serviceAccountName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "uid")},
+			Spec:       corev1.PodSpec{NodeName: "node", ServiceAccountName: serviceAccountName},
+		}
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	clusterStaticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"a:1"},
+		},
+	}
+
+	ctx := context.Background()
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, clusterSPIFFEID, clusterStaticEntry,
+			newPod("pod1", "sa"),
+			newPod("pod2", "sa"),
+			newPod("pod3", ""),
+		).
+		WithStatusSubresource(clusterSPIFFEID, clusterStaticEntry).
+		Build()
+	reporter := new(fakeIdentityReporter)
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:      td,
+		ClusterName:      clusterName,
+		ClusterDomain:    clusterDomain,
+		K8sClient:        k8sClient,
+		EntryClient:      newEntryClient(),
+		IdentityReporter: reporter,
+	}}
+	r.reconcile(ctx)
+
+	require.Equal(t, &identityreport.Report{
+		Total:                4,
+		Namespaces:           map[string]int{"ns": 3},
+		ServiceAccounts:      map[string]int{"ns/sa": 2, "ns/default": 1},
+		ClusterSPIFFEIDs:     map[string]int{"csid": 3},
+		ClusterStaticEntries: 1,
+	}, reporter.report)
+}
+
+func TestReconcileRemoteCluster(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "namespace", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node", ServiceAccountName: "sa"},
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/cluster/{{ .ClusterName }}/sa/{{ .PodSpec.ServiceAccountName }}",
+		},
+	}
+	clusterStaticEntry := &spirev1alpha1.ClusterStaticEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "static"},
+		Spec: spirev1alpha1.ClusterStaticEntrySpec{
+			SPIFFEID:  "spiffe://example.org/static",
+			ParentID:  "spiffe://example.org/parent",
+			Selectors: []string{"unix:uid:0"},
+		},
+	}
+	localEntry := spireapi.Entry{
+		ID:        "local",
+		SPIFFEID:  spiffeid.RequireFromPath(td, "/cluster/test/sa/sa"),
+		ParentID:  spiffeid.RequireFromPath(td, "/spire/agent/k8s_psat/test/nodeuid"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:localpoduid"}},
+	}
+	staleRemoteEntry := spireapi.Entry{
+		ID:        "stale-remote",
+		SPIFFEID:  spiffeid.RequireFromPath(td, "/cluster/remote/sa/old"),
+		ParentID:  spiffeid.RequireFromPath(td, "/spire/agent/k8s_psat/remote/oldnodeuid"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:oldpoduid"}},
+	}
+	remoteEntry := spireapi.Entry{
+		ID:            "created-1",
+		SPIFFEID:      spiffeid.RequireFromPath(td, "/cluster/remote/sa/sa"),
+		ParentID:      spiffeid.RequireFromPath(td, "/spire/agent/k8s_psat/remote/nodeuid"),
+		Selectors:     []spireapi.Selector{{Type: "k8s", Value: "pod-uid:poduid"}},
+		FederatesWith: []spiffeid.TrustDomain{},
+	}
+
+	remoteClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, clusterSPIFFEID, clusterStaticEntry).
+		WithStatusSubresource(clusterSPIFFEID, clusterStaticEntry).
+		Build()
+	entryClient := newEntryClient(localEntry, staleRemoteEntry)
+
+	remotePrefix := ClusterAgentPathPrefix("remote")
+	managedByRemote := func(entry spireapi.Entry) bool {
+		return strings.HasPrefix(entry.ParentID.Path(), remotePrefix)
+	}
+
+	// The remote reconciler only manages the entries of the remote cluster,
+	// and does not reconcile ClusterStaticEntries.
+	remote := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   "remote",
+		ClusterDomain: clusterDomain,
+		K8sClient:     remoteClient,
+		EntryClient:   entryClient,
+		ManagesEntry:  managedByRemote,
+		RemoteCluster: true,
+	}}
+	require.Equal(t, "entry/remote", remote.kind())
+	require.NoError(t, remote.reconcile(context.Background()))
+	require.Equal(t, []spireapi.Entry{remoteEntry, localEntry}, entryClient.getEntries())
+
+	// The local reconciler leaves the entries of the remote cluster alone.
+	local := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8stest.NewClientBuilder(t).Build(),
+		EntryClient:   entryClient,
+		ManagesEntry: func(entry spireapi.Entry) bool {
+			return !managedByRemote(entry)
+		},
+	}}
+	require.NoError(t, local.reconcile(context.Background()))
+	require.Equal(t, []spireapi.Entry{remoteEntry}, entryClient.getEntries())
+}
+
+func TestReconcileSPIREServer(t *testing.T) {
+	staticEntry := func(name, spireServer string) *spirev1alpha1.ClusterStaticEntry {
+		return &spirev1alpha1.ClusterStaticEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterStaticEntrySpec{
+				SPIFFEID:    "spiffe://example.org/" + name,
+				ParentID:    "spiffe://example.org/parent",
+				Selectors:   []string{"unix:uid:0"},
+				SPIREServer: spireServer,
+			},
+		}
+	}
+	defaultStatic := staticEntry("default", "")
+	otherStatic := staticEntry("other", "other")
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(defaultStatic, otherStatic).
+		WithStatusSubresource(defaultStatic, otherStatic).
+		Build()
+
+	// Each reconciler only registers the entries of the ClusterStaticEntries
+	// that select its SPIRE Server.
+	for _, tt := range []struct {
+		spireServer string
+		kind        string
+		expectID    string
+	}{
+		{spireServer: "", kind: "entry", expectID: "spiffe://example.org/default"},
+		{spireServer: "other", kind: "entry@other", expectID: "spiffe://example.org/other"},
+	} {
+		entryClient := newEntryClient()
+		r := &entryReconciler{config: ReconcilerConfig{
+			TrustDomain:   spiffeid.RequireTrustDomainFromString(trustDomain),
+			ClusterName:   clusterName,
+			ClusterDomain: clusterDomain,
+			K8sClient:     k8sClient,
+			EntryClient:   entryClient,
+			SPIREServer:   tt.spireServer,
+		}}
+		require.Equal(t, tt.kind, r.kind())
+		require.NoError(t, r.reconcile(context.Background()))
+		entries := entryClient.getEntries()
+		require.Len(t, entries, 1)
+		require.Equal(t, tt.expectID, entries[0].SPIFFEID.String())
+	}
+}
+
+func TestReconcileClusterNodeAliases(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := func(name, pool string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"pool": pool}}}
+	}
+	gpuAlias := &spirev1alpha1.ClusterNodeAlias{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu"},
+		Spec: spirev1alpha1.ClusterNodeAliasSpec{
+			SPIFFEID:   "spiffe://example.org/nodes/gpu",
+			NodeLabels: map[string]string{"pool": "gpu"},
+			Selectors:  []string{"k8s_psat:agent_sa:spire-agent"},
+		},
+	}
+	otherTrustDomainAlias := &spirev1alpha1.ClusterNodeAlias{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Spec: spirev1alpha1.ClusterNodeAliasSpec{
+			SPIFFEID: "spiffe://other.test/nodes/all",
+		},
+	}
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node("node1", "gpu"), node("node2", "gpu"), node("node3", "cpu"), gpuAlias, otherTrustDomainAlias).
+		WithStatusSubresource(gpuAlias, otherTrustDomainAlias).
+		Build()
+	entryClient := newEntryClient()
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+	}}
+	require.NoError(t, r.reconcile(context.Background()))
+
+	// The node alias is parented by SPIRE Server and selects the agents of
+	// the cluster that have the node labels and selectors.
+	require.Equal(t, []spireapi.Entry{
+		{
+			ID:       "created-1",
+			SPIFFEID: spiffeid.RequireFromPath(td, "/nodes/gpu"),
+			ParentID: spiffeid.RequireFromPath(td, "/spire/server"),
+			Selectors: []spireapi.Selector{
+				{Type: "k8s_psat", Value: "cluster:test"},
+				{Type: "k8s_psat", Value: "agent_node_label:pool:gpu"},
+				{Type: "k8s_psat", Value: "agent_sa:spire-agent"},
+			},
+		},
+	}, entryClient.getEntries())
+
+	actual := new(spirev1alpha1.ClusterNodeAlias)
+	require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(gpuAlias), actual))
+	require.Equal(t, spirev1alpha1.ClusterNodeAliasStatus{NodesSelected: 2, Rendered: true, Set: true}, actual.Status)
+
+	// Node aliases outside of the trust domain are not rendered.
+	require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(otherTrustDomainAlias), actual))
+	require.Equal(t, spirev1alpha1.ClusterNodeAliasStatus{}, actual.Status)
+}
+
+func TestReconcileClusterNodeAliasCRDNotInstalled(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", UID: "poduid"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+	clusterSPIFFEID := &spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid"},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/pod/{{ .PodMeta.Name }}",
+		},
+	}
+	stale := spireapi.Entry{
+		ID:        "stale",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/stale"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/node"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:stale"}},
+	}
+
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithObjects(node, namespace, pod, clusterSPIFFEID).
+		WithStatusSubresource(clusterSPIFFEID).
+		WithInterceptorFuncs(crdsNotInstalled("ClusterNodeAlias")).
+		Build()
+	entryClient := newEntryClient(stale)
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+	}}
+
+	// Until the CRD is confirmed not to be installed, the reconcile fails
+	// instead of deleting the entries of the node aliases.
+	require.Error(t, r.reconcile(context.Background()))
+	require.Equal(t, []spireapi.Entry{stale}, entryClient.getEntries())
+
+	r.config.KindNotServed = kindsNotServed("ClusterNodeAlias")
+	require.NoError(t, r.reconcile(context.Background()))
+
+	// The other entries are still reconciled.
+	entries := entryClient.getEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "spiffe://example.org/ns/ns/pod/pod", entries[0].SPIFFEID.String())
+
+	explanation, err := NewExplainer(r.config).Explain(context.Background(), client.ObjectKeyFromObject(pod))
+	require.NoError(t, err)
+	require.Equal(t, "pod", explanation.Name)
+}
+
+func TestReconcileCRDsNotInstalled(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString(trustDomain)
+	kinds := []string{"ClusterSPIFFEID", "NamespacedSPIFFEID", "ClusterStaticEntry", "ClusterNodeAlias", "ClusterTrustDomainSet"}
+	stale := spireapi.Entry{
+		ID:        "stale",
+		SPIFFEID:  spiffeid.RequireFromString("spiffe://example.org/stale"),
+		ParentID:  spiffeid.RequireFromString("spiffe://example.org/node"),
+		Selectors: []spireapi.Selector{{Type: "k8s", Value: "pod-uid:stale"}},
+	}
+
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithInterceptorFuncs(crdsNotInstalled(kinds...)).
+		Build()
+	entryClient := newEntryClient(stale)
+
+	r := &entryReconciler{config: ReconcilerConfig{
+		TrustDomain:   td,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		K8sClient:     k8sClient,
+		EntryClient:   entryClient,
+	}}
+
+	// A NoMatch error alone may be temporary, e.g. while the REST mapping
+	// is stale, so the reconcile fails without deleting anything.
+	require.Error(t, r.reconcile(context.Background()))
+	require.Equal(t, []spireapi.Entry{stale}, entryClient.getEntries())
+
+	// Once the CRD watcher has confirmed that the kinds are not served,
+	// they have no objects, so the reconcile succeeds and the initial sync
+	// completes.
+	r.config.KindNotServed = kindsNotServed(kinds...)
+	require.NoError(t, r.reconcile(context.Background()))
+	require.Empty(t, entryClient.getEntries())
+}
+
+// kindsNotServed reports the given kinds as confirmed not to be served, the
+// way the CRD watcher does once it has polled for them.
+func kindsNotServed(kinds ...string) func(schema.GroupVersionKind) bool {
+	return func(gvk schema.GroupVersionKind) bool {
+		for _, kind := range kinds {
+			if gvk == spirev1alpha1.GroupVersion.WithKind(kind) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// crdsNotInstalled fails listing the given kinds the way the REST mapper of
+// the manager client does when their CRDs are not installed.
+func crdsNotInstalled(kinds ...string) interceptor.Funcs {
+	return interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			gvk, err := apiutil.GVKForObject(list, c.Scheme())
+			if err != nil {
+				return err
+			}
+			for _, kind := range kinds {
+				if gvk.Kind == kind+"List" {
+					return &meta.NoKindMatchError{
+						GroupKind:        schema.GroupKind{Group: gvk.Group, Kind: kind},
+						SearchedVersions: []string{gvk.Version},
+					}
+				}
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}
+}
+
+type fakeIdentityReporter struct {
+	report *identityreport.Report
+}
+
+func (r *fakeIdentityReporter) Publish(_ context.Context, report *identityreport.Report) {
+	r.report = report
+}
+
+type fakeEntryHook struct {
+	events []entryhook.Event
+}
+
+func (h *fakeEntryHook) Notify(_ context.Context, events []entryhook.Event) {
+	h.events = append(h.events, events...)
+}
+
+type fakeEntryTransformer struct {
+	transform func(entrytransformer.Request) entryhook.Entry
+	err       error
+	requests  []entrytransformer.Request
+}
+
+func (t *fakeEntryTransformer) Transform(_ context.Context, requests []entrytransformer.Request) ([]entryhook.Entry, error) {
+	t.requests = requests
+	if t.err != nil {
+		return nil, t.err
+	}
+	entries := make([]entryhook.Entry, 0, len(requests))
+	for _, request := range requests {
+		entries = append(entries, t.transform(request))
+	}
+	return entries, nil
+}
+
+type bundleClient struct {
+	trustDomains []spiffeid.TrustDomain
+	listErr      error
+	listCalls    int
+}
+
+func (c *bundleClient) GetBundle(context.Context) (*spiffebundle.Bundle, error) {
+	return nil, errors.New("unexpected call to GetBundle")
+}
+
+func (c *bundleClient) GetFederatedBundle(context.Context, spiffeid.TrustDomain) (*spiffebundle.Bundle, error) {
+	return nil, errors.New("unexpected call to GetFederatedBundle")
+}
+
+func (c *bundleClient) ListFederatedBundles(context.Context) ([]*spiffebundle.Bundle, error) {
+	c.listCalls++
+	if c.listErr != nil {
+		return nil, c.listErr
+	}
+	var bundles []*spiffebundle.Bundle
+	for _, td := range c.trustDomains {
+		bundles = append(bundles, spiffebundle.New(td))
+	}
+	return bundles, nil
+}
+
+func (c *bundleClient) DeleteFederatedBundles(context.Context, []spiffeid.TrustDomain) ([]spireapi.Status, error) {
+	return nil, errors.New("unexpected call to DeleteFederatedBundles")
+}
+
+type entryClient struct {
+	entries   map[string]spireapi.Entry
+	nextID    int
+	createErr error
+	updateErr error
+
+	// entryLimit, if set, is the number of entries that can be created for
+	// each parent before creates fail with ResourceExhausted.
+	entryLimit  int
+	createCalls int
+	updateCalls int
+	deleteCalls int
+	listCalls   int
+
+	listBySelectorCalls int
+}
+
+func newEntryClient(entries ...spireapi.Entry) *entryClient {
+	c := &entryClient{entries: make(map[string]spireapi.Entry)}
+	for _, entry := range entries {
+		c.entries[entry.ID] = entry
+	}
+	return c
+}
+
+func (c *entryClient) ListEntries(context.Context) ([]spireapi.Entry, error) {
+	c.listCalls++
+	return c.getEntries(), nil
+}
+
+func (c *entryClient) ListEntriesBySelector(_ context.Context, selector spireapi.Selector) ([]spireapi.Entry, error) {
+	c.listBySelectorCalls++
+	var entries []spireapi.Entry
+	for _, entry := range c.getEntries() {
+		if hasAnySelector(entry, map[spireapi.Selector]bool{selector: true}) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (c *entryClient) CreateEntries(_ context.Context, entries []spireapi.Entry) ([]spireapi.EntryStatus, error) {
+	if c.createErr != nil {
+		return nil, c.createErr
+	}
+	c.createCalls += len(entries)
+	statuses := make([]spireapi.EntryStatus, 0, len(entries))
+	for _, entry := range entries {
+		if c.entryLimit > 0 && c.countEntries(entry.ParentID) >= c.entryLimit {
+			statuses = append(statuses, spireapi.EntryStatus{Status: spireapi.Status{Code: codes.ResourceExhausted, Message: "entry limit exceeded"}})
+			continue
+		}
+		c.nextID++
+		entry.ID = fmt.Sprintf("created-%d", c.nextID)
+		c.entries[entry.ID] = entry
+		statuses = append(statuses, spireapi.EntryStatus{Status: spireapi.Status{Code: codes.OK}, Entry: &entry})
+	}
+	return statuses, nil
+}
+
+func (c *entryClient) UpdateEntries(_ context.Context, entries []spireapi.Entry) ([]spireapi.EntryStatus, error) {
+	if c.updateErr != nil {
+		return nil, c.updateErr
+	}
+	c.updateCalls += len(entries)
+	statuses := make([]spireapi.EntryStatus, 0, len(entries))
+	for _, entry := range entries {
+		if _, ok := c.entries[entry.ID]; !ok {
+			statuses = append(statuses, spireapi.EntryStatus{Status: spireapi.Status{Code: codes.NotFound}})
+			continue
+		}
+		c.entries[entry.ID] = entry
+		entry := entry
+		statuses = append(statuses, spireapi.EntryStatus{Status: spireapi.Status{Code: codes.OK}, Entry: &entry})
+	}
+	return statuses, nil
+}
+
+func (c *entryClient) DeleteEntries(_ context.Context, entryIDs []string) ([]spireapi.Status, error) {
+	c.deleteCalls++
+	statuses := make([]spireapi.Status, 0, len(entryIDs))
+	for _, entryID := range entryIDs {
+		if _, ok := c.entries[entryID]; !ok {
+			statuses = append(statuses, spireapi.Status{Code: codes.NotFound})
+			continue
+		}
+		delete(c.entries, entryID)
+		statuses = append(statuses, spireapi.Status{Code: codes.OK})
+	}
+	return statuses, nil
+}
+
+func (c *entryClient) countEntries(parentID spiffeid.ID) int {
+	n := 0
+	for _, entry := range c.entries {
+		if entry.ParentID == parentID {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *entryClient) getEntries() []spireapi.Entry {
+	var entries []spireapi.Entry
+	for _, entry := range c.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	return entries
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"sync"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// renderCache caches the entries rendered for pods so that templates are not
+// re-executed for pods that have not changed since the last reconcile. A
+// cached entry is only reused if the generation of the ClusterSPIFFEID (or
+// NamespacedSPIFFEID) and the pod and node resource versions all match the
+// ones it was rendered from.
+//
+// Rendering also depends on the reconciler configuration, e.g. the trust
+// domain, cluster name and allowed path prefixes, which is not checked here
+// since it cannot change for the lifetime of the reconciler. A cache that
+// outlives the reconciler must be discarded when the configuration changes.
+//
+// Get and Put are safe for concurrent use, since pods may be rendered
+// concurrently. The other methods are only called from the reconcile loop
+// while no pods are being rendered.
+type renderCache struct {
+	mtx     sync.Mutex
+	entries map[renderCacheKey]*renderCacheEntry
+
+	// dirty is true if the cache changed since it was last saved.
+	dirty bool
+}
+
+type renderCacheKey struct {
+	Object    types.NamespacedName
+	ObjectUID types.UID
+	Pod       types.NamespacedName
+	PodUID    types.UID
+}
+
+type renderCacheEntry struct {
+	objectGeneration    int64
+	podResourceVersion  string
+	nodeResourceVersion string
+	entry               *spireapi.Entry
+	err                 error
+	used                bool
+}
+
+func makeRenderCacheKey(by metav1.Object, pod *corev1.Pod) renderCacheKey {
+	return renderCacheKey{
+		Object:    types.NamespacedName{Namespace: by.GetNamespace(), Name: by.GetName()},
+		ObjectUID: by.GetUID(),
+		Pod:       types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name},
+		PodUID:    pod.UID,
+	}
+}
+
+// Get returns the cached render result, if any, for the pod as selected by
+// the object.
+func (c *renderCache) Get(by metav1.Object, pod *corev1.Pod, node *corev1.Node) (*renderCacheEntry, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	cached, ok := c.entries[makeRenderCacheKey(by, pod)]
+	if !ok ||
+		cached.objectGeneration != by.GetGeneration() ||
+		cached.podResourceVersion != pod.ResourceVersion ||
+		cached.nodeResourceVersion != node.ResourceVersion {
+		return nil, false
+	}
+	cached.used = true
+	return cached, true
+}
+
+// Put stores the render result for the pod as selected by the object.
+func (c *renderCache) Put(by metav1.Object, pod *corev1.Pod, node *corev1.Node, entry *spireapi.Entry, err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[renderCacheKey]*renderCacheEntry)
+	}
+	c.dirty = true
+	c.entries[makeRenderCacheKey(by, pod)] = &renderCacheEntry{
+		objectGeneration:    by.GetGeneration(),
+		podResourceVersion:  pod.ResourceVersion,
+		nodeResourceVersion: node.ResourceVersion,
+		entry:               entry,
+		err:                 err,
+		used:                true,
+	}
+}
+
+// Sweep evicts the entries that were not accessed since the last sweep,
+// e.g. because the pod or the object was deleted or the pod is no
+// longer selected.
+func (c *renderCache) Sweep() {
+	for key, cached := range c.entries {
+		if !cached.used {
+			delete(c.entries, key)
+			c.dirty = true
+			continue
+		}
+		cached.used = false
+	}
+}
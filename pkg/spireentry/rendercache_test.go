@@ -0,0 +1,86 @@
+package spireentry
+
+import (
+	"errors"
+	"testing"
+
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderCache(t *testing.T) {
+	clusterSPIFFEID := &ClusterSPIFFEID{ClusterSPIFFEID: spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid", UID: "csiduid", Generation: 1},
+	}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod", UID: "poduid", ResourceVersion: "1"}}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid", ResourceVersion: "1"}}
+	entry := &spireapi.Entry{Hint: "cached"}
+
+	var cache renderCache
+	_, ok := cache.Get(clusterSPIFFEID, pod, node)
+	require.False(t, ok, "empty cache should miss")
+
+	cache.Put(clusterSPIFFEID, pod, node, entry, nil)
+	cached, ok := cache.Get(clusterSPIFFEID, pod, node)
+	require.True(t, ok)
+	require.Equal(t, entry, cached.entry)
+	require.NoError(t, cached.err)
+
+	t.Run("misses when inputs change", func(t *testing.T) {
+		changedClusterSPIFFEID := &ClusterSPIFFEID{ClusterSPIFFEID: *clusterSPIFFEID.DeepCopy()}
+		changedClusterSPIFFEID.Generation++
+		_, ok := cache.Get(changedClusterSPIFFEID, pod, node)
+		require.False(t, ok, "changed ClusterSPIFFEID generation should miss")
+
+		changedPod := pod.DeepCopy()
+		changedPod.ResourceVersion = "2"
+		_, ok = cache.Get(clusterSPIFFEID, changedPod, node)
+		require.False(t, ok, "changed pod resource version should miss")
+
+		changedNode := node.DeepCopy()
+		changedNode.ResourceVersion = "2"
+		_, ok = cache.Get(clusterSPIFFEID, pod, changedNode)
+		require.False(t, ok, "changed node resource version should miss")
+
+		otherClusterSPIFFEID := &ClusterSPIFFEID{ClusterSPIFFEID: *clusterSPIFFEID.DeepCopy()}
+		otherClusterSPIFFEID.Name = "other"
+		otherClusterSPIFFEID.UID = "otheruid"
+		_, ok = cache.Get(otherClusterSPIFFEID, pod, node)
+		require.False(t, ok, "other ClusterSPIFFEID should miss")
+	})
+
+	t.Run("caches render failures", func(t *testing.T) {
+		failedPod := pod.DeepCopy()
+		failedPod.Name = "failed"
+		failedPod.UID = "faileduid"
+		cache.Put(clusterSPIFFEID, failedPod, node, nil, errors.New("oh no"))
+		cached, ok := cache.Get(clusterSPIFFEID, failedPod, node)
+		require.True(t, ok)
+		require.Nil(t, cached.entry)
+		require.EqualError(t, cached.err, "oh no")
+	})
+
+	t.Run("sweep evicts unused entries", func(t *testing.T) {
+		// Everything was used since the cache was created, so the first
+		// sweep retains everything.
+		cache.Sweep()
+		require.Len(t, cache.entries, 2)
+
+		_, ok := cache.Get(clusterSPIFFEID, pod, node)
+		require.True(t, ok)
+		cache.Sweep()
+		require.Len(t, cache.entries, 1)
+		_, ok = cache.Get(clusterSPIFFEID, pod, node)
+		require.True(t, ok)
+
+		// The remaining entry was just used, so it survives one more sweep
+		// and is evicted by the next.
+		cache.Sweep()
+		require.Len(t, cache.entries, 1)
+		cache.Sweep()
+		require.Empty(t, cache.entries)
+	})
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"github.com/spiffe/spire-controller-manager/pkg/identityreport"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// addToReport counts the identity for the entry that will be set.
+func addToReport(report *identityreport.Report, declaredEntry declaredEntry) {
+	switch by := declaredEntry.By.(type) {
+	case *ClusterSPIFFEID:
+		pod := declaredEntry.Pod
+		if pod == nil {
+			return
+		}
+		report.AddPodIdentity(by.Name, pod.Namespace, podServiceAccountName(pod))
+	case *NamespacedSPIFFEID:
+		pod := declaredEntry.Pod
+		if pod == nil {
+			return
+		}
+		report.AddNamespacedPodIdentity(pod.Namespace, podServiceAccountName(pod))
+	case *ClusterStaticEntry:
+		report.AddStaticIdentity()
+	}
+}
+
+func podServiceAccountName(pod *corev1.Pod) string {
+	if pod.Spec.ServiceAccountName == "" {
+		return "default"
+	}
+	return pod.Spec.ServiceAccountName
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// serviceAccountParentID returns the parent ID of the entries of a
+// ClusterSPIFFEID in the ServiceAccount registration mode, i.e. the SPIFFE ID
+// of the ClusterNodeAlias it names. It returns the zero ID in the Pod
+// registration mode.
+func (r *entryReconciler) serviceAccountParentID(spec *spirev1alpha1.ParsedClusterSPIFFEIDSpec) (spiffeid.ID, error) {
+	if spec.RegistrationMode != spirev1alpha1.RegistrationModeServiceAccount {
+		return spiffeid.ID{}, nil
+	}
+	id, ok := r.nodeAliases[spec.NodeAlias]
+	if !ok {
+		return spiffeid.ID{}, fmt.Errorf("ClusterNodeAlias %q does not exist or is invalid", spec.NodeAlias)
+	}
+	return id, nil
+}
+
+// serviceAccountEntry returns the entry shared by the pods running as the
+// service account of the pod, given the entry rendered for the pod. It
+// selects the namespace and service account of the pod instead of the pod,
+// and is parented by the node alias.
+func serviceAccountEntry(entry *spireapi.Entry, pod *corev1.Pod, parentID spiffeid.ID) *spireapi.Entry {
+	out := *entry
+	out.ParentID = parentID
+	out.Selectors = []spireapi.Selector{
+		{Type: "k8s", Value: "ns:" + pod.Namespace},
+		{Type: "k8s", Value: "sa:" + podServiceAccountName(pod)},
+	}
+	for _, selector := range entry.Selectors {
+		if selector.Type == "k8s" && strings.HasPrefix(selector.Value, "pod-uid:") {
+			continue
+		}
+		out.Selectors = append(out.Selectors, selector)
+	}
+	return &out
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"sort"
+
+	"github.com/spiffe/spire-controller-manager/pkg/k8sapi"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// listNamespaceServices lists the services in the namespace. The services are
+// listed once per namespace per reconcile since every pod in the namespace
+// is matched against them.
+func (r *entryReconciler) listNamespaceServices(ctx context.Context, namespace string) ([]corev1.Service, error) {
+	r.servicesMtx.Lock()
+	defer r.servicesMtx.Unlock()
+
+	if services, ok := r.services[namespace]; ok {
+		return services, nil
+	}
+	services, err := k8sapi.ListNamespaceServices(ctx, r.config.K8sClient, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if r.services == nil {
+		r.services = make(map[string][]corev1.Service)
+	}
+	r.services[namespace] = services
+	return services, nil
+}
+
+// withServiceDNSNames returns the entry with the DNS names of the services
+// that target the pod added to the DNS names already on the entry.
+func (r *entryReconciler) withServiceDNSNames(ctx context.Context, entry *spireapi.Entry, pod *corev1.Pod) (*spireapi.Entry, error) {
+	if entry == nil {
+		return nil, nil
+	}
+	services, err := r.listNamespaceServices(ctx, pod.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	serviceDNSNames := podServiceDNSNames(services, pod, r.config.ClusterDomain)
+	if len(serviceDNSNames) == 0 {
+		return entry, nil
+	}
+
+	dnsNames := append([]string(nil), entry.DNSNames...)
+	seen := make(map[string]struct{}, len(dnsNames)+len(serviceDNSNames))
+	for _, dnsName := range dnsNames {
+		seen[dnsName] = struct{}{}
+	}
+	for _, dnsName := range serviceDNSNames {
+		if _, ok := seen[dnsName]; ok {
+			continue
+		}
+		seen[dnsName] = struct{}{}
+		dnsNames = append(dnsNames, dnsName)
+	}
+
+	copied := *entry
+	copied.DNSNames = dnsNames
+	return &copied, nil
+}
+
+// podServiceDNSNames returns the DNS names the cluster DNS resolves to the
+// pod through the services that select it, sorted. For each service this is
+// the service name and, for headless services that are the subdomain of the
+// pod, the per-pod hostname under the service. Services without a selector
+// are not considered since their endpoints are managed outside of the
+// cluster.
+func podServiceDNSNames(services []corev1.Service, pod *corev1.Pod, clusterDomain string) []string {
+	var dnsNames []string
+	for i := range services {
+		service := &services[i]
+		if service.Spec.Type == corev1.ServiceTypeExternalName || len(service.Spec.Selector) == 0 {
+			continue
+		}
+		if !labels.SelectorFromValidatedSet(service.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		serviceDNSName := service.Name + "." + service.Namespace + ".svc"
+		if clusterDomain != "" {
+			serviceDNSName += "." + clusterDomain
+		}
+		dnsNames = append(dnsNames, serviceDNSName)
+		if service.Spec.ClusterIP == corev1.ClusterIPNone && pod.Spec.Hostname != "" && pod.Spec.Subdomain == service.Name {
+			dnsNames = append(dnsNames, pod.Spec.Hostname+"."+serviceDNSName)
+		}
+	}
+	sort.Strings(dnsNames)
+	return dnsNames
+}
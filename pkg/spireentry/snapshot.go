@@ -0,0 +1,173 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+// renderCacheSnapshotVersion is bumped whenever the snapshot format or the
+// way entries are rendered changes, so that stale snapshots are discarded
+// instead of seeding the cache with entries that would render differently.
+const renderCacheSnapshotVersion = 2
+
+type renderCacheSnapshot struct {
+	Version           int                        `json:"version"`
+	ConfigFingerprint string                     `json:"configFingerprint"`
+	Entries           []renderCacheSnapshotEntry `json:"entries"`
+}
+
+type renderCacheSnapshotEntry struct {
+	Key                 renderCacheKey  `json:"key"`
+	ObjectGeneration    int64           `json:"objectGeneration"`
+	PodResourceVersion  string          `json:"podResourceVersion"`
+	NodeResourceVersion string          `json:"nodeResourceVersion"`
+	Entry               *spireapi.Entry `json:"entry,omitempty"`
+	Err                 string          `json:"err,omitempty"`
+}
+
+// renderConfigFingerprint returns a digest of the reconciler configuration
+// that pod entries are rendered from. A snapshot taken with a different
+// configuration is discarded, since the cache does not check the
+// configuration.
+func renderConfigFingerprint(config ReconcilerConfig) string {
+	renderConfig := struct {
+		TrustDomain                          string   `json:"trustDomain"`
+		ClusterName                          string   `json:"clusterName"`
+		ClusterDomain                        string   `json:"clusterDomain"`
+		AllowedPathPrefixes                  []string `json:"allowedPathPrefixes"`
+		SPIFFEIDAnnotationPathPrefixTemplate string   `json:"spiffeIDAnnotationPathPrefixTemplate"`
+	}{
+		TrustDomain:   config.TrustDomain.String(),
+		ClusterName:   config.ClusterName,
+		ClusterDomain: config.ClusterDomain,
+	}
+	if len(config.AllowedPathPrefixes) > 0 {
+		renderConfig.AllowedPathPrefixes = config.AllowedPathPrefixes
+	}
+	if tmpl := config.SPIFFEIDAnnotationPathPrefixTemplate; tmpl != nil && tmpl.Tree != nil {
+		renderConfig.SPIFFEIDAnnotationPathPrefixTemplate = tmpl.Root.String()
+	}
+
+	// Encoding a struct of strings cannot fail.
+	data, _ := json.Marshal(renderConfig)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Save writes the cached render results to a gzipped JSON snapshot at the
+// path, along with the fingerprint of the configuration they were rendered
+// with. The snapshot is written to a temporary file that is then renamed
+// over the path so that a crash mid-write does not leave a partial snapshot.
+func (c *renderCache) Save(path, configFingerprint string) (err error) {
+	snapshot := renderCacheSnapshot{
+		Version:           renderCacheSnapshotVersion,
+		ConfigFingerprint: configFingerprint,
+		Entries:           make([]renderCacheSnapshotEntry, 0, len(c.entries)),
+	}
+	for key, cached := range c.entries {
+		entry := renderCacheSnapshotEntry{
+			Key:                 key,
+			ObjectGeneration:    cached.objectGeneration,
+			PodResourceVersion:  cached.podResourceVersion,
+			NodeResourceVersion: cached.nodeResourceVersion,
+			Entry:               cached.entry,
+		}
+		if cached.err != nil {
+			entry.Err = cached.err.Error()
+		}
+		snapshot.Entries = append(snapshot.Entries, entry)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	gz := gzip.NewWriter(f)
+	if err := json.NewEncoder(gz).Encode(snapshot); err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// Load seeds the cache from the snapshot at the path. A missing snapshot, or
+// one written by a different version or with a different configuration, is
+// not an error; the cache is left empty. Loaded results are only reused if
+// their inputs still match, and are evicted by the first sweep otherwise.
+func (c *renderCache) Load(path, configFingerprint string) error {
+	f, err := os.Open(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot renderCacheSnapshot
+	if err := json.NewDecoder(gz).Decode(&snapshot); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if snapshot.Version != renderCacheSnapshotVersion || snapshot.ConfigFingerprint != configFingerprint {
+		return nil
+	}
+
+	c.entries = make(map[renderCacheKey]*renderCacheEntry, len(snapshot.Entries))
+	for _, entry := range snapshot.Entries {
+		cached := &renderCacheEntry{
+			objectGeneration:    entry.ObjectGeneration,
+			podResourceVersion:  entry.PodResourceVersion,
+			nodeResourceVersion: entry.NodeResourceVersion,
+			entry:               entry.Entry,
+		}
+		if entry.Err != "" {
+			cached.err = errors.New(entry.Err)
+		}
+		c.entries[entry.Key] = cached
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package spireentry
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderCacheSnapshot(t *testing.T) {
+	clusterSPIFFEID := &ClusterSPIFFEID{ClusterSPIFFEID: spirev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: "csid", UID: "csiduid", Generation: 1},
+	}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod", UID: "poduid", ResourceVersion: "1"}}
+	failedPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "failed", UID: "faileduid", ResourceVersion: "1"}}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "nodeuid", ResourceVersion: "1"}}
+	entry := &spireapi.Entry{
+		SPIFFEID:      spiffeid.RequireFromString("spiffe://domain.test/pod"),
+		ParentID:      spiffeid.RequireFromString("spiffe://domain.test/node"),
+		Selectors:     []spireapi.Selector{{Type: "k8s", Value: "pod-uid:poduid"}},
+		X509SVIDTTL:   time.Hour,
+		FederatesWith: []spiffeid.TrustDomain{spiffeid.RequireTrustDomainFromString("federated.test")},
+		DNSNames:      []string{"pod.ns.svc"},
+		Hint:          "hint",
+	}
+
+	var cache renderCache
+	cache.Put(clusterSPIFFEID, pod, node, entry, nil)
+	cache.Put(clusterSPIFFEID, failedPod, node, nil, errors.New("oh no"))
+	require.True(t, cache.dirty)
+
+	config := ReconcilerConfig{
+		TrustDomain:   spiffeid.RequireTrustDomainFromString("domain.test"),
+		ClusterName:   "cluster",
+		ClusterDomain: "cluster.local",
+	}
+	fingerprint := renderConfigFingerprint(config)
+
+	path := filepath.Join(t.TempDir(), "snapshot")
+	require.NoError(t, cache.Save(path, fingerprint))
+	require.False(t, cache.dirty, "saving should clear the dirty flag")
+
+	var loaded renderCache
+	require.NoError(t, loaded.Load(path, fingerprint))
+	require.False(t, loaded.dirty)
+
+	cached, ok := loaded.Get(clusterSPIFFEID, pod, node)
+	require.True(t, ok)
+	require.Equal(t, entry, cached.entry)
+	require.NoError(t, cached.err)
+
+	cached, ok = loaded.Get(clusterSPIFFEID, failedPod, node)
+	require.True(t, ok)
+	require.Nil(t, cached.entry)
+	require.EqualError(t, cached.err, "oh no")
+
+	t.Run("missing snapshot is ignored", func(t *testing.T) {
+		var cache renderCache
+		require.NoError(t, cache.Load(filepath.Join(t.TempDir(), "missing"), fingerprint))
+		require.Empty(t, cache.entries)
+	})
+
+	t.Run("corrupt snapshot fails", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "corrupt")
+		require.NoError(t, os.WriteFile(path, []byte("garbage"), 0600))
+		var cache renderCache
+		require.Error(t, cache.Load(path, fingerprint))
+		require.Empty(t, cache.entries)
+	})
+
+	t.Run("snapshot of a different configuration is ignored", func(t *testing.T) {
+		for name, change := range map[string]func(*ReconcilerConfig){
+			"trust domain":   func(c *ReconcilerConfig) { c.TrustDomain = spiffeid.RequireTrustDomainFromString("other.test") },
+			"cluster name":   func(c *ReconcilerConfig) { c.ClusterName = "other" },
+			"cluster domain": func(c *ReconcilerConfig) { c.ClusterDomain = "other.local" },
+			"allowed path prefixes": func(c *ReconcilerConfig) {
+				c.AllowedPathPrefixes = []string{"/ns/"}
+			},
+			"SPIFFE ID annotation path prefix template": func(c *ReconcilerConfig) {
+				c.SPIFFEIDAnnotationPathPrefixTemplate = template.Must(template.New("").Parse("/ns/{{ .Namespace }}"))
+			},
+		} {
+			changed := config
+			change(&changed)
+			require.NotEqual(t, fingerprint, renderConfigFingerprint(changed), name)
+
+			var cache renderCache
+			require.NoError(t, cache.Load(path, renderConfigFingerprint(changed)), name)
+			require.Empty(t, cache.entries, name)
+		}
+	})
+
+	t.Run("empty allowed path prefixes do not change the fingerprint", func(t *testing.T) {
+		changed := config
+		changed.AllowedPathPrefixes = []string{}
+		require.Equal(t, fingerprint, renderConfigFingerprint(changed))
+	})
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/entryhook"
+	"github.com/spiffe/spire-controller-manager/pkg/entrytransformer"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+// transformEntries passes the declared entries through the entry
+// transformer. Since the transformer may change the selectors, which are part
+// of the entry key, the declared entries are keyed anew in the returned
+// state.
+func (r *entryReconciler) transformEntries(ctx context.Context, state entriesState) (entriesState, error) {
+	transformed := make(entriesState, len(state))
+	var declared []declaredEntry
+	for key, s := range state {
+		transformed[key] = &entryState{
+			Current:  s.Current,
+			Retained: s.Retained,
+		}
+		declared = append(declared, s.Declared...)
+	}
+	if len(declared) == 0 {
+		return transformed, nil
+	}
+
+	requests := make([]entrytransformer.Request, 0, len(declared))
+	for _, declaredEntry := range declared {
+		request := entrytransformer.Request{
+			Entry: hookEntryFromEntry(declaredEntry.Entry),
+			Owner: ownerFromObject(declaredEntry.By),
+		}
+		if declaredEntry.Pod != nil {
+			request.Workload = workloadFromPod(declaredEntry.Pod)
+		}
+		requests = append(requests, request)
+	}
+
+	entries, err := r.config.EntryTransformer.Transform(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) != len(declared) {
+		return nil, fmt.Errorf("expected %d transformed entries but got %d", len(declared), len(entries))
+	}
+
+	for i, declaredEntry := range declared {
+		entry, err := applyTransformedEntry(declaredEntry.Entry, entries[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid transformed entry for %s: %w", declaredEntry.Entry.SPIFFEID, err)
+		}
+		transformed.AddDeclared(entry, declaredEntry.By, declaredEntry.Pod)
+	}
+	return transformed, nil
+}
+
+// applyTransformedEntry applies the DNS names, selectors, and TTLs of the
+// transformed entry to the entry. The SPIFFE ID and parent ID must not be
+// changed. Other fields are ignored.
+func applyTransformedEntry(entry spireapi.Entry, transformed entryhook.Entry) (spireapi.Entry, error) {
+	if transformed.SPIFFEID != entry.SPIFFEID.String() {
+		return entry, fmt.Errorf("SPIFFE ID changed to %q", transformed.SPIFFEID)
+	}
+	if transformed.ParentID != entry.ParentID.String() {
+		return entry, fmt.Errorf("parent ID changed to %q", transformed.ParentID)
+	}
+
+	if len(transformed.Selectors) == 0 {
+		return entry, fmt.Errorf("no selectors")
+	}
+	selectors := make([]spireapi.Selector, 0, len(transformed.Selectors))
+	for _, selector := range transformed.Selectors {
+		selectorType, selectorValue, ok := strings.Cut(selector, ":")
+		if !ok || selectorType == "" || selectorValue == "" {
+			return entry, fmt.Errorf("invalid selector %q: expected type:value", selector)
+		}
+		selectors = append(selectors, spireapi.Selector{Type: selectorType, Value: selectorValue})
+	}
+
+	x509SVIDTTL, err := parseTransformedTTL(transformed.X509SVIDTTL)
+	if err != nil {
+		return entry, fmt.Errorf("invalid X509-SVID TTL: %w", err)
+	}
+	jwtSVIDTTL, err := parseTransformedTTL(transformed.JWTSVIDTTL)
+	if err != nil {
+		return entry, fmt.Errorf("invalid JWT-SVID TTL: %w", err)
+	}
+
+	entry.Selectors = selectors
+	entry.DNSNames = transformed.DNSNames
+	entry.X509SVIDTTL = x509SVIDTTL
+	entry.JWTSVIDTTL = jwtSVIDTTL
+	return entry, nil
+}
+
+func parseTransformedTTL(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("%q is negative", s)
+	}
+	return ttl, nil
+}
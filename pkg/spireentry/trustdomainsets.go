@@ -0,0 +1,114 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spireentry
+
+import (
+	"context"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/k8sapi"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// trustDomainSets maps ClusterTrustDomainSet names to their trust domains.
+type trustDomainSets map[string][]spiffeid.TrustDomain
+
+// listClusterTrustDomainSets lists the ClusterTrustDomainSets if any of the
+// ClusterSPIFFEIDs or NamespacedSPIFFEIDs reference one. The
+// ClusterTrustDomainSets are not listed otherwise so that the
+// ClusterTrustDomainSet CRD is only required when it is used.
+func (r *entryReconciler) listClusterTrustDomainSets(ctx context.Context, clusterSPIFFEIDs []*ClusterSPIFFEID, namespacedSPIFFEIDs []*NamespacedSPIFFEID) (trustDomainSets, error) {
+	referenced := false
+	for _, clusterSPIFFEID := range clusterSPIFFEIDs {
+		if len(clusterSPIFFEID.Spec.FederatesWithSets) > 0 {
+			referenced = true
+			break
+		}
+	}
+	for _, namespacedSPIFFEID := range namespacedSPIFFEIDs {
+		if len(namespacedSPIFFEID.Spec.FederatesWithSets) > 0 {
+			referenced = true
+			break
+		}
+	}
+	if !referenced {
+		return nil, nil
+	}
+
+	clusterTrustDomainSets, err := k8sapi.ListClusterTrustDomainSets(ctx, r.config.K8sClient)
+	if err := r.ignoreNotServed(err, "ClusterTrustDomainSet"); err != nil {
+		return nil, err
+	}
+
+	log := log.FromContext(ctx)
+	sets := make(trustDomainSets, len(clusterTrustDomainSets))
+	for i := range clusterTrustDomainSets {
+		trustDomains, err := spirev1alpha1.ParseClusterTrustDomainSetSpec(&clusterTrustDomainSets[i].Spec)
+		if err != nil {
+			log.Error(err, "Failed to parse ClusterTrustDomainSet spec", clusterTrustDomainSetLogKey, objectName(&clusterTrustDomainSets[i]))
+			continue
+		}
+		sets[clusterTrustDomainSets[i].Name] = trustDomains
+	}
+	return sets, nil
+}
+
+// resolve returns the trust domains in the named sets, along with the names
+// of the sets that do not exist or are invalid. Those sets are skipped, so
+// that removing a set stops federation with its trust domains instead of
+// removing the entries of every ClusterSPIFFEID that references it.
+func (sets trustDomainSets) resolve(names []string) ([]spiffeid.TrustDomain, []string) {
+	var trustDomains []spiffeid.TrustDomain
+	var missing []string
+	for _, name := range names {
+		setTrustDomains, ok := sets[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		trustDomains = append(trustDomains, setTrustDomains...)
+	}
+	return trustDomains, missing
+}
+
+// withFederatesWith returns the entry with the trust domains added to the
+// trust domains it federates with. The entry is copied, if needed, since it
+// may be shared with the render cache.
+func withFederatesWith(entry *spireapi.Entry, trustDomains []spiffeid.TrustDomain) *spireapi.Entry {
+	if entry == nil || len(trustDomains) == 0 {
+		return entry
+	}
+
+	federatesWith := append([]spiffeid.TrustDomain(nil), entry.FederatesWith...)
+	seen := make(map[spiffeid.TrustDomain]struct{}, len(federatesWith)+len(trustDomains))
+	for _, td := range federatesWith {
+		seen[td] = struct{}{}
+	}
+	for _, td := range trustDomains {
+		if _, ok := seen[td]; ok {
+			continue
+		}
+		seen[td] = struct{}{}
+		federatesWith = append(federatesWith, td)
+	}
+
+	copied := *entry
+	copied.FederatesWith = federatesWith
+	return &copied
+}
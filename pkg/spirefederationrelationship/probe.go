@@ -0,0 +1,232 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spirefederationrelationship
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/federation"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/k8sapi"
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DefaultProbeTimeout is how long a probe of a bundle endpoint may take by
+// default.
+const DefaultProbeTimeout = 10 * time.Second
+
+// Event reasons recorded by the prober.
+const (
+	eventReasonBundleEndpointUnreachable = "BundleEndpointUnreachable"
+	eventReasonBundleEndpointReachable   = "BundleEndpointReachable"
+)
+
+// FetchBundleFunc fetches the bundle of the trust domain from a bundle
+// endpoint.
+type FetchBundleFunc func(ctx context.Context, trustDomain spiffeid.TrustDomain, url string, options ...federation.FetchOption) (*spiffebundle.Bundle, error)
+
+type ProberConfig struct {
+	K8sClient client.Client
+
+	// BundleClient gets the bundles used to authenticate https_spiffe
+	// bundle endpoints: the bundle of TrustDomain, or the federated bundle
+	// SPIRE Server holds for other trust domains.
+	BundleClient spireapi.BundleClient
+	TrustDomain  spiffeid.TrustDomain
+
+	// ClassName, WatchClassless, and SPIREServer select the
+	// ClusterFederatedTrustDomains probed, as for ReconcilerConfig.
+	ClassName      string
+	WatchClassless bool
+	SPIREServer    string
+
+	// Interval is how often the bundle endpoints are probed.
+	Interval time.Duration
+
+	// Timeout bounds each probe. Defaults to DefaultProbeTimeout.
+	Timeout time.Duration
+
+	// EventRecorder, if set, records Events on the
+	// ClusterFederatedTrustDomains whose bundle endpoints become unreachable
+	// or reachable again.
+	EventRecorder record.EventRecorder
+
+	// FetchBundle fetches the bundles. Defaults to federation.FetchBundle.
+	FetchBundle FetchBundleFunc
+}
+
+// Prober returns a reconciler that probes the bundle endpoints of the
+// ClusterFederatedTrustDomains and reports whether they are reachable in
+// their statuses. A typo in a bundle endpoint URL otherwise produces a
+// federation relationship that silently never works.
+func Prober(config ProberConfig) reconciler.Reconciler {
+	kind := "bundle endpoint probe"
+	if config.SPIREServer != "" {
+		kind += "@" + config.SPIREServer
+	}
+	return reconciler.New(reconciler.Config{
+		Kind: kind,
+		Reconcile: func(ctx context.Context) error {
+			return Probe(ctx, config)
+		},
+		GCInterval: config.Interval,
+	})
+}
+
+// Probe probes the bundle endpoints of the ClusterFederatedTrustDomains once
+// and updates their statuses. It returns an error if the
+// ClusterFederatedTrustDomains could not be listed.
+func Probe(ctx context.Context, config ProberConfig) error {
+	if config.Timeout == 0 {
+		config.Timeout = DefaultProbeTimeout
+	}
+	if config.FetchBundle == nil {
+		config.FetchBundle = federation.FetchBundle
+	}
+	log := log.FromContext(ctx)
+
+	clusterFederatedTrustDomains, err := k8sapi.ListClusterFederatedTrustDomains(ctx, config.K8sClient)
+	switch {
+	case meta.IsNoMatchError(err):
+		// The CRD is not installed. There is nothing to probe.
+		return nil
+	case err != nil:
+		log.Error(err, "Failed to list ClusterFederatedTrustDomains")
+		return err
+	}
+	for i := range clusterFederatedTrustDomains {
+		clusterFederatedTrustDomain := &clusterFederatedTrustDomains[i]
+		if !spirev1alpha1.MatchesClass(clusterFederatedTrustDomain.Spec.ClassName, config.ClassName, config.WatchClassless) || clusterFederatedTrustDomain.Spec.SPIREServer != config.SPIREServer {
+			continue
+		}
+		probeBundleEndpoint(ctx, config, clusterFederatedTrustDomain)
+	}
+	return nil
+}
+
+// probeBundleEndpoint probes the bundle endpoint of the
+// ClusterFederatedTrustDomain and updates its status if it changed.
+func probeBundleEndpoint(ctx context.Context, config ProberConfig, clusterFederatedTrustDomain *spirev1alpha1.ClusterFederatedTrustDomain) {
+	log := log.FromContext(ctx).WithValues(clusterFederatedTrustDomainLogKey, objectName(clusterFederatedTrustDomain))
+
+	nextStatus := *clusterFederatedTrustDomain.Status.DeepCopy()
+	reachable := metav1.Condition{
+		Type:               spirev1alpha1.ClusterFederatedTrustDomainConditionReachable,
+		ObservedGeneration: clusterFederatedTrustDomain.Generation,
+	}
+
+	bundle, reason, err := fetchBundle(ctx, config, &clusterFederatedTrustDomain.Spec)
+	switch {
+	case err == nil:
+		reachable.Status = metav1.ConditionTrue
+		reachable.Reason = spirev1alpha1.ClusterFederatedTrustDomainReasonBundleFetched
+		reachable.Message = "Fetched the bundle from the bundle endpoint"
+		nextStatus.LastError = ""
+		if sequenceNumber, ok := bundle.SequenceNumber(); ok {
+			serial := int64(sequenceNumber)
+			nextStatus.LastSyncedBundleSerial = &serial
+		}
+	case reason == spirev1alpha1.ClusterFederatedTrustDomainReasonInvalidSpec:
+		// The federation relationship reconciler reports invalid specs.
+		reachable.Status = metav1.ConditionUnknown
+		reachable.Reason = reason
+		reachable.Message = err.Error()
+		nextStatus.LastError = err.Error()
+	default:
+		reachable.Status = metav1.ConditionFalse
+		reachable.Reason = reason
+		reachable.Message = err.Error()
+		nextStatus.LastError = err.Error()
+	}
+
+	wasReachable := meta.FindStatusCondition(clusterFederatedTrustDomain.Status.Conditions, spirev1alpha1.ClusterFederatedTrustDomainConditionReachable)
+	switch {
+	case reachable.Status == metav1.ConditionFalse && (wasReachable == nil || wasReachable.Status != metav1.ConditionFalse):
+		log.Info("Bundle endpoint is unreachable", bundleEndpointURLKey, clusterFederatedTrustDomain.Spec.BundleEndpointURL, "reason", err.Error())
+		recordProbeEventf(config, clusterFederatedTrustDomain, corev1.EventTypeWarning, eventReasonBundleEndpointUnreachable, "Failed to fetch the bundle from %s: %v", clusterFederatedTrustDomain.Spec.BundleEndpointURL, err)
+	case reachable.Status == metav1.ConditionTrue && wasReachable != nil && wasReachable.Status == metav1.ConditionFalse:
+		log.Info("Bundle endpoint is reachable again", bundleEndpointURLKey, clusterFederatedTrustDomain.Spec.BundleEndpointURL)
+		recordProbeEventf(config, clusterFederatedTrustDomain, corev1.EventTypeNormal, eventReasonBundleEndpointReachable, "Fetched the bundle from %s", clusterFederatedTrustDomain.Spec.BundleEndpointURL)
+	}
+
+	meta.SetStatusCondition(&nextStatus.Conditions, reachable)
+	if equality.Semantic.DeepEqual(clusterFederatedTrustDomain.Status, nextStatus) {
+		return
+	}
+	clusterFederatedTrustDomain.Status = nextStatus
+	if err := config.K8sClient.Status().Update(ctx, clusterFederatedTrustDomain); err != nil {
+		log.Error(err, "Failed to update status")
+	}
+}
+
+// fetchBundle fetches the bundle from the bundle endpoint declared by the
+// spec. On failure, it also returns the reason of the Reachable condition.
+func fetchBundle(ctx context.Context, config ProberConfig, spec *spirev1alpha1.ClusterFederatedTrustDomainSpec) (*spiffebundle.Bundle, string, error) {
+	federationRelationship, err := spirev1alpha1.ParseClusterFederatedTrustDomainSpec(spec)
+	if err != nil {
+		return nil, spirev1alpha1.ClusterFederatedTrustDomainReasonInvalidSpec, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	var options []federation.FetchOption
+	if profile, ok := federationRelationship.BundleEndpointProfile.(spireapi.HTTPSSPIFFEProfile); ok {
+		authBundle, err := getAuthBundle(ctx, config, profile.EndpointSPIFFEID.TrustDomain())
+		if err != nil {
+			return nil, spirev1alpha1.ClusterFederatedTrustDomainReasonAuthBundleUnavailable, err
+		}
+		options = append(options, federation.WithSPIFFEAuth(authBundle, profile.EndpointSPIFFEID))
+	}
+
+	bundle, err := config.FetchBundle(ctx, federationRelationship.TrustDomain, federationRelationship.BundleEndpointURL, options...)
+	if err != nil {
+		return nil, spirev1alpha1.ClusterFederatedTrustDomainReasonFetchFailed, err
+	}
+	return bundle, "", nil
+}
+
+// getAuthBundle gets the bundle used to authenticate an https_spiffe bundle
+// endpoint in the trust domain.
+func getAuthBundle(ctx context.Context, config ProberConfig, trustDomain spiffeid.TrustDomain) (*spiffebundle.Bundle, error) {
+	if trustDomain == config.TrustDomain {
+		return config.BundleClient.GetBundle(ctx)
+	}
+	bundle, err := config.BundleClient.GetFederatedBundle(ctx, trustDomain)
+	if err != nil {
+		return nil, fmt.Errorf("no bundle to authenticate the bundle endpoint: %w", err)
+	}
+	return bundle, nil
+}
+
+func recordProbeEventf(config ProberConfig, obj *spirev1alpha1.ClusterFederatedTrustDomain, eventType, reason, messageFmt string, args ...interface{}) {
+	if config.EventRecorder != nil {
+		config.EventRecorder.Eventf(obj, eventType, reason, messageFmt, args...)
+	}
+}
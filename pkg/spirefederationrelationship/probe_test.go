@@ -0,0 +1,146 @@
+package spirefederationrelationship_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/federation"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/spirefederationrelationship"
+	"github.com/spiffe/spire-controller-manager/pkg/test/k8stest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestProbe(t *testing.T) {
+	cftd := func(name, trustDomain string, profile spirev1alpha1.BundleEndpointProfile) *spirev1alpha1.ClusterFederatedTrustDomain {
+		return &spirev1alpha1.ClusterFederatedTrustDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 1},
+			Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+				TrustDomain:           trustDomain,
+				BundleEndpointURL:     "https://" + name + ".test/bundle",
+				BundleEndpointProfile: profile,
+			},
+		}
+	}
+	httpsWeb := spirev1alpha1.BundleEndpointProfile{Type: "https_web"}
+	httpsSPIFFE := func(endpointID string) spirev1alpha1.BundleEndpointProfile {
+		return spirev1alpha1.BundleEndpointProfile{Type: "https_spiffe", EndpointSPIFFEID: endpointID}
+	}
+
+	objs := []*spirev1alpha1.ClusterFederatedTrustDomain{
+		cftd("reachable", "td2", httpsWeb),
+		cftd("unreachable", "td3", httpsWeb),
+		cftd("spiffe", "td4", httpsSPIFFE("spiffe://td4/spire/server")),
+		cftd("noauthbundle", "td5", httpsSPIFFE("spiffe://td5/spire/server")),
+		cftd("invalid", "", httpsWeb),
+	}
+	builder := k8stest.NewClientBuilder(t)
+	for _, obj := range objs {
+		builder = builder.WithObjects(obj).WithStatusSubresource(obj)
+	}
+	k8sClient := builder.Build()
+
+	fetchErr := errors.New("could not GET bundle: no such host")
+	var fetched []string
+	fetchBundle := func(ctx context.Context, trustDomain spiffeid.TrustDomain, url string, options ...federation.FetchOption) (*spiffebundle.Bundle, error) {
+		fetched = append(fetched, url)
+		if url == "https://unreachable.test/bundle" {
+			return nil, fetchErr
+		}
+		bundle := spiffebundle.New(trustDomain)
+		bundle.SetSequenceNumber(42)
+		return bundle, nil
+	}
+	recorder := record.NewFakeRecorder(10)
+	config := spirefederationrelationship.ProberConfig{
+		K8sClient:     k8sClient,
+		BundleClient:  newBundleClient(spiffeid.RequireTrustDomainFromString("td4")),
+		TrustDomain:   td,
+		EventRecorder: recorder,
+		FetchBundle:   fetchBundle,
+	}
+
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	require.NoError(t, spirefederationrelationship.Probe(ctx, config))
+
+	assert.ElementsMatch(t, []string{
+		"https://reachable.test/bundle",
+		"https://unreachable.test/bundle",
+		"https://spiffe.test/bundle",
+	}, fetched)
+
+	serial := int64(42)
+	for _, tt := range []struct {
+		name         string
+		expectStatus metav1.ConditionStatus
+		expectReason string
+		expectSerial *int64
+		expectError  string
+	}{
+		{
+			name:         "reachable",
+			expectStatus: metav1.ConditionTrue,
+			expectReason: spirev1alpha1.ClusterFederatedTrustDomainReasonBundleFetched,
+			expectSerial: &serial,
+		},
+		{
+			name:         "unreachable",
+			expectStatus: metav1.ConditionFalse,
+			expectReason: spirev1alpha1.ClusterFederatedTrustDomainReasonFetchFailed,
+			expectError:  fetchErr.Error(),
+		},
+		{
+			name:         "spiffe",
+			expectStatus: metav1.ConditionTrue,
+			expectReason: spirev1alpha1.ClusterFederatedTrustDomainReasonBundleFetched,
+			expectSerial: &serial,
+		},
+		{
+			name:         "noauthbundle",
+			expectStatus: metav1.ConditionFalse,
+			expectReason: spirev1alpha1.ClusterFederatedTrustDomainReasonAuthBundleUnavailable,
+			expectError:  `no bundle to authenticate the bundle endpoint: rpc error: code = NotFound desc = no federated bundle for "td5"`,
+		},
+		{
+			name:         "invalid",
+			expectStatus: metav1.ConditionUnknown,
+			expectReason: spirev1alpha1.ClusterFederatedTrustDomainReasonInvalidSpec,
+			expectError:  "invalid trustDomain value: trust domain is missing",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var actual spirev1alpha1.ClusterFederatedTrustDomain
+			require.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Name: tt.name}, &actual))
+			reachable := meta.FindStatusCondition(actual.Status.Conditions, spirev1alpha1.ClusterFederatedTrustDomainConditionReachable)
+			require.NotNil(t, reachable)
+			assert.Equal(t, tt.expectStatus, reachable.Status)
+			assert.Equal(t, tt.expectReason, reachable.Reason)
+			assert.Equal(t, int64(1), reachable.ObservedGeneration)
+			assert.Equal(t, tt.expectSerial, actual.Status.LastSyncedBundleSerial)
+			assert.Equal(t, tt.expectError, actual.Status.LastError)
+		})
+	}
+
+	// Events are only recorded when a bundle endpoint becomes unreachable,
+	// so probing again does not record them again.
+	require.NoError(t, spirefederationrelationship.Probe(ctx, config))
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	assert.ElementsMatch(t, []string{
+		`Warning BundleEndpointUnreachable Failed to fetch the bundle from https://unreachable.test/bundle: could not GET bundle: no such host`,
+		`Warning BundleEndpointUnreachable Failed to fetch the bundle from https://noauthbundle.test/bundle: no bundle to authenticate the bundle endpoint: rpc error: code = NotFound desc = no federated bundle for "td5"`,
+	}, events)
+}
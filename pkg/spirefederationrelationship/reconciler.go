@@ -24,9 +24,13 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
 	"github.com/spiffe/spire-controller-manager/pkg/k8sapi"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
 	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -35,48 +39,133 @@ type ReconcilerConfig struct {
 	TrustDomainClient spireapi.TrustDomainClient
 	K8sClient         client.Client
 
+	// ClassName is the class of the ClusterFederatedTrustDomains reconciled.
+	// ClusterFederatedTrustDomains of other classes are ignored.
+	ClassName string
+
+	// WatchClassless, if true, also reconciles the
+	// ClusterFederatedTrustDomains without a class when ClassName is set.
+	WatchClassless bool
+
+	// SPIREServer is the name of the SPIRE Server target the federation
+	// relationships are set on. Only the ClusterFederatedTrustDomains that
+	// select the target are reconciled. If set, the reconcile metrics are
+	// reported under the "federation relationship@<target name>" kind.
+	SPIREServer string
+
+	// BundleClient is used to delete federated bundles. Required when
+	// DeleteFederatedBundles is set.
+	BundleClient spireapi.BundleClient
+
+	// DeleteFederatedBundles, if set, deletes the federated bundle of a
+	// trust domain before deleting its federation relationship, so that the
+	// keys of a terminated federation are no longer trusted.
+	DeleteFederatedBundles bool
+
+	// KeepFederatedBundles are the trust domains whose federated bundles are
+	// kept even when DeleteFederatedBundles is set.
+	KeepFederatedBundles []spiffeid.TrustDomain
+
+	// DryRun, if true, logs the federation relationships that would be
+	// created, updated, or deleted instead of changing them.
+	DryRun bool
+
 	// GCInterval how long to sit idle (i.e. untriggered) before doing
 	// another reconcile.
 	GCInterval time.Duration
+
+	// EventRecorder, if set, records Events on the ClusterFederatedTrustDomains
+	// that are invalid, conflict, or whose federation relationship failed to
+	// be set.
+	EventRecorder record.EventRecorder
+
+	// KindNotServed, if set, reports whether the CRD of a kind is confirmed
+	// not to be installed. Only then is failing to list the
+	// ClusterFederatedTrustDomains treated as there being none; otherwise
+	// the reconcile fails so that no federation relationships are deleted.
+	KindNotServed func(schema.GroupVersionKind) bool
 }
 
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Event reasons.
+const (
+	eventReasonInvalidSpec                  = "InvalidSpec"
+	eventReasonTrustDomainConflict          = "TrustDomainConflict"
+	eventReasonFederationRelationshipFailed = "FederationRelationshipFailed"
+)
+
 func Reconciler(config ReconcilerConfig) reconciler.Reconciler {
+	kind := "federation relationship"
+	if config.SPIREServer != "" {
+		kind += "@" + config.SPIREServer
+	}
 	return reconciler.New(reconciler.Config{
-		Kind: "federation relationship",
-		Reconcile: func(ctx context.Context) {
-			Reconcile(ctx, config.TrustDomainClient, config.K8sClient)
+		Kind: kind,
+		Reconcile: func(ctx context.Context) error {
+			return Reconcile(ctx, config)
 		},
 		GCInterval: config.GCInterval,
 	})
 }
 
-func Reconcile(ctx context.Context, trustDomainClient spireapi.TrustDomainClient, k8sClient client.Client) {
+// Reconcile reconciles the SPIRE federation relationships with the
+// ClusterFederatedTrustDomains. It returns an error if the reconciliation
+// could not be completed, e.g. because listing failed.
+func Reconcile(ctx context.Context, config ReconcilerConfig) error {
 	r := &federationRelationshipReconciler{
-		trustDomainClient: trustDomainClient,
-		k8sClient:         k8sClient,
+		trustDomainClient: config.TrustDomainClient,
+		k8sClient:         config.K8sClient,
+		eventRecorder:     config.EventRecorder,
+		dryRun:            config.DryRun,
+		className:         config.ClassName,
+		watchClassless:    config.WatchClassless,
+		spireServer:       config.SPIREServer,
+		kindNotServed:     config.KindNotServed,
+	}
+	if config.DeleteFederatedBundles {
+		r.bundleClient = config.BundleClient
+		r.keepFederatedBundles = make(map[spiffeid.TrustDomain]struct{}, len(config.KeepFederatedBundles))
+		for _, td := range config.KeepFederatedBundles {
+			r.keepFederatedBundles[td] = struct{}{}
+		}
 	}
-	r.reconcile(ctx)
+	return r.reconcile(ctx)
 }
 
 type federationRelationshipReconciler struct {
-	trustDomainClient spireapi.TrustDomainClient
-	k8sClient         client.Client
+	trustDomainClient    spireapi.TrustDomainClient
+	k8sClient            client.Client
+	bundleClient         spireapi.BundleClient
+	keepFederatedBundles map[spiffeid.TrustDomain]struct{}
+	eventRecorder        record.EventRecorder
+	dryRun               bool
+	className            string
+	watchClassless       bool
+	spireServer          string
+	kindNotServed        func(schema.GroupVersionKind) bool
+
+	// clusterFederatedTrustDomains are the ClusterFederatedTrustDomains
+	// declaring the federation relationships, by trust domain.
+	clusterFederatedTrustDomains map[spiffeid.TrustDomain]*clusterFederatedTrustDomainState
 }
 
-func (r *federationRelationshipReconciler) reconcile(ctx context.Context) {
+func (r *federationRelationshipReconciler) reconcile(ctx context.Context) error {
 	log := log.FromContext(ctx)
 
 	currentRelationships, err := r.listFederationRelationships(ctx)
 	if err != nil {
+		metrics.SPIREAPIErrors.WithLabelValues("ListFederationRelationships").Inc()
 		log.Error(err, "Failed to list SPIRE federation relationships")
-		return
+		return err
 	}
 
 	clusterFederatedTrustDomains, err := r.listClusterFederatedTrustDomains(ctx)
 	if err != nil {
 		log.Error(err, "Failed to list ClusterFederatedTrustDomains")
-		return
+		return err
 	}
+	r.clusterFederatedTrustDomains = clusterFederatedTrustDomains
 
 	var toDelete []spireapi.FederationRelationship
 	var toCreate []spireapi.FederationRelationship
@@ -97,17 +186,37 @@ func (r *federationRelationshipReconciler) reconcile(ctx context.Context) {
 		}
 	}
 
+	if r.dryRun {
+		r.logDryRun(ctx, toCreate, toUpdate, toDelete)
+		metrics.FederationRelationships.WithLabelValues(metrics.StateInSync).Set(float64(len(clusterFederatedTrustDomains) - len(toCreate) - len(toUpdate)))
+		metrics.FederationRelationships.WithLabelValues(metrics.StateOutOfSync).Set(float64(len(toCreate) + len(toUpdate) + len(toDelete)))
+		return nil
+	}
+
+	// Track the relationships that failed to be changed. They remain out of
+	// sync until a later reconcile succeeds.
+	var failedToDelete, failedToSet int
+	if len(toDelete) > 0 && r.bundleClient != nil {
+		// Only delete the relationships whose bundles are gone so that
+		// bundles that failed to delete are retried on the next reconcile.
+		bundlesDeleted := r.deleteFederatedBundles(ctx, toDelete)
+		failedToDelete += len(toDelete) - len(bundlesDeleted)
+		toDelete = bundlesDeleted
+	}
 	if len(toDelete) > 0 {
-		r.deleteFederationRelationships(ctx, toDelete)
+		failedToDelete += r.deleteFederationRelationships(ctx, toDelete)
 	}
 	if len(toCreate) > 0 {
-		r.createFederationRelationships(ctx, toCreate)
+		failedToSet += r.createFederationRelationships(ctx, toCreate)
 	}
 	if len(toUpdate) > 0 {
-		r.updateFederationRelationships(ctx, toUpdate)
+		failedToSet += r.updateFederationRelationships(ctx, toUpdate)
 	}
+	metrics.FederationRelationships.WithLabelValues(metrics.StateInSync).Set(float64(len(clusterFederatedTrustDomains) - failedToSet))
+	metrics.FederationRelationships.WithLabelValues(metrics.StateOutOfSync).Set(float64(failedToDelete + failedToSet))
 
 	// TODO: Status updates
+	return nil
 }
 
 func (r *federationRelationshipReconciler) listFederationRelationships(ctx context.Context) (map[spiffeid.TrustDomain]spireapi.FederationRelationship, error) {
@@ -126,7 +235,7 @@ func (r *federationRelationshipReconciler) listClusterFederatedTrustDomains(ctx
 	log := log.FromContext(ctx)
 
 	clusterFederatedTrustDomains, err := k8sapi.ListClusterFederatedTrustDomains(ctx, r.k8sClient)
-	if err != nil {
+	if err := k8sapi.IgnoreNotServed(err, spirev1alpha1.GroupVersion.WithKind("ClusterFederatedTrustDomain"), r.kindNotServed); err != nil {
 		return nil, err
 	}
 
@@ -138,11 +247,16 @@ func (r *federationRelationshipReconciler) listClusterFederatedTrustDomains(ctx
 
 	out := make(map[spiffeid.TrustDomain]*clusterFederatedTrustDomainState, len(clusterFederatedTrustDomains))
 	for i := range clusterFederatedTrustDomains {
+		if !spirev1alpha1.MatchesClass(clusterFederatedTrustDomains[i].Spec.ClassName, r.className, r.watchClassless) || clusterFederatedTrustDomains[i].Spec.SPIREServer != r.spireServer {
+			continue
+		}
+
 		log := log.WithValues(clusterFederatedTrustDomainLogKey, objectName(&clusterFederatedTrustDomains[i]))
 
 		federationRelationship, err := spirev1alpha1.ParseClusterFederatedTrustDomainSpec(&clusterFederatedTrustDomains[i].Spec)
 		if err != nil {
 			log.Error(err, "Ignoring invalid ClusterFederatedTrustDomain")
+			r.recordEventf(&clusterFederatedTrustDomains[i], eventReasonInvalidSpec, "Ignoring invalid spec: %v", err)
 			continue
 		}
 
@@ -154,6 +268,7 @@ func (r *federationRelationshipReconciler) listClusterFederatedTrustDomains(ctx
 		if existing, ok := out[federationRelationship.TrustDomain]; ok {
 			log.Info("Ignoring ClusterFederatedTrustDomain with conflicting trust domain",
 				conflictWithKey, objectName(&existing.ClusterFederatedTrustDomain))
+			r.recordEventf(&clusterFederatedTrustDomains[i], eventReasonTrustDomainConflict, "Ignoring trust domain %q already declared by %s", federationRelationship.TrustDomain, existing.ClusterFederatedTrustDomain.Name)
 			continue
 		}
 
@@ -162,61 +277,169 @@ func (r *federationRelationshipReconciler) listClusterFederatedTrustDomains(ctx
 	return out, nil
 }
 
-func (r *federationRelationshipReconciler) createFederationRelationships(ctx context.Context, federationRelationships []spireapi.FederationRelationship) {
+// createFederationRelationships creates the federation relationships and returns
+// how many failed.
+func (r *federationRelationshipReconciler) createFederationRelationships(ctx context.Context, federationRelationships []spireapi.FederationRelationship) int {
 	log := log.FromContext(ctx)
 
 	statuses, err := r.trustDomainClient.CreateFederationRelationships(ctx, federationRelationships)
 	if err != nil {
+		metrics.SPIREAPIErrors.WithLabelValues("CreateFederationRelationships").Inc()
 		log.Error(err, "Failed to create federation relationships")
-		return
+		for _, federationRelationship := range federationRelationships {
+			r.recordFailure(federationRelationship.TrustDomain, "Failed to create federation relationship: %v", err)
+		}
+		return len(federationRelationships)
 	}
 
+	var failed int
 	for i, status := range statuses {
 		switch status.Code {
 		case codes.OK:
 			log.Info("Created federation relationship", federationRelationshipFields(federationRelationships[i])...)
 		default:
+			metrics.SPIREAPIErrors.WithLabelValues("CreateFederationRelationships").Inc()
+			failed++
 			log.Error(status.Err(), "Failed to create federation relationship", federationRelationshipFields(federationRelationships[i])...)
+			r.recordFailure(federationRelationships[i].TrustDomain, "Failed to create federation relationship: %v", status.Err())
 		}
 	}
+	return failed
 }
 
-func (r *federationRelationshipReconciler) updateFederationRelationships(ctx context.Context, federationRelationships []spireapi.FederationRelationship) {
+// updateFederationRelationships updates the federation relationships and returns
+// how many failed.
+func (r *federationRelationshipReconciler) updateFederationRelationships(ctx context.Context, federationRelationships []spireapi.FederationRelationship) int {
 	log := log.FromContext(ctx)
 
 	statuses, err := r.trustDomainClient.UpdateFederationRelationships(ctx, federationRelationships)
 	if err != nil {
+		metrics.SPIREAPIErrors.WithLabelValues("UpdateFederationRelationships").Inc()
 		log.Error(err, "Failed to update federation relationships")
-		return
+		for _, federationRelationship := range federationRelationships {
+			r.recordFailure(federationRelationship.TrustDomain, "Failed to update federation relationship: %v", err)
+		}
+		return len(federationRelationships)
 	}
 
+	var failed int
 	for i, status := range statuses {
 		switch status.Code {
 		case codes.OK:
 			log.Info("Updated federation relationship", federationRelationshipFields(federationRelationships[i])...)
 		default:
+			metrics.SPIREAPIErrors.WithLabelValues("UpdateFederationRelationships").Inc()
+			failed++
 			log.Error(status.Err(), "Failed to update federation relationship", federationRelationshipFields(federationRelationships[i])...)
+			r.recordFailure(federationRelationships[i].TrustDomain, "Failed to update federation relationship: %v", status.Err())
 		}
 	}
+	return failed
 }
 
-func (r *federationRelationshipReconciler) deleteFederationRelationships(ctx context.Context, federationRelationships []spireapi.FederationRelationship) {
+// deleteFederationRelationships deletes the federation relationships and returns
+// how many failed.
+func (r *federationRelationshipReconciler) deleteFederationRelationships(ctx context.Context, federationRelationships []spireapi.FederationRelationship) int {
 	log := log.FromContext(ctx)
 
 	statuses, err := r.trustDomainClient.DeleteFederationRelationships(ctx, trustDomainIDsFromFederationRelationships(federationRelationships))
 	if err != nil {
+		metrics.SPIREAPIErrors.WithLabelValues("DeleteFederationRelationships").Inc()
 		log.Error(err, "Failed to delete federation relationships")
-		return
+		return len(federationRelationships)
 	}
 
+	var failed int
 	for i, status := range statuses {
 		switch status.Code {
 		case codes.OK:
 			log.Info("Deleted federation relationship", federationRelationshipFields(federationRelationships[i])...)
 		default:
+			metrics.SPIREAPIErrors.WithLabelValues("DeleteFederationRelationships").Inc()
+			failed++
 			log.Error(status.Err(), "Failed to delete federation relationship", federationRelationshipFields(federationRelationships[i])...)
 		}
 	}
+	return failed
+}
+
+// deleteFederatedBundles deletes the federated bundles for the federation
+// relationships, except for those that are configured to be kept. It returns
+// the federation relationships that no longer have a federated bundle to
+// delete.
+func (r *federationRelationshipReconciler) deleteFederatedBundles(ctx context.Context, federationRelationships []spireapi.FederationRelationship) []spireapi.FederationRelationship {
+	log := log.FromContext(ctx)
+
+	var done []spireapi.FederationRelationship
+	var toDelete []spireapi.FederationRelationship
+	for _, federationRelationship := range federationRelationships {
+		if _, keep := r.keepFederatedBundles[federationRelationship.TrustDomain]; keep {
+			log.V(1).Info("Keeping federated bundle", federationRelationshipFields(federationRelationship)...)
+			done = append(done, federationRelationship)
+			continue
+		}
+		toDelete = append(toDelete, federationRelationship)
+	}
+	if len(toDelete) == 0 {
+		return done
+	}
+
+	statuses, err := r.bundleClient.DeleteFederatedBundles(ctx, trustDomainIDsFromFederationRelationships(toDelete))
+	if err != nil {
+		metrics.SPIREAPIErrors.WithLabelValues("DeleteFederatedBundles").Inc()
+		log.Error(err, "Failed to delete federated bundles")
+		return done
+	}
+
+	for i, status := range statuses {
+		switch status.Code {
+		case codes.OK:
+			log.Info("Deleted federated bundle", federationRelationshipFields(toDelete[i])...)
+			done = append(done, toDelete[i])
+		case codes.NotFound:
+			done = append(done, toDelete[i])
+		default:
+			metrics.SPIREAPIErrors.WithLabelValues("DeleteFederatedBundles").Inc()
+			log.Error(status.Err(), "Failed to delete federated bundle", federationRelationshipFields(toDelete[i])...)
+		}
+	}
+	return done
+}
+
+// logDryRun logs the changes that would be made to the federation
+// relationships and records how many there are.
+func (r *federationRelationshipReconciler) logDryRun(ctx context.Context, toCreate, toUpdate, toDelete []spireapi.FederationRelationship) {
+	log := log.FromContext(ctx)
+	for _, federationRelationship := range toCreate {
+		log.Info("Dry run: would create federation relationship", federationRelationshipFields(federationRelationship)...)
+	}
+	for _, federationRelationship := range toUpdate {
+		log.Info("Dry run: would update federation relationship", federationRelationshipFields(federationRelationship)...)
+	}
+	for _, federationRelationship := range toDelete {
+		if _, keep := r.keepFederatedBundles[federationRelationship.TrustDomain]; r.bundleClient != nil && !keep {
+			log.Info("Dry run: would delete federated bundle", federationRelationshipFields(federationRelationship)...)
+		}
+		log.Info("Dry run: would delete federation relationship", federationRelationshipFields(federationRelationship)...)
+	}
+	metrics.DryRunChanges.WithLabelValues("federation relationship", metrics.OperationCreate).Set(float64(len(toCreate)))
+	metrics.DryRunChanges.WithLabelValues("federation relationship", metrics.OperationUpdate).Set(float64(len(toUpdate)))
+	metrics.DryRunChanges.WithLabelValues("federation relationship", metrics.OperationDelete).Set(float64(len(toDelete)))
+}
+
+// recordFailure records a failure to set the federation relationship for
+// the trust domain as an Event on the ClusterFederatedTrustDomain declaring
+// it.
+func (r *federationRelationshipReconciler) recordFailure(trustDomain spiffeid.TrustDomain, messageFmt string, args ...interface{}) {
+	if state, ok := r.clusterFederatedTrustDomains[trustDomain]; ok {
+		r.recordEventf(&state.ClusterFederatedTrustDomain, eventReasonFederationRelationshipFailed, messageFmt, args...)
+	}
+}
+
+func (r *federationRelationshipReconciler) recordEventf(obj *spirev1alpha1.ClusterFederatedTrustDomain, reason, messageFmt string, args ...interface{}) {
+	if r.eventRecorder != nil {
+		r.eventRecorder.Eventf(obj, corev1.EventTypeWarning, reason, messageFmt, args...)
+	}
 }
 
 func trustDomainIDsFromFederationRelationships(frs []spireapi.FederationRelationship) []spiffeid.TrustDomain {
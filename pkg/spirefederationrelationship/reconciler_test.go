@@ -24,15 +24,24 @@ import (
 	"time"
 
 	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
 	"github.com/spiffe/spire-controller-manager/pkg/spirefederationrelationship"
 	"github.com/spiffe/spire-controller-manager/pkg/test/k8stest"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -191,12 +200,341 @@ func TestReconcile(t *testing.T) {
 			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
 
 			k8sClient := k8stest.NewClientBuilder(t).WithRuntimeObjects(tt.withObjects...).Build()
-			spirefederationrelationship.Reconcile(ctx, tdc, k8sClient)
+			spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+				TrustDomainClient: tdc,
+				K8sClient:         k8sClient,
+			})
 			assert.Equal(t, tt.expectFRs, tdc.getFederationRelationships())
 		})
 	}
 }
 
+func TestReconcileMetrics(t *testing.T) {
+	td2 := spiffeid.RequireTrustDomainFromString("td2")
+	cftd := func(name, trustDomain string) *spirev1alpha1.ClusterFederatedTrustDomain {
+		return &spirev1alpha1.ClusterFederatedTrustDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+				TrustDomain:           trustDomain,
+				BundleEndpointURL:     "https://" + trustDomain + ".test/bundle",
+				BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+			},
+		}
+	}
+
+	tdc := newTrustDomainClient()
+	tdc.createStatus[td2] = spireapi.Status{Code: codes.Internal}
+
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	k8sClient := k8stest.NewClientBuilder(t).WithRuntimeObjects(cftd("td", "td"), cftd("td2", "td2")).Build()
+
+	errorsBefore := testutil.ToFloat64(metrics.SPIREAPIErrors.WithLabelValues("CreateFederationRelationships"))
+	spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+		TrustDomainClient: tdc,
+		K8sClient:         k8sClient,
+	})
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.FederationRelationships.WithLabelValues(metrics.StateInSync)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.FederationRelationships.WithLabelValues(metrics.StateOutOfSync)))
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(metrics.SPIREAPIErrors.WithLabelValues("CreateFederationRelationships")))
+
+	tdc.createStatus[td2] = spireapi.Status{}
+	spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+		TrustDomainClient: tdc,
+		K8sClient:         k8sClient,
+	})
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.FederationRelationships.WithLabelValues(metrics.StateInSync)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.FederationRelationships.WithLabelValues(metrics.StateOutOfSync)))
+}
+
+func TestReconcileEvents(t *testing.T) {
+	now := time.Now()
+	cftd := func(name, trustDomain string, created time.Time) *spirev1alpha1.ClusterFederatedTrustDomain {
+		return &spirev1alpha1.ClusterFederatedTrustDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.Time{Time: created}},
+			Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+				TrustDomain:           trustDomain,
+				BundleEndpointURL:     "https://" + trustDomain + ".test/bundle",
+				BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+			},
+		}
+	}
+
+	tdc := newTrustDomainClient()
+	tdc.createStatus[spiffeid.RequireTrustDomainFromString("td2")] = spireapi.Status{Code: codes.Internal, Message: "oh no"}
+
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	k8sClient := k8stest.NewClientBuilder(t).WithRuntimeObjects(
+		cftd("td", "td", now),
+		cftd("td2", "td2", now),
+		cftd("conflict", "td", now.Add(time.Second)),
+		cftd("invalid", "", now),
+	).Build()
+	recorder := record.NewFakeRecorder(10)
+
+	spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+		TrustDomainClient: tdc,
+		K8sClient:         k8sClient,
+		EventRecorder:     recorder,
+	})
+	close(recorder.Events)
+
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	assert.ElementsMatch(t, []string{
+		`Warning InvalidSpec Ignoring invalid spec: invalid trustDomain value: trust domain is missing`,
+		`Warning TrustDomainConflict Ignoring trust domain "td" already declared by td`,
+		`Warning FederationRelationshipFailed Failed to create federation relationship: rpc error: code = Internal desc = oh no`,
+	}, events)
+}
+
+func TestReconcileDeletesFederatedBundles(t *testing.T) {
+	td2 := spiffeid.RequireTrustDomainFromString("td2")
+	fr1 := spireapi.FederationRelationship{
+		TrustDomain:           td,
+		BundleEndpointURL:     "https://td.test/bundle",
+		BundleEndpointProfile: spireapi.HTTPSWebProfile{},
+	}
+	fr2 := spireapi.FederationRelationship{
+		TrustDomain:           td2,
+		BundleEndpointURL:     "https://td2.test/bundle",
+		BundleEndpointProfile: spireapi.HTTPSWebProfile{},
+	}
+
+	for _, tt := range []struct {
+		desc                   string
+		deleteFederatedBundles bool
+		keepFederatedBundles   []spiffeid.TrustDomain
+		configureBundleClient  func(bc *bundleClient)
+		expectFRs              []spireapi.FederationRelationship
+		expectBundles          []spiffeid.TrustDomain
+	}{
+		{
+			desc:          "keeps bundles when disabled",
+			expectBundles: []spiffeid.TrustDomain{td, td2},
+		},
+		{
+			desc:                   "deletes bundles",
+			deleteFederatedBundles: true,
+		},
+		{
+			desc:                   "keeps configured bundles",
+			deleteFederatedBundles: true,
+			keepFederatedBundles:   []spiffeid.TrustDomain{td2},
+			expectBundles:          []spiffeid.TrustDomain{td2},
+		},
+		{
+			desc:                   "deletes relationship when bundle is already gone",
+			deleteFederatedBundles: true,
+			configureBundleClient: func(bc *bundleClient) {
+				delete(bc.bundles, td)
+			},
+		},
+		{
+			desc:                   "keeps relationships when delete RPC fails",
+			deleteFederatedBundles: true,
+			configureBundleClient: func(bc *bundleClient) {
+				bc.deleteError = errors.New("oh no")
+			},
+			expectFRs:     []spireapi.FederationRelationship{fr1, fr2},
+			expectBundles: []spiffeid.TrustDomain{td, td2},
+		},
+		{
+			desc:                   "keeps relationship when bundle fails to delete",
+			deleteFederatedBundles: true,
+			configureBundleClient: func(bc *bundleClient) {
+				bc.deleteStatus[td] = spireapi.Status{Code: codes.Internal}
+			},
+			expectFRs:     []spireapi.FederationRelationship{fr1},
+			expectBundles: []spiffeid.TrustDomain{td},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			tdc := newTrustDomainClient()
+			tdc.frs[td] = fr1
+			tdc.frs[td2] = fr2
+
+			bc := newBundleClient(td, td2)
+			if tt.configureBundleClient != nil {
+				tt.configureBundleClient(bc)
+			}
+
+			ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+
+			k8sClient := k8stest.NewClientBuilder(t).Build()
+			spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+				TrustDomainClient:      tdc,
+				K8sClient:              k8sClient,
+				BundleClient:           bc,
+				DeleteFederatedBundles: tt.deleteFederatedBundles,
+				KeepFederatedBundles:   tt.keepFederatedBundles,
+			})
+			assert.Equal(t, tt.expectFRs, tdc.getFederationRelationships())
+			assert.Equal(t, tt.expectBundles, bc.getTrustDomains())
+		})
+	}
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	td2 := spiffeid.RequireTrustDomainFromString("td2")
+	fr2 := spireapi.FederationRelationship{
+		TrustDomain:           td2,
+		BundleEndpointURL:     "https://td2.test/bundle",
+		BundleEndpointProfile: spireapi.HTTPSWebProfile{},
+	}
+
+	tdc := newTrustDomainClient()
+	tdc.frs[td2] = fr2
+	bc := newBundleClient(td2)
+
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	k8sClient := k8stest.NewClientBuilder(t).WithRuntimeObjects(&spirev1alpha1.ClusterFederatedTrustDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "td"},
+		Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+			TrustDomain:           "td",
+			BundleEndpointURL:     "https://td.test/bundle",
+			BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+		},
+	}).Build()
+
+	spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+		TrustDomainClient:      tdc,
+		K8sClient:              k8sClient,
+		BundleClient:           bc,
+		DeleteFederatedBundles: true,
+		DryRun:                 true,
+	})
+
+	// Nothing is changed.
+	assert.Equal(t, []spireapi.FederationRelationship{fr2}, tdc.getFederationRelationships())
+	assert.Equal(t, []spiffeid.TrustDomain{td2}, bc.getTrustDomains())
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DryRunChanges.WithLabelValues("federation relationship", metrics.OperationCreate)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.DryRunChanges.WithLabelValues("federation relationship", metrics.OperationUpdate)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DryRunChanges.WithLabelValues("federation relationship", metrics.OperationDelete)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.FederationRelationships.WithLabelValues(metrics.StateInSync)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.FederationRelationships.WithLabelValues(metrics.StateOutOfSync)))
+}
+
+func TestReconcileClassName(t *testing.T) {
+	td2 := spiffeid.RequireTrustDomainFromString("td2")
+	cftd := func(trustDomain, className string) *spirev1alpha1.ClusterFederatedTrustDomain {
+		return &spirev1alpha1.ClusterFederatedTrustDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: trustDomain},
+			Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+				TrustDomain:           trustDomain,
+				BundleEndpointURL:     "https://" + trustDomain + ".test/bundle",
+				BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+				ClassName:             className,
+			},
+		}
+	}
+
+	tdc := newTrustDomainClient()
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	k8sClient := k8stest.NewClientBuilder(t).WithRuntimeObjects(cftd("td", ""), cftd("td2", "a")).Build()
+
+	spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+		TrustDomainClient: tdc,
+		K8sClient:         k8sClient,
+		ClassName:         "a",
+	})
+	assert.Equal(t, []spireapi.FederationRelationship{{
+		TrustDomain:           td2,
+		BundleEndpointURL:     "https://td2.test/bundle",
+		BundleEndpointProfile: spireapi.HTTPSWebProfile{},
+	}}, tdc.getFederationRelationships())
+}
+
+func TestReconcileSPIREServer(t *testing.T) {
+	td2 := spiffeid.RequireTrustDomainFromString("td2")
+	cftd := func(trustDomain, spireServer string) *spirev1alpha1.ClusterFederatedTrustDomain {
+		return &spirev1alpha1.ClusterFederatedTrustDomain{
+			ObjectMeta: metav1.ObjectMeta{Name: trustDomain},
+			Spec: spirev1alpha1.ClusterFederatedTrustDomainSpec{
+				TrustDomain:           trustDomain,
+				BundleEndpointURL:     "https://" + trustDomain + ".test/bundle",
+				BundleEndpointProfile: spirev1alpha1.BundleEndpointProfile{Type: "https_web"},
+				SPIREServer:           spireServer,
+			},
+		}
+	}
+
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	k8sClient := k8stest.NewClientBuilder(t).WithRuntimeObjects(cftd("td", ""), cftd("td2", "other")).Build()
+
+	// Each reconciler only sets the federation relationships declared for
+	// its SPIRE Server.
+	defaultTDC := newTrustDomainClient()
+	otherTDC := newTrustDomainClient()
+	spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+		TrustDomainClient: otherTDC,
+		K8sClient:         k8sClient,
+		SPIREServer:       "other",
+	})
+	spirefederationrelationship.Reconcile(ctx, spirefederationrelationship.ReconcilerConfig{
+		TrustDomainClient: defaultTDC,
+		K8sClient:         k8sClient,
+	})
+	assert.Equal(t, []spireapi.FederationRelationship{{
+		TrustDomain:           td2,
+		BundleEndpointURL:     "https://td2.test/bundle",
+		BundleEndpointProfile: spireapi.HTTPSWebProfile{},
+	}}, otherTDC.getFederationRelationships())
+	assert.Equal(t, []spireapi.FederationRelationship{{
+		TrustDomain:           spiffeid.RequireTrustDomainFromString("td"),
+		BundleEndpointURL:     "https://td.test/bundle",
+		BundleEndpointProfile: spireapi.HTTPSWebProfile{},
+	}}, defaultTDC.getFederationRelationships())
+}
+
+func TestReconcileCRDNotInstalled(t *testing.T) {
+	ctx := log.IntoContext(context.Background(), logrtesting.NewTestLogger(t))
+	k8sClient := k8stest.NewClientBuilder(t).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				if _, ok := list.(*spirev1alpha1.ClusterFederatedTrustDomainList); ok {
+					return &meta.NoKindMatchError{
+						GroupKind:        spirev1alpha1.GroupVersion.WithKind("ClusterFederatedTrustDomain").GroupKind(),
+						SearchedVersions: []string{spirev1alpha1.GroupVersion.Version},
+					}
+				}
+				return c.List(ctx, list, opts...)
+			},
+		}).
+		Build()
+
+	fr := spireapi.FederationRelationship{
+		TrustDomain:           spiffeid.RequireTrustDomainFromString("td"),
+		BundleEndpointURL:     "https://td.test/bundle",
+		BundleEndpointProfile: spireapi.HTTPSWebProfile{},
+	}
+	tdc := newTrustDomainClient()
+	tdc.frs[fr.TrustDomain] = fr
+
+	// A NoMatch error alone may be temporary, e.g. while the REST mapping
+	// is stale, so the reconcile fails without deleting anything.
+	config := spirefederationrelationship.ReconcilerConfig{
+		TrustDomainClient: tdc,
+		K8sClient:         k8sClient,
+	}
+	assert.Error(t, spirefederationrelationship.Reconcile(ctx, config))
+	assert.Equal(t, []spireapi.FederationRelationship{fr}, tdc.getFederationRelationships())
+
+	// Once the CRD is confirmed not to be installed, there are no trust
+	// domains to federate with.
+	config.KindNotServed = func(gvk schema.GroupVersionKind) bool {
+		return gvk == spirev1alpha1.GroupVersion.WithKind("ClusterFederatedTrustDomain")
+	}
+	assert.NoError(t, spirefederationrelationship.Reconcile(ctx, config))
+	assert.Empty(t, tdc.getFederationRelationships())
+
+	assert.NoError(t, spirefederationrelationship.Probe(ctx, spirefederationrelationship.ProberConfig{
+		K8sClient: k8sClient,
+	}))
+}
+
 type trustDomainClient struct {
 	frs          map[spiffeid.TrustDomain]spireapi.FederationRelationship
 	listError    error
@@ -294,3 +632,66 @@ func (t *trustDomainClient) getFederationRelationships() []spireapi.FederationRe
 	})
 	return out
 }
+
+type bundleClient struct {
+	bundles      map[spiffeid.TrustDomain]struct{}
+	deleteStatus map[spiffeid.TrustDomain]spireapi.Status
+	deleteError  error
+}
+
+func newBundleClient(tds ...spiffeid.TrustDomain) *bundleClient {
+	bc := &bundleClient{
+		bundles:      make(map[spiffeid.TrustDomain]struct{}),
+		deleteStatus: make(map[spiffeid.TrustDomain]spireapi.Status),
+	}
+	for _, td := range tds {
+		bc.bundles[td] = struct{}{}
+	}
+	return bc
+}
+
+func (b *bundleClient) GetBundle(ctx context.Context) (*spiffebundle.Bundle, error) {
+	return nil, errors.New("unexpected call to GetBundle")
+}
+
+func (b *bundleClient) GetFederatedBundle(ctx context.Context, td spiffeid.TrustDomain) (*spiffebundle.Bundle, error) {
+	if _, ok := b.bundles[td]; !ok {
+		return nil, status.Errorf(codes.NotFound, "no federated bundle for %q", td)
+	}
+	return spiffebundle.New(td), nil
+}
+
+func (b *bundleClient) ListFederatedBundles(ctx context.Context) ([]*spiffebundle.Bundle, error) {
+	return nil, errors.New("unexpected call to ListFederatedBundles")
+}
+
+func (b *bundleClient) DeleteFederatedBundles(ctx context.Context, tds []spiffeid.TrustDomain) ([]spireapi.Status, error) {
+	if b.deleteError != nil {
+		return nil, b.deleteError
+	}
+	out := make([]spireapi.Status, 0, len(tds))
+	for _, td := range tds {
+		var st spireapi.Status
+		if _, exists := b.bundles[td]; !exists {
+			st.Code = codes.NotFound
+		} else {
+			st = b.deleteStatus[td]
+		}
+		if st.Code == codes.OK {
+			delete(b.bundles, td)
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func (b *bundleClient) getTrustDomains() []spiffeid.TrustDomain {
+	var out []spiffeid.TrustDomain
+	for td := range b.bundles {
+		out = append(out, td)
+	}
+	sort.Slice(out, func(a, c int) bool {
+		return out[a].Compare(out[c]) < 0
+	})
+	return out
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spirehealth checks the connection to SPIRE Server for the health
+// and ready checks of the manager.
+package spirehealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+)
+
+const (
+	defaultTimeout          = 5 * time.Second
+	defaultFailureThreshold = 1
+)
+
+type Config struct {
+	// BundleClient is used to get the bundle from SPIRE Server, which is
+	// a cheap call that exercises the connection.
+	BundleClient spireapi.BundleClient
+
+	// Timeout is how long to wait for SPIRE Server. Defaults to 5s.
+	Timeout time.Duration
+
+	// FailureThreshold is how many consecutive calls to SPIRE Server must
+	// fail before the check fails. Defaults to 1.
+	FailureThreshold int
+}
+
+// Checker fails once SPIRE Server has not been reachable for the configured
+// number of consecutive checks.
+type Checker struct {
+	config Config
+
+	mtx      sync.Mutex
+	failures int
+}
+
+func NewChecker(config Config) (*Checker, error) {
+	if config.BundleClient == nil {
+		return nil, errors.New("bundle client is required")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaultFailureThreshold
+	}
+	return &Checker{config: config}, nil
+}
+
+// Check implements healthz.Checker.
+func (c *Checker) Check(req *http.Request) error {
+	ctx, cancel := context.WithTimeout(req.Context(), c.config.Timeout)
+	defer cancel()
+	_, err := c.config.BundleClient.GetBundle(ctx)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if err == nil {
+		c.failures = 0
+		return nil
+	}
+	c.failures++
+	if c.failures < c.config.FailureThreshold {
+		return nil
+	}
+	return fmt.Errorf("SPIRE Server unreachable for %d consecutive checks: %w", c.failures, err)
+}
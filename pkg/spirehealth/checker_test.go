@@ -0,0 +1,102 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spirehealth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/spiffe/spire-controller-manager/pkg/spirehealth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	bundleClient := new(bundleClient)
+	checker, err := spirehealth.NewChecker(spirehealth.Config{
+		BundleClient:     bundleClient,
+		FailureThreshold: 2,
+	})
+	require.NoError(t, err)
+
+	check := func() error {
+		return checker.Check(httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	}
+
+	require.NoError(t, check())
+
+	bundleClient.err = errors.New("connection refused")
+	require.NoError(t, check(), "first failure is under the threshold")
+	require.EqualError(t, check(), "SPIRE Server unreachable for 2 consecutive checks: connection refused")
+	require.Error(t, check())
+
+	bundleClient.err = nil
+	require.NoError(t, check())
+
+	bundleClient.err = errors.New("connection refused")
+	require.NoError(t, check(), "failures are reset by a success")
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	checker, err := spirehealth.NewChecker(spirehealth.Config{
+		BundleClient: &bundleClient{block: true},
+		Timeout:      time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	err = checker.Check(httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNewCheckerRequiresBundleClient(t *testing.T) {
+	_, err := spirehealth.NewChecker(spirehealth.Config{})
+	require.EqualError(t, err, "bundle client is required")
+}
+
+type bundleClient struct {
+	err   error
+	block bool
+}
+
+func (c *bundleClient) GetBundle(ctx context.Context) (*spiffebundle.Bundle, error) {
+	if c.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return spiffebundle.New(spiffeid.RequireTrustDomainFromString("example.org")), nil
+}
+
+func (c *bundleClient) GetFederatedBundle(context.Context, spiffeid.TrustDomain) (*spiffebundle.Bundle, error) {
+	return nil, errors.New("unexpected call to GetFederatedBundle")
+}
+
+func (c *bundleClient) ListFederatedBundles(context.Context) ([]*spiffebundle.Bundle, error) {
+	return nil, errors.New("unexpected call to ListFederatedBundles")
+}
+
+func (c *bundleClient) DeleteFederatedBundles(context.Context, []spiffeid.TrustDomain) ([]spireapi.Status, error) {
+	return nil, errors.New("unexpected call to DeleteFederatedBundles")
+}
@@ -6,6 +6,7 @@ import (
 	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -15,7 +16,9 @@ func NewClientBuilder(t *testing.T) *fake.ClientBuilder {
 
 func WithScheme(t *testing.T, b *fake.ClientBuilder) *fake.ClientBuilder {
 	scheme := runtime.NewScheme()
-	err := spirev1alpha1.AddToScheme(scheme)
+	err := clientgoscheme.AddToScheme(scheme)
+	require.NoError(t, err)
+	err = spirev1alpha1.AddToScheme(scheme)
 	require.NoError(t, err)
 	return b.WithScheme(scheme)
 }
@@ -0,0 +1,236 @@
+/*
+Copyright 2022 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhookmanager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// WebhookKind is the kind of a webhook configuration.
+type WebhookKind string
+
+const (
+	ValidatingWebhookKind WebhookKind = "ValidatingWebhookConfiguration"
+	MutatingWebhookKind   WebhookKind = "MutatingWebhookConfiguration"
+
+	// CustomResourceDefinitionKind targets the conversion webhook of custom
+	// resource definitions. Definitions that do not convert through a
+	// webhook are left as is.
+	CustomResourceDefinitionKind WebhookKind = "CustomResourceDefinition"
+)
+
+// CABundleTarget selects additional webhook configurations, or custom
+// resource definitions with a conversion webhook, whose CABundle is kept in
+// sync with the CA of the webhook serving certificate, so that other webhooks
+// serving SPIRE-minted certificates can reuse the same rotation.
+type CABundleTarget struct {
+	// Kind is the kind of the webhook configurations, or
+	// CustomResourceDefinitionKind.
+	Kind WebhookKind
+
+	// Name, if set, selects the webhook configuration with this name.
+	Name string
+
+	// Selector, if set, selects the webhook configurations whose labels
+	// match.
+	Selector labels.Selector
+}
+
+func (t CABundleTarget) matches(obj metav1.Object) bool {
+	return (t.Name != "" && obj.GetName() == t.Name) ||
+		(t.Selector != nil && t.Selector.Matches(labels.Set(obj.GetLabels())))
+}
+
+// MutatingWebhookClient is the subset of the MutatingWebhookConfiguration API
+// used by the manager. The admissionregistration.k8s.io/v1 typed client
+// satisfies this interface.
+type MutatingWebhookClient interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*admissionregistrationv1.MutatingWebhookConfigurationList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*admissionregistrationv1.MutatingWebhookConfiguration, error)
+}
+
+// CustomResourceDefinitionClient is the subset of the CustomResourceDefinition
+// API used by the manager. The apiextensions.k8s.io/v1 typed client satisfies
+// this interface.
+type CustomResourceDefinitionClient interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*apiextensionsv1.CustomResourceDefinitionList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*apiextensionsv1.CustomResourceDefinition, error)
+}
+
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;patch
+
+// isCABundleTarget returns true if the webhook configuration of the given
+// kind is selected by any of the CABundle targets. The webhook configuration
+// of the manager itself is not a target; it is always kept in sync.
+func (m *Manager) isCABundleTarget(kind WebhookKind, obj metav1.Object) bool {
+	if kind == ValidatingWebhookKind && obj.GetName() == m.config.WebhookName {
+		return false
+	}
+	for _, target := range m.config.CABundleTargets {
+		if target.Kind == kind && target.matches(obj) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) hasCABundleTargets(kind WebhookKind) bool {
+	for _, target := range m.config.CABundleTargets {
+		if target.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// updateCABundleTargetsIfNeeded patches the CABundle of the targeted webhook
+// configurations and custom resource definitions that are out of date. Any
+// store may be nil if there are no targets of that kind. Checking uses the
+// cache and only hits the API to patch.
+func (m *Manager) updateCABundleTargetsIfNeeded(ctx context.Context, validatingStore, mutatingStore, crdStore cache.Store) error {
+	m.mtx.RLock()
+	caBundle := m.caBundle
+	m.mtx.RUnlock()
+
+	if validatingStore != nil {
+		for _, obj := range validatingStore.List() {
+			current, ok := obj.(*admissionregistrationv1.ValidatingWebhookConfiguration)
+			if !ok || !m.isCABundleTarget(ValidatingWebhookKind, current) {
+				continue
+			}
+			if err := m.patchValidatingCABundleIfNeeded(ctx, current, caBundle); err != nil {
+				return err
+			}
+		}
+	}
+
+	if mutatingStore != nil {
+		for _, obj := range mutatingStore.List() {
+			current, ok := obj.(*admissionregistrationv1.MutatingWebhookConfiguration)
+			if !ok || !m.isCABundleTarget(MutatingWebhookKind, current) {
+				continue
+			}
+			if err := m.patchMutatingCABundleIfNeeded(ctx, current, caBundle); err != nil {
+				return err
+			}
+		}
+	}
+
+	if crdStore != nil {
+		for _, obj := range crdStore.List() {
+			current, ok := obj.(*apiextensionsv1.CustomResourceDefinition)
+			if !ok || !m.isCABundleTarget(CustomResourceDefinitionKind, current) {
+				continue
+			}
+			if err := m.patchCRDCABundleIfNeeded(ctx, current, caBundle); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Manager) patchValidatingCABundleIfNeeded(ctx context.Context, current *admissionregistrationv1.ValidatingWebhookConfiguration, caBundle []byte) error {
+	var modified *admissionregistrationv1.ValidatingWebhookConfiguration
+	for i, webhook := range current.Webhooks {
+		if bytes.Equal(webhook.ClientConfig.CABundle, caBundle) {
+			continue
+		}
+		if modified == nil {
+			modified = current.DeepCopy()
+		}
+		modified.Webhooks[i].ClientConfig.CABundle = caBundle
+	}
+	if modified == nil {
+		return nil
+	}
+
+	data, err := client.StrategicMergeFrom(current).Data(modified)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook configuration patch: %w", err)
+	}
+	if _, err := m.config.WebhookClient.Patch(ctx, current.Name, types.StrategicMergePatchType, data, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch webhook configuration %q: %w", current.Name, err)
+	}
+	log.FromContext(ctx).Info("Webhook configuration patched with CABundle", "kind", ValidatingWebhookKind, "name", current.Name)
+	return nil
+}
+
+func (m *Manager) patchMutatingCABundleIfNeeded(ctx context.Context, current *admissionregistrationv1.MutatingWebhookConfiguration, caBundle []byte) error {
+	var modified *admissionregistrationv1.MutatingWebhookConfiguration
+	for i, webhook := range current.Webhooks {
+		if bytes.Equal(webhook.ClientConfig.CABundle, caBundle) {
+			continue
+		}
+		if modified == nil {
+			modified = current.DeepCopy()
+		}
+		modified.Webhooks[i].ClientConfig.CABundle = caBundle
+	}
+	if modified == nil {
+		return nil
+	}
+
+	data, err := client.StrategicMergeFrom(current).Data(modified)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook configuration patch: %w", err)
+	}
+	if _, err := m.config.MutatingWebhookClient.Patch(ctx, current.Name, types.StrategicMergePatchType, data, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch webhook configuration %q: %w", current.Name, err)
+	}
+	log.FromContext(ctx).Info("Webhook configuration patched with CABundle", "kind", MutatingWebhookKind, "name", current.Name)
+	return nil
+}
+
+func (m *Manager) patchCRDCABundleIfNeeded(ctx context.Context, current *apiextensionsv1.CustomResourceDefinition, caBundle []byte) error {
+	conversion := current.Spec.Conversion
+	switch {
+	case conversion == nil, conversion.Strategy != apiextensionsv1.WebhookConverter,
+		conversion.Webhook == nil, conversion.Webhook.ClientConfig == nil:
+		return nil
+	case bytes.Equal(conversion.Webhook.ClientConfig.CABundle, caBundle):
+		return nil
+	}
+
+	modified := current.DeepCopy()
+	modified.Spec.Conversion.Webhook.ClientConfig.CABundle = caBundle
+
+	// Custom resource definitions do not support strategic merge patches.
+	data, err := client.MergeFrom(current).Data(modified)
+	if err != nil {
+		return fmt.Errorf("failed to create custom resource definition patch: %w", err)
+	}
+	if _, err := m.config.CRDClient.Patch(ctx, current.Name, types.MergePatchType, data, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch custom resource definition %q: %w", current.Name, err)
+	}
+	log.FromContext(ctx).Info("Custom resource definition patched with CABundle", "kind", CustomResourceDefinitionKind, "name", current.Name)
+	return nil
+}
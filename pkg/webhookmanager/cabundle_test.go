@@ -0,0 +1,146 @@
+package webhookmanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestUpdateCABundleTargetsIfNeeded(t *testing.T) {
+	ctx := context.Background()
+
+	validating := func(name string, labels map[string]string) *admissionregistrationv1.ValidatingWebhookConfiguration {
+		return &admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "webhook.test", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: []byte("old")}}},
+		}
+	}
+	mutating := func(name string, labels map[string]string) *admissionregistrationv1.MutatingWebhookConfiguration {
+		return &admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "webhook.test", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: []byte("old")}}},
+		}
+	}
+
+	objects := []interface{}{
+		validating("own", nil),
+		validating("named", nil),
+		validating("labeled", map[string]string{"spiffe": "yes"}),
+		validating("untargeted", nil),
+		mutating("named", nil),
+		mutating("labeled", map[string]string{"spiffe": "yes"}),
+		mutating("untargeted", nil),
+	}
+	clientset := fake.NewSimpleClientset()
+	validatingStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	mutatingStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	for _, obj := range objects {
+		switch obj := obj.(type) {
+		case *admissionregistrationv1.ValidatingWebhookConfiguration:
+			_, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Create(ctx, obj, metav1.CreateOptions{})
+			require.NoError(t, err)
+			require.NoError(t, validatingStore.Add(obj))
+		case *admissionregistrationv1.MutatingWebhookConfiguration:
+			_, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Create(ctx, obj, metav1.CreateOptions{})
+			require.NoError(t, err)
+			require.NoError(t, mutatingStore.Add(obj))
+		}
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{"spiffe": "yes"})
+	m := New(Config{
+		WebhookName:           "own",
+		WebhookClient:         clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations(),
+		MutatingWebhookClient: clientset.AdmissionregistrationV1().MutatingWebhookConfigurations(),
+		CABundleTargets: []CABundleTarget{
+			{Kind: ValidatingWebhookKind, Name: "named"},
+			{Kind: ValidatingWebhookKind, Selector: selector},
+			{Kind: MutatingWebhookKind, Name: "named"},
+			{Kind: MutatingWebhookKind, Selector: selector},
+		},
+	})
+	m.caBundle = []byte("new")
+
+	require.True(t, m.hasCABundleTargets(MutatingWebhookKind))
+	require.NoError(t, m.updateCABundleTargetsIfNeeded(ctx, validatingStore, mutatingStore, nil))
+
+	requireValidatingCABundle := func(name, expected string) {
+		webhookConfig, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, expected, string(webhookConfig.Webhooks[0].ClientConfig.CABundle), "unexpected CABundle for %s", name)
+	}
+	requireMutatingCABundle := func(name, expected string) {
+		webhookConfig, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, expected, string(webhookConfig.Webhooks[0].ClientConfig.CABundle), "unexpected CABundle for %s", name)
+	}
+
+	// The webhook configuration of the manager itself is updated separately.
+	requireValidatingCABundle("own", "old")
+	requireValidatingCABundle("named", "new")
+	requireValidatingCABundle("labeled", "new")
+	requireValidatingCABundle("untargeted", "old")
+	requireMutatingCABundle("named", "new")
+	requireMutatingCABundle("labeled", "new")
+	requireMutatingCABundle("untargeted", "old")
+}
+
+func TestUpdateCRDCABundleTargetsIfNeeded(t *testing.T) {
+	ctx := context.Background()
+
+	crd := func(name string, strategy apiextensionsv1.ConversionStrategyType) *apiextensionsv1.CustomResourceDefinition {
+		crd := &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Conversion: &apiextensionsv1.CustomResourceConversion{Strategy: strategy},
+			},
+		}
+		if strategy == apiextensionsv1.WebhookConverter {
+			crd.Spec.Conversion.Webhook = &apiextensionsv1.WebhookConversion{
+				ClientConfig: &apiextensionsv1.WebhookClientConfig{CABundle: []byte("old")},
+			}
+		}
+		return crd
+	}
+
+	clientset := apiextensionsfake.NewSimpleClientset()
+	crdStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	for _, obj := range []*apiextensionsv1.CustomResourceDefinition{
+		crd("converted.test", apiextensionsv1.WebhookConverter),
+		crd("unconverted.test", apiextensionsv1.NoneConverter),
+		crd("untargeted.test", apiextensionsv1.WebhookConverter),
+	} {
+		_, err := clientset.ApiextensionsV1().CustomResourceDefinitions().Create(ctx, obj, metav1.CreateOptions{})
+		require.NoError(t, err)
+		require.NoError(t, crdStore.Add(obj))
+	}
+
+	m := New(Config{
+		CRDClient: clientset.ApiextensionsV1().CustomResourceDefinitions(),
+		CABundleTargets: []CABundleTarget{
+			{Kind: CustomResourceDefinitionKind, Name: "converted.test"},
+			{Kind: CustomResourceDefinitionKind, Name: "unconverted.test"},
+		},
+	})
+	m.caBundle = []byte("new")
+
+	require.True(t, m.hasCABundleTargets(CustomResourceDefinitionKind))
+	require.NoError(t, m.updateCABundleTargetsIfNeeded(ctx, nil, nil, crdStore))
+
+	getCRD := func(name string) *apiextensionsv1.CustomResourceDefinition {
+		crd, err := clientset.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return crd
+	}
+	require.Equal(t, "new", string(getCRD("converted.test").Spec.Conversion.Webhook.ClientConfig.CABundle))
+	require.Nil(t, getCRD("unconverted.test").Spec.Conversion.Webhook)
+	require.Equal(t, "old", string(getCRD("untargeted.test").Spec.Conversion.Webhook.ClientConfig.CABundle))
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhookmanager
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// The names of the certificate and private key in the external certificate
+// directory. They match the keys of a kubernetes.io/tls Secret.
+const (
+	externalCertName = "tls.crt"
+	externalKeyName  = "tls.key"
+)
+
+// externalCertificate tracks the externally provided serving certificate.
+// It is only accessed by Init and then by the CertificateRotator, so it is
+// not guarded by the mutex.
+type externalCertificate struct {
+	certPEM []byte
+	keyPEM  []byte
+
+	// checkedResourceVersion is the resource version of the webhook
+	// configuration the certificate was last checked against.
+	checkedResourceVersion string
+}
+
+// loadExternalCertificateIfNeeded loads the externally provided serving
+// certificate if it has changed, and checks it against the webhook
+// configuration whenever either has changed. A certificate that does not
+// match the webhook configuration is still served, since the webhook
+// configuration may not have caught up yet (e.g. the CABundle is injected
+// after the certificate is issued), but the mismatch is logged.
+func (m *Manager) loadExternalCertificateIfNeeded(ctx context.Context, store cache.Store) error {
+	log := log.FromContext(ctx)
+
+	certPEM, err := os.ReadFile(filepath.Join(m.config.ExternalCertDir, externalCertName))
+	if err != nil {
+		return fmt.Errorf("failed to read external webhook certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(m.config.ExternalCertDir, externalKeyName))
+	if err != nil {
+		return fmt.Errorf("failed to read external webhook private key: %w", err)
+	}
+
+	changed := !bytes.Equal(certPEM, m.external.certPEM) || !bytes.Equal(keyPEM, m.external.keyPEM)
+	if changed {
+		certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("invalid external webhook certificate: %w", err)
+		}
+		certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0])
+		if err != nil {
+			return fmt.Errorf("invalid external webhook certificate: %w", err)
+		}
+
+		log.Info("Loaded external webhook certificate", "dnsNames", certificate.Leaf.DNSNames, "expiresAt", certificate.Leaf.NotAfter)
+
+		m.mtx.Lock()
+		m.certificate = &certificate
+		m.rotatedAt = m.config.Clock.Now()
+		m.expiresAt = certificate.Leaf.NotAfter
+		m.mtx.Unlock()
+
+		m.external.certPEM = certPEM
+		m.external.keyPEM = keyPEM
+	}
+
+	webhookConfig, exists, err := getWebhookConfigFromStore(store, m.config.WebhookName)
+	switch {
+	case err != nil:
+		return err
+	case !exists:
+		return nil
+	case !changed && webhookConfig.ResourceVersion == m.external.checkedResourceVersion:
+		return nil
+	}
+
+	m.mtx.RLock()
+	certificate := m.certificate
+	m.mtx.RUnlock()
+
+	if err := m.checkExternalCertificate(certificate, webhookConfig); err != nil {
+		log.Error(err, "External webhook certificate does not match the webhook configuration")
+	}
+	m.external.checkedResourceVersion = webhookConfig.ResourceVersion
+	return nil
+}
+
+// checkExternalCertificate checks that the certificate is valid for the DNS
+// names of the webhook services, and is trusted by the CABundle of each
+// webhook.
+func (m *Manager) checkExternalCertificate(certificate *tls.Certificate, webhookConfig *admissionregistrationv1.ValidatingWebhookConfiguration) error {
+	now := m.config.Clock.Now()
+	leaf := certificate.Leaf
+
+	var problems []string
+	if now.After(leaf.NotAfter) {
+		problems = append(problems, fmt.Sprintf("certificate expired at %s", leaf.NotAfter))
+	}
+	for _, dnsName := range webhookDNSNames(webhookConfig) {
+		if err := leaf.VerifyHostname(dnsName); err != nil {
+			problems = append(problems, fmt.Sprintf("certificate is not valid for %q", dnsName))
+		}
+	}
+
+	for _, webhook := range webhookConfig.Webhooks {
+		err := verifyCertificate(certificate, webhook.ClientConfig.CABundle, now)
+		switch {
+		case errors.Is(err, errNoValidCABundle):
+			problems = append(problems, fmt.Sprintf("webhook %q has no valid CABundle", webhook.Name))
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("certificate is not trusted by the CABundle of webhook %q: %v", webhook.Name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+var errNoValidCABundle = errors.New("no valid CABundle")
+
+// verifyCertificate verifies the serving certificate, along with its
+// intermediates, against the PEM encoded CABundle.
+func verifyCertificate(certificate *tls.Certificate, caBundle []byte, now time.Time) error {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caBundle) {
+		return errNoValidCABundle
+	}
+	intermediates := x509.NewCertPool()
+	for _, der := range certificate.Certificate[1:] {
+		if cert, err := x509.ParseCertificate(der); err == nil {
+			intermediates.AddCert(cert)
+		}
+	}
+	_, err := certificate.Leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return err
+}
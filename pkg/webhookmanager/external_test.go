@@ -0,0 +1,109 @@
+package webhookmanager
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestLoadExternalCertificate(t *testing.T) {
+	ctx := context.Background()
+	ca, err := LoadSelfSignedCA(ctx, SelfSignedCAConfig{
+		SecretClient: fake.NewSimpleClientset().CoreV1().Secrets("spire-system"),
+		SecretName:   "webhook-ca",
+	})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeCert := func(dnsNames ...string) *x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		svid, err := ca.MintX509SVID(ctx, spireapi.X509SVIDParams{
+			Key:      key,
+			ID:       spiffeid.RequireFromString("spiffe://example.org/webhook"),
+			DNSNames: dnsNames,
+			TTL:      time.Hour,
+		})
+		require.NoError(t, err)
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, externalCertName), marshalX509Authorities(svid.CertChain), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, externalKeyName), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+		return svid.CertChain[0]
+	}
+
+	webhookConfig := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook", ResourceVersion: "1"},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{
+				Name: "vclusterspiffeid.kb.io",
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service:  &admissionregistrationv1.ServiceReference{Namespace: "spire-system", Name: "webhook"},
+					CABundle: marshalX509Authorities(ca.X509Authorities()),
+				},
+			},
+		},
+	}
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	require.NoError(t, store.Add(webhookConfig))
+
+	m := New(Config{
+		WebhookName:     "webhook",
+		ExternalCertDir: dir,
+	})
+
+	t.Run("fails if the certificate is missing", func(t *testing.T) {
+		err := m.loadExternalCertificateIfNeeded(ctx, store)
+		require.ErrorContains(t, err, "failed to read external webhook certificate")
+	})
+
+	t.Run("loads the certificate", func(t *testing.T) {
+		leaf := writeCert("webhook.spire-system.svc")
+		require.NoError(t, m.loadExternalCertificateIfNeeded(ctx, store))
+
+		certificate, err := m.GetCertificate(nil)
+		require.NoError(t, err)
+		assert.True(t, leaf.Equal(certificate.Leaf))
+		assert.NoError(t, m.checkExternalCertificate(certificate, webhookConfig))
+	})
+
+	t.Run("reloads the certificate when it is rotated", func(t *testing.T) {
+		leaf := writeCert("webhook.spire-system.svc")
+		require.NoError(t, m.loadExternalCertificateIfNeeded(ctx, store))
+
+		certificate, err := m.GetCertificate(nil)
+		require.NoError(t, err)
+		assert.True(t, leaf.Equal(certificate.Leaf))
+	})
+
+	t.Run("check fails if the certificate does not match the webhook configuration", func(t *testing.T) {
+		writeCert("other.spire-system.svc")
+		require.NoError(t, m.loadExternalCertificateIfNeeded(ctx, store), "mismatched certificates are still served")
+
+		certificate, err := m.GetCertificate(nil)
+		require.NoError(t, err)
+		err = m.checkExternalCertificate(certificate, webhookConfig)
+		require.EqualError(t, err, `certificate is not valid for "webhook.spire-system.svc"`)
+
+		untrusted := webhookConfig.DeepCopy()
+		untrusted.Webhooks[0].ClientConfig.CABundle = nil
+		err = m.checkExternalCertificate(certificate, untrusted)
+		require.ErrorContains(t, err, `webhook "vclusterspiffeid.kb.io" has no valid CABundle`)
+	})
+}
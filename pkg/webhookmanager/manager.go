@@ -22,70 +22,124 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
-	"os"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/metrics"
 	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	types "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
-	admissionregistrationapiv1 "k8s.io/client-go/kubernetes/typed/admissionregistration/v1"
 	"k8s.io/client-go/tools/cache"
 
 	"k8s.io/utils/clock"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 const (
-	x509SVIDTTL = time.Hour * 24
+	// DefaultSVIDTTL is the default lifetime of the webhook serving
+	// certificate.
+	DefaultSVIDTTL = time.Hour * 24
 )
 
 type Config struct {
 	ID            spiffeid.ID
-	KeyPairPath   string
 	WebhookName   string
-	WebhookClient admissionregistrationapiv1.ValidatingWebhookConfigurationInterface
+	WebhookClient WebhookClient
 	SVIDClient    spireapi.SVIDClient
 	BundleClient  spireapi.BundleClient
 	Clock         clock.WithTicker
+
+	// SVIDTTL is the lifetime requested for the webhook serving
+	// certificate. Defaults to DefaultSVIDTTL.
+	SVIDTTL time.Duration
+
+	// RotationThreshold, if set, rotates the webhook serving certificate
+	// once it expires within the threshold. If unset, or not shorter than
+	// the certificate lifetime, the certificate is rotated once a fraction
+	// of its lifetime, which depends on the lifetime, remains.
+	RotationThreshold time.Duration
+
+	// SelfSignedCA, if set, signs the webhook serving certificate and
+	// provides the CABundle instead of SPIRE Server.
+	SelfSignedCA *SelfSignedCA
+
+	// ExternalCertDir, if set, is the directory holding an externally
+	// provided serving certificate (tls.crt) and private key (tls.key),
+	// e.g. a mounted cert-manager Secret. The certificate is reloaded when
+	// it changes instead of being minted, and the CABundle is left to the
+	// external provider, so the manager must not be started.
+	ExternalCertDir string
+
+	// CABundleTargets are additional webhook configurations whose CABundle
+	// is kept in sync with that of WebhookName.
+	CABundleTargets []CABundleTarget
+
+	// MutatingWebhookClient is used to keep the CABundle of the mutating
+	// webhook configurations in CABundleTargets in sync. It is required if
+	// there are any.
+	MutatingWebhookClient MutatingWebhookClient
+
+	// CRDClient is used to keep the CABundle of the conversion webhook of
+	// the custom resource definitions in CABundleTargets in sync. It is
+	// required if there are any.
+	CRDClient CustomResourceDefinitionClient
+
+	// WebhookSettings, if set, are enforced on every webhook of the
+	// webhook configuration, reverting any drift.
+	WebhookSettings *WebhookSettings
+
+	// PerWebhookSettings, keyed by webhook name, override WebhookSettings
+	// for individual webhooks.
+	PerWebhookSettings map[string]WebhookSettings
 }
 
 type Manager struct {
 	config Config
 
-	mtx       sync.RWMutex
-	rotatedAt time.Time
-	expiresAt time.Time
-	dnsNames  []string
-	caBundle  []byte
+	mtx         sync.RWMutex
+	rotatedAt   time.Time
+	expiresAt   time.Time
+	dnsNames    []string
+	caBundle    []byte
+	certificate *tls.Certificate
+
+	external externalCertificate
+
+	// untrustedCABundle is the CABundle that did not trust a freshly minted
+	// certificate, which keeps the certificate from being rotated over and
+	// over until the CABundle changes. It is only accessed by Init and then
+	// by the CertificateRotator, so it is not guarded by the mutex.
+	untrustedCABundle []byte
 }
 
 func New(config Config) *Manager {
 	if config.Clock == nil {
 		config.Clock = clock.RealClock{}
 	}
+	if config.SVIDTTL == 0 {
+		config.SVIDTTL = DefaultSVIDTTL
+	}
 	return &Manager{
 		config: config,
 	}
 }
 
+// Init mints, or loads if externally provided, the initial webhook serving
+// certificate so that the webhook server can be started.
 func (m *Manager) Init(ctx context.Context) error {
 	ctx = withLogName(ctx, "webhook-manager")
 
-	if err := m.refreshBundle(ctx); err != nil {
-		return fmt.Errorf("failed to refresh bundle: %w", err)
-	}
-
 	webhookConfig, err := m.config.WebhookClient.Get(ctx, m.config.WebhookName, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to obtain webhook config: %w", err)
@@ -98,28 +152,74 @@ func (m *Manager) Init(ctx context.Context) error {
 		return fmt.Errorf("failed to populate temporary cache: %w", err)
 	}
 
+	if m.config.ExternalCertDir != "" {
+		return m.loadExternalCertificateIfNeeded(ctx, tempStore)
+	}
 	if err := m.mintX509SVIDIfNeeded(ctx, tempStore); err != nil {
 		return fmt.Errorf("failed to mint SVID: %w", err)
 	}
 
-	if err := m.updateWebhookConfigIfNeeded(ctx, tempStore); err != nil {
-		return fmt.Errorf("failed to updated webhook config: %w", err)
-	}
-
 	return nil
 }
 
+// GetCertificate returns the current webhook serving certificate. It is
+// meant to be used as the GetCertificate callback of the webhook server TLS
+// configuration, so that the certificate and private key are only held in
+// memory.
+func (m *Manager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	if m.certificate == nil {
+		return nil, errors.New("webhook certificate has not been minted")
+	}
+	return m.certificate, nil
+}
+
+// Start keeps the CABundle in the webhook configuration, and in the
+// configured CABundle targets, up to date with the SPIRE trust bundle, or the
+// self-signed CA if configured. It also reverts drift from the configured
+// webhook settings. The webhook configurations are shared by all
+// replicas, so they are only updated by the leader. The serving certificate is rotated by the
+// CertificateRotator.
 func (m *Manager) Start(ctx context.Context) error {
 	ctx = withLogName(ctx, "webhook-manager")
 
 	log := log.FromContext(ctx)
 
-	store, webhookChangedCh, cleanup := startInformer(ctx, m.config)
+	store, webhookChangedCh, cleanup := startInformer(ctx, m.validatingListWatch(ctx), &admissionregistrationv1.ValidatingWebhookConfiguration{}, func(obj metav1.Object) bool {
+		return obj.GetName() == m.config.WebhookName || m.isCABundleTarget(ValidatingWebhookKind, obj)
+	})
 	defer cleanup()
 
-	// Check every second if the SVID has expired or needs to change and
-	// backoff up to a minute on failures to mint.
-	svidTimer := newBackoffTimer(m.config.Clock, time.Second, time.Minute)
+	// The mutating webhook configurations are only watched if targeted. A
+	// nil channel never fires.
+	var mutatingStore cache.Store
+	var mutatingChangedCh chan struct{}
+	if m.hasCABundleTargets(MutatingWebhookKind) {
+		var mutatingCleanup func()
+		mutatingStore, mutatingChangedCh, mutatingCleanup = startInformer(ctx, m.mutatingListWatch(ctx), &admissionregistrationv1.MutatingWebhookConfiguration{}, func(obj metav1.Object) bool {
+			return m.isCABundleTarget(MutatingWebhookKind, obj)
+		})
+		defer mutatingCleanup()
+	}
+
+	// Likewise for the custom resource definitions.
+	var crdStore cache.Store
+	var crdChangedCh chan struct{}
+	if m.hasCABundleTargets(CustomResourceDefinitionKind) {
+		var crdCleanup func()
+		crdStore, crdChangedCh, crdCleanup = startInformer(ctx, m.crdListWatch(ctx), &apiextensionsv1.CustomResourceDefinition{}, func(obj metav1.Object) bool {
+			return m.isCABundleTarget(CustomResourceDefinitionKind, obj)
+		})
+		defer crdCleanup()
+	}
+
+	updateWebhookConfigs := func() error {
+		return errors.Join(
+			m.updateWebhookConfigIfNeeded(ctx, store),
+			m.updateCABundleTargetsIfNeeded(ctx, store, mutatingStore, crdStore),
+		)
+	}
 
 	// Refresh the bundle every 5 seconds, and back off up to a minute
 	// on failure.
@@ -132,33 +232,38 @@ func (m *Manager) Start(ctx context.Context) error {
 
 	for {
 		select {
-		case <-svidTimer.C():
-			if err := m.mintX509SVIDIfNeeded(ctx, store); err != nil {
-				log.Error(err, "Failed to mint X509-SVID")
-				svidTimer.BackOff()
-			} else {
-				svidTimer.Reset()
-			}
 		case <-bundleTimer.C():
 			if err := m.refreshBundle(ctx); err != nil {
 				log.Error(err, "Failed to refresh bundle")
 				bundleTimer.BackOff()
 			} else {
 				bundleTimer.Reset()
-				if err := m.updateWebhookConfigIfNeeded(ctx, store); err != nil {
+				if err := updateWebhookConfigs(); err != nil {
 					log.Error(err, "Failed to update webhook config if needed")
 				}
 				webhookTimer.Reset()
 			}
 		case <-webhookTimer.C():
-			if err := m.updateWebhookConfigIfNeeded(ctx, store); err != nil {
+			if err := updateWebhookConfigs(); err != nil {
 				log.Error(err, "Failed to update webhook config if needed")
 				webhookTimer.BackOff()
 			} else {
 				webhookTimer.Reset()
 			}
 		case <-webhookChangedCh:
-			if err := m.updateWebhookConfigIfNeeded(ctx, store); err != nil {
+			if err := updateWebhookConfigs(); err != nil {
+				log.Error(err, "Failed to update webhook config if needed")
+			}
+			// Whether we succeed or fail here, reset the webhook timer.
+			webhookTimer.Reset()
+		case <-mutatingChangedCh:
+			if err := updateWebhookConfigs(); err != nil {
+				log.Error(err, "Failed to update webhook config if needed")
+			}
+			// Whether we succeed or fail here, reset the webhook timer.
+			webhookTimer.Reset()
+		case <-crdChangedCh:
+			if err := updateWebhookConfigs(); err != nil {
 				log.Error(err, "Failed to update webhook config if needed")
 			}
 			// Whether we succeed or fail here, reset the webhook timer.
@@ -169,12 +274,67 @@ func (m *Manager) Start(ctx context.Context) error {
 	}
 }
 
+// CertificateRotator returns a runnable that rotates the webhook serving
+// certificate.
+func (m *Manager) CertificateRotator() *CertificateRotator {
+	return &CertificateRotator{m: m}
+}
+
+// CertificateRotator rotates the webhook serving certificate. Every replica
+// serves the webhook using its own certificate, so it runs regardless of
+// leader election.
+type CertificateRotator struct {
+	m *Manager
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (r *CertificateRotator) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *CertificateRotator) Start(ctx context.Context) error {
+	ctx = withLogName(ctx, "webhook-certificate-rotator")
+
+	log := log.FromContext(ctx)
+
+	store, _, cleanup := startInformer(ctx, r.m.validatingListWatch(ctx), &admissionregistrationv1.ValidatingWebhookConfiguration{}, func(obj metav1.Object) bool {
+		return obj.GetName() == r.m.config.WebhookName
+	})
+	defer cleanup()
+
+	// Check every second if the SVID has expired or needs to change and
+	// backoff up to a minute on failures to mint. An externally provided
+	// certificate is instead reloaded when it changes.
+	svidTimer := newBackoffTimer(r.m.config.Clock, time.Second, time.Minute)
+
+	rotate, failureMsg := r.m.mintX509SVIDIfNeeded, "Failed to mint X509-SVID"
+	if r.m.config.ExternalCertDir != "" {
+		rotate, failureMsg = r.m.loadExternalCertificateIfNeeded, "Failed to load external webhook certificate"
+	}
+
+	for {
+		select {
+		case <-svidTimer.C():
+			if err := rotate(ctx, store); err != nil {
+				log.Error(err, failureMsg)
+				svidTimer.BackOff()
+			} else {
+				svidTimer.Reset()
+			}
+			r.m.observeExpiry()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 func (m *Manager) mintX509SVIDIfNeeded(ctx context.Context, store cache.Store) error {
 	log := log.FromContext(ctx)
 
 	m.mtx.RLock()
 	rotatedAt, expiresAt := m.rotatedAt, m.expiresAt
 	currentDNSNames := m.dnsNames
+	certificate := m.certificate
 	m.mtx.RUnlock()
 
 	webhookConfig, exists, err := getWebhookConfigFromStore(store, m.config.WebhookName)
@@ -186,6 +346,7 @@ func (m *Manager) mintX509SVIDIfNeeded(ctx context.Context, store cache.Store) e
 	}
 
 	dnsNames := webhookDNSNames(webhookConfig)
+	caBundle := webhookCABundle(webhookConfig)
 
 	var lifetime time.Duration
 	var expiresIn time.Duration
@@ -198,18 +359,59 @@ func (m *Manager) mintX509SVIDIfNeeded(ctx context.Context, store cache.Store) e
 	switch {
 	case lifetime == 0:
 		reason = "initializing"
-	case expiresSoon(lifetime, expiresIn):
+	case m.expiresSoon(lifetime, expiresIn):
 		reason = "expires soon"
 	case expiresIn < 0:
 		reason = "has expired"
 	case !dnsNamesEqual(dnsNames, currentDNSNames):
 		reason = "stale DNS names"
+	case len(caBundle) > 0 && !bytes.Equal(caBundle, m.untrustedCABundle) &&
+		verifyCertificate(certificate, caBundle, m.config.Clock.Now()) != nil:
+		// The CA has changed underneath the certificate, e.g. SPIRE Server
+		// was rebuilt with a new CA or the self-signed CA was configured.
+		reason = "not trusted by CABundle"
 	default:
 		return nil
 	}
 
 	log.Info("Minting webhook certificate", "reason", reason, "dnsNames", dnsNames)
-	return m.mintX509SVID(ctx, dnsNames)
+	if err := m.mintX509SVID(ctx, dnsNames); err != nil {
+		return err
+	}
+
+	m.untrustedCABundle = nil
+	if len(caBundle) > 0 {
+		m.mtx.RLock()
+		certificate = m.certificate
+		m.mtx.RUnlock()
+		if err := verifyCertificate(certificate, caBundle, m.config.Clock.Now()); err != nil {
+			// The CABundle has not caught up yet. It is updated by the
+			// leader shortly after the CA rotates.
+			log.Info("Minted webhook certificate is not yet trusted by the CABundle", "reason", err.Error())
+			m.untrustedCABundle = caBundle
+		}
+	}
+	return nil
+}
+
+// expiresSoon returns true if the certificate should be rotated ahead of
+// expiring.
+func (m *Manager) expiresSoon(lifetime, expiresIn time.Duration) bool {
+	if m.config.RotationThreshold > 0 && m.config.RotationThreshold < lifetime {
+		return expiresIn < m.config.RotationThreshold
+	}
+	return expiresSoon(lifetime, expiresIn)
+}
+
+// observeExpiry records the time until the webhook serving certificate
+// expires.
+func (m *Manager) observeExpiry() {
+	m.mtx.RLock()
+	expiresAt := m.expiresAt
+	m.mtx.RUnlock()
+	if !expiresAt.IsZero() {
+		metrics.WebhookCertificateExpiresIn.Set(expiresAt.Sub(m.config.Clock.Now()).Seconds())
+	}
 }
 
 func (m *Manager) mintX509SVID(ctx context.Context, dnsNames []string) error {
@@ -218,28 +420,35 @@ func (m *Manager) mintX509SVID(ctx context.Context, dnsNames []string) error {
 		return fmt.Errorf("failed to generate X509-SVID private key: %w", err)
 	}
 
-	svid, err := m.config.SVIDClient.MintX509SVID(ctx, spireapi.X509SVIDParams{
+	var mintX509SVID func(context.Context, spireapi.X509SVIDParams) (*spireapi.X509SVID, error)
+	if m.config.SelfSignedCA != nil {
+		mintX509SVID = m.config.SelfSignedCA.MintX509SVID
+	} else {
+		mintX509SVID = m.config.SVIDClient.MintX509SVID
+	}
+
+	svid, err := mintX509SVID(ctx, spireapi.X509SVIDParams{
 		Key:      key,
 		ID:       m.config.ID,
 		DNSNames: dnsNames,
-		TTL:      x509SVIDTTL,
+		TTL:      m.config.SVIDTTL,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to mint webhook certificate: %w", err)
 	}
 
-	data, err := marshalSVID(svid)
-	if err != nil {
-		return fmt.Errorf("failed to serialize webhook keypair: %w", err)
+	certificate := &tls.Certificate{
+		PrivateKey: svid.Key,
+		Leaf:       svid.CertChain[0],
 	}
-
-	if err := os.WriteFile(m.config.KeyPairPath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write webhook keypair: %w", err)
+	for _, cert := range svid.CertChain {
+		certificate.Certificate = append(certificate.Certificate, cert.Raw)
 	}
 
 	log.FromContext(ctx).Info("Minted webhook certificate")
 
 	m.mtx.Lock()
+	m.certificate = certificate
 	m.rotatedAt = m.config.Clock.Now()
 	m.expiresAt = svid.ExpiresAt
 	m.dnsNames = dnsNames
@@ -247,6 +456,15 @@ func (m *Manager) mintX509SVID(ctx context.Context, dnsNames []string) error {
 	return nil
 }
 
+// CABundle returns the PEM encoded CA bundle patched into the webhook
+// configuration. It is empty until the bundle has been fetched, and always
+// when the certificate is externally provided.
+func (m *Manager) CABundle() []byte {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	return m.caBundle
+}
+
 func (m *Manager) updateWebhookConfigIfNeeded(ctx context.Context, store cache.Store) error {
 	m.mtx.RLock()
 	caBundle := m.caBundle
@@ -260,31 +478,17 @@ func (m *Manager) updateWebhookConfigIfNeeded(ctx context.Context, store cache.S
 		return nil
 	}
 
-	var modified *admissionregistrationv1.ValidatingWebhookConfiguration
-	for i, webhook := range current.Webhooks {
-		if bytes.Equal(webhook.ClientConfig.CABundle, caBundle) {
-			continue
-		}
-		if modified == nil {
-			modified = current.DeepCopy()
-		}
-		modified.Webhooks[i].ClientConfig.CABundle = caBundle
-	}
-
-	if modified != nil {
-		data, err := client.StrategicMergeFrom(current).Data(modified)
-		if err != nil {
-			return fmt.Errorf("failed to create webhook configuration patch: %w", err)
-		}
-		if _, err := m.config.WebhookClient.Patch(ctx, m.config.WebhookName, types.StrategicMergePatchType, data, metav1.PatchOptions{}); err != nil {
-			return fmt.Errorf("failed to patch webhook configuration: %w", err)
-		}
-		log.FromContext(ctx).Info("Webhook configuration patched with CABundle")
-	}
-	return nil
+	return m.patchValidatingWebhookConfigIfNeeded(ctx, current, caBundle)
 }
 
 func (m *Manager) refreshBundle(ctx context.Context) error {
+	if m.config.SelfSignedCA != nil {
+		m.mtx.Lock()
+		m.caBundle = marshalX509Authorities(m.config.SelfSignedCA.X509Authorities())
+		m.mtx.Unlock()
+		return nil
+	}
+
 	bundle, err := m.config.BundleClient.GetBundle(ctx)
 	if err != nil {
 		return err
@@ -302,23 +506,6 @@ func marshalX509Authorities(x509Authorities []*x509.Certificate) []byte {
 	return buf.Bytes()
 }
 
-func marshalSVID(svid *spireapi.X509SVID) ([]byte, error) {
-	buf := new(bytes.Buffer)
-	_ = encodeCertificates(buf, svid.CertChain)
-
-	keyBytes, err := x509.MarshalPKCS8PrivateKey(svid.Key)
-	if err != nil {
-		return nil, err
-	}
-
-	_ = pem.Encode(buf, &pem.Block{
-		Type:  "PRIVATE KEY",
-		Bytes: keyBytes,
-	})
-
-	return buf.Bytes(), nil
-}
-
 func encodeCertificates(w io.Writer, certs []*x509.Certificate) error {
 	for _, cert := range certs {
 		if err := pem.Encode(w, &pem.Block{
@@ -343,6 +530,17 @@ func serviceDNSName(service *admissionregistrationv1.ServiceReference) (string,
 	return fmt.Sprintf("%s.%s.svc", service.Name, service.Namespace), true
 }
 
+// webhookCABundle returns the first CABundle set on the webhooks of the
+// configuration. The manager keeps the CABundle of every webhook the same.
+func webhookCABundle(webhookConfig *admissionregistrationv1.ValidatingWebhookConfiguration) []byte {
+	for _, webhook := range webhookConfig.Webhooks {
+		if len(webhook.ClientConfig.CABundle) > 0 {
+			return webhook.ClientConfig.CABundle
+		}
+	}
+	return nil
+}
+
 func webhookDNSNames(webhookConfig *admissionregistrationv1.ValidatingWebhookConfiguration) []string {
 	dnsNamesSet := make(map[string]struct{})
 	for _, webhook := range webhookConfig.Webhooks {
@@ -372,7 +570,42 @@ func dnsNamesEqual(a, b []string) bool {
 	return true
 }
 
-func startInformer(ctx context.Context, config Config) (cache.Store, chan struct{}, func()) {
+func (m *Manager) validatingListWatch(ctx context.Context) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return m.config.WebhookClient.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return m.config.WebhookClient.Watch(ctx, options)
+		},
+	}
+}
+
+func (m *Manager) mutatingListWatch(ctx context.Context) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return m.config.MutatingWebhookClient.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return m.config.MutatingWebhookClient.Watch(ctx, options)
+		},
+	}
+}
+
+func (m *Manager) crdListWatch(ctx context.Context) cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return m.config.CRDClient.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return m.config.CRDClient.Watch(ctx, options)
+		},
+	}
+}
+
+// startInformer caches the webhook configurations listed by lw. The returned
+// channel is notified when a configuration that passes the filter changes.
+func startInformer(ctx context.Context, lw cache.ListerWatcher, objType runtime.Object, filter func(metav1.Object) bool) (cache.Store, chan struct{}, func()) {
 	ch := make(chan struct{}, 1)
 
 	notify := func() {
@@ -384,20 +617,13 @@ func startInformer(ctx context.Context, config Config) (cache.Store, chan struct
 
 	log := log.FromContext(ctx)
 	store, controller := cache.NewInformer(
-		&cache.ListWatch{
-			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-				return config.WebhookClient.List(ctx, options)
-			},
-			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-				return config.WebhookClient.Watch(ctx, options)
-			},
-		},
-		&admissionregistrationv1.ValidatingWebhookConfiguration{},
+		lw,
+		objType,
 		time.Hour,
 		cache.FilteringResourceEventHandler{
 			FilterFunc: func(obj interface{}) bool {
-				o, ok := obj.(*admissionregistrationv1.ValidatingWebhookConfiguration)
-				return ok && o.Name == config.WebhookName
+				o, ok := obj.(metav1.Object)
+				return ok && filter(o)
 			},
 			Handler: cache.ResourceEventHandlerFuncs{
 				AddFunc: func(obj interface{}) {
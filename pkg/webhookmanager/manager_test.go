@@ -0,0 +1,110 @@
+package webhookmanager
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestManagerGetCertificate(t *testing.T) {
+	ctx := context.Background()
+	ca, err := LoadSelfSignedCA(ctx, SelfSignedCAConfig{
+		SecretClient: fake.NewSimpleClientset().CoreV1().Secrets("spire-system"),
+		SecretName:   "webhook-ca",
+	})
+	require.NoError(t, err)
+
+	m := New(Config{
+		ID:           spiffeid.RequireFromString("spiffe://example.org/spire-controller-manager-webhook"),
+		SelfSignedCA: ca,
+	})
+
+	_, err = m.GetCertificate(nil)
+	require.EqualError(t, err, "webhook certificate has not been minted")
+
+	dnsNames := []string{"webhook.spire-system.svc"}
+	require.NoError(t, m.mintX509SVID(ctx, dnsNames))
+
+	certificate, err := m.GetCertificate(nil)
+	require.NoError(t, err)
+	require.NotNil(t, certificate.Leaf)
+	assert.Equal(t, dnsNames, certificate.Leaf.DNSNames)
+	assert.Equal(t, certificate.Leaf.Raw, certificate.Certificate[0])
+	assert.Equal(t, certificate.Leaf.PublicKey, certificate.PrivateKey.(crypto.Signer).Public())
+}
+
+func TestManagerMintX509SVIDIfNeeded(t *testing.T) {
+	ctx := context.Background()
+	clk := clocktesting.NewFakeClock(time.Now())
+	secretClient := fake.NewSimpleClientset().CoreV1().Secrets("spire-system")
+	ca, err := LoadSelfSignedCA(ctx, SelfSignedCAConfig{SecretClient: secretClient, SecretName: "webhook-ca", Clock: clk})
+	require.NoError(t, err)
+	otherCA, err := LoadSelfSignedCA(ctx, SelfSignedCAConfig{SecretClient: secretClient, SecretName: "other-ca", Clock: clk})
+	require.NoError(t, err)
+
+	m := New(Config{
+		ID:                spiffeid.RequireFromString("spiffe://example.org/spire-controller-manager-webhook"),
+		WebhookName:       "webhook",
+		SelfSignedCA:      ca,
+		Clock:             clk,
+		SVIDTTL:           4 * time.Hour,
+		RotationThreshold: time.Hour,
+	})
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	setCABundle := func(caBundle []byte) {
+		require.NoError(t, store.Update(&admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "webhook"},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+				Name: "webhook.test",
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service:  &admissionregistrationv1.ServiceReference{Namespace: "spire-system", Name: "webhook"},
+					CABundle: caBundle,
+				},
+			}},
+		}))
+	}
+	setCABundle(marshalX509Authorities(ca.X509Authorities()))
+
+	var last *tls.Certificate
+	requireMinted := func(expected bool) {
+		require.NoError(t, m.mintX509SVIDIfNeeded(ctx, store))
+		certificate, err := m.GetCertificate(nil)
+		require.NoError(t, err)
+		if expected {
+			require.NotSame(t, last, certificate, "expected a new certificate")
+		} else {
+			require.Same(t, last, certificate, "expected the same certificate")
+		}
+		last = certificate
+	}
+
+	// The certificate is minted with the configured lifetime.
+	requireMinted(true)
+	assert.WithinDuration(t, clk.Now().Add(4*time.Hour), last.Leaf.NotAfter, time.Second)
+	requireMinted(false)
+
+	// The certificate is rotated once it expires within the threshold.
+	clk.Step(3*time.Hour - time.Minute)
+	requireMinted(false)
+	clk.Step(2 * time.Minute)
+	requireMinted(true)
+
+	// The certificate is rotated when the CABundle no longer trusts it, but
+	// only once while the CABundle does not trust the new certificate
+	// either.
+	setCABundle(marshalX509Authorities(otherCA.X509Authorities()))
+	requireMinted(true)
+	requireMinted(false)
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhookmanager
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	selfSignedCATTL        = time.Hour * 24 * 365 * 10
+	selfSignedCACommonName = "spire-controller-manager-webhook-ca"
+
+	// backdate is how far the certificates are backdated to tolerate clock
+	// skew with the API server.
+	backdate = time.Minute
+)
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;create
+
+type SelfSignedCAConfig struct {
+	SecretClient corev1client.SecretInterface
+	SecretName   string
+	Clock        clock.Clock
+}
+
+// SelfSignedCA is a long-lived self-signed CA that signs the webhook serving
+// certificate instead of SPIRE Server. The CA is stored in a Secret so that
+// it is shared by all replicas and survives restarts.
+type SelfSignedCA struct {
+	clock clock.Clock
+	cert  *x509.Certificate
+	key   crypto.Signer
+}
+
+// LoadSelfSignedCA loads the CA from the Secret, generating the CA and
+// creating the Secret if it does not exist yet.
+func LoadSelfSignedCA(ctx context.Context, config SelfSignedCAConfig) (*SelfSignedCA, error) {
+	if config.Clock == nil {
+		config.Clock = clock.RealClock{}
+	}
+
+	secret, err := config.SecretClient.Get(ctx, config.SecretName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		secret, err = createSelfSignedCASecret(ctx, config)
+		if apierrors.IsAlreadyExists(err) {
+			// Another replica won the race to create the CA. Use theirs.
+			secret, err = config.SecretClient.Get(ctx, config.SecretName, metav1.GetOptions{})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CA secret: %w", err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to get CA secret: %w", err)
+	}
+
+	cert, key, err := parseCASecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+	if !config.Clock.Now().Before(cert.NotAfter) {
+		return nil, fmt.Errorf("CA in secret %s/%s has expired; delete the secret to generate a new CA", secret.Namespace, secret.Name)
+	}
+
+	return &SelfSignedCA{
+		clock: config.Clock,
+		cert:  cert,
+		key:   key,
+	}, nil
+}
+
+// X509Authorities returns the CA certificate.
+func (ca *SelfSignedCA) X509Authorities() []*x509.Certificate {
+	return []*x509.Certificate{ca.cert}
+}
+
+// MintX509SVID signs an X509-SVID with the CA. The lifetime of the X509-SVID
+// does not exceed the lifetime of the CA.
+func (ca *SelfSignedCA) MintX509SVID(_ context.Context, params spireapi.X509SVIDParams) (*spireapi.X509SVID, error) {
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := ca.clock.Now()
+	notAfter := now.Add(params.TTL)
+	if notAfter.After(ca.cert.NotAfter) {
+		notAfter = ca.cert.NotAfter
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		NotBefore:             now.Add(-backdate),
+		NotAfter:              notAfter,
+		URIs:                  []*url.URL{params.ID.URL()},
+		DNSNames:              params.DNSNames,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	if len(params.DNSNames) > 0 {
+		template.Subject.CommonName = params.DNSNames[0]
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca.cert, params.Key.Public(), ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign X509-SVID: %w", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse X509-SVID: %w", err)
+	}
+
+	return &spireapi.X509SVID{
+		ID:        params.ID,
+		Key:       params.Key,
+		CertChain: []*x509.Certificate{cert},
+		ExpiresAt: cert.NotAfter,
+	}, nil
+}
+
+func createSelfSignedCASecret(ctx context.Context, config SelfSignedCAConfig) (*corev1.Secret, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA private key: %w", err)
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := config.Clock.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: selfSignedCACommonName},
+		NotBefore:             now.Add(-backdate),
+		NotAfter:              now.Add(selfSignedCATTL),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to self-sign CA certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CA private key: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: config.SecretName,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+		},
+	}
+
+	secret, err = config.SecretClient.Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	log.FromContext(ctx).Info("Generated self-signed webhook CA", "secret", secret.Namespace+"/"+secret.Name)
+	return secret, nil
+}
+
+func parseCASecret(secret *corev1.Secret) (*x509.Certificate, crypto.Signer, error) {
+	certBlock, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if certBlock == nil || certBlock.Type != "CERTIFICATE" {
+		return nil, nil, fmt.Errorf("%s does not contain a PEM encoded certificate", corev1.TLSCertKey)
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	if !cert.IsCA {
+		return nil, nil, errors.New("certificate is not a CA")
+	}
+
+	keyBlock, _ := pem.Decode(secret.Data[corev1.TLSPrivateKeyKey])
+	if keyBlock == nil || keyBlock.Type != "PRIVATE KEY" {
+		return nil, nil, fmt.Errorf("%s does not contain a PEM encoded PKCS#8 private key", corev1.TLSPrivateKeyKey)
+	}
+	rawKey, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	key, ok := rawKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported private key type %T", rawKey)
+	}
+	if !publicKeyEqual(cert.PublicKey, key.Public()) {
+		return nil, nil, errors.New("private key does not match certificate")
+	}
+	return cert, key, nil
+}
+
+func publicKeyEqual(a, b crypto.PublicKey) bool {
+	aa, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && aa.Equal(b)
+}
+
+func newSerialNumber() (*big.Int, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serialNumber, nil
+}
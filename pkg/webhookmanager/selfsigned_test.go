@@ -0,0 +1,96 @@
+package webhookmanager
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-controller-manager/pkg/spireapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestLoadSelfSignedCA(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	config := SelfSignedCAConfig{
+		SecretClient: clientset.CoreV1().Secrets("spire-system"),
+		SecretName:   "webhook-ca",
+	}
+
+	ca, err := LoadSelfSignedCA(ctx, config)
+	require.NoError(t, err)
+
+	secret, err := config.SecretClient.Get(ctx, "webhook-ca", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+
+	t.Run("reuses the CA in the secret", func(t *testing.T) {
+		reloaded, err := LoadSelfSignedCA(ctx, config)
+		require.NoError(t, err)
+		assert.True(t, ca.cert.Equal(reloaded.cert))
+	})
+
+	t.Run("fails if the CA has expired", func(t *testing.T) {
+		config := config
+		config.Clock = clocktesting.NewFakeClock(time.Now().Add(selfSignedCATTL))
+		_, err := LoadSelfSignedCA(ctx, config)
+		require.ErrorContains(t, err, "has expired")
+	})
+
+	t.Run("fails if the secret is invalid", func(t *testing.T) {
+		_, err := clientset.CoreV1().Secrets("spire-system").Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+			Data:       map[string][]byte{corev1.TLSCertKey: []byte("garbage")},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+
+		config := config
+		config.SecretName = "invalid"
+		_, err = LoadSelfSignedCA(ctx, config)
+		require.ErrorContains(t, err, "invalid CA secret spire-system/invalid")
+	})
+}
+
+func TestSelfSignedCAMintX509SVID(t *testing.T) {
+	ctx := context.Background()
+	ca, err := LoadSelfSignedCA(ctx, SelfSignedCAConfig{
+		SecretClient: fake.NewSimpleClientset().CoreV1().Secrets("spire-system"),
+		SecretName:   "webhook-ca",
+	})
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	id := spiffeid.RequireFromString("spiffe://example.org/spire-controller-manager-webhook")
+
+	svid, err := ca.MintX509SVID(ctx, spireapi.X509SVIDParams{
+		Key:      key,
+		ID:       id,
+		DNSNames: []string{"webhook.spire-system.svc"},
+		TTL:      time.Hour,
+	})
+	require.NoError(t, err)
+	require.Len(t, svid.CertChain, 1)
+	assert.Equal(t, id, svid.ID)
+	assert.Equal(t, svid.CertChain[0].NotAfter, svid.ExpiresAt)
+
+	roots := x509.NewCertPool()
+	for _, authority := range ca.X509Authorities() {
+		roots.AddCert(authority)
+	}
+	_, err = svid.CertChain[0].Verify(x509.VerifyOptions{
+		DNSName: "webhook.spire-system.svc",
+		Roots:   roots,
+	})
+	require.NoError(t, err)
+}
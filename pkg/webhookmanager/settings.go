@@ -0,0 +1,158 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhookmanager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// WebhookSettings are the settings enforced on the webhooks of the webhook
+// configuration. Settings that are not set are left as is.
+type WebhookSettings struct {
+	FailurePolicy     *admissionregistrationv1.FailurePolicyType
+	NamespaceSelector *metav1.LabelSelector
+	ObjectSelector    *metav1.LabelSelector
+	Rules             []admissionregistrationv1.RuleWithOperations
+}
+
+// mergedWith returns the settings with those set in override taking
+// precedence.
+func (s WebhookSettings) mergedWith(override WebhookSettings) WebhookSettings {
+	if override.FailurePolicy != nil {
+		s.FailurePolicy = override.FailurePolicy
+	}
+	if override.NamespaceSelector != nil {
+		s.NamespaceSelector = override.NamespaceSelector
+	}
+	if override.ObjectSelector != nil {
+		s.ObjectSelector = override.ObjectSelector
+	}
+	if override.Rules != nil {
+		s.Rules = override.Rules
+	}
+	return s
+}
+
+// apply enforces the settings on the webhook and returns true if it has
+// drifted from them.
+func (s WebhookSettings) apply(webhook *admissionregistrationv1.ValidatingWebhook) bool {
+	drifted := false
+	if s.FailurePolicy != nil && (webhook.FailurePolicy == nil || *webhook.FailurePolicy != *s.FailurePolicy) {
+		failurePolicy := *s.FailurePolicy
+		webhook.FailurePolicy = &failurePolicy
+		drifted = true
+	}
+	if s.NamespaceSelector != nil && !apiequality.Semantic.DeepEqual(webhook.NamespaceSelector, s.NamespaceSelector) {
+		webhook.NamespaceSelector = s.NamespaceSelector.DeepCopy()
+		drifted = true
+	}
+	if s.ObjectSelector != nil && !apiequality.Semantic.DeepEqual(webhook.ObjectSelector, s.ObjectSelector) {
+		webhook.ObjectSelector = s.ObjectSelector.DeepCopy()
+		drifted = true
+	}
+	if s.Rules != nil {
+		rules := defaultRules(s.Rules)
+		if !apiequality.Semantic.DeepEqual(webhook.Rules, rules) {
+			webhook.Rules = rules
+			drifted = true
+		}
+	}
+	return drifted
+}
+
+// defaultRules returns a copy of the rules with the scope defaulted the same
+// way the API server does, so that defaulted rules are not seen as drift.
+func defaultRules(rules []admissionregistrationv1.RuleWithOperations) []admissionregistrationv1.RuleWithOperations {
+	out := make([]admissionregistrationv1.RuleWithOperations, 0, len(rules))
+	for _, rule := range rules {
+		rule = *rule.DeepCopy()
+		if rule.Scope == nil {
+			scope := admissionregistrationv1.AllScopes
+			rule.Scope = &scope
+		}
+		out = append(out, rule)
+	}
+	return out
+}
+
+func (m *Manager) hasWebhookSettings() bool {
+	return m.config.WebhookSettings != nil || len(m.config.PerWebhookSettings) > 0
+}
+
+// webhookSettings returns the settings enforced on the webhook with the
+// given name.
+func (m *Manager) webhookSettings(name string) WebhookSettings {
+	var settings WebhookSettings
+	if m.config.WebhookSettings != nil {
+		settings = *m.config.WebhookSettings
+	}
+	if override, ok := m.config.PerWebhookSettings[name]; ok {
+		settings = settings.mergedWith(override)
+	}
+	return settings
+}
+
+// patchValidatingWebhookConfigIfNeeded patches the CABundle and the
+// configured settings of the webhooks in the webhook configuration of the
+// manager when they are out of date, reverting any drift.
+func (m *Manager) patchValidatingWebhookConfigIfNeeded(ctx context.Context, current *admissionregistrationv1.ValidatingWebhookConfiguration, caBundle []byte) error {
+	if !m.hasWebhookSettings() {
+		return m.patchValidatingCABundleIfNeeded(ctx, current, caBundle)
+	}
+
+	modified := current.DeepCopy()
+	caBundleChanged := false
+	var drifted []string
+	for i := range modified.Webhooks {
+		webhook := &modified.Webhooks[i]
+		if !bytes.Equal(webhook.ClientConfig.CABundle, caBundle) {
+			webhook.ClientConfig.CABundle = caBundle
+			caBundleChanged = true
+		}
+		if m.webhookSettings(webhook.Name).apply(webhook) {
+			drifted = append(drifted, webhook.Name)
+		}
+	}
+	if !caBundleChanged && len(drifted) == 0 {
+		return nil
+	}
+
+	data, err := client.StrategicMergeFrom(current).Data(modified)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook configuration patch: %w", err)
+	}
+	if _, err := m.config.WebhookClient.Patch(ctx, current.Name, types.StrategicMergePatchType, data, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch webhook configuration %q: %w", current.Name, err)
+	}
+	log := log.FromContext(ctx)
+	if caBundleChanged {
+		log.Info("Webhook configuration patched with CABundle", "kind", ValidatingWebhookKind, "name", current.Name)
+	}
+	if len(drifted) > 0 {
+		log.Info("Webhook configuration patched with webhook settings", "kind", ValidatingWebhookKind, "name", current.Name, "webhooks", drifted)
+	}
+	return nil
+}
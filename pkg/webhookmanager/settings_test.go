@@ -0,0 +1,127 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhookmanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestUpdateWebhookConfigIfNeededRevertsSettingsDrift(t *testing.T) {
+	ctx := context.Background()
+
+	ignore := admissionregistrationv1.Ignore
+	fail := admissionregistrationv1.Fail
+	clusterScope := admissionregistrationv1.ClusterScope
+	rule := func(resource string) admissionregistrationv1.RuleWithOperations {
+		return admissionregistrationv1.RuleWithOperations{
+			Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{"spire.spiffe.io"},
+				APIVersions: []string{"v1alpha1"},
+				Resources:   []string{resource},
+			},
+		}
+	}
+	webhook := func(name string) admissionregistrationv1.ValidatingWebhook {
+		return admissionregistrationv1.ValidatingWebhook{
+			Name:          name,
+			FailurePolicy: &ignore,
+			ClientConfig:  admissionregistrationv1.WebhookClientConfig{CABundle: []byte("bundle")},
+			Rules:         []admissionregistrationv1.RuleWithOperations{rule("other")},
+		}
+	}
+
+	clientset := fake.NewSimpleClientset()
+	webhookClient := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	sync := func() {
+		current, err := webhookClient.Get(ctx, "webhook", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.NoError(t, store.Update(current))
+	}
+
+	current := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook"},
+		Webhooks:   []admissionregistrationv1.ValidatingWebhook{webhook("a.test"), webhook("b.test")},
+	}
+	_, err := webhookClient.Create(ctx, current, metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, store.Add(current))
+
+	namespaceSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"spiffe": "enabled"}}
+	m := New(Config{
+		WebhookName:   "webhook",
+		WebhookClient: webhookClient,
+		WebhookSettings: &WebhookSettings{
+			FailurePolicy:     &fail,
+			NamespaceSelector: namespaceSelector,
+		},
+		PerWebhookSettings: map[string]WebhookSettings{
+			"b.test": {
+				FailurePolicy: &ignore,
+				Rules:         []admissionregistrationv1.RuleWithOperations{rule("clusterspiffeids")},
+			},
+		},
+	})
+	m.caBundle = []byte("bundle")
+
+	requireSettings := func() {
+		t.Helper()
+		updated, err := webhookClient.Get(ctx, "webhook", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Len(t, updated.Webhooks, 2)
+
+		a, b := updated.Webhooks[0], updated.Webhooks[1]
+		require.Equal(t, fail, *a.FailurePolicy)
+		require.Equal(t, namespaceSelector, a.NamespaceSelector)
+		require.Equal(t, []admissionregistrationv1.RuleWithOperations{rule("other")}, a.Rules, "unmanaged rules are left as is")
+		require.Equal(t, ignore, *b.FailurePolicy)
+		require.Equal(t, namespaceSelector, b.NamespaceSelector)
+		expectedRule := rule("clusterspiffeids")
+		expectedRule.Scope = new(admissionregistrationv1.ScopeType)
+		*expectedRule.Scope = admissionregistrationv1.AllScopes
+		require.Equal(t, []admissionregistrationv1.RuleWithOperations{expectedRule}, b.Rules)
+		require.Equal(t, "bundle", string(a.ClientConfig.CABundle))
+		require.Equal(t, "bundle", string(b.ClientConfig.CABundle))
+	}
+
+	require.NoError(t, m.updateWebhookConfigIfNeeded(ctx, store))
+	requireSettings()
+
+	// Once in sync, the webhook configuration is not patched again.
+	sync()
+	clientset.ClearActions()
+	require.NoError(t, m.updateWebhookConfigIfNeeded(ctx, store))
+	require.Empty(t, clientset.Actions())
+
+	// Drift is reverted.
+	drifted := current.DeepCopy()
+	drifted.Webhooks[0].FailurePolicy = &ignore
+	drifted.Webhooks[1].Rules[0].Scope = &clusterScope
+	_, err = webhookClient.Update(ctx, drifted, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	sync()
+	require.NoError(t, m.updateWebhookConfigIfNeeded(ctx, store))
+	requireSettings()
+}
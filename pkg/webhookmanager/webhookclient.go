@@ -0,0 +1,150 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhookmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	admissionregistrationapiv1beta1 "k8s.io/client-go/kubernetes/typed/admissionregistration/v1beta1"
+)
+
+// WebhookClient is the subset of the ValidatingWebhookConfiguration API used
+// by the manager. The admissionregistration.k8s.io/v1 typed client satisfies
+// this interface. Use NewV1Beta1WebhookClient to adapt the v1beta1 typed
+// client for clusters that do not serve v1.
+type WebhookClient interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*admissionregistrationv1.ValidatingWebhookConfiguration, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*admissionregistrationv1.ValidatingWebhookConfigurationList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*admissionregistrationv1.ValidatingWebhookConfiguration, error)
+}
+
+// WebhookAPIVersion returns the most preferred admissionregistration.k8s.io
+// group version served by the API server that the manager supports. The v1
+// version is preferred. The v1beta1 version is only used if v1 is not served.
+func WebhookAPIVersion(discoveryClient discovery.DiscoveryInterface) (string, error) {
+	for _, groupVersion := range []string{
+		admissionregistrationv1.SchemeGroupVersion.String(),
+		admissionregistrationv1beta1.SchemeGroupVersion.String(),
+	} {
+		resources, err := discoveryClient.ServerResourcesForGroupVersion(groupVersion)
+		switch {
+		case apierrors.IsNotFound(err):
+			continue
+		case err != nil:
+			return "", fmt.Errorf("failed to discover %s resources: %w", groupVersion, err)
+		}
+		for _, resource := range resources.APIResources {
+			if resource.Name == "validatingwebhookconfigurations" {
+				return groupVersion, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("API server does not serve validating webhook configurations in a supported version")
+}
+
+// NewV1Beta1WebhookClient adapts the admissionregistration.k8s.io/v1beta1
+// typed client to the WebhookClient interface. Objects are converted to v1 on
+// read. Patches are passed through unchanged since the fields patched by the
+// manager are identical between versions.
+func NewV1Beta1WebhookClient(client admissionregistrationapiv1beta1.ValidatingWebhookConfigurationInterface) WebhookClient {
+	return v1beta1WebhookClient{client: client}
+}
+
+type v1beta1WebhookClient struct {
+	client admissionregistrationapiv1beta1.ValidatingWebhookConfigurationInterface
+}
+
+func (c v1beta1WebhookClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*admissionregistrationv1.ValidatingWebhookConfiguration, error) {
+	webhookConfig, err := c.client.Get(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return convertV1Beta1WebhookConfig(webhookConfig)
+}
+
+func (c v1beta1WebhookClient) List(ctx context.Context, opts metav1.ListOptions) (*admissionregistrationv1.ValidatingWebhookConfigurationList, error) {
+	webhookConfigs, err := c.client.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := new(admissionregistrationv1.ValidatingWebhookConfigurationList)
+	if err := convertViaJSON(webhookConfigs, out); err != nil {
+		return nil, err
+	}
+	out.SetGroupVersionKind(admissionregistrationv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfigurationList"))
+	return out, nil
+}
+
+func (c v1beta1WebhookClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	w, err := c.client.Watch(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+		if webhookConfig, ok := in.Object.(*admissionregistrationv1beta1.ValidatingWebhookConfiguration); ok {
+			converted, err := convertV1Beta1WebhookConfig(webhookConfig)
+			if err != nil {
+				return watch.Event{
+					Type:   watch.Error,
+					Object: &apierrors.NewInternalError(err).ErrStatus,
+				}, true
+			}
+			in.Object = converted
+		}
+		return in, true
+	}), nil
+}
+
+func (c v1beta1WebhookClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*admissionregistrationv1.ValidatingWebhookConfiguration, error) {
+	webhookConfig, err := c.client.Patch(ctx, name, pt, data, opts, subresources...)
+	if err != nil {
+		return nil, err
+	}
+	return convertV1Beta1WebhookConfig(webhookConfig)
+}
+
+func convertV1Beta1WebhookConfig(in *admissionregistrationv1beta1.ValidatingWebhookConfiguration) (*admissionregistrationv1.ValidatingWebhookConfiguration, error) {
+	out := new(admissionregistrationv1.ValidatingWebhookConfiguration)
+	if err := convertViaJSON(in, out); err != nil {
+		return nil, err
+	}
+	out.SetGroupVersionKind(admissionregistrationv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfiguration"))
+	return out, nil
+}
+
+// convertViaJSON converts between the v1beta1 and v1 types. The wire format
+// of the two versions is compatible for every field the manager relies on.
+func convertViaJSON(in, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal v1beta1 webhook configuration: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to convert v1beta1 webhook configuration to v1: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package webhookmanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestWebhookAPIVersion(t *testing.T) {
+	webhookResources := func(groupVersion string) *metav1.APIResourceList {
+		return &metav1.APIResourceList{
+			GroupVersion: groupVersion,
+			APIResources: []metav1.APIResource{{Name: "validatingwebhookconfigurations"}},
+		}
+	}
+
+	for _, tt := range []struct {
+		desc          string
+		resources     []*metav1.APIResourceList
+		expectVersion string
+		expectErr     string
+	}{
+		{
+			desc:          "v1 only",
+			resources:     []*metav1.APIResourceList{webhookResources("admissionregistration.k8s.io/v1")},
+			expectVersion: "admissionregistration.k8s.io/v1",
+		},
+		{
+			desc: "v1 and v1beta1",
+			resources: []*metav1.APIResourceList{
+				webhookResources("admissionregistration.k8s.io/v1beta1"),
+				webhookResources("admissionregistration.k8s.io/v1"),
+			},
+			expectVersion: "admissionregistration.k8s.io/v1",
+		},
+		{
+			desc:          "v1beta1 only",
+			resources:     []*metav1.APIResourceList{webhookResources("admissionregistration.k8s.io/v1beta1")},
+			expectVersion: "admissionregistration.k8s.io/v1beta1",
+		},
+		{
+			desc:      "neither",
+			expectErr: "API server does not serve validating webhook configurations in a supported version",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = tt.resources
+
+			version, err := WebhookAPIVersion(clientset.Discovery())
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectVersion, version)
+		})
+	}
+}
+
+func TestV1Beta1WebhookClient(t *testing.T) {
+	ctx := context.Background()
+
+	clientset := fake.NewSimpleClientset(&admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook"},
+		Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{
+			{
+				Name: "vwebhook.kb.io",
+				ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{
+					Service: &admissionregistrationv1beta1.ServiceReference{
+						Namespace: "spire-system",
+						Name:      "spire-controller-manager-webhook-service",
+					},
+				},
+			},
+		},
+	})
+	webhookClient := NewV1Beta1WebhookClient(clientset.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations())
+
+	w, err := webhookClient.Watch(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	defer w.Stop()
+
+	current, err := webhookClient.Get(ctx, "webhook", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"spire-controller-manager-webhook-service.spire-system.svc"}, webhookDNSNames(current))
+
+	list, err := webhookClient.List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, "webhook", list.Items[0].Name)
+
+	// Patches computed against the v1 type apply to the v1beta1 object.
+	modified := current.DeepCopy()
+	modified.Webhooks[0].ClientConfig.CABundle = []byte("CABUNDLE")
+	data, err := client.StrategicMergeFrom(current).Data(modified)
+	require.NoError(t, err)
+	patched, err := webhookClient.Patch(ctx, "webhook", types.StrategicMergePatchType, data, metav1.PatchOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []byte("CABUNDLE"), patched.Webhooks[0].ClientConfig.CABundle)
+
+	stored, err := clientset.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Get(ctx, "webhook", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []byte("CABUNDLE"), stored.Webhooks[0].ClientConfig.CABundle)
+
+	// Watch events are converted to v1.
+	event := <-w.ResultChan()
+	require.Equal(t, watch.Modified, event.Type)
+	require.IsType(t, &admissionregistrationv1.ValidatingWebhookConfiguration{}, event.Object)
+	require.Equal(t, []byte("CABUNDLE"), event.Object.(*admissionregistrationv1.ValidatingWebhookConfiguration).Webhooks[0].ClientConfig.CABundle)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 SPIRE Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire-controller-manager/pkg/crdwatcher"
+	"github.com/spiffe/spire-controller-manager/pkg/reconciler"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestInitialSyncCheck(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	entryReconciler := new(fakeReconciler)
+	spireServerEntryReconciler := new(fakeReconciler)
+	remoteEntryReconciler := new(fakeReconciler)
+	federationRelationshipReconciler := new(fakeReconciler)
+	spireServerFederationRelationshipReconciler := new(fakeReconciler)
+	elected := make(chan struct{})
+	check := initialSyncCheck(elected,
+		[]reconciler.Reconciler{entryReconciler, spireServerEntryReconciler, remoteEntryReconciler},
+		[]reconciler.Reconciler{federationRelationshipReconciler, spireServerFederationRelationshipReconciler},
+	)
+
+	require.NoError(t, check(req), "instances that are not the leader are ready")
+
+	close(elected)
+	require.EqualError(t, check(req), "initial entry reconciliation has not completed")
+
+	entryReconciler.synced = true
+	spireServerEntryReconciler.synced = true
+	require.EqualError(t, check(req), "initial entry reconciliation has not completed", "the remote cluster has not synced")
+
+	remoteEntryReconciler.synced = true
+	require.EqualError(t, check(req), "initial federation relationship reconciliation has not completed")
+
+	federationRelationshipReconciler.synced = true
+	require.EqualError(t, check(req), "initial federation relationship reconciliation has not completed", "the additional SPIRE Server has not synced")
+
+	spireServerFederationRelationshipReconciler.synced = true
+	require.NoError(t, check(req))
+}
+
+func TestCRDAvailabilityCheck(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	fooGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Foo"}
+	barGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Bar"}
+	discoveryClient := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	crdWatcher, err := crdwatcher.New(crdwatcher.Config{
+		Discovery: discoveryClient,
+		Registrations: []crdwatcher.Registration{
+			{GroupVersionKind: fooGVK, Setup: func() error { return nil }},
+			{GroupVersionKind: barGVK, Setup: func() error { return nil }},
+		},
+	})
+	require.NoError(t, err)
+	check := crdAvailabilityCheck(crdWatcher)
+
+	require.EqualError(t, check(req), "custom resource definitions are not installed: Foo, Bar")
+
+	discoveryClient.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "example.org/v1",
+		APIResources: []metav1.APIResource{{Kind: "Foo"}},
+	}}
+	_, err = crdWatcher.SetupAvailable(context.Background())
+	require.NoError(t, err)
+	require.EqualError(t, check(req), "custom resource definitions are not installed: Bar")
+
+	discoveryClient.Resources[0].APIResources = append(discoveryClient.Resources[0].APIResources, metav1.APIResource{Kind: "Bar"})
+	_, err = crdWatcher.SetupAvailable(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, check(req))
+}
+
+type fakeReconciler struct {
+	synced bool
+}
+
+func (r *fakeReconciler) Trigger() {}
+
+func (r *fakeReconciler) Run(context.Context) error {
+	return nil
+}
+
+func (r *fakeReconciler) Synced() bool {
+	return r.synced
+}
+
+func (r *fakeReconciler) SetGCInterval(time.Duration) {}